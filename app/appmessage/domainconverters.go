@@ -541,11 +541,14 @@ func DomainBlockWithTrustedDataToBlockWithTrustedData(block *externalapi.BlockWi
 
 // DomainBlockWithTrustedDataToBlockWithTrustedDataV4 converts a set of *externalapi.DomainBlock, daa window indices and ghostdag data indices
 // to *MsgBlockWithTrustedDataV4
-func DomainBlockWithTrustedDataToBlockWithTrustedDataV4(block *externalapi.DomainBlock, daaWindowIndices, ghostdagDataIndices []uint64) *MsgBlockWithTrustedDataV4 {
+func DomainBlockWithTrustedDataToBlockWithTrustedDataV4(block *externalapi.DomainBlock, daaWindowIndices, ghostdagDataIndices []uint64,
+	deploymentStates []externalapi.DeploymentState) *MsgBlockWithTrustedDataV4 {
+
 	return &MsgBlockWithTrustedDataV4{
 		Block:               DomainBlockToMsgBlock(block),
 		DAAWindowIndices:    daaWindowIndices,
 		GHOSTDAGDataIndices: ghostdagDataIndices,
+		DeploymentStates:    deploymentStates,
 	}
 }
 
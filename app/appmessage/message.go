@@ -163,6 +163,12 @@ const (
 	CmdGetMempoolEntriesByAddressesResponseMessage
 	CmdGetCoinSupplyRequestMessage
 	CmdGetCoinSupplyResponseMessage
+	CmdGetCheckpointsRequestMessage
+	CmdGetCheckpointsResponseMessage
+	CmdGetDeploymentInfoRequestMessage
+	CmdGetDeploymentInfoResponseMessage
+	CmdVerifyMessageRequestMessage
+	CmdVerifyMessageResponseMessage
 )
 
 // ProtocolMessageCommandToString maps all MessageCommands to their string representation
@@ -300,6 +306,12 @@ var RPCMessageCommandToString = map[MessageCommand]string{
 	CmdGetMempoolEntriesByAddressesResponseMessage:                "GetMempoolEntriesByAddressesResponse",
 	CmdGetCoinSupplyRequestMessage:                                "GetCoinSupplyRequest",
 	CmdGetCoinSupplyResponseMessage:                               "GetCoinSupplyResponse",
+	CmdGetCheckpointsRequestMessage:                               "GetCheckpointsRequest",
+	CmdGetCheckpointsResponseMessage:                              "GetCheckpointsResponse",
+	CmdGetDeploymentInfoRequestMessage:                            "GetDeploymentInfoRequest",
+	CmdGetDeploymentInfoResponseMessage:                           "GetDeploymentInfoResponse",
+	CmdVerifyMessageRequestMessage:                                "VerifyMessageRequest",
+	CmdVerifyMessageResponseMessage:                               "VerifyMessageResponse",
 }
 
 // Message is an interface that describes a c4ex message. A type that
@@ -1,5 +1,7 @@
 package appmessage
 
+import "github.com/c4ei/c4exd/domain/consensus/model/externalapi"
+
 // MsgBlockWithTrustedDataV4 represents a c4ex BlockWithTrustedDataV4 message
 type MsgBlockWithTrustedDataV4 struct {
 	baseMessage
@@ -7,6 +9,7 @@ type MsgBlockWithTrustedDataV4 struct {
 	Block               *MsgBlock
 	DAAWindowIndices    []uint64
 	GHOSTDAGDataIndices []uint64
+	DeploymentStates    []externalapi.DeploymentState
 }
 
 // Command returns the protocol command string for the message
@@ -20,15 +20,16 @@ func NewGetBlockDAGInfoRequestMessage() *GetBlockDAGInfoRequestMessage {
 // its respective RPC message
 type GetBlockDAGInfoResponseMessage struct {
 	baseMessage
-	NetworkName         string
-	BlockCount          uint64
-	HeaderCount         uint64
-	TipHashes           []string
-	VirtualParentHashes []string
-	Difficulty          float64
-	PastMedianTime      int64
-	PruningPointHash    string
-	VirtualDAAScore     uint64
+	NetworkName                string
+	BlockCount                 uint64
+	HeaderCount                uint64
+	TipHashes                  []string
+	VirtualParentHashes        []string
+	Difficulty                 float64
+	PastMedianTime             int64
+	PruningPointHash           string
+	VirtualDAAScore            uint64
+	EarliestAvailableBlockHash string
 
 	Error *RPCError
 }
@@ -6,6 +6,14 @@ type GetBlockTemplateRequestMessage struct {
 	baseMessage
 	PayAddress string
 	ExtraData  string
+	Payouts    []*RPCCoinbasePayout
+}
+
+// RPCCoinbasePayout is a payee of a share of the block rewards,
+// weighted in basis points of the rewards
+type RPCCoinbasePayout struct {
+	Address string
+	Weight  uint32
 }
 
 // Command returns the protocol command string for the message
@@ -14,10 +22,11 @@ func (msg *GetBlockTemplateRequestMessage) Command() MessageCommand {
 }
 
 // NewGetBlockTemplateRequestMessage returns a instance of the message
-func NewGetBlockTemplateRequestMessage(payAddress, extraData string) *GetBlockTemplateRequestMessage {
+func NewGetBlockTemplateRequestMessage(payAddress, extraData string, payouts []*RPCCoinbasePayout) *GetBlockTemplateRequestMessage {
 	return &GetBlockTemplateRequestMessage{
 		PayAddress: payAddress,
 		ExtraData:  extraData,
+		Payouts:    payouts,
 	}
 }
 
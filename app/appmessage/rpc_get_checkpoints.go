@@ -0,0 +1,44 @@
+package appmessage
+
+// GetCheckpointsRequestMessage is an appmessage corresponding to
+// its respective RPC message
+type GetCheckpointsRequestMessage struct {
+	baseMessage
+}
+
+// Command returns the protocol command string for the message
+func (msg *GetCheckpointsRequestMessage) Command() MessageCommand {
+	return CmdGetCheckpointsRequestMessage
+}
+
+// NewGetCheckpointsRequestMessage returns a instance of the message
+func NewGetCheckpointsRequestMessage() *GetCheckpointsRequestMessage {
+	return &GetCheckpointsRequestMessage{}
+}
+
+// RPCCheckpoint is a consensus checkpoint as represented in the RPC
+type RPCCheckpoint struct {
+	Hash     string
+	DAAScore uint64
+}
+
+// GetCheckpointsResponseMessage is an appmessage corresponding to
+// its respective RPC message
+type GetCheckpointsResponseMessage struct {
+	baseMessage
+	Checkpoints []*RPCCheckpoint
+
+	Error *RPCError
+}
+
+// Command returns the protocol command string for the message
+func (msg *GetCheckpointsResponseMessage) Command() MessageCommand {
+	return CmdGetCheckpointsResponseMessage
+}
+
+// NewGetCheckpointsResponseMessage returns a instance of the message
+func NewGetCheckpointsResponseMessage(checkpoints []*RPCCheckpoint) *GetCheckpointsResponseMessage {
+	return &GetCheckpointsResponseMessage{
+		Checkpoints: checkpoints,
+	}
+}
@@ -0,0 +1,53 @@
+package appmessage
+
+// GetDeploymentInfoRequestMessage is an appmessage corresponding to
+// its respective RPC message
+type GetDeploymentInfoRequestMessage struct {
+	baseMessage
+}
+
+// Command returns the protocol command string for the message
+func (msg *GetDeploymentInfoRequestMessage) Command() MessageCommand {
+	return CmdGetDeploymentInfoRequestMessage
+}
+
+// NewGetDeploymentInfoRequestMessage returns a instance of the message
+func NewGetDeploymentInfoRequestMessage() *GetDeploymentInfoRequestMessage {
+	return &GetDeploymentInfoRequestMessage{}
+}
+
+// RPCDeployment is a consensus rule change deployment as represented in the RPC
+type RPCDeployment struct {
+	Name            string
+	BitNumber       uint32
+	StartDAAScore   uint64
+	TimeoutDAAScore uint64
+	State           string
+}
+
+// GetDeploymentInfoResponseMessage is an appmessage corresponding to
+// its respective RPC message
+type GetDeploymentInfoResponseMessage struct {
+	baseMessage
+	Deployments                   []*RPCDeployment
+	MinerConfirmationWindow       uint64
+	RuleChangeActivationThreshold uint64
+
+	Error *RPCError
+}
+
+// Command returns the protocol command string for the message
+func (msg *GetDeploymentInfoResponseMessage) Command() MessageCommand {
+	return CmdGetDeploymentInfoResponseMessage
+}
+
+// NewGetDeploymentInfoResponseMessage returns a instance of the message
+func NewGetDeploymentInfoResponseMessage(deployments []*RPCDeployment, minerConfirmationWindow uint64,
+	ruleChangeActivationThreshold uint64) *GetDeploymentInfoResponseMessage {
+
+	return &GetDeploymentInfoResponseMessage{
+		Deployments:                   deployments,
+		MinerConfirmationWindow:       minerConfirmationWindow,
+		RuleChangeActivationThreshold: ruleChangeActivationThreshold,
+	}
+}
@@ -82,6 +82,7 @@ func NewComponentManager(cfg *config.Config, db infrastructuredatabase.Database,
 	consensusConfig := consensus.Config{
 		Params:                          *cfg.ActiveNetParams,
 		IsArchival:                      cfg.IsArchivalNode,
+		RetentionDepth:                  cfg.RetentionDepth(),
 		EnableSanityCheckPruningUTXOSet: cfg.EnableSanityCheckPruningUTXOSet,
 	}
 	mempoolConfig := mempool.DefaultConfig(&consensusConfig.Params)
@@ -129,7 +129,13 @@ func HandlePruningPointAndItsAnticoneRequests(context PruningPointAndItsAnticone
 					return protocolerrors.Errorf(false, "pruning point anticone block %s not found", blockHash)
 				}
 
-				err = outgoingRoute.Enqueue(appmessage.DomainBlockWithTrustedDataToBlockWithTrustedDataV4(block, trustedDataDAABlockIndexes[*blockHash], trustedDataGHOSTDAGDataIndexes[*blockHash]))
+				deploymentStates, err := context.Domain().Consensus().TrustedDeploymentStates(blockHash)
+				if err != nil {
+					return err
+				}
+
+				err = outgoingRoute.Enqueue(appmessage.DomainBlockWithTrustedDataToBlockWithTrustedDataV4(block, trustedDataDAABlockIndexes[*blockHash],
+					trustedDataGHOSTDAGDataIndexes[*blockHash], deploymentStates))
 				if err != nil {
 					return err
 				}
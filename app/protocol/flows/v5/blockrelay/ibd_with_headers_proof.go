@@ -278,9 +278,10 @@ func (flow *handleIBDFlow) processBlockWithTrustedData(
 	consensus externalapi.Consensus, block *appmessage.MsgBlockWithTrustedDataV4, data *appmessage.MsgTrustedData) error {
 
 	blockWithTrustedData := &externalapi.BlockWithTrustedData{
-		Block:        appmessage.MsgBlockToDomainBlock(block.Block),
-		DAAWindow:    make([]*externalapi.TrustedDataDataDAAHeader, 0, len(block.DAAWindowIndices)),
-		GHOSTDAGData: make([]*externalapi.BlockGHOSTDAGDataHashPair, 0, len(block.GHOSTDAGDataIndices)),
+		Block:            appmessage.MsgBlockToDomainBlock(block.Block),
+		DAAWindow:        make([]*externalapi.TrustedDataDataDAAHeader, 0, len(block.DAAWindowIndices)),
+		GHOSTDAGData:     make([]*externalapi.BlockGHOSTDAGDataHashPair, 0, len(block.GHOSTDAGDataIndices)),
+		DeploymentStates: block.DeploymentStates,
 	}
 
 	for _, index := range block.DAAWindowIndices {
@@ -50,6 +50,9 @@ var handlers = map[appmessage.MessageCommand]handler{
 	appmessage.CmdNotifyVirtualDaaScoreChangedRequestMessage:                rpchandlers.HandleNotifyVirtualDaaScoreChanged,
 	appmessage.CmdNotifyNewBlockTemplateRequestMessage:                      rpchandlers.HandleNotifyNewBlockTemplate,
 	appmessage.CmdGetCoinSupplyRequestMessage:                               rpchandlers.HandleGetCoinSupply,
+	appmessage.CmdGetCheckpointsRequestMessage:                              rpchandlers.HandleGetCheckpoints,
+	appmessage.CmdGetDeploymentInfoRequestMessage:                           rpchandlers.HandleGetDeploymentInfo,
+	appmessage.CmdVerifyMessageRequestMessage:                               rpchandlers.HandleVerifyMessage,
 	appmessage.CmdGetMempoolEntriesByAddressesRequestMessage:                rpchandlers.HandleGetMempoolEntriesByAddresses,
 }
 
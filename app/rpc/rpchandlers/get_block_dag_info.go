@@ -43,5 +43,11 @@ func HandleGetBlockDAGInfo(context *rpccontext.Context, _ *router.Router, _ appm
 	}
 	response.PruningPointHash = pruningPoint.String()
 
+	earliestAvailableBlock, err := context.Domain.Consensus().EarliestAvailableBlock()
+	if err != nil {
+		return nil, err
+	}
+	response.EarliestAvailableBlockHash = earliestAvailableBlock.String()
+
 	return response, nil
 }
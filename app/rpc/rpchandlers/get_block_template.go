@@ -4,11 +4,14 @@ import (
 	"github.com/c4ei/c4exd/app/appmessage"
 	"github.com/c4ei/c4exd/app/rpc/rpccontext"
 	"github.com/c4ei/c4exd/domain/consensus/model/externalapi"
+	"github.com/c4ei/c4exd/domain/consensus/processes/coinbasemanager"
+	"github.com/c4ei/c4exd/domain/consensus/ruleerrors"
 	"github.com/c4ei/c4exd/domain/consensus/utils/transactionhelper"
 	"github.com/c4ei/c4exd/domain/consensus/utils/txscript"
 	"github.com/c4ei/c4exd/infrastructure/network/netadapter/router"
 	"github.com/c4ei/c4exd/util"
 	"github.com/c4ei/c4exd/version"
+	"github.com/pkg/errors"
 )
 
 // HandleGetBlockTemplate handles the respectively named RPC command
@@ -27,10 +30,31 @@ func HandleGetBlockTemplate(context *rpccontext.Context, _ *router.Router, reque
 		return nil, err
 	}
 
-	coinbaseData := &externalapi.DomainCoinbaseData{ScriptPublicKey: scriptPublicKey, ExtraData: []byte(version.Version() + "/" + getBlockTemplateRequest.ExtraData)}
+	payouts, errorMessage, err := coinbasePayouts(context, getBlockTemplateRequest.Payouts)
+	if err != nil {
+		return nil, err
+	}
+	if errorMessage != nil {
+		return errorMessage, nil
+	}
+
+	coinbaseData := &externalapi.DomainCoinbaseData{
+		ScriptPublicKey: scriptPublicKey,
+		ExtraData:       []byte(version.Version() + "/" + getBlockTemplateRequest.ExtraData),
+		Payouts:         payouts,
+	}
+	errorMessage = validatePayoutsPayloadLength(context, coinbaseData, []byte(version.Version()+"/"))
+	if errorMessage != nil {
+		return errorMessage, nil
+	}
 
 	templateBlock, isNearlySynced, err := context.Domain.MiningManager().GetBlockTemplate(coinbaseData)
 	if err != nil {
+		if errors.Is(err, ruleerrors.ErrBadCoinbasePayouts) {
+			errorMessage := &appmessage.GetBlockTemplateResponseMessage{}
+			errorMessage.Error = appmessage.RPCErrorf("Invalid payouts: %s", err)
+			return errorMessage, nil
+		}
 		return nil, err
 	}
 
@@ -44,3 +68,79 @@ func HandleGetBlockTemplate(context *rpccontext.Context, _ *router.Router, reque
 
 	return appmessage.NewGetBlockTemplateResponseMessage(rpcBlock, context.ProtocolManager.Context().HasPeers() && isNearlySynced), nil
 }
+
+// coinbasePayouts converts the payouts of a GetBlockTemplate request to their domain form. If the payouts
+// are invalid, the error response to return is returned instead
+func coinbasePayouts(context *rpccontext.Context, rpcPayouts []*appmessage.RPCCoinbasePayout) (
+	[]*externalapi.DomainCoinbasePayout, *appmessage.GetBlockTemplateResponseMessage, error) {
+
+	if len(rpcPayouts) == 0 {
+		return nil, nil, nil
+	}
+
+	payouts := make([]*externalapi.DomainCoinbasePayout, len(rpcPayouts))
+	totalWeight := uint64(0)
+	for i, rpcPayout := range rpcPayouts {
+		if rpcPayout.Weight == 0 || rpcPayout.Weight >= externalapi.CoinbasePayoutWeightDenominator {
+			errorMessage := &appmessage.GetBlockTemplateResponseMessage{}
+			errorMessage.Error = appmessage.RPCErrorf("The weight of payout #%d must be between 1 and %d, got %d",
+				i, externalapi.CoinbasePayoutWeightDenominator-1, rpcPayout.Weight)
+			return nil, errorMessage, nil
+		}
+		totalWeight += uint64(rpcPayout.Weight)
+
+		address, err := util.DecodeAddress(rpcPayout.Address, context.Config.ActiveNetParams.Prefix)
+		if err != nil {
+			errorMessage := &appmessage.GetBlockTemplateResponseMessage{}
+			errorMessage.Error = appmessage.RPCErrorf("Could not decode the address of payout #%d: %s", i, err)
+			return nil, errorMessage, nil
+		}
+		scriptPublicKey, err := txscript.PayToAddrScript(address)
+		if err != nil {
+			return nil, nil, err
+		}
+		payouts[i] = &externalapi.DomainCoinbasePayout{ScriptPublicKey: scriptPublicKey, Weight: uint16(rpcPayout.Weight)}
+	}
+
+	if totalWeight >= externalapi.CoinbasePayoutWeightDenominator {
+		errorMessage := &appmessage.GetBlockTemplateResponseMessage{}
+		errorMessage.Error = appmessage.RPCErrorf("The weights of the payouts sum to %d, which leaves nothing "+
+			"to the pay address. The sum must be below %d", totalWeight, externalapi.CoinbasePayoutWeightDenominator)
+		return nil, errorMessage, nil
+	}
+	return payouts, nil, nil
+}
+
+// validatePayoutsPayloadLength makes sure the payouts of the given coinbase data fit in the coinbase
+// payload along with the given extra data prefix, which every block template has. If they don't, the
+// error response to return is returned.
+func validatePayoutsPayloadLength(context *rpccontext.Context, coinbaseData *externalapi.DomainCoinbaseData,
+	extraDataPrefix []byte) *appmessage.GetBlockTemplateResponseMessage {
+
+	if len(coinbaseData.Payouts) == 0 {
+		return nil
+	}
+
+	maxLength := context.Config.NetParams().MaxCoinbasePayloadLength
+	for i := range coinbaseData.Payouts {
+		withPayouts := &externalapi.DomainCoinbaseData{
+			ScriptPublicKey: coinbaseData.ScriptPublicKey,
+			ExtraData:       extraDataPrefix,
+			Payouts:         coinbaseData.Payouts[:i+1],
+		}
+		if uint64(coinbasemanager.CoinbasePayloadLength(withPayouts)) > maxLength {
+			errorMessage := &appmessage.GetBlockTemplateResponseMessage{}
+			errorMessage.Error = appmessage.RPCErrorf("Too many payouts: only %d of the %d payouts fit in the "+
+				"coinbase payload, whose max length is %d", i, len(coinbaseData.Payouts), maxLength)
+			return errorMessage
+		}
+	}
+
+	if uint64(coinbasemanager.CoinbasePayloadLength(coinbaseData)) > maxLength {
+		errorMessage := &appmessage.GetBlockTemplateResponseMessage{}
+		errorMessage.Error = appmessage.RPCErrorf("Coinbase payload with %d payouts is above max length (%d). "+
+			"Try to shorten the extra data or to use fewer payouts.", len(coinbaseData.Payouts), maxLength)
+		return errorMessage
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package rpchandlers_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/c4ei/c4exd/app/appmessage"
+	"github.com/c4ei/c4exd/app/rpc/rpccontext"
+	"github.com/c4ei/c4exd/app/rpc/rpchandlers"
+	"github.com/c4ei/c4exd/domain/dagconfig"
+	"github.com/c4ei/c4exd/infrastructure/config"
+	"github.com/c4ei/c4exd/util"
+)
+
+func TestHandleGetBlockTemplateTooManyPayouts(t *testing.T) {
+	params := dagconfig.SimnetParams
+	fakeContext := rpccontext.Context{
+		Config: &config.Config{Flags: &config.Flags{NetworkFlags: config.NetworkFlags{ActiveNetParams: &params}}},
+	}
+
+	address := func(id byte) string {
+		publicKey := make([]byte, 32)
+		publicKey[0] = id
+		address, err := util.NewAddressPublicKey(publicKey, params.Prefix)
+		if err != nil {
+			t.Fatalf("NewAddressPublicKey: %+v", err)
+		}
+		return address.String()
+	}
+
+	// Each payout to a public key address takes 39 bytes of the coinbase payload
+	payouts := make([]*appmessage.RPCCoinbasePayout, 5)
+	for i := range payouts {
+		payouts[i] = &appmessage.RPCCoinbasePayout{Address: address(byte(i + 1)), Weight: 100}
+	}
+	request := appmessage.NewGetBlockTemplateRequestMessage(address(0), "", payouts)
+	response, err := rpchandlers.HandleGetBlockTemplate(&fakeContext, nil, request)
+	if err != nil {
+		t.Fatalf("HandleGetBlockTemplate: %+v", err)
+	}
+	rpcError := response.(*appmessage.GetBlockTemplateResponseMessage).Error
+	if rpcError == nil || !strings.Contains(rpcError.Message, "Too many payouts") {
+		t.Fatalf("expected a too many payouts error, got %v", rpcError)
+	}
+}
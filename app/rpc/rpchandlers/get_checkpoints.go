@@ -0,0 +1,21 @@
+package rpchandlers
+
+import (
+	"github.com/c4ei/c4exd/app/appmessage"
+	"github.com/c4ei/c4exd/app/rpc/rpccontext"
+	"github.com/c4ei/c4exd/infrastructure/network/netadapter/router"
+)
+
+// HandleGetCheckpoints handles the respectively named RPC command
+func HandleGetCheckpoints(context *rpccontext.Context, _ *router.Router, _ appmessage.Message) (appmessage.Message, error) {
+	checkpoints := context.Config.ActiveNetParams.Checkpoints
+	rpcCheckpoints := make([]*appmessage.RPCCheckpoint, len(checkpoints))
+	for i, checkpoint := range checkpoints {
+		rpcCheckpoints[i] = &appmessage.RPCCheckpoint{
+			Hash:     checkpoint.Hash.String(),
+			DAAScore: checkpoint.DAAScore,
+		}
+	}
+
+	return appmessage.NewGetCheckpointsResponseMessage(rpcCheckpoints), nil
+}
@@ -0,0 +1,30 @@
+package rpchandlers
+
+import (
+	"github.com/c4ei/c4exd/app/appmessage"
+	"github.com/c4ei/c4exd/app/rpc/rpccontext"
+	"github.com/c4ei/c4exd/infrastructure/network/netadapter/router"
+)
+
+// HandleGetDeploymentInfo handles the respectively named RPC command
+func HandleGetDeploymentInfo(context *rpccontext.Context, _ *router.Router, _ appmessage.Message) (appmessage.Message, error) {
+	params := context.Config.ActiveNetParams
+	states, err := context.Domain.Consensus().GetVirtualDeploymentStates()
+	if err != nil {
+		return nil, err
+	}
+
+	deployments := make([]*appmessage.RPCDeployment, len(params.Deployments))
+	for i, deployment := range params.Deployments {
+		deployments[i] = &appmessage.RPCDeployment{
+			Name:            deployment.Name,
+			BitNumber:       uint32(deployment.BitNumber),
+			StartDAAScore:   deployment.StartDAAScore,
+			TimeoutDAAScore: deployment.TimeoutDAAScore,
+			State:           states[i].String(),
+		}
+	}
+
+	return appmessage.NewGetDeploymentInfoResponseMessage(deployments, params.MinerConfirmationWindow,
+		params.RuleChangeActivationThreshold), nil
+}
@@ -0,0 +1,38 @@
+package rpchandlers
+
+import (
+	"encoding/hex"
+
+	"github.com/c4ei/c4exd/app/appmessage"
+	"github.com/c4ei/c4exd/app/rpc/rpccontext"
+	"github.com/c4ei/c4exd/infrastructure/network/netadapter/router"
+	"github.com/c4ei/c4exd/util"
+)
+
+// HandleVerifyMessage handles the respectively named RPC command
+func HandleVerifyMessage(context *rpccontext.Context, _ *router.Router, request appmessage.Message) (appmessage.Message, error) {
+	verifyMessageRequest := request.(*appmessage.VerifyMessageRequestMessage)
+
+	address, err := util.DecodeAddress(verifyMessageRequest.Address, context.Config.ActiveNetParams.Prefix)
+	if err != nil {
+		errorMessage := &appmessage.VerifyMessageResponseMessage{}
+		errorMessage.Error = appmessage.RPCErrorf("Couldn't decode address '%s': %s", verifyMessageRequest.Address, err)
+		return errorMessage, nil
+	}
+
+	signature, err := hex.DecodeString(verifyMessageRequest.Signature)
+	if err != nil {
+		errorMessage := &appmessage.VerifyMessageResponseMessage{}
+		errorMessage.Error = appmessage.RPCErrorf("Couldn't decode signature: %s", err)
+		return errorMessage, nil
+	}
+
+	isValid, err := util.VerifyMessage(address, verifyMessageRequest.Message, signature)
+	if err != nil {
+		errorMessage := &appmessage.VerifyMessageResponseMessage{}
+		errorMessage.Error = appmessage.RPCErrorf("Couldn't verify the signature: %s", err)
+		return errorMessage, nil
+	}
+
+	return appmessage.NewVerifyMessageResponseMessage(isValid), nil
+}
@@ -15,16 +15,17 @@ import (
 func parseCommand(args []string, commandDescs []*commandDescription) (*protowire.C4exdMessage, error) {
 	commandName, parameterStrings := args[0], args[1:]
 
-	var commandDesc *commandDescription
-	for _, cd := range commandDescs {
-		if cd.name == commandName {
-			commandDesc = cd
-			break
-		}
-	}
+	commandDesc := findCommandDescription(commandName, commandDescs)
 	if commandDesc == nil {
 		return nil, errors.Errorf("unknown command: %s. Use --list-commands to list all commands", commandName)
 	}
+	if len(parameterStrings) > 0 && commandDesc.namedParameter(parameterStrings[0]) != nil {
+		var err error
+		parameterStrings, err = orderNamedParameters(commandDesc, parameterStrings)
+		if err != nil {
+			return nil, err
+		}
+	}
 	if len(parameterStrings) != len(commandDesc.parameters) {
 		return nil, errors.Errorf("command '%s' expects %d parameters but got %d",
 			commandName, len(commandDesc.parameters), len(parameterStrings))
@@ -42,6 +43,44 @@ func parseCommand(args []string, commandDescs []*commandDescription) (*protowire
 	return generateC4exdMessage(commandValue, commandDesc)
 }
 
+// namedParameter returns the parameter that the given parameter string names, if it's of the form
+// <name>=<value> with a case-insensitive parameter name, or nil otherwise
+func (cd *commandDescription) namedParameter(parameterString string) *parameterDescription {
+	name, _, isNamed := strings.Cut(parameterString, "=")
+	if !isNamed {
+		return nil
+	}
+	for _, parameterDesc := range cd.parameters {
+		if strings.EqualFold(parameterDesc.name, name) {
+			return parameterDesc
+		}
+	}
+	return nil
+}
+
+// orderNamedParameters converts parameter strings of the form <name>=<value> to the positional
+// parameter strings of the command. Parameters that aren't named are not passed
+func orderNamedParameters(commandDesc *commandDescription, parameterStrings []string) ([]string, error) {
+	orderedParameterStrings := make([]string, len(commandDesc.parameters))
+	for i := range orderedParameterStrings {
+		orderedParameterStrings[i] = "-"
+	}
+	for _, parameterString := range parameterStrings {
+		parameterDesc := commandDesc.namedParameter(parameterString)
+		if parameterDesc == nil {
+			return nil, errors.Errorf("parameter '%s' is not of the form <name>=<value> with a parameter of "+
+				"command '%s'. Named and positional parameters can't be mixed", parameterString, commandDesc.name)
+		}
+		_, value, _ := strings.Cut(parameterString, "=")
+		for i, candidate := range commandDesc.parameters {
+			if candidate == parameterDesc {
+				orderedParameterStrings[i] = value
+			}
+		}
+	}
+	return orderedParameterStrings, nil
+}
+
 func setField(commandValue reflect.Value, parameterValue reflect.Value, parameterDesc *parameterDescription) {
 	parameterField := commandValue.Elem().FieldByName(parameterDesc.name)
 
@@ -38,9 +38,23 @@ var commandTypes = []reflect.Type{
 	reflect.TypeOf(protowire.C4exdMessage_GetUtxosByAddressesRequest{}),
 	reflect.TypeOf(protowire.C4exdMessage_GetBalanceByAddressRequest{}),
 	reflect.TypeOf(protowire.C4exdMessage_GetCoinSupplyRequest{}),
+	reflect.TypeOf(protowire.C4exdMessage_GetCheckpointsRequest{}),
+	reflect.TypeOf(protowire.C4exdMessage_GetDeploymentInfoRequest{}),
+	reflect.TypeOf(protowire.C4exdMessage_VerifyMessageRequest{}),
 
 	reflect.TypeOf(protowire.C4exdMessage_BanRequest{}),
 	reflect.TypeOf(protowire.C4exdMessage_UnbanRequest{}),
+
+	reflect.TypeOf(protowire.C4exdMessage_NotifyBlockAddedRequest{}),
+	reflect.TypeOf(protowire.C4exdMessage_NotifyNewBlockTemplateRequest{}),
+	reflect.TypeOf(protowire.C4exdMessage_NotifyFinalityConflictsRequest{}),
+	reflect.TypeOf(protowire.C4exdMessage_NotifyUtxosChangedRequest{}),
+	reflect.TypeOf(protowire.C4exdMessage_StopNotifyingUtxosChangedRequest{}),
+	reflect.TypeOf(protowire.C4exdMessage_NotifyVirtualSelectedParentChainChangedRequest{}),
+	reflect.TypeOf(protowire.C4exdMessage_NotifyVirtualSelectedParentBlueScoreChangedRequest{}),
+	reflect.TypeOf(protowire.C4exdMessage_NotifyVirtualDaaScoreChangedRequest{}),
+	reflect.TypeOf(protowire.C4exdMessage_NotifyPruningPointUTXOSetOverrideRequest{}),
+	reflect.TypeOf(protowire.C4exdMessage_StopNotifyingPruningPointUTXOSetOverrideRequest{}),
 }
 
 type commandDescription struct {
@@ -94,3 +108,13 @@ func (cd *commandDescription) help() string {
 	}
 	return sb.String()
 }
+
+// findCommandDescription returns the description of the command with the given name, or nil if there's none
+func findCommandDescription(commandName string, commandDescs []*commandDescription) *commandDescription {
+	for _, cd := range commandDescs {
+		if cd.name == commandName {
+			return cd
+		}
+	}
+	return nil
+}
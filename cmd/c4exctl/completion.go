@@ -0,0 +1,101 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+var shellCommands = []string{"exit", "help", "history", "quit"}
+
+// complete completes the word that ends at pos in the line: the first word to a command
+// name, and the words after it to parameter names of the command. If the word has several
+// completions, it is extended to their common prefix, and when that adds nothing they are
+// listed instead
+func (r *repl) complete(line string, pos int) (newLine string, newPos int, ok bool) {
+	wordStart := strings.LastIndexAny(line[:pos], " \t") + 1
+	word := line[wordStart:pos]
+	precedingWords := strings.Fields(line[:wordStart])
+
+	candidates, suffix := r.completionCandidates(precedingWords, word)
+	if len(candidates) == 0 {
+		return "", 0, false
+	}
+
+	completion := candidates[0]
+	if len(candidates) > 1 {
+		completion = commonPrefix(candidates)
+		if len(completion) <= len(word) {
+			r.printf("%s\n", strings.Join(candidates, "  "))
+			return "", 0, false
+		}
+	} else {
+		completion += suffix
+	}
+
+	newLine = line[:wordStart] + completion + line[pos:]
+	return newLine, wordStart + len(completion), true
+}
+
+// completionCandidates returns the completions of word after the preceding words in the line,
+// and the suffix that follows a completion once it's the only one
+func (r *repl) completionCandidates(precedingWords []string, word string) (candidates []string, suffix string) {
+	if len(precedingWords) == 0 {
+		names := append([]string{}, shellCommands...)
+		for _, commandDesc := range r.commandDescs {
+			names = append(names, commandDesc.name)
+		}
+		return matchingCandidates(names, word), " "
+	}
+
+	if precedingWords[0] == "help" {
+		if len(precedingWords) > 1 {
+			return nil, ""
+		}
+		names := make([]string, len(r.commandDescs))
+		for i, commandDesc := range r.commandDescs {
+			names[i] = commandDesc.name
+		}
+		return matchingCandidates(names, word), " "
+	}
+
+	commandDesc := findCommandDescription(precedingWords[0], r.commandDescs)
+	if commandDesc == nil || strings.Contains(word, "=") {
+		return nil, ""
+	}
+	var names []string
+	for _, parameterDesc := range commandDesc.parameters {
+		isNamed := false
+		for _, precedingWord := range precedingWords[1:] {
+			if commandDesc.namedParameter(precedingWord) == parameterDesc {
+				isNamed = true
+				break
+			}
+		}
+		if !isNamed {
+			names = append(names, parameterDesc.name)
+		}
+	}
+	return matchingCandidates(names, word), "="
+}
+
+// matchingCandidates returns the sorted names that start with the given prefix, ignoring case
+func matchingCandidates(names []string, prefix string) []string {
+	var candidates []string
+	for _, name := range names {
+		if len(name) >= len(prefix) && strings.EqualFold(name[:len(prefix)], prefix) {
+			candidates = append(candidates, name)
+		}
+	}
+	sort.Strings(candidates)
+	return candidates
+}
+
+func commonPrefix(words []string) string {
+	prefix := words[0]
+	for _, word := range words[1:] {
+		for !strings.HasPrefix(strings.ToLower(word), strings.ToLower(prefix)) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"strings"
+
 	"github.com/c4ei/c4exd/infrastructure/config"
 	"github.com/jessevdk/go-flags"
 	"github.com/pkg/errors"
@@ -17,17 +19,25 @@ type configFlags struct {
 	RequestJSON                        string `short:"j" long:"json" description:"The request in JSON format"`
 	ListCommands                       bool   `short:"l" long:"list-commands" description:"List all commands and exit"`
 	AllowConnectionToDifferentVersions bool   `short:"a" long:"allow-connection-to-different-versions" description:"Allow connections to versions different than c4exctl's version'"`
+	Interactive                        bool   `short:"i" long:"interactive" description:"Start an interactive shell that runs commands over a single connection"`
+	Format                             string `long:"format" description:"The output format: json, jsonl (a compact line per message) or table"`
+	Fields                             string `long:"fields" description:"Comma separated fields to print, e.g. verboseData.hash,header.daaScore"`
+	Watch                              uint64 `long:"watch" description:"Re-run the command every given number of seconds and print the changes"`
 	CommandAndParameters               []string
 	config.NetworkFlags
+
+	fields []*field
 }
 
 func parseConfig() (*configFlags, error) {
 	cfg := &configFlags{
 		RPCServer: defaultRPCServer,
 		Timeout:   defaultTimeout,
+		Format:    formatJSON,
 	}
 	parser := flags.NewParser(cfg, flags.HelpFlag)
 	parser.Usage = "c4exctl [OPTIONS] [COMMAND] [COMMAND PARAMETERS].\n\nCommand can be supplied only if --json is not used." +
+		"\n\nUse `c4exctl --interactive` to start a shell that runs commands over a single connection." +
 		"\n\nUse `c4exctl --list-commands` to get a list of all commands and their parameters." +
 		"\nFor optional parameters- use '-' without quotes to not pass the parameter.\n"
 	remainingArgs, err := parser.Parse()
@@ -44,7 +54,28 @@ func parseConfig() (*configFlags, error) {
 		return nil, err
 	}
 
+	isKnownFormat := false
+	for _, format := range outputFormats {
+		isKnownFormat = isKnownFormat || cfg.Format == format
+	}
+	if !isKnownFormat {
+		return nil, errors.Errorf("--format must be one of %s", strings.Join(outputFormats, ", "))
+	}
+	cfg.fields, err = parseFields(cfg.Fields)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing --fields")
+	}
+
 	cfg.CommandAndParameters = remainingArgs
+	if cfg.Interactive {
+		if len(cfg.CommandAndParameters) > 0 || cfg.RequestJSON != "" {
+			return nil, errors.New("Neither --json nor a command can be specified with --interactive")
+		}
+		if cfg.Watch > 0 {
+			return nil, errors.New("--watch can't be used with --interactive")
+		}
+		return cfg, nil
+	}
 	if len(cfg.CommandAndParameters) == 0 && cfg.RequestJSON == "" ||
 		len(cfg.CommandAndParameters) > 0 && cfg.RequestJSON != "" {
 
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"unicode"
+
+	"github.com/c4ei/c4exd/util"
+	"github.com/pkg/errors"
+)
+
+// maxHistoryEntries is the number of previous commands the shell remembers, which is
+// also the number of lines the terminal keeps in its own history
+const maxHistoryEntries = 100
+
+const historyFilename = "history"
+
+var defaultHistoryFile = filepath.Join(util.AppDir("c4exctl", false), historyFilename)
+
+// history holds the previous commands of the shell, and saves them to a file so they
+// are kept across sessions
+type history struct {
+	entries []string
+	file    *os.File
+}
+
+func openHistory() (*history, error) {
+	err := os.MkdirAll(filepath.Dir(defaultHistoryFile), 0700)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error creating the directory of the history file")
+	}
+
+	h := &history{}
+	existingFile, err := os.Open(defaultHistoryFile)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.Wrapf(err, "error opening the history file")
+	}
+	if err == nil {
+		scanner := bufio.NewScanner(existingFile)
+		for scanner.Scan() {
+			h.addEntry(scanner.Text())
+		}
+		existingFile.Close()
+		if scanner.Err() != nil {
+			return nil, errors.Wrapf(scanner.Err(), "error reading the history file")
+		}
+	}
+
+	// The file is rewritten with the remembered entries only, so that it doesn't grow without bounds
+	h.file, err = os.OpenFile(defaultHistoryFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error opening the history file")
+	}
+	for _, entry := range h.entries {
+		_, err := h.file.WriteString(entry + "\n")
+		if err != nil {
+			h.close()
+			return nil, errors.Wrapf(err, "error writing the history file")
+		}
+	}
+	return h, nil
+}
+
+// add adds a command to the history. Failing to save it is not an error, since
+// the history is a convenience
+func (h *history) add(entry string) {
+	if !h.addEntry(entry) {
+		return
+	}
+	_, _ = h.file.WriteString(entry + "\n")
+}
+
+func (h *history) addEntry(entry string) bool {
+	if entry == "" || (len(h.entries) > 0 && h.entries[len(h.entries)-1] == entry) {
+		return false
+	}
+	for _, char := range entry {
+		if !unicode.IsPrint(char) {
+			return false
+		}
+	}
+	h.entries = append(h.entries, entry)
+	if len(h.entries) > maxHistoryEntries {
+		h.entries = h.entries[len(h.entries)-maxHistoryEntries:]
+	}
+	return true
+}
+
+func (h *history) close() {
+	_ = h.file.Close()
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"os/signal"
 	"time"
 
 	"github.com/c4ei/c4exd/version"
@@ -25,92 +26,125 @@ func main() {
 		return
 	}
 
-	rpcAddress, err := cfg.NetParams().NormalizeRPCServerAddress(cfg.RPCServer)
-	if err != nil {
-		printErrorAndExit(fmt.Sprintf("error parsing RPC server address: %s", err))
-	}
-	client, err := grpcclient.Connect(rpcAddress)
-	if err != nil {
-		printErrorAndExit(fmt.Sprintf("error connecting to the RPC server: %s", err))
-	}
+	client := connect(cfg)
 	defer client.Disconnect()
 
-	if !cfg.AllowConnectionToDifferentVersions {
-		c4exdMessage, err := client.Post(&protowire.C4exdMessage{Payload: &protowire.C4exdMessage_GetInfoRequest{GetInfoRequest: &protowire.GetInfoRequestMessage{}}})
+	if cfg.Interactive {
+		err := runREPL(cfg, client)
 		if err != nil {
-			printErrorAndExit(fmt.Sprintf("Cannot post GetInfo message: %s", err))
+			printErrorAndExit(fmt.Sprintf("error in the interactive shell: %s", err))
 		}
+		return
+	}
 
-		localVersion := version.Version()
-		remoteVersion := c4exdMessage.GetGetInfoResponse().ServerVersion
+	request, err := parseRequest(cfg)
+	if err != nil {
+		printErrorAndExit(err.Error())
+	}
 
-		if localVersion != remoteVersion {
-			printErrorAndExit(fmt.Sprintf("Server version mismatch, expect: %s, got: %s", localVersion, remoteVersion))
+	s := newSession(client, time.Duration(cfg.Timeout)*time.Second)
+	p := newPrinter(cfg.Format, cfg.fields, os.Stdout)
+	stop, stopInterrupts := interruptChannel()
+	defer stopInterrupts()
+
+	if cfg.Watch > 0 {
+		if isSubscriptionRequest(request) {
+			printErrorAndExit("--watch can't be used with commands that subscribe to notifications")
+		}
+		err := watch(s, request, time.Duration(cfg.Watch)*time.Second, p, stop)
+		if err != nil {
+			printErrorAndExit(fmt.Sprintf("error watching the command: %s", err))
 		}
+		return
 	}
 
-	responseChan := make(chan string)
-
-	if cfg.RequestJSON != "" {
-		go postJSON(cfg, client, responseChan)
-	} else {
-		go postCommand(cfg, client, responseChan)
+	response, err := s.post(request)
+	if err != nil {
+		printErrorAndExit(fmt.Sprintf("error posting the request to the RPC server: %s", err))
+	}
+	err = p.printResponse(request, response)
+	if err != nil {
+		printErrorAndExit(err.Error())
 	}
 
-	timeout := time.Duration(cfg.Timeout) * time.Second
-	select {
-	case responseString := <-responseChan:
-		prettyResponseString := prettifyResponse(responseString)
-		fmt.Println(prettyResponseString)
-	case <-time.After(timeout):
-		printErrorAndExit(fmt.Sprintf("timeout of %s has been exceeded", timeout))
+	if isSubscriptionRequest(request) && !hasError(response) {
+		err := s.streamNotifications(stop, p.print)
+		if err != nil {
+			printErrorAndExit(fmt.Sprintf("error streaming the notifications: %s", err))
+		}
 	}
 }
 
-func printAllCommands() {
-	requestDescs := commandDescriptions()
-	for _, requestDesc := range requestDescs {
-		fmt.Printf("\t%s\n", requestDesc.help())
+func parseRequest(cfg *configFlags) (*protowire.C4exdMessage, error) {
+	if cfg.RequestJSON != "" {
+		request := &protowire.C4exdMessage{}
+		err := protojson.Unmarshal([]byte(cfg.RequestJSON), request)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error parsing the request")
+		}
+		return request, nil
 	}
+	request, err := parseCommand(cfg.CommandAndParameters, commandDescriptions())
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing command")
+	}
+	return request, nil
 }
 
-func postCommand(cfg *configFlags, client *grpcclient.GRPCClient, responseChan chan string) {
-	message, err := parseCommand(cfg.CommandAndParameters, commandDescriptions())
-	if err != nil {
-		printErrorAndExit(fmt.Sprintf("error parsing command: %s", err))
+// interruptChannel returns a channel that's closed once the process is interrupted,
+// and a function that stops listening to interrupts
+func interruptChannel() (stop <-chan struct{}, stopListening func()) {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+
+	stopChan := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-interrupt:
+			close(stopChan)
+		case <-done:
+		}
+	}()
+	return stopChan, func() {
+		signal.Stop(interrupt)
+		close(done)
 	}
+}
 
-	response, err := client.Post(message)
+// connect connects to the RPC server, and makes sure it runs the version of c4exctl
+// unless connections to different versions are allowed
+func connect(cfg *configFlags) *grpcclient.GRPCClient {
+	rpcAddress, err := cfg.NetParams().NormalizeRPCServerAddress(cfg.RPCServer)
 	if err != nil {
-		printErrorAndExit(fmt.Sprintf("error posting the request to the RPC server: %s", err))
+		printErrorAndExit(fmt.Sprintf("error parsing RPC server address: %s", err))
 	}
-	responseBytes, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(response)
+	client, err := grpcclient.Connect(rpcAddress)
 	if err != nil {
-		printErrorAndExit(errors.Wrapf(err, "error parsing the response from the RPC server").Error())
+		printErrorAndExit(fmt.Sprintf("error connecting to the RPC server: %s", err))
 	}
 
-	responseChan <- string(responseBytes)
-}
+	if !cfg.AllowConnectionToDifferentVersions {
+		c4exdMessage, err := client.Post(&protowire.C4exdMessage{Payload: &protowire.C4exdMessage_GetInfoRequest{GetInfoRequest: &protowire.GetInfoRequestMessage{}}})
+		if err != nil {
+			printErrorAndExit(fmt.Sprintf("Cannot post GetInfo message: %s", err))
+		}
 
-func postJSON(cfg *configFlags, client *grpcclient.GRPCClient, doneChan chan string) {
-	responseString, err := client.PostJSON(cfg.RequestJSON)
-	if err != nil {
-		printErrorAndExit(fmt.Sprintf("error posting the request to the RPC server: %s", err))
+		localVersion := version.Version()
+		remoteVersion := c4exdMessage.GetGetInfoResponse().ServerVersion
+
+		if localVersion != remoteVersion {
+			printErrorAndExit(fmt.Sprintf("Server version mismatch, expect: %s, got: %s", localVersion, remoteVersion))
+		}
 	}
-	doneChan <- responseString
+	return client
 }
 
-func prettifyResponse(response string) string {
-	c4exdMessage := &protowire.C4exdMessage{}
-	err := protojson.Unmarshal([]byte(response), c4exdMessage)
-	if err != nil {
-		printErrorAndExit(fmt.Sprintf("error parsing the response from the RPC server: %s", err))
+func printAllCommands() {
+	requestDescs := commandDescriptions()
+	for _, requestDesc := range requestDescs {
+		fmt.Printf("\t%s\n", requestDesc.help())
 	}
-
-	marshalOptions := &protojson.MarshalOptions{}
-	marshalOptions.Indent = "    "
-	marshalOptions.EmitUnpopulated = true
-	return marshalOptions.Format(c4exdMessage)
 }
 
 func printErrorAndExit(message string) {
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/c4ei/c4exd/infrastructure/network/netadapter/server/grpcserver/protowire"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	formatJSON  = "json"
+	formatJSONL = "jsonl"
+	formatTable = "table"
+)
+
+var outputFormats = []string{formatJSON, formatJSONL, formatTable}
+
+// field is a path of keys into a message, such as verboseData.hash. A path that meets
+// an array continues into each of its elements
+type field struct {
+	name string
+	keys []string
+}
+
+func parseFields(fieldsFlag string) ([]*field, error) {
+	if fieldsFlag == "" {
+		return nil, nil
+	}
+	var fields []*field
+	for _, name := range strings.Split(fieldsFlag, ",") {
+		name = strings.TrimSpace(name)
+		keys := strings.Split(name, ".")
+		for _, key := range keys {
+			if key == "" {
+				return nil, errors.Errorf("field '%s' has an empty key", name)
+			}
+		}
+		fields = append(fields, &field{name: name, keys: keys})
+	}
+	return fields, nil
+}
+
+// printer prints the messages from the RPC server in an output format, optionally
+// projected to some of their fields
+type printer struct {
+	format string
+	fields []*field
+	writer io.Writer
+
+	tableColumns []string
+	tableWidths  []int
+}
+
+func newPrinter(format string, fields []*field, writer io.Writer) *printer {
+	return &printer{
+		format: format,
+		fields: fields,
+		writer: writer,
+	}
+}
+
+// printResponse prints the response to the request. In table format a successful subscription isn't
+// printed, so that the table holds only the notifications that follow it
+func (p *printer) printResponse(request *protowire.C4exdMessage, response *protowire.C4exdMessage) error {
+	if p.format == formatTable && isSubscriptionRequest(request) && !hasError(response) {
+		return nil
+	}
+	return p.print(response)
+}
+
+func (p *printer) print(message *protowire.C4exdMessage) error {
+	if p.format == formatTable {
+		value, err := messageValue(message)
+		if err != nil {
+			return err
+		}
+		return p.printTableRows(tableRows(value))
+	}
+
+	var messageJSON []byte
+	if len(p.fields) == 0 {
+		var err error
+		messageJSON, err = protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(message)
+		if err != nil {
+			return errors.Wrapf(err, "error formatting the message from the RPC server")
+		}
+	} else {
+		value, err := messageValue(message)
+		if err != nil {
+			return err
+		}
+		messageJSON, err = p.projectToJSON(value)
+		if err != nil {
+			return err
+		}
+	}
+
+	buffer := &bytes.Buffer{}
+	var err error
+	if p.format == formatJSONL {
+		err = json.Compact(buffer, messageJSON)
+	} else {
+		err = json.Indent(buffer, messageJSON, "", "    ")
+	}
+	if err != nil {
+		return errors.Wrapf(err, "error formatting the message from the RPC server")
+	}
+	buffer.WriteByte('\n')
+	_, err = p.writer.Write(buffer.Bytes())
+	return err
+}
+
+// projectToJSON returns a JSON object of the printer's fields in the given value, in the order
+// they were given in
+func (p *printer) projectToJSON(value interface{}) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	buffer.WriteByte('{')
+	for i, field := range p.fields {
+		if i > 0 {
+			buffer.WriteByte(',')
+		}
+		nameJSON, err := json.Marshal(field.name)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		fieldValueJSON, err := json.Marshal(field.lookup(value))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		buffer.Write(nameJSON)
+		buffer.WriteByte(':')
+		buffer.Write(fieldValueJSON)
+	}
+	buffer.WriteByte('}')
+	return buffer.Bytes(), nil
+}
+
+// printTableRows prints the rows under a header that's printed with the first rows. The columns
+// are the printer's fields, or the leaves of the first row when it has none
+func (p *printer) printTableRows(rows []interface{}) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	cellRows := make([][]string, len(rows))
+	if p.tableColumns == nil {
+		if len(p.fields) > 0 {
+			for _, field := range p.fields {
+				p.tableColumns = append(p.tableColumns, field.name)
+			}
+		} else {
+			p.tableColumns = sortedKeys(flatten(rows[0]))
+		}
+	}
+	for i, row := range rows {
+		cells := make([]string, len(p.tableColumns))
+		if len(p.fields) > 0 {
+			for j, field := range p.fields {
+				cells[j] = cellString(field.lookup(row))
+			}
+		} else {
+			leaves := flatten(row)
+			for j, column := range p.tableColumns {
+				cells[j] = cellString(leaves[column])
+			}
+		}
+		cellRows[i] = cells
+	}
+
+	sb := &strings.Builder{}
+	if p.tableWidths == nil {
+		// The widths are set by the header and the first rows, so that the columns
+		// of rows that are printed later line up with them
+		p.tableWidths = make([]int, len(p.tableColumns))
+		for i, column := range p.tableColumns {
+			p.tableWidths[i] = len(column)
+		}
+		for _, cells := range cellRows {
+			for i, cell := range cells {
+				if len(cell) > p.tableWidths[i] {
+					p.tableWidths[i] = len(cell)
+				}
+			}
+		}
+		p.writeTableLine(sb, p.tableColumns)
+	}
+	for _, cells := range cellRows {
+		p.writeTableLine(sb, cells)
+	}
+	_, err := io.WriteString(p.writer, sb.String())
+	return err
+}
+
+func (p *printer) writeTableLine(sb *strings.Builder, cells []string) {
+	for i, cell := range cells {
+		if i < len(cells)-1 {
+			fmt.Fprintf(sb, "%-*s  ", p.tableWidths[i], cell)
+		} else {
+			sb.WriteString(cell)
+		}
+	}
+	sb.WriteByte('\n')
+}
+
+// messageValue returns the response or notification that the message wraps as a generic JSON value
+func messageValue(message *protowire.C4exdMessage) (interface{}, error) {
+	messageJSON, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(message)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error formatting the message from the RPC server")
+	}
+	var wrapper map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(messageJSON))
+	decoder.UseNumber()
+	err = decoder.Decode(&wrapper)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error formatting the message from the RPC server")
+	}
+	for _, value := range wrapper {
+		return value, nil
+	}
+	return nil, nil
+}
+
+// lookup returns the value at the field's path in the given value, or nil if there's none. Keys
+// are matched ignoring case. When the path isn't found in an object that holds a single value
+// besides its error, such as the block of a blockAddedNotification, it's looked up in that value
+func (f *field) lookup(value interface{}) interface{} {
+	result, found := lookupKeys(value, f.keys)
+	if found {
+		return result
+	}
+	if inner, ok := singleInnerValue(value); ok {
+		result, _ = lookupKeys(inner, f.keys)
+	}
+	return result
+}
+
+func lookupKeys(value interface{}, keys []string) (interface{}, bool) {
+	if len(keys) == 0 {
+		return value, true
+	}
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		for key, child := range typedValue {
+			if strings.EqualFold(key, keys[0]) {
+				return lookupKeys(child, keys[1:])
+			}
+		}
+		return nil, false
+	case []interface{}:
+		results := make([]interface{}, len(typedValue))
+		isFound := false
+		for i, element := range typedValue {
+			var found bool
+			results[i], found = lookupKeys(element, keys)
+			isFound = isFound || found
+		}
+		return results, isFound
+	default:
+		return nil, false
+	}
+}
+
+func singleInnerValue(value interface{}) (interface{}, bool) {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	var inner interface{}
+	count := 0
+	for key, child := range object {
+		if key == "error" && child == nil {
+			continue
+		}
+		inner = child
+		count++
+	}
+	return inner, count == 1
+}
+
+// tableRows returns the rows of a table of the given value: the elements of its array when it
+// holds a single array besides its error, such as the peers of a getConnectedPeerInfoResponse,
+// or the value itself otherwise
+func tableRows(value interface{}) []interface{} {
+	if inner, ok := singleInnerValue(value); ok {
+		if elements, ok := inner.([]interface{}); ok {
+			return elements
+		}
+	}
+	return []interface{}{value}
+}
+
+// flatten returns the leaves of the given value keyed by their dotted paths. Arrays are leaves
+func flatten(value interface{}) map[string]interface{} {
+	leaves := make(map[string]interface{})
+	flattenInto(leaves, "", value)
+	return leaves
+}
+
+func flattenInto(leaves map[string]interface{}, path string, value interface{}) {
+	object, ok := value.(map[string]interface{})
+	if !ok || (len(object) == 0 && path != "") {
+		leaves[path] = value
+		return
+	}
+	for key, child := range object {
+		childPath := key
+		if path != "" {
+			childPath = path + "." + key
+		}
+		flattenInto(leaves, childPath, child)
+	}
+}
+
+func sortedKeys(leaves map[string]interface{}) []string {
+	keys := make([]string, 0, len(leaves))
+	for key := range leaves {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// cellString returns the string of a value in a table cell or a diff: strings as they
+// are, and other values in compact JSON
+func cellString(value interface{}) string {
+	if value == nil {
+		return "-"
+	}
+	if stringValue, ok := value.(string); ok {
+		return stringValue
+	}
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(valueJSON)
+}
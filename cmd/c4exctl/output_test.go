@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/c4ei/c4exd/infrastructure/network/netadapter/server/grpcserver/protowire"
+)
+
+func blockAddedNotification(hash string, daaScore uint64) *protowire.C4exdMessage {
+	return &protowire.C4exdMessage{Payload: &protowire.C4exdMessage_BlockAddedNotification{
+		BlockAddedNotification: &protowire.BlockAddedNotificationMessage{
+			Block: &protowire.RpcBlock{
+				Header:      &protowire.RpcBlockHeader{DaaScore: daaScore},
+				VerboseData: &protowire.RpcBlockVerboseData{Hash: hash},
+			},
+		},
+	}}
+}
+
+func TestPrinterFields(t *testing.T) {
+	fields, err := parseFields("verboseData.hash, header.daaScore,missing")
+	if err != nil {
+		t.Fatalf("parseFields: %s", err)
+	}
+
+	buffer := &bytes.Buffer{}
+	p := newPrinter(formatJSONL, fields, buffer)
+	err = p.print(blockAddedNotification("aa", 5))
+	if err != nil {
+		t.Fatalf("print: %s", err)
+	}
+	expected := `{"verboseData.hash":"aa","header.daaScore":"5","missing":null}` + "\n"
+	if buffer.String() != expected {
+		t.Fatalf("Expected %s, got %s", expected, buffer.String())
+	}
+
+	_, err = parseFields("header..daaScore")
+	if err == nil {
+		t.Fatalf("Expected an error for a field with an empty key")
+	}
+}
+
+func TestPrinterArrayFields(t *testing.T) {
+	fields, err := parseFields("infos.address")
+	if err != nil {
+		t.Fatalf("parseFields: %s", err)
+	}
+	message := &protowire.C4exdMessage{Payload: &protowire.C4exdMessage_GetConnectedPeerInfoResponse{
+		GetConnectedPeerInfoResponse: &protowire.GetConnectedPeerInfoResponseMessage{
+			Infos: []*protowire.GetConnectedPeerInfoMessage{{Address: "a:1"}, {Address: "b:2"}},
+		},
+	}}
+
+	buffer := &bytes.Buffer{}
+	err = newPrinter(formatJSONL, fields, buffer).print(message)
+	if err != nil {
+		t.Fatalf("print: %s", err)
+	}
+	expected := `{"infos.address":["a:1","b:2"]}` + "\n"
+	if buffer.String() != expected {
+		t.Fatalf("Expected %s, got %s", expected, buffer.String())
+	}
+
+	// In table format, the peers are the rows
+	fields, err = parseFields("address")
+	if err != nil {
+		t.Fatalf("parseFields: %s", err)
+	}
+	buffer.Reset()
+	err = newPrinter(formatTable, fields, buffer).print(message)
+	if err != nil {
+		t.Fatalf("print: %s", err)
+	}
+	expected = "address\na:1\nb:2\n"
+	if buffer.String() != expected {
+		t.Fatalf("Expected %q, got %q", expected, buffer.String())
+	}
+}
+
+func TestPrinterTable(t *testing.T) {
+	fields, err := parseFields("verboseData.hash,header.daaScore")
+	if err != nil {
+		t.Fatalf("parseFields: %s", err)
+	}
+
+	buffer := &bytes.Buffer{}
+	p := newPrinter(formatTable, fields, buffer)
+	for i, hash := range []string{"aaaa", "bb", "cccccc"} {
+		err := p.print(blockAddedNotification(hash, uint64(i)))
+		if err != nil {
+			t.Fatalf("print: %s", err)
+		}
+	}
+	expected := strings.Join([]string{
+		"verboseData.hash  header.daaScore",
+		"aaaa              0",
+		"bb                1",
+		"cccccc            2",
+	}, "\n") + "\n"
+	if buffer.String() != expected {
+		t.Fatalf("Expected:\n%s\ngot:\n%s", expected, buffer.String())
+	}
+}
+
+func TestDiffLeaves(t *testing.T) {
+	previous := map[string]interface{}{"a": "1", "b": "2", "c": []interface{}{"x"}}
+	current := map[string]interface{}{"a": "1", "b": "3", "d": "4"}
+
+	changes := diffLeaves(previous, current)
+	if len(changes) != 3 {
+		t.Fatalf("Expected 3 changes, got %d", len(changes))
+	}
+	expected := []change{
+		{Field: "b", Old: "2", New: "3"},
+		{Field: "c", Old: []interface{}{"x"}},
+		{Field: "d", New: "4"},
+	}
+	for i, change := range changes {
+		if change.Field != expected[i].Field || cellString(change.Old) != cellString(expected[i].Old) ||
+			cellString(change.New) != cellString(expected[i].New) {
+			t.Fatalf("Expected change #%d to be %+v, got %+v", i, expected[i], change)
+		}
+	}
+
+	buffer := &bytes.Buffer{}
+	err := newPrinter(formatJSON, nil, buffer).printChanges(changes)
+	if err != nil {
+		t.Fatalf("printChanges: %s", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	expectedLines := []string{`~ b: 2 -> 3`, `- c: ["x"]`, `+ d: 4`}
+	if len(lines) != len(expectedLines)+1 {
+		t.Fatalf("Expected a time line followed by the changes, got:\n%s", buffer.String())
+	}
+	for i, expectedLine := range expectedLines {
+		if lines[i+1] != expectedLine {
+			t.Fatalf("Expected line %q, got %q", expectedLine, lines[i+1])
+		}
+	}
+}
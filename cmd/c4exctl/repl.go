@@ -0,0 +1,366 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/c4ei/c4exd/infrastructure/network/netadapter/server/grpcserver/protowire"
+	"github.com/c4ei/c4exd/infrastructure/network/rpcclient/grpcclient"
+	"github.com/pkg/errors"
+	"golang.org/x/term"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const replPrompt = "c4exctl> "
+
+// repl is an interactive shell that posts commands over a single connection to the RPC server
+type repl struct {
+	session      *session
+	commandDescs []*commandDescription
+	console      *console
+	history      *history
+	format       string
+	fields       []*field
+}
+
+func runREPL(cfg *configFlags, client *grpcclient.GRPCClient) error {
+	history, err := openHistory()
+	if err != nil {
+		return err
+	}
+	defer history.close()
+
+	console, err := newConsole(history.entries)
+	if err != nil {
+		return err
+	}
+	defer console.close()
+
+	r := &repl{
+		session:      newSession(client, time.Duration(cfg.Timeout)*time.Second),
+		commandDescs: commandDescriptions(),
+		console:      console,
+		history:      history,
+		format:       cfg.Format,
+		fields:       cfg.fields,
+	}
+	console.setAutoComplete(r.complete)
+
+	if console.isTerminal() {
+		r.printf("Connected to %s. Type 'help' to list the commands, and 'exit' or Ctrl+D to quit\n", cfg.RPCServer)
+	}
+	for {
+		line, err := console.readLine()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		history.add(line)
+
+		shouldExit, err := r.execute(line)
+		if err != nil {
+			r.printf("%s\n", err)
+		}
+		if shouldExit {
+			return nil
+		}
+	}
+}
+
+// execute executes a single line of input, and returns whether the shell should exit
+func (r *repl) execute(line string) (shouldExit bool, err error) {
+	var request *protowire.C4exdMessage
+	if strings.HasPrefix(line, "{") {
+		request = &protowire.C4exdMessage{}
+		err := protojson.Unmarshal([]byte(line), request)
+		if err != nil {
+			return false, errors.Wrapf(err, "error parsing the request")
+		}
+	} else {
+		args, err := splitCommandLine(line)
+		if err != nil {
+			return false, err
+		}
+		switch args[0] {
+		case "exit", "quit":
+			return true, nil
+		case "help":
+			r.printHelp(args[1:])
+			return false, nil
+		case "history":
+			for _, entry := range r.history.entries {
+				r.printf("%s\n", entry)
+			}
+			return false, nil
+		}
+		request, err = parseCommand(args, r.commandDescs)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	response, err := r.session.post(request)
+	if err != nil {
+		return false, err
+	}
+	p := newPrinter(r.format, r.fields, r.console)
+	err = p.printResponse(request, response)
+	if err != nil {
+		return false, err
+	}
+	if !isSubscriptionRequest(request) || hasError(response) {
+		return false, nil
+	}
+
+	return false, r.streamNotifications(p)
+}
+
+// streamNotifications prints the notifications of the session until the user interrupts it
+func (r *repl) streamNotifications(p *printer) error {
+	r.printf("Streaming notifications, press Ctrl+C to stop\n")
+
+	// While streaming, the terminal gets its regular mode back so that Ctrl+C interrupts
+	err := r.console.suspend()
+	if err != nil {
+		return err
+	}
+	defer r.console.resume()
+
+	stop, stopInterrupts := interruptChannel()
+	defer stopInterrupts()
+	return r.session.streamNotifications(stop, p.print)
+}
+
+func (r *repl) printHelp(args []string) {
+	if len(args) > 0 {
+		commandDesc := findCommandDescription(args[0], r.commandDescs)
+		if commandDesc == nil {
+			r.printf("Unknown command: %s\n", args[0])
+			return
+		}
+		r.printf("%s\n", commandDesc.help())
+		for _, parameter := range commandDesc.parameters {
+			r.printf("\t%s: %s\n", parameter.name, parameter.typeof)
+		}
+		return
+	}
+
+	r.printf("Commands:\n")
+	for _, commandDesc := range r.commandDescs {
+		r.printf("\t%s\n", commandDesc.help())
+	}
+	r.printf("\nParameters are given by position, or by name as <name>=<value>. Use '-' to not pass an " +
+		"optional positional parameter, and quotes around values with spaces.\n" +
+		"A request can also be given in JSON format, e.g. {\"getBlockDagInfoRequest\":{}}\n" +
+		"Notify* commands stream their notifications until interrupted with Ctrl+C.\n\n" +
+		"Shell commands:\n" +
+		"\thelp [command]\tList the commands, or the parameters of the given command\n" +
+		"\thistory\t\tList the previous commands\n" +
+		"\texit\t\tQuit the shell\n")
+}
+
+func (r *repl) printf(format string, args ...interface{}) {
+	_, _ = fmt.Fprintf(r.console, format, args...)
+}
+
+func hasError(response *protowire.C4exdMessage) bool {
+	// Every response message holds an RPCError in its Error field
+	errorGetter, ok := protoPayload(response).(interface{ GetError() *protowire.RPCError })
+	return ok && errorGetter.GetError() != nil
+}
+
+// splitCommandLine splits a line to its arguments by whitespace. Single and double quotes
+// group text with whitespace to a single argument, and backslashes escape the next character
+// outside single quotes
+func splitCommandLine(line string) ([]string, error) {
+	var args []string
+	current := &strings.Builder{}
+	hasCurrent := false
+	var quote rune
+	isEscaped := false
+	for _, char := range line {
+		switch {
+		case isEscaped:
+			current.WriteRune(char)
+			isEscaped = false
+		case char == '\\' && quote != '\'':
+			isEscaped = true
+			hasCurrent = true
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			} else {
+				current.WriteRune(char)
+			}
+		case char == '"' || char == '\'':
+			quote = char
+			hasCurrent = true
+		case char == ' ' || char == '\t':
+			if hasCurrent {
+				args = append(args, current.String())
+				current.Reset()
+				hasCurrent = false
+			}
+		default:
+			current.WriteRune(char)
+			hasCurrent = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.Errorf("unterminated %c quote", quote)
+	}
+	if isEscaped {
+		return nil, errors.New("the line ends with an escaping backslash")
+	}
+	if hasCurrent {
+		args = append(args, current.String())
+	}
+	if len(args) == 0 {
+		return nil, errors.New("no command was given")
+	}
+	return args, nil
+}
+
+// console reads the lines of the shell and writes its output. When the standard input is a
+// terminal, it's put in raw mode to edit the lines with completion and history
+type console struct {
+	terminal      *term.Terminal
+	terminalState *term.State
+	historyReader *strings.Reader
+	isMuted       bool
+	isSuspended   bool
+	lineScanner   *bufio.Scanner
+}
+
+func newConsole(historyEntries []string) (*console, error) {
+	c := &console{}
+	stdinFD := int(os.Stdin.Fd())
+	if !term.IsTerminal(stdinFD) {
+		c.lineScanner = bufio.NewScanner(os.Stdin)
+		return c, nil
+	}
+
+	terminalState, err := term.MakeRaw(stdinFD)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error setting up the terminal")
+	}
+	c.terminalState = terminalState
+	c.terminal = term.NewTerminal(&consoleReadWriter{console: c}, replPrompt)
+	width, height, err := term.GetSize(stdinFD)
+	if err == nil && width > 0 && height > 0 {
+		_ = c.terminal.SetSize(width, height)
+	}
+
+	// The terminal only adds lines it read to its history, so the saved history is fed
+	// to it as input before anything is shown
+	c.historyReader = strings.NewReader(strings.Join(historyEntries, "\r") + "\r")
+	c.isMuted = true
+	for range historyEntries {
+		_, err := c.terminal.ReadLine()
+		if err != nil {
+			c.close()
+			return nil, errors.Wrapf(err, "error loading the history")
+		}
+	}
+	c.historyReader = nil
+	c.isMuted = false
+
+	return c, nil
+}
+
+func (c *console) isTerminal() bool {
+	return c.terminal != nil
+}
+
+func (c *console) setAutoComplete(complete func(line string, pos int) (newLine string, newPos int, ok bool)) {
+	if c.terminal == nil {
+		return
+	}
+	c.terminal.AutoCompleteCallback = func(line string, pos int, key rune) (string, int, bool) {
+		if key != '\t' {
+			return "", 0, false
+		}
+		return complete(line, pos)
+	}
+}
+
+func (c *console) readLine() (string, error) {
+	if c.terminal != nil {
+		return c.terminal.ReadLine()
+	}
+	if !c.lineScanner.Scan() {
+		if c.lineScanner.Err() != nil {
+			return "", c.lineScanner.Err()
+		}
+		return "", io.EOF
+	}
+	return c.lineScanner.Text(), nil
+}
+
+func (c *console) Write(p []byte) (int, error) {
+	if c.terminal != nil && !c.isSuspended {
+		return c.terminal.Write(p)
+	}
+	return os.Stdout.Write(p)
+}
+
+// suspend gives the terminal its regular mode back until resume is called
+func (c *console) suspend() error {
+	if c.terminal == nil {
+		return nil
+	}
+	err := term.Restore(int(os.Stdin.Fd()), c.terminalState)
+	if err != nil {
+		return errors.Wrapf(err, "error restoring the terminal")
+	}
+	c.isSuspended = true
+	return nil
+}
+
+func (c *console) resume() {
+	if c.terminal == nil {
+		return
+	}
+	terminalState, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err == nil {
+		c.terminalState = terminalState
+	}
+	c.isSuspended = false
+}
+
+func (c *console) close() {
+	if c.terminal == nil {
+		return
+	}
+	_ = term.Restore(int(os.Stdin.Fd()), c.terminalState)
+	fmt.Println()
+}
+
+// consoleReadWriter connects the terminal of a console to the standard input and output
+type consoleReadWriter struct {
+	console *console
+}
+
+func (rw *consoleReadWriter) Read(p []byte) (int, error) {
+	if rw.console.historyReader != nil && rw.console.historyReader.Len() > 0 {
+		return rw.console.historyReader.Read(p)
+	}
+	return os.Stdin.Read(p)
+}
+
+func (rw *consoleReadWriter) Write(p []byte) (int, error) {
+	if rw.console.isMuted {
+		return len(p), nil
+	}
+	return os.Stdout.Write(p)
+}
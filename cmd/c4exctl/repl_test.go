@@ -0,0 +1,106 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := []struct {
+		line         string
+		expectedArgs []string
+		expectsError bool
+	}{
+		{line: "GetInfo", expectedArgs: []string{"GetInfo"}},
+		{line: "  GetBlock  abc\ttrue ", expectedArgs: []string{"GetBlock", "abc", "true"}},
+		{line: `SubmitBlock '{"block": {}}' false`, expectedArgs: []string{"SubmitBlock", `{"block": {}}`, "false"}},
+		{line: `Ban "a b" c\ d ''`, expectedArgs: []string{"Ban", "a b", "c d", ""}},
+		{line: `Ban 'a\b'`, expectedArgs: []string{"Ban", `a\b`}},
+		{line: `Ban "unterminated`, expectsError: true},
+		{line: `Ban escaped\`, expectsError: true},
+		{line: "   ", expectsError: true},
+	}
+
+	for _, test := range tests {
+		args, err := splitCommandLine(test.line)
+		if test.expectsError {
+			if err == nil {
+				t.Errorf("%q: expected an error, got %q", test.line, args)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", test.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(args, test.expectedArgs) {
+			t.Errorf("%q: expected %q, got %q", test.line, test.expectedArgs, args)
+		}
+	}
+}
+
+func TestParseNamedParameters(t *testing.T) {
+	commandDescs := commandDescriptions()
+
+	named, err := parseCommand([]string{"GetBlock", "includeTransactions=true", "Hash=abc"}, commandDescs)
+	if err != nil {
+		t.Fatalf("parseCommand: %s", err)
+	}
+	positional, err := parseCommand([]string{"GetBlock", "abc", "true"}, commandDescs)
+	if err != nil {
+		t.Fatalf("parseCommand: %s", err)
+	}
+	if named.GetGetBlockRequest().Hash != positional.GetGetBlockRequest().Hash ||
+		named.GetGetBlockRequest().IncludeTransactions != positional.GetGetBlockRequest().IncludeTransactions {
+		t.Fatalf("Named parameters %s don't match positional parameters %s", named, positional)
+	}
+
+	onlyHash, err := parseCommand([]string{"GetBlock", "Hash=abc"}, commandDescs)
+	if err != nil {
+		t.Fatalf("parseCommand: %s", err)
+	}
+	if onlyHash.GetGetBlockRequest().IncludeTransactions {
+		t.Fatalf("A parameter that isn't named should not be passed")
+	}
+
+	_, err = parseCommand([]string{"GetBlock", "Hash=abc", "true"}, commandDescs)
+	if err == nil {
+		t.Fatalf("Expected an error when mixing named and positional parameters")
+	}
+}
+
+func TestComplete(t *testing.T) {
+	r := &repl{commandDescs: commandDescriptions(), console: &console{}}
+
+	tests := []struct {
+		line            string
+		expectedLine    string
+		expectsComplete bool
+	}{
+		{line: "getblockc", expectedLine: "GetBlockCount ", expectsComplete: true},
+		{line: "GetBlockD", expectedLine: "GetBlockDagInfo ", expectsComplete: true},
+		{line: "GetBlockTe", expectedLine: "GetBlockTemplate ", expectsComplete: true},
+		{line: "GetMempoolEntr", expectedLine: "GetMempoolEntr", expectsComplete: false},
+		{line: "GetMempoolEn", expectedLine: "GetMempoolEntr", expectsComplete: true},
+		{line: "hel", expectedLine: "help ", expectsComplete: true},
+		{line: "help GetBlockC", expectedLine: "help GetBlockCount ", expectsComplete: true},
+		{line: "GetBlock h", expectedLine: "GetBlock Hash=", expectsComplete: true},
+		{line: "GetBlock Hash=abc ", expectedLine: "GetBlock Hash=abc IncludeTransactions=", expectsComplete: true},
+		{line: "GetBlock Hash=ab", expectsComplete: false},
+		{line: "Unknown x", expectsComplete: false},
+	}
+
+	for _, test := range tests {
+		newLine, newPos, ok := r.complete(test.line, len(test.line))
+		if ok != test.expectsComplete {
+			t.Errorf("%q: expected completion %t, got %t", test.line, test.expectsComplete, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if newLine != test.expectedLine || newPos != len(test.expectedLine) {
+			t.Errorf("%q: expected %q, got %q at %d", test.line, test.expectedLine, newLine, newPos)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/c4ei/c4exd/infrastructure/network/netadapter/server/grpcserver/protowire"
+	"github.com/c4ei/c4exd/infrastructure/network/rpcclient/grpcclient"
+	"github.com/pkg/errors"
+)
+
+const notificationsBufferSize = 1000
+
+// session posts requests over a single connection to the RPC server while notifications
+// from earlier subscriptions keep arriving over it. Responses are handed to the request
+// that waits for them, and notifications are kept only while they are being streamed
+type session struct {
+	client  *grpcclient.GRPCClient
+	timeout time.Duration
+
+	responses     chan *protowire.C4exdMessage
+	notifications chan *protowire.C4exdMessage
+	receiveErrors chan error
+
+	// isStreaming is 1 while notifications are being streamed, and 0 otherwise
+	isStreaming uint32
+}
+
+func newSession(client *grpcclient.GRPCClient, timeout time.Duration) *session {
+	s := &session{
+		client:        client,
+		timeout:       timeout,
+		responses:     make(chan *protowire.C4exdMessage, 1),
+		notifications: make(chan *protowire.C4exdMessage, notificationsBufferSize),
+		receiveErrors: make(chan error, 1),
+	}
+	go s.receiveLoop()
+	return s
+}
+
+func (s *session) receiveLoop() {
+	for {
+		message, err := s.client.Receive()
+		if err != nil {
+			s.receiveErrors <- err
+			return
+		}
+		if !isNotification(message) {
+			s.responses <- message
+			continue
+		}
+		if atomic.LoadUint32(&s.isStreaming) == 0 {
+			continue
+		}
+		select {
+		case s.notifications <- message:
+		default:
+			// The notifications are printed slower than they arrive, so we skip some rather
+			// than hold up the connection
+		}
+	}
+}
+
+// post sends the given request and waits for its response. If the request subscribes to
+// notifications, they are kept from the moment it is sent, to be streamed afterwards
+func (s *session) post(request *protowire.C4exdMessage) (*protowire.C4exdMessage, error) {
+	// A response that arrives after its request timed out is dropped here
+	select {
+	case <-s.responses:
+	default:
+	}
+
+	if isSubscriptionRequest(request) {
+		s.startStreaming()
+	}
+	err := s.client.Send(request)
+	if err != nil {
+		s.stopStreaming()
+		return nil, err
+	}
+
+	select {
+	case response := <-s.responses:
+		return response, nil
+	case err := <-s.receiveErrors:
+		s.stopStreaming()
+		return nil, err
+	case <-time.After(s.timeout):
+		s.stopStreaming()
+		return nil, errors.Errorf("timeout of %s has been exceeded", s.timeout)
+	}
+}
+
+// streamNotifications calls onNotification with every notification that arrives until stop is closed
+// or the connection to the RPC server is lost
+func (s *session) streamNotifications(stop <-chan struct{},
+	onNotification func(notification *protowire.C4exdMessage) error) error {
+
+	s.startStreaming()
+	defer s.stopStreaming()
+	for {
+		select {
+		case notification := <-s.notifications:
+			err := onNotification(notification)
+			if err != nil {
+				return err
+			}
+		case err := <-s.receiveErrors:
+			return err
+		case <-stop:
+			return nil
+		}
+	}
+}
+
+func (s *session) startStreaming() {
+	atomic.StoreUint32(&s.isStreaming, 1)
+}
+
+func (s *session) stopStreaming() {
+	atomic.StoreUint32(&s.isStreaming, 0)
+	for {
+		select {
+		case <-s.notifications:
+		default:
+			return
+		}
+	}
+}
+
+// isNotification returns whether the message is a notification, rather than a response to a request
+func isNotification(message *protowire.C4exdMessage) bool {
+	return strings.HasSuffix(payloadTypeName(message), "Notification")
+}
+
+// isSubscriptionRequest returns whether the request subscribes to notifications
+func isSubscriptionRequest(request *protowire.C4exdMessage) bool {
+	return strings.HasPrefix(payloadTypeName(request), "C4exdMessage_Notify")
+}
+
+func payloadTypeName(message *protowire.C4exdMessage) string {
+	if message.Payload == nil {
+		return ""
+	}
+	return reflect.TypeOf(message.Payload).Elem().Name()
+}
+
+// protoPayload returns the request, response or notification that the message wraps
+func protoPayload(message *protowire.C4exdMessage) interface{} {
+	if message.Payload == nil {
+		return nil
+	}
+	return reflect.ValueOf(message.Payload).Elem().Field(0).Interface()
+}
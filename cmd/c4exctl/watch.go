@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/c4ei/c4exd/infrastructure/network/netadapter/server/grpcserver/protowire"
+	"github.com/pkg/errors"
+)
+
+// change is a difference between two runs of a watched command
+type change struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// watch posts the request every interval until stop is closed. The first response is printed whole,
+// and later ones as the changes from the previous response, or as new rows of the table in table format
+func watch(s *session, request *protowire.C4exdMessage, interval time.Duration, p *printer,
+	stop <-chan struct{}) error {
+
+	var previousLeaves map[string]interface{}
+	for {
+		response, err := s.post(request)
+		if err != nil {
+			return err
+		}
+		leaves, err := p.leaves(response)
+		if err != nil {
+			return err
+		}
+
+		if previousLeaves == nil || p.format == formatTable {
+			err = p.print(response)
+		} else {
+			err = p.printChanges(diffLeaves(previousLeaves, leaves))
+		}
+		if err != nil {
+			return err
+		}
+		previousLeaves = leaves
+
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// leaves returns the leaves of the message that the printer prints, keyed by their paths
+func (p *printer) leaves(message *protowire.C4exdMessage) (map[string]interface{}, error) {
+	value, err := messageValue(message)
+	if err != nil {
+		return nil, err
+	}
+	if len(p.fields) == 0 {
+		return flatten(value), nil
+	}
+	leaves := make(map[string]interface{}, len(p.fields))
+	for _, field := range p.fields {
+		leaves[field.name] = field.lookup(value)
+	}
+	return leaves, nil
+}
+
+func (p *printer) printChanges(changes []*change) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	now := time.Now().Format("2006-01-02 15:04:05")
+
+	if p.format == formatJSONL {
+		changesJSON, err := json.Marshal(struct {
+			Time    string    `json:"time"`
+			Changes []*change `json:"changes"`
+		}{Time: now, Changes: changes})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		_, err = fmt.Fprintf(p.writer, "%s\n", changesJSON)
+		return err
+	}
+
+	_, err := fmt.Fprintf(p.writer, "%s\n", now)
+	if err != nil {
+		return err
+	}
+	for _, change := range changes {
+		switch {
+		case change.Old == nil:
+			_, err = fmt.Fprintf(p.writer, "+ %s: %s\n", change.Field, cellString(change.New))
+		case change.New == nil:
+			_, err = fmt.Fprintf(p.writer, "- %s: %s\n", change.Field, cellString(change.Old))
+		default:
+			_, err = fmt.Fprintf(p.writer, "~ %s: %s -> %s\n", change.Field, cellString(change.Old),
+				cellString(change.New))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// diffLeaves returns the changes between the previous and the current leaves, sorted by their fields
+func diffLeaves(previousLeaves, currentLeaves map[string]interface{}) []*change {
+	var changes []*change
+	for path, currentValue := range currentLeaves {
+		previousValue := previousLeaves[path]
+		if cellString(previousValue) != cellString(currentValue) {
+			changes = append(changes, &change{Field: path, Old: previousValue, New: currentValue})
+		}
+	}
+	for path, previousValue := range previousLeaves {
+		if _, ok := currentLeaves[path]; !ok && previousValue != nil {
+			changes = append(changes, &change{Field: path, Old: previousValue})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
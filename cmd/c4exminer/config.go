@@ -2,11 +2,14 @@ package main
 
 import (
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 
+	"github.com/c4ei/c4exd/app/appmessage"
+	"github.com/c4ei/c4exd/domain/consensus/model/externalapi"
 	"github.com/c4ei/c4exd/infrastructure/config"
 
 	"github.com/c4ei/c4exd/util"
@@ -20,6 +23,7 @@ const (
 	defaultLogFilename          = "c4exminer.log"
 	defaultErrLogFilename       = "c4exminer_err.log"
 	defaultTargetBlockRateRatio = 2.0
+	defaultNumberOfWorkers      = 1
 )
 
 var (
@@ -38,12 +42,18 @@ type configFlags struct {
 	MineWhenNotSynced     bool     `long:"mine-when-not-synced" description:"Mine even if the node is not synced with the rest of the network."`
 	Profile               string   `long:"profile" description:"Enable HTTP profiling on given port -- NOTE port must be between 1024 and 65536"`
 	TargetBlocksPerSecond *float64 `long:"target-blocks-per-second" description:"Sets a maximum block rate. 0 means no limit (The default one is 2 * target network block rate)"`
+	NumberOfWorkers       uint32   `long:"workers" description:"Number of workers that hash in parallel, each over its own range of nonces"`
+	Payouts               []string `long:"payout" description:"Split the block rewards with another payee, as <address>:<percent>. May be repeated, up to 3 times since each payout takes about 40 bytes of the coinbase payload; --miningaddr gets what the payouts leave"`
+	ExtraData             string   `long:"extra-data" description:"Extra data to tag the mined blocks with, such as a pool identifier"`
 	config.NetworkFlags
+
+	payouts []*appmessage.RPCCoinbasePayout
 }
 
 func parseConfig() (*configFlags, error) {
 	cfg := &configFlags{
-		RPCServer: defaultRPCServer,
+		RPCServer:       defaultRPCServer,
+		NumberOfWorkers: defaultNumberOfWorkers,
 	}
 	parser := flags.NewParser(cfg, flags.PrintErrors|flags.HelpFlag)
 	_, err := parser.Parse()
@@ -77,11 +87,59 @@ func parseConfig() (*configFlags, error) {
 		}
 	}
 
+	if cfg.NumberOfWorkers == 0 {
+		return nil, errors.New("--workers must be at least 1")
+	}
+
 	if cfg.MiningAddr == "" {
 		return nil, errors.New("--miningaddr is required")
 	}
 
+	cfg.payouts, err = parsePayouts(cfg.Payouts, cfg.ActiveNetParams.Prefix)
+	if err != nil {
+		return nil, err
+	}
+
 	initLog(defaultLogFile, defaultErrLogFile)
 
 	return cfg, nil
 }
+
+// parsePayouts parses the --payout flags, each of the form <address>:<percent>, to the
+// payouts of a block template request, weighted in basis points of the block rewards.
+// Since the coinbase payload is limited to MaxCoinbasePayloadLength bytes, the node
+// rejects templates with more payouts than fit in it, which is about 3 by default.
+func parsePayouts(payoutFlags []string, prefix util.Bech32Prefix) ([]*appmessage.RPCCoinbasePayout, error) {
+	payouts := make([]*appmessage.RPCCoinbasePayout, 0, len(payoutFlags))
+	totalWeight := uint64(0)
+	for _, payoutFlag := range payoutFlags {
+		// Addresses contain a colon themselves, so the percent is after the last one
+		separatorIndex := strings.LastIndex(payoutFlag, ":")
+		if separatorIndex == -1 {
+			return nil, errors.Errorf("--payout %s is not of the form <address>:<percent>", payoutFlag)
+		}
+		address, percentString := payoutFlag[:separatorIndex], payoutFlag[separatorIndex+1:]
+
+		_, err := util.DecodeAddress(address, prefix)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error decoding the address of --payout %s", payoutFlag)
+		}
+		percent, err := strconv.ParseFloat(percentString, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error parsing the percent of --payout %s", payoutFlag)
+		}
+		weight := math.Round(percent * externalapi.CoinbasePayoutWeightDenominator / 100)
+		if weight < 1 || weight >= externalapi.CoinbasePayoutWeightDenominator {
+			return nil, errors.Errorf("the percent of --payout %s must be between 0.01 and 99.99", payoutFlag)
+		}
+
+		totalWeight += uint64(weight)
+		payouts = append(payouts, &appmessage.RPCCoinbasePayout{Address: address, Weight: uint32(weight)})
+	}
+
+	if totalWeight >= externalapi.CoinbasePayoutWeightDenominator {
+		return nil, errors.New("the percents of the payouts must sum to less than 100, so that --miningaddr " +
+			"gets a share of the rewards")
+	}
+	return payouts, nil
+}
@@ -48,7 +48,8 @@ func main() {
 
 	doneChan := make(chan struct{})
 	spawn("mineLoop", func() {
-		err = mineLoop(client, cfg.NumberOfBlocks, *cfg.TargetBlocksPerSecond, cfg.MineWhenNotSynced, miningAddr)
+		err = mineLoop(client, cfg.NumberOfBlocks, *cfg.TargetBlocksPerSecond, cfg.MineWhenNotSynced, miningAddr,
+			cfg.payouts, cfg.ExtraData, cfg.NumberOfWorkers)
 		if err != nil {
 			panic(errors.Wrap(err, "error in mine loop"))
 		}
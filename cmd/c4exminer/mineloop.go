@@ -2,7 +2,11 @@ package main
 
 import (
 	nativeerrors "errors"
+	"fmt"
+	"math"
 	"math/rand"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -18,13 +22,21 @@ import (
 	"github.com/pkg/errors"
 )
 
-var hashesTried uint64
+// hashCounter counts the hashes a worker tried. It is padded to a cache line so
+// the counters of the workers don't share one.
+type hashCounter struct {
+	hashesTried uint64
+	_           [56]byte
+}
+
+var hashCounters []hashCounter
 
 const logHashRateInterval = 10 * time.Second
 
 func mineLoop(client *minerClient, numberOfBlocks uint64, targetBlocksPerSecond float64, mineWhenNotSynced bool,
-	miningAddr util.Address) error {
+	miningAddr util.Address, payouts []*appmessage.RPCCoinbasePayout, extraData string, numberOfWorkers uint32) error {
 	rand.Seed(time.Now().UnixNano()) // Seed the global concurrent-safe random source.
+	hashCounters = make([]hashCounter, numberOfWorkers)
 
 	errChan := make(chan error)
 	doneChan := make(chan struct{})
@@ -36,7 +48,7 @@ func mineLoop(client *minerClient, numberOfBlocks uint64, targetBlocksPerSecond
 	foundBlockChan := make(chan *externalapi.DomainBlock, router.DefaultMaxMessages/2)
 
 	spawn("templatesLoop", func() {
-		templatesLoop(client, miningAddr, errChan)
+		templatesLoop(client, miningAddr, payouts, extraData, errChan)
 	})
 
 	spawn("blocksLoop", func() {
@@ -96,15 +108,27 @@ func mineLoop(client *minerClient, numberOfBlocks uint64, targetBlocksPerSecond
 func logHashRate() {
 	spawn("logHashRate", func() {
 		lastCheck := time.Now()
+		lastHashesTried := make([]uint64, len(hashCounters))
 		for range time.Tick(logHashRateInterval) {
-			currentHashesTried := atomic.LoadUint64(&hashesTried)
 			currentTime := time.Now()
-			kiloHashesTried := float64(currentHashesTried) / 1000.0
-			hashRate := kiloHashesTried / currentTime.Sub(lastCheck).Seconds()
-			log.Infof("Current hash rate is %.2f Khash/s", hashRate)
+			elapsedSeconds := currentTime.Sub(lastCheck).Seconds()
+			totalHashRate := 0.0
+			workerHashRates := make([]string, len(hashCounters))
+			for i := range hashCounters {
+				currentHashesTried := atomic.LoadUint64(&hashCounters[i].hashesTried)
+				kiloHashesTried := float64(currentHashesTried-lastHashesTried[i]) / 1000.0
+				hashRate := kiloHashesTried / elapsedSeconds
+				totalHashRate += hashRate
+				workerHashRates[i] = fmt.Sprintf("%.2f", hashRate)
+				lastHashesTried[i] = currentHashesTried
+			}
+			if len(hashCounters) == 1 {
+				log.Infof("Current hash rate is %.2f Khash/s", totalHashRate)
+			} else {
+				log.Infof("Current hash rate is %.2f Khash/s (per worker: %s)",
+					totalHashRate, strings.Join(workerHashRates, ", "))
+			}
 			lastCheck = currentTime
-			// subtract from hashesTried the hashes we already sampled
-			atomic.AddUint64(&hashesTried, -currentHashesTried)
 		}
 	})
 }
@@ -135,18 +159,75 @@ func handleFoundBlock(client *minerClient, block *externalapi.DomainBlock) error
 	return nil
 }
 
+// mineNextBlock mines a block with all the workers, each over its own range of nonces,
+// and returns the first block any of them finds
 func mineNextBlock(mineWhenNotSynced bool) *externalapi.DomainBlock {
+	numberOfWorkers := uint64(len(hashCounters))
+	foundBlockChan := make(chan *externalapi.DomainBlock, numberOfWorkers)
+	var isStopped uint32
+	waitGroup := sync.WaitGroup{}
+	for workerIndex := uint64(0); workerIndex < numberOfWorkers; workerIndex++ {
+		counter := &hashCounters[workerIndex]
+		firstNonce, lastNonce := nonceRange(workerIndex, numberOfWorkers)
+		waitGroup.Add(1)
+		spawn("mineInNonceRange", func() {
+			defer waitGroup.Done()
+			block := mineInNonceRange(counter, firstNonce, lastNonce, mineWhenNotSynced, &isStopped)
+			if block != nil {
+				foundBlockChan <- block
+			}
+		})
+	}
+
+	block := <-foundBlockChan
+	atomic.StoreUint32(&isStopped, 1)
+	waitGroup.Wait()
+	return block
+}
+
+// nonceRange returns the first and last nonces of the range the given worker mines with. The ranges
+// of the workers don't overlap and together cover all the nonces.
+func nonceRange(workerIndex, numberOfWorkers uint64) (firstNonce, lastNonce uint64) {
+	nonceRangeSize := math.MaxUint64 / numberOfWorkers
+	firstNonce = workerIndex * nonceRangeSize
+	lastNonce = firstNonce + nonceRangeSize - 1
+	// The last worker also gets the remainder of the division
+	if workerIndex == numberOfWorkers-1 {
+		lastNonce = math.MaxUint64
+	}
+	return firstNonce, lastNonce
+}
+
+// mineInNonceRange mines with the nonces from firstNonce to lastNonce, starting from a random nonce
+// in the range, until it finds a block or until isStopped is set, in which case it returns nil
+func mineInNonceRange(counter *hashCounter, firstNonce, lastNonce uint64, mineWhenNotSynced bool,
+	isStopped *uint32) *externalapi.DomainBlock {
+
 	nonce := rand.Uint64() // Use the global concurrent-safe random source.
-	for {
-		nonce++
-		// For each nonce we try to build a block from the most up to date
-		// block template.
-		// In the rare case where the nonce space is exhausted for a specific
-		// block, it'll keep looping the nonce until a new block template
+	if lastNonce-firstNonce != math.MaxUint64 {
+		nonce = firstNonce + nonce%(lastNonce-firstNonce+1)
+	}
+	var block *externalapi.DomainBlock
+	var state *pow.State
+	var templateVersion uint64
+	for atomic.LoadUint32(isStopped) == 0 {
+		// For each nonce we make sure we're mining the most up to date block template.
+		// In the rare case where the nonce range is exhausted for a specific
+		// block, it'll keep looping the nonce range until a new block template
 		// is discovered.
-		block, state := getBlockForMining(mineWhenNotSynced)
+		if block == nil || templatemanager.Version() != templateVersion {
+			block, state, templateVersion = getBlockForMining(mineWhenNotSynced, isStopped)
+			if block == nil {
+				return nil
+			}
+		}
+		if nonce == lastNonce {
+			nonce = firstNonce
+		} else {
+			nonce++
+		}
 		state.Nonce = nonce
-		atomic.AddUint64(&hashesTried, 1)
+		atomic.AddUint64(&counter.hashesTried, 1)
 		if state.CheckProofOfWork() {
 			mutHeader := block.Header.ToMutable()
 			mutHeader.SetNonce(nonce)
@@ -155,19 +236,22 @@ func mineNextBlock(mineWhenNotSynced bool) *externalapi.DomainBlock {
 			return block
 		}
 	}
+	return nil
 }
 
-func getBlockForMining(mineWhenNotSynced bool) (*externalapi.DomainBlock, *pow.State) {
+// getBlockForMining waits for a template to mine on and returns it along with its version.
+// It returns a nil block if isStopped is set while waiting.
+func getBlockForMining(mineWhenNotSynced bool, isStopped *uint32) (*externalapi.DomainBlock, *pow.State, uint64) {
 	tryCount := 0
 
 	const sleepTime = 500 * time.Millisecond
 	const sleepTimeWhenNotSynced = 5 * time.Second
 
-	for {
+	for atomic.LoadUint32(isStopped) == 0 {
 		tryCount++
 
 		shouldLog := (tryCount-1)%10 == 0
-		template, state, isSynced := templatemanager.Get()
+		template, state, isSynced, templateVersion := templatemanager.Get()
 		if template == nil {
 			if shouldLog {
 				log.Info("Waiting for the initial template")
@@ -183,13 +267,20 @@ func getBlockForMining(mineWhenNotSynced bool) (*externalapi.DomainBlock, *pow.S
 			continue
 		}
 
-		return template, state
+		return template, state, templateVersion
 	}
+	return nil, nil, 0
 }
 
-func templatesLoop(client *minerClient, miningAddr util.Address, errChan chan error) {
+func templatesLoop(client *minerClient, miningAddr util.Address, payouts []*appmessage.RPCCoinbasePayout,
+	extraData string, errChan chan error) {
+
+	coinbaseExtraData := "c4exminer-" + version.Version()
+	if extraData != "" {
+		coinbaseExtraData += "/" + extraData
+	}
 	getBlockTemplate := func() {
-		template, err := client.GetBlockTemplate(miningAddr.String(), "c4exminer-"+version.Version())
+		template, err := client.GetBlockTemplateWithPayouts(miningAddr.String(), coinbaseExtraData, payouts)
 		if nativeerrors.Is(err, router.ErrTimeout) {
 			log.Warnf("Got timeout while requesting block template from %s: %s", client.Address(), err)
 			reconnectErr := client.Reconnect()
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/c4ei/c4exd/app/appmessage"
+	"github.com/c4ei/c4exd/cmd/c4exminer/templatemanager"
+	"github.com/c4ei/c4exd/domain/consensus/model/externalapi"
+	"github.com/c4ei/c4exd/domain/consensus/utils/blockheader"
+	"github.com/c4ei/c4exd/domain/dagconfig"
+)
+
+func TestNonceRange(t *testing.T) {
+	for _, numberOfWorkers := range []uint64{1, 2, 3, 7, 64} {
+		expectedFirstNonce := uint64(0)
+		for workerIndex := uint64(0); workerIndex < numberOfWorkers; workerIndex++ {
+			firstNonce, lastNonce := nonceRange(workerIndex, numberOfWorkers)
+			if firstNonce != expectedFirstNonce {
+				t.Fatalf("with %d workers, expected worker %d to start at nonce %d, got %d",
+					numberOfWorkers, workerIndex, expectedFirstNonce, firstNonce)
+			}
+			if lastNonce < firstNonce {
+				t.Fatalf("with %d workers, worker %d got the empty range %d-%d",
+					numberOfWorkers, workerIndex, firstNonce, lastNonce)
+			}
+			if workerIndex == numberOfWorkers-1 {
+				if lastNonce != math.MaxUint64 {
+					t.Fatalf("with %d workers, expected the last worker to end at the last nonce, got %d",
+						numberOfWorkers, lastNonce)
+				}
+				break
+			}
+			expectedFirstNonce = lastNonce + 1
+		}
+	}
+}
+
+func TestMineInNonceRangeRestartsOnNewTemplate(t *testing.T) {
+	setTemplate := func(bits uint32) {
+		genesis := dagconfig.SimnetParams.GenesisBlock
+		header := genesis.Header
+		templateHeader := blockheader.NewImmutableBlockHeader(header.Version(), header.Parents(), header.HashMerkleRoot(),
+			header.AcceptedIDMerkleRoot(), header.UTXOCommitment(), header.TimeInMilliseconds(), bits, 0,
+			header.DAAScore(), header.BlueScore(), header.BlueWork(), header.PruningPoint())
+		block := &externalapi.DomainBlock{Header: templateHeader, Transactions: genesis.Transactions}
+		err := templatemanager.Set(appmessage.NewGetBlockTemplateResponseMessage(appmessage.DomainBlockToRPCBlock(block), true))
+		if err != nil {
+			t.Fatalf("Set: %+v", err)
+		}
+	}
+
+	// A target of zero can practically never be met, so the worker keeps mining the first template
+	const impossibleBits = 0x03000000
+	const easyBits = 0x207fffff
+	setTemplate(impossibleBits)
+
+	counter := &hashCounter{}
+	firstNonce, lastNonce := nonceRange(1, 4)
+	var isStopped uint32
+	defer atomic.StoreUint32(&isStopped, 1)
+	foundBlockChan := make(chan *externalapi.DomainBlock, 1)
+	go func() {
+		foundBlockChan <- mineInNonceRange(counter, firstNonce, lastNonce, false, &isStopped)
+	}()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for atomic.LoadUint64(&counter.hashesTried) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("the worker didn't start mining")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	setTemplate(easyBits)
+	select {
+	case block := <-foundBlockChan:
+		if block == nil {
+			t.Fatalf("the worker stopped without finding a block")
+		}
+		if block.Header.Bits() != easyBits {
+			t.Fatalf("expected a block of the new template, got one with bits %x", block.Header.Bits())
+		}
+		if block.Header.Nonce() < firstNonce || block.Header.Nonce() > lastNonce {
+			t.Fatalf("the nonce %d is outside the worker's range %d-%d", block.Header.Nonce(), firstNonce, lastNonce)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("the worker didn't switch to the new template")
+	}
+}
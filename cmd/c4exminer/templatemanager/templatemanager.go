@@ -2,6 +2,7 @@ package templatemanager
 
 import (
 	"sync"
+	"sync/atomic"
 
 	"github.com/c4ei/c4exd/app/appmessage"
 	"github.com/c4ei/c4exd/domain/consensus/model/externalapi"
@@ -11,19 +12,26 @@ import (
 var currentTemplate *externalapi.DomainBlock
 var currentState *pow.State
 var isSynced bool
+var version uint64
 var lock = &sync.Mutex{}
 
-// Get returns the template to work on
-func Get() (*externalapi.DomainBlock, *pow.State, bool) {
+// Get returns the template to work on, along with the version of the template
+func Get() (*externalapi.DomainBlock, *pow.State, bool, uint64) {
 	lock.Lock()
 	defer lock.Unlock()
 	// Shallow copy the block so when the user replaces the header it won't affect the template here.
 	if currentTemplate == nil {
-		return nil, nil, false
+		return nil, nil, false, 0
 	}
 	block := *currentTemplate
 	state := *currentState
-	return &block, &state, isSynced
+	return &block, &state, isSynced, atomic.LoadUint64(&version)
+}
+
+// Version returns the version of the current template, which changes whenever
+// the template is set. It is cheap enough to be checked for every hash.
+func Version() uint64 {
+	return atomic.LoadUint64(&version)
 }
 
 // Set sets the current template to work on
@@ -37,5 +45,6 @@ func Set(template *appmessage.GetBlockTemplateResponseMessage) error {
 	currentTemplate = block
 	currentState = pow.NewState(block.Header.ToMutable())
 	isSynced = template.IsSynced
+	atomic.AddUint64(&version, 1)
 	return nil
 }
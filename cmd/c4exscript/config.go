@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+
+	"github.com/jessevdk/go-flags"
+	"github.com/pkg/errors"
+)
+
+const (
+	debugSubCmd = "debug"
+)
+
+type configFlags struct{}
+
+type debugConfig struct {
+	Transaction                string `long:"transaction" short:"t" description:"The transaction to debug (encoded in hex, as produced by c4exwallet)"`
+	TransactionFile            string `long:"transaction-file" short:"F" description:"The file containing the transaction to debug (encoded in hex, as produced by c4exwallet)"`
+	InputIndex                 uint32 `long:"input-index" short:"i" description:"The index of the input whose scripts to execute" default:"0"`
+	UTXOAmount                 uint64 `long:"utxo-amount" description:"The amount in sompi of the UTXO entry spent by the input (default: taken from the transaction)"`
+	UTXOScriptPublicKey        string `long:"utxo-script-public-key" description:"The script public key of the UTXO entry spent by the input, encoded in hex (default: taken from the transaction)"`
+	UTXOScriptPublicKeyVersion uint16 `long:"utxo-script-public-key-version" description:"The version of the script public key of the UTXO entry spent by the input" default:"0"`
+	ScriptFlags                uint32 `long:"script-flags" description:"The script flags to execute with" default:"0"`
+	Trace                      bool   `long:"trace" description:"Execute the whole script and print every step, instead of stepping interactively"`
+}
+
+func parseCommandLine() (subCommand string, config interface{}) {
+	cfg := &configFlags{}
+	parser := flags.NewParser(cfg, flags.PrintErrors|flags.HelpFlag)
+
+	debugConf := &debugConfig{}
+	parser.AddCommand(debugSubCmd, "Step through the scripts of a transaction input",
+		"Executes the signature script and the spent script public key of a transaction input one opcode at a "+
+			"time, printing the disassembled opcodes and the stacks on the way", debugConf)
+
+	_, err := parser.Parse()
+	if err != nil {
+		var flagsErr *flags.Error
+		if ok := errors.As(err, &flagsErr); ok && flagsErr.Type == flags.ErrHelp {
+			os.Exit(0)
+		} else {
+			os.Exit(1)
+		}
+		return "", nil
+	}
+
+	switch parser.Command.Active.Name {
+	case debugSubCmd:
+		err := validateDebugConfig(debugConf)
+		if err != nil {
+			printErrorAndExit(err)
+		}
+		config = debugConf
+	}
+
+	return parser.Command.Active.Name, config
+}
+
+func validateDebugConfig(conf *debugConfig) error {
+	if conf.Transaction == "" && conf.TransactionFile == "" {
+		return errors.Errorf("Either --transaction or --transaction-file is required")
+	}
+	if conf.Transaction != "" && conf.TransactionFile != "" {
+		return errors.Errorf("Both --transaction and --transaction-file cannot be passed at the same time")
+	}
+	return nil
+}
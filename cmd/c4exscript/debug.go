@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet/serialization"
+	"github.com/c4ei/c4exd/domain/consensus/model/externalapi"
+	"github.com/c4ei/c4exd/domain/consensus/utils/consensushashing"
+	"github.com/c4ei/c4exd/domain/consensus/utils/constants"
+	"github.com/c4ei/c4exd/domain/consensus/utils/txscript"
+	"github.com/c4ei/c4exd/domain/consensus/utils/utxo"
+	"github.com/pkg/errors"
+)
+
+const debugHelp = `Commands:
+  step, s (or an empty line)  Execute the next opcode
+  continue, c                 Execute until the end of the scripts
+  stack                       Print the data, alt and condition stacks
+  scripts                     Print the disassembly of all scripts
+  help, h                     Print this help
+  quit, q                     Stop debugging`
+
+func debug(conf *debugConfig) error {
+	tx, err := readDebuggedTransaction(conf)
+	if err != nil {
+		return err
+	}
+	if int(conf.InputIndex) >= len(tx.Inputs) {
+		return errors.Errorf("Input index %d is out of range: the transaction has %d inputs",
+			conf.InputIndex, len(tx.Inputs))
+	}
+
+	entry := tx.Inputs[conf.InputIndex].UTXOEntry
+	if entry == nil {
+		return errors.Errorf("The transaction does not contain the UTXO entry spent by input %d: "+
+			"--utxo-amount and --utxo-script-public-key are required", conf.InputIndex)
+	}
+	scriptPublicKey := entry.ScriptPublicKey()
+	if scriptPublicKey.Version > constants.MaxScriptPublicKeyVersion {
+		fmt.Printf("Script public key version %d is higher than the known version %d: "+
+			"the input is valid without executing its scripts\n", scriptPublicKey.Version, constants.MaxScriptPublicKeyVersion)
+		return nil
+	}
+
+	vm, err := txscript.NewEngine(scriptPublicKey, tx, int(conf.InputIndex), txscript.ScriptFlags(conf.ScriptFlags),
+		nil, nil, &consensushashing.SighashReusedValues{})
+	if err != nil {
+		return errors.Wrap(err, "Failed to start the script engine")
+	}
+
+	printScripts(vm)
+	if conf.Trace {
+		return traceExecution(vm)
+	}
+	return stepInteractively(vm)
+}
+
+// readDebuggedTransaction reads the transaction given in the config, fills the
+// UTXO entries of its inputs from it, and overrides the UTXO entry of the
+// debugged input with the one given in the config.
+func readDebuggedTransaction(conf *debugConfig) (*externalapi.DomainTransaction, error) {
+	transactionHex := conf.Transaction
+	if conf.TransactionFile != "" {
+		transactionHexBytes, err := ioutil.ReadFile(conf.TransactionFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Could not read hex from %s", conf.TransactionFile)
+		}
+		transactionHex = strings.TrimSpace(string(transactionHexBytes))
+	}
+	if strings.Contains(transactionHex, "_") {
+		return nil, errors.Errorf("Only a single transaction can be debugged at a time")
+	}
+	transactionBytes, err := hex.DecodeString(transactionHex)
+	if err != nil {
+		return nil, errors.Wrap(err, "Could not decode the transaction hex")
+	}
+
+	var tx *externalapi.DomainTransaction
+	partiallySignedTransaction, err := serialization.DeserializePartiallySignedTransaction(transactionBytes)
+	if err == nil {
+		tx = partiallySignedTransaction.Tx
+		for i, input := range tx.Inputs {
+			prevOutput := partiallySignedTransaction.PartiallySignedInputs[i].PrevOutput
+			input.UTXOEntry = utxo.NewUTXOEntry(prevOutput.Value, prevOutput.ScriptPublicKey, false, 0)
+		}
+	} else {
+		tx, err = serialization.DeserializeDomainTransaction(transactionBytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "Could not deserialize the transaction")
+		}
+	}
+
+	if int(conf.InputIndex) >= len(tx.Inputs) {
+		return tx, nil
+	}
+	if conf.UTXOScriptPublicKey == "" && conf.UTXOAmount == 0 {
+		return tx, nil
+	}
+
+	// Whatever isn't given in the config is taken from the UTXO entry in the transaction
+	entry := tx.Inputs[conf.InputIndex].UTXOEntry
+	if entry == nil && (conf.UTXOScriptPublicKey == "" || conf.UTXOAmount == 0) {
+		return nil, errors.Errorf("The transaction does not contain the UTXO entry spent by input %d: "+
+			"--utxo-amount and --utxo-script-public-key are both required", conf.InputIndex)
+	}
+	amount := conf.UTXOAmount
+	if amount == 0 {
+		amount = entry.Amount()
+	}
+	var scriptPublicKey *externalapi.ScriptPublicKey
+	if conf.UTXOScriptPublicKey != "" {
+		script, err := hex.DecodeString(conf.UTXOScriptPublicKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "Could not decode the UTXO script public key hex")
+		}
+		scriptPublicKey = &externalapi.ScriptPublicKey{Script: script, Version: conf.UTXOScriptPublicKeyVersion}
+	} else {
+		scriptPublicKey = entry.ScriptPublicKey()
+	}
+	tx.Inputs[conf.InputIndex].UTXOEntry = utxo.NewUTXOEntry(amount, scriptPublicKey, false, 0)
+
+	return tx, nil
+}
+
+func traceExecution(vm *txscript.Engine) error {
+	for !vm.IsDone() {
+		done, err := step(vm)
+		if err != nil {
+			return err
+		}
+		if done {
+			break
+		}
+	}
+	return printResult(vm)
+}
+
+func stepInteractively(vm *txscript.Engine) error {
+	fmt.Println(debugHelp)
+	scanner := bufio.NewScanner(os.Stdin)
+	for !vm.IsDone() {
+		next, err := vm.DisasmPC()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\nnext %s\n> ", next)
+		if !scanner.Scan() {
+			return scanner.Err()
+		}
+
+		switch strings.TrimSpace(scanner.Text()) {
+		case "", "s", "step":
+			done, err := step(vm)
+			if err != nil {
+				return err
+			}
+			if done {
+				return printResult(vm)
+			}
+		case "c", "continue":
+			return traceExecution(vm)
+		case "stack":
+			printStacks(vm)
+		case "scripts":
+			printScripts(vm)
+		case "h", "help":
+			fmt.Println(debugHelp)
+		case "q", "quit":
+			return nil
+		default:
+			fmt.Printf("Unknown command %q\n%s\n", scanner.Text(), debugHelp)
+		}
+	}
+	return printResult(vm)
+}
+
+// step executes the next opcode and prints it along with the resulting stacks
+func step(vm *txscript.Engine) (done bool, err error) {
+	opcode, err := vm.DisasmPC()
+	if err != nil {
+		return false, err
+	}
+	isBranchExecuting := vm.IsBranchExecuting()
+
+	done, err = vm.Step()
+	if err != nil {
+		fmt.Printf("%s\tfailed\n", opcode)
+		return false, errors.Wrapf(err, "Script execution failed at %s", opcode)
+	}
+	if isBranchExecuting {
+		fmt.Println(opcode)
+	} else {
+		fmt.Printf("%s\t(not executed)\n", opcode)
+	}
+	printStacks(vm)
+	return done, nil
+}
+
+func printResult(vm *txscript.Engine) error {
+	err := vm.CheckErrorCondition(true)
+	if err != nil {
+		return errors.Wrap(err, "Script execution failed")
+	}
+	fmt.Println("\nScript execution succeeded")
+	return nil
+}
+
+func printScripts(vm *txscript.Engine) {
+	scriptNames := []string{"Signature script", "Script public key", "Redeem script"}
+	for i, name := range scriptNames {
+		disassembly, err := vm.DisasmScript(i)
+		if err != nil {
+			// The redeem script is known only once the script public key is executed
+			continue
+		}
+		fmt.Printf("%s:\n%s\n", name, disassembly)
+	}
+}
+
+func printStacks(vm *txscript.Engine) {
+	printStack("\tStack", vm.GetStack())
+	if altStack := vm.GetAltStack(); len(altStack) > 0 {
+		printStack("\tAlt stack", altStack)
+	}
+	if condStack := vm.GetCondStack(); len(condStack) > 0 {
+		conditions := make([]string, len(condStack))
+		for i, condition := range condStack {
+			switch condition {
+			case txscript.OpCondTrue:
+				conditions[i] = "true"
+			case txscript.OpCondFalse:
+				conditions[i] = "false"
+			default:
+				conditions[i] = "skip"
+			}
+		}
+		fmt.Printf("\tCondition stack: [%s]\n", strings.Join(conditions, " "))
+	}
+}
+
+// printStack prints the given stack from its top item to its bottom item
+func printStack(name string, stack [][]byte) {
+	if len(stack) == 0 {
+		fmt.Printf("%s: (empty)\n", name)
+		return
+	}
+	fmt.Printf("%s:\n", name)
+	for i := len(stack) - 1; i >= 0; i-- {
+		fmt.Printf("\t\t%d: %x\n", len(stack)-1-i, stack[i])
+	}
+}
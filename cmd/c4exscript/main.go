@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+func main() {
+	subCmd, config := parseCommandLine()
+
+	var err error
+	switch subCmd {
+	case debugSubCmd:
+		err = debug(config.(*debugConfig))
+	default:
+		err = errors.Errorf("Unknown sub-command '%s'\n", subCmd)
+	}
+
+	if err != nil {
+		printErrorAndExit(err)
+	}
+}
+
+func printErrorAndExit(err error) {
+	fmt.Fprintf(os.Stderr, "%s\n", err)
+	os.Exit(1)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"time"
+
+	"github.com/c4ei/c4exd/app/appmessage"
+	"github.com/c4ei/c4exd/infrastructure/logger"
+	"github.com/c4ei/c4exd/infrastructure/network/rpcclient"
+	"github.com/pkg/errors"
+)
+
+const nodeTimeout = 10 * time.Second
+
+type nodeClient struct {
+	*rpcclient.RPCClient
+
+	cfg                              *configFlags
+	newBlockTemplateNotificationChan chan struct{}
+}
+
+func (nc *nodeClient) connect() error {
+	rpcAddress, err := nc.cfg.NetParams().NormalizeRPCServerAddress(nc.cfg.RPCServer)
+	if err != nil {
+		return err
+	}
+	rpcClient, err := rpcclient.NewRPCClient(rpcAddress)
+	if err != nil {
+		return err
+	}
+	nc.RPCClient = rpcClient
+	nc.SetTimeout(nodeTimeout)
+	nc.SetLogger(backendLog, logger.LevelTrace)
+
+	err = nc.RegisterForNewBlockTemplateNotifications(func(_ *appmessage.NewBlockTemplateNotificationMessage) {
+		select {
+		case nc.newBlockTemplateNotificationChan <- struct{}{}:
+		default:
+		}
+	})
+	if err != nil {
+		return errors.Wrapf(err, "error requesting new-block-template notifications")
+	}
+
+	log.Infof("Connected to %s", rpcAddress)
+
+	return nil
+}
+
+func newNodeClient(cfg *configFlags) (*nodeClient, error) {
+	nodeClient := &nodeClient{
+		cfg:                              cfg,
+		newBlockTemplateNotificationChan: make(chan struct{}),
+	}
+
+	err := nodeClient.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	return nodeClient, nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/c4ei/c4exd/infrastructure/config"
+
+	"github.com/c4ei/c4exd/util"
+	"github.com/pkg/errors"
+
+	"github.com/c4ei/c4exd/version"
+	"github.com/jessevdk/go-flags"
+)
+
+const (
+	defaultLogFilename     = "c4exstratum.log"
+	defaultErrLogFilename  = "c4exstratum_err.log"
+	defaultListen          = ":5555"
+	defaultShareDifficulty = 1.0
+	defaultExtranonceSize  = 2
+	defaultStatsInterval   = time.Minute
+	defaultIdleTimeout     = 10 * time.Minute
+	maxExtranonceSize      = 3
+)
+
+var (
+	// Default configuration options
+	defaultAppDir     = util.AppDir("c4exstratum", false)
+	defaultLogFile    = filepath.Join(defaultAppDir, defaultLogFilename)
+	defaultErrLogFile = filepath.Join(defaultAppDir, defaultErrLogFilename)
+	defaultRPCServer  = "localhost"
+)
+
+type configFlags struct {
+	ShowVersion       bool          `short:"V" long:"version" description:"Display version information and exit"`
+	RPCServer         string        `short:"s" long:"rpcserver" description:"RPC server to connect to"`
+	MiningAddr        string        `long:"miningaddr" description:"Address to mine to"`
+	Listen            string        `short:"l" long:"listen" description:"Address to listen for stratum connections on"`
+	ShareDifficulty   float64       `long:"difficulty" description:"The share difficulty of the workers. Difficulty 1 is 2^32 hashes per share"`
+	ExtranonceSize    int           `long:"extranonce-size" description:"The number of nonce bytes that tell the workers apart (1-3)"`
+	StatsInterval     time.Duration `long:"stats-interval" description:"Interval between logs of the worker share statistics. 0 disables them"`
+	IdleTimeout       time.Duration `long:"idle-timeout" description:"Close connections that send no requests for this long, freeing their extranonce. 0 disables it"`
+	MineWhenNotSynced bool          `long:"mine-when-not-synced" description:"Mine even if the node is not synced with the rest of the network."`
+	Profile           string        `long:"profile" description:"Enable HTTP profiling on given port -- NOTE port must be between 1024 and 65536"`
+	config.NetworkFlags
+}
+
+func parseConfig() (*configFlags, error) {
+	cfg := &configFlags{
+		RPCServer:       defaultRPCServer,
+		Listen:          defaultListen,
+		ShareDifficulty: defaultShareDifficulty,
+		ExtranonceSize:  defaultExtranonceSize,
+		StatsInterval:   defaultStatsInterval,
+		IdleTimeout:     defaultIdleTimeout,
+	}
+	parser := flags.NewParser(cfg, flags.PrintErrors|flags.HelpFlag)
+	_, err := parser.Parse()
+
+	// Show the version and exit if the version flag was specified.
+	if cfg.ShowVersion {
+		appName := filepath.Base(os.Args[0])
+		appName = strings.TrimSuffix(appName, filepath.Ext(appName))
+		fmt.Println(appName, "version", version.Version())
+		os.Exit(0)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = cfg.ResolveNetwork(parser)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Profile != "" {
+		profilePort, err := strconv.Atoi(cfg.Profile)
+		if err != nil || profilePort < 1024 || profilePort > 65535 {
+			return nil, errors.New("The profile port must be between 1024 and 65535")
+		}
+	}
+
+	if cfg.MiningAddr == "" {
+		return nil, errors.New("--miningaddr is required")
+	}
+
+	if cfg.ShareDifficulty <= 0 {
+		return nil, errors.New("--difficulty must be positive")
+	}
+
+	if cfg.ExtranonceSize < 1 || cfg.ExtranonceSize > maxExtranonceSize {
+		return nil, errors.Errorf("--extranonce-size must be between 1 and %d", maxExtranonceSize)
+	}
+
+	if cfg.StatsInterval < 0 {
+		return nil, errors.New("--stats-interval must not be negative")
+	}
+
+	if cfg.IdleTimeout < 0 {
+		return nil, errors.New("--idle-timeout must not be negative")
+	}
+
+	initLog(defaultLogFile, defaultErrLogFile)
+
+	return cfg, nil
+}
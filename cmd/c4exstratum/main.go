@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/c4ei/c4exd/util"
+
+	"github.com/c4ei/c4exd/version"
+
+	"github.com/pkg/errors"
+
+	_ "net/http/pprof"
+
+	"github.com/c4ei/c4exd/infrastructure/os/signal"
+	"github.com/c4ei/c4exd/util/panics"
+	"github.com/c4ei/c4exd/util/profiling"
+)
+
+func main() {
+	defer panics.HandlePanic(log, "MAIN", nil)
+	interrupt := signal.InterruptListener()
+
+	cfg, err := parseConfig()
+	if err != nil {
+		printErrorAndExit(errors.Errorf("Error parsing command-line arguments: %s", err))
+	}
+	defer backendLog.Close()
+
+	// Show version at startup.
+	log.Infof("Version %s", version.Version())
+
+	// Enable http profiling server if requested.
+	if cfg.Profile != "" {
+		profiling.Start(cfg.Profile, log)
+	}
+
+	miningAddr, err := util.DecodeAddress(cfg.MiningAddr, cfg.ActiveNetParams.Prefix)
+	if err != nil {
+		printErrorAndExit(errors.Errorf("Error decoding mining address: %s", err))
+	}
+
+	client, err := newNodeClient(cfg)
+	if err != nil {
+		panic(errors.Wrap(err, "error connecting to the RPC server"))
+	}
+	defer client.Disconnect()
+
+	stratumServer := newServer(client, cfg.ShareDifficulty, cfg.ExtranonceSize, cfg.IdleTimeout, cfg.MineWhenNotSynced)
+	listener, err := stratumServer.listen(cfg.Listen)
+	if err != nil {
+		printErrorAndExit(errors.Wrapf(err, "Error listening on %s", cfg.Listen))
+	}
+	defer listener.Close()
+	log.Infof("Listening for stratum connections on %s", listener.Addr())
+
+	errChan := make(chan error)
+	spawn("templatesLoop", func() {
+		templatesLoop(client, stratumServer, miningAddr, errChan)
+	})
+	if cfg.StatsInterval > 0 {
+		spawn("statsLoop", func() {
+			stratumServer.statsLoop(cfg.StatsInterval)
+		})
+	}
+
+	select {
+	case err := <-errChan:
+		panic(errors.Wrap(err, "error in templates loop"))
+	case <-interrupt:
+	}
+}
+
+func printErrorAndExit(err error) {
+	fmt.Fprintf(os.Stderr, "%+v\n", err)
+	os.Exit(1)
+}
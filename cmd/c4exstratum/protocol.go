@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// The stratum protocol is line-delimited JSON-RPC over TCP. The workers call the
+// mining.subscribe, mining.authorize and mining.submit methods, and the server
+// notifies them with mining.set_extranonce, mining.set_difficulty and mining.notify.
+const (
+	methodSubscribe           = "mining.subscribe"
+	methodExtranonceSubscribe = "mining.extranonce.subscribe"
+	methodAuthorize           = "mining.authorize"
+	methodSubmit              = "mining.submit"
+	methodSetExtranonce       = "mining.set_extranonce"
+	methodSetDifficulty       = "mining.set_difficulty"
+	methodNotify              = "mining.notify"
+
+	jsonRPCVersion  = "2.0"
+	protocolVersion = "EthereumStratum/1.0.0"
+)
+
+type request struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+type response struct {
+	ID      json.RawMessage `json:"id"`
+	JSONRPC string          `json:"jsonrpc"`
+	Result  interface{}     `json:"result"`
+	Error   []interface{}   `json:"error"`
+}
+
+type notification struct {
+	ID      interface{}   `json:"id"`
+	JSONRPC string        `json:"jsonrpc"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+// Error codes of the stratum protocol
+const (
+	errorCodeOther            = 20
+	errorCodeJobNotFound      = 21
+	errorCodeDuplicateShare   = 22
+	errorCodeLowDifficulty    = 23
+	errorCodeUnauthorized     = 24
+	errorCodeNotSubscribed    = 25
+	errorCodeInvalidParameter = 26
+)
+
+// stratumError is an error that is returned to the worker in the error field of a response
+type stratumError struct {
+	code    int
+	message string
+}
+
+func (e *stratumError) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.message, e.code)
+}
+
+func (e *stratumError) toJSON() []interface{} {
+	return []interface{}{e.code, e.message, nil}
+}
+
+func newStratumError(code int, format string, args ...interface{}) *stratumError {
+	return &stratumError{code: code, message: fmt.Sprintf(format, args...)}
+}
+
+func (r *request) stringParam(index int) (string, error) {
+	if index >= len(r.Params) {
+		return "", newStratumError(errorCodeInvalidParameter, "%s expects at least %d parameters", r.Method, index+1)
+	}
+	var value string
+	err := json.Unmarshal(r.Params[index], &value)
+	if err != nil {
+		return "", newStratumError(errorCodeInvalidParameter, "parameter %d of %s is not a string", index, r.Method)
+	}
+	return value, nil
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	nativeerrors "errors"
+	"math/big"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/c4ei/c4exd/app/appmessage"
+	"github.com/c4ei/c4exd/domain/consensus/model/externalapi"
+	"github.com/c4ei/c4exd/domain/consensus/utils/consensushashing"
+	"github.com/c4ei/c4exd/domain/consensus/utils/pow"
+	"github.com/pkg/errors"
+)
+
+// maxJobs is the number of most recent jobs shares are accepted for. Shares
+// for older jobs are stale.
+const maxJobs = 8
+
+// blockSubmitter submits the blocks the workers find to the node
+type blockSubmitter interface {
+	SubmitBlock(block *externalapi.DomainBlock) (appmessage.RejectReason, error)
+}
+
+// job is a block template handed out to the workers
+type job struct {
+	id              string
+	block           *externalapi.DomainBlock
+	state           *pow.State
+	submittedNonces map[uint64]struct{}
+}
+
+// workerStats are the share statistics of a worker, accumulated over all its connections
+type workerStats struct {
+	name     string
+	accepted uint64
+	rejected uint64
+	stale    uint64
+	blocks   uint64
+}
+
+type server struct {
+	submitter         blockSubmitter
+	shareDifficulty   float64
+	shareTarget       *big.Int
+	extranonceSize    int
+	idleTimeout       time.Duration
+	mineWhenNotSynced bool
+
+	lock           sync.Mutex
+	jobs           map[string]*job
+	jobIDs         []string
+	nextJobID      uint64
+	sessions       map[*session]struct{}
+	extranonces    map[uint64]struct{}
+	nextExtranonce uint64
+	workers        map[string]*workerStats
+}
+
+func newServer(submitter blockSubmitter, shareDifficulty float64, extranonceSize int, idleTimeout time.Duration,
+	mineWhenNotSynced bool) *server {
+
+	return &server{
+		submitter:         submitter,
+		shareDifficulty:   shareDifficulty,
+		shareTarget:       difficultyToTarget(shareDifficulty),
+		extranonceSize:    extranonceSize,
+		idleTimeout:       idleTimeout,
+		mineWhenNotSynced: mineWhenNotSynced,
+
+		jobs:        make(map[string]*job),
+		sessions:    make(map[*session]struct{}),
+		extranonces: make(map[uint64]struct{}),
+		workers:     make(map[string]*workerStats),
+	}
+}
+
+// listen starts accepting stratum connections on the given address. The returned
+// listener stops the server when closed.
+func (s *server) listen(address string) (net.Listener, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	spawn("acceptLoop", func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				if nativeerrors.Is(err, net.ErrClosed) {
+					return
+				}
+				log.Warnf("Error accepting a stratum connection: %s", err)
+				continue
+			}
+			session := newSession(s, conn)
+			spawn("session.handle", session.handle)
+		}
+	})
+	return listener, nil
+}
+
+// newTemplate makes the given block template the current job and hands it out to all the
+// authorized workers
+func (s *server) newTemplate(template *appmessage.GetBlockTemplateResponseMessage) error {
+	if !template.IsSynced && !s.mineWhenNotSynced {
+		log.Warnf("C4exd is not synced. Skipping current block template")
+		return nil
+	}
+
+	block, err := appmessage.RPCBlockToDomainBlock(template.Block)
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	newJob := &job{
+		id:              strconv.FormatUint(s.nextJobID, 10),
+		block:           block,
+		state:           pow.NewState(block.Header.ToMutable()),
+		submittedNonces: make(map[uint64]struct{}),
+	}
+	s.nextJobID++
+	s.jobs[newJob.id] = newJob
+	s.jobIDs = append(s.jobIDs, newJob.id)
+	if len(s.jobIDs) > maxJobs {
+		delete(s.jobs, s.jobIDs[0])
+		s.jobIDs = s.jobIDs[1:]
+	}
+	sessions := make([]*session, 0, len(s.sessions))
+	for session := range s.sessions {
+		sessions = append(sessions, session)
+	}
+	s.lock.Unlock()
+
+	for _, session := range sessions {
+		err := session.notifyJob(newJob)
+		if err != nil {
+			// The goroutine of the session cleans it up once reading from the closed connection fails
+			log.Debugf("Error sending job %s to %s: %s", newJob.id, session, err)
+			session.conn.Close()
+		}
+	}
+	return nil
+}
+
+func (s *server) allocateExtranonce() (uint64, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	numExtranonces := uint64(1) << (8 * s.extranonceSize)
+	for i := uint64(0); i < numExtranonces; i++ {
+		extranonce := (s.nextExtranonce + i) % numExtranonces
+		if _, isUsed := s.extranonces[extranonce]; isUsed {
+			continue
+		}
+		s.extranonces[extranonce] = struct{}{}
+		s.nextExtranonce = extranonce + 1
+		return extranonce, nil
+	}
+	return 0, errors.Errorf("all %d extranonces are in use", numExtranonces)
+}
+
+// authorize registers the session to receive jobs as the given worker, and returns
+// the stats of the worker and the current job, if any
+func (s *server) authorize(session *session, workerName string) (*workerStats, *job) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	worker, ok := s.workers[workerName]
+	if !ok {
+		worker = &workerStats{name: workerName}
+		s.workers[workerName] = worker
+	}
+	s.sessions[session] = struct{}{}
+
+	var currentJob *job
+	if len(s.jobIDs) > 0 {
+		currentJob = s.jobs[s.jobIDs[len(s.jobIDs)-1]]
+	}
+	return worker, currentJob
+}
+
+func (s *server) removeSession(session *session) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.sessions, session)
+	if session.hasExtranonce {
+		delete(s.extranonces, session.extranonce)
+	}
+}
+
+// extranonceShift is the position of the extranonce in the nonce: the extranonce
+// is the most significant bytes of the nonce, and the workers roll the rest
+func (s *server) extranonceShift() uint {
+	return uint(8 * (8 - s.extranonceSize))
+}
+
+// submitShare validates a share of the given worker, and returns the found block
+// if the share meets the block target as well
+func (s *server) submitShare(worker *workerStats, extranonce uint64, jobID string, nonce uint64) (
+	*externalapi.DomainBlock, error) {
+
+	s.lock.Lock()
+	shareJob, ok := s.jobs[jobID]
+	if !ok {
+		s.lock.Unlock()
+		atomic.AddUint64(&worker.stale, 1)
+		return nil, newStratumError(errorCodeJobNotFound, "job %s not found", jobID)
+	}
+	// Nonces outside the range of the worker are not recorded, so that they can't make the
+	// shares of the worker they belong to duplicates
+	if nonce>>s.extranonceShift() != extranonce {
+		s.lock.Unlock()
+		atomic.AddUint64(&worker.rejected, 1)
+		return nil, newStratumError(errorCodeOther, "nonce %016x is outside the range of extranonce %x",
+			nonce, extranonce)
+	}
+	_, isDuplicate := shareJob.submittedNonces[nonce]
+	shareJob.submittedNonces[nonce] = struct{}{}
+	s.lock.Unlock()
+
+	if isDuplicate {
+		atomic.AddUint64(&worker.rejected, 1)
+		return nil, newStratumError(errorCodeDuplicateShare, "duplicate share")
+	}
+
+	state := *shareJob.state
+	state.Nonce = nonce
+	powNum := state.CalculateProofOfWorkValue()
+	isBlock := powNum.Cmp(&state.Target) <= 0
+	if !isBlock && powNum.Cmp(s.shareTarget) > 0 {
+		atomic.AddUint64(&worker.rejected, 1)
+		return nil, newStratumError(errorCodeLowDifficulty, "low difficulty share")
+	}
+	atomic.AddUint64(&worker.accepted, 1)
+	if !isBlock {
+		return nil, nil
+	}
+
+	block := *shareJob.block
+	mutableHeader := block.Header.ToMutable()
+	mutableHeader.SetNonce(nonce)
+	block.Header = mutableHeader.ToImmutable()
+	return &block, nil
+}
+
+// submitBlock submits a block the given worker found to the node
+func (s *server) submitBlock(worker *workerStats, block *externalapi.DomainBlock) {
+	blockHash := consensushashing.BlockHash(block)
+	_, err := s.submitter.SubmitBlock(block)
+	if err != nil {
+		log.Warnf("Block %s found by worker %s was rejected: %s", blockHash, worker.name, err)
+		return
+	}
+	atomic.AddUint64(&worker.blocks, 1)
+	log.Infof("Submitted block %s found by worker %s", blockHash, worker.name)
+}
+
+func (s *server) statsLoop(interval time.Duration) {
+	for range time.Tick(interval) {
+		s.logStats()
+	}
+}
+
+func (s *server) logStats() {
+	s.lock.Lock()
+	workers := make([]*workerStats, 0, len(s.workers))
+	for _, worker := range s.workers {
+		workers = append(workers, worker)
+	}
+	numSessions := len(s.sessions)
+	s.lock.Unlock()
+
+	sort.Slice(workers, func(i, j int) bool { return workers[i].name < workers[j].name })
+	log.Infof("%d workers connected", numSessions)
+	for _, worker := range workers {
+		log.Infof("Worker %s: %d accepted, %d rejected and %d stale shares, %d blocks", worker.name,
+			atomic.LoadUint64(&worker.accepted), atomic.LoadUint64(&worker.rejected),
+			atomic.LoadUint64(&worker.stale), atomic.LoadUint64(&worker.blocks))
+	}
+}
+
+// diffOneTarget is the share target of difficulty 1, which takes 2^32 hashes on average to meet
+var diffOneTarget = new(big.Int).Lsh(big.NewInt(1), 224)
+
+// maxTarget is the highest target of a share, which any hash meets
+var maxTarget = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+func difficultyToTarget(difficulty float64) *big.Int {
+	target, _ := new(big.Float).Quo(new(big.Float).SetInt(diffOneTarget), big.NewFloat(difficulty)).Int(nil)
+	if target.Cmp(maxTarget) > 0 {
+		return new(big.Int).Set(maxTarget)
+	}
+	return target
+}
@@ -0,0 +1,472 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/c4ei/c4exd/app/appmessage"
+	"github.com/c4ei/c4exd/domain/consensus/model/externalapi"
+	"github.com/c4ei/c4exd/domain/consensus/utils/blockheader"
+	"github.com/c4ei/c4exd/domain/consensus/utils/pow"
+	"github.com/c4ei/c4exd/domain/dagconfig"
+	"github.com/c4ei/c4exd/util/difficulty"
+)
+
+type fakeNode struct {
+	lock   sync.Mutex
+	blocks []*externalapi.DomainBlock
+}
+
+func (n *fakeNode) SubmitBlock(block *externalapi.DomainBlock) (appmessage.RejectReason, error) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.blocks = append(n.blocks, block)
+	return appmessage.RejectReasonNone, nil
+}
+
+func (n *fakeNode) submittedBlocks() []*externalapi.DomainBlock {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	return append([]*externalapi.DomainBlock{}, n.blocks...)
+}
+
+type stratumMessage struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+	Result json.RawMessage   `json:"result"`
+	Error  []interface{}     `json:"error"`
+}
+
+type fakeJob struct {
+	id         string
+	prePowHash []uint64
+	timestamp  int64
+}
+
+// fakeMiner is a stratum client that mines the jobs it gets by the block templates the test gives it
+type fakeMiner struct {
+	t          *testing.T
+	conn       net.Conn
+	scanner    *bufio.Scanner
+	nextID     int
+	extranonce uint64
+	difficulty float64
+	jobs       map[string]*fakeJob
+}
+
+func newFakeMiner(t *testing.T, address string) *fakeMiner {
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		t.Fatalf("Dial: %s", err)
+	}
+	return &fakeMiner{
+		t:       t,
+		conn:    conn,
+		scanner: bufio.NewScanner(conn),
+		jobs:    make(map[string]*fakeJob),
+	}
+}
+
+func (m *fakeMiner) read() *stratumMessage {
+	err := m.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err != nil {
+		m.t.Fatalf("SetReadDeadline: %s", err)
+	}
+	if !m.scanner.Scan() {
+		m.t.Fatalf("Error reading from the server: %v", m.scanner.Err())
+	}
+	message := &stratumMessage{}
+	err = json.Unmarshal(m.scanner.Bytes(), message)
+	if err != nil {
+		m.t.Fatalf("Unmarshal: %s", err)
+	}
+	if message.Method != "" {
+		m.handleNotification(message)
+	}
+	return message
+}
+
+func (m *fakeMiner) handleNotification(message *stratumMessage) {
+	switch message.Method {
+	case methodSetExtranonce:
+		var extranonceHex string
+		m.unmarshalParam(message, 0, &extranonceHex)
+		_, err := fmt.Sscanf(extranonceHex, "%x", &m.extranonce)
+		if err != nil {
+			m.t.Fatalf("Invalid extranonce %s: %s", extranonceHex, err)
+		}
+	case methodSetDifficulty:
+		m.unmarshalParam(message, 0, &m.difficulty)
+	case methodNotify:
+		job := &fakeJob{}
+		m.unmarshalParam(message, 0, &job.id)
+		m.unmarshalParam(message, 1, &job.prePowHash)
+		m.unmarshalParam(message, 2, &job.timestamp)
+		m.jobs[job.id] = job
+	default:
+		m.t.Fatalf("Unexpected notification %s", message.Method)
+	}
+}
+
+func (m *fakeMiner) unmarshalParam(message *stratumMessage, index int, value interface{}) {
+	err := json.Unmarshal(message.Params[index], value)
+	if err != nil {
+		m.t.Fatalf("Invalid parameter %d of %s: %s", index, message.Method, err)
+	}
+}
+
+// call sends a request and returns its response, handling the notifications sent before it
+func (m *fakeMiner) call(method string, params ...interface{}) *stratumMessage {
+	m.nextID++
+	requestJSON, err := json.Marshal(map[string]interface{}{"id": m.nextID, "method": method, "params": params})
+	if err != nil {
+		m.t.Fatalf("Marshal: %s", err)
+	}
+	_, err = m.conn.Write(append(requestJSON, '\n'))
+	if err != nil {
+		m.t.Fatalf("Write: %s", err)
+	}
+	for {
+		message := m.read()
+		if message.Method == "" {
+			if string(message.ID) != fmt.Sprint(m.nextID) {
+				m.t.Fatalf("Got a response with ID %s while expecting %d", message.ID, m.nextID)
+			}
+			return message
+		}
+	}
+}
+
+func (m *fakeMiner) waitForNotification(method string) {
+	for {
+		message := m.read()
+		if message.Method == method {
+			return
+		}
+		if message.Method == "" {
+			m.t.Fatalf("Got an unexpected response while waiting for %s", method)
+		}
+	}
+}
+
+func (m *fakeMiner) waitForJob(id string) *fakeJob {
+	for m.jobs[id] == nil {
+		m.waitForNotification(methodNotify)
+	}
+	return m.jobs[id]
+}
+
+func (m *fakeMiner) subscribeAndAuthorize(workerName string) {
+	expectResult(m.t, m.call(methodSubscribe, "fakeminer/1.0"))
+	m.waitForNotification(methodSetExtranonce)
+	expectResult(m.t, m.call(methodAuthorize, workerName, "x"))
+	m.waitForNotification(methodSetDifficulty)
+}
+
+// mine returns the first nonce in the extranonce range of the miner, starting from the
+// given rolled nonce, whose PoW value satisfies isWanted. The header is the header of
+// the template of the job, which the miner checks the job against.
+func (m *fakeMiner) mine(job *fakeJob, header externalapi.BlockHeader, extranonceSize int, from uint64,
+	isWanted func(powNum *big.Int) bool) uint64 {
+
+	state := pow.NewState(header.ToMutable())
+	prePowHash := state.PrePowHash().ByteArray()
+	for i, word := range job.prePowHash {
+		if word != binary.LittleEndian.Uint64(prePowHash[8*i:]) {
+			m.t.Fatalf("Job %s doesn't match the pre-PoW hash of its template", job.id)
+		}
+	}
+	if job.timestamp != state.Timestamp {
+		m.t.Fatalf("Job %s has timestamp %d while its template has timestamp %d", job.id, job.timestamp, state.Timestamp)
+	}
+
+	for i := from; ; i++ {
+		state.Nonce = m.extranonce<<(8*(8-extranonceSize)) | i
+		if isWanted(state.CalculateProofOfWorkValue()) {
+			return state.Nonce
+		}
+	}
+}
+
+func (m *fakeMiner) submit(workerName, jobID string, nonce uint64) *stratumMessage {
+	return m.call(methodSubmit, workerName, jobID, fmt.Sprintf("%016x", nonce))
+}
+
+func expectResult(t *testing.T, message *stratumMessage) {
+	t.Helper()
+	if message.Error != nil {
+		t.Fatalf("Got error %v", message.Error)
+	}
+}
+
+func expectError(t *testing.T, message *stratumMessage, code int) {
+	t.Helper()
+	if len(message.Error) == 0 {
+		t.Fatalf("Expected error code %d but got result %s", code, message.Result)
+	}
+	if message.Error[0] != float64(code) {
+		t.Fatalf("Expected error code %d but got %v", code, message.Error)
+	}
+}
+
+func newTemplate(target *big.Int, timestamp int64) (
+	externalapi.BlockHeader, *appmessage.GetBlockTemplateResponseMessage) {
+
+	genesis := dagconfig.SimnetParams.GenesisBlock
+	header := genesis.Header
+	templateHeader := blockheader.NewImmutableBlockHeader(header.Version(), header.Parents(), header.HashMerkleRoot(),
+		header.AcceptedIDMerkleRoot(), header.UTXOCommitment(), timestamp, difficulty.BigToCompact(target), 0,
+		header.DAAScore(), header.BlueScore(), header.BlueWork(), header.PruningPoint())
+	block := &externalapi.DomainBlock{Header: templateHeader, Transactions: genesis.Transactions}
+	return templateHeader, appmessage.NewGetBlockTemplateResponseMessage(appmessage.DomainBlockToRPCBlock(block), true)
+}
+
+func startServer(t *testing.T, node *fakeNode, shareDifficulty float64, extranonceSize int,
+	idleTimeout time.Duration) (*server, net.Listener) {
+
+	stratumServer := newServer(node, shareDifficulty, extranonceSize, idleTimeout, false)
+	listener, err := stratumServer.listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %s", err)
+	}
+	return stratumServer, listener
+}
+
+func TestShares(t *testing.T) {
+	const extranonceSize = 2
+	const workerName = "c4exsim:worker.rig1"
+
+	// Blocks are out of reach, while half of the hashes are shares
+	blockTarget := new(big.Int).Lsh(big.NewInt(1), 200)
+	shareTarget := new(big.Int).Lsh(big.NewInt(1), 255)
+	shareDifficulty := 1.0 / (1 << 31)
+
+	node := &fakeNode{}
+	stratumServer, listener := startServer(t, node, shareDifficulty, extranonceSize, 0)
+	defer listener.Close()
+
+	miner := newFakeMiner(t, listener.Addr().String())
+	defer miner.conn.Close()
+
+	expectError(t, miner.submit(workerName, "0", 0), errorCodeUnauthorized)
+	expectError(t, miner.call(methodAuthorize, workerName, "x"), errorCodeNotSubscribed)
+	miner.subscribeAndAuthorize(workerName)
+	if miner.difficulty != shareDifficulty {
+		t.Fatalf("Expected share difficulty %g but got %g", shareDifficulty, miner.difficulty)
+	}
+
+	header, template := newTemplate(blockTarget, 1000)
+	err := stratumServer.newTemplate(template)
+	if err != nil {
+		t.Fatalf("newTemplate: %s", err)
+	}
+	job := miner.waitForJob("0")
+
+	isShare := func(powNum *big.Int) bool { return powNum.Cmp(shareTarget) <= 0 }
+	share := miner.mine(job, header, extranonceSize, 0, isShare)
+	expectResult(t, miner.submit(workerName, job.id, share))
+	expectError(t, miner.submit(workerName, job.id, share), errorCodeDuplicateShare)
+
+	lowDifficultyShare := miner.mine(job, header, extranonceSize, 0, func(powNum *big.Int) bool { return !isShare(powNum) })
+	expectError(t, miner.submit(workerName, job.id, lowDifficultyShare), errorCodeLowDifficulty)
+
+	otherExtranonceShare := share ^ (1 << 63)
+	expectError(t, miner.submit(workerName, job.id, otherExtranonceShare), errorCodeOther)
+
+	expectError(t, miner.submit(workerName, "1234", share+1), errorCodeJobNotFound)
+
+	// A share without the extranonce is completed with the extranonce of the worker
+	const rolledNonceMask = 1<<(8*(8-extranonceSize)) - 1
+	secondShare := miner.mine(job, header, extranonceSize, share&rolledNonceMask+1, isShare)
+	expectResult(t, miner.call(methodSubmit, workerName, job.id,
+		fmt.Sprintf("%0*x", 2*(8-extranonceSize), secondShare&rolledNonceMask)))
+
+	worker := stratumServer.workers[workerName]
+	if worker.accepted != 2 || worker.rejected != 3 || worker.stale != 1 || worker.blocks != 0 {
+		t.Fatalf("Unexpected stats: %d accepted, %d rejected, %d stale, %d blocks",
+			worker.accepted, worker.rejected, worker.stale, worker.blocks)
+	}
+	if len(node.submittedBlocks()) != 0 {
+		t.Fatalf("Shares that don't meet the block target were submitted to the node")
+	}
+}
+
+func TestExtranoncePartitioning(t *testing.T) {
+	const extranonceSize = 1
+
+	node := &fakeNode{}
+	_, listener := startServer(t, node, 1, extranonceSize, 0)
+	defer listener.Close()
+
+	seenExtranonces := make(map[uint64]struct{})
+	miners := make([]*fakeMiner, 0, 1<<(8*extranonceSize))
+	for i := 0; i < 1<<(8*extranonceSize); i++ {
+		miner := newFakeMiner(t, listener.Addr().String())
+		defer miner.conn.Close()
+		miner.subscribeAndAuthorize(fmt.Sprintf("worker%d", i))
+		if _, ok := seenExtranonces[miner.extranonce]; ok {
+			t.Fatalf("Extranonce %x was given to two workers", miner.extranonce)
+		}
+		seenExtranonces[miner.extranonce] = struct{}{}
+		miners = append(miners, miner)
+	}
+
+	// The extranonce space is exhausted until a worker disconnects
+	miner := newFakeMiner(t, listener.Addr().String())
+	defer miner.conn.Close()
+	expectError(t, miner.call(methodSubscribe, "fakeminer/1.0"), errorCodeOther)
+
+	freedExtranonce := miners[0].extranonce
+	miners[0].conn.Close()
+	var message *stratumMessage
+	for i := 0; i < 100; i++ {
+		message = miner.call(methodSubscribe, "fakeminer/1.0")
+		if message.Error == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	expectResult(t, message)
+	miner.waitForNotification(methodSetExtranonce)
+	if miner.extranonce != freedExtranonce {
+		t.Fatalf("Expected the freed extranonce %x but got %x", freedExtranonce, miner.extranonce)
+	}
+}
+
+func TestSharesOfOtherExtranonces(t *testing.T) {
+	const extranonceSize = 2
+
+	blockTarget := new(big.Int).Lsh(big.NewInt(1), 200)
+	shareTarget := new(big.Int).Lsh(big.NewInt(1), 255)
+	shareDifficulty := 1.0 / (1 << 31)
+
+	node := &fakeNode{}
+	stratumServer, listener := startServer(t, node, shareDifficulty, extranonceSize, 0)
+	defer listener.Close()
+
+	victim := newFakeMiner(t, listener.Addr().String())
+	defer victim.conn.Close()
+	victim.subscribeAndAuthorize("victim")
+	attacker := newFakeMiner(t, listener.Addr().String())
+	defer attacker.conn.Close()
+	attacker.subscribeAndAuthorize("attacker")
+
+	header, template := newTemplate(blockTarget, 1000)
+	err := stratumServer.newTemplate(template)
+	if err != nil {
+		t.Fatalf("newTemplate: %s", err)
+	}
+	victimJob := victim.waitForJob("0")
+	attacker.waitForJob("0")
+
+	// A share in the range of the victim is rejected when the attacker submits it, and
+	// is still accepted from the victim afterwards
+	isShare := func(powNum *big.Int) bool { return powNum.Cmp(shareTarget) <= 0 }
+	share := victim.mine(victimJob, header, extranonceSize, 0, isShare)
+	expectError(t, attacker.submit("attacker", victimJob.id, share), errorCodeOther)
+	expectResult(t, victim.submit("victim", victimJob.id, share))
+}
+
+func TestIdleTimeout(t *testing.T) {
+	const extranonceSize = 1
+	const idleTimeout = 200 * time.Millisecond
+
+	node := &fakeNode{}
+	stratumServer, listener := startServer(t, node, 1, extranonceSize, idleTimeout)
+	defer listener.Close()
+
+	miner := newFakeMiner(t, listener.Addr().String())
+	defer miner.conn.Close()
+	miner.subscribeAndAuthorize("idle")
+
+	// Requests keep the connection open
+	for i := 0; i < 3; i++ {
+		time.Sleep(idleTimeout / 2)
+		expectResult(t, miner.call(methodExtranonceSubscribe))
+	}
+
+	err := miner.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err != nil {
+		t.Fatalf("SetReadDeadline: %s", err)
+	}
+	for miner.scanner.Scan() {
+	}
+	if miner.scanner.Err() != nil {
+		t.Fatalf("Expected the server to close the idle connection, but got %s", miner.scanner.Err())
+	}
+
+	// The extranonce of the closed connection is freed
+	for i := 0; i < 100; i++ {
+		stratumServer.lock.Lock()
+		numExtranonces := len(stratumServer.extranonces)
+		stratumServer.lock.Unlock()
+		if numExtranonces == 0 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("The extranonce of the idle connection was not freed")
+}
+
+func TestBlocksAndStaleShares(t *testing.T) {
+	const extranonceSize = 2
+	const workerName = "rig"
+
+	// Half of the hashes are blocks, and the share target is lower than the block target
+	blockTarget := new(big.Int).Lsh(big.NewInt(1), 255)
+
+	node := &fakeNode{}
+	stratumServer, listener := startServer(t, node, 1, extranonceSize, 0)
+	defer listener.Close()
+
+	header, template := newTemplate(blockTarget, 1000)
+	err := stratumServer.newTemplate(template)
+	if err != nil {
+		t.Fatalf("newTemplate: %s", err)
+	}
+
+	miner := newFakeMiner(t, listener.Addr().String())
+	defer miner.conn.Close()
+	miner.subscribeAndAuthorize(workerName)
+	// The current job is sent on authorization
+	job := miner.waitForJob("0")
+
+	isBlock := func(powNum *big.Int) bool { return powNum.Cmp(blockTarget) <= 0 }
+	nonce := miner.mine(job, header, extranonceSize, 0, isBlock)
+	expectResult(t, miner.submit(workerName, job.id, nonce))
+
+	blocks := node.submittedBlocks()
+	if len(blocks) != 1 {
+		t.Fatalf("Expected 1 submitted block but got %d", len(blocks))
+	}
+	if blocks[0].Header.Nonce() != nonce || blocks[0].Header.TimeInMilliseconds() != job.timestamp {
+		t.Fatalf("The submitted block doesn't have the nonce and timestamp of the share")
+	}
+	if !pow.CheckProofOfWorkByBits(blocks[0].Header.ToMutable()) {
+		t.Fatalf("The submitted block doesn't have a valid PoW")
+	}
+
+	// Shares are accepted for the most recent jobs only
+	for i := 1; i <= maxJobs; i++ {
+		_, template := newTemplate(blockTarget, int64(1000+i))
+		err := stratumServer.newTemplate(template)
+		if err != nil {
+			t.Fatalf("newTemplate: %s", err)
+		}
+		miner.waitForJob(fmt.Sprint(i))
+	}
+	expectError(t, miner.submit(workerName, job.id, nonce+1), errorCodeJobNotFound)
+
+	worker := stratumServer.workers[workerName]
+	if worker.accepted != 1 || worker.stale != 1 || worker.blocks != 1 {
+		t.Fatalf("Unexpected stats: %d accepted, %d stale, %d blocks", worker.accepted, worker.stale, worker.blocks)
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	writeTimeout   = 10 * time.Second
+	maxRequestSize = 64 * 1024
+)
+
+// session is a stratum connection of a worker
+type session struct {
+	server *server
+	conn   net.Conn
+
+	writeLock sync.Mutex
+	encoder   *json.Encoder
+	closeOnce sync.Once
+
+	// The fields below are only accessed by the goroutine that reads the requests of the session
+	hasExtranonce bool
+	extranonce    uint64
+	worker        *workerStats
+}
+
+func newSession(server *server, conn net.Conn) *session {
+	return &session{
+		server:  server,
+		conn:    conn,
+		encoder: json.NewEncoder(conn),
+	}
+}
+
+func (s *session) String() string {
+	return s.conn.RemoteAddr().String()
+}
+
+func (s *session) handle() {
+	log.Infof("Stratum connection from %s", s)
+	defer s.close()
+
+	scanner := bufio.NewScanner(s.conn)
+	scanner.Buffer(make([]byte, 0, 4096), maxRequestSize)
+	for {
+		// An idle connection is closed, so that it doesn't keep its extranonce forever
+		if s.server.idleTimeout > 0 {
+			err := s.conn.SetReadDeadline(time.Now().Add(s.server.idleTimeout))
+			if err != nil {
+				log.Debugf("Error setting the read deadline of %s: %s", s, err)
+				return
+			}
+		}
+		if !scanner.Scan() {
+			if errors.Is(scanner.Err(), os.ErrDeadlineExceeded) {
+				log.Infof("Closing idle stratum connection from %s", s)
+			}
+			return
+		}
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		req := &request{}
+		err := json.Unmarshal(line, req)
+		if err != nil {
+			log.Warnf("Malformed request from %s: %s", s, err)
+			return
+		}
+		err = s.handleRequest(req)
+		if err != nil {
+			log.Debugf("Error handling %s from %s: %s", req.Method, s, err)
+			return
+		}
+	}
+}
+
+// handleRequest handles a request of the worker. The returned error is a connection
+// error, the errors of the request itself are sent to the worker.
+func (s *session) handleRequest(req *request) error {
+	switch req.Method {
+	case methodSubscribe:
+		return s.handleSubscribe(req)
+	case methodExtranonceSubscribe:
+		return s.respond(req, true, nil)
+	case methodAuthorize:
+		return s.handleAuthorize(req)
+	case methodSubmit:
+		return s.handleSubmit(req)
+	default:
+		return s.respond(req, nil, newStratumError(errorCodeOther, "unknown method %s", req.Method))
+	}
+}
+
+func (s *session) handleSubscribe(req *request) error {
+	if !s.hasExtranonce {
+		extranonce, err := s.server.allocateExtranonce()
+		if err != nil {
+			return s.respond(req, nil, newStratumError(errorCodeOther, "%s", err))
+		}
+		s.extranonce = extranonce
+		s.hasExtranonce = true
+	}
+
+	err := s.respond(req, []interface{}{true, protocolVersion}, nil)
+	if err != nil {
+		return err
+	}
+	extranonceHex := fmt.Sprintf("%0*x", 2*s.server.extranonceSize, s.extranonce)
+	return s.notify(methodSetExtranonce, extranonceHex, 8-s.server.extranonceSize)
+}
+
+func (s *session) handleAuthorize(req *request) error {
+	if !s.hasExtranonce {
+		return s.respond(req, nil, newStratumError(errorCodeNotSubscribed, "not subscribed"))
+	}
+	workerName, err := req.stringParam(0)
+	if err != nil {
+		return s.respond(req, nil, err)
+	}
+	if workerName == "" {
+		return s.respond(req, nil, newStratumError(errorCodeInvalidParameter, "the worker name must not be empty"))
+	}
+
+	worker, currentJob := s.server.authorize(s, workerName)
+	s.worker = worker
+	log.Infof("Worker %s authorized from %s with extranonce %x", workerName, s, s.extranonce)
+
+	err = s.respond(req, true, nil)
+	if err != nil {
+		return err
+	}
+	err = s.notify(methodSetDifficulty, s.server.shareDifficulty)
+	if err != nil {
+		return err
+	}
+	if currentJob == nil {
+		return nil
+	}
+	return s.notifyJob(currentJob)
+}
+
+func (s *session) handleSubmit(req *request) error {
+	if s.worker == nil {
+		return s.respond(req, nil, newStratumError(errorCodeUnauthorized, "unauthorized worker"))
+	}
+	jobID, err := req.stringParam(1)
+	if err != nil {
+		return s.respond(req, nil, err)
+	}
+	nonceString, err := req.stringParam(2)
+	if err != nil {
+		return s.respond(req, nil, err)
+	}
+	nonce, err := s.parseNonce(nonceString)
+	if err != nil {
+		return s.respond(req, nil, err)
+	}
+
+	block, err := s.server.submitShare(s.worker, s.extranonce, jobID, nonce)
+	if err != nil {
+		log.Debugf("Rejected share of worker %s for job %s: %s", s.worker.name, jobID, err)
+		return s.respond(req, nil, err)
+	}
+	err = s.respond(req, true, nil)
+	if block != nil {
+		s.server.submitBlock(s.worker, block)
+	}
+	return err
+}
+
+// parseNonce parses a nonce in hex. Workers may send either the whole nonce,
+// or only the part they roll, without the extranonce.
+func (s *session) parseNonce(nonceString string) (uint64, error) {
+	nonceString = strings.TrimPrefix(nonceString, "0x")
+	nonce, err := strconv.ParseUint(nonceString, 16, 64)
+	if err != nil {
+		return 0, newStratumError(errorCodeInvalidParameter, "invalid nonce %s", nonceString)
+	}
+	if len(nonceString) <= 2*(8-s.server.extranonceSize) {
+		nonce |= s.extranonce << s.server.extranonceShift()
+	}
+	return nonce, nil
+}
+
+// notifyJob sends a job to the worker. A job is the pre-PoW hash of the block
+// template as four little-endian 64 bit words, and the timestamp of the template.
+func (s *session) notifyJob(job *job) error {
+	prePowHash := job.state.PrePowHash().ByteArray()
+	words := make([]uint64, 4)
+	for i := range words {
+		words[i] = binary.LittleEndian.Uint64(prePowHash[8*i:])
+	}
+	return s.notify(methodNotify, job.id, words, job.state.Timestamp)
+}
+
+func (s *session) respond(req *request, result interface{}, err error) error {
+	resp := &response{
+		ID:      req.ID,
+		JSONRPC: jsonRPCVersion,
+		Result:  result,
+	}
+	if err != nil {
+		stratumErr := &stratumError{}
+		if !errors.As(err, &stratumErr) {
+			stratumErr = newStratumError(errorCodeOther, "%s", err)
+		}
+		resp.Error = stratumErr.toJSON()
+	}
+	return s.send(resp)
+}
+
+func (s *session) notify(method string, params ...interface{}) error {
+	return s.send(&notification{
+		JSONRPC: jsonRPCVersion,
+		Method:  method,
+		Params:  params,
+	})
+}
+
+func (s *session) send(message interface{}) error {
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+
+	err := s.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if err != nil {
+		return err
+	}
+	return s.encoder.Encode(message)
+}
+
+func (s *session) close() {
+	s.closeOnce.Do(func() {
+		s.server.removeSession(s)
+		s.conn.Close()
+		log.Infof("Stratum connection from %s closed", s)
+	})
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	nativeerrors "errors"
+	"time"
+
+	"github.com/c4ei/c4exd/infrastructure/network/netadapter/router"
+	"github.com/c4ei/c4exd/util"
+	"github.com/c4ei/c4exd/version"
+	"github.com/pkg/errors"
+)
+
+// templateRefreshInterval is the interval between jobs when no new block template is
+// announced, so the workers keep mining on templates with recent timestamps and transactions
+const templateRefreshInterval = 5 * time.Second
+
+func templatesLoop(client *nodeClient, stratumServer *server, miningAddr util.Address, errChan chan error) {
+	getBlockTemplate := func() {
+		template, err := client.GetBlockTemplate(miningAddr.String(), "c4exstratum-"+version.Version())
+		if nativeerrors.Is(err, router.ErrTimeout) {
+			log.Warnf("Got timeout while requesting block template from %s: %s", client.Address(), err)
+			reconnectErr := client.Reconnect()
+			if reconnectErr != nil {
+				errChan <- reconnectErr
+			}
+			return
+		}
+		if nativeerrors.Is(err, router.ErrRouteClosed) {
+			log.Debugf("Got route is closed while requesting block template from %s. "+
+				"The client is most likely reconnecting", client.Address())
+			return
+		}
+		if err != nil {
+			errChan <- errors.Wrapf(err, "Error getting block template from %s", client.Address())
+			return
+		}
+		err = stratumServer.newTemplate(template)
+		if err != nil {
+			errChan <- errors.Wrapf(err, "Error setting block template from %s", client.Address())
+			return
+		}
+	}
+
+	getBlockTemplate()
+	ticker := time.NewTicker(templateRefreshInterval)
+	for {
+		select {
+		case <-client.newBlockTemplateNotificationChan:
+			getBlockTemplate()
+			ticker.Reset(templateRefreshInterval)
+		case <-ticker.C:
+			getBlockTemplate()
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/c4ei/c4exd/cmd/c4exwallet/daemon/client"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/daemon/pb"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/keys"
+)
+
+func createAccount(conf *createAccountConfig) error {
+	keysFile, err := keys.ReadKeysFile(conf.NetParams(), conf.KeysFile)
+	if err != nil {
+		return err
+	}
+
+	err = keys.ValidateAccountName(conf.Name)
+	if err != nil {
+		return err
+	}
+
+	// The keys of the new account are derived from the private keys, unless the wallet has none
+	if len(conf.Password) == 0 && !keysFile.IsWatchOnly() && !keysFile.HasExternalSigner() {
+		conf.Password = keys.GetPassword("Password:")
+	}
+
+	daemonClient, tearDown, err := client.Connect(conf.DaemonAddress, conf.securityOptions())
+	if err != nil {
+		return err
+	}
+	defer tearDown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), daemonTimeout)
+	defer cancel()
+
+	response, err := daemonClient.CreateAccount(ctx, &pb.CreateAccountRequest{
+		Name:               conf.Name,
+		Password:           conf.Password,
+		ExtendedPublicKeys: conf.XPubs,
+	})
+	if err != nil {
+		return err
+	}
+
+	account := response.Account
+	fmt.Printf("Created account %d (%s)\n", account.Index, account.Name)
+	for i, extendedPublicKey := range account.ExtendedPublicKeys {
+		fmt.Printf("Extended public key #%d:\n%s\n", i+1, extendedPublicKey)
+	}
+	return nil
+}
+
+func listAccounts(conf *listAccountsConfig) error {
+	daemonClient, tearDown, err := client.Connect(conf.DaemonAddress, conf.securityOptions())
+	if err != nil {
+		return err
+	}
+	defer tearDown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), daemonTimeout)
+	defer cancel()
+
+	response, err := daemonClient.ListAccounts(ctx, &pb.ListAccountsRequest{})
+	if err != nil {
+		return err
+	}
+	balanceResponse, err := daemonClient.GetBalance(ctx, &pb.GetBalanceRequest{})
+	if err != nil {
+		return err
+	}
+
+	printAccountBalances(balanceResponse.AccountBalances)
+	if conf.Verbose {
+		for _, account := range response.Accounts {
+			fmt.Printf("\nAccount %d (%s), last used indexes: external %d, internal %d\n", account.Index, account.Name,
+				account.LastUsedExternalIndex, account.LastUsedInternalIndex)
+			for i, extendedPublicKey := range account.ExtendedPublicKeys {
+				fmt.Printf("Extended public key #%d:\n%s\n", i+1, extendedPublicKey)
+			}
+		}
+	}
+	return nil
+}
@@ -10,7 +10,7 @@ import (
 )
 
 func balance(conf *balanceConfig) error {
-	daemonClient, tearDown, err := client.Connect(conf.DaemonAddress)
+	daemonClient, tearDown, err := client.Connect(conf.DaemonAddress, conf.securityOptions())
 	if err != nil {
 		return err
 	}
@@ -18,7 +18,10 @@ func balance(conf *balanceConfig) error {
 
 	ctx, cancel := context.WithTimeout(context.Background(), daemonTimeout)
 	defer cancel()
-	response, err := daemonClient.GetBalance(ctx, &pb.GetBalanceRequest{})
+	response, err := daemonClient.GetBalance(ctx, &pb.GetBalanceRequest{
+		Account:          conf.Account,
+		MinConfirmations: conf.MinConfirmations,
+	})
 	if err != nil {
 		return err
 	}
@@ -29,15 +32,58 @@ func balance(conf *balanceConfig) error {
 	}
 	if conf.Verbose {
 		pendingSuffix = ""
-		println("Address                                                                       Available             Pending")
-		println("-----------------------------------------------------------------------------------------------------------")
+		println("Address                                                                       Available             Pending            Incoming            Outgoing")
+		println("-------------------------------------------------------------------------------------------------------------------------------------------------------")
 		for _, addressBalance := range response.AddressBalances {
-			fmt.Printf("%s %s %s\n", addressBalance.Address, utils.FormatC4x(addressBalance.Available), utils.FormatC4x(addressBalance.Pending))
+			fmt.Printf("%s %s %s %s %s\n", addressBalance.Address, utils.FormatC4x(addressBalance.Available),
+				utils.FormatC4x(addressBalance.Pending), utils.FormatC4x(addressBalance.UnconfirmedIncoming),
+				utils.FormatC4x(addressBalance.OutgoingInFlight))
 		}
-		println("-----------------------------------------------------------------------------------------------------------")
+		println("-------------------------------------------------------------------------------------------------------------------------------------------------------")
 		print("                                                 ")
 	}
+	if conf.Account != "" {
+		accountBalance := response.AccountBalances[0]
+		fmt.Printf("Balance of account %d (%s), C4X %s %s%s\n", accountBalance.Index, accountBalance.Name,
+			utils.FormatC4x(response.Available), utils.FormatC4x(response.Pending), pendingSuffix)
+		printBalanceBreakdown(response, conf.MinConfirmations)
+		return nil
+	}
 	fmt.Printf("Total balance, C4X %s %s%s\n", utils.FormatC4x(response.Available), utils.FormatC4x(response.Pending), pendingSuffix)
+	printBalanceBreakdown(response, conf.MinConfirmations)
+
+	// A breakdown is only interesting for a wallet with several accounts
+	if len(response.AccountBalances) > 1 {
+		fmt.Println()
+		printAccountBalances(response.AccountBalances)
+	}
 
 	return nil
 }
+
+// printBalanceBreakdown prints the parts of the balance that are not available yet, if there are any
+func printBalanceBreakdown(response *pb.GetBalanceResponse, minConfirmations uint64) {
+	lines := []struct {
+		title  string
+		amount uint64
+	}{
+		{"Immature coinbase", response.ImmatureCoinbase},
+		{fmt.Sprintf("Confirming (fewer than %d confirmations)", minConfirmations), response.Confirming},
+		{"Unconfirmed incoming (in the mempool)", response.UnconfirmedIncoming},
+		{"Outgoing in flight (in the mempool)", response.OutgoingInFlight},
+	}
+	for _, line := range lines {
+		if line.amount > 0 {
+			fmt.Printf("  %-41s %s\n", line.title, utils.FormatC4x(line.amount))
+		}
+	}
+}
+
+func printAccountBalances(accountBalances []*pb.AccountBalance) {
+	fmt.Printf("%-7s %-24s %19s %19s %19s %19s\n", "Index", "Account", "Available", "Pending", "Incoming", "Outgoing")
+	for _, accountBalance := range accountBalances {
+		fmt.Printf("%-7d %-24s %s %s %s %s\n", accountBalance.Index, accountBalance.Name,
+			utils.FormatC4x(accountBalance.Available), utils.FormatC4x(accountBalance.Pending),
+			utils.FormatC4x(accountBalance.UnconfirmedIncoming), utils.FormatC4x(accountBalance.OutgoingInFlight))
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+
+	"github.com/c4ei/c4exd/cmd/c4exwallet/keys"
+	"github.com/pkg/errors"
+)
+
+func changePassword(conf *changePasswordConfig) error {
+	keysFile, err := keys.ReadKeysFile(conf.NetParams(), conf.KeysFile)
+	if err != nil {
+		return err
+	}
+	if keysFile.IsWatchOnly() {
+		return errors.New("the wallet is watch-only and has no private keys to protect with a password")
+	}
+	if keysFile.HasExternalSigner() {
+		return keys.ErrExternalSigner
+	}
+
+	// A running daemon keeps the keys file locked, and would overwrite the new encrypted keys with
+	// the old ones the next time it saves the file
+	err = keysFile.TryLock()
+	if err != nil {
+		return err
+	}
+
+	if len(conf.Password) == 0 {
+		conf.Password = keys.GetPassword("Current password:")
+	}
+	if len(conf.NewPassword) == 0 {
+		newPassword := []byte(keys.GetPassword("New password:"))
+		confirmPassword := []byte(keys.GetPassword("Confirm new password:"))
+		if subtle.ConstantTimeCompare(newPassword, confirmPassword) != 1 {
+			return errors.New("Passwords are not identical")
+		}
+		conf.NewPassword = string(newPassword)
+	}
+
+	err = keysFile.ChangePassword(conf.Password, conf.NewPassword, conf.kdfParams())
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Changed the password of %s\n", keysFile.Path())
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet"
+	"github.com/pkg/errors"
+)
+
+func combine(conf *combineConfig) error {
+	transactionsHexes := conf.Transactions
+	for _, transactionFile := range conf.TransactionFiles {
+		transactionHexBytes, err := ioutil.ReadFile(transactionFile)
+		if err != nil {
+			return errors.Wrapf(err, "Could not read hex from %s", transactionFile)
+		}
+		transactionsHexes = append(transactionsHexes, strings.TrimSpace(string(transactionHexBytes)))
+	}
+	if len(transactionsHexes) < 2 {
+		return errors.Errorf("At least two copies of the transaction(s) are required, " +
+			"passed with --transaction or --transaction-file")
+	}
+
+	// Every copy may have several transactions, and they are combined with the same transactions of the other copies
+	var copies [][][]byte
+	for i, transactionsHex := range transactionsHexes {
+		transactions, err := decodeTransactionsFromHex(transactionsHex)
+		if err != nil {
+			return err
+		}
+		if len(copies) > 0 && len(transactions) != len(copies[0]) {
+			return errors.Errorf("Copy #%d has %d transactions, while copy #1 has %d", i+1, len(transactions),
+				len(copies[0]))
+		}
+		copies = append(copies, transactions)
+	}
+
+	combinedTransactions := make([][]byte, len(copies[0]))
+	areAllTransactionsFullySigned := true
+	for i := range combinedTransactions {
+		transactionCopies := make([][]byte, len(copies))
+		for j, transactions := range copies {
+			transactionCopies[j] = transactions[i]
+		}
+
+		var invalidSignatures []string
+		var err error
+		combinedTransactions[i], invalidSignatures, err = libc4exwallet.Combine(transactionCopies)
+		if err != nil {
+			return errors.Wrapf(err, "Could not combine transaction #%d", i+1)
+		}
+		for _, invalidSignature := range invalidSignatures {
+			fmt.Fprintf(os.Stderr, "Dropped an invalid signature of transaction #%d: %s\n", i+1, invalidSignature)
+		}
+
+		isFullySigned, err := libc4exwallet.IsTransactionFullySigned(combinedTransactions[i])
+		if err != nil {
+			return err
+		}
+		if !isFullySigned {
+			areAllTransactionsFullySigned = false
+		}
+	}
+
+	if areAllTransactionsFullySigned {
+		fmt.Fprintln(os.Stderr, "The transaction is signed and ready to broadcast")
+	} else {
+		fmt.Fprintln(os.Stderr, "Successfully combined the signatures. More signatures are required, "+
+			"use `parse --signatures` to see which")
+	}
+
+	fmt.Println(encodeTransactionsToHex(combinedTransactions))
+	return nil
+}
@@ -1,9 +1,17 @@
 package main
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 	"time"
+
+	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet/serialization"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/utils"
+	"github.com/c4ei/c4exd/domain/consensus/utils/consensushashing"
+	"github.com/pkg/errors"
 )
 
 const daemonTimeout = 2 * time.Minute
@@ -12,3 +20,51 @@ func printErrorAndExit(err error) {
 	fmt.Fprintf(os.Stderr, "%s\n", err)
 	os.Exit(1)
 }
+
+// confirm asks the user the given question, and returns an error unless they answer "y"
+func confirm(question string) error {
+	reader := bufio.NewReader(os.Stdin)
+	fmt.Printf("%s (y/N)? ", question)
+	line, err := utils.ReadLine(reader)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+
+	if line != "y" {
+		return errors.Errorf("Aborted by user")
+	}
+
+	return nil
+}
+
+// printTransactionsSummary prints the inputs, outputs and fee of each of the given
+// unsigned transactions, followed by their total fee
+func printTransactionsSummary(output io.Writer, unsignedTransactions [][]byte) error {
+	totalFee := uint64(0)
+	for i, unsignedTransaction := range unsignedTransactions {
+		transaction, err := serialization.DeserializePartiallySignedTransaction(unsignedTransaction)
+		if err != nil {
+			return err
+		}
+
+		inputsValue := uint64(0)
+		for _, input := range transaction.PartiallySignedInputs {
+			inputsValue += input.PrevOutput.Value
+		}
+		outputsValue := uint64(0)
+		for _, output := range transaction.Tx.Outputs {
+			outputsValue += output.Value
+		}
+		fee := inputsValue - outputsValue
+		totalFee += fee
+
+		fmt.Fprintf(output, "\t#%d %s: %d inputs, %d outputs, fee %s C4X\n", i+1,
+			consensushashing.TransactionID(transaction.Tx), len(transaction.Tx.Inputs), len(transaction.Tx.Outputs),
+			strings.TrimSpace(utils.FormatC4x(fee)))
+	}
+
+	fmt.Fprintf(output, "Total fee: %s C4X\n", strings.TrimSpace(utils.FormatC4x(totalFee)))
+	return nil
+}
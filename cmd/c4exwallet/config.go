@@ -2,8 +2,15 @@ package main
 
 import (
 	"os"
+	"time"
 
+	"github.com/c4ei/c4exd/cmd/c4exwallet/daemon/client"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/keys"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/signer"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/utils"
 	"github.com/c4ei/c4exd/infrastructure/config"
+	"github.com/c4ei/c4exd/util"
 	"github.com/pkg/errors"
 
 	"github.com/jessevdk/go-flags"
@@ -22,6 +29,22 @@ const (
 	newAddressSubCmd                = "new-address"
 	dumpUnencryptedDataSubCmd       = "dump-unencrypted-data"
 	startDaemonSubCmd               = "start-daemon"
+	historySubCmd                   = "history"
+	listUTXOsSubCmd                 = "list-utxos"
+	freezeSubCmd                    = "freeze"
+	unfreezeSubCmd                  = "unfreeze"
+	consolidateSubCmd               = "consolidate"
+	combineSubCmd                   = "combine"
+	finalizeSubCmd                  = "finalize"
+	softwareSignerSubCmd            = "software-signer"
+	createAccountSubCmd             = "create-account"
+	listAccountsSubCmd              = "list-accounts"
+	signMessageSubCmd               = "sign-message"
+	verifyMessageSubCmd             = "verify-message"
+	changePasswordSubCmd            = "change-password"
+	exportXPubSubCmd                = "export-xpub"
+	createPaymentRequestSubCmd      = "create-payment-request"
+	paymentRequestStatusSubCmd      = "payment-request-status"
 )
 
 const (
@@ -33,21 +56,63 @@ type configFlags struct {
 	config.NetworkFlags
 }
 
+// DaemonSecurityFlags are the flags of the commands that connect to a daemon that uses TLS or authentication
+type DaemonSecurityFlags struct {
+	DaemonTLS           bool   `long:"daemon-tls" description:"Connect to the wallet daemon with TLS"`
+	DaemonCAFile        string `long:"daemon-ca-file" description:"A PEM file of the certificate authorities to verify the certificate of the wallet daemon with, e.g. its self-signed certificate (implies --daemon-tls)"`
+	DaemonClientCert    string `long:"daemon-client-cert" description:"A client certificate to authenticate to the wallet daemon with (implies --daemon-tls)"`
+	DaemonClientKey     string `long:"daemon-client-key" description:"The private key of --daemon-client-cert"`
+	DaemonAuthTokenFile string `long:"daemon-auth-token-file" description:"A file that contains the bearer token to authenticate to the wallet daemon with"`
+}
+
+func (dsf *DaemonSecurityFlags) securityOptions() *client.SecurityOptions {
+	return &client.SecurityOptions{
+		UseTLS:         dsf.DaemonTLS,
+		CAFile:         dsf.DaemonCAFile,
+		ClientCertFile: dsf.DaemonClientCert,
+		ClientKeyFile:  dsf.DaemonClientKey,
+		AuthTokenFile:  dsf.DaemonAuthTokenFile,
+	}
+}
+
+// KDFFlags are the flags of the commands that encrypt the mnemonics of the keys file. The key
+// that encrypts a mnemonic is derived from the password with argon2id.
+type KDFFlags struct {
+	KDFTime    uint32 `long:"kdf-time" description:"The number of passes of argon2 over its memory when deriving the encryption key from the password" default:"1"`
+	KDFMemory  uint32 `long:"kdf-memory" description:"The memory argon2 uses when deriving the encryption key from the password, in KiB" default:"65536"`
+	KDFThreads uint8  `long:"kdf-threads" description:"The number of threads argon2 uses when deriving the encryption key from the password. The same number is needed to decrypt the keys" default:"8"`
+}
+
+func (kf *KDFFlags) kdfParams() keys.KDFParams {
+	return keys.KDFParams{
+		Time:    kf.KDFTime,
+		Memory:  kf.KDFMemory,
+		Threads: kf.KDFThreads,
+	}
+}
+
 type createConfig struct {
-	KeysFile          string `long:"keys-file" short:"f" description:"Keys file location (default: ~/.c4exwallet/keys.json (*nix), %USERPROFILE%\\AppData\\Local\\C4exwallet\\key.json (Windows))"`
-	Password          string `long:"password" short:"p" description:"Wallet password"`
-	Yes               bool   `long:"yes" short:"y" description:"Assume \"yes\" to all questions"`
-	MinimumSignatures uint32 `long:"min-signatures" short:"m" description:"Minimum required signatures" default:"1"`
-	NumPrivateKeys    uint32 `long:"num-private-keys" short:"k" description:"Number of private keys" default:"1"`
-	NumPublicKeys     uint32 `long:"num-public-keys" short:"n" description:"Total number of keys" default:"1"`
-	ECDSA             bool   `long:"ecdsa" description:"Create an ECDSA wallet"`
-	Import            bool   `long:"import" short:"i" description:"Import private keys (as opposed to generating them)"`
+	KeysFile          string   `long:"keys-file" short:"f" description:"Keys file location (default: ~/.c4exwallet/keys.json (*nix), %USERPROFILE%\\AppData\\Local\\C4exwallet\\key.json (Windows))"`
+	Password          string   `long:"password" short:"p" description:"Wallet password"`
+	Yes               bool     `long:"yes" short:"y" description:"Assume \"yes\" to all questions"`
+	MinimumSignatures uint32   `long:"min-signatures" short:"m" description:"Minimum required signatures" default:"1"`
+	NumPrivateKeys    uint32   `long:"num-private-keys" short:"k" description:"Number of private keys" default:"1"`
+	NumPublicKeys     uint32   `long:"num-public-keys" short:"n" description:"Total number of keys" default:"1"`
+	ECDSA             bool     `long:"ecdsa" description:"Create an ECDSA wallet"`
+	Import            bool     `long:"import" short:"i" description:"Import private keys (as opposed to generating them)"`
+	WatchOnly         bool     `long:"watch-only" description:"Create a watch-only wallet from extended public keys, without any private keys"`
+	XPubs             []string `long:"xpub" description:"An extended public key of a watch-only wallet. Use multiple times for a multisig wallet, whose keys must be the multisig extended public keys of the cosigners"`
+	ExternalSigner    string   `long:"external-signer" description:"Take the wallet's own keys from an external signer, which signs for the wallet instead of keeping private keys in the keys file. Either exec:<command> to talk to the command over its stdin and stdout, or unix:<path> to connect to a Unix socket"`
+	KDFFlags
 	config.NetworkFlags
 }
 
 type balanceConfig struct {
-	DaemonAddress string `long:"daemonaddress" short:"d" description:"Wallet daemon server to connect to"`
-	Verbose       bool   `long:"verbose" short:"v" description:"Verbose: show addresses with balance"`
+	DaemonAddress    string `long:"daemonaddress" short:"d" description:"Wallet daemon server to connect to"`
+	Verbose          bool   `long:"verbose" short:"v" description:"Verbose: show addresses with balance"`
+	Account          string `long:"account" description:"Show only the balance of the account with this name or index"`
+	MinConfirmations uint64 `long:"min-confirmations" description:"Count UTXOs with fewer confirmations (in DAA score) as confirming rather than available"`
+	DaemonSecurityFlags
 	config.NetworkFlags
 }
 
@@ -55,29 +120,47 @@ type sendConfig struct {
 	KeysFile                 string   `long:"keys-file" short:"f" description:"Keys file location (default: ~/.c4exwallet/keys.json (*nix), %USERPROFILE%\\AppData\\Local\\C4exwallet\\key.json (Windows))"`
 	Password                 string   `long:"password" short:"p" description:"Wallet password"`
 	DaemonAddress            string   `long:"daemonaddress" short:"d" description:"Wallet daemon server to connect to"`
-	ToAddress                string   `long:"to-address" short:"t" description:"The public address to send C4ex to" required:"true"`
+	ToAddress                string   `long:"to-address" short:"t" description:"The public address to send C4ex to"`
 	FromAddresses            []string `long:"from-address" short:"a" description:"Specific public address to send C4ex from. Use multiple times to accept several addresses" required:"false"`
-	SendAmount               float64  `long:"send-amount" short:"v" description:"An amount to send in C4ex (e.g. 1234.12345678)"`
+	SendAmount               string   `long:"send-amount" short:"v" description:"An amount to send in C4ex (e.g. 1234.12345678)"`
 	IsSendAll                bool     `long:"send-all" description:"Send all the C4ex in the wallet (mutually exclusive with --send-amount)"`
 	UseExistingChangeAddress bool     `long:"use-existing-change-address" short:"u" description:"Will use an existing change address (in case no change address was ever used, it will use a new one)"`
 	Verbose                  bool     `long:"show-serialized" short:"s" description:"Show a list of hex encoded sent transactions"`
+	UTXOs                    []string `long:"utxo" description:"A UTXO to spend, in the form <transaction ID>:<index>. Use multiple times to spend several UTXOs. If used, exactly the given UTXOs are spent" required:"false"`
+	RecipientsFile           string   `long:"recipients-file" short:"r" description:"A CSV (address,amount per line) or JSON ([{\"address\": ..., \"amount\": ...}]) file of recipients to pay in a batch, with amounts in C4ex (mutually exclusive with --to-address)"`
+	Yes                      bool     `long:"yes" short:"y" description:"Send a batch payment without asking for confirmation"`
+	Account                  string   `long:"account" description:"The name or index of the account to spend from (default: the default account)"`
+	URI                      string   `long:"uri" description:"A payment URI to pay, such as c4ex:qr...?amount=1.5, which gives the address and usually the amount to send (mutually exclusive with --to-address)"`
+	MinConfirmations         uint64   `long:"min-confirmations" description:"Spend only UTXOs with at least this many confirmations (in DAA score)"`
+	DaemonSecurityFlags
 	config.NetworkFlags
+
+	sendAmountSompi uint64
+	paymentURI      *util.PaymentURI
 }
 
 type sweepConfig struct {
 	PrivateKey    string `long:"private-key" short:"k" description:"Private key in hex format"`
 	DaemonAddress string `long:"daemonaddress" short:"d" description:"Wallet daemon server to connect to"`
+	Account       string `long:"account" description:"The name or index of the account to send the funds to (default: the default account)"`
+	DaemonSecurityFlags
 	config.NetworkFlags
 }
 
 type createUnsignedTransactionConfig struct {
 	DaemonAddress            string   `long:"daemonaddress" short:"d" description:"Wallet daemon server to connect to"`
-	ToAddress                string   `long:"to-address" short:"t" description:"The public address to send C4ex to" required:"true"`
+	ToAddress                string   `long:"to-address" short:"t" description:"The public address to send C4ex to"`
 	FromAddresses            []string `long:"from-address" short:"a" description:"Specific public address to send C4ex from. Use multiple times to accept several addresses" required:"false"`
-	SendAmount               float64  `long:"send-amount" short:"v" description:"An amount to send in C4ex (e.g. 1234.12345678)"`
+	SendAmount               string   `long:"send-amount" short:"v" description:"An amount to send in C4ex (e.g. 1234.12345678)"`
 	IsSendAll                bool     `long:"send-all" description:"Send all the C4ex in the wallet (mutually exclusive with --send-amount)"`
 	UseExistingChangeAddress bool     `long:"use-existing-change-address" short:"u" description:"Will use an existing change address (in case no change address was ever used, it will use a new one)"`
+	UTXOs                    []string `long:"utxo" description:"A UTXO to spend, in the form <transaction ID>:<index>. Use multiple times to spend several UTXOs. If used, exactly the given UTXOs are spent" required:"false"`
+	RecipientsFile           string   `long:"recipients-file" short:"r" description:"A CSV (address,amount per line) or JSON ([{\"address\": ..., \"amount\": ...}]) file of recipients to pay in a batch, with amounts in C4ex (mutually exclusive with --to-address)"`
+	Account                  string   `long:"account" description:"The name or index of the account to spend from (default: the default account)"`
+	DaemonSecurityFlags
 	config.NetworkFlags
+
+	sendAmountSompi uint64
 }
 
 type signConfig struct {
@@ -88,10 +171,42 @@ type signConfig struct {
 	config.NetworkFlags
 }
 
+type signMessageConfig struct {
+	KeysFile      string `long:"keys-file" short:"f" description:"Keys file location (default: ~/.c4exwallet/keys.json (*nix), %USERPROFILE%\\AppData\\Local\\C4exwallet\\key.json (Windows))"`
+	Password      string `long:"password" short:"p" description:"Wallet password"`
+	DaemonAddress string `long:"daemonaddress" short:"d" description:"Wallet daemon server to connect to"`
+	Address       string `long:"address" short:"a" description:"The address of the wallet to sign the message with" required:"true"`
+	Message       string `long:"message" short:"m" description:"The message to sign" required:"true"`
+	DaemonSecurityFlags
+	config.NetworkFlags
+}
+
+type verifyMessageConfig struct {
+	Address   string `long:"address" short:"a" description:"The address that signed the message" required:"true"`
+	Message   string `long:"message" short:"m" description:"The signed message" required:"true"`
+	Signature string `long:"signature" short:"s" description:"The signature of the message (encoded in hex)" required:"true"`
+	config.NetworkFlags
+}
+
+type combineConfig struct {
+	Transactions     []string `long:"transaction" short:"t" description:"A copy of the partially signed transaction(s) to combine (encoded in hex). Use multiple times to combine several copies"`
+	TransactionFiles []string `long:"transaction-file" short:"F" description:"The file containing a copy of the partially signed transaction(s) to combine (encoded in hex). Use multiple times to combine several copies"`
+	config.NetworkFlags
+}
+
+type finalizeConfig struct {
+	KeysFile        string `long:"keys-file" short:"f" description:"Keys file location (default: ~/.c4exwallet/keys.json (*nix), %USERPROFILE%\\AppData\\Local\\C4exwallet\\key.json (Windows))"`
+	Transaction     string `long:"transaction" short:"t" description:"The signed transaction(s) to finalize (encoded in hex)"`
+	TransactionFile string `long:"transaction-file" short:"F" description:"The file containing the signed transaction(s) to finalize (encoded in hex)"`
+	config.NetworkFlags
+}
+
 type broadcastConfig struct {
 	DaemonAddress    string `long:"daemonaddress" short:"d" description:"Wallet daemon server to connect to"`
 	Transactions     string `long:"transaction" short:"t" description:"The signed transaction to broadcast (encoded in hex)"`
 	TransactionsFile string `long:"transaction-file" short:"F" description:"The file containing the unsigned transaction to sign on (encoded in hex)"`
+	IsFinalized      bool   `long:"finalized" description:"The transactions were finalized by the finalize command"`
+	DaemonSecurityFlags
 	config.NetworkFlags
 }
 
@@ -99,26 +214,154 @@ type parseConfig struct {
 	Transaction     string `long:"transaction" short:"t" description:"The transaction to parse (encoded in hex)"`
 	TransactionFile string `long:"transaction-file" short:"F" description:"The file containing the transaction to parse (encoded in hex)"`
 	Verbose         bool   `long:"verbose" short:"v" description:"Verbose: show transaction inputs"`
+	Signatures      bool   `long:"signatures" short:"S" description:"Show which public keys signed every input, and how many signatures are still missing"`
 	config.NetworkFlags
 }
 
 type showAddressesConfig struct {
 	DaemonAddress string `long:"daemonaddress" short:"d" description:"Wallet daemon server to connect to"`
+	Account       string `long:"account" description:"The name or index of the account to show the addresses of (default: the default account)"`
+	DaemonSecurityFlags
 	config.NetworkFlags
 }
 
 type newAddressConfig struct {
 	DaemonAddress string `long:"daemonaddress" short:"d" description:"Wallet daemon server to connect to"`
+	Account       string `long:"account" description:"The name or index of the account to generate the address for (default: the default account)"`
+	DaemonSecurityFlags
 	config.NetworkFlags
 }
 
+type createPaymentRequestConfig struct {
+	DaemonAddress string        `long:"daemonaddress" short:"d" description:"Wallet daemon server to connect to"`
+	Account       string        `long:"account" description:"The name or index of the account to receive the payment in (default: the default account)"`
+	Amount        string        `long:"amount" short:"v" description:"The amount to request in C4ex (e.g. 1234.12345678) (default: the payer chooses the amount)"`
+	Label         string        `long:"label" description:"A label for the payment request, such as the name of the payee"`
+	Message       string        `long:"message" short:"m" description:"A message to show to the payer, such as what the payment is for"`
+	ExpiresIn     time.Duration `long:"expires-in" description:"The payment request expires after this duration (e.g. 1h) (default: it never expires)"`
+	DaemonSecurityFlags
+	config.NetworkFlags
+}
+
+type paymentRequestStatusConfig struct {
+	DaemonAddress string `long:"daemonaddress" short:"d" description:"Wallet daemon server to connect to"`
+	Address       string `long:"address" short:"a" description:"The address of the payment request" required:"true"`
+	Wait          bool   `long:"wait" short:"w" description:"Wait until the payment request is paid or expires"`
+	DaemonSecurityFlags
+	config.NetworkFlags
+}
+
+type historyConfig struct {
+	DaemonAddress string `long:"daemonaddress" short:"d" description:"Wallet daemon server to connect to"`
+	Offset        uint32 `long:"offset" description:"Number of the newest transactions to skip"`
+	Limit         uint32 `long:"limit" short:"n" description:"Maximum number of transactions to show, 0 for all" default:"20"`
+	Address       string `long:"address" short:"a" description:"Show only transactions that change the balance of this address"`
+	Direction     string `long:"direction" description:"Show only transactions of this direction: incoming, outgoing or self"`
+	PendingOnly   bool   `long:"pending" description:"Show only transactions that were not confirmed yet"`
+	ConfirmedOnly bool   `long:"confirmed" description:"Show only confirmed transactions"`
+	Verbose       bool   `long:"verbose" short:"v" description:"Verbose: show the amount of every address in each transaction"`
+	DaemonSecurityFlags
+	config.NetworkFlags
+}
+
+type listUTXOsConfig struct {
+	DaemonAddress string   `long:"daemonaddress" short:"d" description:"Wallet daemon server to connect to"`
+	Addresses     []string `long:"address" short:"a" description:"Show only the UTXOs of this address. Use multiple times to show the UTXOs of several addresses"`
+	Account       string   `long:"account" description:"Show only the UTXOs of the account with this name or index"`
+	DaemonSecurityFlags
+	config.NetworkFlags
+}
+
+type freezeConfig struct {
+	DaemonAddress string   `long:"daemonaddress" short:"d" description:"Wallet daemon server to connect to"`
+	UTXOs         []string `long:"utxo" description:"A UTXO to freeze, in the form <transaction ID>:<index>. Use multiple times to freeze several UTXOs" required:"true"`
+	DaemonSecurityFlags
+	config.NetworkFlags
+}
+
+type unfreezeConfig struct {
+	DaemonAddress string   `long:"daemonaddress" short:"d" description:"Wallet daemon server to connect to"`
+	UTXOs         []string `long:"utxo" description:"A UTXO to unfreeze, in the form <transaction ID>:<index>. Use multiple times to unfreeze several UTXOs" required:"true"`
+	DaemonSecurityFlags
+	config.NetworkFlags
+}
+
+type consolidateConfig struct {
+	KeysFile      string `long:"keys-file" short:"f" description:"Keys file location (default: ~/.c4exwallet/keys.json (*nix), %USERPROFILE%\\AppData\\Local\\C4exwallet\\key.json (Windows))"`
+	Password      string `long:"password" short:"p" description:"Wallet password"`
+	DaemonAddress string `long:"daemonaddress" short:"d" description:"Wallet daemon server to connect to"`
+	Threshold     string `long:"threshold" short:"t" description:"Consolidate only UTXOs smaller than this amount in C4ex (default: consolidate all the UTXOs)"`
+	OutputCount   uint32 `long:"outputs" short:"o" description:"The number of outputs to consolidate the UTXOs into" default:"1"`
+	FeeRate       uint64 `long:"fee-rate" description:"The fee to pay, in sompi per gram of transaction mass" default:"1"`
+	MinUTXOCount  uint32 `long:"min-utxos" description:"Consolidate only if there are at least this many UTXOs to consolidate"`
+	DryRun        bool   `long:"dry-run" description:"Only show what would be consolidated, without sending any transaction"`
+	Yes           bool   `long:"yes" short:"y" description:"Send the consolidation transactions without asking for confirmation"`
+	Account       string `long:"account" description:"The name or index of the account to consolidate the UTXOs of (default: the default account)"`
+	DaemonSecurityFlags
+	config.NetworkFlags
+
+	thresholdSompi uint64
+}
+
 type startDaemonConfig struct {
-	KeysFile  string `long:"keys-file" short:"f" description:"Keys file location (default: ~/.c4exwallet/keys.json (*nix), %USERPROFILE%\\AppData\\Local\\C4exwallet\\key.json (Windows))"`
-	Password  string `long:"password" short:"p" description:"Wallet password"`
-	RPCServer string `long:"rpcserver" short:"s" description:"RPC server to connect to"`
-	Listen    string `long:"listen" short:"l" description:"Address to listen on (default: 0.0.0.0:8082)"`
-	Timeout   uint32 `long:"wait-timeout" short:"w" description:"Waiting timeout for RPC calls, seconds (default: 30 s)"`
-	Profile   string `long:"profile" description:"Enable HTTP profiling on given port -- NOTE port must be between 1024 and 65536"`
+	KeysFile                      string        `long:"keys-file" short:"f" description:"Keys file location (default: ~/.c4exwallet/keys.json (*nix), %USERPROFILE%\\AppData\\Local\\C4exwallet\\key.json (Windows))"`
+	Password                      string        `long:"password" short:"p" description:"Wallet password"`
+	RPCServer                     string        `long:"rpcserver" short:"s" description:"RPC server to connect to"`
+	Listen                        string        `long:"listen" short:"l" description:"Address to listen on (default: localhost:8082)"`
+	TLSCert                       string        `long:"tls-cert" description:"A PEM certificate to serve TLS with. Requires --tls-key"`
+	TLSKey                        string        `long:"tls-key" description:"The PEM private key of --tls-cert"`
+	AuthTokenFile                 string        `long:"auth-token-file" description:"Require a bearer token, read from this file, or a client certificate for every call. The token grants full access"`
+	ReadOnlyAuthTokenFile         string        `long:"readonly-auth-token-file" description:"A file that contains a bearer token that grants access to the read-only calls, such as getting the balance or the addresses"`
+	ClientCA                      string        `long:"client-ca" description:"Accept client certificates signed by the certificate authorities in this PEM file, with full access. Requires TLS"`
+	ReadOnlyClientCA              string        `long:"readonly-client-ca" description:"Accept client certificates signed by the certificate authorities in this PEM file, with access to the read-only calls. Requires TLS"`
+	Timeout                       uint32        `long:"wait-timeout" short:"w" description:"Waiting timeout for RPC calls, seconds (default: 30 s)"`
+	Profile                       string        `long:"profile" description:"Enable HTTP profiling on given port -- NOTE port must be between 1024 and 65536"`
+	AutoConsolidateInterval       time.Duration `long:"auto-consolidate-interval" description:"Consolidate the UTXOs of the wallet automatically at this interval (e.g. 1h). Requires the wallet password (default: disabled)"`
+	AutoConsolidateThreshold      string        `long:"auto-consolidate-threshold" description:"Automatically consolidate only UTXOs smaller than this amount in C4ex (default: consolidate all the UTXOs)"`
+	AutoConsolidateOutputs        uint32        `long:"auto-consolidate-outputs" description:"The number of outputs to automatically consolidate the UTXOs into" default:"1"`
+	AutoConsolidateFeeRate        uint64        `long:"auto-consolidate-fee-rate" description:"The fee to pay for automatic consolidation, in sompi per gram of transaction mass" default:"1"`
+	AutoConsolidateMinUTXOs       uint32        `long:"auto-consolidate-min-utxos" description:"Consolidate automatically only if there are at least this many UTXOs to consolidate" default:"100"`
+	AutoConsolidateMaxMempoolSize uint64        `long:"auto-consolidate-max-mempool-size" description:"Consolidate automatically only while the mempool of the node has at most this many transactions" default:"100"`
+	config.NetworkFlags
+
+	autoConsolidateThresholdSompi uint64
+}
+
+type softwareSignerConfig struct {
+	KeysFile string `long:"keys-file" short:"f" description:"Keys file location (default: ~/.c4exwallet/keys.json (*nix), %USERPROFILE%\\AppData\\Local\\C4exwallet\\key.json (Windows))"`
+	Password string `long:"password" short:"p" description:"Wallet password"`
+	Listen   string `long:"listen" short:"l" description:"The path of a Unix socket to listen on (default: serve a single session over stdin and stdout)"`
+	config.NetworkFlags
+}
+
+type createAccountConfig struct {
+	KeysFile      string   `long:"keys-file" short:"f" description:"Keys file location (default: ~/.c4exwallet/keys.json (*nix), %USERPROFILE%\\AppData\\Local\\C4exwallet\\key.json (Windows))"`
+	Password      string   `long:"password" short:"p" description:"Wallet password"`
+	DaemonAddress string   `long:"daemonaddress" short:"d" description:"Wallet daemon server to connect to"`
+	Name          string   `long:"name" short:"n" description:"The name of the new account" required:"true"`
+	XPubs         []string `long:"xpub" description:"An extended public key of the new account for a key the wallet doesn't hold, such as the key of another cosigner of a multisig wallet. Use multiple times for several keys"`
+	DaemonSecurityFlags
+	config.NetworkFlags
+}
+
+type listAccountsConfig struct {
+	DaemonAddress string `long:"daemonaddress" short:"d" description:"Wallet daemon server to connect to"`
+	Verbose       bool   `long:"verbose" short:"v" description:"Verbose: show the extended public keys of the accounts"`
+	DaemonSecurityFlags
+	config.NetworkFlags
+}
+
+type changePasswordConfig struct {
+	KeysFile    string `long:"keys-file" short:"f" description:"Keys file location (default: ~/.c4exwallet/keys.json (*nix), %USERPROFILE%\\AppData\\Local\\C4exwallet\\key.json (Windows))"`
+	Password    string `long:"password" short:"p" description:"The current wallet password"`
+	NewPassword string `long:"new-password" description:"The new wallet password"`
+	KDFFlags
+	config.NetworkFlags
+}
+
+type exportXPubConfig struct {
+	KeysFile string `long:"keys-file" short:"f" description:"Keys file location (default: ~/.c4exwallet/keys.json (*nix), %USERPROFILE%\\AppData\\Local\\C4exwallet\\key.json (Windows))"`
+	Account  string `long:"account" description:"Export only the account with this name or index (default: all the accounts)"`
 	config.NetworkFlags
 }
 
@@ -163,6 +406,25 @@ func parseCommandLine() (subCommand string, config interface{}) {
 	parser.AddCommand(broadcastSubCmd, "Broadcast the given transaction",
 		"Broadcast the given transaction", broadcastConf)
 
+	signMessageConf := &signMessageConfig{DaemonAddress: defaultListen}
+	parser.AddCommand(signMessageSubCmd, "Sign a message with the key of an address of the wallet",
+		"Sign a message with the private key of an address of the wallet, to prove control of the address "+
+			"without sending a transaction. The wallet daemon must be running to find the key of the address", signMessageConf)
+
+	verifyMessageConf := &verifyMessageConfig{}
+	parser.AddCommand(verifyMessageSubCmd, "Verify the signature of a message by an address",
+		"Verify that a signature created by sign-message is a signature of the message by the given address", verifyMessageConf)
+
+	combineConf := &combineConfig{}
+	parser.AddCommand(combineSubCmd, "Combine the signatures of copies of a partially signed transaction",
+		"Combine the signatures of copies of the same partially signed transaction that were signed "+
+			"independently by different cosigners", combineConf)
+
+	finalizeConf := &finalizeConfig{}
+	parser.AddCommand(finalizeSubCmd, "Finalize a fully signed transaction",
+		"Finalize a fully signed partially signed transaction into a transaction that can be broadcast, "+
+			"or fail with the inputs that are missing signatures", finalizeConf)
+
 	parseConf := &parseConfig{}
 	parser.AddCommand(parseSubCmd, "Parse the given transaction and print its contents",
 		"Parse the given transaction and print its contents", parseConf)
@@ -175,11 +437,69 @@ func parseCommandLine() (subCommand string, config interface{}) {
 	parser.AddCommand(newAddressSubCmd, "Generates new public address of the current wallet and shows it",
 		"Generates new public address of the current wallet and shows it", newAddressConf)
 
+	createPaymentRequestConf := &createPaymentRequestConfig{DaemonAddress: defaultListen}
+	parser.AddCommand(createPaymentRequestSubCmd, "Creates a payment request to a new address of the wallet",
+		"Creates a payment request to a new address of the wallet, and shows its payment URI. "+
+			"The payer can pay the URI with 'send --uri'", createPaymentRequestConf)
+
+	paymentRequestStatusConf := &paymentRequestStatusConfig{DaemonAddress: defaultListen}
+	parser.AddCommand(paymentRequestStatusSubCmd, "Shows whether a payment request was paid",
+		"Shows how much the address of a payment request received, and whether the request was paid or expired",
+		paymentRequestStatusConf)
+
+	changePasswordConf := &changePasswordConfig{}
+	parser.AddCommand(changePasswordSubCmd, "Changes the password of the wallet",
+		"Re-encrypts the private keys of the keys file with a new password, optionally with new KDF parameters. "+
+			"The wallet daemon must not be running", changePasswordConf)
+
+	exportXPubConf := &exportXPubConfig{}
+	parser.AddCommand(exportXPubSubCmd, "Prints the extended public keys of the wallet accounts",
+		"Prints the extended public keys of the accounts of the wallet, which can be used to create watch-only "+
+			"wallets of the accounts with 'create --watch-only'", exportXPubConf)
+
 	dumpUnencryptedDataConf := &dumpUnencryptedDataConfig{}
 	parser.AddCommand(dumpUnencryptedDataSubCmd, "Prints the unencrypted wallet data",
 		"Prints the unencrypted wallet data including its private keys. Anyone that sees it can access "+
 			"the funds. Use only on safe environment.", dumpUnencryptedDataConf)
 
+	softwareSignerConf := &softwareSignerConfig{}
+	parser.AddCommand(softwareSignerSubCmd, "Runs a reference external signer",
+		"Runs a reference external signer that signs with the private keys of a keys file, for testing "+
+			"wallets created with --external-signer. Serves a single session over stdin and stdout, or "+
+			"connections to a Unix socket if --listen is given", softwareSignerConf)
+
+	historyConf := &historyConfig{DaemonAddress: defaultListen}
+	parser.AddCommand(historySubCmd, "Shows the transaction history of the wallet",
+		"Shows the transactions that changed the balance of the wallet, from the newest to the oldest, as recorded by the wallet daemon", historyConf)
+
+	listUTXOsConf := &listUTXOsConfig{DaemonAddress: defaultListen}
+	parser.AddCommand(listUTXOsSubCmd, "Lists the UTXOs of the wallet",
+		"Lists the UTXOs of the wallet, with their maturity and whether they are frozen", listUTXOsConf)
+
+	freezeConf := &freezeConfig{DaemonAddress: defaultListen}
+	parser.AddCommand(freezeSubCmd, "Freezes UTXOs of the wallet",
+		"Freezes UTXOs of the wallet, so that they are never spent unless they are unfrozen or explicitly "+
+			"specified with --utxo", freezeConf)
+
+	unfreezeConf := &unfreezeConfig{DaemonAddress: defaultListen}
+	parser.AddCommand(unfreezeSubCmd, "Unfreezes frozen UTXOs of the wallet",
+		"Unfreezes frozen UTXOs of the wallet", unfreezeConf)
+
+	consolidateConf := &consolidateConfig{DaemonAddress: defaultListen}
+	parser.AddCommand(consolidateSubCmd, "Consolidates small UTXOs of the wallet",
+		"Consolidates the UTXOs of the wallet below a threshold into a few outputs to new change addresses, so that "+
+			"later transactions spend fewer inputs. UTXOs that don't fit into a single transaction are consolidated "+
+			"by a chain of transactions", consolidateConf)
+
+	createAccountConf := &createAccountConfig{DaemonAddress: defaultListen}
+	parser.AddCommand(createAccountSubCmd, "Creates a new account in the wallet",
+		"Creates a new BIP44 account in the wallet, derived from the same keys under the next account index, so that "+
+			"its funds are kept apart from the funds of the other accounts. The wallet daemon must be running", createAccountConf)
+
+	listAccountsConf := &listAccountsConfig{DaemonAddress: defaultListen}
+	parser.AddCommand(listAccountsSubCmd, "Lists the accounts of the wallet",
+		"Lists the accounts of the wallet with their balances", listAccountsConf)
+
 	startDaemonConf := &startDaemonConfig{
 		RPCServer: defaultRPCServer,
 		Listen:    defaultListen,
@@ -204,6 +524,10 @@ func parseCommandLine() (subCommand string, config interface{}) {
 		if err != nil {
 			printErrorAndExit(err)
 		}
+		err = validateCreateConfig(createConf)
+		if err != nil {
+			printErrorAndExit(err)
+		}
 		config = createConf
 	case balanceSubCmd:
 		combineNetworkFlags(&balanceConf.NetworkFlags, &cfg.NetworkFlags)
@@ -248,6 +572,20 @@ func parseCommandLine() (subCommand string, config interface{}) {
 			printErrorAndExit(err)
 		}
 		config = signConf
+	case signMessageSubCmd:
+		combineNetworkFlags(&signMessageConf.NetworkFlags, &cfg.NetworkFlags)
+		err := signMessageConf.ResolveNetwork(parser)
+		if err != nil {
+			printErrorAndExit(err)
+		}
+		config = signMessageConf
+	case verifyMessageSubCmd:
+		combineNetworkFlags(&verifyMessageConf.NetworkFlags, &cfg.NetworkFlags)
+		err := verifyMessageConf.ResolveNetwork(parser)
+		if err != nil {
+			printErrorAndExit(err)
+		}
+		config = verifyMessageConf
 	case broadcastSubCmd:
 		combineNetworkFlags(&broadcastConf.NetworkFlags, &cfg.NetworkFlags)
 		err := broadcastConf.ResolveNetwork(parser)
@@ -276,6 +614,41 @@ func parseCommandLine() (subCommand string, config interface{}) {
 			printErrorAndExit(err)
 		}
 		config = newAddressConf
+	case createPaymentRequestSubCmd:
+		combineNetworkFlags(&createPaymentRequestConf.NetworkFlags, &cfg.NetworkFlags)
+		err := createPaymentRequestConf.ResolveNetwork(parser)
+		if err != nil {
+			printErrorAndExit(err)
+		}
+		if createPaymentRequestConf.ExpiresIn < 0 {
+			printErrorAndExit(errors.New("'--expires-in' can't be negative"))
+		}
+		config = createPaymentRequestConf
+	case paymentRequestStatusSubCmd:
+		combineNetworkFlags(&paymentRequestStatusConf.NetworkFlags, &cfg.NetworkFlags)
+		err := paymentRequestStatusConf.ResolveNetwork(parser)
+		if err != nil {
+			printErrorAndExit(err)
+		}
+		config = paymentRequestStatusConf
+	case changePasswordSubCmd:
+		combineNetworkFlags(&changePasswordConf.NetworkFlags, &cfg.NetworkFlags)
+		err := changePasswordConf.ResolveNetwork(parser)
+		if err != nil {
+			printErrorAndExit(err)
+		}
+		err = changePasswordConf.kdfParams().Validate()
+		if err != nil {
+			printErrorAndExit(err)
+		}
+		config = changePasswordConf
+	case exportXPubSubCmd:
+		combineNetworkFlags(&exportXPubConf.NetworkFlags, &cfg.NetworkFlags)
+		err := exportXPubConf.ResolveNetwork(parser)
+		if err != nil {
+			printErrorAndExit(err)
+		}
+		config = exportXPubConf
 	case dumpUnencryptedDataSubCmd:
 		combineNetworkFlags(&dumpUnencryptedDataConf.NetworkFlags, &cfg.NetworkFlags)
 		err := dumpUnencryptedDataConf.ResolveNetwork(parser)
@@ -283,36 +656,273 @@ func parseCommandLine() (subCommand string, config interface{}) {
 			printErrorAndExit(err)
 		}
 		config = dumpUnencryptedDataConf
+	case softwareSignerSubCmd:
+		combineNetworkFlags(&softwareSignerConf.NetworkFlags, &cfg.NetworkFlags)
+		err := softwareSignerConf.ResolveNetwork(parser)
+		if err != nil {
+			printErrorAndExit(err)
+		}
+		config = softwareSignerConf
 	case startDaemonSubCmd:
 		combineNetworkFlags(&startDaemonConf.NetworkFlags, &cfg.NetworkFlags)
 		err := startDaemonConf.ResolveNetwork(parser)
 		if err != nil {
 			printErrorAndExit(err)
 		}
+		err = validateStartDaemonConfig(startDaemonConf)
+		if err != nil {
+			printErrorAndExit(err)
+		}
 		config = startDaemonConf
+	case historySubCmd:
+		combineNetworkFlags(&historyConf.NetworkFlags, &cfg.NetworkFlags)
+		err := historyConf.ResolveNetwork(parser)
+		if err != nil {
+			printErrorAndExit(err)
+		}
+		err = validateHistoryConfig(historyConf)
+		if err != nil {
+			printErrorAndExit(err)
+		}
+		config = historyConf
+	case listUTXOsSubCmd:
+		combineNetworkFlags(&listUTXOsConf.NetworkFlags, &cfg.NetworkFlags)
+		err := listUTXOsConf.ResolveNetwork(parser)
+		if err != nil {
+			printErrorAndExit(err)
+		}
+		config = listUTXOsConf
+	case freezeSubCmd:
+		combineNetworkFlags(&freezeConf.NetworkFlags, &cfg.NetworkFlags)
+		err := freezeConf.ResolveNetwork(parser)
+		if err != nil {
+			printErrorAndExit(err)
+		}
+		config = freezeConf
+	case unfreezeSubCmd:
+		combineNetworkFlags(&unfreezeConf.NetworkFlags, &cfg.NetworkFlags)
+		err := unfreezeConf.ResolveNetwork(parser)
+		if err != nil {
+			printErrorAndExit(err)
+		}
+		config = unfreezeConf
+	case combineSubCmd:
+		combineNetworkFlags(&combineConf.NetworkFlags, &cfg.NetworkFlags)
+		err := combineConf.ResolveNetwork(parser)
+		if err != nil {
+			printErrorAndExit(err)
+		}
+		config = combineConf
+	case finalizeSubCmd:
+		combineNetworkFlags(&finalizeConf.NetworkFlags, &cfg.NetworkFlags)
+		err := finalizeConf.ResolveNetwork(parser)
+		if err != nil {
+			printErrorAndExit(err)
+		}
+		config = finalizeConf
+	case consolidateSubCmd:
+		combineNetworkFlags(&consolidateConf.NetworkFlags, &cfg.NetworkFlags)
+		err := consolidateConf.ResolveNetwork(parser)
+		if err != nil {
+			printErrorAndExit(err)
+		}
+		err = validateConsolidateConfig(consolidateConf)
+		if err != nil {
+			printErrorAndExit(err)
+		}
+		config = consolidateConf
+	case createAccountSubCmd:
+		combineNetworkFlags(&createAccountConf.NetworkFlags, &cfg.NetworkFlags)
+		err := createAccountConf.ResolveNetwork(parser)
+		if err != nil {
+			printErrorAndExit(err)
+		}
+		config = createAccountConf
+	case listAccountsSubCmd:
+		combineNetworkFlags(&listAccountsConf.NetworkFlags, &cfg.NetworkFlags)
+		err := listAccountsConf.ResolveNetwork(parser)
+		if err != nil {
+			printErrorAndExit(err)
+		}
+		config = listAccountsConf
 	}
 
 	return parser.Command.Active.Name, config
 }
 
-func validateCreateUnsignedTransactionConf(conf *createUnsignedTransactionConfig) error {
-	if (!conf.IsSendAll && conf.SendAmount == 0) ||
-		(conf.IsSendAll && conf.SendAmount > 0) {
+func validateCreateConfig(conf *createConfig) error {
+	err := conf.kdfParams().Validate()
+	if err != nil {
+		return err
+	}
 
-		return errors.New("exactly one of '--send-amount' or '--all' must be specified")
+	if conf.ExternalSigner != "" {
+		if conf.WatchOnly || conf.Import {
+			return errors.New("'--external-signer' cannot be used with '--watch-only' or '--import'")
+		}
+		if conf.Password != "" {
+			return errors.New("the keys of a wallet with an external signer are not protected with '--password'")
+		}
+		return signer.ValidateAddress(conf.ExternalSigner)
+	}
+
+	if !conf.WatchOnly {
+		if len(conf.XPubs) > 0 {
+			return errors.New("'--xpub' can only be used with '--watch-only'")
+		}
+		return nil
+	}
+
+	if conf.Import {
+		return errors.New("'--watch-only' and '--import' cannot be used together")
+	}
+	if conf.Password != "" {
+		return errors.New("a watch-only wallet has no private keys to protect with '--password'")
+	}
+	for _, extendedPublicKey := range conf.XPubs {
+		err := libc4exwallet.ValidateExtendedPublicKey(conf.NetParams(), extendedPublicKey)
+		if err != nil {
+			return err
+		}
+	}
+	conf.NumPrivateKeys = 0
+	if uint32(len(conf.XPubs)) > conf.NumPublicKeys {
+		conf.NumPublicKeys = uint32(len(conf.XPubs))
+	}
+	if conf.MinimumSignatures == 0 || conf.MinimumSignatures > conf.NumPublicKeys {
+		return errors.Errorf("'--min-signatures' must be between 1 and the number of public keys (%d)", conf.NumPublicKeys)
+	}
+	return nil
+}
+
+func validateStartDaemonConfig(conf *startDaemonConfig) error {
+	var err error
+	conf.autoConsolidateThresholdSompi, err = parseAmountFlag("--auto-consolidate-threshold",
+		conf.AutoConsolidateThreshold)
+	if err != nil {
+		return err
+	}
+	if (conf.TLSCert == "") != (conf.TLSKey == "") {
+		return errors.New("'--tls-cert' and '--tls-key' must be used together")
+	}
+	if conf.TLSCert == "" && (conf.ClientCA != "" || conf.ReadOnlyClientCA != "") {
+		return errors.New("'--client-ca' and '--readonly-client-ca' require '--tls-cert'")
+	}
+	return nil
+}
+
+func validateConsolidateConfig(conf *consolidateConfig) error {
+	var err error
+	conf.thresholdSompi, err = parseAmountFlag("--threshold", conf.Threshold)
+	return err
+}
+
+func validateCreateUnsignedTransactionConf(conf *createUnsignedTransactionConfig) error {
+	var err error
+	conf.sendAmountSompi, err = parseAmountFlag("--send-amount", conf.SendAmount)
+	if err != nil {
+		return err
+	}
+	err = validateRecipientFlags(conf.ToAddress, conf.sendAmountSompi, conf.IsSendAll, conf.RecipientsFile)
+	if err != nil {
+		return err
+	}
+	if len(conf.FromAddresses) > 0 && len(conf.UTXOs) > 0 {
+		return errors.New("'--from-address' and '--utxo' are mutually exclusive")
 	}
 	return nil
 }
 
 func validateSendConfig(conf *sendConfig) error {
-	if (!conf.IsSendAll && conf.SendAmount == 0) ||
-		(conf.IsSendAll && conf.SendAmount > 0) {
+	var err error
+	conf.sendAmountSompi, err = parseAmountFlag("--send-amount", conf.SendAmount)
+	if err != nil {
+		return err
+	}
+	if conf.URI != "" {
+		err := applyPaymentURI(conf)
+		if err != nil {
+			return err
+		}
+	}
+	err = validateRecipientFlags(conf.ToAddress, conf.sendAmountSompi, conf.IsSendAll, conf.RecipientsFile)
+	if err != nil {
+		return err
+	}
+	if len(conf.FromAddresses) > 0 && len(conf.UTXOs) > 0 {
+		return errors.New("'--from-address' and '--utxo' are mutually exclusive")
+	}
+	return nil
+}
+
+// applyPaymentURI sets the address to send to, and the amount if the URI has one, from the payment URI
+func applyPaymentURI(conf *sendConfig) error {
+	if conf.ToAddress != "" || conf.RecipientsFile != "" {
+		return errors.New("'--uri' is mutually exclusive with '--to-address' and '--recipients-file'")
+	}
+
+	paymentURI, err := util.DecodePaymentURI(conf.URI, conf.NetParams().Prefix)
+	if err != nil {
+		return err
+	}
+	if paymentURI.IsExpired(time.Now()) {
+		return errors.Errorf("the payment request expired at %s", paymentURI.Expiry)
+	}
+	if paymentURI.Amount != 0 {
+		if conf.sendAmountSompi != 0 || conf.IsSendAll {
+			return errors.New("'--send-amount' and '--send-all' can't be used with a payment URI that has an amount")
+		}
+		conf.sendAmountSompi = uint64(paymentURI.Amount)
+	}
+	conf.ToAddress = paymentURI.Address.String()
+	conf.paymentURI = paymentURI
+	return nil
+}
+
+// parseAmountFlag parses the amount in C4ex of the given flag exactly, which is 0 if it's not given
+func parseAmountFlag(flag string, amount string) (uint64, error) {
+	if amount == "" {
+		return 0, nil
+	}
+	sompi, err := utils.ParseC4x(amount)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid '%s'", flag)
+	}
+	return sompi, nil
+}
+
+func validateRecipientFlags(toAddress string, sendAmount uint64, isSendAll bool, recipientsFile string) error {
+	if recipientsFile != "" {
+		if toAddress != "" || sendAmount != 0 || isSendAll {
+			return errors.New("'--recipients-file' is mutually exclusive with '--to-address', " +
+				"'--send-amount' and '--send-all'")
+		}
+		return nil
+	}
+
+	if toAddress == "" {
+		return errors.New("either '--to-address' or '--recipients-file' must be specified")
+	}
+	if (!isSendAll && sendAmount == 0) ||
+		(isSendAll && sendAmount > 0) {
 
 		return errors.New("exactly one of '--send-amount' or '--all' must be specified")
 	}
 	return nil
 }
 
+func validateHistoryConfig(conf *historyConfig) error {
+	if conf.PendingOnly && conf.ConfirmedOnly {
+		return errors.New("'--pending' and '--confirmed' are mutually exclusive")
+	}
+	switch conf.Direction {
+	case "", "incoming", "outgoing", "self":
+	default:
+		return errors.Errorf("'--direction' must be one of incoming, outgoing or self, got %s", conf.Direction)
+	}
+	return nil
+}
+
 func combineNetworkFlags(dst, src *config.NetworkFlags) {
 	dst.Testnet = dst.Testnet || src.Testnet
 	dst.Simnet = dst.Simnet || src.Simnet
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/c4ei/c4exd/cmd/c4exwallet/daemon/client"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/daemon/pb"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/keys"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/signer"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/utils"
+)
+
+func consolidate(conf *consolidateConfig) error {
+	keysFile, err := keys.ReadKeysFile(conf.NetParams(), conf.KeysFile)
+	if err != nil {
+		return err
+	}
+	if keysFile.IsWatchOnly() && !conf.DryRun {
+		return keys.ErrWatchOnly
+	}
+
+	daemonClient, tearDown, err := client.Connect(conf.DaemonAddress, conf.securityOptions())
+	if err != nil {
+		return err
+	}
+	defer tearDown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), daemonTimeout)
+	defer cancel()
+
+	response, err := daemonClient.CreateConsolidationTransactions(ctx, &pb.CreateConsolidationTransactionsRequest{
+		Threshold:    conf.thresholdSompi,
+		OutputCount:  conf.OutputCount,
+		FeeRate:      conf.FeeRate,
+		MinUtxoCount: conf.MinUTXOCount,
+		DryRun:       conf.DryRun,
+		Account:      conf.Account,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Consolidating %d UTXOs worth %s C4X into %d output(s) in %d transaction(s):\n", response.UtxoCount,
+		strings.TrimSpace(utils.FormatC4x(response.Amount)), conf.OutputCount, len(response.UnsignedTransactions))
+	err = printTransactionsSummary(os.Stdout, response.UnsignedTransactions)
+	if err != nil {
+		return err
+	}
+	if conf.DryRun {
+		return nil
+	}
+
+	if !conf.Yes {
+		err = confirm("Send the consolidation transactions")
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(conf.Password) == 0 && !keysFile.HasExternalSigner() {
+		conf.Password = keys.GetPassword("Password:")
+	}
+	signedTransactions, err := signer.SignTransactions(conf.NetParams(), keysFile, conf.Password,
+		response.UnsignedTransactions)
+	if err != nil {
+		return err
+	}
+
+	// Since we waited for user input, create a new context for broadcast, to reset the timeout
+	broadcastCtx, broadcastCancel := context.WithTimeout(context.Background(), daemonTimeout)
+	defer broadcastCancel()
+
+	broadcastResponse, err := daemonClient.Broadcast(broadcastCtx, &pb.BroadcastRequest{Transactions: signedTransactions})
+	if err != nil {
+		return err
+	}
+	fmt.Println("Transactions were sent successfully")
+	fmt.Println("Transaction ID(s): ")
+	for _, txID := range broadcastResponse.TxIDs {
+		fmt.Printf("\t%s\n", txID)
+	}
+
+	return nil
+}
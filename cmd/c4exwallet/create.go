@@ -6,7 +6,7 @@ import (
 	"os"
 
 	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet"
-	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet/bip32"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/signer"
 	"github.com/c4ei/c4exd/cmd/c4exwallet/utils"
 	"github.com/pkg/errors"
 
@@ -18,36 +18,56 @@ func create(conf *createConfig) error {
 	var signerExtendedPublicKeys []string
 	var err error
 	isMultisig := conf.NumPublicKeys > 1
-	if !conf.Import {
-		encryptedMnemonics, signerExtendedPublicKeys, err = keys.CreateMnemonics(conf.NetParams(), conf.NumPrivateKeys, conf.Password, isMultisig)
-	} else {
-		encryptedMnemonics, signerExtendedPublicKeys, err = keys.ImportMnemonics(conf.NetParams(), conf.NumPrivateKeys, conf.Password, isMultisig)
-	}
-	if err != nil {
-		return err
-	}
+	if conf.ExternalSigner != "" {
+		signerExtendedPublicKeys, err = externalSignerExtendedPublicKeys(conf, isMultisig)
+		if err != nil {
+			return err
+		}
 
-	for i, extendedPublicKey := range signerExtendedPublicKeys {
-		fmt.Printf("Extended public key of mnemonic #%d:\n%s\n\n", i+1, extendedPublicKey)
-	}
+		for i, extendedPublicKey := range signerExtendedPublicKeys {
+			fmt.Printf("Extended public key #%d of the external signer:\n%s\n\n", i+1, extendedPublicKey)
+		}
+	} else if !conf.WatchOnly {
+		if !conf.Import {
+			encryptedMnemonics, signerExtendedPublicKeys, err = keys.CreateMnemonics(conf.NetParams(), conf.NumPrivateKeys, conf.Password, isMultisig,
+				conf.kdfParams())
+		} else {
+			encryptedMnemonics, signerExtendedPublicKeys, err = keys.ImportMnemonics(conf.NetParams(), conf.NumPrivateKeys, conf.Password, isMultisig,
+				conf.kdfParams())
+		}
+		if err != nil {
+			return err
+		}
 
-	fmt.Printf("Notice the above is neither a secret key to your wallet " +
-		"(use \"c4exwallet dump-unencrypted-data\" to see a secret seed phrase) " +
-		"nor a wallet public address (use \"c4exwallet new-address\" to create and see one)\n\n")
+		for i, extendedPublicKey := range signerExtendedPublicKeys {
+			fmt.Printf("Extended public key of mnemonic #%d:\n%s\n\n", i+1, extendedPublicKey)
+		}
+
+		fmt.Printf("Notice the above is neither a secret key to your wallet " +
+			"(use \"c4exwallet dump-unencrypted-data\" to see a secret seed phrase) " +
+			"nor a wallet public address (use \"c4exwallet new-address\" to create and see one)\n\n")
+	}
 
 	extendedPublicKeys := make([]string, conf.NumPrivateKeys, conf.NumPublicKeys)
 	copy(extendedPublicKeys, signerExtendedPublicKeys)
+	for _, extendedPublicKey := range conf.XPubs {
+		err := libc4exwallet.ValidateExtendedPublicKey(conf.NetParams(), extendedPublicKey)
+		if err != nil {
+			return err
+		}
+		extendedPublicKeys = append(extendedPublicKeys, extendedPublicKey)
+	}
 	reader := bufio.NewReader(os.Stdin)
-	for i := conf.NumPrivateKeys; i < conf.NumPublicKeys; i++ {
+	for i := uint32(len(extendedPublicKeys)); i < conf.NumPublicKeys; i++ {
 		fmt.Printf("Enter public key #%d here:\n", i+1)
 		extendedPublicKey, err := utils.ReadLine(reader)
 		if err != nil {
 			return err
 		}
 
-		_, err = bip32.DeserializeExtendedKey(string(extendedPublicKey))
+		err = libc4exwallet.ValidateExtendedPublicKey(conf.NetParams(), string(extendedPublicKey))
 		if err != nil {
-			return errors.Wrapf(err, "%s is invalid extended public key", string(extendedPublicKey))
+			return err
 		}
 
 		fmt.Println()
@@ -55,6 +75,14 @@ func create(conf *createConfig) error {
 		extendedPublicKeys = append(extendedPublicKeys, string(extendedPublicKey))
 	}
 
+	seenExtendedPublicKeys := make(map[string]struct{}, len(extendedPublicKeys))
+	for _, extendedPublicKey := range extendedPublicKeys {
+		if _, exists := seenExtendedPublicKeys[extendedPublicKey]; exists {
+			return errors.Errorf("extended public key %s appears more than once", extendedPublicKey)
+		}
+		seenExtendedPublicKeys[extendedPublicKey] = struct{}{}
+	}
+
 	// For a read only wallet the cosigner index is 0
 	cosignerIndex := uint32(0)
 	if len(signerExtendedPublicKeys) > 0 {
@@ -71,6 +99,10 @@ func create(conf *createConfig) error {
 		MinimumSignatures:  conf.MinimumSignatures,
 		CosignerIndex:      cosignerIndex,
 		ECDSA:              conf.ECDSA,
+		ExternalSigner:     conf.ExternalSigner,
+	}
+	if file.HasExternalSigner() {
+		file.ExternalSignerKeys = uint32(len(signerExtendedPublicKeys))
 	}
 
 	err = file.SetPath(conf.NetParams(), conf.KeysFile, conf.Yes)
@@ -88,6 +120,38 @@ func create(conf *createConfig) error {
 		return err
 	}
 
+	if file.IsWatchOnly() {
+		fmt.Printf("Wrote the watch-only keys into %s\n", file.Path())
+		return nil
+	}
+	if file.HasExternalSigner() {
+		fmt.Printf("Wrote the keys into %s, signing with the external signer at %s\n", file.Path(), file.ExternalSigner)
+		return nil
+	}
 	fmt.Printf("Wrote the keys into %s\n", file.Path())
 	return nil
 }
+
+func externalSignerExtendedPublicKeys(conf *createConfig, isMultisig bool) ([]string, error) {
+	client, err := signer.Connect(conf.NetParams(), conf.ExternalSigner)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	extendedPublicKeys, err := client.ExtendedPublicKeys(isMultisig, 0)
+	if err != nil {
+		return nil, err
+	}
+	if uint32(len(extendedPublicKeys)) != conf.NumPrivateKeys {
+		return nil, errors.Errorf("the external signer holds %d keys, but --num-private-keys is %d",
+			len(extendedPublicKeys), conf.NumPrivateKeys)
+	}
+	for _, extendedPublicKey := range extendedPublicKeys {
+		err := libc4exwallet.ValidateExtendedPublicKey(conf.NetParams(), extendedPublicKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return extendedPublicKeys, nil
+}
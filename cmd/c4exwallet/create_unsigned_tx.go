@@ -7,11 +7,10 @@ import (
 
 	"github.com/c4ei/c4exd/cmd/c4exwallet/daemon/client"
 	"github.com/c4ei/c4exd/cmd/c4exwallet/daemon/pb"
-	"github.com/c4ei/c4exd/domain/consensus/utils/constants"
 )
 
 func createUnsignedTransaction(conf *createUnsignedTransactionConfig) error {
-	daemonClient, tearDown, err := client.Connect(conf.DaemonAddress)
+	daemonClient, tearDown, err := client.Connect(conf.DaemonAddress, conf.securityOptions())
 	if err != nil {
 		return err
 	}
@@ -20,18 +19,40 @@ func createUnsignedTransaction(conf *createUnsignedTransactionConfig) error {
 	ctx, cancel := context.WithTimeout(context.Background(), daemonTimeout)
 	defer cancel()
 
-	sendAmountSompi := uint64(conf.SendAmount * constants.SompiPerC4ex)
+	utxos, err := parseOutpoints(conf.UTXOs)
+	if err != nil {
+		return err
+	}
+
+	var recipients []*pb.Recipient
+	if conf.RecipientsFile != "" {
+		recipients, err = readRecipientsFile(conf.RecipientsFile)
+		if err != nil {
+			return err
+		}
+	}
+
 	response, err := daemonClient.CreateUnsignedTransactions(ctx, &pb.CreateUnsignedTransactionsRequest{
 		From:                     conf.FromAddresses,
 		Address:                  conf.ToAddress,
-		Amount:                   sendAmountSompi,
+		Amount:                   conf.sendAmountSompi,
 		IsSendAll:                conf.IsSendAll,
 		UseExistingChangeAddress: conf.UseExistingChangeAddress,
+		Utxos:                    utxos,
+		Recipients:               recipients,
+		Account:                  conf.Account,
 	})
 	if err != nil {
 		return err
 	}
 
+	if len(recipients) > 0 {
+		err = printRecipientsSummary(os.Stderr, response.UnsignedTransactions, recipients)
+		if err != nil {
+			return err
+		}
+	}
+
 	fmt.Fprintln(os.Stderr, "Created unsigned transaction")
 	fmt.Println(encodeTransactionsToHex(response.UnsignedTransactions))
 
@@ -2,6 +2,10 @@ package client
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"strings"
 	"time"
 
 	"github.com/c4ei/c4exd/cmd/c4exwallet/daemon/server"
@@ -10,18 +14,44 @@ import (
 
 	"github.com/c4ei/c4exd/cmd/c4exwallet/daemon/pb"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
-// Connect connects to the c4exwalletd server, and returns the client instance
-func Connect(address string) (pb.C4exwalletdClient, func(), error) {
+// SecurityOptions configure how to connect to a daemon that uses TLS or authentication
+type SecurityOptions struct {
+	// UseTLS connects with TLS. It's implied by any of the file options below, except AuthTokenFile.
+	UseTLS bool
+
+	// CAFile is a PEM file of the certificate authorities to verify the daemon's certificate
+	// with, instead of the system's. It may be the daemon's self-signed certificate itself.
+	CAFile string
+
+	ClientCertFile string
+	ClientKeyFile  string
+
+	// AuthTokenFile is a file that contains the bearer token to authenticate with
+	AuthTokenFile string
+}
+
+// Connect connects to the c4exwalletd server, and returns the client instance.
+// securityOptions may be nil to connect without TLS or authentication.
+func Connect(address string, securityOptions *SecurityOptions) (pb.C4exwalletdClient, func(), error) {
+	dialOptions, err := securityDialOptions(securityOptions)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// Connection is local, so 1 second timeout is sufficient
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	defer cancel()
 
-	conn, err := grpc.DialContext(ctx, address, grpc.WithInsecure(), grpc.WithBlock(), grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(server.MaxDaemonSendMsgSize)))
+	dialOptions = append(dialOptions, grpc.WithBlock(), grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(server.MaxDaemonSendMsgSize)))
+	conn, err := grpc.DialContext(ctx, address, dialOptions...)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
-			return nil, nil, errors.New("c4exwallet daemon is not running, start it with `c4exwallet start-daemon`")
+			return nil, nil, errors.Errorf("could not connect to the c4exwallet daemon at %s: either it's not running, "+
+				"in which case start it with `c4exwallet start-daemon`, or its TLS settings don't match the "+
+				"--daemon-tls and --daemon-ca-file options", address)
 		}
 		return nil, nil, err
 	}
@@ -30,3 +60,58 @@ func Connect(address string) (pb.C4exwalletdClient, func(), error) {
 		conn.Close()
 	}, nil
 }
+
+func securityDialOptions(securityOptions *SecurityOptions) ([]grpc.DialOption, error) {
+	if securityOptions == nil {
+		return []grpc.DialOption{grpc.WithInsecure()}, nil
+	}
+
+	var dialOptions []grpc.DialOption
+	if securityOptions.UseTLS || securityOptions.CAFile != "" || securityOptions.ClientCertFile != "" {
+		tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+		if securityOptions.CAFile != "" {
+			pem, err := ioutil.ReadFile(securityOptions.CAFile)
+			if err != nil {
+				return nil, errors.Wrap(err, "error reading the daemon CA file")
+			}
+			tlsConfig.RootCAs = x509.NewCertPool()
+			if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+				return nil, errors.Errorf("no certificates found in %s", securityOptions.CAFile)
+			}
+		}
+		if securityOptions.ClientCertFile != "" {
+			certificate, err := tls.LoadX509KeyPair(securityOptions.ClientCertFile, securityOptions.ClientKeyFile)
+			if err != nil {
+				return nil, errors.Wrap(err, "error loading the client certificate")
+			}
+			tlsConfig.Certificates = []tls.Certificate{certificate}
+		}
+		dialOptions = append(dialOptions, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	} else {
+		dialOptions = append(dialOptions, grpc.WithInsecure())
+	}
+
+	if securityOptions.AuthTokenFile != "" {
+		content, err := ioutil.ReadFile(securityOptions.AuthTokenFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "error reading the auth token")
+		}
+		dialOptions = append(dialOptions, grpc.WithPerRPCCredentials(bearerToken(strings.TrimSpace(string(content)))))
+	}
+
+	return dialOptions, nil
+}
+
+// bearerToken sends a token in the authorization header of every call
+type bearerToken string
+
+// GetRequestMetadata returns the authorization header
+func (bt bearerToken) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(bt)}, nil
+}
+
+// RequireTransportSecurity returns false so that a daemon on a loopback address
+// can be called without TLS
+func (bt bearerToken) RequireTransportSecurity() bool {
+	return false
+}
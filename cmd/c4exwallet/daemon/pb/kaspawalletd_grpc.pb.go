@@ -29,6 +29,17 @@ type C4exwalletdClient interface {
 	Send(ctx context.Context, in *SendRequest, opts ...grpc.CallOption) (*SendResponse, error)
 	// Since SignRequest contains a password - this command should only be used on a trusted or secure connection
 	Sign(ctx context.Context, in *SignRequest, opts ...grpc.CallOption) (*SignResponse, error)
+	GetTransactionHistory(ctx context.Context, in *GetTransactionHistoryRequest, opts ...grpc.CallOption) (*GetTransactionHistoryResponse, error)
+	ListUTXOs(ctx context.Context, in *ListUTXOsRequest, opts ...grpc.CallOption) (*ListUTXOsResponse, error)
+	FreezeUTXOs(ctx context.Context, in *FreezeUTXOsRequest, opts ...grpc.CallOption) (*FreezeUTXOsResponse, error)
+	UnfreezeUTXOs(ctx context.Context, in *UnfreezeUTXOsRequest, opts ...grpc.CallOption) (*UnfreezeUTXOsResponse, error)
+	CreateConsolidationTransactions(ctx context.Context, in *CreateConsolidationTransactionsRequest, opts ...grpc.CallOption) (*CreateConsolidationTransactionsResponse, error)
+	// Since CreateAccountRequest contains a password - this command should only be used on a trusted or secure connection
+	CreateAccount(ctx context.Context, in *CreateAccountRequest, opts ...grpc.CallOption) (*CreateAccountResponse, error)
+	ListAccounts(ctx context.Context, in *ListAccountsRequest, opts ...grpc.CallOption) (*ListAccountsResponse, error)
+	GetAddressDerivationPath(ctx context.Context, in *GetAddressDerivationPathRequest, opts ...grpc.CallOption) (*GetAddressDerivationPathResponse, error)
+	CreatePaymentRequest(ctx context.Context, in *CreatePaymentRequestRequest, opts ...grpc.CallOption) (*CreatePaymentRequestResponse, error)
+	GetPaymentRequest(ctx context.Context, in *GetPaymentRequestRequest, opts ...grpc.CallOption) (*GetPaymentRequestResponse, error)
 }
 
 type c4exwalletdClient struct {
@@ -120,6 +131,96 @@ func (c *c4exwalletdClient) Sign(ctx context.Context, in *SignRequest, opts ...g
 	return out, nil
 }
 
+func (c *c4exwalletdClient) GetTransactionHistory(ctx context.Context, in *GetTransactionHistoryRequest, opts ...grpc.CallOption) (*GetTransactionHistoryResponse, error) {
+	out := new(GetTransactionHistoryResponse)
+	err := c.cc.Invoke(ctx, "/c4exwalletd.c4exwalletd/GetTransactionHistory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *c4exwalletdClient) ListUTXOs(ctx context.Context, in *ListUTXOsRequest, opts ...grpc.CallOption) (*ListUTXOsResponse, error) {
+	out := new(ListUTXOsResponse)
+	err := c.cc.Invoke(ctx, "/c4exwalletd.c4exwalletd/ListUTXOs", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *c4exwalletdClient) FreezeUTXOs(ctx context.Context, in *FreezeUTXOsRequest, opts ...grpc.CallOption) (*FreezeUTXOsResponse, error) {
+	out := new(FreezeUTXOsResponse)
+	err := c.cc.Invoke(ctx, "/c4exwalletd.c4exwalletd/FreezeUTXOs", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *c4exwalletdClient) UnfreezeUTXOs(ctx context.Context, in *UnfreezeUTXOsRequest, opts ...grpc.CallOption) (*UnfreezeUTXOsResponse, error) {
+	out := new(UnfreezeUTXOsResponse)
+	err := c.cc.Invoke(ctx, "/c4exwalletd.c4exwalletd/UnfreezeUTXOs", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *c4exwalletdClient) CreateConsolidationTransactions(ctx context.Context, in *CreateConsolidationTransactionsRequest, opts ...grpc.CallOption) (*CreateConsolidationTransactionsResponse, error) {
+	out := new(CreateConsolidationTransactionsResponse)
+	err := c.cc.Invoke(ctx, "/c4exwalletd.c4exwalletd/CreateConsolidationTransactions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *c4exwalletdClient) CreateAccount(ctx context.Context, in *CreateAccountRequest, opts ...grpc.CallOption) (*CreateAccountResponse, error) {
+	out := new(CreateAccountResponse)
+	err := c.cc.Invoke(ctx, "/c4exwalletd.c4exwalletd/CreateAccount", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *c4exwalletdClient) ListAccounts(ctx context.Context, in *ListAccountsRequest, opts ...grpc.CallOption) (*ListAccountsResponse, error) {
+	out := new(ListAccountsResponse)
+	err := c.cc.Invoke(ctx, "/c4exwalletd.c4exwalletd/ListAccounts", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *c4exwalletdClient) GetAddressDerivationPath(ctx context.Context, in *GetAddressDerivationPathRequest, opts ...grpc.CallOption) (*GetAddressDerivationPathResponse, error) {
+	out := new(GetAddressDerivationPathResponse)
+	err := c.cc.Invoke(ctx, "/c4exwalletd.c4exwalletd/GetAddressDerivationPath", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *c4exwalletdClient) CreatePaymentRequest(ctx context.Context, in *CreatePaymentRequestRequest, opts ...grpc.CallOption) (*CreatePaymentRequestResponse, error) {
+	out := new(CreatePaymentRequestResponse)
+	err := c.cc.Invoke(ctx, "/c4exwalletd.c4exwalletd/CreatePaymentRequest", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *c4exwalletdClient) GetPaymentRequest(ctx context.Context, in *GetPaymentRequestRequest, opts ...grpc.CallOption) (*GetPaymentRequestResponse, error) {
+	out := new(GetPaymentRequestResponse)
+	err := c.cc.Invoke(ctx, "/c4exwalletd.c4exwalletd/GetPaymentRequest", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // C4exwalletdServer is the server API for C4exwalletd service.
 // All implementations must embed UnimplementedC4exwalletdServer
 // for forward compatibility
@@ -135,6 +236,17 @@ type C4exwalletdServer interface {
 	Send(context.Context, *SendRequest) (*SendResponse, error)
 	// Since SignRequest contains a password - this command should only be used on a trusted or secure connection
 	Sign(context.Context, *SignRequest) (*SignResponse, error)
+	GetTransactionHistory(context.Context, *GetTransactionHistoryRequest) (*GetTransactionHistoryResponse, error)
+	ListUTXOs(context.Context, *ListUTXOsRequest) (*ListUTXOsResponse, error)
+	FreezeUTXOs(context.Context, *FreezeUTXOsRequest) (*FreezeUTXOsResponse, error)
+	UnfreezeUTXOs(context.Context, *UnfreezeUTXOsRequest) (*UnfreezeUTXOsResponse, error)
+	CreateConsolidationTransactions(context.Context, *CreateConsolidationTransactionsRequest) (*CreateConsolidationTransactionsResponse, error)
+	// Since CreateAccountRequest contains a password - this command should only be used on a trusted or secure connection
+	CreateAccount(context.Context, *CreateAccountRequest) (*CreateAccountResponse, error)
+	ListAccounts(context.Context, *ListAccountsRequest) (*ListAccountsResponse, error)
+	GetAddressDerivationPath(context.Context, *GetAddressDerivationPathRequest) (*GetAddressDerivationPathResponse, error)
+	CreatePaymentRequest(context.Context, *CreatePaymentRequestRequest) (*CreatePaymentRequestResponse, error)
+	GetPaymentRequest(context.Context, *GetPaymentRequestRequest) (*GetPaymentRequestResponse, error)
 	mustEmbedUnimplementedC4exwalletdServer()
 }
 
@@ -169,6 +281,36 @@ func (UnimplementedC4exwalletdServer) Send(context.Context, *SendRequest) (*Send
 func (UnimplementedC4exwalletdServer) Sign(context.Context, *SignRequest) (*SignResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Sign not implemented")
 }
+func (UnimplementedC4exwalletdServer) GetTransactionHistory(context.Context, *GetTransactionHistoryRequest) (*GetTransactionHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTransactionHistory not implemented")
+}
+func (UnimplementedC4exwalletdServer) ListUTXOs(context.Context, *ListUTXOsRequest) (*ListUTXOsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListUTXOs not implemented")
+}
+func (UnimplementedC4exwalletdServer) FreezeUTXOs(context.Context, *FreezeUTXOsRequest) (*FreezeUTXOsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FreezeUTXOs not implemented")
+}
+func (UnimplementedC4exwalletdServer) UnfreezeUTXOs(context.Context, *UnfreezeUTXOsRequest) (*UnfreezeUTXOsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnfreezeUTXOs not implemented")
+}
+func (UnimplementedC4exwalletdServer) CreateConsolidationTransactions(context.Context, *CreateConsolidationTransactionsRequest) (*CreateConsolidationTransactionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateConsolidationTransactions not implemented")
+}
+func (UnimplementedC4exwalletdServer) CreateAccount(context.Context, *CreateAccountRequest) (*CreateAccountResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateAccount not implemented")
+}
+func (UnimplementedC4exwalletdServer) ListAccounts(context.Context, *ListAccountsRequest) (*ListAccountsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAccounts not implemented")
+}
+func (UnimplementedC4exwalletdServer) GetAddressDerivationPath(context.Context, *GetAddressDerivationPathRequest) (*GetAddressDerivationPathResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAddressDerivationPath not implemented")
+}
+func (UnimplementedC4exwalletdServer) CreatePaymentRequest(context.Context, *CreatePaymentRequestRequest) (*CreatePaymentRequestResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreatePaymentRequest not implemented")
+}
+func (UnimplementedC4exwalletdServer) GetPaymentRequest(context.Context, *GetPaymentRequestRequest) (*GetPaymentRequestResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPaymentRequest not implemented")
+}
 func (UnimplementedC4exwalletdServer) mustEmbedUnimplementedC4exwalletdServer() {}
 
 // UnsafeC4exwalletdServer may be embedded to opt out of forward compatibility for this service.
@@ -344,6 +486,186 @@ func _C4exwalletd_Sign_Handler(srv interface{}, ctx context.Context, dec func(in
 	return interceptor(ctx, in, info, handler)
 }
 
+func _C4exwalletd_GetTransactionHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTransactionHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(C4exwalletdServer).GetTransactionHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/c4exwalletd.c4exwalletd/GetTransactionHistory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(C4exwalletdServer).GetTransactionHistory(ctx, req.(*GetTransactionHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _C4exwalletd_ListUTXOs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListUTXOsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(C4exwalletdServer).ListUTXOs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/c4exwalletd.c4exwalletd/ListUTXOs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(C4exwalletdServer).ListUTXOs(ctx, req.(*ListUTXOsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _C4exwalletd_FreezeUTXOs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FreezeUTXOsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(C4exwalletdServer).FreezeUTXOs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/c4exwalletd.c4exwalletd/FreezeUTXOs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(C4exwalletdServer).FreezeUTXOs(ctx, req.(*FreezeUTXOsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _C4exwalletd_UnfreezeUTXOs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnfreezeUTXOsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(C4exwalletdServer).UnfreezeUTXOs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/c4exwalletd.c4exwalletd/UnfreezeUTXOs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(C4exwalletdServer).UnfreezeUTXOs(ctx, req.(*UnfreezeUTXOsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _C4exwalletd_CreateConsolidationTransactions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateConsolidationTransactionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(C4exwalletdServer).CreateConsolidationTransactions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/c4exwalletd.c4exwalletd/CreateConsolidationTransactions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(C4exwalletdServer).CreateConsolidationTransactions(ctx, req.(*CreateConsolidationTransactionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _C4exwalletd_CreateAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(C4exwalletdServer).CreateAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/c4exwalletd.c4exwalletd/CreateAccount",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(C4exwalletdServer).CreateAccount(ctx, req.(*CreateAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _C4exwalletd_ListAccounts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAccountsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(C4exwalletdServer).ListAccounts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/c4exwalletd.c4exwalletd/ListAccounts",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(C4exwalletdServer).ListAccounts(ctx, req.(*ListAccountsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _C4exwalletd_GetAddressDerivationPath_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAddressDerivationPathRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(C4exwalletdServer).GetAddressDerivationPath(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/c4exwalletd.c4exwalletd/GetAddressDerivationPath",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(C4exwalletdServer).GetAddressDerivationPath(ctx, req.(*GetAddressDerivationPathRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _C4exwalletd_CreatePaymentRequest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreatePaymentRequestRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(C4exwalletdServer).CreatePaymentRequest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/c4exwalletd.c4exwalletd/CreatePaymentRequest",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(C4exwalletdServer).CreatePaymentRequest(ctx, req.(*CreatePaymentRequestRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _C4exwalletd_GetPaymentRequest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPaymentRequestRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(C4exwalletdServer).GetPaymentRequest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/c4exwalletd.c4exwalletd/GetPaymentRequest",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(C4exwalletdServer).GetPaymentRequest(ctx, req.(*GetPaymentRequestRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // C4exwalletd_ServiceDesc is the grpc.ServiceDesc for C4exwalletd service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -387,6 +709,46 @@ var C4exwalletd_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Sign",
 			Handler:    _C4exwalletd_Sign_Handler,
 		},
+		{
+			MethodName: "GetTransactionHistory",
+			Handler:    _C4exwalletd_GetTransactionHistory_Handler,
+		},
+		{
+			MethodName: "ListUTXOs",
+			Handler:    _C4exwalletd_ListUTXOs_Handler,
+		},
+		{
+			MethodName: "FreezeUTXOs",
+			Handler:    _C4exwalletd_FreezeUTXOs_Handler,
+		},
+		{
+			MethodName: "UnfreezeUTXOs",
+			Handler:    _C4exwalletd_UnfreezeUTXOs_Handler,
+		},
+		{
+			MethodName: "CreateConsolidationTransactions",
+			Handler:    _C4exwalletd_CreateConsolidationTransactions_Handler,
+		},
+		{
+			MethodName: "CreateAccount",
+			Handler:    _C4exwalletd_CreateAccount_Handler,
+		},
+		{
+			MethodName: "ListAccounts",
+			Handler:    _C4exwalletd_ListAccounts_Handler,
+		},
+		{
+			MethodName: "GetAddressDerivationPath",
+			Handler:    _C4exwalletd_GetAddressDerivationPath_Handler,
+		},
+		{
+			MethodName: "CreatePaymentRequest",
+			Handler:    _C4exwalletd_CreatePaymentRequest_Handler,
+		},
+		{
+			MethodName: "GetPaymentRequest",
+			Handler:    _C4exwalletd_GetPaymentRequest_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "c4exwalletd.proto",
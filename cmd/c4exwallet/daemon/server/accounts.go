@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+
+	"github.com/c4ei/c4exd/cmd/c4exwallet/daemon/pb"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/keys"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/signer"
+	"github.com/pkg/errors"
+)
+
+func (s *server) CreateAccount(_ context.Context, request *pb.CreateAccountRequest) (*pb.CreateAccountResponse, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.isSynced() {
+		return nil, errors.Errorf("wallet daemon is not synced yet, %s", s.formatSyncStateReport())
+	}
+
+	err := keys.ValidateAccountName(request.Name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.keysFile.FindAccount(request.Name); err == nil {
+		return nil, errors.Errorf("the wallet already has an account named %s", request.Name)
+	}
+
+	index := s.keysFile.NextAccountIndex()
+	signerExtendedPublicKeys, err := signer.AccountExtendedPublicKeys(s.params, s.keysFile, request.Password, index)
+	if err != nil {
+		return nil, err
+	}
+
+	numOtherKeys := len(s.keysFile.ExtendedPublicKeys) - len(signerExtendedPublicKeys)
+	if len(request.ExtendedPublicKeys) != numOtherKeys {
+		return nil, errors.Errorf("the wallet holds %d of the %d keys of an account, so the extended public keys "+
+			"of account %d of the other %d keys are required, while %d were given", len(signerExtendedPublicKeys),
+			len(s.keysFile.ExtendedPublicKeys), index, numOtherKeys, len(request.ExtendedPublicKeys))
+	}
+
+	extendedPublicKeys := append(signerExtendedPublicKeys, request.ExtendedPublicKeys...)
+	seenExtendedPublicKeys := make(map[string]struct{}, len(extendedPublicKeys))
+	for _, extendedPublicKey := range extendedPublicKeys {
+		err := libc4exwallet.ValidateExtendedPublicKey(s.params, extendedPublicKey)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := seenExtendedPublicKeys[extendedPublicKey]; exists {
+			return nil, errors.Errorf("extended public key %s appears more than once", extendedPublicKey)
+		}
+		seenExtendedPublicKeys[extendedPublicKey] = struct{}{}
+	}
+
+	// For a read only wallet the cosigner index is 0
+	cosignerIndex := uint32(0)
+	if len(signerExtendedPublicKeys) > 0 {
+		cosignerIndex, err = libc4exwallet.MinimumCosignerIndex(signerExtendedPublicKeys, extendedPublicKeys)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	account, err := s.keysFile.AddAccount(request.Name, extendedPublicKeys, cosignerIndex)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("Created account %d (%s)", account.Index, account.Name)
+
+	// The account is already part of the wallet, so a sync failure is only logged,
+	// and the next full refresh completes the sync
+	err = s.syncNewAccount(account)
+	if err != nil {
+		log.Warnf("Failed syncing the new account %s: %s", account.Name, err)
+	}
+
+	return &pb.CreateAccountResponse{Account: accountToPB(account)}, nil
+}
+
+func (s *server) ListAccounts(_ context.Context, _ *pb.ListAccountsRequest) (*pb.ListAccountsResponse, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	accounts := s.keysFile.Accounts()
+	pbAccounts := make([]*pb.Account, len(accounts))
+	for i, account := range accounts {
+		pbAccounts[i] = accountToPB(account)
+	}
+	return &pb.ListAccountsResponse{Accounts: pbAccounts}, nil
+}
+
+func accountToPB(account *keys.Account) *pb.Account {
+	return &pb.Account{
+		Index:                 account.Index,
+		Name:                  account.Name,
+		ExtendedPublicKeys:    account.ExtendedPublicKeys,
+		LastUsedExternalIndex: account.LastUsedExternalIndex(),
+		LastUsedInternalIndex: account.LastUsedInternalIndex(),
+	}
+}
+
+// syncNewAccount brings a new account to the sync state of the rest of the wallet: the used addresses
+// of the account are collected up to the index the other accounts were scanned to, and its addresses
+// are watched up to the index the addresses of the other accounts are watched to. A new account may
+// have funds already if the wallet was restored from its mnemonics.
+func (s *server) syncNewAccount(account *keys.Account) error {
+	accounts := []*keys.Account{account}
+	for start := uint32(0); start < s.nextSyncStartIndex; start += numIndexesToQueryForRecentAddresses {
+		end := start + numIndexesToQueryForRecentAddresses
+		if end > s.nextSyncStartIndex {
+			end = s.nextSyncStartIndex
+		}
+		err := s.collectAddresses(accounts, start, end)
+		if err != nil {
+			return err
+		}
+	}
+
+	if s.isRegisteredForUTXOsChanged {
+		for start := uint32(0); start < s.nextWatchedIndex; start += numIndexesToQueryForRecentAddresses {
+			end := start + numIndexesToQueryForRecentAddresses
+			if end > s.nextWatchedIndex {
+				end = s.nextWatchedIndex
+			}
+			addresses, err := s.addressesToQuery(accounts, start, end)
+			if err != nil {
+				return err
+			}
+			err = s.rpcClient.AddUTXOsChangedNotificationAddresses(addresses.strings())
+			if err != nil {
+				return err
+			}
+			for addressString, address := range addresses {
+				s.watchedAddresses[addressString] = address
+			}
+		}
+	}
+
+	// The account may have used addresses beyond the ones the other accounts are watched up to
+	err := s.watchRecentAddresses()
+	if err != nil {
+		return err
+	}
+	return s.refreshUTXOs()
+}
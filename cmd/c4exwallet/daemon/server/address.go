@@ -5,19 +5,22 @@ import (
 	"fmt"
 
 	"github.com/c4ei/c4exd/cmd/c4exwallet/daemon/pb"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/keys"
 	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet"
 	"github.com/c4ei/c4exd/util"
 	"github.com/pkg/errors"
 )
 
-func (s *server) changeAddress(useExisting bool, fromAddresses []*walletAddress) (util.Address, *walletAddress, error) {
+func (s *server) changeAddress(account *keys.Account, useExisting bool, fromAddresses []*walletAddress) (
+	util.Address, *walletAddress, error) {
+
 	var walletAddr *walletAddress
 	if len(fromAddresses) != 0 && useExisting {
 		walletAddr = fromAddresses[0]
 	} else {
 		internalIndex := uint32(0)
 		if !useExisting {
-			err := s.keysFile.SetLastUsedInternalIndex(s.keysFile.LastUsedInternalIndex() + 1)
+			err := account.SetLastUsedInternalIndex(account.LastUsedInternalIndex() + 1)
 			if err != nil {
 				return nil, nil, err
 			}
@@ -27,18 +30,18 @@ func (s *server) changeAddress(useExisting bool, fromAddresses []*walletAddress)
 				return nil, nil, err
 			}
 
-			internalIndex = s.keysFile.LastUsedInternalIndex()
+			internalIndex = account.LastUsedInternalIndex()
 		}
 
 		walletAddr = &walletAddress{
+			account:       account.Index,
 			index:         internalIndex,
-			cosignerIndex: s.keysFile.CosignerIndex,
+			cosignerIndex: account.CosignerIndex,
 			keyChain:      libc4exwallet.InternalKeychain,
 		}
 	}
 
-	path := s.walletAddressPath(walletAddr)
-	address, err := libc4exwallet.Address(s.params, s.keysFile.ExtendedPublicKeys, s.keysFile.MinimumSignatures, path, s.keysFile.ECDSA)
+	address, err := s.walletAddressAddress(walletAddr)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -53,19 +56,24 @@ func (s *server) ShowAddresses(_ context.Context, request *pb.ShowAddressesReque
 		return nil, errors.Errorf("wallet daemon is not synced yet, %s", s.formatSyncStateReport())
 	}
 
-	addresses := make([]string, s.keysFile.LastUsedExternalIndex())
-	for i := uint32(1); i <= s.keysFile.LastUsedExternalIndex(); i++ {
+	account, err := s.keysFile.FindAccount(request.Account)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := make([]string, account.LastUsedExternalIndex())
+	for i := uint32(1); i <= account.LastUsedExternalIndex(); i++ {
 		walletAddr := &walletAddress{
+			account:       account.Index,
 			index:         i,
-			cosignerIndex: s.keysFile.CosignerIndex,
+			cosignerIndex: account.CosignerIndex,
 			keyChain:      libc4exwallet.ExternalKeychain,
 		}
-		path := s.walletAddressPath(walletAddr)
-		address, err := libc4exwallet.Address(s.params, s.keysFile.ExtendedPublicKeys, s.keysFile.MinimumSignatures, path, s.keysFile.ECDSA)
+		address, err := s.walletAddressString(walletAddr)
 		if err != nil {
 			return nil, err
 		}
-		addresses[i-1] = address.String()
+		addresses[i-1] = address
 	}
 
 	return &pb.ShowAddressesResponse{Address: addresses}, nil
@@ -79,33 +87,42 @@ func (s *server) NewAddress(_ context.Context, request *pb.NewAddressRequest) (*
 		return nil, errors.Errorf("wallet daemon is not synced yet, %s", s.formatSyncStateReport())
 	}
 
-	err := s.keysFile.SetLastUsedExternalIndex(s.keysFile.LastUsedExternalIndex() + 1)
+	account, err := s.keysFile.FindAccount(request.Account)
 	if err != nil {
 		return nil, err
 	}
 
-	err = s.keysFile.Save()
+	address, err := s.newReceiveAddress(account)
 	if err != nil {
 		return nil, err
 	}
 
-	walletAddr := &walletAddress{
-		index:         s.keysFile.LastUsedExternalIndex(),
-		cosignerIndex: s.keysFile.CosignerIndex,
-		keyChain:      libc4exwallet.ExternalKeychain,
+	return &pb.NewAddressResponse{Address: address}, nil
+}
+
+// newReceiveAddress marks the next external address of the account as used, and returns it
+func (s *server) newReceiveAddress(account *keys.Account) (string, error) {
+	err := account.SetLastUsedExternalIndex(account.LastUsedExternalIndex() + 1)
+	if err != nil {
+		return "", err
 	}
-	path := s.walletAddressPath(walletAddr)
-	address, err := libc4exwallet.Address(s.params, s.keysFile.ExtendedPublicKeys, s.keysFile.MinimumSignatures, path, s.keysFile.ECDSA)
+
+	err = s.keysFile.Save()
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
-	return &pb.NewAddressResponse{Address: address.String()}, nil
+	walletAddr := &walletAddress{
+		account:       account.Index,
+		index:         account.LastUsedExternalIndex(),
+		cosignerIndex: account.CosignerIndex,
+		keyChain:      libc4exwallet.ExternalKeychain,
+	}
+	return s.walletAddressString(walletAddr)
 }
 
 func (s *server) walletAddressString(wAddr *walletAddress) (string, error) {
-	path := s.walletAddressPath(wAddr)
-	addr, err := libc4exwallet.Address(s.params, s.keysFile.ExtendedPublicKeys, s.keysFile.MinimumSignatures, path, s.keysFile.ECDSA)
+	addr, err := s.walletAddressAddress(wAddr)
 	if err != nil {
 		return "", err
 	}
@@ -113,6 +130,16 @@ func (s *server) walletAddressString(wAddr *walletAddress) (string, error) {
 	return addr.String(), nil
 }
 
+func (s *server) walletAddressAddress(wAddr *walletAddress) (util.Address, error) {
+	account, err := s.keysFile.Account(wAddr.account)
+	if err != nil {
+		return nil, err
+	}
+
+	path := s.walletAddressPath(wAddr)
+	return libc4exwallet.Address(s.params, account.ExtendedPublicKeys, s.keysFile.MinimumSignatures, path, s.keysFile.ECDSA)
+}
+
 func (s *server) walletAddressPath(wAddr *walletAddress) string {
 	if s.isMultisig() {
 		return fmt.Sprintf("m/%d/%d/%d", wAddr.cosignerIndex, wAddr.keyChain, wAddr.index)
@@ -123,3 +150,61 @@ func (s *server) walletAddressPath(wAddr *walletAddress) string {
 func (s *server) isMultisig() bool {
 	return len(s.keysFile.ExtendedPublicKeys) > 1
 }
+
+func (s *server) GetAddressDerivationPath(_ context.Context, request *pb.GetAddressDerivationPathRequest) (
+	*pb.GetAddressDerivationPathResponse, error) {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	walletAddr, err := s.findWalletAddress(request.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.GetAddressDerivationPathResponse{
+		Account:        walletAddr.account,
+		DerivationPath: s.walletAddressPath(walletAddr),
+	}, nil
+}
+
+// findWalletAddress returns the wallet address of the given address. It's either an address the
+// daemon found funds in, or one that was handed out as a receive or change address, even if
+// it was never used
+func (s *server) findWalletAddress(addressString string) (*walletAddress, error) {
+	address, err := util.DecodeAddress(addressString, s.params.Prefix)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid address %s", addressString)
+	}
+	addressString = address.String()
+
+	if walletAddr, ok := s.addressSet[addressString]; ok {
+		return walletAddr, nil
+	}
+
+	for _, account := range s.keysFile.Accounts() {
+		lastUsedIndexes := map[uint8]uint32{
+			libc4exwallet.ExternalKeychain: account.LastUsedExternalIndex(),
+			libc4exwallet.InternalKeychain: account.LastUsedInternalIndex(),
+		}
+		for keyChain, lastUsedIndex := range lastUsedIndexes {
+			for index := uint32(0); index <= lastUsedIndex; index++ {
+				walletAddr := &walletAddress{
+					account:       account.Index,
+					index:         index,
+					cosignerIndex: account.CosignerIndex,
+					keyChain:      keyChain,
+				}
+				candidate, err := s.walletAddressString(walletAddr)
+				if err != nil {
+					return nil, err
+				}
+				if candidate == addressString {
+					return walletAddr, nil
+				}
+			}
+		}
+	}
+
+	return nil, errors.Errorf("address %s doesn't belong to the wallet", addressString)
+}
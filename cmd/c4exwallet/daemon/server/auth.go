@@ -0,0 +1,279 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"strings"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// SecurityConfig configures TLS and authentication for the daemon's gRPC server.
+// Authentication is enabled if any token or client CA file is set, in which case
+// every call must present either a bearer token or a client certificate signed by
+// one of the client CAs. The read-only token and CA grant access to the calls
+// that can't change the wallet or spend from it only.
+type SecurityConfig struct {
+	TLSCertFile string
+	TLSKeyFile  string
+
+	AuthTokenFile         string
+	ReadOnlyAuthTokenFile string
+
+	ClientCAFile         string
+	ReadOnlyClientCAFile string
+}
+
+func (sc *SecurityConfig) isTLSEnabled() bool {
+	return sc != nil && sc.TLSCertFile != ""
+}
+
+func (sc *SecurityConfig) isAuthEnabled() bool {
+	return sc != nil && (sc.AuthTokenFile != "" || sc.ReadOnlyAuthTokenFile != "" ||
+		sc.ClientCAFile != "" || sc.ReadOnlyClientCAFile != "")
+}
+
+// readOnlyMethods are the calls that are allowed with read-only access
+var readOnlyMethods = map[string]struct{}{
+	"/c4exwalletd.c4exwalletd/GetBalance":                {},
+	"/c4exwalletd.c4exwalletd/GetExternalSpendableUTXOs": {},
+	"/c4exwalletd.c4exwalletd/ShowAddresses":             {},
+	"/c4exwalletd.c4exwalletd/GetTransactionHistory":     {},
+	"/c4exwalletd.c4exwalletd/ListUTXOs":                 {},
+	"/c4exwalletd.c4exwalletd/ListAccounts":              {},
+	"/c4exwalletd.c4exwalletd/GetAddressDerivationPath":  {},
+	"/c4exwalletd.c4exwalletd/GetPaymentRequest":         {},
+}
+
+type accessLevel int
+
+const (
+	accessNone accessLevel = iota
+	accessReadOnly
+	accessFull
+)
+
+type authenticator struct {
+	authToken         []byte
+	readOnlyAuthToken []byte
+
+	clientCAs         *x509.CertPool
+	readOnlyClientCAs *x509.CertPool
+}
+
+// serverOptions returns the gRPC server options that apply the given security config
+func serverOptions(config *SecurityConfig) ([]grpc.ServerOption, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	auth := &authenticator{}
+	var err error
+	auth.authToken, err = readAuthToken(config.AuthTokenFile)
+	if err != nil {
+		return nil, err
+	}
+	auth.readOnlyAuthToken, err = readAuthToken(config.ReadOnlyAuthTokenFile)
+	if err != nil {
+		return nil, err
+	}
+	auth.clientCAs, err = readCertPool(config.ClientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	auth.readOnlyClientCAs, err = readCertPool(config.ReadOnlyClientCAFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var options []grpc.ServerOption
+	if config.isTLSEnabled() {
+		certificate, err := tls.LoadX509KeyPair(config.TLSCertFile, config.TLSKeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "error loading the TLS certificate")
+		}
+		tlsConfig := &tls.Config{
+			Certificates: []tls.Certificate{certificate},
+			MinVersion:   tls.VersionTLS12,
+		}
+
+		// The client certificate is verified against both pools, and the pool that
+		// verifies it decides its access level later
+		if auth.clientCAs != nil || auth.readOnlyClientCAs != nil {
+			tlsConfig.ClientCAs, err = readCertPool(config.ClientCAFile, config.ReadOnlyClientCAFile)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		}
+		options = append(options, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	} else if auth.clientCAs != nil || auth.readOnlyClientCAs != nil {
+		return nil, errors.New("client certificates require TLS")
+	}
+
+	if auth.isEnabled() {
+		options = append(options,
+			grpc.UnaryInterceptor(auth.unaryInterceptor),
+			grpc.StreamInterceptor(auth.streamInterceptor))
+	}
+	return options, nil
+}
+
+func (a *authenticator) isEnabled() bool {
+	return a.authToken != nil || a.readOnlyAuthToken != nil || a.clientCAs != nil || a.readOnlyClientCAs != nil
+}
+
+func (a *authenticator) unaryInterceptor(ctx context.Context, request interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+
+	err := a.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, request)
+}
+
+func (a *authenticator) streamInterceptor(server interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) error {
+
+	err := a.authorize(stream.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(server, stream)
+}
+
+func (a *authenticator) authorize(ctx context.Context, fullMethod string) error {
+	level := a.accessLevel(ctx)
+	if level == accessNone {
+		return status.Error(codes.Unauthenticated, "a valid auth token or client certificate is required")
+	}
+	if level == accessReadOnly {
+		if _, ok := readOnlyMethods[fullMethod]; !ok {
+			return status.Errorf(codes.PermissionDenied, "%s requires full access, but the credentials are read-only",
+				fullMethod)
+		}
+	}
+	return nil
+}
+
+// accessLevel returns the highest access level that the credentials of the call grant
+func (a *authenticator) accessLevel(ctx context.Context) accessLevel {
+	level := accessNone
+
+	if token, ok := bearerToken(ctx); ok {
+		if a.authToken != nil && subtle.ConstantTimeCompare(token, a.authToken) == 1 {
+			return accessFull
+		}
+		if a.readOnlyAuthToken != nil && subtle.ConstantTimeCompare(token, a.readOnlyAuthToken) == 1 {
+			level = accessReadOnly
+		}
+	}
+
+	if clientCertificate, ok := verifiedClientCertificate(ctx); ok {
+		if isSignedBy(clientCertificate, a.clientCAs) {
+			return accessFull
+		}
+		if isSignedBy(clientCertificate, a.readOnlyClientCAs) {
+			level = accessReadOnly
+		}
+	}
+
+	return level
+}
+
+func bearerToken(ctx context.Context) ([]byte, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	for _, authorization := range md.Get("authorization") {
+		const prefix = "Bearer "
+		if strings.HasPrefix(authorization, prefix) {
+			return []byte(strings.TrimPrefix(authorization, prefix)), true
+		}
+	}
+	return nil, false
+}
+
+func verifiedClientCertificate(ctx context.Context) (*x509.Certificate, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 {
+		return nil, false
+	}
+	return tlsInfo.State.VerifiedChains[0][0], true
+}
+
+func isSignedBy(certificate *x509.Certificate, certificateAuthorities *x509.CertPool) bool {
+	if certificateAuthorities == nil {
+		return false
+	}
+	_, err := certificate.Verify(x509.VerifyOptions{
+		Roots:     certificateAuthorities,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	return err == nil
+}
+
+func readAuthToken(path string) ([]byte, error) {
+	if path == "" {
+		return nil, nil
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading the auth token")
+	}
+	token := strings.TrimSpace(string(content))
+	if token == "" {
+		return nil, errors.Errorf("the auth token file %s is empty", path)
+	}
+	return []byte(token), nil
+}
+
+// readCertPool returns a pool of the certificates in the given PEM files, or nil if no file is given
+func readCertPool(paths ...string) (*x509.CertPool, error) {
+	var certPool *x509.CertPool
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		pem, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrap(err, "error reading the client CA file")
+		}
+		if certPool == nil {
+			certPool = x509.NewCertPool()
+		}
+		if !certPool.AppendCertsFromPEM(pem) {
+			return nil, errors.Errorf("no certificates found in %s", path)
+		}
+	}
+	return certPool, nil
+}
+
+// isLoopbackAddress returns whether the given listen address only accepts local connections
+func isLoopbackAddress(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
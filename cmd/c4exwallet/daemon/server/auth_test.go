@@ -0,0 +1,120 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestAuthorize(t *testing.T) {
+	fullCA, fullCAKey := certificateForTest(t, "full CA", nil, nil)
+	readOnlyCA, readOnlyCAKey := certificateForTest(t, "read-only CA", nil, nil)
+	fullClient, _ := certificateForTest(t, "full client", fullCA, fullCAKey)
+	readOnlyClient, _ := certificateForTest(t, "read-only client", readOnlyCA, readOnlyCAKey)
+
+	auth := &authenticator{
+		authToken:         []byte("full-token"),
+		readOnlyAuthToken: []byte("read-only-token"),
+		clientCAs:         x509.NewCertPool(),
+		readOnlyClientCAs: x509.NewCertPool(),
+	}
+	auth.clientCAs.AddCert(fullCA)
+	auth.readOnlyClientCAs.AddCert(readOnlyCA)
+
+	withToken := func(token string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+	}
+	withCertificate := func(certificate, certificateAuthority *x509.Certificate) context.Context {
+		return peer.NewContext(context.Background(), &peer.Peer{
+			AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+				VerifiedChains: [][]*x509.Certificate{{certificate, certificateAuthority}},
+			}},
+		})
+	}
+
+	const readOnlyMethod = "/c4exwalletd.c4exwalletd/GetBalance"
+	const spendingMethod = "/c4exwalletd.c4exwalletd/Send"
+	tests := []struct {
+		name           string
+		ctx            context.Context
+		method         string
+		expectedStatus codes.Code
+	}{
+		{"no credentials", context.Background(), readOnlyMethod, codes.Unauthenticated},
+		{"wrong token", withToken("wrong-token"), readOnlyMethod, codes.Unauthenticated},
+		{"full token, read-only call", withToken("full-token"), readOnlyMethod, codes.OK},
+		{"full token, spending call", withToken("full-token"), spendingMethod, codes.OK},
+		{"read-only token, read-only call", withToken("read-only-token"), readOnlyMethod, codes.OK},
+		{"read-only token, spending call", withToken("read-only-token"), spendingMethod, codes.PermissionDenied},
+		{"full certificate, spending call", withCertificate(fullClient, fullCA), spendingMethod, codes.OK},
+		{"read-only certificate, read-only call", withCertificate(readOnlyClient, readOnlyCA), readOnlyMethod, codes.OK},
+		{"read-only certificate, spending call", withCertificate(readOnlyClient, readOnlyCA), spendingMethod,
+			codes.PermissionDenied},
+	}
+	for _, test := range tests {
+		err := auth.authorize(test.ctx, test.method)
+		if status.Code(err) != test.expectedStatus {
+			t.Errorf("%s: expected %s, got %v", test.name, test.expectedStatus, err)
+		}
+	}
+}
+
+func TestIsLoopbackAddress(t *testing.T) {
+	for _, address := range []string{"localhost:8082", "127.0.0.1:8082", "[::1]:8082"} {
+		if !isLoopbackAddress(address) {
+			t.Errorf("%s is expected to be a loopback address", address)
+		}
+	}
+	for _, address := range []string{"0.0.0.0:8082", ":8082", "192.168.1.1:8082", "example.com:8082"} {
+		if isLoopbackAddress(address) {
+			t.Errorf("%s is not expected to be a loopback address", address)
+		}
+	}
+}
+
+// certificateForTest creates a certificate signed by the given parent, or a self-signed CA if parent is nil
+func certificateForTest(t *testing.T, commonName string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (
+	*x509.Certificate, *ecdsa.PrivateKey) {
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign
+		parent = template
+		parentKey = key
+	}
+
+	certificateBytes, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %s", err)
+	}
+	certificate, err := x509.ParseCertificate(certificateBytes)
+	if err != nil {
+		t.Fatalf("ParseCertificate: %s", err)
+	}
+	return certificate, key
+}
@@ -3,68 +3,207 @@ package server
 import (
 	"context"
 
+	"github.com/c4ei/c4exd/app/appmessage"
 	"github.com/c4ei/c4exd/cmd/c4exwallet/daemon/pb"
-	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/keys"
 )
 
-type balancesType struct{ available, pending uint64 }
-type balancesMapType map[*walletAddress]*balancesType
+// balancesType is a balance broken down by how soon it can be spent. pending is
+// immatureCoinbase plus confirming.
+type balancesType struct {
+	available, immatureCoinbase, confirming, unconfirmedIncoming, outgoingInFlight uint64
+}
+
+// balancesMapType is keyed by the address itself rather than by a pointer to it, since the
+// address set, the watched addresses and the UTXOs may hold different instances of the same address
+type balancesMapType map[walletAddress]*balancesType
+
+func (b *balancesType) pending() uint64 {
+	return b.immatureCoinbase + b.confirming
+}
 
-func (s *server) GetBalance(_ context.Context, _ *pb.GetBalanceRequest) (*pb.GetBalanceResponse, error) {
+func (b *balancesType) add(other *balancesType) {
+	b.available += other.available
+	b.immatureCoinbase += other.immatureCoinbase
+	b.confirming += other.confirming
+	b.unconfirmedIncoming += other.unconfirmedIncoming
+	b.outgoingInFlight += other.outgoingInFlight
+}
+
+// GetBalance returns the balance of the wallet, along with the balances of each of its addresses and accounts.
+// If an account is requested, only its balance is returned.
+func (s *server) GetBalance(_ context.Context, request *pb.GetBalanceRequest) (*pb.GetBalanceResponse, error) {
+	// Funds may be sent to an address that was handed out but not used yet, such as a new change address.
+	// The node is queried without holding the lock, so that a slow node doesn't block the sync loop.
 	s.lock.RLock()
-	defer s.lock.RUnlock()
+	addresses := s.trackedAddresses()
+	s.lock.RUnlock()
 
 	dagInfo, err := s.rpcClient.GetBlockDAGInfo()
 	if err != nil {
 		return nil, err
 	}
-	daaScore := dagInfo.VirtualDAAScore
-	maturity := s.params.BlockCoinbaseMaturity
-
-	balancesMap := make(balancesMapType, 0)
-	for _, entry := range s.utxosSortedByAmount {
-		amount := entry.UTXOEntry.Amount()
-		address := entry.address
-		balances, ok := balancesMap[address]
-		if !ok {
-			balances = new(balancesType)
-			balancesMap[address] = balances
-		}
-		if isUTXOSpendable(entry, daaScore, maturity) {
-			balances.available += amount
-		} else {
-			balances.pending += amount
+	mempoolEntries, err := s.rpcClient.GetMempoolEntriesByAddresses(addresses, false, false)
+	if err != nil {
+		return nil, err
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	accounts := s.keysFile.Accounts()
+	if request.Account != "" {
+		account, err := s.keysFile.FindAccount(request.Account)
+		if err != nil {
+			return nil, err
 		}
+		accounts = []*keys.Account{account}
+	}
+	accountBalances := make(map[uint32]*balancesType, len(accounts))
+	for _, account := range accounts {
+		accountBalances[account.Index] = new(balancesType)
 	}
 
-	addressBalances := make([]*pb.AddressBalances, len(balancesMap))
-	i := 0
-	var available, pending uint64
+	balancesMap := s.addressBalances(mempoolEntries.Entries, dagInfo.VirtualDAAScore, request.MinConfirmations,
+		accountBalances)
+
+	addressBalances := make([]*pb.AddressBalances, 0, len(balancesMap))
+	total := new(balancesType)
 	for walletAddress, balances := range balancesMap {
-		address, err := libc4exwallet.Address(s.params, s.keysFile.ExtendedPublicKeys, s.keysFile.MinimumSignatures, s.walletAddressPath(walletAddress), s.keysFile.ECDSA)
+		address, err := s.walletAddressString(&walletAddress)
 		if err != nil {
 			return nil, err
 		}
-		addressBalances[i] = &pb.AddressBalances{
-			Address:   address.String(),
-			Available: balances.available,
-			Pending:   balances.pending,
+		addressBalances = append(addressBalances, &pb.AddressBalances{
+			Address:             address,
+			Available:           balances.available,
+			Pending:             balances.pending(),
+			ImmatureCoinbase:    balances.immatureCoinbase,
+			Confirming:          balances.confirming,
+			UnconfirmedIncoming: balances.unconfirmedIncoming,
+			OutgoingInFlight:    balances.outgoingInFlight,
+		})
+		total.add(balances)
+		accountBalances[walletAddress.account].add(balances)
+	}
+
+	pbAccountBalances := make([]*pb.AccountBalance, len(accounts))
+	for i, account := range accounts {
+		balances := accountBalances[account.Index]
+		pbAccountBalances[i] = &pb.AccountBalance{
+			Index:               account.Index,
+			Name:                account.Name,
+			Available:           balances.available,
+			Pending:             balances.pending(),
+			ImmatureCoinbase:    balances.immatureCoinbase,
+			Confirming:          balances.confirming,
+			UnconfirmedIncoming: balances.unconfirmedIncoming,
+			OutgoingInFlight:    balances.outgoingInFlight,
 		}
-		i++
-		available += balances.available
-		pending += balances.pending
 	}
 
 	return &pb.GetBalanceResponse{
-		Available:       available,
-		Pending:         pending,
-		AddressBalances: addressBalances,
+		Available:           total.available,
+		Pending:             total.pending(),
+		AddressBalances:     addressBalances,
+		AccountBalances:     pbAccountBalances,
+		ImmatureCoinbase:    total.immatureCoinbase,
+		Confirming:          total.confirming,
+		UnconfirmedIncoming: total.unconfirmedIncoming,
+		OutgoingInFlight:    total.outgoingInFlight,
 	}, nil
 }
 
+// addressBalances returns the balances of the addresses of the given accounts, from the UTXOs of the
+// wallet and the mempool transactions that pay to or spend from its addresses. The outgoing in-flight
+// balance is made of the UTXOs the sync left out of the UTXO set because mempool transactions spend
+// them, and of the UTXOs in the set that mempool transactions spent since the sync last checked.
+func (s *server) addressBalances(mempoolEntries []*appmessage.MempoolEntryByAddress, virtualDAAScore uint64,
+	minConfirmations uint64, accounts map[uint32]*balancesType) balancesMapType {
+
+	balancesMap := make(balancesMapType)
+	addressBalances := func(address *walletAddress) *balancesType {
+		balances, ok := balancesMap[*address]
+		if !ok {
+			balances = new(balancesType)
+			balancesMap[*address] = balances
+		}
+		return balances
+	}
+
+	// A transaction that spends from several addresses of the wallet is listed under each of them
+	spentInMempool := make(map[appmessage.RPCOutpoint]struct{})
+	for _, entriesByAddress := range mempoolEntries {
+		walletAddress, ok := s.addressSet[entriesByAddress.Address]
+		if !ok {
+			walletAddress, ok = s.watchedAddresses[entriesByAddress.Address]
+			if !ok {
+				continue
+			}
+		}
+		if _, ok := accounts[walletAddress.account]; !ok {
+			continue
+		}
+		for _, entry := range entriesByAddress.Sending {
+			for _, input := range entry.Transaction.Inputs {
+				spentInMempool[*input.PreviousOutpoint] = struct{}{}
+			}
+		}
+		for _, entry := range entriesByAddress.Receiving {
+			for _, output := range entry.Transaction.Outputs {
+				if output.VerboseData != nil && output.VerboseData.ScriptPublicKeyAddress == entriesByAddress.Address {
+					addressBalances(walletAddress).unconfirmedIncoming += output.Amount
+				}
+			}
+		}
+	}
+
+	for _, utxo := range s.mempoolSpentUTXOs {
+		if _, ok := accounts[utxo.address.account]; !ok {
+			continue
+		}
+		addressBalances(utxo.address).outgoingInFlight += utxo.UTXOEntry.Amount()
+	}
+
+	for _, utxo := range s.utxosSortedByAmount {
+		if _, ok := accounts[utxo.address.account]; !ok {
+			continue
+		}
+		amount := utxo.UTXOEntry.Amount()
+		balances := addressBalances(utxo.address)
+
+		outpoint := appmessage.RPCOutpoint{TransactionID: utxo.Outpoint.TransactionID.String(), Index: utxo.Outpoint.Index}
+		if _, ok := spentInMempool[outpoint]; ok {
+			balances.outgoingInFlight += amount
+			continue
+		}
+
+		switch {
+		case !isUTXOSpendable(utxo, virtualDAAScore, s.params.BlockCoinbaseMaturity):
+			balances.immatureCoinbase += amount
+		case utxoConfirmations(utxo, virtualDAAScore) < minConfirmations:
+			balances.confirming += amount
+		default:
+			balances.available += amount
+		}
+	}
+
+	return balancesMap
+}
+
 func isUTXOSpendable(entry *walletUTXO, virtualDAAScore uint64, coinbaseMaturity uint64) bool {
 	if !entry.UTXOEntry.IsCoinbase() {
 		return true
 	}
 	return entry.UTXOEntry.BlockDAAScore()+coinbaseMaturity < virtualDAAScore
 }
+
+// utxoConfirmations returns the number of confirmations of the UTXO, which is the DAA score distance
+// from the block that accepted it
+func utxoConfirmations(entry *walletUTXO, virtualDAAScore uint64) uint64 {
+	// The virtual DAA score the node reports may lag behind the UTXO changes it notified about
+	if virtualDAAScore <= entry.UTXOEntry.BlockDAAScore() {
+		return 0
+	}
+	return virtualDAAScore - entry.UTXOEntry.BlockDAAScore()
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/c4ei/c4exd/app/appmessage"
+	"github.com/c4ei/c4exd/domain/consensus/model/externalapi"
+	"github.com/c4ei/c4exd/domain/consensus/utils/utxo"
+	"github.com/c4ei/c4exd/domain/dagconfig"
+)
+
+func TestAddressBalances(t *testing.T) {
+	address1 := &walletAddress{account: 0, index: 1}
+	address2 := &walletAddress{account: 0, index: 2}
+	otherAccountAddress := &walletAddress{account: 1, index: 1}
+
+	newUTXO := func(id byte, address *walletAddress, amount uint64, isCoinbase bool, blockDAAScore uint64) *walletUTXO {
+		return &walletUTXO{
+			Outpoint:  &externalapi.DomainOutpoint{TransactionID: *transactionIDForTest(id), Index: 0},
+			UTXOEntry: utxo.NewUTXOEntry(amount, &externalapi.ScriptPublicKey{}, isCoinbase, blockDAAScore),
+			address:   address,
+		}
+	}
+	spentUTXO := newUTXO(5, address2, 16, false, 100)
+	keptAsideUTXO := newUTXO(7, address2, 64, false, 100)
+	// A UTXO may point to a different instance of an address than the address set does
+	address1Copy := *address1
+
+	s := &server{
+		params: &dagconfig.SimnetParams,
+		addressSet: walletAddressSet{
+			"address1": address1,
+			"address2": address2,
+			"address3": otherAccountAddress,
+		},
+		utxosSortedByAmount: []*walletUTXO{
+			newUTXO(1, &address1Copy, 1, false, 100), // 900 confirmations
+			newUTXO(2, address1, 2, false, 995),      // 5 confirmations
+			newUTXO(3, address1, 4, true, 950),       // An immature coinbase
+			newUTXO(4, address2, 8, true, 100),       // A mature coinbase
+			spentUTXO,                                // Spent by a mempool transaction the sync didn't see yet
+			newUTXO(6, otherAccountAddress, 32, false, 100),
+		},
+		// The sync leaves the UTXOs spent by mempool transactions out of the UTXO set
+		mempoolSpentUTXOs: map[externalapi.DomainOutpoint]*walletUTXO{
+			*keptAsideUTXO.Outpoint:                             keptAsideUTXO,
+			{TransactionID: *transactionIDForTest(8), Index: 0}: newUTXO(8, otherAccountAddress, 128, false, 100),
+		},
+	}
+
+	mempoolEntries := []*appmessage.MempoolEntryByAddress{
+		{
+			Address: "address2",
+			Sending: []*appmessage.MempoolEntry{{Transaction: &appmessage.RPCTransaction{
+				Inputs: []*appmessage.RPCTransactionInput{{PreviousOutpoint: &appmessage.RPCOutpoint{
+					TransactionID: spentUTXO.Outpoint.TransactionID.String(),
+					Index:         spentUTXO.Outpoint.Index,
+				}}},
+			}}},
+		},
+		{
+			Address: "address1",
+			Receiving: []*appmessage.MempoolEntry{{Transaction: &appmessage.RPCTransaction{
+				Outputs: []*appmessage.RPCTransactionOutput{
+					{Amount: 64, VerboseData: &appmessage.RPCTransactionOutputVerboseData{ScriptPublicKeyAddress: "address1"}},
+					{Amount: 128, VerboseData: &appmessage.RPCTransactionOutputVerboseData{ScriptPublicKeyAddress: "someone-else"}},
+				},
+			}}},
+		},
+	}
+
+	accounts := map[uint32]*balancesType{0: new(balancesType)}
+	balancesMap := s.addressBalances(mempoolEntries, 1000, 10, accounts)
+
+	if _, ok := balancesMap[*otherAccountAddress]; ok {
+		t.Fatalf("the balance of an address of another account was returned")
+	}
+	expectedBalances := map[*walletAddress]balancesType{
+		address1: {available: 1, confirming: 2, immatureCoinbase: 4, unconfirmedIncoming: 64},
+		address2: {available: 8, outgoingInFlight: 80},
+	}
+	if len(balancesMap) != len(expectedBalances) {
+		t.Fatalf("expected the balances of %d addresses, got %d", len(expectedBalances), len(balancesMap))
+	}
+	for address, expected := range expectedBalances {
+		balances, ok := balancesMap[*address]
+		if !ok {
+			t.Fatalf("missing the balance of address %d", address.index)
+		}
+		if *balances != expected {
+			t.Fatalf("unexpected balance of address %d: expected %+v, got %+v", address.index, expected, *balances)
+		}
+	}
+	if balancesMap[*address1].pending() != 6 {
+		t.Fatalf("expected a pending balance of 6, got %d", balancesMap[*address1].pending())
+	}
+
+	// Without a minimum number of confirmations, nothing is confirming
+	balancesMap = s.addressBalances(mempoolEntries, 1000, 0, accounts)
+	if balancesMap[*address1].available != 3 || balancesMap[*address1].confirming != 0 {
+		t.Fatalf("unexpected balance without minimum confirmations: %+v", *balancesMap[*address1])
+	}
+}
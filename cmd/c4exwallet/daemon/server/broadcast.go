@@ -50,6 +50,15 @@ func (s *server) broadcast(transactions [][]byte, isDomain bool) ([]string, erro
 			return nil, err
 		}
 
+		outputsOfWallet, err := s.utxoSetOutputs()
+		if err != nil {
+			return nil, err
+		}
+		err = s.recordSpendInHistory(txIDs[i], tx, outputsOfWallet)
+		if err != nil {
+			return nil, err
+		}
+
 		for _, input := range tx.Inputs {
 			s.usedOutpoints[input.PreviousOutpoint] = time.Now()
 		}
@@ -63,6 +72,14 @@ func (s *server) broadcast(transactions [][]byte, isDomain bool) ([]string, erro
 	return txIDs, nil
 }
 
+func (s *server) isWalletAddress(address string) bool {
+	if _, ok := s.addressSet[address]; ok {
+		return true
+	}
+	_, ok := s.watchedAddresses[address]
+	return ok
+}
+
 func sendTransaction(client *rpcclient.RPCClient, tx *externalapi.DomainTransaction) (string, error) {
 	submitTransactionResponse, err := client.SubmitTransaction(appmessage.DomainTransactionToRPCTransaction(tx), false)
 	if err != nil {
@@ -0,0 +1,387 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/c4ei/c4exd/cmd/c4exwallet/daemon/pb"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/keys"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet/serialization"
+	"github.com/c4ei/c4exd/domain/consensus/model/externalapi"
+	"github.com/c4ei/c4exd/domain/consensus/utils/consensushashing"
+	"github.com/c4ei/c4exd/domain/consensus/utils/constants"
+	"github.com/c4ei/c4exd/domain/consensus/utils/utxo"
+	"github.com/c4ei/c4exd/domain/miningmanager/mempool"
+	"github.com/c4ei/c4exd/util"
+	"github.com/pkg/errors"
+)
+
+// defaultConsolidationFeeRate is the fee rate of consolidation transactions, in sompi per gram
+// of mass, if none is requested. It's the minimum fee rate that nodes relay by default.
+const defaultConsolidationFeeRate = 1
+
+var errNotEnoughUTXOsToConsolidate = errors.New("not enough UTXOs to consolidate")
+
+// AutoConsolidationConfig configures the daemon to periodically consolidate the small UTXOs
+// of the wallet while the mempool of the node is quiet
+type AutoConsolidationConfig struct {
+	Interval       time.Duration
+	Threshold      uint64
+	OutputCount    uint32
+	FeeRate        uint64
+	MinUTXOCount   uint32
+	MaxMempoolSize uint64
+	Password       string
+}
+
+type consolidation struct {
+	unsignedTransactions [][]byte
+	utxoCount            int
+	amount               uint64
+	fee                  uint64
+}
+
+func (s *server) CreateConsolidationTransactions(_ context.Context, request *pb.CreateConsolidationTransactionsRequest) (
+	*pb.CreateConsolidationTransactionsResponse, error) {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	account, err := s.keysFile.FindAccount(request.Account)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.createConsolidationTransactions(account, request.Threshold, request.OutputCount, request.FeeRate,
+		request.MinUtxoCount, request.DryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.CreateConsolidationTransactionsResponse{
+		UnsignedTransactions: result.unsignedTransactions,
+		UtxoCount:            uint32(result.utxoCount),
+		Amount:               result.amount,
+		Fee:                  result.fee,
+	}, nil
+}
+
+// createConsolidationTransactions creates transactions that spend the spendable UTXOs of the given account
+// with an amount below threshold into outputCount outputs to new change addresses of the account.
+//
+// UTXOs that don't fit into a single transaction are first compacted the same way
+// maybeSplitAndMergeTransaction does it: split transactions spend as many UTXOs as they can into a
+// single output each, and the next transactions spend the outputs of the split transactions while
+// they're still in the mempool. Unlike split transactions, every consolidation transaction pays a fee
+// of feeRate sompi per gram of its mass.
+func (s *server) createConsolidationTransactions(account *keys.Account, threshold uint64, outputCount uint32,
+	feeRate uint64, minUTXOCount uint32, dryRun bool) (*consolidation, error) {
+
+	if !s.isSynced() {
+		return nil, errors.Errorf("wallet daemon is not synced yet, %s", s.formatSyncStateReport())
+	}
+	if outputCount == 0 {
+		outputCount = 1
+	}
+	if feeRate == 0 {
+		feeRate = defaultConsolidationFeeRate
+	}
+
+	err := s.refreshUTXOs()
+	if err != nil {
+		return nil, err
+	}
+
+	utxos, err := s.consolidationCandidates(account, threshold)
+	if err != nil {
+		return nil, err
+	}
+	// Consolidating into as many outputs as there are UTXOs would only pay fees
+	requiredUTXOCount := int(outputCount) + 1
+	if int(minUTXOCount) > requiredUTXOCount {
+		requiredUTXOCount = int(minUTXOCount)
+	}
+	if len(utxos) < requiredUTXOCount {
+		return nil, errors.Wrapf(errNotEnoughUTXOsToConsolidate, "found %d UTXOs to consolidate, while at least %d "+
+			"are required", len(utxos), requiredUTXOCount)
+	}
+
+	result := &consolidation{utxoCount: len(utxos)}
+	for _, utxo := range utxos {
+		result.amount += utxo.UTXOEntry.Amount()
+	}
+
+	// A dry run uses the first change address instead of reserving new ones, which doesn't change the mass
+	outputAddresses := make([]util.Address, outputCount)
+	for i := range outputAddresses {
+		outputAddresses[i], _, err = s.changeAddress(account, dryRun, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var splitAddress util.Address
+	var splitWalletAddress *walletAddress
+	for {
+		finalInputCapacity, err := s.consolidationInputCapacity(account, utxos[0], outputAddresses)
+		if err != nil {
+			return nil, err
+		}
+		if finalInputCapacity < 1 {
+			return nil, errors.Errorf("%d outputs don't leave room for inputs under the maximum standard "+
+				"transaction mass of %d", outputCount, mempool.MaximumStandardTransactionMass)
+		}
+		if len(utxos) <= finalInputCapacity {
+			transaction, fee, err := s.createConsolidationTransaction(account, utxos, outputAddresses, feeRate)
+			if err != nil {
+				return nil, err
+			}
+			result.unsignedTransactions = append(result.unsignedTransactions, transaction)
+			result.fee += fee
+			return result, nil
+		}
+
+		if splitAddress == nil {
+			splitAddress, splitWalletAddress, err = s.changeAddress(account, dryRun, nil)
+			if err != nil {
+				return nil, err
+			}
+		}
+		splitInputCapacity, err := s.consolidationInputCapacity(account, utxos[0], []util.Address{splitAddress})
+		if err != nil {
+			return nil, err
+		}
+		if splitInputCapacity < 2 {
+			return nil, errors.Errorf("a split transaction can only have %d inputs", splitInputCapacity)
+		}
+
+		splitUTXOs := make([]*libc4exwallet.UTXO, 0, len(utxos)/splitInputCapacity+1)
+		for startIndex := 0; startIndex < len(utxos); startIndex += splitInputCapacity {
+			endIndex := startIndex + splitInputCapacity
+			if endIndex > len(utxos) {
+				endIndex = len(utxos)
+			}
+			// A split of a single UTXO would only pay a fee, so it's left to the next transactions
+			if endIndex-startIndex == 1 {
+				splitUTXOs = append(splitUTXOs, utxos[startIndex])
+				continue
+			}
+
+			transactionBytes, fee, err := s.createConsolidationTransaction(account, utxos[startIndex:endIndex],
+				[]util.Address{splitAddress}, feeRate)
+			if err != nil {
+				return nil, err
+			}
+			result.unsignedTransactions = append(result.unsignedTransactions, transactionBytes)
+			result.fee += fee
+
+			transaction, err := serialization.DeserializePartiallySignedTransaction(transactionBytes)
+			if err != nil {
+				return nil, err
+			}
+			output := transaction.Tx.Outputs[0]
+			splitUTXOs = append(splitUTXOs, &libc4exwallet.UTXO{
+				Outpoint: &externalapi.DomainOutpoint{
+					TransactionID: *consensushashing.TransactionID(transaction.Tx),
+					Index:         0,
+				},
+				UTXOEntry:      utxo.NewUTXOEntry(output.Value, output.ScriptPublicKey, false, constants.UnacceptedDAAScore),
+				DerivationPath: s.walletAddressPath(splitWalletAddress),
+			})
+		}
+		utxos = splitUTXOs
+	}
+}
+
+// consolidationCandidates returns the UTXOs of the given account with an amount below threshold
+// that can be spent right away. All of them are returned if threshold is 0.
+func (s *server) consolidationCandidates(account *keys.Account, threshold uint64) ([]*libc4exwallet.UTXO, error) {
+	dagInfo, err := s.rpcClient.GetBlockDAGInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []*libc4exwallet.UTXO
+	for _, utxo := range s.utxosSortedByAmount {
+		if utxo.address.account != account.Index {
+			continue
+		}
+		if threshold > 0 && utxo.UTXOEntry.Amount() >= threshold {
+			continue
+		}
+		if _, ok := s.frozenOutpoints[*utxo.Outpoint]; ok {
+			continue
+		}
+		if !isUTXOSpendable(utxo, dagInfo.VirtualDAAScore, s.params.BlockCoinbaseMaturity) {
+			continue
+		}
+		if broadcastTime, ok := s.usedOutpoints[*utxo.Outpoint]; ok && time.Since(broadcastTime) <= time.Minute {
+			continue
+		}
+
+		candidates = append(candidates, &libc4exwallet.UTXO{
+			Outpoint:       utxo.Outpoint,
+			UTXOEntry:      utxo.UTXOEntry,
+			DerivationPath: s.walletAddressPath(utxo.address),
+		})
+	}
+	return candidates, nil
+}
+
+// consolidationInputCapacity returns the number of inputs like sampleUTXO that a transaction paying
+// to the given addresses can have under the maximum standard transaction mass. Since the transactions
+// are generated by c4exwallet, all inputs are assumed to have the same mass.
+func (s *server) consolidationInputCapacity(account *keys.Account, sampleUTXO *libc4exwallet.UTXO,
+	addresses []util.Address) (int, error) {
+
+	massWithoutInputs, err := s.consolidationTransactionMass(account, nil, addresses)
+	if err != nil {
+		return 0, err
+	}
+	if massWithoutInputs >= mempool.MaximumStandardTransactionMass {
+		return 0, nil
+	}
+	massWithSampleInput, err := s.consolidationTransactionMass(account, []*libc4exwallet.UTXO{sampleUTXO}, addresses)
+	if err != nil {
+		return 0, err
+	}
+
+	massPerInput := massWithSampleInput - massWithoutInputs
+	return int((mempool.MaximumStandardTransactionMass - 1 - massWithoutInputs) / massPerInput), nil
+}
+
+func (s *server) consolidationTransactionMass(account *keys.Account, utxos []*libc4exwallet.UTXO,
+	addresses []util.Address) (uint64, error) {
+
+	transactionBytes, err := libc4exwallet.CreateUnsignedTransaction(account.ExtendedPublicKeys,
+		s.keysFile.MinimumSignatures, consolidationPayments(addresses, 0), utxos)
+	if err != nil {
+		return 0, err
+	}
+	transaction, err := serialization.DeserializePartiallySignedTransaction(transactionBytes)
+	if err != nil {
+		return 0, err
+	}
+	return s.estimateMassAfterSignatures(transaction)
+}
+
+// createConsolidationTransaction creates a transaction that spends the given UTXOs into equal
+// outputs to the given addresses, and pays a fee of feeRate sompi per gram of its mass
+func (s *server) createConsolidationTransaction(account *keys.Account, utxos []*libc4exwallet.UTXO,
+	addresses []util.Address, feeRate uint64) (transaction []byte, fee uint64, err error) {
+
+	totalValue := uint64(0)
+	for _, utxo := range utxos {
+		totalValue += utxo.UTXOEntry.Amount()
+	}
+
+	// The amounts of the outputs don't affect the mass of the transaction
+	mass, err := s.consolidationTransactionMass(account, utxos, addresses)
+	if err != nil {
+		return nil, 0, err
+	}
+	if mass >= mempool.MaximumStandardTransactionMass {
+		return nil, 0, errors.Errorf("consolidation transaction has a mass of %d, which exceeds the maximum "+
+			"standard transaction mass of %d", mass, mempool.MaximumStandardTransactionMass)
+	}
+
+	fee = mass * feeRate
+	if fee >= totalValue {
+		return nil, 0, errors.Errorf("the fee of %f C4X is higher than the %f C4X the consolidated UTXOs are worth",
+			float64(fee)/constants.SompiPerC4ex, float64(totalValue)/constants.SompiPerC4ex)
+	}
+
+	transaction, err = libc4exwallet.CreateUnsignedTransaction(account.ExtendedPublicKeys,
+		s.keysFile.MinimumSignatures, consolidationPayments(addresses, totalValue-fee), utxos)
+	if err != nil {
+		return nil, 0, err
+	}
+	return transaction, fee, nil
+}
+
+// consolidationPayments splits amount evenly between the given addresses
+func consolidationPayments(addresses []util.Address, amount uint64) []*libc4exwallet.Payment {
+	payments := make([]*libc4exwallet.Payment, len(addresses))
+	for i, address := range addresses {
+		payments[i] = &libc4exwallet.Payment{
+			Address: address,
+			Amount:  amount / uint64(len(addresses)),
+		}
+	}
+	payments[0].Amount += amount % uint64(len(addresses))
+	return payments
+}
+
+// autoConsolidate periodically consolidates the UTXOs of the wallet as configured, until the daemon shuts down
+func (s *server) autoConsolidate(config *AutoConsolidationConfig) {
+	ticker := time.NewTicker(config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.shutdown:
+			return
+		case <-ticker.C:
+		}
+
+		err := s.maybeConsolidate(config)
+		if err != nil {
+			log.Warnf("Automatic UTXO consolidation failed: %s", err)
+		}
+	}
+}
+
+func (s *server) maybeConsolidate(config *AutoConsolidationConfig) error {
+	info, err := s.rpcClient.GetInfo()
+	if err != nil {
+		return err
+	}
+	if info.MempoolSize > config.MaxMempoolSize {
+		log.Debugf("Skipping automatic UTXO consolidation: the mempool has %d transactions, more than %d",
+			info.MempoolSize, config.MaxMempoolSize)
+		return nil
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.isSynced() {
+		return nil
+	}
+
+	// Each account is consolidated separately, since a transaction spends the UTXOs of a single account
+	for _, account := range s.keysFile.Accounts() {
+		err = s.consolidateAccount(account, config)
+		if err != nil {
+			return errors.Wrapf(err, "error consolidating account %s", account.Name)
+		}
+	}
+	return nil
+}
+
+func (s *server) consolidateAccount(account *keys.Account, config *AutoConsolidationConfig) error {
+	result, err := s.createConsolidationTransactions(account, config.Threshold, config.OutputCount, config.FeeRate,
+		config.MinUTXOCount, false)
+	if errors.Is(err, errNotEnoughUTXOsToConsolidate) {
+		log.Debugf("Skipping automatic UTXO consolidation of account %s: %s", account.Name, err)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	signedTransactions, err := s.signTransactions(result.unsignedTransactions, config.Password)
+	if err != nil {
+		return err
+	}
+	_, err = s.broadcast(signedTransactions, false)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Consolidated %d UTXOs of account %s worth %f C4X in %d transactions, with a fee of %f C4X",
+		result.utxoCount, account.Name, float64(result.amount)/constants.SompiPerC4ex, len(signedTransactions),
+		float64(result.fee)/constants.SompiPerC4ex)
+	return nil
+}
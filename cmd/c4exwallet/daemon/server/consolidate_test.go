@@ -0,0 +1,74 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet/serialization"
+	"github.com/c4ei/c4exd/domain/miningmanager/mempool"
+	"github.com/c4ei/c4exd/util"
+)
+
+func TestCreateConsolidationTransaction(t *testing.T) {
+	serverInstance, address, changeWalletAddress := singleKeyServerForTest(t)
+	path := serverInstance.walletAddressPath(changeWalletAddress)
+	account := serverInstance.keysFile.DefaultAccount()
+
+	const utxoAmount = 1_00000000
+	sampleUTXO := utxosForTest(t, address, path, utxoAmount, 1)[0]
+	addresses := []util.Address{address, address, address}
+	inputCapacity, err := serverInstance.consolidationInputCapacity(account, sampleUTXO, addresses)
+	if err != nil {
+		t.Fatalf("consolidationInputCapacity: %+v", err)
+	}
+	if inputCapacity < 2 {
+		t.Fatalf("expected room for at least 2 inputs, got %d", inputCapacity)
+	}
+
+	utxos := utxosForTest(t, address, path, utxoAmount, inputCapacity)
+	const feeRate = 3
+	transactionBytes, fee, err := serverInstance.createConsolidationTransaction(account, utxos, addresses, feeRate)
+	if err != nil {
+		t.Fatalf("createConsolidationTransaction: %+v", err)
+	}
+	transaction, err := serialization.DeserializePartiallySignedTransaction(transactionBytes)
+	if err != nil {
+		t.Fatalf("DeserializePartiallySignedTransaction: %+v", err)
+	}
+
+	mass, err := serverInstance.estimateMassAfterSignatures(transaction)
+	if err != nil {
+		t.Fatalf("estimateMassAfterSignatures: %+v", err)
+	}
+	if mass >= mempool.MaximumStandardTransactionMass {
+		t.Fatalf("a transaction with the input capacity has a mass of %d", mass)
+	}
+	if fee != mass*feeRate {
+		t.Fatalf("expected a fee of %d, got %d", mass*feeRate, fee)
+	}
+
+	outputsValue := uint64(0)
+	for _, output := range transaction.Tx.Outputs {
+		outputsValue += output.Value
+	}
+	if len(transaction.Tx.Outputs) != len(addresses) || outputsValue != uint64(len(utxos))*utxoAmount-fee {
+		t.Fatalf("unexpected outputs: %+v", transaction.Tx.Outputs)
+	}
+
+	// One more input exceeds the maximum standard transaction mass
+	_, _, err = serverInstance.createConsolidationTransaction(account,
+		utxosForTest(t, address, path, utxoAmount, inputCapacity+1), addresses, feeRate)
+	if err == nil {
+		t.Fatalf("expected an error for a transaction with %d inputs", inputCapacity+1)
+	}
+}
+
+func TestConsolidationPayments(t *testing.T) {
+	_, address, _ := singleKeyServerForTest(t)
+	payments := consolidationPayments([]util.Address{address, address, address}, 10)
+	expected := []uint64{4, 3, 3}
+	for i, payment := range payments {
+		if payment.Amount != expected[i] {
+			t.Fatalf("expected payment %d to be of %d sompi, got %d", i, expected[i], payment.Amount)
+		}
+	}
+}
@@ -6,7 +6,10 @@ import (
 	"time"
 
 	"github.com/c4ei/c4exd/cmd/c4exwallet/daemon/pb"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/keys"
 	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet/serialization"
+	"github.com/c4ei/c4exd/domain/consensus/model/externalapi"
 	"github.com/c4ei/c4exd/domain/consensus/utils/constants"
 	"github.com/c4ei/c4exd/util"
 	"github.com/pkg/errors"
@@ -16,14 +19,29 @@ import (
 // TODO: Implement a better fee estimation mechanism
 const feePerInput = 10000
 
+// minimumFeeRate is the fee rate, in sompi per gram, that the nodes require by default to relay a
+// transaction. feePerInput covers the mass of an input, but the outputs of transactions with many
+// recipients may need more.
+const minimumFeeRate = 1
+
 func (s *server) CreateUnsignedTransactions(_ context.Context, request *pb.CreateUnsignedTransactionsRequest) (
 	*pb.CreateUnsignedTransactionsResponse, error,
 ) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
-	unsignedTransactions, err := s.createUnsignedTransactions(request.Address, request.Amount, request.IsSendAll,
-		request.From, request.UseExistingChangeAddress)
+	recipients, err := requestRecipients(request.Address, request.Amount, request.Recipients)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := s.keysFile.FindAccount(request.Account)
+	if err != nil {
+		return nil, err
+	}
+
+	unsignedTransactions, err := s.createUnsignedTransactions(account, recipients, request.IsSendAll,
+		request.From, request.UseExistingChangeAddress, request.Utxos, request.MinConfirmations)
 	if err != nil {
 		return nil, err
 	}
@@ -31,19 +49,57 @@ func (s *server) CreateUnsignedTransactions(_ context.Context, request *pb.Creat
 	return &pb.CreateUnsignedTransactionsResponse{UnsignedTransactions: unsignedTransactions}, nil
 }
 
-func (s *server) createUnsignedTransactions(address string, amount uint64, isSendAll bool, fromAddressesString []string, useExistingChangeAddress bool) ([][]byte, error) {
+// requestRecipients returns the recipients of a request, which pays either a single
+// address and amount or a list of recipients
+func requestRecipients(address string, amount uint64, recipients []*pb.Recipient) ([]*pb.Recipient, error) {
+	if len(recipients) == 0 {
+		return []*pb.Recipient{{Address: address, Amount: amount}}, nil
+	}
+	if address != "" || amount != 0 {
+		return nil, errors.New("a single address and amount and a list of recipients are mutually exclusive")
+	}
+	return recipients, nil
+}
+
+// createUnsignedTransactions creates transactions that pay the given recipients from the UTXOs of
+// the given account with at least minConfirmations confirmations, and send the change back to it
+func (s *server) createUnsignedTransactions(account *keys.Account, recipients []*pb.Recipient, isSendAll bool,
+	fromAddressesString []string, useExistingChangeAddress bool, utxos []*pb.Outpoint, minConfirmations uint64) (
+	[][]byte, error) {
+
 	if !s.isSynced() {
 		return nil, errors.Errorf("wallet daemon is not synced yet, %s", s.formatSyncStateReport())
 	}
+	if len(fromAddressesString) > 0 && len(utxos) > 0 {
+		return nil, errors.New("from addresses and UTXOs to spend are mutually exclusive")
+	}
+	if isSendAll && len(recipients) > 1 {
+		return nil, errors.New("send all is only possible with a single recipient")
+	}
 
-	// make sure address string is correct before proceeding to a
+	// make sure the addresses and amounts are correct before proceeding to a
 	// potentially long UTXO refreshment operation
-	toAddress, err := util.DecodeAddress(address, s.params.Prefix)
-	if err != nil {
-		return nil, err
+	payments := make([]*libc4exwallet.Payment, len(recipients))
+	totalAmount := uint64(0)
+	for i, recipient := range recipients {
+		toAddress, err := util.DecodeAddress(recipient.Address, s.params.Prefix)
+		if err != nil {
+			return nil, err
+		}
+		if !isSendAll && recipient.Amount == 0 {
+			return nil, errors.Errorf("the amount to send to %s must be positive", recipient.Address)
+		}
+		if totalAmount+recipient.Amount < totalAmount {
+			return nil, errors.New("the total amount to send overflows")
+		}
+		totalAmount += recipient.Amount
+		payments[i] = &libc4exwallet.Payment{
+			Address: toAddress,
+			Amount:  recipient.Amount,
+		}
 	}
 
-	err = s.refreshUTXOs()
+	err := s.refreshUTXOs()
 	if err != nil {
 		return nil, err
 	}
@@ -54,48 +110,116 @@ func (s *server) createUnsignedTransactions(address string, amount uint64, isSen
 		if !exists {
 			return nil, fmt.Errorf("Specified from address %s does not exists", from)
 		}
+		if fromAddress.account != account.Index {
+			return nil, errors.Errorf("Specified from address %s doesn't belong to account %s", from, account.Name)
+		}
 		fromAddresses = append(fromAddresses, fromAddress)
 	}
 
-	selectedUTXOs, spendValue, changeSompi, err := s.selectUTXOs(amount, isSendAll, feePerInput, fromAddresses)
+	var preselectedUTXOs []*externalapi.DomainOutpoint
+	if len(utxos) > 0 {
+		preselectedUTXOs, err = s.walletOutpoints(utxos)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	changeAddress, changeWalletAddress, err := s.changeAddress(account, useExistingChangeAddress, fromAddresses)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(selectedUTXOs) == 0 {
-		return nil, errors.Errorf("couldn't find funds to spend")
+	// The fee the mass of the transaction requires beyond feePerInput is spent along with the amount,
+	// so that it's left out of the change. Since it may require more UTXOs, which feePerInput covers,
+	// the UTXOs are selected again until the fee is enough.
+	additionalFee := uint64(0)
+	var unsignedTransaction []byte
+	for {
+		selectedUTXOs, spendValue, changeSompi, err := s.selectUTXOs(account, totalAmount+additionalFee, isSendAll,
+			feePerInput, fromAddresses, preselectedUTXOs, minConfirmations)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(selectedUTXOs) == 0 {
+			return nil, errors.Errorf("couldn't find funds to spend")
+		}
+
+		if isSendAll {
+			if spendValue <= additionalFee {
+				return nil, errors.Errorf("the funds to send don't cover the fee of %d sompi", additionalFee)
+			}
+			payments[0].Amount = spendValue - additionalFee
+		}
+		outputs := append([]*libc4exwallet.Payment{}, payments...)
+		if changeSompi > 0 {
+			outputs = append(outputs, &libc4exwallet.Payment{
+				Address: changeAddress,
+				Amount:  changeSompi,
+			})
+		}
+		unsignedTransaction, err = libc4exwallet.CreateUnsignedTransaction(account.ExtendedPublicKeys,
+			s.keysFile.MinimumSignatures,
+			outputs, selectedUTXOs)
+		if err != nil {
+			return nil, err
+		}
+
+		requiredAdditionalFee, err := s.additionalFee(unsignedTransaction)
+		if err != nil {
+			return nil, err
+		}
+		if requiredAdditionalFee <= additionalFee {
+			break
+		}
+		additionalFee = requiredAdditionalFee
 	}
 
-	changeAddress, changeWalletAddress, err := s.changeAddress(useExistingChangeAddress, fromAddresses)
+	unsignedTransactions, err := s.maybeAutoCompoundTransaction(account, unsignedTransaction, payments, changeAddress,
+		changeWalletAddress, &spendRestrictions{
+			fromAddresses:    fromAddresses,
+			preselectedUTXOs: preselectedUTXOs,
+			minConfirmations: minConfirmations,
+		})
 	if err != nil {
 		return nil, err
 	}
+	return unsignedTransactions, nil
+}
 
-	payments := []*libc4exwallet.Payment{{
-		Address: toAddress,
-		Amount:  spendValue,
-	}}
-	if changeSompi > 0 {
-		payments = append(payments, &libc4exwallet.Payment{
-			Address: changeAddress,
-			Amount:  changeSompi,
-		})
+// additionalFee returns the fee the given transaction has to pay on top of feePerInput for each
+// of its inputs, so that it pays minimumFeeRate for its mass once it's signed
+func (s *server) additionalFee(transactionBytes []byte) (uint64, error) {
+	transaction, err := serialization.DeserializePartiallySignedTransaction(transactionBytes)
+	if err != nil {
+		return 0, err
 	}
-	unsignedTransaction, err := libc4exwallet.CreateUnsignedTransaction(s.keysFile.ExtendedPublicKeys,
-		s.keysFile.MinimumSignatures,
-		payments, selectedUTXOs)
+	mass, err := s.estimateMassAfterSignatures(transaction)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
 
-	unsignedTransactions, err := s.maybeAutoCompoundTransaction(unsignedTransaction, toAddress, changeAddress, changeWalletAddress)
-	if err != nil {
-		return nil, err
+	requiredFee := mass * minimumFeeRate
+	inputsFee := feePerInput * uint64(len(transaction.Tx.Inputs))
+	if requiredFee <= inputsFee {
+		return 0, nil
 	}
-	return unsignedTransactions, nil
+	return requiredFee - inputsFee, nil
 }
 
-func (s *server) selectUTXOs(spendAmount uint64, isSendAll bool, feePerInput uint64, fromAddresses []*walletAddress) (
+// spendRestrictions are the restrictions a request puts on the UTXOs its transactions spend.
+// fromAddresses and preselectedUTXOs are nil unless they're given.
+type spendRestrictions struct {
+	fromAddresses    []*walletAddress
+	preselectedUTXOs []*externalapi.DomainOutpoint
+	minConfirmations uint64
+}
+
+// selectUTXOs selects the UTXOs of the given account to spend, from the largest to the smallest. Frozen
+// UTXOs and UTXOs with fewer than minConfirmations confirmations are never selected. If preselectedUTXOs
+// is not empty, all of them and only them are spent.
+func (s *server) selectUTXOs(account *keys.Account, spendAmount uint64, isSendAll bool, feePerInput uint64,
+	fromAddresses []*walletAddress, preselectedUTXOs []*externalapi.DomainOutpoint, minConfirmations uint64) (
 	selectedUTXOs []*libc4exwallet.UTXO, totalReceived uint64, changeSompi uint64, err error) {
 
 	selectedUTXOs = []*libc4exwallet.UTXO{}
@@ -106,9 +230,30 @@ func (s *server) selectUTXOs(spendAmount uint64, isSendAll bool, feePerInput uin
 		return nil, 0, 0, err
 	}
 
+	var preselected map[externalapi.DomainOutpoint]struct{}
+	if len(preselectedUTXOs) > 0 {
+		preselected, err = s.validatePreselectedUTXOs(account, preselectedUTXOs, dagInfo.VirtualDAAScore,
+			minConfirmations)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+	}
+
 	for _, utxo := range s.utxosSortedByAmount {
+		if preselected != nil {
+			if _, ok := preselected[*utxo.Outpoint]; !ok {
+				continue
+			}
+		} else if _, ok := s.frozenOutpoints[*utxo.Outpoint]; ok {
+			continue
+		}
+		if utxo.address.account != account.Index {
+			continue
+		}
+
 		if (fromAddresses != nil && !slices.Contains(fromAddresses, utxo.address)) ||
-			!isUTXOSpendable(utxo, dagInfo.VirtualDAAScore, s.params.BlockCoinbaseMaturity) {
+			!isUTXOSpendable(utxo, dagInfo.VirtualDAAScore, s.params.BlockCoinbaseMaturity) ||
+			utxoConfirmations(utxo, dagInfo.VirtualDAAScore) < minConfirmations {
 			continue
 		}
 
@@ -130,7 +275,7 @@ func (s *server) selectUTXOs(spendAmount uint64, isSendAll bool, feePerInput uin
 
 		fee := feePerInput * uint64(len(selectedUTXOs))
 		totalSpend := spendAmount + fee
-		if !isSendAll && totalValue >= totalSpend {
+		if !isSendAll && preselected == nil && totalValue >= totalSpend {
 			break
 		}
 	}
@@ -151,3 +296,40 @@ func (s *server) selectUTXOs(spendAmount uint64, isSendAll bool, feePerInput uin
 
 	return selectedUTXOs, totalReceived, totalValue - totalSpend, nil
 }
+
+// validatePreselectedUTXOs makes sure all the given UTXOs of the wallet belong to the given account,
+// have at least minConfirmations confirmations, and can be spent right away
+func (s *server) validatePreselectedUTXOs(account *keys.Account, outpoints []*externalapi.DomainOutpoint,
+	virtualDAAScore uint64, minConfirmations uint64) (
+	map[externalapi.DomainOutpoint]struct{}, error) {
+
+	utxos := make(map[externalapi.DomainOutpoint]*walletUTXO, len(s.utxosSortedByAmount))
+	for _, utxo := range s.utxosSortedByAmount {
+		utxos[*utxo.Outpoint] = utxo
+	}
+
+	preselected := make(map[externalapi.DomainOutpoint]struct{}, len(outpoints))
+	for _, outpoint := range outpoints {
+		if _, ok := s.frozenOutpoints[*outpoint]; ok {
+			return nil, errors.Errorf("UTXO %s:%d is frozen", outpoint.TransactionID, outpoint.Index)
+		}
+		if utxos[*outpoint].address.account != account.Index {
+			return nil, errors.Errorf("UTXO %s:%d doesn't belong to account %s", outpoint.TransactionID,
+				outpoint.Index, account.Name)
+		}
+		if !isUTXOSpendable(utxos[*outpoint], virtualDAAScore, s.params.BlockCoinbaseMaturity) {
+			return nil, errors.Errorf("UTXO %s:%d is an immature coinbase UTXO", outpoint.TransactionID, outpoint.Index)
+		}
+		if confirmations := utxoConfirmations(utxos[*outpoint], virtualDAAScore); confirmations < minConfirmations {
+			return nil, errors.Errorf("UTXO %s:%d has only %d confirmations", outpoint.TransactionID,
+				outpoint.Index, confirmations)
+		}
+		if broadcastTime, ok := s.usedOutpoints[*outpoint]; ok && time.Since(broadcastTime) <= time.Minute {
+			return nil, errors.Errorf("UTXO %s:%d was spent by a recently broadcast transaction",
+				outpoint.TransactionID, outpoint.Index)
+		}
+		preselected[*outpoint] = struct{}{}
+	}
+
+	return preselected, nil
+}
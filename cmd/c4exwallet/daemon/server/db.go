@@ -0,0 +1,21 @@
+package server
+
+import (
+	"github.com/c4ei/c4exd/infrastructure/db/database"
+	"github.com/c4ei/c4exd/infrastructure/db/database/ldb"
+	"github.com/pkg/errors"
+)
+
+const databaseCacheSizeMiB = 8
+
+// openDatabase opens the daemon's database of the given keys file. It keeps the
+// wallet's state that is not part of the keys file, most of which is the transaction
+// history, so its path is the path of the keys file with a ".history" suffix.
+func openDatabase(keysFilePath string) (database.Database, error) {
+	path := keysFilePath + ".history"
+	db, err := ldb.NewLevelDB(path, databaseCacheSizeMiB)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error opening the wallet database %s", path)
+	}
+	return db, nil
+}
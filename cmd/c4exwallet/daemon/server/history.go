@@ -0,0 +1,337 @@
+package server
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/c4ei/c4exd/domain/consensus/model/externalapi"
+	"github.com/c4ei/c4exd/infrastructure/db/database"
+	"github.com/pkg/errors"
+)
+
+const (
+	historyDirectionIncoming = "incoming"
+	historyDirectionOutgoing = "outgoing"
+	historyDirectionSelf     = "self"
+)
+
+var historyBucket = database.MakeBucket([]byte("transaction-history"))
+
+// transactionHistory is the wallet's record of the transactions that changed its
+// balance. It's built from the UTXO changes the node notifies about, and from the
+// transactions that spend the wallet's outputs: those the daemon broadcasts, those
+// it sees in the mempool and those it finds accepted by the selected chain. It's
+// persisted in the daemon's database so that it survives restarts. All the entries
+// are also kept in memory.
+type transactionHistory struct {
+	db      database.Database
+	entries map[string]*historyEntry
+
+	// spendingTransactionIDs maps the outpoints of the wallet that recorded
+	// transactions spend to their IDs
+	spendingTransactionIDs map[externalapi.DomainOutpoint]string
+
+	// transactionIDsByOutputAddress maps the wallet's addresses to the IDs of the
+	// transactions that have outputs to them
+	transactionIDsByOutputAddress map[string]map[string]struct{}
+}
+
+type historyEntry struct {
+	TransactionID          string           `json:"transactionId"`
+	Direction              string           `json:"direction"`
+	Inputs                 []*historyInput  `json:"inputs,omitempty"`
+	Outputs                []*historyOutput `json:"outputs,omitempty"`
+	Fee                    uint64           `json:"fee"`
+	IsFeeKnown             bool             `json:"isFeeKnown"`
+	IsCoinbase             bool             `json:"isCoinbase"`
+	IsConfirmed            bool             `json:"isConfirmed"`
+	AcceptingBlockDAAScore uint64           `json:"acceptingBlockDaaScore"`
+	Timestamp              int64            `json:"timestamp"`
+}
+
+// historyInput is an output of the wallet that a transaction spends
+type historyInput struct {
+	TransactionID string `json:"transactionId"`
+	Index         uint32 `json:"index"`
+	Address       string `json:"address"`
+	Amount        uint64 `json:"amount"`
+}
+
+// historyOutput is an output of a transaction to an address of the wallet
+type historyOutput struct {
+	Index   uint32 `json:"index"`
+	Address string `json:"address"`
+	Amount  uint64 `json:"amount"`
+}
+
+func newTransactionHistory(db database.Database) (*transactionHistory, error) {
+	history := &transactionHistory{
+		db:                            db,
+		entries:                       make(map[string]*historyEntry),
+		spendingTransactionIDs:        make(map[externalapi.DomainOutpoint]string),
+		transactionIDsByOutputAddress: make(map[string]map[string]struct{}),
+	}
+
+	cursor, err := db.Cursor(historyBucket)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close()
+
+	for ok := cursor.First(); ok; ok = cursor.Next() {
+		value, err := cursor.Value()
+		if err != nil {
+			return nil, err
+		}
+
+		entry := &historyEntry{}
+		err = json.Unmarshal(value, entry)
+		if err != nil {
+			return nil, errors.Wrap(err, "error parsing the transaction history")
+		}
+
+		history.entries[entry.TransactionID] = entry
+		history.indexOutputs(entry)
+		err = history.indexInputs(entry)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return history, nil
+}
+
+// recordSpend records a transaction that spends outputs of the wallet, as pending until
+// it's confirmed. A transaction that was recorded by its outputs to the wallet alone gets
+// the spent outputs and its direction, and one whose spends are recorded is left as is.
+func (th *transactionHistory) recordSpend(transactionID string, inputs []*historyInput, outputs []*historyOutput,
+	fee uint64, isFeeKnown bool, hasExternalOutputs bool) error {
+
+	entry, ok := th.entries[transactionID]
+	if ok && len(entry.Inputs) > 0 {
+		return nil
+	}
+	if !ok {
+		entry = &historyEntry{
+			TransactionID: transactionID,
+			Timestamp:     time.Now().UnixMilli(),
+		}
+	}
+
+	entry.Direction = historyDirectionSelf
+	if hasExternalOutputs {
+		entry.Direction = historyDirectionOutgoing
+	}
+	entry.Inputs = inputs
+	entry.Fee = fee
+	entry.IsFeeKnown = isFeeKnown
+	for _, output := range outputs {
+		if !entry.hasOutput(output.Index) {
+			entry.Outputs = append(entry.Outputs, output)
+		}
+	}
+
+	err := th.indexInputs(entry)
+	if err != nil {
+		return err
+	}
+	return th.store(entry)
+}
+
+// spendingTransactionID returns the ID of the recorded transaction that spends the given
+// output of the wallet, if there is one
+func (th *transactionHistory) spendingTransactionID(outpoint *externalapi.DomainOutpoint) (string, bool) {
+	transactionID, ok := th.spendingTransactionIDs[*outpoint]
+	return transactionID, ok
+}
+
+// confirmSpend confirms the given recorded transaction as accepted by the block with the given DAA score
+func (th *transactionHistory) confirmSpend(transactionID string, acceptingBlockDAAScore uint64) error {
+	entry, ok := th.entries[transactionID]
+	if !ok {
+		return errors.Errorf("transaction %s is not in the history", transactionID)
+	}
+	if entry.IsConfirmed && entry.AcceptingBlockDAAScore == acceptingBlockDAAScore {
+		return nil
+	}
+	th.confirm(entry, acceptingBlockDAAScore)
+	return th.store(entry)
+}
+
+// recordOutput records an output to an address of the wallet that was accepted by the
+// block with the given DAA score. An output of a transaction that isn't recorded yet
+// makes it an incoming transaction, and an output of a pending transaction confirms it.
+// Recording an output more than once has no effect.
+func (th *transactionHistory) recordOutput(outpoint *externalapi.DomainOutpoint, address string, amount uint64,
+	isCoinbase bool, blockDAAScore uint64) error {
+
+	transactionID := outpoint.TransactionID.String()
+	entry, ok := th.entries[transactionID]
+	if !ok {
+		entry = &historyEntry{
+			TransactionID: transactionID,
+			Direction:     historyDirectionIncoming,
+			IsCoinbase:    isCoinbase,
+			Timestamp:     time.Now().UnixMilli(),
+		}
+		th.entries[transactionID] = entry
+	}
+
+	// A transaction may be accepted again by a different block after a reorg
+	isChanged := false
+	if !entry.IsConfirmed || entry.AcceptingBlockDAAScore != blockDAAScore {
+		th.confirm(entry, blockDAAScore)
+		isChanged = true
+	}
+	if !entry.hasOutput(outpoint.Index) {
+		entry.Outputs = append(entry.Outputs, &historyOutput{
+			Index:   outpoint.Index,
+			Address: address,
+			Amount:  amount,
+		})
+		isChanged = true
+	}
+
+	if !isChanged {
+		return nil
+	}
+	return th.store(entry)
+}
+
+// removeRevertedCoinbaseOutput handles the removal of the given output of the wallet from
+// the UTXO set if it's an immature coinbase output, and returns whether it is. An immature
+// coinbase output can't be spent, so its removal means that its block left the selected
+// chain, and the output is removed from the history.
+func (th *transactionHistory) removeRevertedCoinbaseOutput(outpoint *externalapi.DomainOutpoint,
+	virtualDAAScore func() (uint64, error), coinbaseMaturity uint64) (bool, error) {
+
+	entry, ok := th.entries[outpoint.TransactionID.String()]
+	if !ok || !entry.IsCoinbase || !entry.hasOutput(outpoint.Index) {
+		return false, nil
+	}
+	daaScore, err := virtualDAAScore()
+	if err != nil {
+		return false, err
+	}
+	if entry.AcceptingBlockDAAScore+coinbaseMaturity < daaScore {
+		return false, nil
+	}
+
+	return true, th.removeOutput(entry, outpoint.Index)
+}
+
+func (th *transactionHistory) removeOutput(entry *historyEntry, index uint32) error {
+	outputs := make([]*historyOutput, 0, len(entry.Outputs))
+	for _, output := range entry.Outputs {
+		if output.Index != index {
+			outputs = append(outputs, output)
+		}
+	}
+	th.unindexOutputs(entry)
+	entry.Outputs = outputs
+	th.indexOutputs(entry)
+
+	if len(entry.Outputs) > 0 || len(entry.Inputs) > 0 {
+		return th.store(entry)
+	}
+	delete(th.entries, entry.TransactionID)
+	return th.db.Delete(historyBucket.Key([]byte(entry.TransactionID)))
+}
+
+func (th *transactionHistory) confirm(entry *historyEntry, acceptingBlockDAAScore uint64) {
+	entry.IsConfirmed = true
+	entry.AcceptingBlockDAAScore = acceptingBlockDAAScore
+}
+
+func (th *transactionHistory) indexInputs(entry *historyEntry) error {
+	for _, input := range entry.Inputs {
+		transactionID, err := externalapi.NewDomainTransactionIDFromString(input.TransactionID)
+		if err != nil {
+			return err
+		}
+		th.spendingTransactionIDs[externalapi.DomainOutpoint{TransactionID: *transactionID, Index: input.Index}] =
+			entry.TransactionID
+	}
+	return nil
+}
+
+func (th *transactionHistory) indexOutputs(entry *historyEntry) {
+	for _, output := range entry.Outputs {
+		transactionIDs, ok := th.transactionIDsByOutputAddress[output.Address]
+		if !ok {
+			transactionIDs = make(map[string]struct{})
+			th.transactionIDsByOutputAddress[output.Address] = transactionIDs
+		}
+		transactionIDs[entry.TransactionID] = struct{}{}
+	}
+}
+
+func (th *transactionHistory) unindexOutputs(entry *historyEntry) {
+	for _, output := range entry.Outputs {
+		transactionIDs := th.transactionIDsByOutputAddress[output.Address]
+		delete(transactionIDs, entry.TransactionID)
+		if len(transactionIDs) == 0 {
+			delete(th.transactionIDsByOutputAddress, output.Address)
+		}
+	}
+}
+
+// confirmedOutputsToAddress returns the total amount of the confirmed outputs to the
+// given address of the wallet, and the sorted IDs of their transactions. If receivedBefore
+// isn't zero, only transactions that were first seen before it (in UNIX milliseconds) count.
+func (th *transactionHistory) confirmedOutputsToAddress(address string, receivedBefore int64) (
+	amount uint64, transactionIDs []string) {
+
+	transactionIDs = []string{}
+	for transactionID := range th.transactionIDsByOutputAddress[address] {
+		entry := th.entries[transactionID]
+		if !entry.IsConfirmed {
+			continue
+		}
+		if receivedBefore != 0 && entry.Timestamp >= receivedBefore {
+			continue
+		}
+		for _, output := range entry.Outputs {
+			if output.Address == address {
+				amount += output.Amount
+			}
+		}
+		transactionIDs = append(transactionIDs, transactionID)
+	}
+	sort.Strings(transactionIDs)
+	return amount, transactionIDs
+}
+
+func (th *transactionHistory) store(entry *historyEntry) error {
+	th.entries[entry.TransactionID] = entry
+	th.indexOutputs(entry)
+
+	serializedEntry, err := json.Marshal(entry)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return th.db.Put(historyBucket.Key([]byte(entry.TransactionID)), serializedEntry)
+}
+
+func (entry *historyEntry) hasOutput(index uint32) bool {
+	for _, output := range entry.Outputs {
+		if output.Index == index {
+			return true
+		}
+	}
+	return false
+}
+
+// amountsByAddress returns the change the transaction made to the balance of each
+// of the wallet's addresses, in sompi
+func (entry *historyEntry) amountsByAddress() map[string]int64 {
+	amounts := make(map[string]int64)
+	for _, input := range entry.Inputs {
+		amounts[input.Address] -= int64(input.Amount)
+	}
+	for _, output := range entry.Outputs {
+		amounts[output.Address] += int64(output.Amount)
+	}
+	return amounts
+}
@@ -0,0 +1,368 @@
+package server
+
+import (
+	"github.com/c4ei/c4exd/app/appmessage"
+	"github.com/c4ei/c4exd/domain/consensus/model/externalapi"
+	"github.com/c4ei/c4exd/domain/consensus/utils/consensushashing"
+	"github.com/c4ei/c4exd/domain/consensus/utils/txscript"
+)
+
+const (
+	// maxRecentChainBlocks is the number of the most recent selected chain blocks whose
+	// accepted transactions are kept, to find the blocks that accepted spends of the wallet
+	maxRecentChainBlocks = 1000
+
+	// maxChainBlocksToSearchForSpends is the number of the most recent selected chain blocks
+	// whose merged transactions are fetched to find a spend of the wallet that isn't recorded yet
+	maxChainBlocksToSearchForSpends = 100
+)
+
+// chainBlock is a block of the selected chain, along with the IDs of the transactions it accepted
+type chainBlock struct {
+	hash                   string
+	acceptedTransactionIDs map[string]struct{}
+
+	// daaScore is fetched the first time it's needed
+	daaScore *uint64
+}
+
+// acceptedSpend is a transaction that spends outputs of the wallet, along with the DAA score
+// of the block that accepted it
+type acceptedSpend struct {
+	transaction            *externalapi.DomainTransaction
+	acceptingBlockDAAScore uint64
+}
+
+// walletOutputs maps outputs of the wallet to the history inputs of the transactions that spend them
+type walletOutputs map[externalapi.DomainOutpoint]*historyInput
+
+// resetRecentChainBlocks starts keeping track of the selected chain from its current tip
+func (s *server) resetRecentChainBlocks() error {
+	selectedTipHash, err := s.rpcClient.GetSelectedTipHash()
+	if err != nil {
+		return err
+	}
+	s.lastChainBlockHash = selectedTipHash.SelectedTipHash
+	s.recentChainBlocks = nil
+	return nil
+}
+
+// updateRecentChainBlocks adds the blocks that joined the selected chain since the last update
+// to the recent chain blocks, and removes those that left it
+func (s *server) updateRecentChainBlocks() error {
+	chainChanges, err := s.rpcClient.GetVirtualSelectedParentChainFromBlock(s.lastChainBlockHash, true)
+	if err != nil {
+		return err
+	}
+	s.applyChainChanges(chainChanges.RemovedChainBlockHashes, chainChanges.AcceptedTransactionIDs)
+	return nil
+}
+
+func (s *server) applyChainChanges(removedChainBlockHashes []string,
+	acceptedTransactionIDs []*appmessage.AcceptedTransactionIDs) {
+
+	removed := make(map[string]struct{}, len(removedChainBlockHashes))
+	for _, hash := range removedChainBlockHashes {
+		removed[hash] = struct{}{}
+	}
+	chainBlocks := make([]*chainBlock, 0, len(s.recentChainBlocks)+len(acceptedTransactionIDs))
+	for _, block := range s.recentChainBlocks {
+		if _, ok := removed[block.hash]; !ok {
+			chainBlocks = append(chainBlocks, block)
+		}
+	}
+
+	for _, accepted := range acceptedTransactionIDs {
+		transactionIDs := make(map[string]struct{}, len(accepted.AcceptedTransactionIDs))
+		for _, transactionID := range accepted.AcceptedTransactionIDs {
+			transactionIDs[transactionID] = struct{}{}
+		}
+		chainBlocks = append(chainBlocks, &chainBlock{
+			hash:                   accepted.AcceptingBlockHash,
+			acceptedTransactionIDs: transactionIDs,
+		})
+		s.lastChainBlockHash = accepted.AcceptingBlockHash
+	}
+
+	if len(chainBlocks) > maxRecentChainBlocks {
+		chainBlocks = chainBlocks[len(chainBlocks)-maxRecentChainBlocks:]
+	}
+	s.recentChainBlocks = chainBlocks
+}
+
+func (s *server) chainBlockDAAScore(block *chainBlock) (uint64, error) {
+	if block.daaScore == nil {
+		response, err := s.rpcClient.GetBlock(block.hash, false)
+		if err != nil {
+			return 0, err
+		}
+		block.daaScore = &response.Block.Header.DAAScore
+	}
+	return *block.daaScore, nil
+}
+
+// acceptingBlockDAAScore returns the DAA score of the recent chain block that accepted the
+// given transaction, if there is one
+func (s *server) acceptingBlockDAAScore(transactionID string) (uint64, bool, error) {
+	for i := len(s.recentChainBlocks) - 1; i >= 0; i-- {
+		block := s.recentChainBlocks[i]
+		if _, ok := block.acceptedTransactionIDs[transactionID]; !ok {
+			continue
+		}
+		daaScore, err := s.chainBlockDAAScore(block)
+		if err != nil {
+			return 0, false, err
+		}
+		return daaScore, true, nil
+	}
+	return 0, false, nil
+}
+
+// findAcceptedSpends looks for the transactions that spent the given outputs in the blocks
+// merged by the most recent chain blocks, and returns them by the outputs they spend
+func (s *server) findAcceptedSpends(outpoints map[externalapi.DomainOutpoint]struct{}) (
+	map[externalapi.DomainOutpoint]*acceptedSpend, error) {
+
+	spends := make(map[externalapi.DomainOutpoint]*acceptedSpend, len(outpoints))
+	searchEnd := len(s.recentChainBlocks) - maxChainBlocksToSearchForSpends
+	for i := len(s.recentChainBlocks) - 1; i >= 0 && i >= searchEnd && len(spends) < len(outpoints); i-- {
+		block := s.recentChainBlocks[i]
+		if len(block.acceptedTransactionIDs) == 0 {
+			continue
+		}
+		response, err := s.rpcClient.GetBlock(block.hash, false)
+		if err != nil {
+			return nil, err
+		}
+		block.daaScore = &response.Block.Header.DAAScore
+
+		mergeSetHashes := append(append([]string{}, response.Block.VerboseData.MergeSetBluesHashes...),
+			response.Block.VerboseData.MergeSetRedsHashes...)
+		for _, mergedBlockHash := range mergeSetHashes {
+			mergedBlock, err := s.rpcClient.GetBlock(mergedBlockHash, true)
+			if err != nil {
+				return nil, err
+			}
+			for _, rpcTransaction := range mergedBlock.Block.Transactions {
+				transaction, err := appmessage.RPCTransactionToDomainTransaction(rpcTransaction)
+				if err != nil {
+					return nil, err
+				}
+				if _, ok := block.acceptedTransactionIDs[consensushashing.TransactionID(transaction).String()]; !ok {
+					continue
+				}
+				for _, input := range transaction.Inputs {
+					if _, ok := outpoints[input.PreviousOutpoint]; ok {
+						spends[input.PreviousOutpoint] = &acceptedSpend{
+							transaction:            transaction,
+							acceptingBlockDAAScore: *block.daaScore,
+						}
+					}
+				}
+			}
+		}
+	}
+	return spends, nil
+}
+
+// utxoSetOutputs returns the outputs of the wallet's UTXO set, including those spent in the mempool
+func (s *server) utxoSetOutputs() (walletOutputs, error) {
+	outputs := make(walletOutputs, len(s.utxosSortedByAmount)+len(s.mempoolSpentUTXOs))
+	addUTXO := func(utxo *walletUTXO) error {
+		address, err := s.walletAddressString(utxo.address)
+		if err != nil {
+			return err
+		}
+		outputs[*utxo.Outpoint] = &historyInput{
+			TransactionID: utxo.Outpoint.TransactionID.String(),
+			Index:         utxo.Outpoint.Index,
+			Address:       address,
+			Amount:        utxo.UTXOEntry.Amount(),
+		}
+		return nil
+	}
+	for _, utxo := range s.utxosSortedByAmount {
+		err := addUTXO(utxo)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, utxo := range s.mempoolSpentUTXOs {
+		err := addUTXO(utxo)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return outputs, nil
+}
+
+// recordSpendInHistory records the given transaction, which spends some of the given outputs
+// of the wallet, in the transaction history. Its fee is known only if all of its inputs spend
+// outputs of the wallet.
+func (s *server) recordSpendInHistory(txID string, tx *externalapi.DomainTransaction, outputsOfWallet walletOutputs) error {
+	var inputs []*historyInput
+	var inputsAmount uint64
+	isFeeKnown := true
+	for _, input := range tx.Inputs {
+		spentOutput, ok := outputsOfWallet[input.PreviousOutpoint]
+		if !ok {
+			isFeeKnown = false
+			continue
+		}
+		inputs = append(inputs, spentOutput)
+		inputsAmount += spentOutput.Amount
+	}
+
+	var outputs []*historyOutput
+	var outputsAmount uint64
+	hasExternalOutputs := false
+	for i, output := range tx.Outputs {
+		outputsAmount += output.Value
+
+		// A script that doesn't parse can't pay to the wallet
+		_, address, err := txscript.ExtractScriptPubKeyAddress(output.ScriptPublicKey, s.params)
+		if err != nil || address == nil || !s.isWalletAddress(address.String()) {
+			hasExternalOutputs = true
+			continue
+		}
+		outputs = append(outputs, &historyOutput{
+			Index:   uint32(i),
+			Address: address.String(),
+			Amount:  output.Value,
+		})
+	}
+
+	var fee uint64
+	if isFeeKnown && inputsAmount >= outputsAmount {
+		fee = inputsAmount - outputsAmount
+	} else {
+		isFeeKnown = false
+	}
+
+	return s.history.recordSpend(txID, inputs, outputs, fee, isFeeKnown, hasExternalOutputs)
+}
+
+// recordMempoolSpendsInHistory records the given mempool transactions that spend outputs of the
+// wallet as pending, so that spends by other instances of the wallet or by other cosigners are
+// recorded along with the transactions the daemon broadcasts
+func (s *server) recordMempoolSpendsInHistory(mempoolEntries []*appmessage.MempoolEntryByAddress) error {
+	var outputsOfWallet walletOutputs
+	for _, entriesByAddress := range mempoolEntries {
+		for _, entry := range entriesByAddress.Sending {
+			transaction, err := appmessage.RPCTransactionToDomainTransaction(entry.Transaction)
+			if err != nil {
+				return err
+			}
+			transactionID := consensushashing.TransactionID(transaction).String()
+			if historyEntry, ok := s.history.entries[transactionID]; ok && len(historyEntry.Inputs) > 0 {
+				continue
+			}
+
+			if outputsOfWallet == nil {
+				outputsOfWallet, err = s.utxoSetOutputs()
+				if err != nil {
+					return err
+				}
+			}
+			err = s.recordSpendInHistory(transactionID, transaction, outputsOfWallet)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// recordRemovedOutputsInHistory handles the removal of the given outputs of the wallet from the
+// UTXO set in the transaction history. The transaction that spent an output is confirmed with the
+// DAA score of the block that accepted it, and is recorded first if it wasn't seen before.
+func (s *server) recordRemovedOutputsInHistory(removedEntries []*appmessage.UTXOsByAddressesEntry) error {
+	// The virtual DAA score is only needed for some removals, so it's fetched lazily
+	var virtualDAAScore *uint64
+	getVirtualDAAScore := func() (uint64, error) {
+		if virtualDAAScore == nil {
+			dagInfo, err := s.rpcClient.GetBlockDAGInfo()
+			if err != nil {
+				return 0, err
+			}
+			virtualDAAScore = &dagInfo.VirtualDAAScore
+		}
+		return *virtualDAAScore, nil
+	}
+
+	outputsOfWallet := make(walletOutputs, len(removedEntries))
+	unknownSpends := make(map[externalapi.DomainOutpoint]struct{})
+	for _, entry := range removedEntries {
+		outpoint, err := appmessage.RPCOutpointToDomainOutpoint(entry.Outpoint)
+		if err != nil {
+			return err
+		}
+		if entry.UTXOEntry != nil {
+			outputsOfWallet[*outpoint] = &historyInput{
+				TransactionID: outpoint.TransactionID.String(),
+				Index:         outpoint.Index,
+				Address:       entry.Address,
+				Amount:        entry.UTXOEntry.Amount,
+			}
+		}
+
+		isReverted, err := s.history.removeRevertedCoinbaseOutput(outpoint, getVirtualDAAScore,
+			s.params.BlockCoinbaseMaturity)
+		if err != nil {
+			return err
+		}
+		if isReverted {
+			continue
+		}
+
+		transactionID, ok := s.history.spendingTransactionID(outpoint)
+		if !ok {
+			unknownSpends[*outpoint] = struct{}{}
+			continue
+		}
+		if s.history.entries[transactionID].IsConfirmed {
+			continue
+		}
+		daaScore, found, err := s.acceptingBlockDAAScore(transactionID)
+		if err != nil {
+			return err
+		}
+		if !found {
+			// The accepting block is older than the recent chain blocks, which only
+			// happens if the notification was delayed for long
+			daaScore, err = getVirtualDAAScore()
+			if err != nil {
+				return err
+			}
+		}
+		err = s.history.confirmSpend(transactionID, daaScore)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(unknownSpends) == 0 {
+		return nil
+	}
+	spends, err := s.findAcceptedSpends(unknownSpends)
+	if err != nil {
+		return err
+	}
+	for outpoint := range unknownSpends {
+		spend, ok := spends[outpoint]
+		if !ok {
+			log.Debugf("The transaction that spent %s:%d was not found", outpoint.TransactionID, outpoint.Index)
+			continue
+		}
+		transactionID := consensushashing.TransactionID(spend.transaction).String()
+		err = s.recordSpendInHistory(transactionID, spend.transaction, outputsOfWallet)
+		if err != nil {
+			return err
+		}
+		err = s.history.confirmSpend(transactionID, spend.acceptingBlockDAAScore)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
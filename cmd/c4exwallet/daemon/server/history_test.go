@@ -0,0 +1,245 @@
+package server
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/c4ei/c4exd/app/appmessage"
+	"github.com/c4ei/c4exd/domain/consensus/model/externalapi"
+	"github.com/c4ei/c4exd/infrastructure/db/database/ldb"
+)
+
+func TestTransactionHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	db, err := openDatabase(path)
+	if err != nil {
+		t.Fatalf("openDatabase: %s", err)
+	}
+	history, err := newTransactionHistory(db)
+	if err != nil {
+		t.Fatalf("newTransactionHistory: %s", err)
+	}
+
+	incomingOutpoint := &externalapi.DomainOutpoint{TransactionID: *transactionIDForTest(1), Index: 0}
+	err = history.recordOutput(incomingOutpoint, "address1", 1000, false, 10)
+	if err != nil {
+		t.Fatalf("recordOutput: %s", err)
+	}
+	// Recording the same output again must not change the entry
+	err = history.recordOutput(incomingOutpoint, "address1", 1000, false, 10)
+	if err != nil {
+		t.Fatalf("recordOutput: %s", err)
+	}
+
+	// An outgoing transaction that spends the incoming output and sends change back
+	outgoingID := *transactionIDForTest(2)
+	inputs := []*historyInput{{
+		TransactionID: incomingOutpoint.TransactionID.String(),
+		Index:         incomingOutpoint.Index,
+		Address:       "address1",
+		Amount:        1000,
+	}}
+	outputs := []*historyOutput{{Index: 1, Address: "address2", Amount: 690}}
+	err = history.recordSpend(outgoingID.String(), inputs, outputs, 10, true, true)
+	if err != nil {
+		t.Fatalf("recordSpend: %s", err)
+	}
+
+	outgoing := history.entries[outgoingID.String()]
+	if outgoing.Direction != historyDirectionOutgoing || outgoing.IsConfirmed {
+		t.Fatalf("unexpected outgoing entry: %+v", outgoing)
+	}
+	amounts := outgoing.amountsByAddress()
+	if amounts["address1"] != -1000 || amounts["address2"] != 690 {
+		t.Fatalf("unexpected amounts: %v", amounts)
+	}
+
+	spendingTransactionID, ok := history.spendingTransactionID(incomingOutpoint)
+	if !ok || spendingTransactionID != outgoingID.String() {
+		t.Fatalf("expected the incoming output to be spent by the outgoing transaction, got %s", spendingTransactionID)
+	}
+	err = history.confirmSpend(outgoingID.String(), 20)
+	if err != nil {
+		t.Fatalf("confirmSpend: %s", err)
+	}
+	if !outgoing.IsConfirmed || outgoing.AcceptingBlockDAAScore != 20 {
+		t.Fatalf("the outgoing transaction was not confirmed: %+v", outgoing)
+	}
+
+	// The removal of an immature coinbase output means its block left the selected chain
+	virtualDAAScore := func() (uint64, error) { return 20, nil }
+	coinbaseOutpoint := &externalapi.DomainOutpoint{TransactionID: *transactionIDForTest(3), Index: 0}
+	err = history.recordOutput(coinbaseOutpoint, "address1", 5000, true, 15)
+	if err != nil {
+		t.Fatalf("recordOutput: %s", err)
+	}
+	isReverted, err := history.removeRevertedCoinbaseOutput(coinbaseOutpoint, virtualDAAScore, 100)
+	if err != nil {
+		t.Fatalf("removeRevertedCoinbaseOutput: %s", err)
+	}
+	if !isReverted {
+		t.Fatalf("expected the removal of an immature coinbase output to revert it")
+	}
+	if _, ok := history.entries[coinbaseOutpoint.TransactionID.String()]; ok {
+		t.Fatalf("the reverted coinbase transaction was not removed")
+	}
+	isReverted, err = history.removeRevertedCoinbaseOutput(incomingOutpoint, virtualDAAScore, 100)
+	if err != nil {
+		t.Fatalf("removeRevertedCoinbaseOutput: %s", err)
+	}
+	if isReverted {
+		t.Fatalf("the removal of a spent output reverted it")
+	}
+
+	err = db.Close()
+	if err != nil {
+		t.Fatalf("Close: %s", err)
+	}
+
+	db, err = openDatabase(path)
+	if err != nil {
+		t.Fatalf("openDatabase: %s", err)
+	}
+	defer db.Close()
+	reopened, err := newTransactionHistory(db)
+	if err != nil {
+		t.Fatalf("newTransactionHistory: %s", err)
+	}
+
+	if len(reopened.entries) != 2 {
+		t.Fatalf("expected 2 entries after reopening, got %d", len(reopened.entries))
+	}
+	incoming := reopened.entries[incomingOutpoint.TransactionID.String()]
+	if incoming.Direction != historyDirectionIncoming || len(incoming.Outputs) != 1 ||
+		!incoming.IsConfirmed || incoming.AcceptingBlockDAAScore != 10 {
+		t.Fatalf("unexpected incoming entry after reopening: %+v", incoming)
+	}
+	if _, ok := reopened.spendingTransactionID(incomingOutpoint); !ok {
+		t.Fatalf("the spend of the incoming output was not kept after reopening")
+	}
+}
+
+func TestTransactionHistoryRecordSpendOfReceivingTransaction(t *testing.T) {
+	db, err := openDatabase(filepath.Join(t.TempDir(), "keys.json"))
+	if err != nil {
+		t.Fatalf("openDatabase: %s", err)
+	}
+	defer db.Close()
+	history, err := newTransactionHistory(db)
+	if err != nil {
+		t.Fatalf("newTransactionHistory: %s", err)
+	}
+
+	// A transaction of another cosigner is first seen by its change output to the wallet
+	spendID := transactionIDForTest(2)
+	changeOutpoint := &externalapi.DomainOutpoint{TransactionID: *spendID, Index: 1}
+	err = history.recordOutput(changeOutpoint, "address2", 690, false, 30)
+	if err != nil {
+		t.Fatalf("recordOutput: %s", err)
+	}
+
+	inputs := []*historyInput{{TransactionID: transactionIDForTest(1).String(), Index: 0, Address: "address1", Amount: 1000}}
+	outputs := []*historyOutput{{Index: 1, Address: "address2", Amount: 690}}
+	err = history.recordSpend(spendID.String(), inputs, outputs, 10, true, true)
+	if err != nil {
+		t.Fatalf("recordSpend: %s", err)
+	}
+
+	entry := history.entries[spendID.String()]
+	if entry.Direction != historyDirectionOutgoing || len(entry.Inputs) != 1 || len(entry.Outputs) != 1 ||
+		!entry.IsFeeKnown || entry.Fee != 10 {
+		t.Fatalf("unexpected entry: %+v", entry)
+	}
+	if !entry.IsConfirmed || entry.AcceptingBlockDAAScore != 30 {
+		t.Fatalf("the confirmation of the entry was not kept: %+v", entry)
+	}
+}
+
+func transactionIDForTest(id byte) *externalapi.DomainTransactionID {
+	return externalapi.NewDomainTransactionIDFromByteArray(&[externalapi.DomainHashSize]byte{id})
+}
+
+func TestTransactionHistoryDatabasePath(t *testing.T) {
+	// The database is at the path of the keys file with a ".history" suffix
+	path := filepath.Join(t.TempDir(), "keys.json")
+	historyDB, err := ldb.NewLevelDB(path+".history", databaseCacheSizeMiB)
+	if err != nil {
+		t.Fatalf("NewLevelDB: %s", err)
+	}
+	entry := &historyEntry{TransactionID: "tx1", Direction: historyDirectionIncoming}
+	serializedEntry, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatalf("Marshal: %s", err)
+	}
+	err = historyDB.Put(historyBucket.Key([]byte(entry.TransactionID)), serializedEntry)
+	if err != nil {
+		t.Fatalf("Put: %s", err)
+	}
+	err = historyDB.Close()
+	if err != nil {
+		t.Fatalf("Close: %s", err)
+	}
+
+	db, err := openDatabase(path)
+	if err != nil {
+		t.Fatalf("openDatabase: %s", err)
+	}
+	defer db.Close()
+	history, err := newTransactionHistory(db)
+	if err != nil {
+		t.Fatalf("newTransactionHistory: %s", err)
+	}
+	if _, ok := history.entries[entry.TransactionID]; !ok {
+		t.Fatalf("the existing transaction history was not kept")
+	}
+}
+
+func TestRecordRemovedOutputsInHistory(t *testing.T) {
+	s, _, _ := singleKeyServerForTest(t)
+	db, err := openDatabase(filepath.Join(t.TempDir(), "keys.json"))
+	if err != nil {
+		t.Fatalf("openDatabase: %s", err)
+	}
+	defer db.Close()
+	s.history, err = newTransactionHistory(db)
+	if err != nil {
+		t.Fatalf("newTransactionHistory: %s", err)
+	}
+
+	spentOutpoint := &externalapi.DomainOutpoint{TransactionID: *transactionIDForTest(1), Index: 0}
+	spendID := transactionIDForTest(2).String()
+	inputs := []*historyInput{{TransactionID: spentOutpoint.TransactionID.String(), Index: 0, Address: "address1", Amount: 1000}}
+	err = s.history.recordSpend(spendID, inputs, nil, 0, false, true)
+	if err != nil {
+		t.Fatalf("recordSpend: %s", err)
+	}
+
+	// The block that accepted the spend left the selected chain, and another one accepted it again
+	daaScores := []uint64{40, 50}
+	s.applyChainChanges(nil, []*appmessage.AcceptedTransactionIDs{
+		{AcceptingBlockHash: "block1", AcceptedTransactionIDs: []string{spendID}},
+	})
+	s.applyChainChanges([]string{"block1"}, []*appmessage.AcceptedTransactionIDs{
+		{AcceptingBlockHash: "block2", AcceptedTransactionIDs: []string{spendID}},
+		{AcceptingBlockHash: "block3"},
+	})
+	if len(s.recentChainBlocks) != 2 || s.lastChainBlockHash != "block3" {
+		t.Fatalf("unexpected recent chain blocks: %d up to %s", len(s.recentChainBlocks), s.lastChainBlockHash)
+	}
+	for i, block := range s.recentChainBlocks {
+		block.daaScore = &daaScores[i]
+	}
+
+	err = s.recordRemovedOutputsInHistory([]*appmessage.UTXOsByAddressesEntry{{
+		Address:  "address1",
+		Outpoint: &appmessage.RPCOutpoint{TransactionID: spentOutpoint.TransactionID.String(), Index: 0},
+	}})
+	if err != nil {
+		t.Fatalf("recordRemovedOutputsInHistory: %+v", err)
+	}
+	entry := s.history.entries[spendID]
+	if !entry.IsConfirmed || entry.AcceptingBlockDAAScore != 40 {
+		t.Fatalf("expected the spend to be confirmed by the accepting block, got %+v", entry)
+	}
+}
@@ -0,0 +1,177 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/c4ei/c4exd/cmd/c4exwallet/daemon/pb"
+	"github.com/c4ei/c4exd/domain/consensus/utils/constants"
+	"github.com/c4ei/c4exd/infrastructure/db/database"
+	"github.com/c4ei/c4exd/util"
+	"github.com/pkg/errors"
+)
+
+var paymentRequestsBucket = database.MakeBucket([]byte("payment-requests"))
+
+// paymentRequest is a request for a payment to a fresh receive address of the wallet.
+// Each request has its own address, so its payments are the outputs to that address.
+type paymentRequest struct {
+	Address   string `json:"address"`
+	Account   uint32 `json:"account"`
+	Amount    uint64 `json:"amount"`
+	Label     string `json:"label,omitempty"`
+	Message   string `json:"message,omitempty"`
+	CreatedAt int64  `json:"createdAt"`
+	Expiry    int64  `json:"expiry,omitempty"`
+}
+
+func (s *server) CreatePaymentRequest(_ context.Context, request *pb.CreatePaymentRequestRequest) (
+	*pb.CreatePaymentRequestResponse, error) {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.isSynced() {
+		return nil, errors.Errorf("wallet daemon is not synced yet, %s", s.formatSyncStateReport())
+	}
+
+	if request.Amount > constants.MaxSompi {
+		return nil, errors.Errorf("amount %d is greater than the maximum of %d sompi", request.Amount, constants.MaxSompi)
+	}
+
+	account, err := s.keysFile.FindAccount(request.Account)
+	if err != nil {
+		return nil, err
+	}
+
+	address, err := s.newReceiveAddress(account)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	paymentRequest := &paymentRequest{
+		Address:   address,
+		Account:   account.Index,
+		Amount:    request.Amount,
+		Label:     request.Label,
+		Message:   request.Message,
+		CreatedAt: now.Unix(),
+	}
+	if request.ExpiresInSeconds != 0 {
+		paymentRequest.Expiry = now.Unix() + int64(request.ExpiresInSeconds)
+	}
+
+	serializedPaymentRequest, err := json.Marshal(paymentRequest)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	err = s.database.Put(paymentRequestsBucket.Key([]byte(address)), serializedPaymentRequest)
+	if err != nil {
+		return nil, err
+	}
+	s.paymentRequests[address] = paymentRequest
+
+	pbPaymentRequest, err := s.paymentRequestToPB(paymentRequest, now)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CreatePaymentRequestResponse{PaymentRequest: pbPaymentRequest}, nil
+}
+
+func (s *server) GetPaymentRequest(_ context.Context, request *pb.GetPaymentRequestRequest) (
+	*pb.GetPaymentRequestResponse, error) {
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	address, err := util.DecodeAddress(request.Address, s.params.Prefix)
+	if err != nil {
+		return nil, err
+	}
+	paymentRequest, ok := s.paymentRequests[address.String()]
+	if !ok {
+		return nil, errors.Errorf("there is no payment request for address %s", request.Address)
+	}
+
+	pbPaymentRequest, err := s.paymentRequestToPB(paymentRequest, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetPaymentRequestResponse{PaymentRequest: pbPaymentRequest}, nil
+}
+
+// paymentRequestToPB returns the payment request along with its status at the given time.
+// Only outputs that were accepted by the DAG count as received, and once the request
+// expires only the ones the wallet saw before the expiry do, so that a late payment
+// doesn't pay a request at a price that is no longer valid.
+func (s *server) paymentRequestToPB(paymentRequest *paymentRequest, now time.Time) (*pb.PaymentRequest, error) {
+	paymentURI, err := s.paymentRequestURI(paymentRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	receivedBefore := paymentRequest.Expiry * 1000
+	receivedAmount, transactionIDs := s.history.confirmedOutputsToAddress(paymentRequest.Address, receivedBefore)
+
+	isPaid := receivedAmount > 0 && receivedAmount >= paymentRequest.Amount
+	return &pb.PaymentRequest{
+		Address:        paymentRequest.Address,
+		Uri:            paymentURI.String(),
+		Account:        paymentRequest.Account,
+		Amount:         paymentRequest.Amount,
+		Label:          paymentRequest.Label,
+		Message:        paymentRequest.Message,
+		CreatedAt:      paymentRequest.CreatedAt,
+		Expiry:         paymentRequest.Expiry,
+		ReceivedAmount: receivedAmount,
+		TransactionIds: transactionIDs,
+		IsPaid:         isPaid,
+		IsExpired:      !isPaid && paymentURI.IsExpired(now),
+	}, nil
+}
+
+func (s *server) paymentRequestURI(paymentRequest *paymentRequest) (*util.PaymentURI, error) {
+	address, err := util.DecodeAddress(paymentRequest.Address, s.params.Prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	paymentURI := &util.PaymentURI{
+		Address: address,
+		Amount:  util.Amount(paymentRequest.Amount),
+		Label:   paymentRequest.Label,
+		Message: paymentRequest.Message,
+	}
+	if paymentRequest.Expiry != 0 {
+		paymentURI.Expiry = time.Unix(paymentRequest.Expiry, 0)
+	}
+	return paymentURI, nil
+}
+
+// readPaymentRequests reads the payment requests that were created by the daemon, keyed by their addresses
+func readPaymentRequests(db database.Database) (map[string]*paymentRequest, error) {
+	cursor, err := db.Cursor(paymentRequestsBucket)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close()
+
+	paymentRequests := make(map[string]*paymentRequest)
+	for ok := cursor.First(); ok; ok = cursor.Next() {
+		value, err := cursor.Value()
+		if err != nil {
+			return nil, err
+		}
+
+		paymentRequest := &paymentRequest{}
+		err = json.Unmarshal(value, paymentRequest)
+		if err != nil {
+			return nil, errors.Wrap(err, "error parsing the payment requests")
+		}
+		paymentRequests[paymentRequest.Address] = paymentRequest
+	}
+
+	return paymentRequests, nil
+}
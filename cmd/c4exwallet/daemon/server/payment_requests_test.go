@@ -0,0 +1,177 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/c4ei/c4exd/cmd/c4exwallet/daemon/pb"
+	"github.com/c4ei/c4exd/domain/consensus/model/externalapi"
+	"github.com/c4ei/c4exd/domain/dagconfig"
+	"github.com/c4ei/c4exd/util"
+)
+
+func TestPaymentRequestStatus(t *testing.T) {
+	db, err := openDatabase(filepath.Join(t.TempDir(), "keys.json"))
+	if err != nil {
+		t.Fatalf("openDatabase: %s", err)
+	}
+	defer db.Close()
+	history, err := newTransactionHistory(db)
+	if err != nil {
+		t.Fatalf("newTransactionHistory: %s", err)
+	}
+	s := &server{params: &dagconfig.SimnetParams, history: history}
+
+	address, err := util.NewAddressPublicKey(make([]byte, 32), dagconfig.SimnetParams.Prefix)
+	if err != nil {
+		t.Fatalf("NewAddressPublicKey: %s", err)
+	}
+	createdAt := time.Now().Unix()
+	paymentRequest := &paymentRequest{
+		Address:   address.String(),
+		Amount:    1500,
+		Label:     "Shop",
+		CreatedAt: createdAt,
+		Expiry:    createdAt + 1000,
+	}
+
+	status := func(now int64) (receivedAmount uint64, isPaid bool, isExpired bool) {
+		pbPaymentRequest, err := s.paymentRequestToPB(paymentRequest, time.Unix(createdAt+now, 0))
+		if err != nil {
+			t.Fatalf("paymentRequestToPB: %s", err)
+		}
+		return pbPaymentRequest.ReceivedAmount, pbPaymentRequest.IsPaid, pbPaymentRequest.IsExpired
+	}
+
+	receivedAmount, isPaid, isExpired := status(500)
+	if receivedAmount != 0 || isPaid || isExpired {
+		t.Fatalf("unexpected status of an unpaid request: %d, %t, %t", receivedAmount, isPaid, isExpired)
+	}
+	receivedAmount, isPaid, isExpired = status(1000)
+	if receivedAmount != 0 || isPaid || !isExpired {
+		t.Fatalf("unexpected status of an expired request: %d, %t, %t", receivedAmount, isPaid, isExpired)
+	}
+
+	// A partial payment doesn't pay the request
+	outpoint := &externalapi.DomainOutpoint{TransactionID: *transactionIDForTest(1), Index: 0}
+	err = history.recordOutput(outpoint, paymentRequest.Address, 1000, false, 10)
+	if err != nil {
+		t.Fatalf("recordOutput: %s", err)
+	}
+	receivedAmount, isPaid, _ = status(500)
+	if receivedAmount != 1000 || isPaid {
+		t.Fatalf("unexpected status of a partially paid request: %d, %t", receivedAmount, isPaid)
+	}
+
+	// Once the rest is paid the request is paid, and it no longer expires
+	outpoint = &externalapi.DomainOutpoint{TransactionID: *transactionIDForTest(2), Index: 1}
+	err = history.recordOutput(outpoint, paymentRequest.Address, 500, false, 11)
+	if err != nil {
+		t.Fatalf("recordOutput: %s", err)
+	}
+	pbPaymentRequest, err := s.paymentRequestToPB(paymentRequest, time.Unix(createdAt+2000, 0))
+	if err != nil {
+		t.Fatalf("paymentRequestToPB: %s", err)
+	}
+	if pbPaymentRequest.ReceivedAmount != 1500 || !pbPaymentRequest.IsPaid || pbPaymentRequest.IsExpired {
+		t.Fatalf("unexpected status of a paid request: %+v", pbPaymentRequest)
+	}
+	if len(pbPaymentRequest.TransactionIds) != 2 {
+		t.Fatalf("expected 2 paying transactions, got %v", pbPaymentRequest.TransactionIds)
+	}
+
+	expectedURI := fmt.Sprintf("%s?amount=0.000015&label=Shop&expiry=%d", address, paymentRequest.Expiry)
+	if pbPaymentRequest.Uri != expectedURI {
+		t.Fatalf("expected URI %s, got %s", expectedURI, pbPaymentRequest.Uri)
+	}
+
+	// The payments are found again after the history is reloaded
+	s.history, err = newTransactionHistory(db)
+	if err != nil {
+		t.Fatalf("newTransactionHistory: %s", err)
+	}
+	receivedAmount, isPaid, _ = status(2000)
+	if receivedAmount != 1500 || !isPaid {
+		t.Fatalf("unexpected status of a paid request after reloading the history: %d, %t", receivedAmount, isPaid)
+	}
+
+	// The request is found by any valid form of its address
+	s.paymentRequests, err = readPaymentRequests(db)
+	if err != nil {
+		t.Fatalf("readPaymentRequests: %s", err)
+	}
+	s.paymentRequests[paymentRequest.Address] = paymentRequest
+	response, err := s.GetPaymentRequest(context.Background(),
+		&pb.GetPaymentRequestRequest{Address: strings.ToUpper(address.String())})
+	if err != nil {
+		t.Fatalf("GetPaymentRequest: %s", err)
+	}
+	if response.PaymentRequest.Address != address.String() {
+		t.Fatalf("expected the payment request of %s, got %s", address, response.PaymentRequest.Address)
+	}
+}
+
+func TestPaymentRequestLatePayment(t *testing.T) {
+	db, err := openDatabase(filepath.Join(t.TempDir(), "keys.json"))
+	if err != nil {
+		t.Fatalf("openDatabase: %s", err)
+	}
+	defer db.Close()
+	history, err := newTransactionHistory(db)
+	if err != nil {
+		t.Fatalf("newTransactionHistory: %s", err)
+	}
+	s := &server{params: &dagconfig.SimnetParams, history: history}
+
+	address, err := util.NewAddressPublicKey(make([]byte, 32), dagconfig.SimnetParams.Prefix)
+	if err != nil {
+		t.Fatalf("NewAddressPublicKey: %s", err)
+	}
+	createdAt := time.Now().Unix() - 2000
+	paymentRequest := &paymentRequest{
+		Address:   address.String(),
+		Amount:    1500,
+		CreatedAt: createdAt,
+		Expiry:    createdAt + 1000,
+	}
+
+	// A payment that the wallet saw before the expiry counts, and one that it saw after doesn't
+	earlyOutpoint := &externalapi.DomainOutpoint{TransactionID: *transactionIDForTest(1), Index: 0}
+	err = history.recordOutput(earlyOutpoint, paymentRequest.Address, 1000, false, 10)
+	if err != nil {
+		t.Fatalf("recordOutput: %s", err)
+	}
+	history.entries[earlyOutpoint.TransactionID.String()].Timestamp = (paymentRequest.Expiry - 1) * 1000
+	lateOutpoint := &externalapi.DomainOutpoint{TransactionID: *transactionIDForTest(2), Index: 0}
+	err = history.recordOutput(lateOutpoint, paymentRequest.Address, 1000, false, 11)
+	if err != nil {
+		t.Fatalf("recordOutput: %s", err)
+	}
+
+	pbPaymentRequest, err := s.paymentRequestToPB(paymentRequest, time.Now())
+	if err != nil {
+		t.Fatalf("paymentRequestToPB: %s", err)
+	}
+	if pbPaymentRequest.ReceivedAmount != 1000 || pbPaymentRequest.IsPaid || !pbPaymentRequest.IsExpired {
+		t.Fatalf("unexpected status of a request that was paid late: %+v", pbPaymentRequest)
+	}
+	expectedTransactionIDs := []string{earlyOutpoint.TransactionID.String()}
+	if !reflect.DeepEqual(pbPaymentRequest.TransactionIds, expectedTransactionIDs) {
+		t.Fatalf("expected paying transactions %v, got %v", expectedTransactionIDs, pbPaymentRequest.TransactionIds)
+	}
+
+	// Without an expiry every payment counts
+	paymentRequest.Expiry = 0
+	pbPaymentRequest, err = s.paymentRequestToPB(paymentRequest, time.Now())
+	if err != nil {
+		t.Fatalf("paymentRequestToPB: %s", err)
+	}
+	if pbPaymentRequest.ReceivedAmount != 2000 || !pbPaymentRequest.IsPaid || pbPaymentRequest.IsExpired {
+		t.Fatalf("unexpected status of a request without an expiry: %+v", pbPaymentRequest)
+	}
+}
@@ -4,14 +4,29 @@ import (
 	"context"
 
 	"github.com/c4ei/c4exd/cmd/c4exwallet/daemon/pb"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/keys"
 )
 
 func (s *server) Send(_ context.Context, request *pb.SendRequest) (*pb.SendResponse, error) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
-	unsignedTransactions, err := s.createUnsignedTransactions(request.ToAddress, request.Amount, request.IsSendAll,
-		request.From, request.UseExistingChangeAddress)
+	if s.keysFile.IsWatchOnly() {
+		return nil, keys.ErrWatchOnly
+	}
+
+	recipients, err := requestRecipients(request.ToAddress, request.Amount, request.Recipients)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := s.keysFile.FindAccount(request.Account)
+	if err != nil {
+		return nil, err
+	}
+
+	unsignedTransactions, err := s.createUnsignedTransactions(account, recipients, request.IsSendAll,
+		request.From, request.UseExistingChangeAddress, request.Utxos, request.MinConfirmations)
 
 	if err != nil {
 		return nil, err
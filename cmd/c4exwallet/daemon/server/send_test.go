@@ -0,0 +1,23 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/c4ei/c4exd/cmd/c4exwallet/daemon/pb"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/keys"
+	"github.com/pkg/errors"
+)
+
+func TestSendWithWatchOnlyWallet(t *testing.T) {
+	serverInstance, address, _ := singleKeyServerForTest(t)
+	serverInstance.keysFile = &keys.File{
+		ExtendedPublicKeys: serverInstance.keysFile.ExtendedPublicKeys,
+		MinimumSignatures:  1,
+	}
+
+	_, err := serverInstance.Send(context.Background(), &pb.SendRequest{ToAddress: address.String(), Amount: 1})
+	if !errors.Is(err, keys.ErrWatchOnly) {
+		t.Fatalf("expected Send to return ErrWatchOnly, got %v", err)
+	}
+}
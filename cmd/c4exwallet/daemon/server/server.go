@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/c4ei/c4exd/app/appmessage"
 	"github.com/c4ei/c4exd/domain/consensus/model/externalapi"
 
 	"github.com/c4ei/c4exd/util/txmass"
@@ -16,6 +17,7 @@ import (
 	"github.com/c4ei/c4exd/cmd/c4exwallet/daemon/pb"
 	"github.com/c4ei/c4exd/cmd/c4exwallet/keys"
 	"github.com/c4ei/c4exd/domain/dagconfig"
+	"github.com/c4ei/c4exd/infrastructure/db/database"
 	"github.com/c4ei/c4exd/infrastructure/network/rpcclient"
 	"github.com/c4ei/c4exd/infrastructure/os/signal"
 	"github.com/c4ei/c4exd/util/panics"
@@ -38,6 +40,31 @@ type server struct {
 	addressSet          walletAddressSet
 	txMassCalculator    *txmass.Calculator
 	usedOutpoints       map[externalapi.DomainOutpoint]time.Time
+	database            database.Database
+	history             *transactionHistory
+	frozenOutpoints     map[externalapi.DomainOutpoint]struct{}
+	paymentRequests     map[string]*paymentRequest
+
+	// The following fields are used by the sync loop. watchedAddresses are the
+	// addresses whose UTXO changes are notified, which are all the addresses
+	// with an index below nextWatchedIndex. mempoolSpentUTXOs are the UTXOs of
+	// the wallet that are left out of utxosSortedByAmount because transactions
+	// in the mempool spend them. recentChainBlocks are the most recent blocks
+	// of the selected chain, up to lastChainBlockHash.
+	watchedAddresses              walletAddressSet
+	mempoolSpentUTXOs             map[externalapi.DomainOutpoint]*walletUTXO
+	lastChainBlockHash            string
+	recentChainBlocks             []*chainBlock
+	nextWatchedIndex              uint32
+	isRegisteredForNotifications  bool
+	isRegisteredForUTXOsChanged   bool
+	lastFarAddressesCollection    time.Time
+	pendingUTXOsChangedLock       sync.Mutex
+	pendingUTXOsChanged           []*appmessage.UTXOsChangedNotificationMessage
+	utxosChanged                  chan struct{}
+	virtualDAAScoreChanged        chan struct{}
+	pruningPointUTXOSetOverridden chan struct{}
+	reconnected                   chan struct{}
 
 	isLogFinalProgressLineShown bool
 	maxUsedAddressesForLog      uint32
@@ -48,8 +75,11 @@ type server struct {
 // Currently, set to 100MB
 const MaxDaemonSendMsgSize = 100_000_000
 
-// Start starts the c4exwalletd server
-func Start(params *dagconfig.Params, listen, rpcServer string, keysFilePath string, profile string, timeout uint32) error {
+// Start starts the c4exwalletd server. autoConsolidation is nil unless the
+// daemon should consolidate the UTXOs of the wallet periodically, and security
+// is nil unless the gRPC server should use TLS or authentication.
+func Start(params *dagconfig.Params, listen, rpcServer string, keysFilePath string, profile string, timeout uint32,
+	autoConsolidation *AutoConsolidationConfig, security *SecurityConfig) error {
 	initLog(defaultLogFile, defaultErrLogFile)
 
 	defer panics.HandlePanic(log, "MAIN", nil)
@@ -59,6 +89,20 @@ func Start(params *dagconfig.Params, listen, rpcServer string, keysFilePath stri
 		profiling.Start(profile, log)
 	}
 
+	grpcServerOptions, err := serverOptions(security)
+	if err != nil {
+		return err
+	}
+	if !isLoopbackAddress(listen) {
+		if !security.isAuthEnabled() {
+			log.Warnf("Listening on %s, which is not a loopback address, without authentication: "+
+				"anyone who can reach this address can access the wallet", listen)
+		} else if !security.isTLSEnabled() {
+			log.Warnf("Listening on %s, which is not a loopback address, without TLS: "+
+				"the auth tokens and wallet passwords are sent unencrypted", listen)
+		}
+	}
+
 	listener, err := net.Listen("tcp", listen)
 	if err != nil {
 		return (errors.Wrapf(err, "Error listening to TCP on %s", listen))
@@ -82,19 +126,62 @@ func Start(params *dagconfig.Params, listen, rpcServer string, keysFilePath stri
 		return err
 	}
 
+	if autoConsolidation != nil {
+		if keysFile.IsWatchOnly() {
+			return errors.Wrap(keys.ErrWatchOnly, "automatic UTXO consolidation requires signing")
+		}
+		// Make sure the password is correct before it's needed
+		if !keysFile.HasExternalSigner() {
+			_, err = keysFile.DecryptMnemonics(autoConsolidation.Password)
+			if err != nil {
+				return errors.Wrap(err, "error decrypting the keys file for automatic UTXO consolidation")
+			}
+		}
+	}
+
+	db, err := openDatabase(keysFile.Path())
+	if err != nil {
+		return err
+	}
+
+	history, err := newTransactionHistory(db)
+	if err != nil {
+		return err
+	}
+
+	frozenOutpoints, err := readFrozenOutpoints(db)
+	if err != nil {
+		return err
+	}
+
+	paymentRequests, err := readPaymentRequests(db)
+	if err != nil {
+		return err
+	}
+
 	serverInstance := &server{
-		rpcClient:                   rpcClient,
-		params:                      params,
-		utxosSortedByAmount:         []*walletUTXO{},
-		nextSyncStartIndex:          0,
-		keysFile:                    keysFile,
-		shutdown:                    make(chan struct{}),
-		addressSet:                  make(walletAddressSet),
-		txMassCalculator:            txmass.NewCalculator(params.MassPerTxByte, params.MassPerScriptPubKeyByte, params.MassPerSigOp),
-		usedOutpoints:               map[externalapi.DomainOutpoint]time.Time{},
-		isLogFinalProgressLineShown: false,
-		maxUsedAddressesForLog:      0,
-		maxProcessedAddressesForLog: 0,
+		rpcClient:                     rpcClient,
+		params:                        params,
+		utxosSortedByAmount:           []*walletUTXO{},
+		nextSyncStartIndex:            0,
+		keysFile:                      keysFile,
+		shutdown:                      make(chan struct{}),
+		addressSet:                    make(walletAddressSet),
+		txMassCalculator:              txmass.NewCalculator(params.MassPerTxByte, params.MassPerScriptPubKeyByte, params.MassPerSigOp),
+		usedOutpoints:                 map[externalapi.DomainOutpoint]time.Time{},
+		database:                      db,
+		history:                       history,
+		frozenOutpoints:               frozenOutpoints,
+		paymentRequests:               paymentRequests,
+		watchedAddresses:              make(walletAddressSet),
+		mempoolSpentUTXOs:             make(map[externalapi.DomainOutpoint]*walletUTXO),
+		utxosChanged:                  make(chan struct{}, 1),
+		virtualDAAScoreChanged:        make(chan struct{}, 1),
+		pruningPointUTXOSetOverridden: make(chan struct{}, 1),
+		reconnected:                   make(chan struct{}, 1),
+		isLogFinalProgressLineShown:   false,
+		maxUsedAddressesForLog:        0,
+		maxProcessedAddressesForLog:   0,
 	}
 
 	log.Infof("Read, syncing the wallet...")
@@ -105,7 +192,14 @@ func Start(params *dagconfig.Params, listen, rpcServer string, keysFilePath stri
 		}
 	})
 
-	grpcServer := grpc.NewServer(grpc.MaxSendMsgSize(MaxDaemonSendMsgSize))
+	if autoConsolidation != nil {
+		log.Infof("Consolidating UTXOs automatically every %s", autoConsolidation.Interval)
+		spawn("serverInstance.autoConsolidate", func() {
+			serverInstance.autoConsolidate(autoConsolidation)
+		})
+	}
+
+	grpcServer := grpc.NewServer(append(grpcServerOptions, grpc.MaxSendMsgSize(MaxDaemonSendMsgSize))...)
 	pb.RegisterC4exwalletdServer(grpcServer, serverInstance)
 
 	spawn("grpcServer.Serve", func() {
@@ -134,7 +228,10 @@ func Start(params *dagconfig.Params, listen, rpcServer string, keysFilePath stri
 		}
 	}
 
-	return nil
+	// The lock is kept until the process exits, so that nothing is written to
+	// the database after it's closed
+	serverInstance.lock.Lock()
+	return serverInstance.database.Close()
 }
 
 func printErrorAndExit(err error) {
@@ -3,7 +3,7 @@ package server
 import (
 	"context"
 
-	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/signer"
 
 	"github.com/c4ei/c4exd/cmd/c4exwallet/daemon/pb"
 )
@@ -20,17 +20,5 @@ func (s *server) Sign(_ context.Context, request *pb.SignRequest) (*pb.SignRespo
 }
 
 func (s *server) signTransactions(unsignedTransactions [][]byte, password string) ([][]byte, error) {
-	mnemonics, err := s.keysFile.DecryptMnemonics(password)
-	if err != nil {
-		return nil, err
-	}
-	signedTransactions := make([][]byte, len(unsignedTransactions))
-	for i, unsignedTransaction := range unsignedTransactions {
-		signedTransaction, err := libc4exwallet.Sign(s.params, mnemonics, unsignedTransaction, s.keysFile.ECDSA)
-		if err != nil {
-			return nil, err
-		}
-		signedTransactions[i] = signedTransaction
-	}
-	return signedTransactions, nil
+	return signer.SignTransactions(s.params, s.keysFile, password, unsignedTransactions)
 }
@@ -1,9 +1,13 @@
 package server
 
 import (
+	"time"
+
 	"github.com/kaspanet/go-secp256k1"
 	"github.com/pkg/errors"
+	"golang.org/x/exp/slices"
 
+	"github.com/c4ei/c4exd/cmd/c4exwallet/keys"
 	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet"
 	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet/serialization"
 	"github.com/c4ei/c4exd/domain/consensus/model/externalapi"
@@ -18,16 +22,19 @@ import (
 // transaction.
 // If it is - the transaction is split into multiple transactions, each with a portion of the inputs and a single output
 // into a change address.
-// An additional `mergeTransaction` is generated - which merges the outputs of the above splits into a single output
-// paying to the original transaction's payee.
-func (s *server) maybeAutoCompoundTransaction(transactionBytes []byte, toAddress util.Address,
-	changeAddress util.Address, changeWalletAddress *walletAddress) ([][]byte, error) {
+// An additional `mergeTransaction` is generated - which merges the outputs of the above splits into the payments
+// of the original transaction. If the merge transaction needs more UTXOs, they're selected under the
+// given restrictions.
+func (s *server) maybeAutoCompoundTransaction(account *keys.Account, transactionBytes []byte,
+	payments []*libc4exwallet.Payment, changeAddress util.Address, changeWalletAddress *walletAddress,
+	restrictions *spendRestrictions) ([][]byte, error) {
 	transaction, err := serialization.DeserializePartiallySignedTransaction(transactionBytes)
 	if err != nil {
 		return nil, err
 	}
 
-	splitTransactions, err := s.maybeSplitAndMergeTransaction(transaction, toAddress, changeAddress, changeWalletAddress)
+	splitTransactions, err := s.maybeSplitAndMergeTransaction(account, transaction, payments, changeAddress,
+		changeWalletAddress, restrictions)
 	if err != nil {
 		return nil, err
 	}
@@ -42,23 +49,28 @@ func (s *server) maybeAutoCompoundTransaction(transactionBytes []byte, toAddress
 }
 
 func (s *server) mergeTransaction(
+	account *keys.Account,
 	splitTransactions []*serialization.PartiallySignedTransaction,
 	originalTransaction *serialization.PartiallySignedTransaction,
-	toAddress util.Address,
+	payments []*libc4exwallet.Payment,
 	changeAddress util.Address,
 	changeWalletAddress *walletAddress,
+	restrictions *spendRestrictions,
 ) (*serialization.PartiallySignedTransaction, error) {
 	numOutputs := len(originalTransaction.Tx.Outputs)
-	if numOutputs > 2 || numOutputs == 0 {
-		// This is a sanity check to make sure originalTransaction has either 1 or 2 outputs:
-		// 1. For the payment itself
-		// 2. (optional) for change
-		return nil, errors.Errorf("original transaction has %d outputs, while 1 or 2 are expected",
-			len(originalTransaction.Tx.Outputs))
+	if numOutputs != len(payments) && numOutputs != len(payments)+1 {
+		// This is a sanity check to make sure originalTransaction has:
+		// 1. An output for each of the payments
+		// 2. (optional) An output for change
+		return nil, errors.Errorf("original transaction has %d outputs, while %d or %d are expected",
+			numOutputs, len(payments), len(payments)+1)
 	}
 
 	totalValue := uint64(0)
-	sentValue := originalTransaction.Tx.Outputs[0].Value
+	sentValue := uint64(0)
+	for _, payment := range payments {
+		sentValue += payment.Amount
+	}
 	utxos := make([]*libc4exwallet.UTXO, len(splitTransactions))
 	for i, splitTransaction := range splitTransactions {
 		output := splitTransaction.Tx.Outputs[0]
@@ -74,39 +86,51 @@ func (s *server) mergeTransaction(
 		totalValue -= feePerInput
 	}
 
-	if totalValue < sentValue {
-		// sometimes the fees from compound transactions make the total output higher than what's available from selected
-		// utxos, in such cases - find one more UTXO and use it.
-		additionalUTXOs, totalValueAdded, err := s.moreUTXOsForMergeTransaction(utxos, sentValue-totalValue)
+	// The fee the mass of the merge transaction requires beyond feePerInput is left out of the change
+	additionalFee := uint64(0)
+	for {
+		requiredValue := sentValue + additionalFee
+		if totalValue < requiredValue {
+			// sometimes the fees from compound transactions make the total output higher than what's available from selected
+			// utxos, in such cases - find one more UTXO and use it.
+			additionalUTXOs, totalValueAdded, err := s.moreUTXOsForMergeTransaction(account, utxos, requiredValue-totalValue,
+				restrictions)
+			if err != nil {
+				return nil, err
+			}
+			utxos = append(utxos, additionalUTXOs...)
+			totalValue += totalValueAdded
+		}
+
+		outputs := append([]*libc4exwallet.Payment{}, payments...)
+		if totalValue > requiredValue {
+			outputs = append(outputs, &libc4exwallet.Payment{
+				Address: changeAddress,
+				Amount:  totalValue - requiredValue,
+			})
+		}
+
+		mergeTransactionBytes, err := libc4exwallet.CreateUnsignedTransaction(account.ExtendedPublicKeys,
+			s.keysFile.MinimumSignatures, outputs, utxos)
 		if err != nil {
 			return nil, err
 		}
-		utxos = append(utxos, additionalUTXOs...)
-		totalValue += totalValueAdded
-	}
 
-	payments := []*libc4exwallet.Payment{{
-		Address: toAddress,
-		Amount:  sentValue,
-	}}
-	if totalValue > sentValue {
-		payments = append(payments, &libc4exwallet.Payment{
-			Address: changeAddress,
-			Amount:  totalValue - sentValue,
-		})
-	}
-
-	mergeTransactionBytes, err := libc4exwallet.CreateUnsignedTransaction(s.keysFile.ExtendedPublicKeys,
-		s.keysFile.MinimumSignatures, payments, utxos)
-	if err != nil {
-		return nil, err
+		requiredAdditionalFee, err := s.additionalFee(mergeTransactionBytes)
+		if err != nil {
+			return nil, err
+		}
+		if requiredAdditionalFee <= additionalFee {
+			return serialization.DeserializePartiallySignedTransaction(mergeTransactionBytes)
+		}
+		additionalFee = requiredAdditionalFee
 	}
-
-	return serialization.DeserializePartiallySignedTransaction(mergeTransactionBytes)
 }
 
-func (s *server) maybeSplitAndMergeTransaction(transaction *serialization.PartiallySignedTransaction, toAddress util.Address,
-	changeAddress util.Address, changeWalletAddress *walletAddress) ([]*serialization.PartiallySignedTransaction, error) {
+func (s *server) maybeSplitAndMergeTransaction(account *keys.Account,
+	transaction *serialization.PartiallySignedTransaction, payments []*libc4exwallet.Payment,
+	changeAddress util.Address, changeWalletAddress *walletAddress, restrictions *spendRestrictions) (
+	[]*serialization.PartiallySignedTransaction, error) {
 
 	transactionMass, err := s.estimateMassAfterSignatures(transaction)
 	if err != nil {
@@ -117,7 +141,8 @@ func (s *server) maybeSplitAndMergeTransaction(transaction *serialization.Partia
 		return []*serialization.PartiallySignedTransaction{transaction}, nil
 	}
 
-	splitCount, inputCountPerSplit, err := s.splitAndInputPerSplitCounts(transaction, transactionMass, changeAddress)
+	splitCount, inputCountPerSplit, err := s.splitAndInputPerSplitCounts(account, transaction, transactionMass,
+		changeAddress)
 	if err != nil {
 		return nil, err
 	}
@@ -127,19 +152,21 @@ func (s *server) maybeSplitAndMergeTransaction(transaction *serialization.Partia
 		startIndex := i * inputCountPerSplit
 		endIndex := startIndex + inputCountPerSplit
 		var err error
-		splitTransactions[i], err = s.createSplitTransaction(transaction, changeAddress, startIndex, endIndex)
+		splitTransactions[i], err = s.createSplitTransaction(account, transaction, changeAddress, startIndex, endIndex)
 		if err != nil {
 			return nil, err
 		}
 	}
 
 	if len(splitTransactions) > 1 {
-		mergeTransaction, err := s.mergeTransaction(splitTransactions, transaction, toAddress, changeAddress, changeWalletAddress)
+		mergeTransaction, err := s.mergeTransaction(account, splitTransactions, transaction, payments, changeAddress,
+			changeWalletAddress, restrictions)
 		if err != nil {
 			return nil, err
 		}
 		// Recursion will be 2-3 iterations deep even in the rarest` cases, so considered safe..
-		splitMergeTransaction, err := s.maybeSplitAndMergeTransaction(mergeTransaction, toAddress, changeAddress, changeWalletAddress)
+		splitMergeTransaction, err := s.maybeSplitAndMergeTransaction(account, mergeTransaction, payments, changeAddress,
+			changeWalletAddress, restrictions)
 		if err != nil {
 			return nil, err
 		}
@@ -151,8 +178,8 @@ func (s *server) maybeSplitAndMergeTransaction(transaction *serialization.Partia
 }
 
 // splitAndInputPerSplitCounts calculates the number of splits to create, and the number of inputs to assign per split.
-func (s *server) splitAndInputPerSplitCounts(transaction *serialization.PartiallySignedTransaction, transactionMass uint64,
-	changeAddress util.Address) (splitCount, inputsPerSplitCount int, err error) {
+func (s *server) splitAndInputPerSplitCounts(account *keys.Account, transaction *serialization.PartiallySignedTransaction,
+	transactionMass uint64, changeAddress util.Address) (splitCount, inputsPerSplitCount int, err error) {
 
 	// Create a dummy transaction which is a clone of the original transaction, but without inputs,
 	// to calculate how much mass do all the inputs have
@@ -170,9 +197,17 @@ func (s *server) splitAndInputPerSplitCounts(transaction *serialization.Partiall
 		massPerInput++
 	}
 
+	// The merge transaction has the same outputs as the original transaction, so they must leave
+	// room for at least two inputs for the merge to make progress
+	if massWithoutInputs+2*massPerInput >= mempool.MaximumStandardTransactionMass {
+		return 0, 0, errors.Errorf("the outputs of the transaction have a mass of %d, which doesn't leave room "+
+			"for its inputs under the maximum standard transaction mass of %d - try paying fewer recipients",
+			massWithoutInputs, mempool.MaximumStandardTransactionMass)
+	}
+
 	// Create another dummy transaction, this time one similar to the split transactions we wish to generate,
 	// but with 0 inputs, to calculate how much mass for inputs do we have available in the split transactions
-	splitTransactionWithoutInputs, err := s.createSplitTransaction(transaction, changeAddress, 0, 0)
+	splitTransactionWithoutInputs, err := s.createSplitTransaction(account, transaction, changeAddress, 0, 0)
 	if err != nil {
 		return 0, 0, err
 	}
@@ -189,7 +224,7 @@ func (s *server) splitAndInputPerSplitCounts(transaction *serialization.Partiall
 	return splitCount, inputsPerSplitCount, nil
 }
 
-func (s *server) createSplitTransaction(transaction *serialization.PartiallySignedTransaction,
+func (s *server) createSplitTransaction(account *keys.Account, transaction *serialization.PartiallySignedTransaction,
 	changeAddress util.Address, startIndex int, endIndex int) (*serialization.PartiallySignedTransaction, error) {
 
 	selectedUTXOs := make([]*libc4exwallet.UTXO, 0, endIndex-startIndex)
@@ -208,7 +243,7 @@ func (s *server) createSplitTransaction(transaction *serialization.PartiallySign
 		totalSompi += selectedUTXOs[i-startIndex].UTXOEntry.Amount()
 		totalSompi -= feePerInput
 	}
-	unsignedTransactionBytes, err := libc4exwallet.CreateUnsignedTransaction(s.keysFile.ExtendedPublicKeys,
+	unsignedTransactionBytes, err := libc4exwallet.CreateUnsignedTransaction(account.ExtendedPublicKeys,
 		s.keysFile.MinimumSignatures,
 		[]*libc4exwallet.Payment{{
 			Address: changeAddress,
@@ -248,9 +283,18 @@ func (s *server) estimateMassAfterSignatures(transaction *serialization.Partiall
 	return s.txMassCalculator.CalculateTransactionMass(transactionWithSignatures), nil
 }
 
-func (s *server) moreUTXOsForMergeTransaction(alreadySelectedUTXOs []*libc4exwallet.UTXO, requiredAmount uint64) (
+// moreUTXOsForMergeTransaction selects UTXOs of the given account that were not selected yet to cover
+// the given amount, under the same restrictions as selectUTXOs. Since all the preselected UTXOs are
+// already spent by the original transaction, no UTXOs can be added when there are any.
+func (s *server) moreUTXOsForMergeTransaction(account *keys.Account, alreadySelectedUTXOs []*libc4exwallet.UTXO,
+	requiredAmount uint64, restrictions *spendRestrictions) (
 	additionalUTXOs []*libc4exwallet.UTXO, totalValueAdded uint64, err error) {
 
+	if len(restrictions.preselectedUTXOs) > 0 {
+		return nil, 0, errors.Errorf("the selected UTXOs don't cover the fees of the transactions that "+
+			"merge them, which require %f more", float64(requiredAmount)/constants.SompiPerC4ex)
+	}
+
 	dagInfo, err := s.rpcClient.GetBlockDAGInfo()
 	if err != nil {
 		return nil, 0, err
@@ -264,7 +308,18 @@ func (s *server) moreUTXOsForMergeTransaction(alreadySelectedUTXOs []*libc4exwal
 		if _, ok := alreadySelectedUTXOsMap[*utxo.Outpoint]; ok {
 			continue
 		}
-		if !isUTXOSpendable(utxo, dagInfo.VirtualDAAScore, s.params.BlockCoinbaseMaturity) {
+		if utxo.address.account != account.Index {
+			continue
+		}
+		if _, ok := s.frozenOutpoints[*utxo.Outpoint]; ok {
+			continue
+		}
+		if (restrictions.fromAddresses != nil && !slices.Contains(restrictions.fromAddresses, utxo.address)) ||
+			!isUTXOSpendable(utxo, dagInfo.VirtualDAAScore, s.params.BlockCoinbaseMaturity) ||
+			utxoConfirmations(utxo, dagInfo.VirtualDAAScore) < restrictions.minConfirmations {
+			continue
+		}
+		if broadcastTime, ok := s.usedOutpoints[*utxo.Outpoint]; ok && time.Since(broadcastTime) <= time.Minute {
 			continue
 		}
 		additionalUTXOs = append(additionalUTXOs, &libc4exwallet.UTXO{
@@ -9,6 +9,7 @@ import (
 	"github.com/c4ei/c4exd/util/txmass"
 
 	"github.com/c4ei/c4exd/domain/dagconfig"
+	"github.com/c4ei/c4exd/util"
 
 	"github.com/c4ei/c4exd/domain/consensus/model/externalapi"
 	"github.com/c4ei/c4exd/domain/consensus/utils/consensushashing"
@@ -67,6 +68,163 @@ func TestEstimateMassAfterSignatures(t *testing.T) {
 	})
 }
 
+func TestMergeTransactionWithMultiplePayments(t *testing.T) {
+	serverInstance, address, changeWalletAddress := singleKeyServerForTest(t)
+	account := serverInstance.keysFile.DefaultAccount()
+
+	const utxoAmount = 100_00000000
+	selectedUTXOs := utxosForTest(t, address, serverInstance.walletAddressPath(changeWalletAddress), utxoAmount, 2)
+
+	// The fee per input covers the mass of a few payments, but not of many
+	for _, paymentCount := range []int{3, 60} {
+		payments := make([]*libc4exwallet.Payment, paymentCount)
+		totalPaid := uint64(0)
+		for i := range payments {
+			payments[i] = &libc4exwallet.Payment{Address: address, Amount: uint64(i+1) * 1_00000000 / 10}
+			totalPaid += payments[i].Amount
+		}
+		originalTransactionBytes, err := libc4exwallet.CreateUnsignedTransaction(serverInstance.keysFile.ExtendedPublicKeys, 1,
+			append(payments, &libc4exwallet.Payment{Address: address, Amount: 1000}), selectedUTXOs)
+		if err != nil {
+			t.Fatalf("CreateUnsignedTransaction: %+v", err)
+		}
+		originalTransaction, err := serialization.DeserializePartiallySignedTransaction(originalTransactionBytes)
+		if err != nil {
+			t.Fatalf("DeserializePartiallySignedTransaction: %+v", err)
+		}
+
+		splitTransactions := make([]*serialization.PartiallySignedTransaction, len(selectedUTXOs))
+		for i := range splitTransactions {
+			splitTransactions[i], err = serverInstance.createSplitTransaction(account, originalTransaction, address, i, i+1)
+			if err != nil {
+				t.Fatalf("createSplitTransaction: %+v", err)
+			}
+		}
+
+		mergeTransaction, err := serverInstance.mergeTransaction(account, splitTransactions, originalTransaction, payments,
+			address, changeWalletAddress, &spendRestrictions{})
+		if err != nil {
+			t.Fatalf("mergeTransaction: %+v", err)
+		}
+
+		outputs := mergeTransaction.Tx.Outputs
+		if len(outputs) != len(payments)+1 {
+			t.Fatalf("expected %d outputs in the merge transaction, got %d", len(payments)+1, len(outputs))
+		}
+		for i, payment := range payments {
+			if outputs[i].Value != payment.Amount {
+				t.Errorf("expected output %d to pay %d, got %d", i, payment.Amount, outputs[i].Value)
+			}
+		}
+
+		// Each UTXO pays the fee per input once in its split transaction and once in the merge transaction,
+		// and the merge transaction pays for the rest of its mass
+		mass, err := serverInstance.estimateMassAfterSignatures(mergeTransaction)
+		if err != nil {
+			t.Fatalf("estimateMassAfterSignatures: %+v", err)
+		}
+		mergeFee := uint64(len(selectedUTXOs)) * feePerInput
+		if mass*minimumFeeRate > mergeFee {
+			if paymentCount == 3 {
+				t.Fatalf("expected the fee per input to cover the mass of %d payments", paymentCount)
+			}
+			mergeFee = mass * minimumFeeRate
+		} else if paymentCount == 60 {
+			t.Fatalf("expected the fee per input not to cover the mass of %d payments", paymentCount)
+		}
+		expectedChange := uint64(len(selectedUTXOs))*(utxoAmount-feePerInput) - mergeFee - totalPaid
+		if outputs[len(payments)].Value != expectedChange {
+			t.Errorf("%d payments: expected change of %d, got %d", paymentCount, expectedChange,
+				outputs[len(payments)].Value)
+		}
+	}
+}
+
+func TestMergeTransactionOfPreselectedUTXOs(t *testing.T) {
+	serverInstance, address, changeWalletAddress := singleKeyServerForTest(t)
+	account := serverInstance.keysFile.DefaultAccount()
+
+	const utxoAmount = 100_00000000
+	selectedUTXOs := utxosForTest(t, address, serverInstance.walletAddressPath(changeWalletAddress), utxoAmount, 2)
+	preselectedUTXOs := []*externalapi.DomainOutpoint{selectedUTXOs[0].Outpoint, selectedUTXOs[1].Outpoint}
+
+	// The original transaction spends all of the preselected UTXOs, which leaves nothing for the fees of the merge
+	payments := []*libc4exwallet.Payment{{Address: address, Amount: 2*utxoAmount - 2*feePerInput}}
+	originalTransactionBytes, err := libc4exwallet.CreateUnsignedTransaction(serverInstance.keysFile.ExtendedPublicKeys, 1,
+		payments, selectedUTXOs)
+	if err != nil {
+		t.Fatalf("CreateUnsignedTransaction: %+v", err)
+	}
+	originalTransaction, err := serialization.DeserializePartiallySignedTransaction(originalTransactionBytes)
+	if err != nil {
+		t.Fatalf("DeserializePartiallySignedTransaction: %+v", err)
+	}
+	splitTransactions := make([]*serialization.PartiallySignedTransaction, len(selectedUTXOs))
+	for i := range splitTransactions {
+		splitTransactions[i], err = serverInstance.createSplitTransaction(account, originalTransaction, address, i, i+1)
+		if err != nil {
+			t.Fatalf("createSplitTransaction: %+v", err)
+		}
+	}
+
+	// Other UTXOs of the wallet must not be added to the merge transaction
+	serverInstance.utxosSortedByAmount = []*walletUTXO{{
+		Outpoint:  &externalapi.DomainOutpoint{TransactionID: *transactionIDForTest(1), Index: 0},
+		UTXOEntry: utxo.NewUTXOEntry(utxoAmount, selectedUTXOs[0].UTXOEntry.ScriptPublicKey(), false, 0),
+		address:   changeWalletAddress,
+	}}
+	_, err = serverInstance.mergeTransaction(account, splitTransactions, originalTransaction, payments,
+		address, changeWalletAddress, &spendRestrictions{preselectedUTXOs: preselectedUTXOs})
+	if err == nil {
+		t.Fatalf("expected the merge transaction of preselected UTXOs that don't cover its fee to fail")
+	}
+}
+
+// singleKeyServerForTest returns a server of a single key wallet on simnet, with the address
+// of its first change address
+func singleKeyServerForTest(t *testing.T) (*server, util.Address, *walletAddress) {
+	params := &dagconfig.SimnetParams
+	mnemonic, err := libc4exwallet.CreateMnemonic()
+	if err != nil {
+		t.Fatalf("CreateMnemonic: %+v", err)
+	}
+	publicKey, err := libc4exwallet.MasterPublicKeyFromMnemonic(params, mnemonic, false)
+	if err != nil {
+		t.Fatalf("MasterPublicKeyFromMnemonic: %+v", err)
+	}
+
+	serverInstance := &server{
+		params:           params,
+		keysFile:         &keys.File{ExtendedPublicKeys: []string{publicKey}, MinimumSignatures: 1},
+		txMassCalculator: txmass.NewCalculator(params.MassPerTxByte, params.MassPerScriptPubKeyByte, params.MassPerSigOp),
+	}
+	changeWalletAddress := &walletAddress{keyChain: libc4exwallet.InternalKeychain}
+	address, err := libc4exwallet.Address(params, serverInstance.keysFile.ExtendedPublicKeys, 1,
+		serverInstance.walletAddressPath(changeWalletAddress), false)
+	if err != nil {
+		t.Fatalf("Address: %+v", err)
+	}
+	return serverInstance, address, changeWalletAddress
+}
+
+// utxosForTest returns count UTXOs of the given amount that pay to address
+func utxosForTest(t *testing.T, address util.Address, path string, amount uint64, count int) []*libc4exwallet.UTXO {
+	scriptPublicKey, err := txscript.PayToAddrScript(address)
+	if err != nil {
+		t.Fatalf("PayToAddrScript: %+v", err)
+	}
+
+	utxos := make([]*libc4exwallet.UTXO, count)
+	for i := range utxos {
+		utxos[i] = &libc4exwallet.UTXO{
+			Outpoint:       &externalapi.DomainOutpoint{TransactionID: *transactionIDForTest(byte(i + 1)), Index: 0},
+			UTXOEntry:      utxo.NewUTXOEntry(amount, scriptPublicKey, false, 0),
+			DerivationPath: path,
+		}
+	}
+	return utxos
+}
+
 func testEstimateMassIncreaseForSignaturesSetUp(t *testing.T, consensusConfig *consensus.Config) (
 	[]byte, []string, *dagconfig.Params, func(keepDataDir bool)) {
 
@@ -5,7 +5,9 @@ import (
 	"sort"
 	"time"
 
+	"github.com/c4ei/c4exd/cmd/c4exwallet/keys"
 	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet"
+	"github.com/c4ei/c4exd/domain/consensus/model/externalapi"
 
 	"github.com/c4ei/c4exd/app/appmessage"
 	"github.com/pkg/errors"
@@ -23,64 +25,456 @@ func (was walletAddressSet) strings() []string {
 	return addresses
 }
 
+// sync keeps the wallet's addresses and UTXO set up to date. The UTXO set is loaded in
+// full on startup, after reconnecting to the node, and when the node's UTXO set is
+// overridden by a new pruning point UTXO set. In between, it's kept up to date by
+// applying the UTXO changes the node notifies about for the watched addresses.
 func (s *server) sync() error {
-	ticker := time.NewTicker(time.Second)
-	defer ticker.Stop()
+	s.rpcClient.SetOnReconnectedHandler(func() {
+		signalSyncEvent(s.reconnected)
+	})
 
-	err := s.collectRecentAddresses()
+	err := s.fullRefresh()
 	if err != nil {
 		return err
 	}
 
-	err = s.refreshExistingUTXOsWithLock()
+	for {
+		select {
+		case <-s.utxosChanged:
+			err = s.applyPendingUTXOsChangedNotifications()
+		case <-s.virtualDAAScoreChanged:
+			err = s.onVirtualDAAScoreChanged()
+		case <-s.pruningPointUTXOSetOverridden:
+			log.Infof("The node's UTXO set was overridden by a new pruning point UTXO set, reloading the wallet UTXOs")
+			err = s.fullRefresh()
+		case <-s.reconnected:
+			log.Infof("Reconnected to the node, reloading the wallet UTXOs")
+			s.isRegisteredForNotifications = false
+			err = s.fullRefresh()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// signalSyncEvent notifies the sync loop about an event without blocking. Events
+// of the same kind that occur before the loop handles the first are merged.
+func signalSyncEvent(events chan struct{}) {
+	select {
+	case events <- struct{}{}:
+	default:
+	}
+}
+
+// fullRefresh registers for the notifications the sync relies on, rescans the
+// recently used addresses and reloads the UTXO set from scratch
+func (s *server) fullRefresh() error {
+	err := s.registerForNotifications()
+	if err != nil {
+		return err
+	}
+
+	// Spends are looked up in the chain blocks added from now on, since any earlier spend is
+	// reflected by the UTXO set that is loaded below
+	err = s.resetRecentChainBlocks()
+	if err != nil {
+		return err
+	}
+
+	// Watch the addresses before loading the UTXO set, so that no change
+	// that happens in between is missed
+	s.lock.Lock()
+	s.watchedAddresses = make(walletAddressSet)
+	s.nextWatchedIndex = 0
+	err = s.watchRecentAddresses()
+	s.lock.Unlock()
+	if err != nil {
+		return err
+	}
+
+	err = s.collectRecentAddresses()
+	if err != nil {
+		return err
+	}
+
+	err = s.watchRecentAddressesWithLock()
+	if err != nil {
+		return err
+	}
+
+	return s.refreshExistingUTXOsWithLock()
+}
+
+func (s *server) registerForNotifications() error {
+	if s.isRegisteredForNotifications {
+		return nil
+	}
+
+	err := s.rpcClient.RegisterForVirtualDaaScoreChangedNotifications(
+		func(_ *appmessage.VirtualDaaScoreChangedNotificationMessage) {
+			signalSyncEvent(s.virtualDAAScoreChanged)
+		})
+	if err != nil {
+		return err
+	}
+
+	err = s.rpcClient.RegisterPruningPointUTXOSetNotifications(func() {
+		signalSyncEvent(s.pruningPointUTXOSetOverridden)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.isRegisteredForNotifications = true
+	s.isRegisteredForUTXOsChanged = false
+	return nil
+}
+
+// onUTXOsChanged queues the given notification for the sync loop. It must not
+// block, or the node's notifications would pile up in the RPC client.
+func (s *server) onUTXOsChanged(notification *appmessage.UTXOsChangedNotificationMessage) {
+	s.pendingUTXOsChangedLock.Lock()
+	s.pendingUTXOsChanged = append(s.pendingUTXOsChanged, notification)
+	s.pendingUTXOsChangedLock.Unlock()
+	signalSyncEvent(s.utxosChanged)
+}
+
+func (s *server) watchRecentAddressesWithLock() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.watchRecentAddresses()
+}
+
+// watchRecentAddresses makes sure the UTXO changes of all the addresses up to
+// the address with the index of the last used address + numIndexesToQueryForRecentAddresses
+// are notified, so that the wallet keeps track of funds sent to any address it may
+// have handed out. The addresses are registered in batches of numIndexesToQueryForRecentAddresses.
+func (s *server) watchRecentAddresses() error {
+	end := s.maxUsedIndex() + numIndexesToQueryForRecentAddresses
+	for s.nextWatchedIndex < end {
+		batchEnd := s.nextWatchedIndex + numIndexesToQueryForRecentAddresses
+		if batchEnd > end {
+			batchEnd = end
+		}
+
+		addresses, err := s.addressesToQuery(s.keysFile.Accounts(), s.nextWatchedIndex, batchEnd)
+		if err != nil {
+			return err
+		}
+
+		// Registering with no addresses would mean watching all of the node's addresses,
+		// so the registration is made along with the first batch
+		if !s.isRegisteredForUTXOsChanged {
+			err = s.rpcClient.RegisterForUTXOsChangedNotifications(addresses.strings(), s.onUTXOsChanged)
+			if err != nil {
+				return err
+			}
+			s.isRegisteredForUTXOsChanged = true
+		} else {
+			err = s.rpcClient.AddUTXOsChangedNotificationAddresses(addresses.strings())
+			if err != nil {
+				return err
+			}
+		}
+
+		for addressString, address := range addresses {
+			s.watchedAddresses[addressString] = address
+		}
+		s.nextWatchedIndex = batchEnd
+	}
+
+	return nil
+}
+
+func (s *server) onVirtualDAAScoreChanged() error {
+	err := s.restoreUTXOsNoLongerSpentInMempool()
+	if err != nil {
+		return err
+	}
+
+	err = s.watchRecentAddressesWithLock()
+	if err != nil {
+		return err
+	}
+
+	if time.Since(s.lastFarAddressesCollection) < farAddressesCollectionInterval {
+		return nil
+	}
+	s.lastFarAddressesCollection = time.Now()
+	err = s.collectFarAddresses()
+	if err != nil {
+		return err
+	}
+
+	// Far addresses may turn out to be used, so the watched addresses are extended accordingly
+	return s.watchRecentAddressesWithLock()
+}
+
+// restoreUTXOsNoLongerSpentInMempool returns the UTXOs that were left out of the UTXO set
+// because a mempool transaction spent them to the set, once that transaction is no longer
+// in the mempool. A transaction that leaves the mempool because it was accepted removes
+// its inputs with a UTXO change notification, but one that is evicted, expires or is
+// replaced doesn't trigger any notification.
+func (s *server) restoreUTXOsNoLongerSpentInMempool() error {
+	s.lock.RLock()
+	addressSet := make(map[string]struct{})
+	for _, utxo := range s.mempoolSpentUTXOs {
+		address, err := s.walletAddressString(utxo.address)
+		if err != nil {
+			s.lock.RUnlock()
+			return err
+		}
+		addressSet[address] = struct{}{}
+	}
+	s.lock.RUnlock()
+	if len(addressSet) == 0 {
+		return nil
+	}
+
+	addresses := make([]string, 0, len(addressSet))
+	for address := range addressSet {
+		addresses = append(addresses, address)
+	}
+	mempoolEntriesByAddresses, err := s.rpcClient.GetMempoolEntriesByAddresses(addresses, true, true)
+	if err != nil {
+		return err
+	}
+	spentInMempool, err := mempoolSpentOutpoints(mempoolEntriesByAddresses.Entries)
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.restoreUTXOsNotSpentIn(spentInMempool)
+	return nil
+}
+
+// restoreUTXOsNotSpentIn moves the UTXOs of mempoolSpentUTXOs that are not in the given
+// spent outpoints back to the UTXO set. If the transaction that spent a UTXO was accepted
+// instead, the UTXO is removed again by the notification about it.
+func (s *server) restoreUTXOsNotSpentIn(spentInMempool map[externalapi.DomainOutpoint]struct{}) {
+	isRestored := false
+	for outpoint, utxo := range s.mempoolSpentUTXOs {
+		if _, ok := spentInMempool[outpoint]; ok {
+			continue
+		}
+		delete(s.mempoolSpentUTXOs, outpoint)
+		s.utxosSortedByAmount = append(s.utxosSortedByAmount, utxo)
+		isRestored = true
+	}
+	if isRestored {
+		sortUTXOsByAmount(s.utxosSortedByAmount)
+	}
+}
+
+func sortUTXOsByAmount(utxos []*walletUTXO) {
+	sort.Slice(utxos, func(i, j int) bool { return utxos[i].UTXOEntry.Amount() > utxos[j].UTXOEntry.Amount() })
+}
+
+func (s *server) applyPendingUTXOsChangedNotifications() error {
+	s.pendingUTXOsChangedLock.Lock()
+	notifications := s.pendingUTXOsChanged
+	s.pendingUTXOsChanged = nil
+	s.pendingUTXOsChangedLock.Unlock()
+
+	// The mempool is checked after the notifications are taken, so an output whose
+	// spending transaction leaves the mempool in between is removed by a later notification
+	s.lock.RLock()
+	addresses := s.trackedAddresses()
+	s.lock.RUnlock()
+	mempoolEntriesByAddresses, err := s.rpcClient.GetMempoolEntriesByAddresses(addresses, true, true)
+	if err != nil {
+		return err
+	}
+	spentInMempool, err := mempoolSpentOutpoints(mempoolEntriesByAddresses.Entries)
+	if err != nil {
+		return err
+	}
+	// The chain is checked after the notifications are taken as well, so that it
+	// includes the blocks that accepted the spends they notify about
+	err = s.updateRecentChainBlocks()
 	if err != nil {
 		return err
 	}
 
-	for range ticker.C {
-		err = s.collectFarAddresses()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	err = s.recordMempoolSpendsInHistory(mempoolEntriesByAddresses.Entries)
+	if err != nil {
+		return err
+	}
+	for _, notification := range notifications {
+		err := s.applyUTXOsChanged(notification, spentInMempool)
 		if err != nil {
 			return err
 		}
+	}
+
+	return s.watchRecentAddresses()
+}
 
-		err = s.collectRecentAddresses()
+// trackedAddresses returns the addresses whose UTXOs the wallet tracks: its used
+// addresses, and the watched addresses that may have been handed out but not used yet
+func (s *server) trackedAddresses() []string {
+	addresses := make([]string, 0, len(s.watchedAddresses))
+	for address := range s.addressSet {
+		addresses = append(addresses, address)
+	}
+	for address := range s.watchedAddresses {
+		if _, ok := s.addressSet[address]; !ok {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses
+}
+
+// mempoolSpentOutpoints returns the outpoints that the given mempool transactions spend
+func mempoolSpentOutpoints(mempoolEntries []*appmessage.MempoolEntryByAddress) (
+	map[externalapi.DomainOutpoint]struct{}, error) {
+
+	outpoints := make(map[externalapi.DomainOutpoint]struct{})
+	for _, entriesByAddress := range mempoolEntries {
+		for _, entry := range entriesByAddress.Sending {
+			for _, input := range entry.Transaction.Inputs {
+				outpoint, err := appmessage.RPCOutpointToDomainOutpoint(input.PreviousOutpoint)
+				if err != nil {
+					return nil, err
+				}
+				outpoints[*outpoint] = struct{}{}
+			}
+		}
+	}
+	return outpoints, nil
+}
+
+// applyUTXOsChanged applies the given UTXO changes to the UTXO set. Since the
+// notifications may have been queued before the last full refresh, adding an
+// existing UTXO or removing a missing one is not an error. As in a full refresh,
+// UTXOs that are spent by transactions in the mempool are left out of the set, and
+// kept in mempoolSpentUTXOs until they're removed or no longer spent.
+func (s *server) applyUTXOsChanged(notification *appmessage.UTXOsChangedNotificationMessage,
+	spentInMempool map[externalapi.DomainOutpoint]struct{}) error {
+
+	removed := make(map[externalapi.DomainOutpoint]struct{}, len(notification.Removed))
+	for _, entry := range notification.Removed {
+		outpoint, err := appmessage.RPCOutpointToDomainOutpoint(entry.Outpoint)
 		if err != nil {
 			return err
 		}
+		removed[*outpoint] = struct{}{}
+		delete(s.mempoolSpentUTXOs, *outpoint)
+	}
+
+	utxos := make([]*walletUTXO, 0, len(s.utxosSortedByAmount)+len(notification.Added))
+	existing := make(map[externalapi.DomainOutpoint]struct{}, len(s.utxosSortedByAmount))
+	for _, utxo := range s.utxosSortedByAmount {
+		if _, ok := removed[*utxo.Outpoint]; ok {
+			continue
+		}
+		if _, ok := spentInMempool[*utxo.Outpoint]; ok {
+			s.mempoolSpentUTXOs[*utxo.Outpoint] = utxo
+			continue
+		}
+		utxos = append(utxos, utxo)
+		existing[*utxo.Outpoint] = struct{}{}
+	}
+
+	usedAddresses := make(walletAddressSet)
+	for _, entry := range notification.Added {
+		address, ok := s.addressSet[entry.Address]
+		if !ok {
+			address, ok = s.watchedAddresses[entry.Address]
+			if !ok {
+				log.Warnf("Got a UTXO change for address %s even though it isn't watched", entry.Address)
+				continue
+			}
+		}
+
+		outpoint, err := appmessage.RPCOutpointToDomainOutpoint(entry.Outpoint)
+		if err != nil {
+			return err
+		}
+		err = s.history.recordOutput(outpoint, entry.Address, entry.UTXOEntry.Amount,
+			entry.UTXOEntry.IsCoinbase, entry.UTXOEntry.BlockDAAScore)
+		if err != nil {
+			return err
+		}
+		if _, ok := existing[*outpoint]; ok {
+			continue
+		}
+		if _, ok := removed[*outpoint]; ok {
+			continue
+		}
 
-		err = s.refreshExistingUTXOsWithLock()
+		utxoEntry, err := appmessage.RPCUTXOEntryToUTXOEntry(entry.UTXOEntry)
 		if err != nil {
 			return err
 		}
+		utxo := &walletUTXO{
+			Outpoint:  outpoint,
+			UTXOEntry: utxoEntry,
+			address:   address,
+		}
+		usedAddresses[entry.Address] = address
+
+		if _, ok := spentInMempool[*outpoint]; ok {
+			s.mempoolSpentUTXOs[*outpoint] = utxo
+			continue
+		}
+		utxos = append(utxos, utxo)
+		existing[*outpoint] = struct{}{}
 	}
 
-	return nil
+	sortUTXOsByAmount(utxos)
+	s.utxosSortedByAmount = utxos
+	// A UTXO that was left out earlier may have been spent by a transaction that is no longer in the mempool
+	s.restoreUTXOsNotSpentIn(spentInMempool)
+
+	err := s.recordRemovedOutputsInHistory(notification.Removed)
+	if err != nil {
+		return err
+	}
+
+	return s.updateAddressesAndLastUsedIndexes(usedAddresses)
 }
 
 const (
 	numIndexesToQueryForFarAddresses    = 100
 	numIndexesToQueryForRecentAddresses = 1000
+
+	// farAddressesCollectionInterval is the minimal interval between scans
+	// of addresses beyond the watched ones
+	farAddressesCollectionInterval = 10 * time.Second
 )
 
-// addressesToQuery scans the addresses in the given range. Because
-// each cosigner in a multisig has its own unique path for generating
-// addresses it goes over all the cosigners and add their addresses
+// addressesToQuery scans the addresses of the given accounts in the given
+// range. Because each cosigner in a multisig has its own unique path for
+// generating addresses it goes over all the cosigners and add their addresses
 // for each key chain.
-func (s *server) addressesToQuery(start, end uint32) (walletAddressSet, error) {
+func (s *server) addressesToQuery(accounts []*keys.Account, start, end uint32) (walletAddressSet, error) {
 	addresses := make(walletAddressSet)
-	for index := start; index < end; index++ {
-		for cosignerIndex := uint32(0); cosignerIndex < uint32(len(s.keysFile.ExtendedPublicKeys)); cosignerIndex++ {
-			for _, keychain := range keyChains {
-				address := &walletAddress{
-					index:         index,
-					cosignerIndex: cosignerIndex,
-					keyChain:      keychain,
-				}
-				addressString, err := s.walletAddressString(address)
-				if err != nil {
-					return nil, err
+	for _, account := range accounts {
+		for index := start; index < end; index++ {
+			for cosignerIndex := uint32(0); cosignerIndex < uint32(len(account.ExtendedPublicKeys)); cosignerIndex++ {
+				for _, keychain := range keyChains {
+					address := &walletAddress{
+						account:       account.Index,
+						index:         index,
+						cosignerIndex: cosignerIndex,
+						keyChain:      keychain,
+					}
+					addressString, err := s.walletAddressString(address)
+					if err != nil {
+						return nil, err
+					}
+					addresses[addressString] = address
 				}
-				addresses[addressString] = address
 			}
 		}
 	}
@@ -93,7 +487,8 @@ func (s *server) addressesToQuery(start, end uint32) (walletAddressSet, error) {
 func (s *server) collectFarAddresses() error {
 	s.lock.Lock()
 	defer s.lock.Unlock()
-	err := s.collectAddresses(s.nextSyncStartIndex, s.nextSyncStartIndex+numIndexesToQueryForFarAddresses)
+	err := s.collectAddresses(s.keysFile.Accounts(), s.nextSyncStartIndex,
+		s.nextSyncStartIndex+numIndexesToQueryForFarAddresses)
 	if err != nil {
 		return err
 	}
@@ -109,10 +504,16 @@ func (s *server) maxUsedIndexWithLock() uint32 {
 	return s.maxUsedIndex()
 }
 
+// maxUsedIndex returns the highest last used index of all the key chains of all the accounts
 func (s *server) maxUsedIndex() uint32 {
-	maxUsedIndex := s.keysFile.LastUsedExternalIndex()
-	if s.keysFile.LastUsedInternalIndex() > maxUsedIndex {
-		maxUsedIndex = s.keysFile.LastUsedInternalIndex()
+	maxUsedIndex := uint32(0)
+	for _, account := range s.keysFile.Accounts() {
+		if account.LastUsedExternalIndex() > maxUsedIndex {
+			maxUsedIndex = account.LastUsedExternalIndex()
+		}
+		if account.LastUsedInternalIndex() > maxUsedIndex {
+			maxUsedIndex = account.LastUsedInternalIndex()
+		}
 	}
 
 	return maxUsedIndex
@@ -149,11 +550,11 @@ func (s *server) collectAddressesWithLock(start, end uint32) error {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
-	return s.collectAddresses(start, end)
+	return s.collectAddresses(s.keysFile.Accounts(), start, end)
 }
 
-func (s *server) collectAddresses(start, end uint32) error {
-	addressSet, err := s.addressesToQuery(start, end)
+func (s *server) collectAddresses(accounts []*keys.Account, start, end uint32) error {
+	addressSet, err := s.addressesToQuery(accounts, start, end)
 	if err != nil {
 		return err
 	}
@@ -163,21 +564,9 @@ func (s *server) collectAddresses(start, end uint32) error {
 		return err
 	}
 
-	err = s.updateAddressesAndLastUsedIndexes(addressSet, getBalancesByAddressesResponse)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (s *server) updateAddressesAndLastUsedIndexes(requestedAddressSet walletAddressSet,
-	getBalancesByAddressesResponse *appmessage.GetBalancesByAddressesResponseMessage) error {
-	lastUsedExternalIndex := s.keysFile.LastUsedExternalIndex()
-	lastUsedInternalIndex := s.keysFile.LastUsedInternalIndex()
-
+	usedAddresses := make(walletAddressSet)
 	for _, entry := range getBalancesByAddressesResponse.Entries {
-		walletAddress, ok := requestedAddressSet[entry.Address]
+		walletAddress, ok := addressSet[entry.Address]
 		if !ok {
 			return errors.Errorf("Got result from address %s even though it wasn't requested", entry.Address)
 		}
@@ -185,27 +574,61 @@ func (s *server) updateAddressesAndLastUsedIndexes(requestedAddressSet walletAdd
 		if entry.Balance == 0 {
 			continue
 		}
+		usedAddresses[entry.Address] = walletAddress
+	}
+
+	return s.updateAddressesAndLastUsedIndexes(usedAddresses)
+}
 
-		s.addressSet[entry.Address] = walletAddress
+// updateAddressesAndLastUsedIndexes adds the given used addresses to the address set,
+// and advances the last used indexes of their accounts past them.
+func (s *server) updateAddressesAndLastUsedIndexes(usedAddresses walletAddressSet) error {
+	type lastUsedIndexes struct{ external, internal uint32 }
+	lastUsedIndexesByAccount := make(map[uint32]*lastUsedIndexes)
 
+	for addressString, walletAddress := range usedAddresses {
+		// Keep the existing instance, since UTXOs are matched to their addresses by identity
+		if _, ok := s.addressSet[addressString]; !ok {
+			s.addressSet[addressString] = walletAddress
+		}
+
+		indexes, ok := lastUsedIndexesByAccount[walletAddress.account]
+		if !ok {
+			indexes = &lastUsedIndexes{}
+			lastUsedIndexesByAccount[walletAddress.account] = indexes
+		}
 		if walletAddress.keyChain == libc4exwallet.ExternalKeychain {
-			if walletAddress.index > lastUsedExternalIndex {
-				lastUsedExternalIndex = walletAddress.index
+			if walletAddress.index > indexes.external {
+				indexes.external = walletAddress.index
 			}
 			continue
 		}
 
-		if walletAddress.index > lastUsedInternalIndex {
-			lastUsedInternalIndex = walletAddress.index
+		if walletAddress.index > indexes.internal {
+			indexes.internal = walletAddress.index
 		}
 	}
 
-	err := s.keysFile.SetLastUsedExternalIndex(lastUsedExternalIndex)
-	if err != nil {
-		return err
+	for accountIndex, indexes := range lastUsedIndexesByAccount {
+		account, err := s.keysFile.Account(accountIndex)
+		if err != nil {
+			return err
+		}
+		if indexes.external > account.LastUsedExternalIndex() {
+			err = account.SetLastUsedExternalIndex(indexes.external)
+			if err != nil {
+				return err
+			}
+		}
+		if indexes.internal > account.LastUsedInternalIndex() {
+			err = account.SetLastUsedInternalIndex(indexes.internal)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
-	return s.keysFile.SetLastUsedInternalIndex(lastUsedInternalIndex)
+	return nil
 }
 
 func (s *server) refreshExistingUTXOsWithLock() error {
@@ -215,24 +638,18 @@ func (s *server) refreshExistingUTXOsWithLock() error {
 	return s.refreshUTXOs()
 }
 
-// updateUTXOSet clears the current UTXO set, and re-fills it with the given entries
+// updateUTXOSet clears the current UTXO set, and re-fills it with the given entries.
+// Entries that are spent by the given mempool transactions are kept in mempoolSpentUTXOs instead.
 func (s *server) updateUTXOSet(entries []*appmessage.UTXOsByAddressesEntry, mempoolEntries []*appmessage.MempoolEntryByAddress) error {
 	utxos := make([]*walletUTXO, 0, len(entries))
+	mempoolSpentUTXOs := make(map[externalapi.DomainOutpoint]*walletUTXO)
 
-	exclude := make(map[appmessage.RPCOutpoint]struct{})
-	for _, entriesByAddress := range mempoolEntries {
-		for _, entry := range entriesByAddress.Sending {
-			for _, input := range entry.Transaction.Inputs {
-				exclude[*input.PreviousOutpoint] = struct{}{}
-			}
-		}
+	exclude, err := mempoolSpentOutpoints(mempoolEntries)
+	if err != nil {
+		return err
 	}
 
 	for _, entry := range entries {
-		if _, ok := exclude[*entry.Outpoint]; ok {
-			continue
-		}
-
 		outpoint, err := appmessage.RPCOutpointToDomainOutpoint(entry.Outpoint)
 		if err != nil {
 			return err
@@ -247,16 +664,30 @@ func (s *server) updateUTXOSet(entries []*appmessage.UTXOsByAddressesEntry, memp
 		if !ok {
 			return errors.Errorf("Got result from address %s even though it wasn't requested", entry.Address)
 		}
-		utxos = append(utxos, &walletUTXO{
+
+		// UTXOs received while the daemon wasn't running are only seen here
+		err = s.history.recordOutput(outpoint, entry.Address, utxoEntry.Amount(), utxoEntry.IsCoinbase(),
+			utxoEntry.BlockDAAScore())
+		if err != nil {
+			return err
+		}
+
+		utxo := &walletUTXO{
 			Outpoint:  outpoint,
 			UTXOEntry: utxoEntry,
 			address:   address,
-		})
+		}
+		if _, ok := exclude[*outpoint]; ok {
+			mempoolSpentUTXOs[*outpoint] = utxo
+			continue
+		}
+		utxos = append(utxos, utxo)
 	}
 
-	sort.Slice(utxos, func(i, j int) bool { return utxos[i].UTXOEntry.Amount() > utxos[j].UTXOEntry.Amount() })
+	sortUTXOsByAmount(utxos)
 
 	s.utxosSortedByAmount = utxos
+	s.mempoolSpentUTXOs = mempoolSpentUTXOs
 
 	return nil
 }
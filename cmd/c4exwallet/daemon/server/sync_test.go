@@ -0,0 +1,97 @@
+package server
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/c4ei/c4exd/app/appmessage"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet"
+	"github.com/c4ei/c4exd/domain/consensus/model/externalapi"
+	"github.com/c4ei/c4exd/domain/consensus/utils/utxo"
+)
+
+func TestApplyUTXOsChanged(t *testing.T) {
+	s, address, _ := singleKeyServerForTest(t)
+	db, err := openDatabase(filepath.Join(t.TempDir(), "keys.json"))
+	if err != nil {
+		t.Fatalf("openDatabase: %s", err)
+	}
+	defer db.Close()
+	s.history, err = newTransactionHistory(db)
+	if err != nil {
+		t.Fatalf("newTransactionHistory: %s", err)
+	}
+
+	walletAddress := &walletAddress{keyChain: libc4exwallet.ExternalKeychain}
+	s.addressSet = walletAddressSet{address.String(): walletAddress}
+	s.watchedAddresses = walletAddressSet{}
+	s.mempoolSpentUTXOs = map[externalapi.DomainOutpoint]*walletUTXO{}
+
+	outpoint := func(id byte) *externalapi.DomainOutpoint {
+		return &externalapi.DomainOutpoint{TransactionID: *transactionIDForTest(id), Index: 0}
+	}
+	s.utxosSortedByAmount = []*walletUTXO{
+		{Outpoint: outpoint(1), UTXOEntry: utxo.NewUTXOEntry(2, &externalapi.ScriptPublicKey{}, false, 1), address: walletAddress},
+		{Outpoint: outpoint(2), UTXOEntry: utxo.NewUTXOEntry(1, &externalapi.ScriptPublicKey{}, false, 1), address: walletAddress},
+	}
+
+	added := func(id byte, amount uint64) *appmessage.UTXOsByAddressesEntry {
+		return &appmessage.UTXOsByAddressesEntry{
+			Address:  address.String(),
+			Outpoint: &appmessage.RPCOutpoint{TransactionID: outpoint(id).TransactionID.String(), Index: 0},
+			UTXOEntry: &appmessage.RPCUTXOEntry{
+				Amount:          amount,
+				ScriptPublicKey: &appmessage.RPCScriptPublicKey{},
+				BlockDAAScore:   10,
+			},
+		}
+	}
+	notification := &appmessage.UTXOsChangedNotificationMessage{
+		Added: []*appmessage.UTXOsByAddressesEntry{added(3, 4), added(4, 8)},
+	}
+
+	// A UTXO that was already in the set and a new one are both spent by mempool transactions
+	spentInMempool := map[externalapi.DomainOutpoint]struct{}{
+		*outpoint(2): {},
+		*outpoint(4): {},
+	}
+	err = s.applyUTXOsChanged(notification, spentInMempool)
+	if err != nil {
+		t.Fatalf("applyUTXOsChanged: %+v", err)
+	}
+
+	checkUTXOs := func(expectedOutpoints ...*externalapi.DomainOutpoint) {
+		if len(s.utxosSortedByAmount) != len(expectedOutpoints) {
+			t.Fatalf("expected %d UTXOs, got %d", len(expectedOutpoints), len(s.utxosSortedByAmount))
+		}
+		for i, expectedOutpoint := range expectedOutpoints {
+			if !s.utxosSortedByAmount[i].Outpoint.Equal(expectedOutpoint) {
+				t.Fatalf("expected UTXO %d to be %s, got %s", i, expectedOutpoint, s.utxosSortedByAmount[i].Outpoint)
+			}
+		}
+	}
+	checkUTXOs(outpoint(3), outpoint(1))
+	if len(s.mempoolSpentUTXOs) != 2 {
+		t.Fatalf("expected the 2 UTXOs spent in the mempool to be kept aside, got %d", len(s.mempoolSpentUTXOs))
+	}
+
+	// The outputs are still part of the wallet's history
+	receivedAmount, _ := s.history.confirmedOutputsToAddress(address.String(), 0)
+	if receivedAmount != 12 {
+		t.Fatalf("expected 12 sompi of received outputs, got %d", receivedAmount)
+	}
+
+	// The transaction that spent outpoint(4) was accepted, while the one that spent outpoint(2)
+	// left the mempool without being accepted, so only outpoint(2) returns to the set
+	notification = &appmessage.UTXOsChangedNotificationMessage{
+		Removed: []*appmessage.UTXOsByAddressesEntry{added(4, 8)},
+	}
+	err = s.applyUTXOsChanged(notification, map[externalapi.DomainOutpoint]struct{}{})
+	if err != nil {
+		t.Fatalf("applyUTXOsChanged: %+v", err)
+	}
+	checkUTXOs(outpoint(3), outpoint(1), outpoint(2))
+	if len(s.mempoolSpentUTXOs) != 0 {
+		t.Fatalf("expected no UTXOs to be kept aside, got %d", len(s.mempoolSpentUTXOs))
+	}
+}
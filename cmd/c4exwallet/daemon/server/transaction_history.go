@@ -0,0 +1,123 @@
+package server
+
+import (
+	"context"
+	"sort"
+
+	"github.com/c4ei/c4exd/cmd/c4exwallet/daemon/pb"
+	"github.com/pkg/errors"
+)
+
+func (s *server) GetTransactionHistory(_ context.Context, request *pb.GetTransactionHistoryRequest) (
+	*pb.GetTransactionHistoryResponse, error) {
+
+	switch request.Direction {
+	case "", historyDirectionIncoming, historyDirectionOutgoing, historyDirectionSelf:
+	default:
+		return nil, errors.Errorf("unknown direction %s, expected %s, %s or %s", request.Direction,
+			historyDirectionIncoming, historyDirectionOutgoing, historyDirectionSelf)
+	}
+	if request.PendingOnly && request.ConfirmedOnly {
+		return nil, errors.New("pendingOnly and confirmedOnly are mutually exclusive")
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	dagInfo, err := s.rpcClient.GetBlockDAGInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*historyEntry, 0, len(s.history.entries))
+	for _, entry := range s.history.entries {
+		if isHistoryEntryFiltered(entry, request) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	sortHistoryEntries(entries)
+
+	totalCount := uint32(len(entries))
+	if request.Offset >= totalCount {
+		entries = nil
+	} else {
+		entries = entries[request.Offset:]
+	}
+	if request.Limit > 0 && uint32(len(entries)) > request.Limit {
+		entries = entries[:request.Limit]
+	}
+
+	pbEntries := make([]*pb.TransactionHistoryEntry, len(entries))
+	for i, entry := range entries {
+		pbEntries[i] = historyEntryToPB(entry, dagInfo.VirtualDAAScore)
+	}
+
+	return &pb.GetTransactionHistoryResponse{
+		Entries:    pbEntries,
+		TotalCount: totalCount,
+	}, nil
+}
+
+func isHistoryEntryFiltered(entry *historyEntry, request *pb.GetTransactionHistoryRequest) bool {
+	if request.Direction != "" && entry.Direction != request.Direction {
+		return true
+	}
+	if request.PendingOnly && entry.IsConfirmed {
+		return true
+	}
+	if request.ConfirmedOnly && !entry.IsConfirmed {
+		return true
+	}
+	if request.Address != "" {
+		if _, ok := entry.amountsByAddress()[request.Address]; !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// sortHistoryEntries sorts the entries from the newest to the oldest: pending
+// transactions first, then the confirmed ones by their accepting block DAA score
+func sortHistoryEntries(entries []*historyEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].IsConfirmed != entries[j].IsConfirmed {
+			return !entries[i].IsConfirmed
+		}
+		if entries[i].AcceptingBlockDAAScore != entries[j].AcceptingBlockDAAScore {
+			return entries[i].AcceptingBlockDAAScore > entries[j].AcceptingBlockDAAScore
+		}
+		if entries[i].Timestamp != entries[j].Timestamp {
+			return entries[i].Timestamp > entries[j].Timestamp
+		}
+		return entries[i].TransactionID < entries[j].TransactionID
+	})
+}
+
+func historyEntryToPB(entry *historyEntry, virtualDAAScore uint64) *pb.TransactionHistoryEntry {
+	amountsByAddress := entry.amountsByAddress()
+	amounts := make([]*pb.AddressAmount, 0, len(amountsByAddress))
+	for address, amount := range amountsByAddress {
+		amounts = append(amounts, &pb.AddressAmount{Address: address, Amount: amount})
+	}
+	sort.Slice(amounts, func(i, j int) bool { return amounts[i].Address < amounts[j].Address })
+
+	// The virtual DAA score the node reports may lag behind the UTXO changes it notified about
+	var confirmations uint64
+	if entry.IsConfirmed && virtualDAAScore > entry.AcceptingBlockDAAScore {
+		confirmations = virtualDAAScore - entry.AcceptingBlockDAAScore
+	}
+
+	return &pb.TransactionHistoryEntry{
+		TransactionId:          entry.TransactionID,
+		Direction:              entry.Direction,
+		Amounts:                amounts,
+		Fee:                    entry.Fee,
+		IsFeeKnown:             entry.IsFeeKnown,
+		IsCoinbase:             entry.IsCoinbase,
+		IsConfirmed:            entry.IsConfirmed,
+		AcceptingBlockDaaScore: entry.AcceptingBlockDAAScore,
+		Confirmations:          confirmations,
+		Timestamp:              entry.Timestamp,
+	}
+}
@@ -0,0 +1,195 @@
+package server
+
+import (
+	"context"
+	"encoding/binary"
+
+	"github.com/c4ei/c4exd/cmd/c4exwallet/daemon/pb"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/keys"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet"
+	"github.com/c4ei/c4exd/domain/consensus/model/externalapi"
+	"github.com/c4ei/c4exd/infrastructure/db/database"
+	"github.com/pkg/errors"
+)
+
+var frozenOutpointsBucket = database.MakeBucket([]byte("frozen-outpoints"))
+
+func (s *server) ListUTXOs(_ context.Context, request *pb.ListUTXOsRequest) (*pb.ListUTXOsResponse, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var addresses map[*walletAddress]struct{}
+	if len(request.Addresses) > 0 {
+		addresses = make(map[*walletAddress]struct{}, len(request.Addresses))
+		for _, address := range request.Addresses {
+			walletAddress, ok := s.addressSet[address]
+			if !ok {
+				return nil, errors.Errorf("address %s is not a used address of the wallet", address)
+			}
+			addresses[walletAddress] = struct{}{}
+		}
+	}
+
+	var account *keys.Account
+	if request.Account != "" {
+		var err error
+		account, err = s.keysFile.FindAccount(request.Account)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	dagInfo, err := s.rpcClient.GetBlockDAGInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	utxos := make([]*pb.WalletUTXO, 0, len(s.utxosSortedByAmount))
+	for _, utxo := range s.utxosSortedByAmount {
+		if account != nil && utxo.address.account != account.Index {
+			continue
+		}
+		if addresses != nil {
+			if _, ok := addresses[utxo.address]; !ok {
+				continue
+			}
+		}
+
+		address, err := s.walletAddressString(utxo.address)
+		if err != nil {
+			return nil, err
+		}
+
+		maturityDAAScore := utxo.UTXOEntry.BlockDAAScore()
+		if utxo.UTXOEntry.IsCoinbase() {
+			maturityDAAScore += s.params.BlockCoinbaseMaturity + 1
+		}
+
+		_, isFrozen := s.frozenOutpoints[*utxo.Outpoint]
+		utxos = append(utxos, &pb.WalletUTXO{
+			Address: address,
+			Outpoint: &pb.Outpoint{
+				TransactionId: utxo.Outpoint.TransactionID.String(),
+				Index:         utxo.Outpoint.Index,
+			},
+			Amount:           utxo.UTXOEntry.Amount(),
+			BlockDaaScore:    utxo.UTXOEntry.BlockDAAScore(),
+			IsCoinbase:       utxo.UTXOEntry.IsCoinbase(),
+			IsMature:         isUTXOSpendable(utxo, dagInfo.VirtualDAAScore, s.params.BlockCoinbaseMaturity),
+			MaturityDaaScore: maturityDAAScore,
+			IsFrozen:         isFrozen,
+			Account:          utxo.address.account,
+		})
+	}
+
+	return &pb.ListUTXOsResponse{
+		Utxos:           utxos,
+		VirtualDaaScore: dagInfo.VirtualDAAScore,
+	}, nil
+}
+
+func (s *server) FreezeUTXOs(_ context.Context, request *pb.FreezeUTXOsRequest) (*pb.FreezeUTXOsResponse, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	outpoints, err := s.walletOutpoints(request.Outpoints)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, outpoint := range outpoints {
+		err = s.database.Put(frozenOutpointKey(outpoint), []byte{})
+		if err != nil {
+			return nil, err
+		}
+		s.frozenOutpoints[*outpoint] = struct{}{}
+	}
+
+	return &pb.FreezeUTXOsResponse{}, nil
+}
+
+func (s *server) UnfreezeUTXOs(_ context.Context, request *pb.UnfreezeUTXOsRequest) (*pb.UnfreezeUTXOsResponse, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	outpoints := make([]*externalapi.DomainOutpoint, len(request.Outpoints))
+	for i, pbOutpoint := range request.Outpoints {
+		outpoint, err := libc4exwallet.C4exwalletdOutpointToDomainOutpoint(pbOutpoint)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := s.frozenOutpoints[*outpoint]; !ok {
+			return nil, errors.Errorf("UTXO %s:%d is not frozen", outpoint.TransactionID, outpoint.Index)
+		}
+		outpoints[i] = outpoint
+	}
+
+	for _, outpoint := range outpoints {
+		err := s.database.Delete(frozenOutpointKey(outpoint))
+		if err != nil {
+			return nil, err
+		}
+		delete(s.frozenOutpoints, *outpoint)
+	}
+
+	return &pb.UnfreezeUTXOsResponse{}, nil
+}
+
+// walletOutpoints converts the given outpoints, and makes sure all of them are UTXOs of the wallet
+func (s *server) walletOutpoints(pbOutpoints []*pb.Outpoint) ([]*externalapi.DomainOutpoint, error) {
+	utxos := make(map[externalapi.DomainOutpoint]struct{}, len(s.utxosSortedByAmount))
+	for _, utxo := range s.utxosSortedByAmount {
+		utxos[*utxo.Outpoint] = struct{}{}
+	}
+
+	outpoints := make([]*externalapi.DomainOutpoint, len(pbOutpoints))
+	for i, pbOutpoint := range pbOutpoints {
+		outpoint, err := libc4exwallet.C4exwalletdOutpointToDomainOutpoint(pbOutpoint)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := utxos[*outpoint]; !ok {
+			return nil, errors.Errorf("%s:%d is not a UTXO of the wallet", outpoint.TransactionID, outpoint.Index)
+		}
+		outpoints[i] = outpoint
+	}
+	return outpoints, nil
+}
+
+// readFrozenOutpoints reads the outpoints that were frozen by the user. They are kept
+// even after they're spent, in case they come back to the UTXO set after a reorg.
+func readFrozenOutpoints(db database.Database) (map[externalapi.DomainOutpoint]struct{}, error) {
+	cursor, err := db.Cursor(frozenOutpointsBucket)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close()
+
+	frozenOutpoints := make(map[externalapi.DomainOutpoint]struct{})
+	for ok := cursor.First(); ok; ok = cursor.Next() {
+		key, err := cursor.Key()
+		if err != nil {
+			return nil, err
+		}
+
+		suffix := key.Suffix()
+		if len(suffix) != externalapi.DomainHashSize+4 {
+			return nil, errors.Errorf("invalid frozen outpoint key %s", key)
+		}
+		transactionID, err := externalapi.NewDomainTransactionIDFromByteSlice(suffix[:externalapi.DomainHashSize])
+		if err != nil {
+			return nil, err
+		}
+		index := binary.LittleEndian.Uint32(suffix[externalapi.DomainHashSize:])
+		frozenOutpoints[externalapi.DomainOutpoint{TransactionID: *transactionID, Index: index}] = struct{}{}
+	}
+
+	return frozenOutpoints, nil
+}
+
+func frozenOutpointKey(outpoint *externalapi.DomainOutpoint) *database.Key {
+	suffix := make([]byte, externalapi.DomainHashSize+4)
+	copy(suffix, outpoint.TransactionID.ByteSlice())
+	binary.LittleEndian.PutUint32(suffix[externalapi.DomainHashSize:], outpoint.Index)
+	return frozenOutpointsBucket.Key(suffix)
+}
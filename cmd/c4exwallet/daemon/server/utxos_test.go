@@ -0,0 +1,41 @@
+package server
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/c4ei/c4exd/domain/consensus/model/externalapi"
+)
+
+func TestReadFrozenOutpoints(t *testing.T) {
+	db, err := openDatabase(filepath.Join(t.TempDir(), "keys.json"))
+	if err != nil {
+		t.Fatalf("openDatabase: %s", err)
+	}
+	defer db.Close()
+
+	outpoints := []*externalapi.DomainOutpoint{
+		{TransactionID: *transactionIDForTest(1), Index: 0},
+		{TransactionID: *transactionIDForTest(1), Index: 1000},
+		{TransactionID: *transactionIDForTest(2), Index: 3},
+	}
+	for _, outpoint := range outpoints {
+		err = db.Put(frozenOutpointKey(outpoint), []byte{})
+		if err != nil {
+			t.Fatalf("Put: %s", err)
+		}
+	}
+
+	frozenOutpoints, err := readFrozenOutpoints(db)
+	if err != nil {
+		t.Fatalf("readFrozenOutpoints: %s", err)
+	}
+	if len(frozenOutpoints) != len(outpoints) {
+		t.Fatalf("expected %d frozen outpoints, got %d", len(outpoints), len(frozenOutpoints))
+	}
+	for _, outpoint := range outpoints {
+		if _, ok := frozenOutpoints[*outpoint]; !ok {
+			t.Fatalf("outpoint %s was not read", outpoint)
+		}
+	}
+}
@@ -25,12 +25,15 @@ func dumpUnencryptedData(conf *dumpUnencryptedDataConfig) error {
 		return err
 	}
 
-	if len(conf.Password) == 0 {
-		conf.Password = keys.GetPassword("Password:")
-	}
-	mnemonics, err := keysFile.DecryptMnemonics(conf.Password)
-	if err != nil {
-		return err
+	var mnemonics []string
+	if !keysFile.IsWatchOnly() && !keysFile.HasExternalSigner() {
+		if len(conf.Password) == 0 {
+			conf.Password = keys.GetPassword("Password:")
+		}
+		mnemonics, err = keysFile.DecryptMnemonics(conf.Password)
+		if err != nil {
+			return err
+		}
 	}
 
 	mnemonicPublicKeys := make(map[string]struct{})
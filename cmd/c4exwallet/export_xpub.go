@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/c4ei/c4exd/cmd/c4exwallet/keys"
+)
+
+func exportXPub(conf *exportXPubConfig) error {
+	keysFile, err := keys.ReadKeysFile(conf.NetParams(), conf.KeysFile)
+	if err != nil {
+		return err
+	}
+
+	accounts := keysFile.Accounts()
+	if conf.Account != "" {
+		account, err := keysFile.FindAccount(conf.Account)
+		if err != nil {
+			return err
+		}
+		accounts = []*keys.Account{account}
+	}
+
+	keyType := "Schnorr"
+	if keysFile.ECDSA {
+		keyType = "ECDSA"
+	}
+	for i, account := range accounts {
+		if i > 0 {
+			fmt.Println()
+		}
+		if len(account.ExtendedPublicKeys) > 1 {
+			fmt.Printf("Account %d (%s), %s multisig of %d of %d keys\n", account.Index, account.Name, keyType,
+				keysFile.MinimumSignatures, len(account.ExtendedPublicKeys))
+		} else {
+			fmt.Printf("Account %d (%s), %s\n", account.Index, account.Name, keyType)
+		}
+		for j, extendedPublicKey := range account.ExtendedPublicKeys {
+			fmt.Printf("Extended public key #%d:\n%s\n", j+1, extendedPublicKey)
+		}
+	}
+
+	fmt.Printf("\nTo watch an account, create a wallet with 'create --watch-only' and an '--xpub' for each " +
+		"of its extended public keys, along with '--min-signatures' for a multisig account and '--ecdsa' " +
+		"for an ECDSA wallet\n")
+	return nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/c4ei/c4exd/cmd/c4exwallet/keys"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet"
+	"github.com/pkg/errors"
+)
+
+func finalize(conf *finalizeConfig) error {
+	if conf.Transaction == "" && conf.TransactionFile == "" {
+		return errors.Errorf("Either --transaction or --transaction-file is required")
+	}
+	if conf.Transaction != "" && conf.TransactionFile != "" {
+		return errors.Errorf("Both --transaction and --transaction-file cannot be passed at the same time")
+	}
+
+	// The keys file tells the signature scheme, which the signature scripts depend on
+	keysFile, err := keys.ReadKeysFile(conf.NetParams(), conf.KeysFile)
+	if err != nil {
+		return err
+	}
+
+	transactionsHex := conf.Transaction
+	if conf.TransactionFile != "" {
+		transactionHexBytes, err := ioutil.ReadFile(conf.TransactionFile)
+		if err != nil {
+			return errors.Wrapf(err, "Could not read hex from %s", conf.TransactionFile)
+		}
+		transactionsHex = strings.TrimSpace(string(transactionHexBytes))
+	}
+	partiallySignedTransactions, err := decodeTransactionsFromHex(transactionsHex)
+	if err != nil {
+		return err
+	}
+
+	finalizedTransactions := make([][]byte, len(partiallySignedTransactions))
+	for i, partiallySignedTransaction := range partiallySignedTransactions {
+		finalizedTransactions[i], err = libc4exwallet.Finalize(partiallySignedTransaction, keysFile.ECDSA)
+		if err != nil {
+			return errors.Wrapf(err, "Could not finalize transaction #%d", i+1)
+		}
+	}
+
+	fmt.Fprintln(os.Stderr, "The transaction is finalized, broadcast it with `broadcast --finalized`")
+	fmt.Println(encodeTransactionsToHex(finalizedTransactions))
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/c4ei/c4exd/cmd/c4exwallet/daemon/client"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/daemon/pb"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/utils"
+	"github.com/c4ei/c4exd/domain/consensus/utils/constants"
+)
+
+func history(conf *historyConfig) error {
+	daemonClient, tearDown, err := client.Connect(conf.DaemonAddress, conf.securityOptions())
+	if err != nil {
+		return err
+	}
+	defer tearDown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), daemonTimeout)
+	defer cancel()
+	response, err := daemonClient.GetTransactionHistory(ctx, &pb.GetTransactionHistoryRequest{
+		Offset:        conf.Offset,
+		Limit:         conf.Limit,
+		Address:       conf.Address,
+		Direction:     conf.Direction,
+		PendingOnly:   conf.PendingOnly,
+		ConfirmedOnly: conf.ConfirmedOnly,
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(response.Entries) == 0 {
+		fmt.Println("No transactions found")
+		return nil
+	}
+
+	println("Transaction ID                                                   Direction   Amount, C4X          Fee, C4X             Status")
+	println("--------------------------------------------------------------------------------------------------------------------------------------")
+	for _, entry := range response.Entries {
+		var total int64
+		for _, amount := range entry.Amounts {
+			total += amount.Amount
+		}
+
+		fee := "unknown            "
+		if entry.IsFeeKnown {
+			fee = utils.FormatC4x(entry.Fee)
+		}
+
+		fmt.Printf("%s %-11s %s %s %s\n", entry.TransactionId, formatDirection(entry), formatSignedC4x(total), fee,
+			formatHistoryStatus(entry))
+
+		if conf.Verbose {
+			fmt.Printf("    Seen at %s\n", time.UnixMilli(entry.Timestamp).Format(time.RFC3339))
+			for _, amount := range entry.Amounts {
+				fmt.Printf("    %s %s\n", amount.Address, formatSignedC4x(amount.Amount))
+			}
+		}
+	}
+	println("--------------------------------------------------------------------------------------------------------------------------------------")
+	fmt.Printf("Showing %d to %d of %d transactions\n", conf.Offset+1, conf.Offset+uint32(len(response.Entries)),
+		response.TotalCount)
+
+	return nil
+}
+
+func formatDirection(entry *pb.TransactionHistoryEntry) string {
+	if entry.IsCoinbase {
+		return "coinbase"
+	}
+	return entry.Direction
+}
+
+func formatHistoryStatus(entry *pb.TransactionHistoryEntry) string {
+	if !entry.IsConfirmed {
+		return "pending"
+	}
+	return fmt.Sprintf("%d confirmations (DAA score %d)", entry.Confirmations, entry.AcceptingBlockDaaScore)
+}
+
+// formatSignedC4x is like utils.FormatC4x, but for amounts that may be negative
+func formatSignedC4x(amount int64) string {
+	return fmt.Sprintf("%+20.8f", float64(amount)/constants.SompiPerC4ex)
+}
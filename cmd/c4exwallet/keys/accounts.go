@@ -0,0 +1,209 @@
+package keys
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet"
+	"github.com/pkg/errors"
+)
+
+// DefaultAccountName is the name of account 0, the account the wallet was created with
+const DefaultAccountName = "default"
+
+type accountJSON struct {
+	Index                 uint32   `json:"index"`
+	Name                  string   `json:"name"`
+	ExtendedPublicKeys    []string `json:"publicKeys"`
+	CosignerIndex         uint32   `json:"cosignerIndex"`
+	LastUsedExternalIndex uint32   `json:"lastUsedExternalIndex"`
+	LastUsedInternalIndex uint32   `json:"lastUsedInternalIndex"`
+}
+
+// Account is a BIP44 account of the wallet. All the accounts of a wallet are derived
+// from the same keys, each under its own hardened account path, so the funds of each
+// account are kept apart while the wallet keeps a single backup.
+type Account struct {
+	Index              uint32
+	Name               string
+	ExtendedPublicKeys []string
+	CosignerIndex      uint32
+
+	lastUsedExternalIndex uint32
+	lastUsedInternalIndex uint32
+	file                  *File
+}
+
+func (a *Account) toJSON() *accountJSON {
+	return &accountJSON{
+		Index:                 a.Index,
+		Name:                  a.Name,
+		ExtendedPublicKeys:    a.ExtendedPublicKeys,
+		CosignerIndex:         a.CosignerIndex,
+		LastUsedExternalIndex: a.lastUsedExternalIndex,
+		LastUsedInternalIndex: a.lastUsedInternalIndex,
+	}
+}
+
+func (d *File) accountFromJSON(accountJSON *accountJSON) *Account {
+	return &Account{
+		Index:                 accountJSON.Index,
+		Name:                  accountJSON.Name,
+		ExtendedPublicKeys:    accountJSON.ExtendedPublicKeys,
+		CosignerIndex:         accountJSON.CosignerIndex,
+		lastUsedExternalIndex: accountJSON.LastUsedExternalIndex,
+		lastUsedInternalIndex: accountJSON.LastUsedInternalIndex,
+		file:                  d,
+	}
+}
+
+// SetLastUsedExternalIndex sets the last used index in the external key
+// chain of the account, and saves the file with the updated data.
+func (a *Account) SetLastUsedExternalIndex(index uint32) error {
+	if a.lastUsedExternalIndex == index {
+		return nil
+	}
+
+	a.lastUsedExternalIndex = index
+	return a.file.Save()
+}
+
+// LastUsedExternalIndex returns the last used index in the external key chain of the account
+func (a *Account) LastUsedExternalIndex() uint32 {
+	return a.lastUsedExternalIndex
+}
+
+// SetLastUsedInternalIndex sets the last used index in the internal key chain
+// of the account, and saves the file.
+func (a *Account) SetLastUsedInternalIndex(index uint32) error {
+	if a.lastUsedInternalIndex == index {
+		return nil
+	}
+
+	a.lastUsedInternalIndex = index
+	return a.file.Save()
+}
+
+// LastUsedInternalIndex returns the last used index in the internal key chain of the account
+func (a *Account) LastUsedInternalIndex() uint32 {
+	return a.lastUsedInternalIndex
+}
+
+// DefaultAccount returns account 0, whose keys are the ExtendedPublicKeys of the file
+func (d *File) DefaultAccount() *Account {
+	if len(d.accounts) == 0 {
+		d.accounts = []*Account{{
+			Index:              0,
+			Name:               DefaultAccountName,
+			ExtendedPublicKeys: d.ExtendedPublicKeys,
+			CosignerIndex:      d.CosignerIndex,
+			file:               d,
+		}}
+	}
+	return d.accounts[0]
+}
+
+// Accounts returns all the accounts of the wallet, ordered by their index
+func (d *File) Accounts() []*Account {
+	d.DefaultAccount()
+	return d.accounts
+}
+
+// Account returns the account with the given index
+func (d *File) Account(index uint32) (*Account, error) {
+	for _, account := range d.Accounts() {
+		if account.Index == index {
+			return account, nil
+		}
+	}
+	return nil, errors.Errorf("the wallet has no account with index %d", index)
+}
+
+// FindAccount returns the account with the given name or index. An empty string
+// stands for the default account.
+func (d *File) FindAccount(nameOrIndex string) (*Account, error) {
+	if nameOrIndex == "" {
+		return d.DefaultAccount(), nil
+	}
+
+	for _, account := range d.Accounts() {
+		if account.Name == nameOrIndex {
+			return account, nil
+		}
+	}
+
+	index, err := strconv.ParseUint(nameOrIndex, 10, 32)
+	if err != nil {
+		return nil, errors.Errorf("the wallet has no account named %s", nameOrIndex)
+	}
+	return d.Account(uint32(index))
+}
+
+// NextAccountIndex returns the index of the account AddAccount adds next
+func (d *File) NextAccountIndex() uint32 {
+	accounts := d.Accounts()
+	return accounts[len(accounts)-1].Index + 1
+}
+
+// AddAccount adds an account with the given name and extended public keys as the
+// account with the index NextAccountIndex returns, and saves the file.
+func (d *File) AddAccount(name string, extendedPublicKeys []string, cosignerIndex uint32) (*Account, error) {
+	account := &Account{
+		Index:              d.NextAccountIndex(),
+		Name:               name,
+		ExtendedPublicKeys: extendedPublicKeys,
+		CosignerIndex:      cosignerIndex,
+		file:               d,
+	}
+	err := d.addAccount(account)
+	if err != nil {
+		return nil, err
+	}
+
+	err = d.Save()
+	if err != nil {
+		d.accounts = d.accounts[:len(d.accounts)-1]
+		return nil, err
+	}
+	return account, nil
+}
+
+func (d *File) addAccount(account *Account) error {
+	err := ValidateAccountName(account.Name)
+	if err != nil {
+		return err
+	}
+
+	accounts := d.Accounts()
+	lastAccount := accounts[len(accounts)-1]
+	if account.Index <= lastAccount.Index || account.Index > libc4exwallet.MaxAccountIndex {
+		return errors.Errorf("invalid index %d for account %s", account.Index, account.Name)
+	}
+	for _, existingAccount := range accounts {
+		if existingAccount.Name == account.Name {
+			return errors.Errorf("the wallet already has an account named %s", account.Name)
+		}
+	}
+	if len(account.ExtendedPublicKeys) != len(d.ExtendedPublicKeys) {
+		return errors.Errorf("account %s has %d extended public keys, while the wallet has %d",
+			account.Name, len(account.ExtendedPublicKeys), len(d.ExtendedPublicKeys))
+	}
+	if account.CosignerIndex >= uint32(len(account.ExtendedPublicKeys)) {
+		return errors.Errorf("invalid cosigner index %d for account %s", account.CosignerIndex, account.Name)
+	}
+
+	d.accounts = append(d.accounts, account)
+	return nil
+}
+
+// ValidateAccountName returns an error if the given name can't be the name of an account.
+// Names that are numbers are rejected, since accounts are also referred to by their index.
+func ValidateAccountName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return errors.New("the account name must not be empty")
+	}
+	if _, err := strconv.ParseUint(name, 10, 64); err == nil {
+		return errors.Errorf("the account name %s must not be a number", name)
+	}
+	return nil
+}
@@ -14,8 +14,10 @@ import (
 	"github.com/tyler-smith/go-bip39"
 )
 
-// CreateMnemonics generates `numKeys` number of mnemonics.
-func CreateMnemonics(params *dagconfig.Params, numKeys uint32, cmdLinePassword string, isMultisig bool) (encryptedPrivateKeys []*EncryptedMnemonic, extendedPublicKeys []string, err error) {
+// CreateMnemonics generates `numKeys` number of mnemonics, and encrypts them with the given KDF parameters.
+func CreateMnemonics(params *dagconfig.Params, numKeys uint32, cmdLinePassword string, isMultisig bool,
+	kdfParams KDFParams) (encryptedPrivateKeys []*EncryptedMnemonic, extendedPublicKeys []string, err error) {
+
 	mnemonics := make([]string, numKeys)
 	for i := uint32(0); i < numKeys; i++ {
 		var err error
@@ -25,11 +27,13 @@ func CreateMnemonics(params *dagconfig.Params, numKeys uint32, cmdLinePassword s
 		}
 	}
 
-	return encryptedMnemonicExtendedPublicKeyPairs(params, mnemonics, cmdLinePassword, isMultisig)
+	return encryptedMnemonicExtendedPublicKeyPairs(params, mnemonics, cmdLinePassword, isMultisig, kdfParams)
 }
 
-// ImportMnemonics imports a `numKeys` of mnemonics.
-func ImportMnemonics(params *dagconfig.Params, numKeys uint32, cmdLinePassword string, isMultisig bool) (encryptedPrivateKeys []*EncryptedMnemonic, extendedPublicKeys []string, err error) {
+// ImportMnemonics imports a `numKeys` of mnemonics, and encrypts them with the given KDF parameters.
+func ImportMnemonics(params *dagconfig.Params, numKeys uint32, cmdLinePassword string, isMultisig bool,
+	kdfParams KDFParams) (encryptedPrivateKeys []*EncryptedMnemonic, extendedPublicKeys []string, err error) {
+
 	mnemonics := make([]string, numKeys)
 	for i := uint32(0); i < numKeys; i++ {
 		fmt.Printf("Enter mnemonic #%d here:\n", i+1)
@@ -45,11 +49,18 @@ func ImportMnemonics(params *dagconfig.Params, numKeys uint32, cmdLinePassword s
 
 		mnemonics[i] = string(mnemonic)
 	}
-	return encryptedMnemonicExtendedPublicKeyPairs(params, mnemonics, cmdLinePassword, isMultisig)
+	return encryptedMnemonicExtendedPublicKeyPairs(params, mnemonics, cmdLinePassword, isMultisig, kdfParams)
 }
 
-func encryptedMnemonicExtendedPublicKeyPairs(params *dagconfig.Params, mnemonics []string, cmdLinePassword string, isMultisig bool) (
-	encryptedPrivateKeys []*EncryptedMnemonic, extendedPublicKeys []string, err error) {
+func encryptedMnemonicExtendedPublicKeyPairs(params *dagconfig.Params, mnemonics []string, cmdLinePassword string,
+	isMultisig bool, kdfParams KDFParams) (encryptedPrivateKeys []*EncryptedMnemonic, extendedPublicKeys []string,
+	err error) {
+
+	err = kdfParams.Validate()
+	if err != nil {
+		return nil, nil, err
+	}
+
 	password := []byte(cmdLinePassword)
 	if len(password) == 0 {
 
@@ -72,7 +83,7 @@ func encryptedMnemonicExtendedPublicKeyPairs(params *dagconfig.Params, mnemonics
 
 		extendedPublicKeys = append(extendedPublicKeys, extendedPublicKey)
 
-		encryptedPrivateKey, err := encryptMnemonic(mnemonic, password)
+		encryptedPrivateKey, err := encryptMnemonic(mnemonic, password, kdfParams)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -92,7 +103,7 @@ func generateSalt() ([]byte, error) {
 	return salt, nil
 }
 
-func encryptMnemonic(mnemonic string, password []byte) (*EncryptedMnemonic, error) {
+func encryptMnemonic(mnemonic string, password []byte, kdfParams KDFParams) (*EncryptedMnemonic, error) {
 	mnemonicBytes := []byte(mnemonic)
 
 	salt, err := generateSalt()
@@ -100,7 +111,7 @@ func encryptMnemonic(mnemonic string, password []byte) (*EncryptedMnemonic, erro
 		return nil, err
 	}
 
-	aead, err := getAEAD(defaultNumThreads, password, salt)
+	aead, err := getAEAD(kdfParams, password, salt)
 	if err != nil {
 		return nil, err
 	}
@@ -115,7 +126,8 @@ func encryptMnemonic(mnemonic string, password []byte) (*EncryptedMnemonic, erro
 	cipher := aead.Seal(nonce, nonce, []byte(mnemonicBytes), nil)
 
 	return &EncryptedMnemonic{
-		cipher: cipher,
-		salt:   salt,
+		cipher:    cipher,
+		salt:      salt,
+		kdfParams: kdfParams,
 	}, nil
 }
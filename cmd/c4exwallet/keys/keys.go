@@ -26,16 +26,26 @@ var (
 	defaultAppDir = util.AppDir("c4exwallet", false)
 )
 
-// LastVersion is the most up to date file format version
-const LastVersion = 1
+// ErrWatchOnly is returned when secrets are requested from a watch-only keys file
+var ErrWatchOnly = errors.New("the wallet is watch-only and has no private keys: " +
+	"sign the transaction with the wallet that holds the private keys")
+
+// ErrExternalSigner is returned when secrets are requested from a keys file whose keys are held by an external signer
+var ErrExternalSigner = errors.New("the private keys of the wallet are held by an external signer")
+
+// LastVersion is the most up to date file format version. Version 2 added the
+// accounts other than the default one, and version 3 the KDF parameters of each
+// encrypted mnemonic.
+const LastVersion = 3
 
 func defaultKeysFile(netParams *dagconfig.Params) string {
 	return filepath.Join(defaultAppDir, netParams.Name, "keys.json")
 }
 
 type encryptedPrivateKeyJSON struct {
-	Cipher string `json:"cipher"`
-	Salt   string `json:"salt"`
+	Cipher    string     `json:"cipher"`
+	Salt      string     `json:"salt"`
+	KDFParams *KDFParams `json:"kdfParams,omitempty"`
 }
 
 type keysFileJSON struct {
@@ -48,26 +58,34 @@ type keysFileJSON struct {
 	LastUsedExternalIndex uint32                     `json:"lastUsedExternalIndex"`
 	LastUsedInternalIndex uint32                     `json:"lastUsedInternalIndex"`
 	ECDSA                 bool                       `json:"ecdsa"`
+	ExternalSigner        string                     `json:"externalSigner,omitempty"`
+	ExternalSignerKeys    uint32                     `json:"externalSignerKeys,omitempty"`
+	Accounts              []*accountJSON             `json:"accounts,omitempty"`
 }
 
 // EncryptedMnemonic represents an encrypted mnemonic
 type EncryptedMnemonic struct {
-	cipher []byte
-	salt   []byte
+	cipher    []byte
+	salt      []byte
+	kdfParams KDFParams
 }
 
 // File holds all the data related to the wallet keys
 type File struct {
-	Version               uint32
-	NumThreads            uint8 // This field is ignored for versions different than 0
-	EncryptedMnemonics    []*EncryptedMnemonic
-	ExtendedPublicKeys    []string
-	MinimumSignatures     uint32
-	CosignerIndex         uint32
-	lastUsedExternalIndex uint32
-	lastUsedInternalIndex uint32
-	ECDSA                 bool
-	path                  string
+	Version            uint32
+	NumThreads         uint8 // This field is ignored for versions different than 0
+	EncryptedMnemonics []*EncryptedMnemonic
+	ExtendedPublicKeys []string
+	MinimumSignatures  uint32
+	CosignerIndex      uint32
+	ECDSA              bool
+	ExternalSigner     string // The address of the external signer that holds the private keys, if any
+	ExternalSignerKeys uint32 // The number of private keys the external signer holds
+	path               string
+
+	// accounts holds all the accounts of the wallet, starting with the default one. It's
+	// initialized lazily, since files created in code set ExtendedPublicKeys and CosignerIndex only.
+	accounts []*Account
 }
 
 func (d *File) toJSON() *keysFileJSON {
@@ -77,6 +95,17 @@ func (d *File) toJSON() *keysFileJSON {
 			Cipher: hex.EncodeToString(encryptedPrivateKey.cipher),
 			Salt:   hex.EncodeToString(encryptedPrivateKey.salt),
 		}
+		// The number of threads of version 0 files is only known once it's detected
+		if d.Version != 0 {
+			kdfParams := encryptedPrivateKey.kdfParams
+			encryptedPrivateKeysJSON[i].KDFParams = &kdfParams
+		}
+	}
+
+	defaultAccount := d.DefaultAccount()
+	accountsJSON := make([]*accountJSON, 0, len(d.accounts)-1)
+	for _, account := range d.accounts[1:] {
+		accountsJSON = append(accountsJSON, account.toJSON())
 	}
 
 	return &keysFileJSON{
@@ -86,16 +115,19 @@ func (d *File) toJSON() *keysFileJSON {
 		ExtendedPublicKeys:    d.ExtendedPublicKeys,
 		MinimumSignatures:     d.MinimumSignatures,
 		ECDSA:                 d.ECDSA,
+		ExternalSigner:        d.ExternalSigner,
+		ExternalSignerKeys:    d.ExternalSignerKeys,
 		CosignerIndex:         d.CosignerIndex,
-		LastUsedExternalIndex: d.lastUsedExternalIndex,
-		LastUsedInternalIndex: d.lastUsedInternalIndex,
+		LastUsedExternalIndex: defaultAccount.lastUsedExternalIndex,
+		LastUsedInternalIndex: defaultAccount.lastUsedInternalIndex,
+		Accounts:              accountsJSON,
 	}
 }
 
 // NewFileFromMnemonic generates a new File from the given mnemonic string
 func NewFileFromMnemonic(params *dagconfig.Params, mnemonic string, password string) (*File, error) {
 	encryptedMnemonics, extendedPublicKeys, err :=
-		encryptedMnemonicExtendedPublicKeyPairs(params, []string{mnemonic}, password, false)
+		encryptedMnemonicExtendedPublicKeyPairs(params, []string{mnemonic}, password, false, DefaultKDFParams)
 	if err != nil {
 		return nil, err
 	}
@@ -110,14 +142,37 @@ func NewFileFromMnemonic(params *dagconfig.Params, mnemonic string, password str
 }
 
 func (d *File) fromJSON(fileJSON *keysFileJSON) error {
+	if fileJSON.Version > LastVersion {
+		return errors.Errorf("the keys file version is %d, while the latest version this wallet supports is %d: "+
+			"upgrade the wallet to use it", fileJSON.Version, LastVersion)
+	}
+
 	d.Version = fileJSON.Version
+	// Versions 2 and 3 only add fields to version 1, and the mnemonics of versions 1 and 2 are encrypted
+	// with the default KDF parameters, so these files are migrated by setting their version. The migrated
+	// file is written the next time it's saved. Version 0 files keep their version until the number of
+	// threads their mnemonics are encrypted with is detected, which requires the password.
+	isMigrated := d.Version == 1 || d.Version == 2
+	if isMigrated {
+		d.Version = LastVersion
+	}
 	d.NumThreads = fileJSON.NumThreads
 	d.MinimumSignatures = fileJSON.MinimumSignatures
 	d.ECDSA = fileJSON.ECDSA
+	d.ExternalSigner = fileJSON.ExternalSigner
+	d.ExternalSignerKeys = fileJSON.ExternalSignerKeys
 	d.ExtendedPublicKeys = fileJSON.ExtendedPublicKeys
 	d.CosignerIndex = fileJSON.CosignerIndex
-	d.lastUsedExternalIndex = fileJSON.LastUsedExternalIndex
-	d.lastUsedInternalIndex = fileJSON.LastUsedInternalIndex
+
+	defaultAccount := d.DefaultAccount()
+	defaultAccount.lastUsedExternalIndex = fileJSON.LastUsedExternalIndex
+	defaultAccount.lastUsedInternalIndex = fileJSON.LastUsedInternalIndex
+	for _, accountJSON := range fileJSON.Accounts {
+		err := d.addAccount(d.accountFromJSON(accountJSON))
+		if err != nil {
+			return err
+		}
+	}
 
 	d.EncryptedMnemonics = make([]*EncryptedMnemonic, len(fileJSON.EncryptedPrivateKeys))
 	for i, encryptedPrivateKeyJSON := range fileJSON.EncryptedPrivateKeys {
@@ -135,6 +190,22 @@ func (d *File) fromJSON(fileJSON *keysFileJSON) error {
 			cipher: cipher,
 			salt:   salt,
 		}
+		switch {
+		case d.Version == 0:
+			d.EncryptedMnemonics[i].kdfParams = DefaultKDFParams
+			d.EncryptedMnemonics[i].kdfParams.Threads = d.NumThreads
+		case isMigrated:
+			d.EncryptedMnemonics[i].kdfParams = DefaultKDFParams
+		default:
+			if encryptedPrivateKeyJSON.KDFParams == nil {
+				return errors.Errorf("encrypted mnemonic #%d has no KDF parameters", i+1)
+			}
+			err = encryptedPrivateKeyJSON.KDFParams.Validate()
+			if err != nil {
+				return errors.Wrapf(err, "invalid KDF parameters of encrypted mnemonic #%d", i+1)
+			}
+			d.EncryptedMnemonics[i].kdfParams = *encryptedPrivateKeyJSON.KDFParams
+		}
 	}
 
 	return nil
@@ -174,47 +245,65 @@ func (d *File) Path() string {
 	return d.path
 }
 
-// SetLastUsedExternalIndex sets the last used index in the external key
-// chain, and saves the file with the updated data.
+// SetLastUsedExternalIndex sets the last used index in the external key chain of
+// the default account, and saves the file with the updated data.
 func (d *File) SetLastUsedExternalIndex(index uint32) error {
-	if d.lastUsedExternalIndex == index {
-		return nil
-	}
-
-	d.lastUsedExternalIndex = index
-	return d.Save()
+	return d.DefaultAccount().SetLastUsedExternalIndex(index)
 }
 
 // LastUsedExternalIndex returns the last used index in the external key
-// chain and saves the file with the updated data.
+// chain of the default account
 func (d *File) LastUsedExternalIndex() uint32 {
-	return d.lastUsedExternalIndex
+	return d.DefaultAccount().LastUsedExternalIndex()
 }
 
-// SetLastUsedInternalIndex sets the last used index in the internal key chain, and saves the file.
+// SetLastUsedInternalIndex sets the last used index in the internal key chain
+// of the default account, and saves the file.
 func (d *File) SetLastUsedInternalIndex(index uint32) error {
-	if d.lastUsedInternalIndex == index {
-		return nil
-	}
-
-	d.lastUsedInternalIndex = index
-	return d.Save()
+	return d.DefaultAccount().SetLastUsedInternalIndex(index)
 }
 
-// LastUsedInternalIndex returns the last used index in the internal key chain
+// LastUsedInternalIndex returns the last used index in the internal key chain of the default account
 func (d *File) LastUsedInternalIndex() uint32 {
-	return d.lastUsedInternalIndex
+	return d.DefaultAccount().LastUsedInternalIndex()
+}
+
+// IsWatchOnly returns whether the file holds extended public keys only, in which
+// case the wallet can watch its addresses and create unsigned transactions, but
+// cannot sign them.
+func (d *File) IsWatchOnly() bool {
+	return len(d.EncryptedMnemonics) == 0 && !d.HasExternalSigner()
+}
+
+// HasExternalSigner returns whether the private keys of the file are held by an
+// external signer, which signs the wallet's transactions instead of the file's keys.
+func (d *File) HasExternalSigner() bool {
+	return d.ExternalSigner != ""
+}
+
+// NumPrivateKeys returns the number of the file's extended public keys whose
+// private keys the wallet can sign with
+func (d *File) NumPrivateKeys() int {
+	if d.HasExternalSigner() {
+		return int(d.ExternalSignerKeys)
+	}
+	return len(d.EncryptedMnemonics)
 }
 
 // DecryptMnemonics asks the user to enter the password for the private keys and
 // returns the decrypted private keys.
 func (d *File) DecryptMnemonics(password string) ([]string, error) {
+	if d.IsWatchOnly() {
+		return nil, ErrWatchOnly
+	}
+	if d.HasExternalSigner() {
+		return nil, ErrExternalSigner
+	}
+
 	passwordBytes := []byte(password)
 
-	var numThreads uint8
-	if len(d.EncryptedMnemonics) > 0 {
-		var err error
-		numThreads, err = d.numThreads(passwordBytes)
+	if d.Version == 0 && len(d.EncryptedMnemonics) > 0 {
+		err := d.migrateVersion0(passwordBytes)
 		if err != nil {
 			return nil, err
 		}
@@ -223,7 +312,7 @@ func (d *File) DecryptMnemonics(password string) ([]string, error) {
 	privateKeys := make([]string, len(d.EncryptedMnemonics))
 	for i, encryptedPrivateKey := range d.EncryptedMnemonics {
 		var err error
-		privateKeys[i], err = decryptMnemonic(numThreads, encryptedPrivateKey, passwordBytes)
+		privateKeys[i], err = decryptMnemonic(encryptedPrivateKey, passwordBytes)
 		if err != nil {
 			return nil, err
 		}
@@ -232,6 +321,32 @@ func (d *File) DecryptMnemonics(password string) ([]string, error) {
 	return privateKeys, nil
 }
 
+// ChangePassword re-encrypts the mnemonics of the file with a new password and the given KDF
+// parameters, and saves the file. Each mnemonic is encrypted with a new salt and nonce.
+func (d *File) ChangePassword(password string, newPassword string, kdfParams KDFParams) error {
+	err := kdfParams.Validate()
+	if err != nil {
+		return err
+	}
+
+	mnemonics, err := d.DecryptMnemonics(password)
+	if err != nil {
+		return err
+	}
+
+	encryptedMnemonics := make([]*EncryptedMnemonic, len(mnemonics))
+	for i, mnemonic := range mnemonics {
+		encryptedMnemonics[i], err = encryptMnemonic(mnemonic, []byte(newPassword), kdfParams)
+		if err != nil {
+			return err
+		}
+	}
+
+	d.EncryptedMnemonics = encryptedMnemonics
+	d.Version = LastVersion
+	return d.Save()
+}
+
 // ReadKeysFile returns the data related to the keys file
 func ReadKeysFile(netParams *dagconfig.Params, path string) (*File, error) {
 	if path == "" {
@@ -291,7 +406,8 @@ func pathExists(path string) (bool, error) {
 	return false, err
 }
 
-// Save writes the file contents to the disk.
+// Save writes the file contents to the disk. The contents are written to a temporary file that
+// then replaces the file, so that the file is never left partially written.
 func (d *File) Save() error {
 	if d.path == "" {
 		return errors.New("cannot save a file with uninitialized path")
@@ -302,47 +418,88 @@ func (d *File) Save() error {
 		return err
 	}
 
-	file, err := os.OpenFile(d.path, os.O_WRONLY|os.O_CREATE, 0600)
+	temporaryPath := d.path + ".tmp"
+	file, err := os.OpenFile(temporaryPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
 
 	encoder := json.NewEncoder(file)
 	err = encoder.Encode(d.toJSON())
 	if err != nil {
+		file.Close()
 		return err
 	}
 
-	return nil
+	err = file.Sync()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(temporaryPath, d.path)
 }
 
 const defaultNumThreads = 8
 
-func (d *File) numThreads(password []byte) (uint8, error) {
-	// There's a bug in v0 wallets where the number of threads
-	// was determined by the number of logical CPUs at the machine,
-	// which made the authentication non-deterministic across platforms.
-	// In order to solve it we introduce v1 where the number of threads
-	// is constant, and brute force the number of threads in v0. After we
-	// find the right amount via brute force we save the result to the file.
+// KDFParams are the argon2id parameters that derive the key that encrypts a mnemonic from the password
+type KDFParams struct {
+	// Time is the number of passes over the memory
+	Time uint32 `json:"time"`
+	// Memory is the size of the memory in KiB
+	Memory  uint32 `json:"memory"`
+	Threads uint8  `json:"threads"`
+}
 
-	if d.Version != 0 {
-		return defaultNumThreads, nil
+// DefaultKDFParams are the KDF parameters new mnemonics are encrypted with by default. The mnemonics
+// of files of versions before 3 are encrypted with them.
+var DefaultKDFParams = KDFParams{
+	Time:    1,
+	Memory:  64 * 1024,
+	Threads: defaultNumThreads,
+}
+
+// Validate returns an error if argon2 can't derive a key with the parameters
+func (p KDFParams) Validate() error {
+	if p.Time < 1 {
+		return errors.New("the KDF time must be at least 1")
+	}
+	if p.Threads < 1 {
+		return errors.New("the KDF threads must be at least 1")
+	}
+	// argon2 uses at least 8 KiB of memory per thread
+	if p.Memory < 8*uint32(p.Threads) {
+		return errors.Errorf("the KDF memory must be at least %d KiB with %d threads", 8*uint32(p.Threads), p.Threads)
 	}
+	return nil
+}
 
+// migrateVersion0 migrates a version 0 file to the last version, and saves it.
+//
+// There's a bug in v0 wallets where the number of threads
+// was determined by the number of logical CPUs at the machine,
+// which made the authentication non-deterministic across platforms.
+// In order to solve it we introduce v1 where the number of threads
+// is constant, and brute force the number of threads in v0. After we
+// find the right amount via brute force we save it as the number of
+// threads of the KDF parameters of each mnemonic.
+func (d *File) migrateVersion0(password []byte) error {
 	numThreads, err := d.detectNumThreads(password, d.EncryptedMnemonics[0])
 	if err != nil {
-		return 0, err
+		return err
 	}
 
-	d.NumThreads = numThreads
-	err = d.Save()
-	if err != nil {
-		return 0, err
+	for _, encryptedMnemonic := range d.EncryptedMnemonics {
+		encryptedMnemonic.kdfParams.Threads = numThreads
 	}
-
-	return numThreads, nil
+	d.NumThreads = 0
+	d.Version = LastVersion
+	return d.Save()
 }
 
 func (d *File) detectNumThreads(password []byte, encryptedMnemonic *EncryptedMnemonic) (uint8, error) {
@@ -350,7 +507,14 @@ func (d *File) detectNumThreads(password []byte, encryptedMnemonic *EncryptedMne
 	if d.NumThreads == 0 {
 		firstGuessNumThreads = uint8(runtime.NumCPU())
 	}
-	_, err := decryptMnemonic(firstGuessNumThreads, encryptedMnemonic, password)
+	decryptWithNumThreads := func(numThreads uint8) error {
+		guess := *encryptedMnemonic
+		guess.kdfParams.Threads = numThreads
+		_, err := decryptMnemonic(&guess, password)
+		return err
+	}
+
+	err := decryptWithNumThreads(firstGuessNumThreads)
 	if err != nil {
 		if !strings.Contains(err.Error(), "message authentication failed") {
 			return 0, err
@@ -364,7 +528,7 @@ func (d *File) detectNumThreads(password []byte, encryptedMnemonic *EncryptedMne
 			continue
 		}
 
-		_, err := decryptMnemonic(numThreadsGuess, encryptedMnemonic, password)
+		err := decryptWithNumThreads(numThreadsGuess)
 		if err != nil {
 			const maxTries = 255
 			if numThreadsGuess == maxTries || !strings.Contains(err.Error(), "message authentication failed") {
@@ -376,13 +540,13 @@ func (d *File) detectNumThreads(password []byte, encryptedMnemonic *EncryptedMne
 	}
 }
 
-func getAEAD(threads uint8, password, salt []byte) (cipher.AEAD, error) {
-	key := argon2.IDKey(password, salt, 1, 64*1024, threads, 32)
+func getAEAD(kdfParams KDFParams, password, salt []byte) (cipher.AEAD, error) {
+	key := argon2.IDKey(password, salt, kdfParams.Time, kdfParams.Memory, kdfParams.Threads, 32)
 	return chacha20poly1305.NewX(key)
 }
 
-func decryptMnemonic(numThreads uint8, encryptedPrivateKey *EncryptedMnemonic, password []byte) (string, error) {
-	aead, err := getAEAD(numThreads, password, encryptedPrivateKey.salt)
+func decryptMnemonic(encryptedPrivateKey *EncryptedMnemonic, password []byte) (string, error) {
+	aead, err := getAEAD(encryptedPrivateKey.kdfParams, password, encryptedPrivateKey.salt)
 	if err != nil {
 		return "", err
 	}
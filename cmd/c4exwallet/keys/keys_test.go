@@ -0,0 +1,189 @@
+package keys
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet"
+	"github.com/c4ei/c4exd/domain/dagconfig"
+	"github.com/pkg/errors"
+)
+
+func TestChangePassword(t *testing.T) {
+	params := &dagconfig.SimnetParams
+	mnemonic, err := libc4exwallet.CreateMnemonic()
+	if err != nil {
+		t.Fatalf("CreateMnemonic: %s", err)
+	}
+
+	file, err := NewFileFromMnemonic(params, mnemonic, "old")
+	if err != nil {
+		t.Fatalf("NewFileFromMnemonic: %s", err)
+	}
+	path := filepath.Join(t.TempDir(), "keys.json")
+	err = file.SetPath(params, path, false)
+	if err != nil {
+		t.Fatalf("SetPath: %s", err)
+	}
+
+	kdfParams := KDFParams{Time: 2, Memory: 1024, Threads: 2}
+	err = file.ChangePassword("wrong", "new", kdfParams)
+	if err == nil {
+		t.Fatalf("ChangePassword unexpectedly succeeded with a wrong password")
+	}
+	err = file.ChangePassword("old", "new", KDFParams{Time: 1, Memory: 8, Threads: 2})
+	if err == nil {
+		t.Fatalf("ChangePassword unexpectedly succeeded with too little KDF memory")
+	}
+	err = file.ChangePassword("old", "new", kdfParams)
+	if err != nil {
+		t.Fatalf("ChangePassword: %s", err)
+	}
+
+	// The KDF parameters are read back from the file
+	readFile, err := ReadKeysFile(params, path)
+	if err != nil {
+		t.Fatalf("ReadKeysFile: %s", err)
+	}
+	if readFile.EncryptedMnemonics[0].kdfParams != kdfParams {
+		t.Fatalf("expected KDF parameters %+v, got %+v", kdfParams, readFile.EncryptedMnemonics[0].kdfParams)
+	}
+	_, err = readFile.DecryptMnemonics("old")
+	if err == nil {
+		t.Fatalf("the old password still decrypts the mnemonic")
+	}
+	mnemonics, err := readFile.DecryptMnemonics("new")
+	if err != nil {
+		t.Fatalf("DecryptMnemonics: %s", err)
+	}
+	if mnemonics[0] != mnemonic {
+		t.Fatalf("the mnemonic changed along with the password")
+	}
+}
+
+func TestMigrateKDFParams(t *testing.T) {
+	params := &dagconfig.SimnetParams
+	mnemonic, err := libc4exwallet.CreateMnemonic()
+	if err != nil {
+		t.Fatalf("CreateMnemonic: %s", err)
+	}
+
+	tests := []struct {
+		name       string
+		version    uint32
+		numThreads uint8
+	}{
+		{name: "version 2", version: 2, numThreads: defaultNumThreads},
+		// Version 0 files were encrypted with the number of CPUs of the machine they were created on
+		{name: "version 0", version: 0, numThreads: 3},
+	}
+	for _, test := range tests {
+		kdfParams := DefaultKDFParams
+		kdfParams.Threads = test.numThreads
+		encryptedMnemonic, err := encryptMnemonic(mnemonic, []byte("password"), kdfParams)
+		if err != nil {
+			t.Fatalf("%s: encryptMnemonic: %s", test.name, err)
+		}
+		file := &File{
+			Version:            LastVersion,
+			EncryptedMnemonics: []*EncryptedMnemonic{encryptedMnemonic},
+			ExtendedPublicKeys: []string{"xpub"},
+			MinimumSignatures:  1,
+		}
+		fileJSON := file.toJSON()
+		fileJSON.Version = test.version
+		fileJSON.EncryptedPrivateKeys[0].KDFParams = nil
+
+		path := filepath.Join(t.TempDir(), "keys.json")
+		serializedFile, err := json.Marshal(fileJSON)
+		if err != nil {
+			t.Fatalf("%s: Marshal: %s", test.name, err)
+		}
+		err = os.WriteFile(path, serializedFile, 0600)
+		if err != nil {
+			t.Fatalf("%s: WriteFile: %s", test.name, err)
+		}
+
+		readFile, err := ReadKeysFile(params, path)
+		if err != nil {
+			t.Fatalf("%s: ReadKeysFile: %s", test.name, err)
+		}
+		mnemonics, err := readFile.DecryptMnemonics("password")
+		if err != nil {
+			t.Fatalf("%s: DecryptMnemonics: %s", test.name, err)
+		}
+		if mnemonics[0] != mnemonic {
+			t.Fatalf("%s: unexpected mnemonic", test.name)
+		}
+		if readFile.Version != LastVersion || readFile.EncryptedMnemonics[0].kdfParams != kdfParams {
+			t.Fatalf("%s: the file was not migrated: version %d, KDF parameters %+v", test.name,
+				readFile.Version, readFile.EncryptedMnemonics[0].kdfParams)
+		}
+	}
+}
+
+func TestWatchOnlyFile(t *testing.T) {
+	params := &dagconfig.SimnetParams
+	mnemonic, err := libc4exwallet.CreateMnemonic()
+	if err != nil {
+		t.Fatalf("CreateMnemonic: %s", err)
+	}
+	extendedPublicKey, err := libc4exwallet.MasterPublicKeyFromMnemonic(params, mnemonic, false)
+	if err != nil {
+		t.Fatalf("MasterPublicKeyFromMnemonic: %s", err)
+	}
+
+	// The extended public key of a watch-only wallet must be of the wallet's network
+	err = libc4exwallet.ValidateExtendedPublicKey(params, extendedPublicKey)
+	if err != nil {
+		t.Fatalf("ValidateExtendedPublicKey: %s", err)
+	}
+	err = libc4exwallet.ValidateExtendedPublicKey(&dagconfig.MainnetParams, extendedPublicKey)
+	if err == nil {
+		t.Fatalf("ValidateExtendedPublicKey unexpectedly accepted a simnet key on mainnet")
+	}
+
+	file := &File{
+		Version:            LastVersion,
+		ExtendedPublicKeys: []string{extendedPublicKey},
+		MinimumSignatures:  1,
+	}
+	path := filepath.Join(t.TempDir(), "keys.json")
+	err = file.SetPath(params, path, false)
+	if err != nil {
+		t.Fatalf("SetPath: %s", err)
+	}
+	err = file.Save()
+	if err != nil {
+		t.Fatalf("Save: %s", err)
+	}
+
+	readFile, err := ReadKeysFile(params, path)
+	if err != nil {
+		t.Fatalf("ReadKeysFile: %s", err)
+	}
+	if !readFile.IsWatchOnly() {
+		t.Fatalf("a keys file without mnemonics is not watch-only")
+	}
+	if readFile.NumPrivateKeys() != 0 {
+		t.Fatalf("expected a watch-only keys file to have no private keys, got %d", readFile.NumPrivateKeys())
+	}
+	_, err = readFile.DecryptMnemonics("")
+	if !errors.Is(err, ErrWatchOnly) {
+		t.Fatalf("expected DecryptMnemonics to return ErrWatchOnly, got %v", err)
+	}
+
+	// The addresses of the watch-only wallet are the addresses of the wallet that holds the key
+	fileFromMnemonic, err := NewFileFromMnemonic(params, mnemonic, "password")
+	if err != nil {
+		t.Fatalf("NewFileFromMnemonic: %s", err)
+	}
+	if fileFromMnemonic.IsWatchOnly() {
+		t.Fatalf("a keys file with a mnemonic is watch-only")
+	}
+	if fileFromMnemonic.ExtendedPublicKeys[0] != extendedPublicKey {
+		t.Fatalf("expected extended public key %s, got %s", extendedPublicKey, fileFromMnemonic.ExtendedPublicKeys[0])
+	}
+}
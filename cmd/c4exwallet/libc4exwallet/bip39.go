@@ -26,18 +26,35 @@ const (
 	CoinType = 111111
 )
 
+// MaxAccountIndex is the highest index of an account, since accounts are derived with hardened derivation
+const MaxAccountIndex = 0x7fffffff
+
 func defaultPath(isMultisig bool) string {
+	return accountPath(isMultisig, 0)
+}
+
+func accountPath(isMultisig bool, account uint32) string {
 	purpose := SingleSignerPurpose
 	if isMultisig {
 		purpose = MultiSigPurpose
 	}
 
-	return fmt.Sprintf("m/%d'/%d'/0'", purpose, CoinType)
+	return fmt.Sprintf("m/%d'/%d'/%d'", purpose, CoinType, account)
 }
 
 // MasterPublicKeyFromMnemonic returns the master public key with the correct derivation for the given mnemonic.
 func MasterPublicKeyFromMnemonic(params *dagconfig.Params, mnemonic string, isMultisig bool) (string, error) {
-	path := defaultPath(isMultisig)
+	return AccountPublicKeyFromMnemonic(params, mnemonic, isMultisig, 0)
+}
+
+// AccountPublicKeyFromMnemonic returns the extended public key of the account with the given index for the given
+// mnemonic. Account 0 is the account of the master public key.
+func AccountPublicKeyFromMnemonic(params *dagconfig.Params, mnemonic string, isMultisig bool, account uint32) (string, error) {
+	if account > MaxAccountIndex {
+		return "", errors.Errorf("account index %d is greater than the maximum of %d", account, MaxAccountIndex)
+	}
+
+	path := accountPath(isMultisig, account)
 	extendedKey, err := extendedKeyFromMnemonicAndPath(mnemonic, path, params)
 	if err != nil {
 		return "", err
@@ -80,3 +97,55 @@ func versionFromParams(params *dagconfig.Params) ([4]byte, error) {
 
 	return [4]byte{}, errors.Errorf("unknown network %s", params.Name)
 }
+
+func publicVersionFromParams(params *dagconfig.Params) ([4]byte, error) {
+	switch params.Name {
+	case dagconfig.MainnetParams.Name:
+		return bip32.C4exMainnetPublic, nil
+	case dagconfig.TestnetParams.Name:
+		return bip32.C4exTestnetPublic, nil
+	case dagconfig.DevnetParams.Name:
+		return bip32.C4exDevnetPublic, nil
+	case dagconfig.SimnetParams.Name:
+		return bip32.C4exSimnetPublic, nil
+	}
+
+	return [4]byte{}, errors.Errorf("unknown network %s", params.Name)
+}
+
+// KeyPairFromMnemonic returns the private and public keys at the given derivation path of the given mnemonic,
+// serialized the way CreateKeyPair serializes them
+func KeyPairFromMnemonic(params *dagconfig.Params, mnemonic string, path string, ecdsa bool) ([]byte, []byte, error) {
+	if !bip39.IsMnemonicValid(mnemonic) {
+		return nil, nil, errors.Errorf("invalid mnemonic")
+	}
+
+	extendedKey, err := extendedKeyFromMnemonicAndPath(mnemonic, path, params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	privateKey := extendedKey.PrivateKey()
+	publicKey, err := privateKey.ECDSAPublicKey()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Failed to generate public key")
+	}
+
+	if ecdsa {
+		publicKeySerialized, err := publicKey.Serialize()
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "Failed to serialize public key")
+		}
+		return privateKey.Serialize()[:], publicKeySerialized[:], nil
+	}
+
+	schnorrPublicKey, err := publicKey.ToSchnorr()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Failed to generate public key")
+	}
+	publicKeySerialized, err := schnorrPublicKey.Serialize()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Failed to serialize public key")
+	}
+	return privateKey.Serialize()[:], publicKeySerialized[:], nil
+}
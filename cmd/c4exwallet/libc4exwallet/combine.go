@@ -0,0 +1,229 @@
+package libc4exwallet
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet/bip32"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet/serialization"
+	"github.com/c4ei/c4exd/domain/consensus/model/externalapi"
+	"github.com/c4ei/c4exd/domain/consensus/utils/consensushashing"
+	"github.com/c4ei/c4exd/domain/consensus/utils/txscript"
+	"github.com/c4ei/c4exd/domain/consensus/utils/utxo"
+	"github.com/kaspanet/go-secp256k1"
+	"github.com/pkg/errors"
+)
+
+// Combine merges the signatures of copies of the same partially signed transaction that were
+// signed independently by different cosigners into a single partially signed transaction.
+// Every signature is verified against its public key and the transaction, and invalid ones
+// are dropped and described in the returned invalidSignatures. If several copies have a valid
+// signature of the same public key, the signature of the first one is kept.
+func Combine(serializedPSTxs [][]byte) (combinedPSTx []byte, invalidSignatures []string, err error) {
+	if len(serializedPSTxs) == 0 {
+		return nil, nil, errors.New("no transactions to combine")
+	}
+
+	partiallySignedTransactions := make([]*serialization.PartiallySignedTransaction, len(serializedPSTxs))
+	for i, serializedPSTx := range serializedPSTxs {
+		partiallySignedTransactions[i], err = serialization.DeserializePartiallySignedTransaction(serializedPSTx)
+		if err != nil {
+			return nil, nil, err
+		}
+		if i == 0 {
+			continue
+		}
+		err = validateSameTransaction(partiallySignedTransactions[0], partiallySignedTransactions[i])
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "transaction #%d doesn't match transaction #1", i+1)
+		}
+	}
+
+	// The signatures commit to the spent outputs and to the signature operation counts that signing sets
+	combined := partiallySignedTransactions[0]
+	for i, input := range combined.PartiallySignedInputs {
+		combined.Tx.Inputs[i].UTXOEntry = utxo.NewUTXOEntry(input.PrevOutput.Value, input.PrevOutput.ScriptPublicKey,
+			false, 0)
+		combined.Tx.Inputs[i].SigOpCount = byte(len(input.PubKeySignaturePairs))
+	}
+
+	sighashReusedValues := &consensushashing.SighashReusedValues{}
+	for i, input := range combined.PartiallySignedInputs {
+		ecdsa, err := isECDSAInput(input)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		signatures := make([][]byte, len(input.PubKeySignaturePairs))
+		for j, partiallySignedTransaction := range partiallySignedTransactions {
+			for k, pair := range partiallySignedTransaction.PartiallySignedInputs[i].PubKeySignaturePairs {
+				if pair.Signature == nil || signatures[k] != nil {
+					continue
+				}
+				err := verifyInputSignature(combined.Tx, i, pair, ecdsa, sighashReusedValues)
+				if err != nil {
+					invalidSignatures = append(invalidSignatures, fmt.Sprintf(
+						"the signature of input %d by %s in copy #%d: %s", i, pair.ExtendedPublicKey, j+1, err))
+					continue
+				}
+				signatures[k] = pair.Signature
+			}
+		}
+		for k, pair := range input.PubKeySignaturePairs {
+			pair.Signature = signatures[k]
+		}
+	}
+
+	combinedPSTx, err = serialization.SerializePartiallySignedTransaction(combined)
+	if err != nil {
+		return nil, nil, err
+	}
+	return combinedPSTx, invalidSignatures, nil
+}
+
+// isECDSAInput returns whether the output the input spends is locked to ECDSA keys rather than to Schnorr keys
+func isECDSAInput(input *serialization.PartiallySignedInput) (bool, error) {
+	scriptPublicKey := input.PrevOutput.ScriptPublicKey.Script
+	if len(input.PubKeySignaturePairs) == 1 {
+		return txscript.GetScriptClass(scriptPublicKey) == txscript.PubKeyECDSATy, nil
+	}
+
+	redeemScript, err := partiallySignedInputMultisigRedeemScript(input, true)
+	if err != nil {
+		return false, err
+	}
+	ecdsaScriptPublicKey, err := txscript.PayToScriptHashScript(redeemScript)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(scriptPublicKey, ecdsaScriptPublicKey), nil
+}
+
+// verifyInputSignature checks that the signature of the pair is a valid signature of the
+// given input of the transaction by the pair's public key, the way script validation does
+func verifyInputSignature(tx *externalapi.DomainTransaction, inputIndex int, pair *serialization.PubKeySignaturePair,
+	ecdsa bool, sighashReusedValues *consensushashing.SighashReusedValues) error {
+
+	if len(pair.Signature) == 0 {
+		return errors.New("the signature is empty")
+	}
+	hashType := consensushashing.SigHashType(pair.Signature[len(pair.Signature)-1])
+	if !hashType.IsStandardSigHashType() {
+		return errors.Errorf("invalid hash type 0x%x", hashType)
+	}
+	signatureBytes := pair.Signature[:len(pair.Signature)-1]
+
+	extendedKey, err := bip32.DeserializeExtendedKey(pair.ExtendedPublicKey)
+	if err != nil {
+		return err
+	}
+	publicKey, err := extendedKey.PublicKey()
+	if err != nil {
+		return err
+	}
+
+	if ecdsa {
+		sigHash, err := consensushashing.CalculateSignatureHashECDSA(tx, inputIndex, hashType, sighashReusedValues)
+		if err != nil {
+			return err
+		}
+		signature, err := secp256k1.DeserializeECDSASignatureFromSlice(signatureBytes)
+		if err != nil {
+			return errors.Wrap(err, "malformed ECDSA signature")
+		}
+		secpHash := secp256k1.Hash(*sigHash.ByteArray())
+		if !publicKey.ECDSAVerify(&secpHash, signature) {
+			return errors.New("the ECDSA signature doesn't match the public key and the transaction")
+		}
+		return nil
+	}
+
+	schnorrPublicKey, err := publicKey.ToSchnorr()
+	if err != nil {
+		return err
+	}
+	sigHash, err := consensushashing.CalculateSignatureHashSchnorr(tx, inputIndex, hashType, sighashReusedValues)
+	if err != nil {
+		return err
+	}
+	signature, err := secp256k1.DeserializeSchnorrSignatureFromSlice(signatureBytes)
+	if err != nil {
+		return errors.Wrap(err, "malformed Schnorr signature")
+	}
+	secpHash := secp256k1.Hash(*sigHash.ByteArray())
+	if !schnorrPublicKey.SchnorrVerify(&secpHash, signature) {
+		return errors.New("the Schnorr signature doesn't match the public key and the transaction")
+	}
+	return nil
+}
+
+func validateSameTransaction(expected, actual *serialization.PartiallySignedTransaction) error {
+	expectedID := consensushashing.TransactionID(expected.Tx)
+	actualID := consensushashing.TransactionID(actual.Tx)
+	if !expectedID.Equal(actualID) {
+		return errors.Errorf("the transaction IDs are %s and %s", expectedID, actualID)
+	}
+	if len(expected.PartiallySignedInputs) != len(actual.PartiallySignedInputs) {
+		return errors.Errorf("the transactions have %d and %d partially signed inputs",
+			len(expected.PartiallySignedInputs), len(actual.PartiallySignedInputs))
+	}
+
+	for i, expectedInput := range expected.PartiallySignedInputs {
+		actualInput := actual.PartiallySignedInputs[i]
+		if expectedInput.MinimumSignatures != actualInput.MinimumSignatures ||
+			expectedInput.DerivationPath != actualInput.DerivationPath ||
+			!expectedInput.PrevOutput.Equal(actualInput.PrevOutput) ||
+			len(expectedInput.PubKeySignaturePairs) != len(actualInput.PubKeySignaturePairs) {
+
+			return errors.Errorf("input %d spends a different output or requires different signatures", i)
+		}
+		for j, expectedPair := range expectedInput.PubKeySignaturePairs {
+			if expectedPair.ExtendedPublicKey != actualInput.PubKeySignaturePairs[j].ExtendedPublicKey {
+				return errors.Errorf("input %d requires signatures of different public keys", i)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Finalize extracts the transaction out of a fully signed partially signed transaction, and returns it
+// serialized as a domain transaction. If any input doesn't have enough signatures, the returned error
+// names all such inputs.
+func Finalize(serializedPSTx []byte, ecdsa bool) ([]byte, error) {
+	partiallySignedTransaction, err := serialization.DeserializePartiallySignedTransaction(serializedPSTx)
+	if err != nil {
+		return nil, err
+	}
+
+	var missingSignatures []string
+	for i, input := range partiallySignedTransaction.PartiallySignedInputs {
+		signatureCount := InputSignatureCount(input)
+		if signatureCount < input.MinimumSignatures {
+			missingSignatures = append(missingSignatures, fmt.Sprintf("input %d has %d of the %d required signatures",
+				i, signatureCount, input.MinimumSignatures))
+		}
+	}
+	if len(missingSignatures) > 0 {
+		return nil, errors.Errorf("transaction %s is not fully signed: %s",
+			consensushashing.TransactionID(partiallySignedTransaction.Tx), strings.Join(missingSignatures, ", "))
+	}
+
+	transaction, err := ExtractTransactionDeserialized(partiallySignedTransaction, ecdsa)
+	if err != nil {
+		return nil, err
+	}
+	return serialization.SerializeDomainTransaction(transaction)
+}
+
+// InputSignatureCount returns the number of signatures a partially signed input has
+func InputSignatureCount(input *serialization.PartiallySignedInput) uint32 {
+	signatureCount := uint32(0)
+	for _, pair := range input.PubKeySignaturePairs {
+		if pair.Signature != nil {
+			signatureCount++
+		}
+	}
+	return signatureCount
+}
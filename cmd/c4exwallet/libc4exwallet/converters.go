@@ -59,3 +59,15 @@ func AppMessageUTXOToC4exwalletdUTXO(appUTXOsByAddressesEntry *appmessage.UTXOsB
 		},
 	}
 }
+
+// C4exwalletdOutpointToDomainOutpoint converts a pb.Outpoint to an externalapi.DomainOutpoint
+func C4exwalletdOutpointToDomainOutpoint(outpoint *pb.Outpoint) (*externalapi.DomainOutpoint, error) {
+	transactionID, err := transactionid.FromString(outpoint.TransactionId)
+	if err != nil {
+		return nil, err
+	}
+	return &externalapi.DomainOutpoint{
+		TransactionID: *transactionID,
+		Index:         outpoint.Index,
+	}, nil
+}
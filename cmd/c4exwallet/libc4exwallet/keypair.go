@@ -167,3 +167,24 @@ func MinimumCosignerIndex(cosignerExtendedPublicKeys, allExtendedPublicKeys []st
 
 	return min, nil
 }
+
+// ValidateExtendedPublicKey makes sure that the given key is a valid extended key, that
+// it's public, so that no secret ends up unencrypted in the keys file, and that it's a
+// key of the given network, so that the wallet doesn't watch addresses of another network.
+func ValidateExtendedPublicKey(params *dagconfig.Params, extendedPublicKey string) error {
+	extendedKey, err := bip32.DeserializeExtendedKey(extendedPublicKey)
+	if err != nil {
+		return errors.Wrapf(err, "%s is invalid extended public key", extendedPublicKey)
+	}
+	if extendedKey.IsPrivate() {
+		return errors.New("an extended private key was given where an extended public key is expected")
+	}
+	version, err := publicVersionFromParams(params)
+	if err != nil {
+		return err
+	}
+	if extendedKey.Version != version {
+		return errors.Errorf("extended public key %s is not a key of network %s", extendedPublicKey, params.Name)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package libc4exwallet
+
+import (
+	"github.com/c4ei/c4exd/domain/dagconfig"
+	"github.com/c4ei/c4exd/util"
+	"github.com/kaspanet/go-secp256k1"
+	"github.com/pkg/errors"
+)
+
+// SignMessage signs the message with the key at the given derivation path of the given account of the mnemonic
+func SignMessage(params *dagconfig.Params, mnemonic string, account uint32, path string, message string,
+	ecdsa bool) ([]byte, error) {
+
+	if account > MaxAccountIndex {
+		return nil, errors.Errorf("account index %d is greater than the maximum of %d", account, MaxAccountIndex)
+	}
+
+	extendedKey, err := extendedKeyFromMnemonicAndPath(mnemonic, accountPath(false, account), params)
+	if err != nil {
+		return nil, err
+	}
+	derivedKey, err := extendedKey.DeriveFromPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return SignMessageWithPrivateKey(derivedKey.PrivateKey().Serialize()[:], message, ecdsa)
+}
+
+// SignMessageWithPrivateKey signs the message with the given private key
+func SignMessageWithPrivateKey(privateKey []byte, message string, ecdsa bool) ([]byte, error) {
+	secpHash := secp256k1.Hash(*util.MessageHash(message).ByteArray())
+	if ecdsa {
+		key, err := secp256k1.DeserializeECDSAPrivateKeyFromSlice(privateKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to deserialize private key")
+		}
+		signature, err := key.ECDSASign(&secpHash)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to sign message")
+		}
+		serializedSignature := signature.Serialize()
+		return serializedSignature[:], nil
+	}
+
+	keyPair, err := secp256k1.DeserializeSchnorrPrivateKeyFromSlice(privateKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to deserialize private key")
+	}
+	signature, err := keyPair.SchnorrSign(&secpHash)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to sign message")
+	}
+	serializedSignature := signature.Serialize()
+	return serializedSignature[:], nil
+}
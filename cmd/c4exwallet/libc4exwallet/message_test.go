@@ -0,0 +1,77 @@
+package libc4exwallet_test
+
+import (
+	"testing"
+
+	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet"
+	"github.com/c4ei/c4exd/domain/dagconfig"
+	"github.com/c4ei/c4exd/util"
+)
+
+func TestSignMessage(t *testing.T) {
+	params := &dagconfig.SimnetParams
+	forSchnorrAndECDSA(t, func(t *testing.T, ecdsa bool) {
+		mnemonic, err := libc4exwallet.CreateMnemonic()
+		if err != nil {
+			t.Fatalf("CreateMnemonic: %+v", err)
+		}
+		const account = 1
+		accountPublicKey, err := libc4exwallet.AccountPublicKeyFromMnemonic(params, mnemonic, false, account)
+		if err != nil {
+			t.Fatalf("AccountPublicKeyFromMnemonic: %+v", err)
+		}
+		path := "m/0/7"
+		address, err := libc4exwallet.Address(params, []string{accountPublicKey}, 1, path, ecdsa)
+		if err != nil {
+			t.Fatalf("Address: %+v", err)
+		}
+
+		const message = "I control this address"
+		signature, err := libc4exwallet.SignMessage(params, mnemonic, account, path, message, ecdsa)
+		if err != nil {
+			t.Fatalf("SignMessage: %+v", err)
+		}
+
+		isValid, err := util.VerifyMessage(address, message, signature)
+		if err != nil {
+			t.Fatalf("VerifyMessage: %+v", err)
+		}
+		if !isValid {
+			t.Fatalf("Expected the signature to be valid")
+		}
+
+		isValid, err = util.VerifyMessage(address, message+".", signature)
+		if err != nil {
+			t.Fatalf("VerifyMessage: %+v", err)
+		}
+		if isValid {
+			t.Fatalf("Expected the signature of another message to be invalid")
+		}
+
+		otherAddress, err := libc4exwallet.Address(params, []string{accountPublicKey}, 1, "m/0/8", ecdsa)
+		if err != nil {
+			t.Fatalf("Address: %+v", err)
+		}
+		isValid, err = util.VerifyMessage(otherAddress, message, signature)
+		if err != nil {
+			t.Fatalf("VerifyMessage: %+v", err)
+		}
+		if isValid {
+			t.Fatalf("Expected the signature to be invalid for another address")
+		}
+
+		_, err = util.VerifyMessage(address, message, signature[1:])
+		if err == nil {
+			t.Fatalf("Expected an error for a malformed signature")
+		}
+
+		scriptHashAddress, err := util.NewAddressScriptHash([]byte{1, 2, 3}, params.Prefix)
+		if err != nil {
+			t.Fatalf("NewAddressScriptHash: %+v", err)
+		}
+		_, err = util.VerifyMessage(scriptHashAddress, message, signature)
+		if err == nil {
+			t.Fatalf("Expected an error for a pay-to-script-hash address")
+		}
+	})
+}
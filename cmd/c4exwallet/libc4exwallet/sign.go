@@ -29,13 +29,21 @@ func rawTxInSignature(extendedKey *bip32.ExtendedKey, tx *externalapi.DomainTran
 
 // Sign signs the transaction with the given private keys
 func Sign(params *dagconfig.Params, mnemonics []string, serializedPSTx []byte, ecdsa bool) ([]byte, error) {
+	return SignWithAccounts(params, mnemonics, []uint32{0}, serializedPSTx, ecdsa)
+}
+
+// SignWithAccounts signs the transaction with the given private keys, where the inputs may
+// spend the outputs of any of the given accounts
+func SignWithAccounts(params *dagconfig.Params, mnemonics []string, accounts []uint32, serializedPSTx []byte,
+	ecdsa bool) ([]byte, error) {
+
 	partiallySignedTransaction, err := serialization.DeserializePartiallySignedTransaction(serializedPSTx)
 	if err != nil {
 		return nil, err
 	}
 
 	for _, mnemonic := range mnemonics {
-		err = sign(params, mnemonic, partiallySignedTransaction, ecdsa)
+		err = sign(params, mnemonic, accounts, partiallySignedTransaction, ecdsa)
 		if err != nil {
 			return nil, err
 		}
@@ -43,7 +51,9 @@ func Sign(params *dagconfig.Params, mnemonics []string, serializedPSTx []byte, e
 	return serialization.SerializePartiallySignedTransaction(partiallySignedTransaction)
 }
 
-func sign(params *dagconfig.Params, mnemonic string, partiallySignedTransaction *serialization.PartiallySignedTransaction, ecdsa bool) error {
+func sign(params *dagconfig.Params, mnemonic string, accounts []uint32,
+	partiallySignedTransaction *serialization.PartiallySignedTransaction, ecdsa bool) error {
+
 	if isTransactionFullySigned(partiallySignedTransaction) {
 		return nil
 	}
@@ -60,33 +70,47 @@ func sign(params *dagconfig.Params, mnemonic string, partiallySignedTransaction
 		partiallySignedTransaction.Tx.Inputs[i].SigOpCount = byte(len(partiallySignedInput.PubKeySignaturePairs))
 	}
 
+	// The account keys are derived once per account, since deriving them from the mnemonic is expensive
+	accountKeys := make(map[string]*bip32.ExtendedKey)
 	signed := false
 	for i, partiallySignedInput := range partiallySignedTransaction.PartiallySignedInputs {
 		isMultisig := len(partiallySignedInput.PubKeySignaturePairs) > 1
-		path := defaultPath(isMultisig)
-		extendedKey, err := extendedKeyFromMnemonicAndPath(mnemonic, path, params)
-		if err != nil {
-			return err
-		}
-
-		derivedKey, err := extendedKey.DeriveFromPath(partiallySignedInput.DerivationPath)
-		if err != nil {
-			return err
-		}
-
-		derivedPublicKey, err := derivedKey.Public()
-		if err != nil {
-			return err
-		}
-
-		for _, pair := range partiallySignedInput.PubKeySignaturePairs {
-			if pair.ExtendedPublicKey == derivedPublicKey.String() {
-				pair.Signature, err = rawTxInSignature(derivedKey, partiallySignedTransaction.Tx, i, consensushashing.SigHashAll, sighashReusedValues, ecdsa)
+		for _, account := range accounts {
+			path := accountPath(isMultisig, account)
+			extendedKey, ok := accountKeys[path]
+			if !ok {
+				var err error
+				extendedKey, err = extendedKeyFromMnemonicAndPath(mnemonic, path, params)
 				if err != nil {
 					return err
 				}
+				accountKeys[path] = extendedKey
+			}
+
+			derivedKey, err := extendedKey.DeriveFromPath(partiallySignedInput.DerivationPath)
+			if err != nil {
+				return err
+			}
 
-				signed = true
+			derivedPublicKey, err := derivedKey.Public()
+			if err != nil {
+				return err
+			}
+
+			isInputOfAccount := false
+			for _, pair := range partiallySignedInput.PubKeySignaturePairs {
+				if pair.ExtendedPublicKey == derivedPublicKey.String() {
+					pair.Signature, err = rawTxInSignature(derivedKey, partiallySignedTransaction.Tx, i, consensushashing.SigHashAll, sighashReusedValues, ecdsa)
+					if err != nil {
+						return err
+					}
+
+					signed = true
+					isInputOfAccount = true
+				}
+			}
+			if isInputOfAccount {
+				break
 			}
 		}
 	}
@@ -174,13 +174,7 @@ func IsTransactionFullySigned(partiallySignedTransactionBytes []byte) (bool, err
 
 func isTransactionFullySigned(partiallySignedTransaction *serialization.PartiallySignedTransaction) bool {
 	for _, input := range partiallySignedTransaction.PartiallySignedInputs {
-		numSignatures := 0
-		for _, pair := range input.PubKeySignaturePairs {
-			if pair.Signature != nil {
-				numSignatures++
-			}
-		}
-		if uint32(numSignatures) < input.MinimumSignatures {
+		if InputSignatureCount(input) < input.MinimumSignatures {
 			return false
 		}
 	}
@@ -215,7 +209,8 @@ func ExtractTransactionDeserialized(partiallySignedTransaction *serialization.Pa
 				}
 			}
 			if uint32(signatureCount) < input.MinimumSignatures {
-				return nil, errors.Errorf("missing %d signatures", input.MinimumSignatures-uint32(signatureCount))
+				return nil, errors.Errorf("input %d: missing %d signatures", i,
+					input.MinimumSignatures-uint32(signatureCount))
 			}
 
 			redeemScript, err := partiallySignedInputMultisigRedeemScript(input, ecdsa)
@@ -236,7 +231,7 @@ func ExtractTransactionDeserialized(partiallySignedTransaction *serialization.Pa
 			}
 
 			if input.PubKeySignaturePairs[0].Signature == nil {
-				return nil, errors.Errorf("missing signature")
+				return nil, errors.Errorf("input %d: missing signature", i)
 			}
 
 			sigScript, err := txscript.NewScriptBuilder().
@@ -8,6 +8,7 @@ import (
 	"github.com/c4ei/c4exd/domain/consensus/utils/constants"
 
 	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet/serialization"
 	"github.com/c4ei/c4exd/domain/consensus"
 	"github.com/c4ei/c4exd/domain/consensus/model/externalapi"
 	"github.com/c4ei/c4exd/domain/consensus/utils/consensushashing"
@@ -166,6 +167,94 @@ func TestMultisig(t *testing.T) {
 				t.Fatalf("Expected extractedSignedTxOneStep and extractedSignedTxStep2 IDs to be equal")
 			}
 
+			_, err = libc4exwallet.Finalize(signedTxStep1, ecdsa)
+			if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("input 0 has 1 of the %d required signatures", minimumSignatures)) {
+				t.Fatalf("Unexpectedly succeeded to finalize a transaction that is missing signatures: %v", err)
+			}
+
+			// The second cosigner signs a copy of the unsigned transaction, independently of the first one
+			independentlySignedTx, err := libc4exwallet.Sign(params, mnemonics[1:2], unsignedTransaction, ecdsa)
+			if err != nil {
+				t.Fatalf("Sign: %+v", err)
+			}
+
+			combinedTx, invalidSignatures, err := libc4exwallet.Combine(
+				[][]byte{unsignedTransaction, signedTxStep1, independentlySignedTx})
+			if err != nil {
+				t.Fatalf("Combine: %+v", err)
+			}
+			if len(invalidSignatures) != 0 {
+				t.Fatalf("Unexpected invalid signatures: %v", invalidSignatures)
+			}
+
+			finalizedCombinedTx, err := libc4exwallet.Finalize(combinedTx, ecdsa)
+			if err != nil {
+				t.Fatalf("Finalize: %+v", err)
+			}
+			extractedCombinedTx, err := serialization.DeserializeDomainTransaction(finalizedCombinedTx)
+			if err != nil {
+				t.Fatalf("DeserializeDomainTransaction: %+v", err)
+			}
+			err = tc.ValidateTransactionAndPopulateWithConsensusData(extractedCombinedTx)
+			if err != nil {
+				t.Fatalf("The combined transaction is invalid: %+v", err)
+			}
+
+			// A corrupt signature, and a signature of the wrong signature scheme, are dropped in favor of the
+			// valid signatures of the same keys in later copies
+			corruptSignedTx := corruptSignatures(t, signedTxStep1)
+			wrongSchemeSignedTx, err := libc4exwallet.Sign(params, mnemonics[1:2], unsignedTransaction, !ecdsa)
+			if err != nil {
+				t.Fatalf("Sign: %+v", err)
+			}
+			combinedTx, invalidSignatures, err = libc4exwallet.Combine(
+				[][]byte{corruptSignedTx, wrongSchemeSignedTx, signedTxStep1, independentlySignedTx})
+			if err != nil {
+				t.Fatalf("Combine: %+v", err)
+			}
+			if len(invalidSignatures) != 2 || !strings.Contains(invalidSignatures[0], "copy #1") ||
+				!strings.Contains(invalidSignatures[1], "copy #2") {
+				t.Fatalf("Expected the signatures of copies #1 and #2 to be invalid, got: %v", invalidSignatures)
+			}
+			finalizedCombinedTx, err = libc4exwallet.Finalize(combinedTx, ecdsa)
+			if err != nil {
+				t.Fatalf("Finalize: %+v", err)
+			}
+			extractedCombinedTx, err = serialization.DeserializeDomainTransaction(finalizedCombinedTx)
+			if err != nil {
+				t.Fatalf("DeserializeDomainTransaction: %+v", err)
+			}
+			err = tc.ValidateTransactionAndPopulateWithConsensusData(extractedCombinedTx)
+			if err != nil {
+				t.Fatalf("The combined transaction is invalid: %+v", err)
+			}
+
+			// Without the valid copies, the invalid signatures leave the transaction unsigned
+			combinedTx, _, err = libc4exwallet.Combine([][]byte{corruptSignedTx, wrongSchemeSignedTx})
+			if err != nil {
+				t.Fatalf("Combine: %+v", err)
+			}
+			isFullySigned, err = libc4exwallet.IsTransactionFullySigned(combinedTx)
+			if err != nil {
+				t.Fatalf("IsTransactionFullySigned: %+v", err)
+			}
+			if isFullySigned {
+				t.Fatalf("A transaction combined of invalid signatures is not expected to be fully signed")
+			}
+
+			otherUnsignedTransaction, err := libc4exwallet.CreateUnsignedTransaction(publicKeys, minimumSignatures,
+				[]*libc4exwallet.Payment{{
+					Address: address,
+					Amount:  11,
+				}}, selectedUTXOs)
+			if err != nil {
+				t.Fatalf("CreateUnsignedTransactions: %+v", err)
+			}
+			_, _, err = libc4exwallet.Combine([][]byte{signedTxStep1, otherUnsignedTransaction})
+			if err == nil {
+				t.Fatalf("Unexpectedly succeeded to combine different transactions")
+			}
+
 			_, virtualChangeSet, err := tc.AddBlock([]*externalapi.DomainHash{block1Hash}, nil, []*externalapi.DomainTransaction{extractedSignedTxStep2})
 			if err != nil {
 				t.Fatalf("AddBlock: %+v", err)
@@ -182,6 +271,26 @@ func TestMultisig(t *testing.T) {
 	})
 }
 
+// corruptSignatures returns a copy of the partially signed transaction with a bit of each of its signatures flipped
+func corruptSignatures(t *testing.T, serializedPSTx []byte) []byte {
+	partiallySignedTransaction, err := serialization.DeserializePartiallySignedTransaction(serializedPSTx)
+	if err != nil {
+		t.Fatalf("DeserializePartiallySignedTransaction: %+v", err)
+	}
+	for _, input := range partiallySignedTransaction.PartiallySignedInputs {
+		for _, pair := range input.PubKeySignaturePairs {
+			if pair.Signature != nil {
+				pair.Signature[0] ^= 1
+			}
+		}
+	}
+	corruptPSTx, err := serialization.SerializePartiallySignedTransaction(partiallySignedTransaction)
+	if err != nil {
+		t.Fatalf("SerializePartiallySignedTransaction: %+v", err)
+	}
+	return corruptPSTx
+}
+
 func TestP2PK(t *testing.T) {
 	testutils.ForAllNets(t, true, func(t *testing.T, consensusConfig *consensus.Config) {
 		params := &consensusConfig.Params
@@ -17,6 +17,10 @@ func main() {
 		err = createUnsignedTransaction(config.(*createUnsignedTransactionConfig))
 	case signSubCmd:
 		err = sign(config.(*signConfig))
+	case signMessageSubCmd:
+		err = signMessage(config.(*signMessageConfig))
+	case verifyMessageSubCmd:
+		err = verifyMessage(config.(*verifyMessageConfig))
 	case broadcastSubCmd:
 		err = broadcast(config.(*broadcastConfig))
 	case parseSubCmd:
@@ -25,12 +29,40 @@ func main() {
 		err = showAddresses(config.(*showAddressesConfig))
 	case newAddressSubCmd:
 		err = newAddress(config.(*newAddressConfig))
+	case createPaymentRequestSubCmd:
+		err = createPaymentRequest(config.(*createPaymentRequestConfig))
+	case paymentRequestStatusSubCmd:
+		err = paymentRequestStatus(config.(*paymentRequestStatusConfig))
+	case changePasswordSubCmd:
+		err = changePassword(config.(*changePasswordConfig))
+	case exportXPubSubCmd:
+		err = exportXPub(config.(*exportXPubConfig))
 	case dumpUnencryptedDataSubCmd:
 		err = dumpUnencryptedData(config.(*dumpUnencryptedDataConfig))
 	case startDaemonSubCmd:
 		err = startDaemon(config.(*startDaemonConfig))
 	case sweepSubCmd:
 		err = sweep(config.(*sweepConfig))
+	case historySubCmd:
+		err = history(config.(*historyConfig))
+	case listUTXOsSubCmd:
+		err = listUTXOs(config.(*listUTXOsConfig))
+	case freezeSubCmd:
+		err = freeze(config.(*freezeConfig))
+	case unfreezeSubCmd:
+		err = unfreeze(config.(*unfreezeConfig))
+	case softwareSignerSubCmd:
+		err = softwareSigner(config.(*softwareSignerConfig))
+	case combineSubCmd:
+		err = combine(config.(*combineConfig))
+	case finalizeSubCmd:
+		err = finalize(config.(*finalizeConfig))
+	case consolidateSubCmd:
+		err = consolidate(config.(*consolidateConfig))
+	case createAccountSubCmd:
+		err = createAccount(config.(*createAccountConfig))
+	case listAccountsSubCmd:
+		err = listAccounts(config.(*listAccountsConfig))
 	default:
 		err = errors.Errorf("Unknown sub-command '%s'\n", subCmd)
 	}
@@ -9,7 +9,7 @@ import (
 )
 
 func newAddress(conf *newAddressConfig) error {
-	daemonClient, tearDown, err := client.Connect(conf.DaemonAddress)
+	daemonClient, tearDown, err := client.Connect(conf.DaemonAddress, conf.securityOptions())
 	if err != nil {
 		return err
 	}
@@ -18,7 +18,7 @@ func newAddress(conf *newAddressConfig) error {
 	ctx, cancel := context.WithTimeout(context.Background(), daemonTimeout)
 	defer cancel()
 
-	response, err := daemonClient.NewAddress(ctx, &pb.NewAddressRequest{})
+	response, err := daemonClient.NewAddress(ctx, &pb.NewAddressRequest{Account: conf.Account})
 	if err != nil {
 		return err
 	}
@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"strings"
 
+	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet"
 	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet/serialization"
 	"github.com/c4ei/c4exd/domain/consensus/utils/consensushashing"
 	"github.com/c4ei/c4exd/domain/consensus/utils/constants"
@@ -80,7 +81,37 @@ func parse(conf *parseConfig) error {
 		fmt.Println()
 
 		fmt.Printf("Fee:\t%d Sompi\n\n", allInputSompi-allOutputSompi)
+
+		if conf.Signatures {
+			printSignatureStatus(partiallySignedTransaction)
+		}
 	}
 
 	return nil
 }
+
+func printSignatureStatus(partiallySignedTransaction *serialization.PartiallySignedTransaction) {
+	missingSignatures := uint32(0)
+	for index, input := range partiallySignedTransaction.PartiallySignedInputs {
+		signatureCount := libc4exwallet.InputSignatureCount(input)
+		fmt.Printf("Input %d: \t%d of %d required signatures\n", index, signatureCount, input.MinimumSignatures)
+		for cosignerIndex, pair := range input.PubKeySignaturePairs {
+			status := "not signed"
+			if pair.Signature != nil {
+				status = "signed"
+			}
+			fmt.Printf("\tCosigner #%d %s: %s\n", cosignerIndex+1, pair.ExtendedPublicKey, status)
+		}
+
+		if signatureCount < input.MinimumSignatures {
+			missingSignatures += input.MinimumSignatures - signatureCount
+		}
+	}
+	fmt.Println()
+
+	if missingSignatures == 0 {
+		fmt.Printf("The transaction is fully signed and ready to be finalized\n\n")
+	} else {
+		fmt.Printf("The transaction is missing %d signatures\n\n", missingSignatures)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/c4ei/c4exd/cmd/c4exwallet/daemon/client"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/daemon/pb"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/utils"
+	"github.com/c4ei/c4exd/util"
+)
+
+// paymentRequestPollInterval is the interval at which 'payment-request-status --wait' checks the payment request
+const paymentRequestPollInterval = time.Second
+
+func createPaymentRequest(conf *createPaymentRequestConfig) error {
+	amount := uint64(0)
+	if conf.Amount != "" {
+		var err error
+		amount, err = utils.ParseC4x(conf.Amount)
+		if err != nil {
+			return err
+		}
+	}
+
+	daemonClient, tearDown, err := client.Connect(conf.DaemonAddress, conf.securityOptions())
+	if err != nil {
+		return err
+	}
+	defer tearDown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), daemonTimeout)
+	defer cancel()
+
+	response, err := daemonClient.CreatePaymentRequest(ctx, &pb.CreatePaymentRequestRequest{
+		Account:          conf.Account,
+		Amount:           amount,
+		Label:            conf.Label,
+		Message:          conf.Message,
+		ExpiresInSeconds: uint64(conf.ExpiresIn / time.Second),
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Address:\n%s\n\n", response.PaymentRequest.Address)
+	fmt.Printf("Payment URI:\n%s\n", response.PaymentRequest.Uri)
+	if response.PaymentRequest.Expiry != 0 {
+		fmt.Printf("\nExpires at %s\n", time.Unix(response.PaymentRequest.Expiry, 0).Format(time.RFC3339))
+	}
+	return nil
+}
+
+func paymentRequestStatus(conf *paymentRequestStatusConfig) error {
+	daemonClient, tearDown, err := client.Connect(conf.DaemonAddress, conf.securityOptions())
+	if err != nil {
+		return err
+	}
+	defer tearDown()
+
+	getPaymentRequest := func() (*pb.PaymentRequest, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), daemonTimeout)
+		defer cancel()
+
+		response, err := daemonClient.GetPaymentRequest(ctx, &pb.GetPaymentRequestRequest{Address: conf.Address})
+		if err != nil {
+			return nil, err
+		}
+		return response.PaymentRequest, nil
+	}
+
+	paymentRequest, err := getPaymentRequest()
+	if err != nil {
+		return err
+	}
+	if conf.Wait {
+		fmt.Printf("Waiting for a payment to %s...\n", paymentRequest.Address)
+		for !paymentRequest.IsPaid && !paymentRequest.IsExpired {
+			time.Sleep(paymentRequestPollInterval)
+			paymentRequest, err = getPaymentRequest()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	printPaymentRequestStatus(paymentRequest)
+	return nil
+}
+
+func printPaymentRequestStatus(paymentRequest *pb.PaymentRequest) {
+	fmt.Printf("Address:     %s\n", paymentRequest.Address)
+	fmt.Printf("Payment URI: %s\n", paymentRequest.Uri)
+	if paymentRequest.Label != "" {
+		fmt.Printf("Label:       %s\n", paymentRequest.Label)
+	}
+	if paymentRequest.Message != "" {
+		fmt.Printf("Message:     %s\n", paymentRequest.Message)
+	}
+	fmt.Printf("Created at:  %s\n", time.Unix(paymentRequest.CreatedAt, 0).Format(time.RFC3339))
+	if paymentRequest.Expiry != 0 {
+		fmt.Printf("Expires at:  %s\n", time.Unix(paymentRequest.Expiry, 0).Format(time.RFC3339))
+	}
+	if paymentRequest.Amount != 0 {
+		fmt.Printf("Amount:      %s\n", util.Amount(paymentRequest.Amount))
+	} else {
+		fmt.Printf("Amount:      chosen by the payer\n")
+	}
+	fmt.Printf("Received:    %s\n", util.Amount(paymentRequest.ReceivedAmount))
+	for _, transactionID := range paymentRequest.TransactionIds {
+		fmt.Printf("             in transaction %s\n", transactionID)
+	}
+
+	switch {
+	case paymentRequest.IsPaid:
+		fmt.Printf("Status:      paid\n")
+	case paymentRequest.IsExpired:
+		fmt.Printf("Status:      expired\n")
+	default:
+		fmt.Printf("Status:      waiting for payment\n")
+	}
+}
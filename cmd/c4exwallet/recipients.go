@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/c4ei/c4exd/cmd/c4exwallet/daemon/pb"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/utils"
+	"github.com/pkg/errors"
+)
+
+// readRecipientsFile reads the recipients of a batch payment from a file. The file is either
+// a JSON array of {"address": ..., "amount": ...} objects, or CSV with an address and an amount
+// in every line. Amounts are in C4X. Empty lines, lines starting with '#' and an
+// "address,amount" header line are ignored in CSV files.
+func readRecipientsFile(path string) ([]*pb.Recipient, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read the recipients file %s", path)
+	}
+
+	var recipients []*pb.Recipient
+	if bytes.HasPrefix(bytes.TrimSpace(content), []byte("[")) {
+		recipients, err = parseJSONRecipients(content)
+	} else {
+		recipients, err = parseCSVRecipients(content)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing the recipients file %s", path)
+	}
+	if len(recipients) == 0 {
+		return nil, errors.Errorf("the recipients file %s has no recipients", path)
+	}
+	return recipients, nil
+}
+
+func parseJSONRecipients(content []byte) ([]*pb.Recipient, error) {
+	var entries []struct {
+		Address string      `json:"address"`
+		Amount  json.Number `json:"amount"`
+	}
+	err := json.Unmarshal(content, &entries)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	recipients := make([]*pb.Recipient, len(entries))
+	for i, entry := range entries {
+		recipients[i], err = parseRecipient(entry.Address, entry.Amount.String())
+		if err != nil {
+			return nil, errors.Wrapf(err, "recipient #%d", i+1)
+		}
+	}
+	return recipients, nil
+}
+
+func parseCSVRecipients(content []byte) ([]*pb.Recipient, error) {
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+
+	var recipients []*pb.Recipient
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		if len(recipients) == 0 && strings.EqualFold(strings.TrimSpace(record[0]), "address") {
+			continue
+		}
+		recipient, err := parseRecipient(record[0], record[1])
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d", line)
+		}
+		recipients = append(recipients, recipient)
+	}
+	return recipients, nil
+}
+
+func parseRecipient(address string, amount string) (*pb.Recipient, error) {
+	address = strings.TrimSpace(address)
+	if address == "" {
+		return nil, errors.New("missing address")
+	}
+	amountSompi, err := utils.ParseC4x(amount)
+	if err != nil {
+		return nil, err
+	}
+	if amountSompi == 0 {
+		return nil, errors.Errorf("the amount to send to %s must be positive", address)
+	}
+	return &pb.Recipient{Address: address, Amount: amountSompi}, nil
+}
+
+// printRecipientsSummary prints the summary of transactions that pay the given recipients
+func printRecipientsSummary(output io.Writer, unsignedTransactions [][]byte, recipients []*pb.Recipient) error {
+	totalAmount := uint64(0)
+	for _, recipient := range recipients {
+		totalAmount += recipient.Amount
+	}
+
+	fmt.Fprintf(output, "Paying %d recipients a total of %s C4X in %d transaction(s):\n", len(recipients),
+		strings.TrimSpace(utils.FormatC4x(totalAmount)), len(unsignedTransactions))
+	return printTransactionsSummary(output, unsignedTransactions)
+}
@@ -9,8 +9,8 @@ import (
 	"github.com/c4ei/c4exd/cmd/c4exwallet/daemon/client"
 	"github.com/c4ei/c4exd/cmd/c4exwallet/daemon/pb"
 	"github.com/c4ei/c4exd/cmd/c4exwallet/keys"
-	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet"
-	"github.com/c4ei/c4exd/domain/consensus/utils/constants"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/signer"
+	"github.com/c4ei/c4exd/util"
 	"github.com/pkg/errors"
 )
 
@@ -20,11 +20,11 @@ func send(conf *sendConfig) error {
 		return err
 	}
 
-	if len(keysFile.ExtendedPublicKeys) > len(keysFile.EncryptedMnemonics) {
+	if len(keysFile.ExtendedPublicKeys) > keysFile.NumPrivateKeys() {
 		return errors.Errorf("Cannot use 'send' command for multisig wallet without all of the keys")
 	}
 
-	daemonClient, tearDown, err := client.Connect(conf.DaemonAddress)
+	daemonClient, tearDown, err := client.Connect(conf.DaemonAddress, conf.securityOptions())
 	if err != nil {
 		return err
 	}
@@ -33,27 +33,57 @@ func send(conf *sendConfig) error {
 	ctx, cancel := context.WithTimeout(context.Background(), daemonTimeout)
 	defer cancel()
 
-	var sendAmountSompi uint64
-	if !conf.IsSendAll {
-		sendAmountSompi = uint64(conf.SendAmount * constants.SompiPerC4ex)
+	utxos, err := parseOutpoints(conf.UTXOs)
+	if err != nil {
+		return err
+	}
+
+	if conf.paymentURI != nil {
+		printPaymentRequest(conf.paymentURI)
+	}
+
+	var recipients []*pb.Recipient
+	if conf.RecipientsFile != "" {
+		recipients, err = readRecipientsFile(conf.RecipientsFile)
+		if err != nil {
+			return err
+		}
 	}
 
 	createUnsignedTransactionsResponse, err :=
 		daemonClient.CreateUnsignedTransactions(ctx, &pb.CreateUnsignedTransactionsRequest{
 			From:                     conf.FromAddresses,
 			Address:                  conf.ToAddress,
-			Amount:                   sendAmountSompi,
+			Amount:                   conf.sendAmountSompi,
 			IsSendAll:                conf.IsSendAll,
 			UseExistingChangeAddress: conf.UseExistingChangeAddress,
+			Utxos:                    utxos,
+			Recipients:               recipients,
+			Account:                  conf.Account,
+			MinConfirmations:         conf.MinConfirmations,
 		})
 	if err != nil {
 		return err
 	}
 
-	if len(conf.Password) == 0 {
+	if len(recipients) > 0 {
+		err = printRecipientsSummary(os.Stdout, createUnsignedTransactionsResponse.UnsignedTransactions, recipients)
+		if err != nil {
+			return err
+		}
+		if !conf.Yes {
+			err = confirm("Send the batch payment")
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(conf.Password) == 0 && !keysFile.HasExternalSigner() {
 		conf.Password = keys.GetPassword("Password:")
 	}
-	mnemonics, err := keysFile.DecryptMnemonics(conf.Password)
+	signedTransactions, err := signer.SignTransactions(conf.NetParams(), keysFile, conf.Password,
+		createUnsignedTransactionsResponse.UnsignedTransactions)
 	if err != nil {
 		if strings.Contains(err.Error(), "message authentication failed") {
 			fmt.Fprintf(os.Stderr, "Password decryption failed. Sometimes this is a result of not "+
@@ -62,15 +92,6 @@ func send(conf *sendConfig) error {
 		return err
 	}
 
-	signedTransactions := make([][]byte, len(createUnsignedTransactionsResponse.UnsignedTransactions))
-	for i, unsignedTransaction := range createUnsignedTransactionsResponse.UnsignedTransactions {
-		signedTransaction, err := libc4exwallet.Sign(conf.NetParams(), mnemonics, unsignedTransaction, keysFile.ECDSA)
-		if err != nil {
-			return err
-		}
-		signedTransactions[i] = signedTransaction
-	}
-
 	if len(signedTransactions) > 1 {
 		fmt.Printf("Broadcasting %d transactions\n", len(signedTransactions))
 	}
@@ -99,3 +120,17 @@ func send(conf *sendConfig) error {
 
 	return nil
 }
+
+func printPaymentRequest(paymentURI *util.PaymentURI) {
+	if paymentURI.Amount != 0 {
+		fmt.Printf("Paying %s to %s\n", paymentURI.Amount, paymentURI.Address)
+	} else {
+		fmt.Printf("Paying to %s\n", paymentURI.Address)
+	}
+	if paymentURI.Label != "" {
+		fmt.Printf("Label: %s\n", paymentURI.Label)
+	}
+	if paymentURI.Message != "" {
+		fmt.Printf("Message: %s\n", paymentURI.Message)
+	}
+}
@@ -9,7 +9,7 @@ import (
 )
 
 func showAddresses(conf *showAddressesConfig) error {
-	daemonClient, tearDown, err := client.Connect(conf.DaemonAddress)
+	daemonClient, tearDown, err := client.Connect(conf.DaemonAddress, conf.securityOptions())
 	if err != nil {
 		return err
 	}
@@ -18,7 +18,7 @@ func showAddresses(conf *showAddressesConfig) error {
 	ctx, cancel := context.WithTimeout(context.Background(), daemonTimeout)
 	defer cancel()
 
-	response, err := daemonClient.ShowAddresses(ctx, &pb.ShowAddressesRequest{})
+	response, err := daemonClient.ShowAddresses(ctx, &pb.ShowAddressesRequest{Account: conf.Account})
 	if err != nil {
 		return err
 	}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/c4ei/c4exd/cmd/c4exwallet/keys"
 	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/signer"
 	"github.com/pkg/errors"
 )
 
@@ -24,12 +25,12 @@ func sign(conf *signConfig) error {
 		return err
 	}
 
-	if len(conf.Password) == 0 {
-		conf.Password = keys.GetPassword("Password:")
+	if keysFile.IsWatchOnly() {
+		return keys.ErrWatchOnly
 	}
-	privateKeys, err := keysFile.DecryptMnemonics(conf.Password)
-	if err != nil {
-		return err
+
+	if len(conf.Password) == 0 && !keysFile.HasExternalSigner() {
+		conf.Password = keys.GetPassword("Password:")
 	}
 
 	transactionsHex := conf.Transaction
@@ -45,13 +46,10 @@ func sign(conf *signConfig) error {
 		return err
 	}
 
-	updatedPartiallySignedTransactions := make([][]byte, len(partiallySignedTransactions))
-	for i, partiallySignedTransaction := range partiallySignedTransactions {
-		updatedPartiallySignedTransactions[i], err =
-			libc4exwallet.Sign(conf.NetParams(), privateKeys, partiallySignedTransaction, keysFile.ECDSA)
-		if err != nil {
-			return err
-		}
+	updatedPartiallySignedTransactions, err :=
+		signer.SignTransactions(conf.NetParams(), keysFile, conf.Password, partiallySignedTransactions)
+	if err != nil {
+		return err
 	}
 
 	areAllTransactionsFullySigned := true
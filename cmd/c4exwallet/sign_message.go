@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/c4ei/c4exd/cmd/c4exwallet/daemon/client"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/daemon/pb"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/keys"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/signer"
+)
+
+func signMessage(conf *signMessageConfig) error {
+	keysFile, err := keys.ReadKeysFile(conf.NetParams(), conf.KeysFile)
+	if err != nil {
+		return err
+	}
+
+	err = signer.CanSignMessages(keysFile)
+	if err != nil {
+		return err
+	}
+
+	daemonClient, tearDown, err := client.Connect(conf.DaemonAddress, conf.securityOptions())
+	if err != nil {
+		return err
+	}
+	defer tearDown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), daemonTimeout)
+	defer cancel()
+
+	response, err := daemonClient.GetAddressDerivationPath(ctx,
+		&pb.GetAddressDerivationPathRequest{Address: conf.Address})
+	if err != nil {
+		return err
+	}
+
+	if len(conf.Password) == 0 {
+		conf.Password = keys.GetPassword("Password:")
+	}
+	signature, err := signer.SignMessage(conf.NetParams(), keysFile, conf.Password, response.Account,
+		response.DerivationPath, conf.Message)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(hex.EncodeToString(signature))
+	return nil
+}
@@ -0,0 +1,205 @@
+package signer
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet/serialization"
+	"github.com/c4ei/c4exd/domain/dagconfig"
+	"github.com/pkg/errors"
+)
+
+const (
+	execAddressPrefix = "exec:"
+	unixAddressPrefix = "unix:"
+)
+
+// Client is a session with an external signer
+type Client struct {
+	params     *dagconfig.Params
+	connection io.ReadWriteCloser
+	reader     *bufio.Reader
+}
+
+// Connect starts a session with the external signer at the given address
+func Connect(params *dagconfig.Params, address string) (*Client, error) {
+	var connection io.ReadWriteCloser
+	var err error
+	switch {
+	case strings.HasPrefix(address, execAddressPrefix):
+		connection, err = startSignerCommand(strings.TrimPrefix(address, execAddressPrefix))
+	case strings.HasPrefix(address, unixAddressPrefix):
+		connection, err = net.Dial("unix", strings.TrimPrefix(address, unixAddressPrefix))
+	default:
+		return nil, errors.Errorf("invalid external signer address %s: expected %s<command> or %s<path>",
+			address, execAddressPrefix, unixAddressPrefix)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "error connecting to the external signer at %s", address)
+	}
+
+	return newClient(params, connection), nil
+}
+
+func newClient(params *dagconfig.Params, connection io.ReadWriteCloser) *Client {
+	return &Client{
+		params:     params,
+		connection: connection,
+		reader:     bufio.NewReader(connection),
+	}
+}
+
+// ValidateAddress returns an error if the given external signer address is malformed
+func ValidateAddress(address string) error {
+	if strings.HasPrefix(address, execAddressPrefix) {
+		if len(strings.Fields(strings.TrimPrefix(address, execAddressPrefix))) == 0 {
+			return errors.Errorf("the external signer address %s has no command", address)
+		}
+		return nil
+	}
+	if strings.HasPrefix(address, unixAddressPrefix) {
+		if strings.TrimPrefix(address, unixAddressPrefix) == "" {
+			return errors.Errorf("the external signer address %s has no socket path", address)
+		}
+		return nil
+	}
+	return errors.Errorf("invalid external signer address %s: expected %s<command> or %s<path>",
+		address, execAddressPrefix, unixAddressPrefix)
+}
+
+// ExtendedPublicKeys returns the extended public keys of the given account of the keys the signer holds
+func (c *Client) ExtendedPublicKeys(isMultisig bool, account uint32) ([]string, error) {
+	response, err := c.call(&Request{
+		Method:     MethodGetExtendedPublicKeys,
+		IsMultisig: isMultisig,
+		Account:    account,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(response.ExtendedPublicKeys) == 0 {
+		return nil, errors.New("the external signer holds no keys")
+	}
+	return response.ExtendedPublicKeys, nil
+}
+
+// Sign asks the signer to sign the given partially signed transaction with its keys of
+// the given accounts. The signed transaction is validated to be the same transaction that was sent.
+func (c *Client) Sign(serializedPSTx []byte, accounts []uint32, ecdsa bool) ([]byte, error) {
+	partiallySignedTransaction, err := serialization.DeserializePartiallySignedTransaction(serializedPSTx)
+	if err != nil {
+		return nil, err
+	}
+	derivationPaths := make([]string, len(partiallySignedTransaction.PartiallySignedInputs))
+	for i, input := range partiallySignedTransaction.PartiallySignedInputs {
+		derivationPaths[i] = input.DerivationPath
+	}
+
+	response, err := c.call(&Request{
+		Method:                     MethodSignTransaction,
+		PartiallySignedTransaction: hex.EncodeToString(serializedPSTx),
+		DerivationPaths:            derivationPaths,
+		Accounts:                   accounts,
+		ECDSA:                      ecdsa,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	signedTransaction, err := hex.DecodeString(response.PartiallySignedTransaction)
+	if err != nil {
+		return nil, errors.Wrap(err, "the external signer returned a malformed transaction")
+	}
+	combinedTransaction, invalidSignatures, err := libc4exwallet.Combine([][]byte{serializedPSTx, signedTransaction})
+	if err != nil {
+		return nil, errors.Wrap(err, "the external signer returned a different transaction")
+	}
+	if len(invalidSignatures) > 0 {
+		return nil, errors.Errorf("the signed transaction has invalid signatures: %s",
+			strings.Join(invalidSignatures, "; "))
+	}
+	return combinedTransaction, nil
+}
+
+// Close ends the session with the signer
+func (c *Client) Close() error {
+	return c.connection.Close()
+}
+
+func (c *Client) call(request *Request) (*Response, error) {
+	request.Network = c.params.Name
+	serializedRequest, err := json.Marshal(request)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	_, err = c.connection.Write(append(serializedRequest, '\n'))
+	if err != nil {
+		return nil, errors.Wrap(err, "error sending a request to the external signer")
+	}
+
+	line, err := c.reader.ReadBytes('\n')
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading the response of the external signer")
+	}
+	response := &Response{}
+	err = json.Unmarshal(line, response)
+	if err != nil {
+		return nil, errors.Wrap(err, "the external signer returned a malformed response")
+	}
+	if response.Error != "" {
+		return nil, errors.Errorf("the external signer failed: %s", response.Error)
+	}
+	return response, nil
+}
+
+// commandConnection talks to a signer command over its stdin and stdout
+type commandConnection struct {
+	io.Reader
+	io.WriteCloser
+	cmd *exec.Cmd
+}
+
+func startSignerCommand(commandLine string) (*commandConnection, error) {
+	fields := strings.Fields(commandLine)
+	if len(fields) == 0 {
+		return nil, errors.New("no command to start")
+	}
+
+	cmd := exec.Command(fields[0], fields[1:]...)
+	// The signer may log or prompt the user on stderr
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	err = cmd.Start()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &commandConnection{
+		Reader:      stdout,
+		WriteCloser: stdin,
+		cmd:         cmd,
+	}, nil
+}
+
+// Close closes the stdin of the signer, which ends its session, and waits for it to exit
+func (cc *commandConnection) Close() error {
+	err := cc.WriteCloser.Close()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(cc.cmd.Wait())
+}
@@ -0,0 +1,60 @@
+// Package signer implements the protocol c4exwallet uses to delegate signing to
+// an external signer: a separate process, such as an HSM front-end or an
+// air-gapped signer, that holds the private keys instead of the keys file.
+//
+// The wallet sends requests as JSON objects, one per line, and the signer replies
+// to every request with a single line JSON response. A signer is addressed either
+// by a command that is started for every session and talked to over its stdin and
+// stdout ("exec:<command> [arguments...]"), or by a Unix socket it listens on
+// ("unix:<path>").
+package signer
+
+const (
+	// MethodGetExtendedPublicKeys requests the extended public keys of the keys the
+	// signer holds. The response has ExtendedPublicKeys set.
+	MethodGetExtendedPublicKeys = "getExtendedPublicKeys"
+
+	// MethodSignTransaction requests to sign a partially signed transaction with
+	// the keys the signer holds. The response has PartiallySignedTransaction set.
+	MethodSignTransaction = "signTransaction"
+)
+
+// Request is a request the wallet sends to the signer
+type Request struct {
+	Method string `json:"method"`
+
+	// Network is the name of the network the wallet runs on. A signer should
+	// refuse requests for a network it doesn't hold keys for.
+	Network string `json:"network"`
+
+	// IsMultisig tells whether the extended public keys are requested for a
+	// multisig wallet, which derives its keys from a different path
+	IsMultisig bool `json:"isMultisig,omitempty"`
+
+	// Account is the index of the BIP44 account whose extended public keys are
+	// requested. Account 0 is the account the wallet was created with.
+	Account uint32 `json:"account,omitempty"`
+
+	// PartiallySignedTransaction is the serialized partially signed transaction
+	// to sign, encoded in hex
+	PartiallySignedTransaction string `json:"partiallySignedTransaction,omitempty"`
+
+	// DerivationPaths are the paths, relative to the extended public keys of the
+	// signer, of the keys that sign each of the inputs of the transaction
+	DerivationPaths []string `json:"derivationPaths,omitempty"`
+
+	// Accounts are the indexes of the accounts whose keys may sign the inputs of
+	// the transaction. If it's empty, only the keys of account 0 may sign.
+	Accounts []uint32 `json:"accounts,omitempty"`
+
+	// ECDSA tells whether to sign with ECDSA rather than Schnorr signatures
+	ECDSA bool `json:"ecdsa,omitempty"`
+}
+
+// Response is the reply of the signer to a Request. If Error is set the
+// request failed, and the rest of the fields are empty.
+type Response struct {
+	ExtendedPublicKeys         []string `json:"extendedPublicKeys,omitempty"`
+	PartiallySignedTransaction string   `json:"partiallySignedTransaction,omitempty"`
+	Error                      string   `json:"error,omitempty"`
+}
@@ -0,0 +1,147 @@
+package signer
+
+import (
+	"github.com/c4ei/c4exd/cmd/c4exwallet/keys"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet"
+	"github.com/c4ei/c4exd/domain/dagconfig"
+	"github.com/pkg/errors"
+)
+
+// SignTransactions signs the given partially signed transactions with the keys of
+// the keys file: by its external signer if it has one, or otherwise with its private
+// keys, which are decrypted with the given password
+func SignTransactions(params *dagconfig.Params, keysFile *keys.File, password string,
+	partiallySignedTransactions [][]byte) ([][]byte, error) {
+
+	if keysFile.HasExternalSigner() {
+		return signWithExternalSigner(params, keysFile, partiallySignedTransactions)
+	}
+
+	mnemonics, err := keysFile.DecryptMnemonics(password)
+	if err != nil {
+		return nil, err
+	}
+	accounts := accountIndexes(keysFile)
+	signedTransactions := make([][]byte, len(partiallySignedTransactions))
+	for i, partiallySignedTransaction := range partiallySignedTransactions {
+		signedTransactions[i], err = libc4exwallet.SignWithAccounts(params, mnemonics, accounts, partiallySignedTransaction,
+			keysFile.ECDSA)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return signedTransactions, nil
+}
+
+func signWithExternalSigner(params *dagconfig.Params, keysFile *keys.File, partiallySignedTransactions [][]byte) (
+	[][]byte, error) {
+
+	client, err := Connect(params, keysFile.ExternalSigner)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	accounts := accountIndexes(keysFile)
+	signedTransactions := make([][]byte, len(partiallySignedTransactions))
+	for i, partiallySignedTransaction := range partiallySignedTransactions {
+		signedTransactions[i], err = client.Sign(partiallySignedTransaction, accounts, keysFile.ECDSA)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error signing transaction #%d", i+1)
+		}
+	}
+	return signedTransactions, nil
+}
+
+// CanSignMessages returns an error if the keys file has no single private key to sign messages with:
+// if it's watch-only, its keys are held by an external signer, or it's a multisig wallet, whose
+// addresses are not of a single key
+func CanSignMessages(keysFile *keys.File) error {
+	if keysFile.IsWatchOnly() {
+		return keys.ErrWatchOnly
+	}
+	if keysFile.HasExternalSigner() {
+		return errors.New("wallets with an external signer can't sign messages")
+	}
+	if len(keysFile.ExtendedPublicKeys) > 1 {
+		return errors.New("multisig wallets can't sign messages, since their addresses are not of a single key")
+	}
+	return nil
+}
+
+// SignMessage signs the message with the private key of the keys file at the given derivation path
+// of the given account. The private key is decrypted with the given password
+func SignMessage(params *dagconfig.Params, keysFile *keys.File, password string, account uint32,
+	derivationPath string, message string) ([]byte, error) {
+
+	err := CanSignMessages(keysFile)
+	if err != nil {
+		return nil, err
+	}
+
+	mnemonics, err := keysFile.DecryptMnemonics(password)
+	if err != nil {
+		return nil, err
+	}
+	return libc4exwallet.SignMessage(params, mnemonics[0], account, derivationPath, message, keysFile.ECDSA)
+}
+
+// accountIndexes returns the indexes of all the accounts of the keys file, since
+// the transactions may spend the outputs of any of them
+func accountIndexes(keysFile *keys.File) []uint32 {
+	accounts := keysFile.Accounts()
+	indexes := make([]uint32, len(accounts))
+	for i, account := range accounts {
+		indexes[i] = account.Index
+	}
+	return indexes
+}
+
+// AccountExtendedPublicKeys returns the extended public keys of the account with the given index for the
+// keys of the keys file: those of its external signer if it has one, or otherwise those of its private keys,
+// which are decrypted with the given password. A watch-only keys file has no such keys.
+func AccountExtendedPublicKeys(params *dagconfig.Params, keysFile *keys.File, password string, account uint32) (
+	[]string, error) {
+
+	if keysFile.IsWatchOnly() {
+		return nil, nil
+	}
+
+	isMultisig := len(keysFile.ExtendedPublicKeys) > 1
+	if keysFile.HasExternalSigner() {
+		client, err := Connect(params, keysFile.ExternalSigner)
+		if err != nil {
+			return nil, err
+		}
+		defer client.Close()
+
+		extendedPublicKeys, err := client.ExtendedPublicKeys(isMultisig, account)
+		if err != nil {
+			return nil, err
+		}
+		if len(extendedPublicKeys) != keysFile.NumPrivateKeys() {
+			return nil, errors.Errorf("the external signer holds %d keys, while the wallet expects %d",
+				len(extendedPublicKeys), keysFile.NumPrivateKeys())
+		}
+		for _, extendedPublicKey := range extendedPublicKeys {
+			err := libc4exwallet.ValidateExtendedPublicKey(params, extendedPublicKey)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return extendedPublicKeys, nil
+	}
+
+	mnemonics, err := keysFile.DecryptMnemonics(password)
+	if err != nil {
+		return nil, err
+	}
+	extendedPublicKeys := make([]string, len(mnemonics))
+	for i, mnemonic := range mnemonics {
+		extendedPublicKeys[i], err = libc4exwallet.AccountPublicKeyFromMnemonic(params, mnemonic, isMultisig, account)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return extendedPublicKeys, nil
+}
@@ -0,0 +1,146 @@
+package signer
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/c4ei/c4exd/cmd/c4exwallet/keys"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet/serialization"
+	"github.com/c4ei/c4exd/domain/consensus/model/externalapi"
+	"github.com/c4ei/c4exd/domain/consensus/utils/txscript"
+	"github.com/c4ei/c4exd/domain/consensus/utils/utxo"
+	"github.com/c4ei/c4exd/domain/dagconfig"
+	"github.com/pkg/errors"
+)
+
+func TestSoftwareSigner(t *testing.T) {
+	params := &dagconfig.SimnetParams
+
+	const numKeys = 2
+	mnemonics := make([]string, numKeys)
+	extendedPublicKeys := make([]string, numKeys)
+	for i := range mnemonics {
+		var err error
+		mnemonics[i], err = libc4exwallet.CreateMnemonic()
+		if err != nil {
+			t.Fatalf("CreateMnemonic: %+v", err)
+		}
+		extendedPublicKeys[i], err = libc4exwallet.MasterPublicKeyFromMnemonic(params, mnemonics[i], true)
+		if err != nil {
+			t.Fatalf("MasterPublicKeyFromMnemonic: %+v", err)
+		}
+	}
+
+	// The signer holds only the first key of the multisig wallet
+	client := clientForTest(t, params, mnemonics[:1])
+	defer client.Close()
+
+	signerExtendedPublicKeys, err := client.ExtendedPublicKeys(true, 0)
+	if err != nil {
+		t.Fatalf("ExtendedPublicKeys: %+v", err)
+	}
+	if len(signerExtendedPublicKeys) != 1 || signerExtendedPublicKeys[0] != extendedPublicKeys[0] {
+		t.Fatalf("unexpected extended public keys %v, expected %s", signerExtendedPublicKeys, extendedPublicKeys[0])
+	}
+
+	const minimumSignatures = 2
+	path := "m/0/1"
+	address, err := libc4exwallet.Address(params, extendedPublicKeys, minimumSignatures, path, false)
+	if err != nil {
+		t.Fatalf("Address: %+v", err)
+	}
+	scriptPublicKey, err := txscript.PayToAddrScript(address)
+	if err != nil {
+		t.Fatalf("PayToAddrScript: %+v", err)
+	}
+	unsignedTransaction, err := libc4exwallet.CreateUnsignedTransaction(extendedPublicKeys, minimumSignatures,
+		[]*libc4exwallet.Payment{{Address: address, Amount: 10}},
+		[]*libc4exwallet.UTXO{{
+			Outpoint: &externalapi.DomainOutpoint{
+				TransactionID: *externalapi.NewDomainTransactionIDFromByteArray(&[externalapi.DomainHashSize]byte{1}),
+			},
+			UTXOEntry:      utxo.NewUTXOEntry(1000, scriptPublicKey, false, 0),
+			DerivationPath: path,
+		}})
+	if err != nil {
+		t.Fatalf("CreateUnsignedTransaction: %+v", err)
+	}
+
+	signedTransaction, err := client.Sign(unsignedTransaction, nil, false)
+	if err != nil {
+		t.Fatalf("Sign: %+v", err)
+	}
+	partiallySignedTransaction, err := serialization.DeserializePartiallySignedTransaction(signedTransaction)
+	if err != nil {
+		t.Fatalf("DeserializePartiallySignedTransaction: %+v", err)
+	}
+	signatureCount := libc4exwallet.InputSignatureCount(partiallySignedTransaction.PartiallySignedInputs[0])
+	if signatureCount != 1 {
+		t.Fatalf("expected the signer to add 1 signature, got %d", signatureCount)
+	}
+
+	// A signer refuses requests for a network it doesn't hold keys for
+	testnetClient := clientForTest(t, &dagconfig.TestnetParams, mnemonics[:1])
+	defer testnetClient.Close()
+	_, err = testnetClient.ExtendedPublicKeys(true, 0)
+	if err == nil || !strings.Contains(err.Error(), "the signer holds keys for") {
+		t.Fatalf("expected a network mismatch error, got %v", err)
+	}
+}
+
+func TestValidateAddress(t *testing.T) {
+	for _, address := range []string{"exec:c4exwallet software-signer", "unix:/tmp/signer.sock"} {
+		err := ValidateAddress(address)
+		if err != nil {
+			t.Fatalf("ValidateAddress(%s): %+v", address, err)
+		}
+	}
+	for _, address := range []string{"", "exec:", "exec:  ", "unix:", "tcp:localhost:1234"} {
+		err := ValidateAddress(address)
+		if err == nil {
+			t.Fatalf("ValidateAddress(%s) unexpectedly succeeded", address)
+		}
+	}
+}
+
+// clientForTest returns a client of a SoftwareSigner for the simnet that holds the given mnemonics
+func clientForTest(t *testing.T, params *dagconfig.Params, mnemonics []string) *Client {
+	clientConnection, signerConnection := net.Pipe()
+	softwareSigner := NewSoftwareSigner(&dagconfig.SimnetParams, mnemonics)
+	go func() {
+		defer signerConnection.Close()
+		err := softwareSigner.Serve(signerConnection)
+		if err != nil {
+			t.Errorf("Serve: %+v", err)
+		}
+	}()
+	return newClient(params, clientConnection)
+}
+
+func TestSignWithWatchOnlyFile(t *testing.T) {
+	params := &dagconfig.SimnetParams
+	mnemonic, err := libc4exwallet.CreateMnemonic()
+	if err != nil {
+		t.Fatalf("CreateMnemonic: %+v", err)
+	}
+	extendedPublicKey, err := libc4exwallet.MasterPublicKeyFromMnemonic(params, mnemonic, false)
+	if err != nil {
+		t.Fatalf("MasterPublicKeyFromMnemonic: %+v", err)
+	}
+	keysFile := &keys.File{
+		Version:            keys.LastVersion,
+		ExtendedPublicKeys: []string{extendedPublicKey},
+		MinimumSignatures:  1,
+	}
+
+	_, err = SignTransactions(params, keysFile, "", [][]byte{{}})
+	if !errors.Is(err, keys.ErrWatchOnly) {
+		t.Fatalf("expected SignTransactions to return ErrWatchOnly, got %v", err)
+	}
+	err = CanSignMessages(keysFile)
+	if !errors.Is(err, keys.ErrWatchOnly) {
+		t.Fatalf("expected CanSignMessages to return ErrWatchOnly, got %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package signer
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+
+	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet/serialization"
+	"github.com/c4ei/c4exd/domain/dagconfig"
+	"github.com/pkg/errors"
+)
+
+// SoftwareSigner is a reference implementation of an external signer that holds
+// its mnemonics in memory. It's meant for testing and as an example for signer
+// implementations, and offers no protection beyond keeping the keys in a
+// separate process.
+type SoftwareSigner struct {
+	params    *dagconfig.Params
+	mnemonics []string
+}
+
+// NewSoftwareSigner returns a SoftwareSigner that signs with the given mnemonics
+func NewSoftwareSigner(params *dagconfig.Params, mnemonics []string) *SoftwareSigner {
+	return &SoftwareSigner{
+		params:    params,
+		mnemonics: mnemonics,
+	}
+}
+
+// Serve handles the requests read from the given connection until it's closed
+func (ss *SoftwareSigner) Serve(connection io.ReadWriter) error {
+	reader := bufio.NewReader(connection)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF && len(line) == 0 {
+			return nil
+		}
+		if err != nil && err != io.EOF {
+			return errors.WithStack(err)
+		}
+
+		response := ss.handle(line)
+		serializedResponse, err := json.Marshal(response)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		_, err = connection.Write(append(serializedResponse, '\n'))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+}
+
+func (ss *SoftwareSigner) handle(line []byte) *Response {
+	request := &Request{}
+	err := json.Unmarshal(line, request)
+	if err != nil {
+		return &Response{Error: "malformed request: " + err.Error()}
+	}
+	if request.Network != ss.params.Name {
+		return &Response{Error: "the signer holds keys for " + ss.params.Name + ", not for " + request.Network}
+	}
+
+	switch request.Method {
+	case MethodGetExtendedPublicKeys:
+		extendedPublicKeys, err := ss.extendedPublicKeys(request.IsMultisig, request.Account)
+		if err != nil {
+			return &Response{Error: err.Error()}
+		}
+		return &Response{ExtendedPublicKeys: extendedPublicKeys}
+	case MethodSignTransaction:
+		signedTransaction, err := ss.sign(request)
+		if err != nil {
+			return &Response{Error: err.Error()}
+		}
+		return &Response{PartiallySignedTransaction: hex.EncodeToString(signedTransaction)}
+	default:
+		return &Response{Error: "unknown method " + request.Method}
+	}
+}
+
+func (ss *SoftwareSigner) extendedPublicKeys(isMultisig bool, account uint32) ([]string, error) {
+	extendedPublicKeys := make([]string, len(ss.mnemonics))
+	for i, mnemonic := range ss.mnemonics {
+		var err error
+		extendedPublicKeys[i], err = libc4exwallet.AccountPublicKeyFromMnemonic(ss.params, mnemonic, isMultisig, account)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return extendedPublicKeys, nil
+}
+
+func (ss *SoftwareSigner) sign(request *Request) ([]byte, error) {
+	serializedPSTx, err := hex.DecodeString(request.PartiallySignedTransaction)
+	if err != nil {
+		return nil, errors.Wrap(err, "malformed transaction")
+	}
+	partiallySignedTransaction, err := serialization.DeserializePartiallySignedTransaction(serializedPSTx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only sign with the keys the wallet asked for
+	if len(request.DerivationPaths) != len(partiallySignedTransaction.PartiallySignedInputs) {
+		return nil, errors.Errorf("got %d derivation paths for %d inputs", len(request.DerivationPaths),
+			len(partiallySignedTransaction.PartiallySignedInputs))
+	}
+	for i, input := range partiallySignedTransaction.PartiallySignedInputs {
+		if input.DerivationPath != request.DerivationPaths[i] {
+			return nil, errors.Errorf("input %d is derived from %s rather than %s", i, input.DerivationPath,
+				request.DerivationPaths[i])
+		}
+	}
+
+	accounts := request.Accounts
+	if len(accounts) == 0 {
+		accounts = []uint32{0}
+	}
+	return libc4exwallet.SignWithAccounts(ss.params, ss.mnemonics, accounts, serializedPSTx, request.ECDSA)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/c4ei/c4exd/cmd/c4exwallet/keys"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/signer"
+	"github.com/pkg/errors"
+)
+
+func softwareSigner(conf *softwareSignerConfig) error {
+	keysFile, err := keys.ReadKeysFile(conf.NetParams(), conf.KeysFile)
+	if err != nil {
+		return err
+	}
+
+	if len(conf.Password) == 0 {
+		// Without --listen, stdin is where the requests of the wallet come from
+		if conf.Listen == "" {
+			return errors.New("--password is required when serving over stdin and stdout")
+		}
+		conf.Password = keys.GetPassword("Password:")
+	}
+	mnemonics, err := keysFile.DecryptMnemonics(conf.Password)
+	if err != nil {
+		return err
+	}
+	softwareSigner := signer.NewSoftwareSigner(conf.NetParams(), mnemonics)
+
+	if conf.Listen == "" {
+		return softwareSigner.Serve(struct {
+			io.Reader
+			io.Writer
+		}{os.Stdin, os.Stdout})
+	}
+
+	listener, err := net.Listen("unix", conf.Listen)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	// Closing the listener removes the socket file
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-interrupt
+		listener.Close()
+	}()
+
+	fmt.Fprintf(os.Stderr, "Listening on %s\n", conf.Listen)
+	for {
+		connection, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return errors.WithStack(err)
+		}
+
+		go func() {
+			defer connection.Close()
+			err := softwareSigner.Serve(connection)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error serving a session: %s\n", err)
+			}
+		}()
+	}
+}
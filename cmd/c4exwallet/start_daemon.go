@@ -1,7 +1,44 @@
 package main
 
-import "github.com/c4ei/c4exd/cmd/c4exwallet/daemon/server"
+import (
+	"github.com/c4ei/c4exd/cmd/c4exwallet/daemon/server"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/keys"
+	"github.com/pkg/errors"
+)
 
 func startDaemon(conf *startDaemonConfig) error {
-	return server.Start(conf.NetParams(), conf.Listen, conf.RPCServer, conf.KeysFile, conf.Profile, conf.Timeout)
+	var autoConsolidation *server.AutoConsolidationConfig
+	if conf.AutoConsolidateInterval > 0 {
+		keysFile, err := keys.ReadKeysFile(conf.NetParams(), conf.KeysFile)
+		if err != nil {
+			return err
+		}
+		if keysFile.IsWatchOnly() {
+			return errors.Wrap(keys.ErrWatchOnly, "automatic UTXO consolidation requires signing")
+		}
+		if len(conf.Password) == 0 && !keysFile.HasExternalSigner() {
+			conf.Password = keys.GetPassword("Password:")
+		}
+		autoConsolidation = &server.AutoConsolidationConfig{
+			Interval:       conf.AutoConsolidateInterval,
+			Threshold:      conf.autoConsolidateThresholdSompi,
+			OutputCount:    conf.AutoConsolidateOutputs,
+			FeeRate:        conf.AutoConsolidateFeeRate,
+			MinUTXOCount:   conf.AutoConsolidateMinUTXOs,
+			MaxMempoolSize: conf.AutoConsolidateMaxMempoolSize,
+			Password:       conf.Password,
+		}
+	}
+
+	security := &server.SecurityConfig{
+		TLSCertFile:           conf.TLSCert,
+		TLSKeyFile:            conf.TLSKey,
+		AuthTokenFile:         conf.AuthTokenFile,
+		ReadOnlyAuthTokenFile: conf.ReadOnlyAuthTokenFile,
+		ClientCAFile:          conf.ClientCA,
+		ReadOnlyClientCAFile:  conf.ReadOnlyClientCA,
+	}
+
+	return server.Start(conf.NetParams(), conf.Listen, conf.RPCServer, conf.KeysFile, conf.Profile, conf.Timeout,
+		autoConsolidation, security)
 }
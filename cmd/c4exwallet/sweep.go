@@ -48,7 +48,7 @@ func sweep(conf *sweepConfig) error {
 		return err
 	}
 
-	daemonClient, tearDown, err := client.Connect(conf.DaemonAddress)
+	daemonClient, tearDown, err := client.Connect(conf.DaemonAddress, conf.securityOptions())
 	if err != nil {
 		return err
 	}
@@ -79,7 +79,7 @@ func sweep(conf *sweepConfig) error {
 		paymentAmount = paymentAmount + UTXO.UTXOEntry.Amount()
 	}
 
-	newAddressResponse, err := daemonClient.NewAddress(ctx, &pb.NewAddressRequest{})
+	newAddressResponse, err := daemonClient.NewAddress(ctx, &pb.NewAddressRequest{Account: conf.Account})
 	if err != nil {
 		return err
 	}
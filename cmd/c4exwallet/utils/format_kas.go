@@ -2,15 +2,17 @@ package utils
 
 import (
 	"fmt"
+	"strings"
 
-	"github.com/c4ei/c4exd/domain/consensus/utils/constants"
+	"github.com/c4ei/c4exd/util"
 )
 
 // FormatC4x takes the amount of sompis as uint64, and returns amount of C4X with 8  decimal places
 func FormatC4x(amount uint64) string {
 	res := "                   "
 	if amount > 0 {
-		res = fmt.Sprintf("%19.8f", float64(amount)/constants.SompiPerC4ex)
+		whole, fraction, _ := strings.Cut(util.Amount(amount).C4XString(), ".")
+		res = fmt.Sprintf("%19s", whole+"."+fraction+strings.Repeat("0", 8-len(fraction)))
 	}
 	return res
 }
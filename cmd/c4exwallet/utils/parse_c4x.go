@@ -0,0 +1,17 @@
+package utils
+
+import (
+	"strings"
+
+	"github.com/c4ei/c4exd/util"
+)
+
+// ParseC4x parses an amount of C4X with up to 8 decimal places (e.g. 1234.12345678), and returns it in sompi.
+// Unlike converting a float, the conversion is exact.
+func ParseC4x(amount string) (uint64, error) {
+	sompi, err := util.ParseC4X(strings.TrimSpace(amount))
+	if err != nil {
+		return 0, err
+	}
+	return uint64(sompi), nil
+}
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseC4x(t *testing.T) {
+	tests := []struct {
+		amount        string
+		expectedSompi uint64
+		expectedErr   bool
+	}{
+		{amount: "1", expectedSompi: 100_000_000},
+		{amount: "1234.12345678", expectedSompi: 123_412_345_678},
+		{amount: " 1.5 ", expectedSompi: 150_000_000},
+		{amount: "0.00000001", expectedSompi: 1},
+		{amount: "0", expectedSompi: 0},
+		// Amounts that are rounded when converted through a float64
+		{amount: "0.29", expectedSompi: 29_000_000},
+		{amount: "1.15", expectedSompi: 115_000_000},
+		{amount: "4.35", expectedSompi: 435_000_000},
+		{amount: "184467440737.09551615", expectedSompi: math.MaxUint64},
+
+		{amount: "0.123456789", expectedErr: true},
+		{amount: "1.000000000", expectedErr: true},
+		{amount: "184467440737.09551616", expectedErr: true},
+		{amount: "184467440738", expectedErr: true},
+		{amount: "99999999999999999999", expectedErr: true},
+		{amount: "-1", expectedErr: true},
+		{amount: "+1", expectedErr: true},
+		{amount: "", expectedErr: true},
+		{amount: ".", expectedErr: true},
+		{amount: ".5", expectedErr: true},
+		{amount: "1.", expectedErr: true},
+		{amount: "1.2.3", expectedErr: true},
+		{amount: "1e8", expectedErr: true},
+		{amount: "0x10", expectedErr: true},
+		{amount: "1,5", expectedErr: true},
+		{amount: "abc", expectedErr: true},
+	}
+
+	for _, test := range tests {
+		sompi, err := ParseC4x(test.amount)
+		if test.expectedErr {
+			if err == nil {
+				t.Errorf("ParseC4x(%q): expected an error, got %d", test.amount, sompi)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseC4x(%q): %s", test.amount, err)
+			continue
+		}
+		if sompi != test.expectedSompi {
+			t.Errorf("ParseC4x(%q): expected %d, got %d", test.amount, test.expectedSompi, sompi)
+		}
+	}
+}
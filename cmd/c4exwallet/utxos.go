@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/c4ei/c4exd/cmd/c4exwallet/daemon/client"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/daemon/pb"
+	"github.com/c4ei/c4exd/cmd/c4exwallet/utils"
+	"github.com/c4ei/c4exd/domain/consensus/utils/transactionid"
+	"github.com/pkg/errors"
+)
+
+func listUTXOs(conf *listUTXOsConfig) error {
+	daemonClient, tearDown, err := client.Connect(conf.DaemonAddress, conf.securityOptions())
+	if err != nil {
+		return err
+	}
+	defer tearDown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), daemonTimeout)
+	defer cancel()
+	response, err := daemonClient.ListUTXOs(ctx, &pb.ListUTXOsRequest{
+		Addresses: conf.Addresses,
+		Account:   conf.Account,
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(response.Utxos) == 0 {
+		fmt.Println("No UTXOs found")
+		return nil
+	}
+
+	var total, frozen uint64
+	for _, utxo := range response.Utxos {
+		fmt.Printf("%s:%d\n", utxo.Outpoint.TransactionId, utxo.Outpoint.Index)
+		fmt.Printf("    Address:   %s\n", utxo.Address)
+		if utxo.Account != 0 {
+			fmt.Printf("    Account:   %d\n", utxo.Account)
+		}
+		fmt.Printf("    Amount:    %s C4X\n", strings.TrimSpace(utils.FormatC4x(utxo.Amount)))
+		fmt.Printf("    DAA score: %d\n", utxo.BlockDaaScore)
+		if utxo.IsCoinbase {
+			if utxo.IsMature {
+				fmt.Printf("    Coinbase:  mature\n")
+			} else {
+				fmt.Printf("    Coinbase:  immature, spendable from DAA score %d (%d to go)\n",
+					utxo.MaturityDaaScore, utxo.MaturityDaaScore-response.VirtualDaaScore)
+			}
+		}
+		if utxo.IsFrozen {
+			fmt.Printf("    Frozen\n")
+			frozen += utxo.Amount
+		}
+		total += utxo.Amount
+	}
+
+	fmt.Printf("\n%d UTXOs, total: %s C4X", len(response.Utxos), strings.TrimSpace(utils.FormatC4x(total)))
+	if frozen > 0 {
+		fmt.Printf(", frozen: %s C4X", strings.TrimSpace(utils.FormatC4x(frozen)))
+	}
+	fmt.Println()
+	return nil
+}
+
+func freeze(conf *freezeConfig) error {
+	outpoints, err := parseOutpoints(conf.UTXOs)
+	if err != nil {
+		return err
+	}
+
+	daemonClient, tearDown, err := client.Connect(conf.DaemonAddress, conf.securityOptions())
+	if err != nil {
+		return err
+	}
+	defer tearDown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), daemonTimeout)
+	defer cancel()
+	_, err = daemonClient.FreezeUTXOs(ctx, &pb.FreezeUTXOsRequest{Outpoints: outpoints})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Froze %d UTXOs\n", len(outpoints))
+	return nil
+}
+
+func unfreeze(conf *unfreezeConfig) error {
+	outpoints, err := parseOutpoints(conf.UTXOs)
+	if err != nil {
+		return err
+	}
+
+	daemonClient, tearDown, err := client.Connect(conf.DaemonAddress, conf.securityOptions())
+	if err != nil {
+		return err
+	}
+	defer tearDown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), daemonTimeout)
+	defer cancel()
+	_, err = daemonClient.UnfreezeUTXOs(ctx, &pb.UnfreezeUTXOsRequest{Outpoints: outpoints})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Unfroze %d UTXOs\n", len(outpoints))
+	return nil
+}
+
+// parseOutpoints parses outpoints in the <transaction ID>:<index> form
+func parseOutpoints(outpointStrings []string) ([]*pb.Outpoint, error) {
+	outpoints := make([]*pb.Outpoint, len(outpointStrings))
+	for i, outpointString := range outpointStrings {
+		parts := strings.Split(outpointString, ":")
+		if len(parts) != 2 {
+			return nil, errors.Errorf("UTXO %s is not in the <transaction ID>:<index> form", outpointString)
+		}
+
+		_, err := transactionid.FromString(parts[0])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid transaction ID %s", parts[0])
+		}
+
+		index, err := strconv.ParseUint(parts[1], 10, 32)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid index %s", parts[1])
+		}
+
+		outpoints[i] = &pb.Outpoint{
+			TransactionId: parts[0],
+			Index:         uint32(index),
+		}
+	}
+	return outpoints, nil
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/c4ei/c4exd/util"
+	"github.com/pkg/errors"
+)
+
+func verifyMessage(conf *verifyMessageConfig) error {
+	address, err := util.DecodeAddress(conf.Address, conf.NetParams().Prefix)
+	if err != nil {
+		return errors.Wrapf(err, "invalid address %s", conf.Address)
+	}
+
+	signature, err := hex.DecodeString(conf.Signature)
+	if err != nil {
+		return errors.Wrap(err, "the signature is not in hex format")
+	}
+
+	isValid, err := util.VerifyMessage(address, conf.Message, signature)
+	if err != nil {
+		return err
+	}
+	if !isValid {
+		return errors.Errorf("The signature is not a signature of the message by %s", address)
+	}
+
+	fmt.Printf("The signature is a valid signature of the message by %s\n", address)
+	return nil
+}
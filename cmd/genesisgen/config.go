@@ -0,0 +1,33 @@
+package main
+
+import (
+	"github.com/c4ei/c4exd/domain/dagconfig"
+	"github.com/jessevdk/go-flags"
+	"github.com/pkg/errors"
+)
+
+type configFlags struct {
+	ParamsFile string `long:"params" short:"p" description:"The JSON or TOML network parameters file to create the genesis block for (required)"`
+	OutputFile string `long:"output" short:"o" description:"Write the resulting JSON network parameters file to this path instead of stdout"`
+	Timestamp  int64  `long:"timestamp" description:"Genesis timestamp in milliseconds (defaults to the one in the parameters file, or to now if it's not set)"`
+}
+
+func parseConfig() (*configFlags, error) {
+	cfg := &configFlags{}
+	parser := flags.NewParser(cfg, flags.PrintErrors|flags.HelpFlag)
+	_, err := parser.Parse()
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.ParamsFile == "" {
+		return nil, errors.New("--params is required")
+	}
+	// TOML integers are signed, so some default values, such as a disabled
+	// activation score, can only be written in JSON
+	if dagconfig.IsTOMLParamsFile(cfg.OutputFile) {
+		return nil, errors.New("--output must be a JSON file")
+	}
+
+	return cfg, nil
+}
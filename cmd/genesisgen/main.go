@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/c4ei/c4exd/domain/consensus/utils/consensushashing"
+	"github.com/c4ei/c4exd/domain/consensus/utils/pow"
+	"github.com/c4ei/c4exd/domain/dagconfig"
+	"github.com/c4ei/c4exd/util/mstime"
+	"github.com/jessevdk/go-flags"
+	"github.com/pkg/errors"
+)
+
+func main() {
+	cfg, err := parseConfig()
+	if err != nil {
+		// Errors of the flags parser are already printed by it
+		var flagsErr *flags.Error
+		if !errors.As(err, &flagsErr) {
+			fmt.Fprintf(os.Stderr, "Error parsing command-line arguments: %s\n", err)
+		}
+		os.Exit(1)
+	}
+
+	err = generateGenesis(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%+v\n", err)
+		os.Exit(1)
+	}
+}
+
+func generateGenesis(cfg *configFlags) error {
+	paramsFile, err := dagconfig.ReadParamsFile(cfg.ParamsFile)
+	if err != nil {
+		return err
+	}
+	if paramsFile.Genesis == nil {
+		paramsFile.Genesis = dagconfig.NewParamsFile().Genesis
+	}
+
+	genesis := paramsFile.Genesis
+	if cfg.Timestamp != 0 {
+		genesis.TimeInMilliseconds = cfg.Timestamp
+	}
+	if genesis.TimeInMilliseconds == 0 {
+		genesis.TimeInMilliseconds = mstime.Now().UnixMilliseconds()
+	}
+	genesis.Hash = ""
+
+	if !paramsFile.SkipProofOfWork {
+		fmt.Fprintf(os.Stderr, "Mining genesis block with bits %x...\n", genesis.Bits)
+		genesis.Nonce, err = mineGenesis(paramsFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	genesisBlock, err := paramsFile.GenesisBlock()
+	if err != nil {
+		return err
+	}
+	genesis.Hash = consensushashing.BlockHash(genesisBlock).String()
+
+	_, err = paramsFile.ToParams()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Genesis hash: %s\n", genesis.Hash)
+
+	output, err := json.MarshalIndent(paramsFile, "", "  ")
+	if err != nil {
+		return err
+	}
+	output = append(output, '\n')
+
+	if cfg.OutputFile == "" {
+		_, err = os.Stdout.Write(output)
+		return err
+	}
+	return os.WriteFile(cfg.OutputFile, output, 0600)
+}
+
+func mineGenesis(paramsFile *dagconfig.ParamsFile) (uint64, error) {
+	genesisBlock, err := paramsFile.GenesisBlock()
+	if err != nil {
+		return 0, err
+	}
+
+	state := pow.NewState(genesisBlock.Header.ToMutable())
+	state.Nonce = 0
+	for {
+		if state.CheckProofOfWork() {
+			return state.Nonce, nil
+		}
+		if state.Nonce == ^uint64(0) {
+			return 0, errors.New("exhausted the nonce space without finding a valid genesis block")
+		}
+		state.IncrementNonce()
+	}
+}
@@ -1,19 +1,56 @@
 package main
 
 import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet"
 	"github.com/c4ei/c4exd/infrastructure/config"
 	"github.com/jessevdk/go-flags"
+	"github.com/pkg/errors"
+)
+
+const (
+	formatText = "text"
+	formatJSON = "json"
+	formatCSV  = "csv"
+)
+
+var (
+	outputFormats = []string{formatText, formatJSON, formatCSV}
+
+	// defaultPath is the path of the first receive address of the first account of a c4exwallet
+	// created from the same mnemonic
+	defaultPath = fmt.Sprintf("m/%d'/%d'/0'/0/0", libc4exwallet.SingleSignerPurpose, libc4exwallet.CoinType)
 )
 
 type configFlags struct {
+	ECDSA    bool   `long:"ecdsa" description:"Generate ECDSA key pairs instead of Schnorr key pairs"`
+	Mnemonic bool   `long:"mnemonic" description:"Generate a bip39 mnemonic for every key pair, and derive the key pair from it"`
+	Path     string `long:"path" description:"The derivation path of the key pair when --mnemonic is used (default: the first address of a c4exwallet created from the mnemonic)"`
+	Count    uint32 `short:"n" long:"count" description:"Number of key pairs to generate"`
+	Format   string `long:"format" description:"Output format: text, json or csv"`
+	Vanity   string `long:"vanity" description:"Generate key pairs until their addresses start with this prefix, which comes after the network prefix (such as qq in c4ex:qq...)"`
+	Workers  int    `long:"workers" description:"Number of workers that search for a vanity address in parallel (default: the number of CPUs)"`
 	config.NetworkFlags
 }
 
 func parseConfig() (*configFlags, error) {
-	cfg := &configFlags{}
+	cfg := &configFlags{
+		Path:    defaultPath,
+		Count:   1,
+		Format:  formatText,
+		Workers: runtime.NumCPU(),
+	}
 	parser := flags.NewParser(cfg, flags.PrintErrors|flags.HelpFlag)
 	_, err := parser.Parse()
 	if err != nil {
+		var flagsErr *flags.Error
+		if ok := errors.As(err, &flagsErr); ok && flagsErr.Type == flags.ErrHelp {
+			os.Exit(0)
+		}
 		return nil, err
 	}
 
@@ -22,5 +59,25 @@ func parseConfig() (*configFlags, error) {
 		return nil, err
 	}
 
+	if cfg.Count == 0 {
+		return nil, errors.New("--count must be at least 1")
+	}
+	if cfg.Workers < 1 {
+		return nil, errors.New("--workers must be at least 1")
+	}
+	isKnownFormat := false
+	for _, format := range outputFormats {
+		if cfg.Format == format {
+			isKnownFormat = true
+			break
+		}
+	}
+	if !isKnownFormat {
+		return nil, errors.Errorf("--format must be one of %s", strings.Join(outputFormats, ", "))
+	}
+	if cfg.Path != defaultPath && !cfg.Mnemonic {
+		return nil, errors.New("--path can only be used with --mnemonic")
+	}
+
 	return cfg, nil
 }
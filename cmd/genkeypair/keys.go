@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/hex"
+	"strings"
+	"sync"
+
+	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet"
+	"github.com/c4ei/c4exd/domain/dagconfig"
+	"github.com/c4ei/c4exd/util"
+	"github.com/pkg/errors"
+)
+
+// bech32Charset is the alphabet of the part of an address that comes after its network prefix
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// The leading bits of an address payload are the same for all the addresses of a key type: the
+// version byte, followed for ECDSA by the first 7 bits of the compressed public key, which are
+// 0000001. A vanity prefix that doesn't agree with them can never be found
+const (
+	schnorrFixedBits = "00000000"
+	ecdsaFixedBits   = "000000010000001"
+)
+
+type keyPair struct {
+	Mnemonic   string `json:"mnemonic,omitempty"`
+	Path       string `json:"path,omitempty"`
+	PrivateKey string `json:"privateKey"`
+	PublicKey  string `json:"publicKey"`
+	Address    string `json:"address"`
+}
+
+type keyGenerator struct {
+	params   *dagconfig.Params
+	ecdsa    bool
+	mnemonic bool
+	path     string
+}
+
+func (g *keyGenerator) generate() (*keyPair, error) {
+	var mnemonic, path string
+	var privateKey, publicKey []byte
+	var err error
+	if g.mnemonic {
+		mnemonic, err = libc4exwallet.CreateMnemonic()
+		if err != nil {
+			return nil, err
+		}
+		path = g.path
+		privateKey, publicKey, err = libc4exwallet.KeyPairFromMnemonic(g.params, mnemonic, path, g.ecdsa)
+	} else {
+		privateKey, publicKey, err = libc4exwallet.CreateKeyPair(g.ecdsa)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var address util.Address
+	if g.ecdsa {
+		address, err = util.NewAddressPublicKeyECDSA(publicKey, g.params.Prefix)
+	} else {
+		address, err = util.NewAddressPublicKey(publicKey, g.params.Prefix)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &keyPair{
+		Mnemonic:   mnemonic,
+		Path:       path,
+		PrivateKey: hex.EncodeToString(privateKey),
+		PublicKey:  hex.EncodeToString(publicKey),
+		Address:    address.EncodeAddress(),
+	}, nil
+}
+
+// generateKeyPairs generates key pairs with the given number of workers until it has count
+// key pairs whose addresses start with the vanity prefix. An empty vanity prefix matches any address
+func (g *keyGenerator) generateKeyPairs(count uint32, vanity string, workers int) ([]*keyPair, error) {
+	addressPrefix := g.params.Prefix.String() + ":" + vanity
+
+	results := make(chan *keyPair)
+	errs := make(chan error, workers)
+	stop := make(chan struct{})
+	wg := sync.WaitGroup{}
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+
+				keyPair, err := g.generate()
+				if err != nil {
+					errs <- err
+					return
+				}
+				if !strings.HasPrefix(keyPair.Address, addressPrefix) {
+					continue
+				}
+				select {
+				case results <- keyPair:
+				case <-stop:
+					return
+				}
+			}
+		}()
+	}
+	defer func() {
+		close(stop)
+		wg.Wait()
+	}()
+
+	keyPairs := make([]*keyPair, 0, count)
+	for uint32(len(keyPairs)) < count {
+		select {
+		case keyPair := <-results:
+			keyPairs = append(keyPairs, keyPair)
+		case err := <-errs:
+			return nil, err
+		}
+	}
+	return keyPairs, nil
+}
+
+// validateVanity returns the vanity prefix without the network prefix, if it was given with one,
+// or an error if no address of the key type can start with it
+func validateVanity(vanity string, params *dagconfig.Params, ecdsa bool) (string, error) {
+	vanity = strings.TrimPrefix(vanity, params.Prefix.String()+":")
+
+	fixedBits := schnorrFixedBits
+	if ecdsa {
+		fixedBits = ecdsaFixedBits
+	}
+	for i, char := range vanity {
+		value := strings.IndexRune(bech32Charset, char)
+		if value < 0 {
+			return "", errors.Errorf("vanity prefix %s has the character '%c', which can't appear in an address. "+
+				"The possible characters are %s", vanity, char, bech32Charset)
+		}
+		for bit := 0; bit < 5; bit++ {
+			position := i*5 + bit
+			if position >= len(fixedBits) {
+				break
+			}
+			if (value>>(4-bit))&1 != int(fixedBits[position]-'0') {
+				return "", errors.Errorf("no address of this key type starts with %s:%s",
+					params.Prefix, vanity[:i+1])
+			}
+		}
+	}
+	return vanity, nil
+}
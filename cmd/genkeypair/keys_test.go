@@ -0,0 +1,84 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet"
+	"github.com/c4ei/c4exd/domain/dagconfig"
+)
+
+func TestMnemonicKeyPairMatchesWallet(t *testing.T) {
+	params := &dagconfig.SimnetParams
+	for _, ecdsa := range []bool{false, true} {
+		generator := &keyGenerator{params: params, ecdsa: ecdsa, mnemonic: true, path: defaultPath}
+		keyPair, err := generator.generate()
+		if err != nil {
+			t.Fatalf("generate: %s", err)
+		}
+
+		// The default path is that of the first receive address of a wallet created from the mnemonic
+		masterPublicKey, err := libc4exwallet.MasterPublicKeyFromMnemonic(params, keyPair.Mnemonic, false)
+		if err != nil {
+			t.Fatalf("MasterPublicKeyFromMnemonic: %s", err)
+		}
+		walletAddress, err := libc4exwallet.Address(params, []string{masterPublicKey}, 1, "m/0/0", ecdsa)
+		if err != nil {
+			t.Fatalf("Address: %s", err)
+		}
+		if keyPair.Address != walletAddress.EncodeAddress() {
+			t.Fatalf("ecdsa=%t: expected address %s, got %s", ecdsa, walletAddress, keyPair.Address)
+		}
+	}
+}
+
+func TestVanity(t *testing.T) {
+	params := &dagconfig.SimnetParams
+	tests := []struct {
+		vanity         string
+		ecdsa          bool
+		expectedVanity string
+		expectsError   bool
+	}{
+		{vanity: "", expectedVanity: ""},
+		{vanity: "qr", expectedVanity: "qr"},
+		{vanity: "c4exsim:qz", expectedVanity: "qz"},
+		{vanity: "qy", expectsError: true},
+		{vanity: "p", expectsError: true},
+		{vanity: "qb", expectsError: true},
+		{vanity: "qyp9", ecdsa: true, expectedVanity: "qyp9"},
+		{vanity: "qypq", ecdsa: true, expectedVanity: "qypq"},
+		{vanity: "qq", ecdsa: true, expectsError: true},
+		{vanity: "qyq", ecdsa: true, expectsError: true},
+	}
+	for _, test := range tests {
+		vanity, err := validateVanity(test.vanity, params, test.ecdsa)
+		if test.expectsError {
+			if err == nil {
+				t.Errorf("%s (ecdsa=%t): expected an error", test.vanity, test.ecdsa)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s (ecdsa=%t): unexpected error: %s", test.vanity, test.ecdsa, err)
+			continue
+		}
+		if vanity != test.expectedVanity {
+			t.Errorf("%s (ecdsa=%t): expected %s, got %s", test.vanity, test.ecdsa, test.expectedVanity, vanity)
+		}
+	}
+
+	generator := &keyGenerator{params: params}
+	keyPairs, err := generator.generateKeyPairs(3, "qp", 2)
+	if err != nil {
+		t.Fatalf("generateKeyPairs: %s", err)
+	}
+	if len(keyPairs) != 3 {
+		t.Fatalf("Expected 3 key pairs, got %d", len(keyPairs))
+	}
+	for _, keyPair := range keyPairs {
+		if !strings.HasPrefix(keyPair.Address, "c4exsim:qp") {
+			t.Fatalf("Address %s doesn't start with the vanity prefix", keyPair.Address)
+		}
+	}
+}
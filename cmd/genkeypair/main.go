@@ -2,27 +2,40 @@ package main
 
 import (
 	"fmt"
+	"os"
 
-	"github.com/c4ei/c4exd/cmd/c4exwallet/libc4exwallet"
-	"github.com/c4ei/c4exd/util"
+	"github.com/pkg/errors"
 )
 
 func main() {
 	cfg, err := parseConfig()
 	if err != nil {
-		panic(err)
+		printErrorAndExit(errors.Errorf("Error parsing command-line arguments: %s", err))
 	}
 
-	privateKey, publicKey, err := libc4exwallet.CreateKeyPair(false)
+	vanity, err := validateVanity(cfg.Vanity, cfg.NetParams(), cfg.ECDSA)
 	if err != nil {
-		panic(err)
+		printErrorAndExit(err)
 	}
 
-	addr, err := util.NewAddressPublicKey(publicKey, cfg.NetParams().Prefix)
+	generator := &keyGenerator{
+		params:   cfg.NetParams(),
+		ecdsa:    cfg.ECDSA,
+		mnemonic: cfg.Mnemonic,
+		path:     cfg.Path,
+	}
+	keyPairs, err := generator.generateKeyPairs(cfg.Count, vanity, cfg.Workers)
 	if err != nil {
-		panic(err)
+		printErrorAndExit(err)
 	}
 
-	fmt.Printf("Private key: %x\n", privateKey)
-	fmt.Printf("Address: %s\n", addr)
+	err = printKeyPairs(os.Stdout, cfg.Format, keyPairs, cfg.Mnemonic)
+	if err != nil {
+		printErrorAndExit(err)
+	}
+}
+
+func printErrorAndExit(err error) {
+	fmt.Fprintf(os.Stderr, "%s\n", err)
+	os.Exit(1)
 }
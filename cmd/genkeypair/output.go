@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+func printKeyPairs(writer io.Writer, format string, keyPairs []*keyPair, withMnemonic bool) error {
+	switch format {
+	case formatJSON:
+		keyPairsJSON, err := json.MarshalIndent(keyPairs, "", "    ")
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		_, err = fmt.Fprintf(writer, "%s\n", keyPairsJSON)
+		return err
+	case formatCSV:
+		csvWriter := csv.NewWriter(writer)
+		header := []string{"privateKey", "publicKey", "address"}
+		if withMnemonic {
+			header = append([]string{"mnemonic", "path"}, header...)
+		}
+		err := csvWriter.Write(header)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		for _, keyPair := range keyPairs {
+			record := []string{keyPair.PrivateKey, keyPair.PublicKey, keyPair.Address}
+			if withMnemonic {
+				record = append([]string{keyPair.Mnemonic, keyPair.Path}, record...)
+			}
+			err := csvWriter.Write(record)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		csvWriter.Flush()
+		return errors.WithStack(csvWriter.Error())
+	default:
+		for i, keyPair := range keyPairs {
+			if i > 0 {
+				fmt.Fprintln(writer)
+			}
+			if withMnemonic {
+				fmt.Fprintf(writer, "Mnemonic: %s\n", keyPair.Mnemonic)
+				fmt.Fprintf(writer, "Path: %s\n", keyPair.Path)
+			}
+			fmt.Fprintf(writer, "Private key: %s\n", keyPair.PrivateKey)
+			fmt.Fprintf(writer, "Address: %s\n", keyPair.Address)
+		}
+		return nil
+	}
+}
@@ -42,6 +42,7 @@ type consensus struct {
 	reachabilityManager   model.ReachabilityManager
 	finalityManager       model.FinalityManager
 	pruningProofManager   model.PruningProofManager
+	deploymentManager     model.DeploymentManager
 
 	acceptanceDataStore                 model.AcceptanceDataStore
 	blockStore                          model.BlockStore
@@ -149,6 +150,93 @@ func (s *consensus) Init(skipAddingGenesis bool) error {
 		}
 	}
 
+	return s.computeMissingDeploymentStates()
+}
+
+// deploymentStatesBatchSize is the number of selected chain blocks whose deployment states
+// computeMissingDeploymentStates computes in every committed batch
+const deploymentStatesBatchSize = 1000
+
+// computeMissingDeploymentStates computes the deployment states of the headers selected chain
+// if the headers selected tip doesn't have them, such as after a deployment was added. Without
+// it, validating the next header would compute the states of the whole chain at once, within
+// a single staging area. The computation starts from the pruning point if its states are known,
+// or from the start of the chain otherwise.
+func (s *consensus) computeMissingDeploymentStates() error {
+	stagingArea := model.NewStagingArea()
+	hasHeadersSelectedTip, err := s.headersSelectedTipStore.Has(s.databaseContext, stagingArea)
+	if err != nil {
+		return err
+	}
+	if !hasHeadersSelectedTip {
+		return nil
+	}
+	headersSelectedTip, err := s.headersSelectedTipStore.HeadersSelectedTip(s.databaseContext, stagingArea)
+	if err != nil {
+		return err
+	}
+	hasStates, err := s.deploymentManager.HasDeploymentStates(stagingArea, headersSelectedTip)
+	if err != nil {
+		return err
+	}
+	if hasStates {
+		return nil
+	}
+
+	headersSelectedTipIndex, err := s.headersSelectedChainStore.GetIndexByHash(s.databaseContext, stagingArea,
+		headersSelectedTip)
+	if err != nil {
+		return err
+	}
+	startIndex := uint64(0)
+	hasPruningPoint, err := s.pruningStore.HasPruningPoint(s.databaseContext, stagingArea)
+	if err != nil {
+		return err
+	}
+	if hasPruningPoint {
+		pruningPoint, err := s.pruningStore.PruningPoint(s.databaseContext, stagingArea)
+		if err != nil {
+			return err
+		}
+		hasPruningPointStates, err := s.deploymentManager.HasDeploymentStates(stagingArea, pruningPoint)
+		if err != nil {
+			return err
+		}
+		if hasPruningPointStates {
+			startIndex, err = s.headersSelectedChainStore.GetIndexByHash(s.databaseContext, stagingArea, pruningPoint)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	log.Infof("Computing the deployment states of %d selected chain blocks", headersSelectedTipIndex-startIndex+1)
+	for batchStart := startIndex; batchStart <= headersSelectedTipIndex; batchStart += deploymentStatesBatchSize {
+		batchEnd := batchStart + deploymentStatesBatchSize - 1
+		if batchEnd > headersSelectedTipIndex {
+			batchEnd = headersSelectedTipIndex
+		}
+
+		// Every block's selected parent is the previous chain block, whose states are already
+		// known, so every block is computed in a single step
+		stagingArea := model.NewStagingArea()
+		for index := batchStart; index <= batchEnd; index++ {
+			blockHash, err := s.headersSelectedChainStore.GetHashByIndex(s.databaseContext, stagingArea, index)
+			if err != nil {
+				return err
+			}
+			_, err = s.deploymentManager.DeploymentStates(stagingArea, blockHash)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = staging.CommitAllChanges(s.databaseContext, stagingArea)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -623,6 +711,24 @@ func (s *consensus) PruningPoint() (*externalapi.DomainHash, error) {
 	return s.pruningStore.PruningPoint(s.databaseContext, stagingArea)
 }
 
+func (s *consensus) EarliestAvailableBlock() (*externalapi.DomainHash, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	stagingArea := model.NewStagingArea()
+
+	return s.pruningManager.EarliestAvailableBlock(stagingArea)
+}
+
+func (s *consensus) GetVirtualDeploymentStates() ([]externalapi.DeploymentState, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	stagingArea := model.NewStagingArea()
+
+	return s.deploymentManager.DeploymentStates(stagingArea, model.VirtualBlockHash)
+}
+
 func (s *consensus) PruningPointHeaders() ([]externalapi.BlockHeader, error) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
@@ -1087,6 +1193,14 @@ func (s *consensus) TrustedGHOSTDAGData(blockHash *externalapi.DomainHash) (*ext
 	return ghostdagData, nil
 }
 
+func (s *consensus) TrustedDeploymentStates(blockHash *externalapi.DomainHash) ([]externalapi.DeploymentState, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	stagingArea := model.NewStagingArea()
+	return s.deploymentManager.DeploymentStates(stagingArea, blockHash)
+}
+
 func (s *consensus) IsChainBlock(blockHash *externalapi.DomainHash) (bool, error) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
@@ -0,0 +1,46 @@
+package deploymentstatestore
+
+import (
+	"github.com/c4ei/c4exd/domain/consensus/model"
+	"github.com/c4ei/c4exd/domain/consensus/model/externalapi"
+)
+
+type deploymentStateStagingShard struct {
+	store    *deploymentStateStore
+	toAdd    map[externalapi.DomainHash][]externalapi.DeploymentState
+	toDelete map[externalapi.DomainHash]struct{}
+}
+
+func (dss *deploymentStateStore) stagingShard(stagingArea *model.StagingArea) *deploymentStateStagingShard {
+	return stagingArea.GetOrCreateShard(dss.shardID, func() model.StagingShard {
+		return &deploymentStateStagingShard{
+			store:    dss,
+			toAdd:    make(map[externalapi.DomainHash][]externalapi.DeploymentState),
+			toDelete: make(map[externalapi.DomainHash]struct{}),
+		}
+	}).(*deploymentStateStagingShard)
+}
+
+func (dsss *deploymentStateStagingShard) Commit(dbTx model.DBTransaction) error {
+	for hash, states := range dsss.toAdd {
+		err := dbTx.Put(dsss.store.hashAsKey(&hash), serializeDeploymentStates(states))
+		if err != nil {
+			return err
+		}
+		dsss.store.cache.Add(&hash, states)
+	}
+
+	for hash := range dsss.toDelete {
+		err := dbTx.Delete(dsss.store.hashAsKey(&hash))
+		if err != nil {
+			return err
+		}
+		dsss.store.cache.Remove(&hash)
+	}
+
+	return nil
+}
+
+func (dsss *deploymentStateStagingShard) isStaged() bool {
+	return len(dsss.toAdd) != 0 || len(dsss.toDelete) != 0
+}
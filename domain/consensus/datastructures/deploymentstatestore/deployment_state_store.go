@@ -0,0 +1,123 @@
+package deploymentstatestore
+
+import (
+	"github.com/c4ei/c4exd/domain/consensus/model"
+	"github.com/c4ei/c4exd/domain/consensus/model/externalapi"
+	"github.com/c4ei/c4exd/domain/consensus/utils/lrucache"
+	"github.com/c4ei/c4exd/infrastructure/db/database"
+	"github.com/c4ei/c4exd/util/staging"
+	"github.com/pkg/errors"
+)
+
+var bucketName = []byte("deployment-states")
+
+type deploymentStateStore struct {
+	shardID model.StagingShardID
+	cache   *lrucache.LRUCache
+	bucket  model.DBBucket
+}
+
+// New instantiates a new DeploymentStateStore
+func New(prefixBucket model.DBBucket, cacheSize int, preallocate bool) model.DeploymentStateStore {
+	return &deploymentStateStore{
+		shardID: staging.GenerateShardingID(),
+		cache:   lrucache.New(cacheSize, preallocate),
+		bucket:  prefixBucket.Bucket(bucketName),
+	}
+}
+
+// Stage stages the given deployment states for the given blockHash
+func (dss *deploymentStateStore) Stage(stagingArea *model.StagingArea, blockHash *externalapi.DomainHash,
+	states []externalapi.DeploymentState) {
+
+	stagingShard := dss.stagingShard(stagingArea)
+
+	stagingShard.toAdd[*blockHash] = states
+	delete(stagingShard.toDelete, *blockHash)
+}
+
+// Delete deletes the deployment states of the given blockHash
+func (dss *deploymentStateStore) Delete(stagingArea *model.StagingArea, blockHash *externalapi.DomainHash) {
+	stagingShard := dss.stagingShard(stagingArea)
+
+	if _, ok := stagingShard.toAdd[*blockHash]; ok {
+		delete(stagingShard.toAdd, *blockHash)
+		return
+	}
+	stagingShard.toDelete[*blockHash] = struct{}{}
+}
+
+func (dss *deploymentStateStore) IsStaged(stagingArea *model.StagingArea) bool {
+	return dss.stagingShard(stagingArea).isStaged()
+}
+
+// DeploymentStates gets the deployment states associated with the given blockHash
+func (dss *deploymentStateStore) DeploymentStates(dbContext model.DBReader, stagingArea *model.StagingArea,
+	blockHash *externalapi.DomainHash) ([]externalapi.DeploymentState, error) {
+
+	stagingShard := dss.stagingShard(stagingArea)
+
+	if _, ok := stagingShard.toDelete[*blockHash]; ok {
+		return nil, errors.Wrapf(database.ErrNotFound, "deployment states of %s not found", blockHash)
+	}
+
+	if states, ok := stagingShard.toAdd[*blockHash]; ok {
+		return states, nil
+	}
+
+	if states, ok := dss.cache.Get(blockHash); ok {
+		return states.([]externalapi.DeploymentState), nil
+	}
+
+	statesBytes, err := dbContext.Get(dss.hashAsKey(blockHash))
+	if err != nil {
+		return nil, err
+	}
+	states := deserializeDeploymentStates(statesBytes)
+
+	dss.cache.Add(blockHash, states)
+	return states, nil
+}
+
+// Has returns whether deployment states are stored for the given blockHash
+func (dss *deploymentStateStore) Has(dbContext model.DBReader, stagingArea *model.StagingArea,
+	blockHash *externalapi.DomainHash) (bool, error) {
+
+	stagingShard := dss.stagingShard(stagingArea)
+
+	if _, ok := stagingShard.toDelete[*blockHash]; ok {
+		return false, nil
+	}
+
+	if _, ok := stagingShard.toAdd[*blockHash]; ok {
+		return true, nil
+	}
+
+	if dss.cache.Has(blockHash) {
+		return true, nil
+	}
+
+	return dbContext.Has(dss.hashAsKey(blockHash))
+}
+
+func (dss *deploymentStateStore) hashAsKey(hash *externalapi.DomainHash) model.DBKey {
+	return dss.bucket.Key(hash.ByteSlice())
+}
+
+// The states are serialized as one byte per deployment, in the order of the deployments
+// in the network parameters
+func serializeDeploymentStates(states []externalapi.DeploymentState) []byte {
+	statesBytes := make([]byte, len(states))
+	for i, state := range states {
+		statesBytes[i] = byte(state)
+	}
+	return statesBytes
+}
+
+func deserializeDeploymentStates(statesBytes []byte) []externalapi.DeploymentState {
+	states := make([]externalapi.DeploymentState, len(statesBytes))
+	for i, stateByte := range statesBytes {
+		states[i] = externalapi.DeploymentState(stateByte)
+	}
+	return states
+}
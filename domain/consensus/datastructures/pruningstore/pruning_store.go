@@ -19,6 +19,7 @@ var candidatePruningPointHashKeyName = []byte("candidate-pruning-point-hash")
 var pruningPointUTXOSetBucketName = []byte("pruning-point-utxo-set")
 var updatingPruningPointUTXOSetKeyName = []byte("updating-pruning-point-utxo-set")
 var pruningPointByIndexBucketName = []byte("pruning-point-by-index")
+var retentionPointHashKeyName = []byte("retention-point-hash")
 
 // pruningStore represents a store for the current pruning state
 type pruningStore struct {
@@ -26,6 +27,7 @@ type pruningStore struct {
 	pruningPointByIndexCache      *lrucacheuint64tohash.LRUCache
 	currentPruningPointIndexCache *uint64
 	pruningPointCandidateCache    *externalapi.DomainHash
+	retentionPointCache           *externalapi.DomainHash
 
 	currentPruningPointIndexKey     model.DBKey
 	candidatePruningPointHashKey    model.DBKey
@@ -34,6 +36,7 @@ type pruningStore struct {
 	importedPruningPointUTXOsBucket model.DBBucket
 	importedPruningPointMultisetKey model.DBKey
 	pruningPointByIndexBucket       model.DBBucket
+	retentionPointHashKey           model.DBKey
 }
 
 // New instantiates a new PruningStore
@@ -48,6 +51,7 @@ func New(prefixBucket model.DBBucket, cacheSize int, preallocate bool) model.Pru
 		updatingPruningPointUTXOSetKey:  prefixBucket.Key(updatingPruningPointUTXOSetKeyName),
 		importedPruningPointMultisetKey: prefixBucket.Key(importedPruningPointMultisetKeyName),
 		pruningPointByIndexBucket:       prefixBucket.Bucket(pruningPointByIndexBucketName),
+		retentionPointHashKey:           prefixBucket.Key(retentionPointHashKeyName),
 	}
 }
 
@@ -95,6 +99,52 @@ func (ps *pruningStore) HasPruningPointCandidate(dbContext model.DBReader, stagi
 	return dbContext.Has(ps.candidatePruningPointHashKey)
 }
 
+func (ps *pruningStore) StageRetentionPoint(stagingArea *model.StagingArea, retentionPoint *externalapi.DomainHash) {
+	stagingShard := ps.stagingShard(stagingArea)
+
+	stagingShard.newRetentionPoint = retentionPoint
+}
+
+// RetentionPoint gets the earliest selected chain block below the pruning point
+// whose block data is still retained
+func (ps *pruningStore) RetentionPoint(dbContext model.DBReader, stagingArea *model.StagingArea) (*externalapi.DomainHash, error) {
+	stagingShard := ps.stagingShard(stagingArea)
+
+	if stagingShard.newRetentionPoint != nil {
+		return stagingShard.newRetentionPoint, nil
+	}
+
+	if ps.retentionPointCache != nil {
+		return ps.retentionPointCache, nil
+	}
+
+	retentionPointBytes, err := dbContext.Get(ps.retentionPointHashKey)
+	if err != nil {
+		return nil, err
+	}
+
+	retentionPoint, err := ps.deserializePruningPoint(retentionPointBytes)
+	if err != nil {
+		return nil, err
+	}
+	ps.retentionPointCache = retentionPoint
+	return retentionPoint, nil
+}
+
+func (ps *pruningStore) HasRetentionPoint(dbContext model.DBReader, stagingArea *model.StagingArea) (bool, error) {
+	stagingShard := ps.stagingShard(stagingArea)
+
+	if stagingShard.newRetentionPoint != nil {
+		return true, nil
+	}
+
+	if ps.retentionPointCache != nil {
+		return true, nil
+	}
+
+	return dbContext.Has(ps.retentionPointHashKey)
+}
+
 // StagePruningPoint stages the pruning state
 func (ps *pruningStore) StagePruningPoint(dbContext model.DBWriter, stagingArea *model.StagingArea, pruningPointBlockHash *externalapi.DomainHash) error {
 	newPruningPointIndex := uint64(0)
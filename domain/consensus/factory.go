@@ -26,6 +26,7 @@ import (
 	"github.com/c4ei/c4exd/domain/consensus/datastructures/blockstore"
 	"github.com/c4ei/c4exd/domain/consensus/datastructures/consensusstatestore"
 	"github.com/c4ei/c4exd/domain/consensus/datastructures/daablocksstore"
+	"github.com/c4ei/c4exd/domain/consensus/datastructures/deploymentstatestore"
 	"github.com/c4ei/c4exd/domain/consensus/datastructures/finalitystore"
 	"github.com/c4ei/c4exd/domain/consensus/datastructures/ghostdagdatastore"
 	"github.com/c4ei/c4exd/domain/consensus/datastructures/headersselectedchainstore"
@@ -43,6 +44,7 @@ import (
 	"github.com/c4ei/c4exd/domain/consensus/processes/consensusstatemanager"
 	"github.com/c4ei/c4exd/domain/consensus/processes/dagtopologymanager"
 	"github.com/c4ei/c4exd/domain/consensus/processes/dagtraversalmanager"
+	"github.com/c4ei/c4exd/domain/consensus/processes/deploymentmanager"
 	"github.com/c4ei/c4exd/domain/consensus/processes/difficultymanager"
 	"github.com/c4ei/c4exd/domain/consensus/processes/finalitymanager"
 	"github.com/c4ei/c4exd/domain/consensus/processes/ghostdagmanager"
@@ -69,6 +71,9 @@ type Config struct {
 	dagconfig.Params
 	// IsArchival tells the consensus if it should not prune old blocks
 	IsArchival bool
+	// RetentionDepth is the DAA score distance below the pruning point up to which
+	// a non-archival node keeps block bodies and acceptance data
+	RetentionDepth uint64
 	// EnableSanityCheckPruningUTXOSet checks the full pruning point utxo set against the commitment at every pruning movement
 	EnableSanityCheckPruningUTXOSet bool
 
@@ -151,6 +156,7 @@ func (f *factory) NewConsensus(config *Config, db infrastructuredatabase.Databas
 
 	headersSelectedTipStore := headersselectedtipstore.New(prefixBucket)
 	finalityStore := finalitystore.New(prefixBucket, 200, preallocateCaches)
+	deploymentStateStore := deploymentstatestore.New(prefixBucket, 10_000, preallocateCaches)
 	headersSelectedChainStore := headersselectedchainstore.New(prefixBucket, pruningWindowSizeForCaches, preallocateCaches)
 	daaBlocksStore := daablocksstore.New(prefixBucket, pruningWindowSizeForCaches, int(config.FinalityDepth()), preallocateCaches)
 	windowHeapSliceStore := blockwindowheapslicestore.New(2000, preallocateCaches)
@@ -213,6 +219,19 @@ func (f *factory) NewConsensus(config *Config, db infrastructuredatabase.Databas
 		blockHeaderStore,
 		ghostdagDataStore,
 		config.GenesisHash)
+	deploymentManager := deploymentmanager.New(
+		dbManager,
+		config.GenesisHash,
+
+		ghostdagDataStore,
+		blockHeaderStore,
+		daaBlocksStore,
+		deploymentStateStore,
+
+		config.Deployments,
+		config.RuleChangeActivationThreshold,
+		config.MinerConfirmationWindow,
+	)
 	transactionValidator := transactionvalidator.New(config.BlockCoinbaseMaturity,
 		config.EnableNonNativeSubnetworks,
 		config.MaxCoinbasePayloadLength,
@@ -222,6 +241,7 @@ func (f *factory) NewConsensus(config *Config, db infrastructuredatabase.Databas
 		pastMedianTimeManager,
 		ghostdagDataStore,
 		daaBlocksStore,
+		deploymentManager,
 		txMassCalculator)
 	difficultyManager := f.difficultyConstructor(
 		dbManager,
@@ -246,6 +266,7 @@ func (f *factory) NewConsensus(config *Config, db infrastructuredatabase.Databas
 		config.GenesisHash,
 		config.DeflationaryPhaseDaaScore,
 		config.DeflationaryPhaseBaseSubsidy,
+		config.PayoutSplitActivationBlueScore,
 
 		dagTraversalManager,
 		ghostdagDataStore,
@@ -329,8 +350,10 @@ func (f *factory) NewConsensus(config *Config, db infrastructuredatabase.Databas
 		daaBlocksStore,
 		reachabilityDataStore,
 		daaWindowStore,
+		deploymentStateStore,
 
 		config.IsArchival,
+		config.RetentionDepth,
 		genesisHash,
 		config.FinalityDepth(),
 		config.PruningDepth(),
@@ -350,6 +373,7 @@ func (f *factory) NewConsensus(config *Config, db infrastructuredatabase.Databas
 		config.TimestampDeviationTolerance,
 		config.TargetTimePerBlock,
 		config.MaxBlockLevel,
+		config.Checkpoints,
 
 		dbManager,
 		difficultyManager,
@@ -365,6 +389,7 @@ func (f *factory) NewConsensus(config *Config, db infrastructuredatabase.Databas
 		blockParentBuilder,
 		pruningManager,
 		parentsManager,
+		deploymentManager,
 
 		pruningStore,
 		blockStore,
@@ -407,6 +432,7 @@ func (f *factory) NewConsensus(config *Config, db infrastructuredatabase.Databas
 		finalityManager,
 		blockParentBuilder,
 		pruningManager,
+		deploymentManager,
 
 		acceptanceDataStore,
 		blockRelationStore,
@@ -430,6 +456,7 @@ func (f *factory) NewConsensus(config *Config, db infrastructuredatabase.Databas
 		coinbaseManager,
 		headerTipsManager,
 		syncManager,
+		deploymentManager,
 
 		acceptanceDataStore,
 		blockStore,
@@ -470,6 +497,7 @@ func (f *factory) NewConsensus(config *Config, db infrastructuredatabase.Databas
 		config.K,
 		config.PruningProofM,
 		config.MaxBlockLevel,
+		config.Checkpoints,
 	)
 
 	c := &consensus{
@@ -500,6 +528,7 @@ func (f *factory) NewConsensus(config *Config, db infrastructuredatabase.Databas
 		reachabilityManager:   reachabilityManager,
 		finalityManager:       finalityManager,
 		pruningProofManager:   pruningProofManager,
+		deploymentManager:     deploymentManager,
 
 		acceptanceDataStore:                 acceptanceDataStore,
 		blockStore:                          blockStore,
@@ -5,9 +5,10 @@ package externalapi
 // This is used when bring the pruning point and its
 // anticone on a pruned-headers node.
 type BlockWithTrustedData struct {
-	Block        *DomainBlock
-	DAAWindow    []*TrustedDataDataDAAHeader
-	GHOSTDAGData []*BlockGHOSTDAGDataHashPair
+	Block            *DomainBlock
+	DAAWindow        []*TrustedDataDataDAAHeader
+	GHOSTDAGData     []*BlockGHOSTDAGDataHashPair
+	DeploymentStates []DeploymentState
 }
 
 // TrustedDataDataDAAHeader is a block that belongs to BlockWithTrustedData.DAAWindow
@@ -0,0 +1,42 @@
+package externalapi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Checkpoint is a known-good selected chain block, identified by its
+// hash and DAA score. Any chain that reaches the checkpoint's DAA score
+// without passing through the checkpoint block is rejected.
+type Checkpoint struct {
+	Hash     *DomainHash
+	DAAScore uint64
+}
+
+// String returns the checkpoint in its <hash>:<daa score> form
+func (c Checkpoint) String() string {
+	return fmt.Sprintf("%s:%d", c.Hash, c.DAAScore)
+}
+
+// ParseCheckpoint parses a checkpoint in the <hash>:<daa score> form
+func ParseCheckpoint(checkpointString string) (Checkpoint, error) {
+	parts := strings.Split(checkpointString, ":")
+	if len(parts) != 2 {
+		return Checkpoint{}, errors.Errorf("checkpoint %s is not in the <hash>:<daa score> form", checkpointString)
+	}
+
+	hash, err := NewDomainHashFromString(parts[0])
+	if err != nil {
+		return Checkpoint{}, errors.Wrapf(err, "invalid checkpoint hash %s", parts[0])
+	}
+
+	daaScore, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return Checkpoint{}, errors.Wrapf(err, "invalid checkpoint DAA score %s", parts[1])
+	}
+
+	return Checkpoint{Hash: hash, DAAScore: daaScore}, nil
+}
@@ -2,11 +2,25 @@ package externalapi
 
 import "bytes"
 
+// CoinbasePayoutWeightDenominator is the denominator of the weights of coinbase payouts:
+// a payout of weight w gets w/CoinbasePayoutWeightDenominator of the rewards of a block
+const CoinbasePayoutWeightDenominator = 10000
+
 // DomainCoinbaseData contains data by which a coinbase transaction
 // is built
 type DomainCoinbaseData struct {
 	ScriptPublicKey *ScriptPublicKey
 	ExtraData       []byte
+
+	// Payouts are the payees the rewards are split with. ScriptPublicKey
+	// gets what the payouts leave.
+	Payouts []*DomainCoinbasePayout
+}
+
+// DomainCoinbasePayout is a payee the rewards of a block are split with
+type DomainCoinbasePayout struct {
+	ScriptPublicKey *ScriptPublicKey
+	Weight          uint16
 }
 
 // Clone returns a clone of DomainCoinbaseData
@@ -18,9 +32,18 @@ func (dcd *DomainCoinbaseData) Clone() *DomainCoinbaseData {
 	extraDataClone := make([]byte, len(dcd.ExtraData))
 	copy(extraDataClone, dcd.ExtraData)
 
+	var payoutsClone []*DomainCoinbasePayout
+	if dcd.Payouts != nil {
+		payoutsClone = make([]*DomainCoinbasePayout, len(dcd.Payouts))
+		for i, payout := range dcd.Payouts {
+			payoutsClone[i] = payout.Clone()
+		}
+	}
+
 	return &DomainCoinbaseData{
 		ScriptPublicKey: &ScriptPublicKey{Script: scriptPubKeyClone, Version: dcd.ScriptPublicKey.Version},
 		ExtraData:       extraDataClone,
+		Payouts:         payoutsClone,
 	}
 }
 
@@ -34,5 +57,34 @@ func (dcd *DomainCoinbaseData) Equal(other *DomainCoinbaseData) bool {
 		return false
 	}
 
+	if len(dcd.Payouts) != len(other.Payouts) {
+		return false
+	}
+	for i, payout := range dcd.Payouts {
+		if !payout.Equal(other.Payouts[i]) {
+			return false
+		}
+	}
+
 	return dcd.ScriptPublicKey.Equal(other.ScriptPublicKey)
 }
+
+// Clone returns a clone of DomainCoinbasePayout
+func (dcp *DomainCoinbasePayout) Clone() *DomainCoinbasePayout {
+	scriptPubKeyClone := make([]byte, len(dcp.ScriptPublicKey.Script))
+	copy(scriptPubKeyClone, dcp.ScriptPublicKey.Script)
+
+	return &DomainCoinbasePayout{
+		ScriptPublicKey: &ScriptPublicKey{Script: scriptPubKeyClone, Version: dcp.ScriptPublicKey.Version},
+		Weight:          dcp.Weight,
+	}
+}
+
+// Equal returns whether dcp equals to other
+func (dcp *DomainCoinbasePayout) Equal(other *DomainCoinbasePayout) bool {
+	if dcp == nil || other == nil {
+		return dcp == other
+	}
+
+	return dcp.Weight == other.Weight && dcp.ScriptPublicKey.Equal(other.ScriptPublicKey)
+}
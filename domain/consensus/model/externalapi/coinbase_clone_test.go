@@ -11,6 +11,7 @@ func initTestCoinbaseDataStructsForClone() []*DomainCoinbaseData {
 		{
 			&ScriptPublicKey{Script: []byte{1, 2, 3, 4, 5, 6}, Version: 0},
 			[]byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF},
+			nil,
 		}, {
 			&ScriptPublicKey{Script: []byte{0, 0, 0, 0, 55}, Version: 0},
 			[]byte{0xFF, 0xFF, 0xFF, 0xFF,
@@ -42,6 +43,14 @@ func initTestCoinbaseDataStructsForClone() []*DomainCoinbaseData {
 				0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF,
 				0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF,
 				0xFF, 0xFF, 0xFF},
+			nil,
+		}, {
+			&ScriptPublicKey{Script: []byte{1, 2, 3}, Version: 0},
+			[]byte{0xFF},
+			[]*DomainCoinbasePayout{
+				{&ScriptPublicKey{Script: []byte{4, 5, 6}, Version: 0}, 2500},
+				{&ScriptPublicKey{Script: []byte{7, 8}, Version: 1}, 1},
+			},
 		},
 	}
 	return tests
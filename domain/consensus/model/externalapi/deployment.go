@@ -0,0 +1,58 @@
+package externalapi
+
+// ConsensusDeployment defines a consensus rule change that is activated once
+// enough chain blocks signal for it in their header versions
+type ConsensusDeployment struct {
+	// Name is a human-readable identifier of the deployment
+	Name string
+
+	// BitNumber is the bit of the block version that signals for the deployment
+	BitNumber uint8
+
+	// StartDAAScore is the DAA score from which signals for the deployment are counted
+	StartDAAScore uint64
+
+	// TimeoutDAAScore is the DAA score at which the deployment fails if it wasn't locked in
+	TimeoutDAAScore uint64
+
+	// ScriptFlags are the script engine flags that are enforced once the deployment is active
+	ScriptFlags uint32
+}
+
+// DeploymentState is the state of a consensus deployment in a given block
+type DeploymentState uint8
+
+const (
+	// DeploymentStateDefined is the first state of every deployment,
+	// in which signals for it are not yet counted
+	DeploymentStateDefined DeploymentState = iota
+
+	// DeploymentStateStarted is the state in which signals for the deployment are counted
+	DeploymentStateStarted
+
+	// DeploymentStateLockedIn is the state of a deployment for one window after enough signals
+	// were counted for it
+	DeploymentStateLockedIn
+
+	// DeploymentStateActive is the state in which the rules of the deployment are enforced
+	DeploymentStateActive
+
+	// DeploymentStateFailed is the state of a deployment that timed out before locking in
+	DeploymentStateFailed
+)
+
+var deploymentStateStrings = map[DeploymentState]string{
+	DeploymentStateDefined:  "defined",
+	DeploymentStateStarted:  "started",
+	DeploymentStateLockedIn: "locked-in",
+	DeploymentStateActive:   "active",
+	DeploymentStateFailed:   "failed",
+}
+
+func (s DeploymentState) String() string {
+	stateString, ok := deploymentStateStrings[s]
+	if !ok {
+		return "unknown"
+	}
+	return stateString
+}
@@ -0,0 +1,16 @@
+package model
+
+import (
+	"github.com/c4ei/c4exd/domain/consensus/model/externalapi"
+)
+
+// DeploymentStateStore represents a store for the states of the consensus deployments
+// in each chain block
+type DeploymentStateStore interface {
+	Store
+	IsStaged(stagingArea *StagingArea) bool
+	Stage(stagingArea *StagingArea, blockHash *externalapi.DomainHash, states []externalapi.DeploymentState)
+	DeploymentStates(dbContext DBReader, stagingArea *StagingArea, blockHash *externalapi.DomainHash) ([]externalapi.DeploymentState, error)
+	Has(dbContext DBReader, stagingArea *StagingArea, blockHash *externalapi.DomainHash) (bool, error)
+	Delete(stagingArea *StagingArea, blockHash *externalapi.DomainHash)
+}
@@ -12,6 +12,9 @@ type PruningStore interface {
 	IsStaged(stagingArea *StagingArea) bool
 	PruningPointCandidate(dbContext DBReader, stagingArea *StagingArea) (*externalapi.DomainHash, error)
 	HasPruningPointCandidate(dbContext DBReader, stagingArea *StagingArea) (bool, error)
+	StageRetentionPoint(stagingArea *StagingArea, retentionPoint *externalapi.DomainHash)
+	RetentionPoint(dbContext DBReader, stagingArea *StagingArea) (*externalapi.DomainHash, error)
+	HasRetentionPoint(dbContext DBReader, stagingArea *StagingArea) (bool, error)
 	PruningPoint(dbContext DBReader, stagingArea *StagingArea) (*externalapi.DomainHash, error)
 	HasPruningPoint(dbContext DBReader, stagingArea *StagingArea) (bool, error)
 	CurrentPruningPointIndex(dbContext DBReader, stagingArea *StagingArea) (uint64, error)
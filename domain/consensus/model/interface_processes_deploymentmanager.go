@@ -0,0 +1,15 @@
+package model
+
+import "github.com/c4ei/c4exd/domain/consensus/model/externalapi"
+
+// DeploymentManager computes the states of the consensus deployments
+type DeploymentManager interface {
+	DeploymentStates(stagingArea *StagingArea, blockHash *externalapi.DomainHash) ([]externalapi.DeploymentState, error)
+	HasDeploymentStates(stagingArea *StagingArea, blockHash *externalapi.DomainHash) (bool, error)
+	StageTrustedDeploymentStates(stagingArea *StagingArea, blockHash *externalapi.DomainHash,
+		states []externalapi.DeploymentState) error
+	IsDeploymentActive(stagingArea *StagingArea, blockHash *externalapi.DomainHash, deploymentName string) (bool, error)
+	ActiveDeployments(stagingArea *StagingArea, blockHash *externalapi.DomainHash) ([]*externalapi.ConsensusDeployment, error)
+	BlockVersion(stagingArea *StagingArea, blockHash *externalapi.DomainHash) (uint16, error)
+	IsSignalingVersionAllowed(version uint16, daaScore uint64) bool
+}
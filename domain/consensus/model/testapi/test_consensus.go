@@ -78,6 +78,7 @@ type TestConsensus interface {
 	CoinbaseManager() model.CoinbaseManager
 	ConsensusStateManager() TestConsensusStateManager
 	FinalityManager() model.FinalityManager
+	DeploymentManager() model.DeploymentManager
 	DAGTopologyManager() model.DAGTopologyManager
 	DAGTraversalManager() model.DAGTraversalManager
 	DifficultyManager() model.DifficultyManager
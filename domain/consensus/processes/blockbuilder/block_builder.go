@@ -11,7 +11,6 @@ import (
 	"github.com/c4ei/c4exd/domain/consensus/model"
 	"github.com/c4ei/c4exd/domain/consensus/model/externalapi"
 	"github.com/c4ei/c4exd/domain/consensus/utils/consensushashing"
-	"github.com/c4ei/c4exd/domain/consensus/utils/constants"
 	"github.com/c4ei/c4exd/domain/consensus/utils/merkle"
 	"github.com/c4ei/c4exd/infrastructure/logger"
 	"github.com/c4ei/c4exd/util/mstime"
@@ -30,6 +29,7 @@ type blockBuilder struct {
 	finalityManager       model.FinalityManager
 	pruningManager        model.PruningManager
 	blockParentBuilder    model.BlockParentBuilder
+	deploymentManager     model.DeploymentManager
 
 	acceptanceDataStore model.AcceptanceDataStore
 	blockRelationStore  model.BlockRelationStore
@@ -52,6 +52,7 @@ func New(
 	finalityManager model.FinalityManager,
 	blockParentBuilder model.BlockParentBuilder,
 	pruningManager model.PruningManager,
+	deploymentManager model.DeploymentManager,
 
 	acceptanceDataStore model.AcceptanceDataStore,
 	blockRelationStore model.BlockRelationStore,
@@ -73,6 +74,7 @@ func New(
 		finalityManager:       finalityManager,
 		blockParentBuilder:    blockParentBuilder,
 		pruningManager:        pruningManager,
+		deploymentManager:     deploymentManager,
 
 		acceptanceDataStore: acceptanceDataStore,
 		blockRelationStore:  blockRelationStore,
@@ -223,9 +225,13 @@ func (bb *blockBuilder) buildHeader(stagingArea *model.StagingArea, transactions
 	if err != nil {
 		return nil, err
 	}
+	version, err := bb.deploymentManager.BlockVersion(stagingArea, model.VirtualBlockHash)
+	if err != nil {
+		return nil, err
+	}
 
 	return blockheader.NewImmutableBlockHeader(
-		constants.BlockVersion,
+		version,
 		parents,
 		hashMerkleRoot,
 		acceptedIDMerkleRoot,
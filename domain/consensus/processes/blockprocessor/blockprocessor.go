@@ -29,6 +29,7 @@ type blockProcessor struct {
 	headerTipsManager     model.HeadersSelectedTipManager
 	syncManager           model.SyncManager
 	finalityManager       model.FinalityManager
+	deploymentManager     model.DeploymentManager
 
 	acceptanceDataStore                 model.AcceptanceDataStore
 	blockStore                          model.BlockStore
@@ -67,6 +68,7 @@ func New(
 	coinbaseManager model.CoinbaseManager,
 	headerTipsManager model.HeadersSelectedTipManager,
 	syncManager model.SyncManager,
+	deploymentManager model.DeploymentManager,
 
 	acceptanceDataStore model.AcceptanceDataStore,
 	blockStore model.BlockStore,
@@ -101,6 +103,7 @@ func New(
 		coinbaseManager:       coinbaseManager,
 		headerTipsManager:     headerTipsManager,
 		syncManager:           syncManager,
+		deploymentManager:     deploymentManager,
 
 		consensusStateManager:               consensusStateManager,
 		acceptanceDataStore:                 acceptanceDataStore,
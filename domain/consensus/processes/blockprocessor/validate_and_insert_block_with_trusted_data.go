@@ -30,6 +30,11 @@ func (bp *blockProcessor) validateAndInsertBlockWithTrustedData(stagingArea *mod
 		bp.ghostdagDataStore.Stage(stagingArea, pair.Hash, pair.GHOSTDAGData, true)
 	}
 
+	err = bp.deploymentManager.StageTrustedDeploymentStates(stagingArea, blockHash, block.DeploymentStates)
+	if err != nil {
+		return nil, externalapi.StatusInvalid, err
+	}
+
 	bp.daaBlocksStore.StageDAAScore(stagingArea, blockHash, block.Block.Header.DAAScore())
 	return bp.validateAndInsertBlock(stagingArea, block.Block, false, validateUTXO, true)
 }
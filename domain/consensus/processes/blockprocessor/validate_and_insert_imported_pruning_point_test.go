@@ -109,10 +109,16 @@ func TestValidateAndInsertImportedPruningPoint(t *testing.T) {
 					t.Fatalf("TrustedBlockAssociatedGHOSTDAGDataBlockHashes: %+v", err)
 				}
 
+				deploymentStates, err := tcSyncer.TrustedDeploymentStates(blockHash)
+				if err != nil {
+					t.Fatalf("TrustedDeploymentStates: %+v", err)
+				}
+
 				blockWithTrustedData := &externalapi.BlockWithTrustedData{
-					Block:        block,
-					DAAWindow:    make([]*externalapi.TrustedDataDataDAAHeader, 0, len(blockDAAWindowHashes)),
-					GHOSTDAGData: make([]*externalapi.BlockGHOSTDAGDataHashPair, 0, len(ghostdagDataBlockHashes)),
+					Block:            block,
+					DAAWindow:        make([]*externalapi.TrustedDataDataDAAHeader, 0, len(blockDAAWindowHashes)),
+					GHOSTDAGData:     make([]*externalapi.BlockGHOSTDAGDataHashPair, 0, len(ghostdagDataBlockHashes)),
+					DeploymentStates: deploymentStates,
 				}
 
 				for i, daaBlockHash := range blockDAAWindowHashes {
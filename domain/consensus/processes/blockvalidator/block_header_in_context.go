@@ -101,11 +101,96 @@ func (v *blockValidator) ValidateHeaderInContext(stagingArea *model.StagingArea,
 		if err != nil {
 			return err
 		}
+
+		err = v.checkCheckpoints(stagingArea, blockHash)
+		if err != nil {
+			return err
+		}
+
+		// The deployment states are computed here so that they're stored along with the header
+		_, err = v.deploymentManager.DeploymentStates(stagingArea, blockHash)
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+// checkCheckpoints validates that the selected chain of the block passes through every
+// checkpoint its finality point has reached. The finality point is used rather than the
+// block itself so that blocks in the anticone of a checkpoint are not rejected.
+func (v *blockValidator) checkCheckpoints(stagingArea *model.StagingArea, blockHash *externalapi.DomainHash) error {
+	if len(v.checkpoints) == 0 {
+		return nil
+	}
+
+	finalityPoint, err := v.finalityManager.FinalityPoint(stagingArea, blockHash, false)
+	if err != nil {
+		return err
+	}
+	if finalityPoint.Equal(model.VirtualGenesisBlockHash) {
+		return nil
+	}
+
+	finalityPointHeader, err := v.blockHeaderStore.BlockHeader(v.databaseContext, stagingArea, finalityPoint)
+	if err != nil {
+		return err
+	}
+
+	for _, checkpoint := range v.checkpoints {
+		if finalityPointHeader.DAAScore() < checkpoint.DAAScore {
+			continue
+		}
+
+		hasCheckpointReachabilityData, err := v.reachabilityStore.HasReachabilityData(v.databaseContext, stagingArea, checkpoint.Hash)
+		if err != nil {
+			return err
+		}
+		if !hasCheckpointReachabilityData {
+			// A checkpoint is allowed to be unknown only if it's below the pruning point
+			// the node synced from, in which case it was validated against the pruning
+			// point proof.
+			isBelowPruningPoint, err := v.isBelowPruningPoint(stagingArea, checkpoint)
+			if err != nil {
+				return err
+			}
+			if isBelowPruningPoint {
+				continue
+			}
+
+			return errors.Wrapf(ruleerrors.ErrCheckpointViolation, "the selected chain of block %s passed the "+
+				"DAA score of checkpoint %s without passing through it", blockHash, checkpoint)
+		}
+
+		isCheckpointInSelectedChain, err := v.dagTopologyManagers[0].IsInSelectedParentChainOf(stagingArea,
+			checkpoint.Hash, finalityPoint)
+		if err != nil {
+			return err
+		}
+		if !isCheckpointInSelectedChain {
+			return errors.Wrapf(ruleerrors.ErrCheckpointViolation, "checkpoint %s is not in the selected "+
+				"chain of block %s", checkpoint, blockHash)
+		}
+	}
+
+	return nil
+}
+
+func (v *blockValidator) isBelowPruningPoint(stagingArea *model.StagingArea, checkpoint externalapi.Checkpoint) (bool, error) {
+	pruningPoint, err := v.pruningStore.PruningPoint(v.databaseContext, stagingArea)
+	if err != nil {
+		return false, err
+	}
+
+	pruningPointHeader, err := v.blockHeaderStore.BlockHeader(v.databaseContext, stagingArea, pruningPoint)
+	if err != nil {
+		return false, err
+	}
+
+	return checkpoint.DAAScore <= pruningPointHeader.DAAScore(), nil
+}
+
 func (v *blockValidator) hasValidatedHeader(stagingArea *model.StagingArea, blockHash *externalapi.DomainHash) (bool, error) {
 	exists, err := v.blockStatusStore.Exists(v.databaseContext, stagingArea, blockHash)
 	if err != nil {
@@ -12,6 +12,7 @@ import (
 	"github.com/c4ei/c4exd/domain/consensus"
 	"github.com/c4ei/c4exd/domain/consensus/model"
 	"github.com/c4ei/c4exd/domain/consensus/model/externalapi"
+	"github.com/c4ei/c4exd/domain/consensus/model/testapi"
 	"github.com/c4ei/c4exd/domain/consensus/ruleerrors"
 	"github.com/c4ei/c4exd/domain/consensus/utils/consensushashing"
 	"github.com/c4ei/c4exd/domain/consensus/utils/testutils"
@@ -252,3 +253,97 @@ func TestVirtualSelectionViolatingMergeSizeLimit(t *testing.T) {
 		}
 	})
 }
+
+func TestCheckpoints(t *testing.T) {
+	testutils.ForAllNets(t, true, func(t *testing.T, consensusConfig *consensus.Config) {
+		const chainLength = 30
+		consensusConfig.FinalityDuration = 10 * consensusConfig.TargetTimePerBlock
+
+		factory := consensus.NewFactory()
+		buildChain := func(testName string, coinbaseData *externalapi.DomainCoinbaseData) []*externalapi.DomainBlock {
+			tc, teardown, err := factory.NewTestConsensus(consensusConfig, testName)
+			if err != nil {
+				t.Fatalf("Error setting up consensus: %+v", err)
+			}
+			defer teardown(false)
+
+			chain := make([]*externalapi.DomainBlock, chainLength)
+			tipHash := consensusConfig.GenesisHash
+			for i := range chain {
+				tipHash, _, err = tc.AddBlock([]*externalapi.DomainHash{tipHash}, coinbaseData, nil)
+				if err != nil {
+					t.Fatalf("AddBlock: %+v", err)
+				}
+				chain[i], _, err = tc.GetBlock(tipHash)
+				if err != nil {
+					t.Fatalf("GetBlock: %+v", err)
+				}
+			}
+			return chain
+		}
+
+		mainChain := buildChain("TestCheckpoints_main", nil)
+		sideChain := buildChain("TestCheckpoints_side", &externalapi.DomainCoinbaseData{
+			ScriptPublicKey: &externalapi.ScriptPublicKey{Script: nil, Version: 0},
+			ExtraData:       []byte("side chain"),
+		})
+
+		checkpointBlock := mainChain[5]
+		checkpointConfig := *consensusConfig
+		checkpointConfig.Checkpoints = []externalapi.Checkpoint{{
+			Hash:     consensushashing.BlockHash(checkpointBlock),
+			DAAScore: checkpointBlock.Header.DAAScore(),
+		}}
+
+		// insertChain inserts the given blocks and returns the error of the first
+		// block that fails validation
+		insertChain := func(tc testapi.TestConsensus, chain []*externalapi.DomainBlock) error {
+			for _, block := range chain {
+				err := tc.ValidateAndInsertBlock(block, true)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		tc, teardown, err := factory.NewTestConsensus(&checkpointConfig, "TestCheckpoints_valid")
+		if err != nil {
+			t.Fatalf("Error setting up consensus: %+v", err)
+		}
+		defer teardown(false)
+
+		err = insertChain(tc, mainChain)
+		if err != nil {
+			t.Fatalf("The chain that passes through the checkpoint was rejected: %+v", err)
+		}
+
+		// A chain that never saw the checkpoint should be rejected
+		tcUnknown, teardownUnknown, err := factory.NewTestConsensus(&checkpointConfig, "TestCheckpoints_unknown")
+		if err != nil {
+			t.Fatalf("Error setting up consensus: %+v", err)
+		}
+		defer teardownUnknown(false)
+
+		err = insertChain(tcUnknown, sideChain)
+		if !errors.Is(err, ruleerrors.ErrCheckpointViolation) {
+			t.Fatalf("Expected ErrCheckpointViolation for a chain without the checkpoint but got: %+v", err)
+		}
+
+		// A chain that doesn't pass through a known checkpoint should be rejected as well
+		tcKnown, teardownKnown, err := factory.NewTestConsensus(&checkpointConfig, "TestCheckpoints_known")
+		if err != nil {
+			t.Fatalf("Error setting up consensus: %+v", err)
+		}
+		defer teardownKnown(false)
+
+		err = insertChain(tcKnown, mainChain[:6])
+		if err != nil {
+			t.Fatalf("The chain that passes through the checkpoint was rejected: %+v", err)
+		}
+		err = insertChain(tcKnown, sideChain)
+		if !errors.Is(err, ruleerrors.ErrCheckpointViolation) {
+			t.Fatalf("Expected ErrCheckpointViolation for a chain that skips the checkpoint but got: %+v", err)
+		}
+	})
+}
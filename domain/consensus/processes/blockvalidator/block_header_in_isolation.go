@@ -56,7 +56,10 @@ func (v *blockValidator) checkParentsLimit(header externalapi.BlockHeader) error
 }
 
 func (v *blockValidator) checkBlockVersion(header externalapi.BlockHeader) error {
-	if header.Version() != constants.BlockVersion {
+	// Blocks that signal for consensus deployments follow the rules of constants.BlockVersion. The
+	// DAA score of the header is only validated in context, but a wrong DAA score fails the block anyway.
+	if header.Version() != constants.BlockVersion &&
+		!v.deploymentManager.IsSignalingVersionAllowed(header.Version(), header.DAAScore()) {
 		return errors.Wrapf(
 			ruleerrors.ErrWrongBlockVersion, "The block version should be %d", constants.BlockVersion)
 	}
@@ -58,30 +58,37 @@ func CheckParentsLimit(t *testing.T, tc testapi.TestConsensus, consensusConfig *
 }
 
 func CheckBlockVersion(t *testing.T, tc testapi.TestConsensus, consensusConfig *consensus.Config) {
-	block, _, err := tc.BuildBlockWithParents([]*externalapi.DomainHash{consensusConfig.GenesisHash}, nil, nil)
-	if err != nil {
-		t.Fatalf("BuildBlockWithParents: %+v", err)
+	// None of the networks defines deployments, so versions that signal for them are rejected as well
+	wrongVersions := []uint16{
+		constants.BlockVersion + 1,
+		constants.VersionBitsTopBits,
+		constants.VersionBitsTopBits | 1,
 	}
+	for _, wrongVersion := range wrongVersions {
+		block, _, err := tc.BuildBlockWithParents([]*externalapi.DomainHash{consensusConfig.GenesisHash}, nil, nil)
+		if err != nil {
+			t.Fatalf("BuildBlockWithParents: %+v", err)
+		}
 
-	expectedVersion := constants.BlockVersion
-	block.Header = blockheader.NewImmutableBlockHeader(
-		expectedVersion+1,
-		block.Header.Parents(),
-		block.Header.HashMerkleRoot(),
-		block.Header.AcceptedIDMerkleRoot(),
-		block.Header.UTXOCommitment(),
-		block.Header.TimeInMilliseconds(),
-		block.Header.Bits(),
-		block.Header.Nonce(),
-		block.Header.DAAScore(),
-		block.Header.BlueScore(),
-		block.Header.BlueWork(),
-		block.Header.PruningPoint(),
-	)
-
-	err = tc.ValidateAndInsertBlock(block, true)
-	if !errors.Is(err, ruleerrors.ErrWrongBlockVersion) {
-		t.Fatalf("Unexpected error: %+v", err)
+		block.Header = blockheader.NewImmutableBlockHeader(
+			wrongVersion,
+			block.Header.Parents(),
+			block.Header.HashMerkleRoot(),
+			block.Header.AcceptedIDMerkleRoot(),
+			block.Header.UTXOCommitment(),
+			block.Header.TimeInMilliseconds(),
+			block.Header.Bits(),
+			block.Header.Nonce(),
+			block.Header.DAAScore(),
+			block.Header.BlueScore(),
+			block.Header.BlueWork(),
+			block.Header.PruningPoint(),
+		)
+
+		err = tc.ValidateAndInsertBlock(block, true)
+		if !errors.Is(err, ruleerrors.ErrWrongBlockVersion) {
+			t.Fatalf("Unexpected error for version %x: %+v", wrongVersion, err)
+		}
 	}
 }
 
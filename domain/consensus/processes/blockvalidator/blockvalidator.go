@@ -25,6 +25,7 @@ type blockValidator struct {
 	timestampDeviationTolerance int
 	targetTimePerBlock          time.Duration
 	maxBlockLevel               int
+	checkpoints                 []externalapi.Checkpoint
 
 	databaseContext       model.DBReader
 	difficultyManager     model.DifficultyManager
@@ -41,6 +42,7 @@ type blockValidator struct {
 	blockParentBuilder    model.BlockParentBuilder
 	pruningManager        model.PruningManager
 	parentsManager        model.ParentsManager
+	deploymentManager     model.DeploymentManager
 
 	blockStore          model.BlockStore
 	ghostdagDataStores  []model.GHOSTDAGDataStore
@@ -64,6 +66,7 @@ func New(powMax *big.Int,
 	timestampDeviationTolerance int,
 	targetTimePerBlock time.Duration,
 	maxBlockLevel int,
+	checkpoints []externalapi.Checkpoint,
 
 	databaseContext model.DBReader,
 
@@ -80,6 +83,7 @@ func New(powMax *big.Int,
 	blockParentBuilder model.BlockParentBuilder,
 	pruningManager model.PruningManager,
 	parentsManager model.ParentsManager,
+	deploymentManager model.DeploymentManager,
 
 	pruningStore model.PruningStore,
 	blockStore model.BlockStore,
@@ -103,6 +107,7 @@ func New(powMax *big.Int,
 		mergeSetSizeLimit:          mergeSetSizeLimit,
 		maxBlockParents:            maxBlockParents,
 		maxBlockLevel:              maxBlockLevel,
+		checkpoints:                checkpoints,
 
 		timestampDeviationTolerance: timestampDeviationTolerance,
 		targetTimePerBlock:          targetTimePerBlock,
@@ -120,6 +125,7 @@ func New(powMax *big.Int,
 		blockParentBuilder:          blockParentBuilder,
 		pruningManager:              pruningManager,
 		parentsManager:              parentsManager,
+		deploymentManager:           deploymentManager,
 
 		pruningStore:        pruningStore,
 		blockStore:          blockStore,
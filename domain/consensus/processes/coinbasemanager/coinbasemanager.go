@@ -2,6 +2,7 @@ package coinbasemanager
 
 import (
 	"math"
+	"math/bits"
 
 	"github.com/c4ei/c4exd/domain/consensus/model"
 	"github.com/c4ei/c4exd/domain/consensus/model/externalapi"
@@ -20,6 +21,7 @@ type coinbaseManager struct {
 	genesisHash                             *externalapi.DomainHash
 	deflationaryPhaseDaaScore               uint64
 	deflationaryPhaseBaseSubsidy            uint64
+	payoutSplitActivationBlueScore          uint64
 
 	databaseContext     model.DBReader
 	dagTraversalManager model.DAGTraversalManager
@@ -60,25 +62,22 @@ func (c *coinbaseManager) ExpectedCoinbaseTransaction(stagingArea *model.Staging
 	txOuts := make([]*externalapi.DomainTransactionOutput, 0, len(ghostdagData.MergeSetBlues()))
 	acceptanceDataMap := acceptanceDataFromArrayToMap(acceptanceData)
 	for _, blue := range ghostdagData.MergeSetBlues() {
-		txOut, hasReward, err := c.coinbaseOutputForBlueBlock(stagingArea, blue, acceptanceDataMap[*blue], daaAddedBlocksSet)
+		blueTxOuts, err := c.coinbaseOutputsForBlueBlock(stagingArea, blue, acceptanceDataMap[*blue], daaAddedBlocksSet)
 		if err != nil {
 			return nil, false, err
 		}
 
-		if hasReward {
-			txOuts = append(txOuts, txOut)
-		}
+		txOuts = append(txOuts, blueTxOuts...)
 	}
 
-	txOut, hasRedReward, err := c.coinbaseOutputForRewardFromRedBlocks(
+	redTxOuts, err := c.coinbaseOutputsForRewardFromRedBlocks(
 		stagingArea, ghostdagData, acceptanceData, daaAddedBlocksSet, coinbaseData)
 	if err != nil {
 		return nil, false, err
 	}
 
-	if hasRedReward {
-		txOuts = append(txOuts, txOut)
-	}
+	hasRedReward = len(redTxOuts) > 0
+	txOuts = append(txOuts, redTxOuts...)
 
 	subsidy, err := c.CalcBlockSubsidy(stagingArea, blockHash)
 	if err != nil {
@@ -112,58 +111,76 @@ func (c *coinbaseManager) daaAddedBlocksSet(stagingArea *model.StagingArea, bloc
 	return hashset.NewFromSlice(daaAddedBlocks...), nil
 }
 
-// coinbaseOutputForBlueBlock calculates the output that should go into the coinbase transaction of blueBlock
-// If blueBlock gets no fee - returns nil for txOut
-func (c *coinbaseManager) coinbaseOutputForBlueBlock(stagingArea *model.StagingArea,
+// coinbaseOutputsForBlueBlock calculates the outputs that should go into the coinbase transaction of blueBlock
+// If blueBlock gets no fee - returns no outputs
+func (c *coinbaseManager) coinbaseOutputsForBlueBlock(stagingArea *model.StagingArea,
 	blueBlock *externalapi.DomainHash, blockAcceptanceData *externalapi.BlockAcceptanceData,
-	mergingBlockDAAAddedBlocksSet hashset.HashSet) (*externalapi.DomainTransactionOutput, bool, error) {
+	mergingBlockDAAAddedBlocksSet hashset.HashSet) ([]*externalapi.DomainTransactionOutput, error) {
 
 	blockReward, err := c.calcMergedBlockReward(stagingArea, blueBlock, blockAcceptanceData, mergingBlockDAAAddedBlocksSet)
 	if err != nil {
-		return nil, false, err
+		return nil, err
 	}
 
 	if blockReward == 0 {
-		return nil, false, nil
+		return nil, nil
 	}
 
-	// the ScriptPublicKey for the coinbase is parsed from the coinbase payload
+	// the ScriptPublicKey and the payouts for the coinbase are parsed from the coinbase payload
 	_, coinbaseData, _, err := c.ExtractCoinbaseDataBlueScoreAndSubsidy(blockAcceptanceData.TransactionAcceptanceData[0].Transaction)
 	if err != nil {
-		return nil, false, err
-	}
-
-	txOut := &externalapi.DomainTransactionOutput{
-		Value:           blockReward,
-		ScriptPublicKey: coinbaseData.ScriptPublicKey,
+		return nil, err
 	}
 
-	return txOut, true, nil
+	return splitReward(blockReward, coinbaseData), nil
 }
 
-func (c *coinbaseManager) coinbaseOutputForRewardFromRedBlocks(stagingArea *model.StagingArea,
+func (c *coinbaseManager) coinbaseOutputsForRewardFromRedBlocks(stagingArea *model.StagingArea,
 	ghostdagData *externalapi.BlockGHOSTDAGData, acceptanceData externalapi.AcceptanceData, daaAddedBlocksSet hashset.HashSet,
-	coinbaseData *externalapi.DomainCoinbaseData) (*externalapi.DomainTransactionOutput, bool, error) {
+	coinbaseData *externalapi.DomainCoinbaseData) ([]*externalapi.DomainTransactionOutput, error) {
 
 	acceptanceDataMap := acceptanceDataFromArrayToMap(acceptanceData)
 	totalReward := uint64(0)
 	for _, red := range ghostdagData.MergeSetReds() {
 		reward, err := c.calcMergedBlockReward(stagingArea, red, acceptanceDataMap[*red], daaAddedBlocksSet)
 		if err != nil {
-			return nil, false, err
+			return nil, err
 		}
 
 		totalReward += reward
 	}
 
 	if totalReward == 0 {
-		return nil, false, nil
+		return nil, nil
 	}
 
-	return &externalapi.DomainTransactionOutput{
-		Value:           totalReward,
+	return splitReward(totalReward, coinbaseData), nil
+}
+
+// splitReward splits a reward between the payees of the given coinbase data by their weights. The
+// main script public key gets the first output, with what the payouts leave including the rounding
+// remainders, and payouts whose share rounds down to zero get no output.
+func splitReward(reward uint64, coinbaseData *externalapi.DomainCoinbaseData) []*externalapi.DomainTransactionOutput {
+	txOuts := make([]*externalapi.DomainTransactionOutput, 1, 1+len(coinbaseData.Payouts))
+	mainValue := reward
+	for _, payout := range coinbaseData.Payouts {
+		// The weights of the payouts are below the denominator, so the quotient fits in 64 bits
+		high, low := bits.Mul64(reward, uint64(payout.Weight))
+		value, _ := bits.Div64(high, low, externalapi.CoinbasePayoutWeightDenominator)
+		if value == 0 {
+			continue
+		}
+		mainValue -= value
+		txOuts = append(txOuts, &externalapi.DomainTransactionOutput{
+			Value:           value,
+			ScriptPublicKey: payout.ScriptPublicKey,
+		})
+	}
+	txOuts[0] = &externalapi.DomainTransactionOutput{
+		Value:           mainValue,
 		ScriptPublicKey: coinbaseData.ScriptPublicKey,
-	}, true, nil
+	}
+	return txOuts
 }
 
 func acceptanceDataFromArrayToMap(acceptanceData externalapi.AcceptanceData) map[externalapi.DomainHash]*externalapi.BlockAcceptanceData {
@@ -284,6 +301,7 @@ func New(
 	genesisHash *externalapi.DomainHash,
 	deflationaryPhaseDaaScore uint64,
 	deflationaryPhaseBaseSubsidy uint64,
+	payoutSplitActivationBlueScore uint64,
 
 	dagTraversalManager model.DAGTraversalManager,
 	ghostdagDataStore model.GHOSTDAGDataStore,
@@ -302,6 +320,7 @@ func New(
 		genesisHash:                             genesisHash,
 		deflationaryPhaseDaaScore:               deflationaryPhaseDaaScore,
 		deflationaryPhaseBaseSubsidy:            deflationaryPhaseBaseSubsidy,
+		payoutSplitActivationBlueScore:          payoutSplitActivationBlueScore,
 
 		dagTraversalManager: dagTraversalManager,
 		ghostdagDataStore:   ghostdagDataStore,
@@ -1,12 +1,16 @@
 package coinbasemanager_test
 
 import (
+	"bytes"
+	"errors"
 	"testing"
 
 	"github.com/c4ei/c4exd/domain/consensus"
 	"github.com/c4ei/c4exd/domain/consensus/model"
 	"github.com/c4ei/c4exd/domain/consensus/model/externalapi"
+	"github.com/c4ei/c4exd/domain/consensus/ruleerrors"
 	"github.com/c4ei/c4exd/domain/consensus/utils/testutils"
+	"github.com/c4ei/c4exd/domain/consensus/utils/transactionhelper"
 )
 
 func TestExtractCoinbaseDataBlueScoreAndSubsidy(t *testing.T) {
@@ -56,3 +60,73 @@ func TestExtractCoinbaseDataBlueScoreAndSubsidy(t *testing.T) {
 
 	})
 }
+
+func TestCoinbasePayouts(t *testing.T) {
+	testutils.ForAllNets(t, true, func(t *testing.T, consensusConfig *consensus.Config) {
+		consensusConfig.PayoutSplitActivationBlueScore = 2
+		factory := consensus.NewFactory()
+		tc, teardown, err := factory.NewTestConsensus(consensusConfig, "TestCoinbasePayouts")
+		if err != nil {
+			t.Fatalf("Error setting up consensus: %+v", err)
+		}
+		defer teardown(false)
+
+		coinbaseData := &externalapi.DomainCoinbaseData{
+			ScriptPublicKey: &externalapi.ScriptPublicKey{Script: []byte{1}, Version: 0},
+			ExtraData:       []byte("pool"),
+			Payouts: []*externalapi.DomainCoinbasePayout{
+				{ScriptPublicKey: &externalapi.ScriptPublicKey{Script: []byte{2}, Version: 0}, Weight: 2500},
+				{ScriptPublicKey: &externalapi.ScriptPublicKey{Script: []byte{3}, Version: 0}, Weight: 100},
+			},
+		}
+
+		// The first block has a blue score of 1, before the activation
+		_, _, err = tc.AddBlock([]*externalapi.DomainHash{consensusConfig.GenesisHash}, coinbaseData, nil)
+		if !errors.Is(err, ruleerrors.ErrBadCoinbasePayouts) {
+			t.Fatalf("Expected ErrBadCoinbasePayouts before the activation, got: %v", err)
+		}
+		blockHash, _, err := tc.AddBlock([]*externalapi.DomainHash{consensusConfig.GenesisHash}, nil, nil)
+		if err != nil {
+			t.Fatalf("AddBlock: %+v", err)
+		}
+
+		blockWithPayoutsHash, _, err := tc.AddBlock([]*externalapi.DomainHash{blockHash}, coinbaseData, nil)
+		if err != nil {
+			t.Fatalf("AddBlock: %+v", err)
+		}
+		blockWithPayouts, _, err := tc.GetBlock(blockWithPayoutsHash)
+		if err != nil {
+			t.Fatalf("GetBlock: %+v", err)
+		}
+		_, extractedCoinbaseData, _, err := tc.CoinbaseManager().ExtractCoinbaseDataBlueScoreAndSubsidy(
+			blockWithPayouts.Transactions[transactionhelper.CoinbaseTransactionIndex])
+		if err != nil {
+			t.Fatalf("ExtractCoinbaseDataBlueScoreAndSubsidy: %+v", err)
+		}
+		if !extractedCoinbaseData.Equal(coinbaseData) {
+			t.Fatalf("Extracted coinbase data %+v doesn't match the mined one", extractedCoinbaseData)
+		}
+
+		// The child of the block pays its reward, split between its payees
+		childHash, _, err := tc.AddBlock([]*externalapi.DomainHash{blockWithPayoutsHash}, nil, nil)
+		if err != nil {
+			t.Fatalf("AddBlock: %+v", err)
+		}
+		child, _, err := tc.GetBlock(childHash)
+		if err != nil {
+			t.Fatalf("GetBlock: %+v", err)
+		}
+		outputs := child.Transactions[transactionhelper.CoinbaseTransactionIndex].Outputs
+		if len(outputs) != 3 {
+			t.Fatalf("Expected the child coinbase to have 3 outputs, got %d", len(outputs))
+		}
+		reward := outputs[0].Value + outputs[1].Value + outputs[2].Value
+		expectedScripts := [][]byte{{1}, {2}, {3}}
+		expectedValues := []uint64{reward - reward*2500/10000 - reward*100/10000, reward * 2500 / 10000, reward * 100 / 10000}
+		for i, output := range outputs {
+			if !bytes.Equal(output.ScriptPublicKey.Script, expectedScripts[i]) || output.Value != expectedValues[i] {
+				t.Fatalf("Unexpected output #%d: %d to %x", i, output.Value, output.ScriptPublicKey.Script)
+			}
+		}
+	})
+}
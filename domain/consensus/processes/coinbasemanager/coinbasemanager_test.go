@@ -22,6 +22,7 @@ func TestCalcDeflationaryPeriodBlockSubsidy(t *testing.T) {
 		&externalapi.DomainHash{},
 		deflationaryPhaseDaaScore,
 		deflationaryPhaseBaseSubsidy,
+		0,
 		nil,
 		nil,
 		nil,
@@ -97,6 +98,7 @@ func TestBuildSubsidyTable(t *testing.T) {
 		&externalapi.DomainHash{},
 		0,
 		deflationaryPhaseBaseSubsidy,
+		0,
 		nil,
 		nil,
 		nil,
@@ -13,83 +13,241 @@ const uint16Len = 2
 const lengthOfSubsidy = uint64Len
 const lengthOfScriptPubKeyLength = 1
 const lengthOfVersionScriptPubKey = uint16Len
+const lengthOfPayoutsCount = 1
+const lengthOfPayoutWeight = uint16Len
+
+// The coinbase payload is built as follows:
+//   blue score (8 bytes) || subsidy (8 bytes) || script public key version (2 bytes) ||
+//   script public key length (1 byte) || script public key || extra data
+// From the payout split activation on, the script public key is followed by the payouts
+// the rewards are split with:
+//   payouts count (1 byte) || for each payout: weight (2 bytes) || script public key version (2 bytes) ||
+//   script public key length (1 byte) || script public key
+// The blue score comes first so the format of a payload can be told from the payload itself.
 
 // serializeCoinbasePayload builds the coinbase payload based on the provided scriptPubKey and extra data.
 func (c *coinbaseManager) serializeCoinbasePayload(blueScore uint64,
 	coinbaseData *externalapi.DomainCoinbaseData, subsidy uint64) ([]byte, error) {
 
-	scriptLengthOfScriptPubKey := len(coinbaseData.ScriptPublicKey.Script)
-	if scriptLengthOfScriptPubKey > int(c.coinbasePayloadScriptPublicKeyMaxLength) {
-		return nil, errors.Wrapf(ruleerrors.ErrBadCoinbasePayloadLen, "coinbase's payload script public key is "+
-			"longer than the max allowed length of %d", c.coinbasePayloadScriptPublicKeyMaxLength)
+	return serializeCoinbasePayload(blueScore, subsidy, coinbaseData, c.coinbasePayloadScriptPublicKeyMaxLength,
+		c.payoutSplitActivationBlueScore)
+}
+
+func serializeCoinbasePayload(blueScore uint64, subsidy uint64, coinbaseData *externalapi.DomainCoinbaseData,
+	coinbasePayloadScriptPublicKeyMaxLength uint8, payoutSplitActivationBlueScore uint64) ([]byte, error) {
+
+	err := validateScriptPublicKeyLength(coinbaseData.ScriptPublicKey, coinbasePayloadScriptPublicKeyMaxLength)
+	if err != nil {
+		return nil, err
+	}
+
+	hasPayouts := blueScore >= payoutSplitActivationBlueScore
+	if !hasPayouts && len(coinbaseData.Payouts) > 0 {
+		return nil, errors.Wrapf(ruleerrors.ErrBadCoinbasePayouts, "coinbase payouts are only allowed "+
+			"from blue score %d", payoutSplitActivationBlueScore)
+	}
+	err = validateCoinbasePayouts(coinbaseData.Payouts, coinbasePayloadScriptPublicKeyMaxLength)
+	if err != nil {
+		return nil, err
 	}
 
-	payload := make([]byte, uint64Len+lengthOfVersionScriptPubKey+lengthOfScriptPubKeyLength+scriptLengthOfScriptPubKey+len(coinbaseData.ExtraData)+lengthOfSubsidy)
+	payloadLength := uint64Len + lengthOfSubsidy + scriptPublicKeyLength(coinbaseData.ScriptPublicKey) +
+		len(coinbaseData.ExtraData)
+	if hasPayouts {
+		payloadLength += payoutsLength(coinbaseData.Payouts)
+	}
+	payload := make([]byte, uint64Len+lengthOfSubsidy, payloadLength)
 	binary.LittleEndian.PutUint64(payload[:uint64Len], blueScore)
 	binary.LittleEndian.PutUint64(payload[uint64Len:], subsidy)
-
-	binary.LittleEndian.PutUint16(payload[uint64Len+lengthOfSubsidy:], coinbaseData.ScriptPublicKey.Version)
-	payload[uint64Len+lengthOfSubsidy+lengthOfVersionScriptPubKey] = uint8(len(coinbaseData.ScriptPublicKey.Script))
-	copy(payload[uint64Len+lengthOfSubsidy+lengthOfVersionScriptPubKey+lengthOfScriptPubKeyLength:], coinbaseData.ScriptPublicKey.Script)
-	copy(payload[uint64Len+lengthOfSubsidy+lengthOfVersionScriptPubKey+lengthOfScriptPubKeyLength+scriptLengthOfScriptPubKey:], coinbaseData.ExtraData)
+	payload = appendScriptPublicKey(payload, coinbaseData.ScriptPublicKey)
+	if hasPayouts {
+		payload = append(payload, uint8(len(coinbaseData.Payouts)))
+		for _, payout := range coinbaseData.Payouts {
+			payload = appendUint16(payload, payout.Weight)
+			payload = appendScriptPublicKey(payload, payout.ScriptPublicKey)
+		}
+	}
+	payload = append(payload, coinbaseData.ExtraData...)
 
 	return payload, nil
 }
 
-// ModifyCoinbasePayload modifies the coinbase payload based on the provided scriptPubKey and extra data.
-func ModifyCoinbasePayload(payload []byte, coinbaseData *externalapi.DomainCoinbaseData, coinbasePayloadScriptPublicKeyMaxLength uint8) ([]byte, error) {
+// CoinbasePayloadLength returns the length of the coinbase payload built from the given coinbase
+// data from the payout split activation on
+func CoinbasePayloadLength(coinbaseData *externalapi.DomainCoinbaseData) int {
+	return uint64Len + lengthOfSubsidy + scriptPublicKeyLength(coinbaseData.ScriptPublicKey) +
+		payoutsLength(coinbaseData.Payouts) + len(coinbaseData.ExtraData)
+}
 
-	scriptLengthOfScriptPubKey := len(coinbaseData.ScriptPublicKey.Script)
-	if scriptLengthOfScriptPubKey > int(coinbasePayloadScriptPublicKeyMaxLength) {
-		return nil, errors.Wrapf(ruleerrors.ErrBadCoinbasePayloadLen, "coinbase's payload script public key is "+
+func appendUint16(payload []byte, value uint16) []byte {
+	var buffer [uint16Len]byte
+	binary.LittleEndian.PutUint16(buffer[:], value)
+	return append(payload, buffer[:]...)
+}
+
+func scriptPublicKeyLength(scriptPublicKey *externalapi.ScriptPublicKey) int {
+	return lengthOfVersionScriptPubKey + lengthOfScriptPubKeyLength + len(scriptPublicKey.Script)
+}
+
+func payoutsLength(payouts []*externalapi.DomainCoinbasePayout) int {
+	length := lengthOfPayoutsCount
+	for _, payout := range payouts {
+		length += lengthOfPayoutWeight + scriptPublicKeyLength(payout.ScriptPublicKey)
+	}
+	return length
+}
+
+func appendScriptPublicKey(payload []byte, scriptPublicKey *externalapi.ScriptPublicKey) []byte {
+	payload = appendUint16(payload, scriptPublicKey.Version)
+	payload = append(payload, uint8(len(scriptPublicKey.Script)))
+	return append(payload, scriptPublicKey.Script...)
+}
+
+func validateScriptPublicKeyLength(scriptPublicKey *externalapi.ScriptPublicKey,
+	coinbasePayloadScriptPublicKeyMaxLength uint8) error {
+
+	if len(scriptPublicKey.Script) > int(coinbasePayloadScriptPublicKeyMaxLength) {
+		return errors.Wrapf(ruleerrors.ErrBadCoinbasePayloadLen, "coinbase's payload script public key is "+
 			"longer than the max allowed length of %d", coinbasePayloadScriptPublicKeyMaxLength)
 	}
+	return nil
+}
+
+// validateCoinbasePayouts checks that every payout gets a share of the rewards, and
+// that the payouts leave a share for the main script public key of the coinbase
+func validateCoinbasePayouts(payouts []*externalapi.DomainCoinbasePayout,
+	coinbasePayloadScriptPublicKeyMaxLength uint8) error {
 
-	newPayloadLen := uint64Len + lengthOfVersionScriptPubKey + lengthOfScriptPubKeyLength + scriptLengthOfScriptPubKey + len(coinbaseData.ExtraData) + lengthOfSubsidy
-	if len(payload) != newPayloadLen {
-		newPayload := make([]byte, newPayloadLen)
-		copy(newPayload, payload[:uint64Len+lengthOfSubsidy])
-		payload = newPayload
+	if len(payouts) > 0xff {
+		return errors.Wrapf(ruleerrors.ErrBadCoinbasePayouts, "coinbase has %d payouts while the maximum is %d",
+			len(payouts), 0xff)
+	}
+	totalWeight := uint64(0)
+	for _, payout := range payouts {
+		err := validateScriptPublicKeyLength(payout.ScriptPublicKey, coinbasePayloadScriptPublicKeyMaxLength)
+		if err != nil {
+			return err
+		}
+		if payout.Weight == 0 {
+			return errors.Wrapf(ruleerrors.ErrBadCoinbasePayouts, "coinbase payout has zero weight")
+		}
+		totalWeight += uint64(payout.Weight)
 	}
+	if totalWeight >= externalapi.CoinbasePayoutWeightDenominator {
+		return errors.Wrapf(ruleerrors.ErrBadCoinbasePayouts, "the total weight of the coinbase payouts is %d "+
+			"while it must be below %d", totalWeight, externalapi.CoinbasePayoutWeightDenominator)
+	}
+	return nil
+}
+
+// ModifyCoinbasePayload modifies the coinbase payload based on the provided scriptPubKey and extra data.
+func ModifyCoinbasePayload(payload []byte, coinbaseData *externalapi.DomainCoinbaseData,
+	coinbasePayloadScriptPublicKeyMaxLength uint8, payoutSplitActivationBlueScore uint64) ([]byte, error) {
 
-	binary.LittleEndian.PutUint16(payload[uint64Len+lengthOfSubsidy:uint64Len+lengthOfSubsidy+lengthOfVersionScriptPubKey], coinbaseData.ScriptPublicKey.Version)
-	payload[uint64Len+lengthOfSubsidy+lengthOfVersionScriptPubKey] = uint8(len(coinbaseData.ScriptPublicKey.Script))
-	copy(payload[uint64Len+lengthOfSubsidy+lengthOfVersionScriptPubKey+lengthOfScriptPubKeyLength:], coinbaseData.ScriptPublicKey.Script)
-	copy(payload[uint64Len+lengthOfSubsidy+lengthOfVersionScriptPubKey+lengthOfScriptPubKeyLength+scriptLengthOfScriptPubKey:], coinbaseData.ExtraData)
+	if len(payload) < uint64Len+lengthOfSubsidy {
+		return nil, errors.Wrapf(ruleerrors.ErrBadCoinbasePayloadLen,
+			"coinbase payload is less than the minimum length of %d", uint64Len+lengthOfSubsidy)
+	}
+	blueScore := binary.LittleEndian.Uint64(payload[:uint64Len])
+	subsidy := binary.LittleEndian.Uint64(payload[uint64Len:])
 
-	return payload, nil
+	return serializeCoinbasePayload(blueScore, subsidy, coinbaseData, coinbasePayloadScriptPublicKeyMaxLength,
+		payoutSplitActivationBlueScore)
 }
 
 // ExtractCoinbaseDataBlueScoreAndSubsidy deserializes the coinbase payload to its component (scriptPubKey, extra data, and subsidy).
 func (c *coinbaseManager) ExtractCoinbaseDataBlueScoreAndSubsidy(coinbaseTx *externalapi.DomainTransaction) (
 	blueScore uint64, coinbaseData *externalapi.DomainCoinbaseData, subsidy uint64, err error) {
 
+	payload := coinbaseTx.Payload
 	minLength := uint64Len + lengthOfSubsidy + lengthOfVersionScriptPubKey + lengthOfScriptPubKeyLength
-	if len(coinbaseTx.Payload) < minLength {
+	if len(payload) < minLength {
 		return 0, nil, 0, errors.Wrapf(ruleerrors.ErrBadCoinbasePayloadLen,
 			"coinbase payload is less than the minimum length of %d", minLength)
 	}
 
-	blueScore = binary.LittleEndian.Uint64(coinbaseTx.Payload[:uint64Len])
-	subsidy = binary.LittleEndian.Uint64(coinbaseTx.Payload[uint64Len:])
+	blueScore = binary.LittleEndian.Uint64(payload[:uint64Len])
+	subsidy = binary.LittleEndian.Uint64(payload[uint64Len:])
+
+	scriptPublicKey, offset, err := c.extractScriptPublicKey(payload, uint64Len+lengthOfSubsidy)
+	if err != nil {
+		return 0, nil, 0, err
+	}
+
+	var payouts []*externalapi.DomainCoinbasePayout
+	if blueScore >= c.payoutSplitActivationBlueScore {
+		payouts, offset, err = c.extractPayouts(payload, offset)
+		if err != nil {
+			return 0, nil, 0, err
+		}
+	}
+
+	return blueScore, &externalapi.DomainCoinbaseData{
+		ScriptPublicKey: scriptPublicKey,
+		ExtraData:       payload[offset:],
+		Payouts:         payouts,
+	}, subsidy, nil
+}
 
-	scriptPubKeyVersion := binary.LittleEndian.Uint16(coinbaseTx.Payload[uint64Len+lengthOfSubsidy : uint64Len+lengthOfSubsidy+uint16Len])
+// extractScriptPublicKey deserializes the script public key at the given offset of the payload, and
+// returns it along with the offset right after it
+func (c *coinbaseManager) extractScriptPublicKey(payload []byte, offset int) (*externalapi.ScriptPublicKey, int, error) {
+	if len(payload) < offset+lengthOfVersionScriptPubKey+lengthOfScriptPubKeyLength {
+		return nil, 0, errors.Wrapf(ruleerrors.ErrBadCoinbasePayloadLen,
+			"coinbase payload doesn't have enough bytes to contain a script public key")
+	}
+	scriptPubKeyVersion := binary.LittleEndian.Uint16(payload[offset : offset+lengthOfVersionScriptPubKey])
+	offset += lengthOfVersionScriptPubKey
 
-	scriptPubKeyScriptLength := coinbaseTx.Payload[uint64Len+lengthOfSubsidy+lengthOfVersionScriptPubKey]
+	scriptPubKeyScriptLength := payload[offset]
+	offset += lengthOfScriptPubKeyLength
 
 	if scriptPubKeyScriptLength > c.coinbasePayloadScriptPublicKeyMaxLength {
-		return 0, nil, 0, errors.Wrapf(ruleerrors.ErrBadCoinbasePayloadLen, "coinbase's payload script public key is "+
+		return nil, 0, errors.Wrapf(ruleerrors.ErrBadCoinbasePayloadLen, "coinbase's payload script public key is "+
 			"longer than the max allowed length of %d", c.coinbasePayloadScriptPublicKeyMaxLength)
 	}
 
-	if len(coinbaseTx.Payload) < minLength+int(scriptPubKeyScriptLength) {
-		return 0, nil, 0, errors.Wrapf(ruleerrors.ErrBadCoinbasePayloadLen,
+	if len(payload) < offset+int(scriptPubKeyScriptLength) {
+		return nil, 0, errors.Wrapf(ruleerrors.ErrBadCoinbasePayloadLen,
 			"coinbase payload doesn't have enough bytes to contain a script public key of %d bytes", scriptPubKeyScriptLength)
 	}
-	scriptPubKeyScript := coinbaseTx.Payload[uint64Len+lengthOfSubsidy+lengthOfVersionScriptPubKey+lengthOfScriptPubKeyLength : uint64Len+lengthOfSubsidy+lengthOfVersionScriptPubKey+lengthOfScriptPubKeyLength+scriptPubKeyScriptLength]
+	scriptPubKeyScript := payload[offset : offset+int(scriptPubKeyScriptLength)]
+	offset += int(scriptPubKeyScriptLength)
 
-	return blueScore, &externalapi.DomainCoinbaseData{
-		ScriptPublicKey: &externalapi.ScriptPublicKey{Script: scriptPubKeyScript, Version: scriptPubKeyVersion},
-		ExtraData:       coinbaseTx.Payload[uint64Len+lengthOfSubsidy+lengthOfVersionScriptPubKey+lengthOfScriptPubKeyLength+scriptPubKeyScriptLength:],
-	}, subsidy, nil
+	return &externalapi.ScriptPublicKey{Script: scriptPubKeyScript, Version: scriptPubKeyVersion}, offset, nil
+}
+
+// extractPayouts deserializes the payouts at the given offset of the payload, and returns
+// them along with the offset right after them
+func (c *coinbaseManager) extractPayouts(payload []byte, offset int) ([]*externalapi.DomainCoinbasePayout, int, error) {
+	if len(payload) < offset+lengthOfPayoutsCount {
+		return nil, 0, errors.Wrapf(ruleerrors.ErrBadCoinbasePayloadLen,
+			"coinbase payload doesn't have enough bytes to contain the payouts count")
+	}
+	payoutsCount := int(payload[offset])
+	offset += lengthOfPayoutsCount
+
+	payouts := make([]*externalapi.DomainCoinbasePayout, payoutsCount)
+	for i := range payouts {
+		if len(payload) < offset+lengthOfPayoutWeight {
+			return nil, 0, errors.Wrapf(ruleerrors.ErrBadCoinbasePayloadLen,
+				"coinbase payload doesn't have enough bytes to contain payout #%d", i)
+		}
+		weight := binary.LittleEndian.Uint16(payload[offset : offset+lengthOfPayoutWeight])
+		offset += lengthOfPayoutWeight
+
+		var scriptPublicKey *externalapi.ScriptPublicKey
+		var err error
+		scriptPublicKey, offset, err = c.extractScriptPublicKey(payload, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		payouts[i] = &externalapi.DomainCoinbasePayout{ScriptPublicKey: scriptPublicKey, Weight: weight}
+	}
+
+	err := validateCoinbasePayouts(payouts, c.coinbasePayloadScriptPublicKeyMaxLength)
+	if err != nil {
+		return nil, 0, err
+	}
+	return payouts, offset, nil
 }
@@ -0,0 +1,172 @@
+package coinbasemanager
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/c4ei/c4exd/domain/consensus/model/externalapi"
+	"github.com/c4ei/c4exd/domain/consensus/ruleerrors"
+)
+
+func newTestCoinbaseManager(payoutSplitActivationBlueScore uint64) *coinbaseManager {
+	return New(nil, 0, 0, 150, &externalapi.DomainHash{}, 0, 0, payoutSplitActivationBlueScore,
+		nil, nil, nil, nil, nil, nil, nil).(*coinbaseManager)
+}
+
+func testPayout(scriptByte byte, weight uint16) *externalapi.DomainCoinbasePayout {
+	return &externalapi.DomainCoinbasePayout{
+		ScriptPublicKey: &externalapi.ScriptPublicKey{Script: []byte{scriptByte, scriptByte}, Version: 0},
+		Weight:          weight,
+	}
+}
+
+func TestCoinbasePayloadPayouts(t *testing.T) {
+	const activationBlueScore = 10
+	c := newTestCoinbaseManager(activationBlueScore)
+
+	coinbaseData := &externalapi.DomainCoinbaseData{
+		ScriptPublicKey: &externalapi.ScriptPublicKey{Script: []byte{1, 2, 3}, Version: 0},
+		ExtraData:       []byte("pool"),
+		Payouts:         []*externalapi.DomainCoinbasePayout{testPayout(4, 2500), testPayout(5, 100)},
+	}
+
+	_, err := c.serializeCoinbasePayload(activationBlueScore-1, coinbaseData, 1000)
+	if !errors.Is(err, ruleerrors.ErrBadCoinbasePayouts) {
+		t.Fatalf("Expected ErrBadCoinbasePayouts before the activation, got: %v", err)
+	}
+
+	payload, err := c.serializeCoinbasePayload(activationBlueScore, coinbaseData, 1000)
+	if err != nil {
+		t.Fatalf("serializeCoinbasePayload: %+v", err)
+	}
+	blueScore, extractedCoinbaseData, subsidy, err := c.ExtractCoinbaseDataBlueScoreAndSubsidy(
+		&externalapi.DomainTransaction{Payload: payload})
+	if err != nil {
+		t.Fatalf("ExtractCoinbaseDataBlueScoreAndSubsidy: %+v", err)
+	}
+	if blueScore != activationBlueScore || subsidy != 1000 {
+		t.Fatalf("Unexpected blue score %d or subsidy %d", blueScore, subsidy)
+	}
+	if !extractedCoinbaseData.Equal(coinbaseData) {
+		t.Fatalf("Extracted coinbase data %+v doesn't match the serialized one", extractedCoinbaseData)
+	}
+
+	// Before the activation the payload has no payouts section, so the same bytes are extra data
+	preActivationData := &externalapi.DomainCoinbaseData{
+		ScriptPublicKey: coinbaseData.ScriptPublicKey,
+		ExtraData:       []byte{0},
+	}
+	payload, err = c.serializeCoinbasePayload(activationBlueScore-1, preActivationData, 1000)
+	if err != nil {
+		t.Fatalf("serializeCoinbasePayload: %+v", err)
+	}
+	_, extractedCoinbaseData, _, err = c.ExtractCoinbaseDataBlueScoreAndSubsidy(
+		&externalapi.DomainTransaction{Payload: payload})
+	if err != nil {
+		t.Fatalf("ExtractCoinbaseDataBlueScoreAndSubsidy: %+v", err)
+	}
+	if !extractedCoinbaseData.Equal(preActivationData) {
+		t.Fatalf("Extracted coinbase data %+v doesn't match the serialized one", extractedCoinbaseData)
+	}
+}
+
+func TestInvalidCoinbasePayouts(t *testing.T) {
+	c := newTestCoinbaseManager(0)
+
+	tests := []struct {
+		name    string
+		payouts []*externalapi.DomainCoinbasePayout
+	}{
+		{
+			name:    "zero weight",
+			payouts: []*externalapi.DomainCoinbasePayout{testPayout(1, 0)},
+		},
+		{
+			name:    "whole reward",
+			payouts: []*externalapi.DomainCoinbasePayout{testPayout(1, externalapi.CoinbasePayoutWeightDenominator)},
+		},
+		{
+			name:    "weights sum to the whole reward",
+			payouts: []*externalapi.DomainCoinbasePayout{testPayout(1, 5000), testPayout(2, 5000)},
+		},
+	}
+
+	for _, test := range tests {
+		coinbaseData := &externalapi.DomainCoinbaseData{
+			ScriptPublicKey: &externalapi.ScriptPublicKey{Script: []byte{1}, Version: 0},
+			Payouts:         test.payouts,
+		}
+		_, err := c.serializeCoinbasePayload(1, coinbaseData, 0)
+		if !errors.Is(err, ruleerrors.ErrBadCoinbasePayouts) {
+			t.Fatalf("%s: expected ErrBadCoinbasePayouts when serializing, got: %v", test.name, err)
+		}
+
+		// Build the payload bypassing the validation, and make sure extracting it fails as well
+		payload, err := serializeCoinbasePayload(1, 0, &externalapi.DomainCoinbaseData{
+			ScriptPublicKey: coinbaseData.ScriptPublicKey}, c.coinbasePayloadScriptPublicKeyMaxLength, 0)
+		if err != nil {
+			t.Fatalf("%s: serializeCoinbasePayload: %+v", test.name, err)
+		}
+		payload = payload[:len(payload)-lengthOfPayoutsCount]
+		payload = append(payload, uint8(len(test.payouts)))
+		for _, payout := range test.payouts {
+			payload = appendUint16(payload, payout.Weight)
+			payload = appendScriptPublicKey(payload, payout.ScriptPublicKey)
+		}
+		_, _, _, err = c.ExtractCoinbaseDataBlueScoreAndSubsidy(&externalapi.DomainTransaction{Payload: payload})
+		if !errors.Is(err, ruleerrors.ErrBadCoinbasePayouts) {
+			t.Fatalf("%s: expected ErrBadCoinbasePayouts when extracting, got: %v", test.name, err)
+		}
+	}
+
+	// A truncated payouts section is a bad payload length
+	payload, err := c.serializeCoinbasePayload(1, &externalapi.DomainCoinbaseData{
+		ScriptPublicKey: &externalapi.ScriptPublicKey{Script: []byte{1}, Version: 0},
+		Payouts:         []*externalapi.DomainCoinbasePayout{testPayout(2, 100)},
+	}, 0)
+	if err != nil {
+		t.Fatalf("serializeCoinbasePayload: %+v", err)
+	}
+	_, _, _, err = c.ExtractCoinbaseDataBlueScoreAndSubsidy(&externalapi.DomainTransaction{Payload: payload[:len(payload)-1]})
+	if !errors.Is(err, ruleerrors.ErrBadCoinbasePayloadLen) {
+		t.Fatalf("Expected ErrBadCoinbasePayloadLen for a truncated payout, got: %v", err)
+	}
+}
+
+func TestSplitReward(t *testing.T) {
+	coinbaseData := &externalapi.DomainCoinbaseData{
+		ScriptPublicKey: &externalapi.ScriptPublicKey{Script: []byte{1}, Version: 0},
+		Payouts:         []*externalapi.DomainCoinbasePayout{testPayout(2, 3333), testPayout(3, 1)},
+	}
+
+	tests := []struct {
+		reward         uint64
+		expectedValues []uint64
+	}{
+		{reward: 10000, expectedValues: []uint64{6666, 3333, 1}},
+		{reward: 100, expectedValues: []uint64{67, 33}},
+		{reward: 1, expectedValues: []uint64{1}},
+		{reward: ^uint64(0), expectedValues: []uint64{12296599599534787107, 6148299799767393553, 1844674407370955}},
+	}
+
+	for _, test := range tests {
+		txOuts := splitReward(test.reward, coinbaseData)
+		if len(txOuts) != len(test.expectedValues) {
+			t.Fatalf("reward %d: expected %d outputs, got %d", test.reward, len(test.expectedValues), len(txOuts))
+		}
+		total := uint64(0)
+		for i, txOut := range txOuts {
+			if txOut.Value != test.expectedValues[i] {
+				t.Fatalf("reward %d: expected output #%d to be %d, got %d",
+					test.reward, i, test.expectedValues[i], txOut.Value)
+			}
+			total += txOut.Value
+		}
+		if total != test.reward {
+			t.Fatalf("reward %d: the outputs sum to %d", test.reward, total)
+		}
+		if !txOuts[0].ScriptPublicKey.Equal(coinbaseData.ScriptPublicKey) {
+			t.Fatalf("reward %d: the first output doesn't pay the main script public key", test.reward)
+		}
+	}
+}
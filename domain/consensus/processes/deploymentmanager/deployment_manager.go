@@ -0,0 +1,443 @@
+package deploymentmanager
+
+import (
+	"github.com/c4ei/c4exd/domain/consensus/model"
+	"github.com/c4ei/c4exd/domain/consensus/model/externalapi"
+	"github.com/c4ei/c4exd/domain/consensus/ruleerrors"
+	"github.com/c4ei/c4exd/domain/consensus/utils/constants"
+	"github.com/c4ei/c4exd/infrastructure/db/database"
+	"github.com/pkg/errors"
+)
+
+// deploymentManager computes the states of the consensus deployments, in the
+// spirit of BIP0009. The DAG is divided into windows of `window` DAA score each,
+// and the state of every deployment may change only when the selected chain
+// crosses into a new window:
+//
+//	defined   -> started   once the new window starts at or after StartDAAScore
+//	started   -> locked-in if enough chain blocks of the previous window signaled
+//	locked-in -> active    unconditionally
+//	defined/started -> failed once the new window starts at or after TimeoutDAAScore
+//
+// A deployment is considered signaled for in a window if at least
+// threshold/window of the chain blocks in that window signaled for it.
+//
+// The states are computed for every chain block from the states of its selected
+// parent, and are cached in the deployment state store. The computation starts
+// from genesis. Nodes that synced from a pruning point proof don't have the
+// selected chain below the pruning point, so they receive the states of the
+// pruning point and its anticone along with the rest of their trusted data.
+type deploymentManager struct {
+	databaseContext model.DBReader
+	genesisHash     *externalapi.DomainHash
+
+	ghostdagDataStore    model.GHOSTDAGDataStore
+	blockHeaderStore     model.BlockHeaderStore
+	daaBlocksStore       model.DAABlocksStore
+	deploymentStateStore model.DeploymentStateStore
+
+	deployments []externalapi.ConsensusDeployment
+	threshold   uint64
+	window      uint64
+}
+
+// New instantiates a new DeploymentManager
+func New(
+	databaseContext model.DBReader,
+	genesisHash *externalapi.DomainHash,
+
+	ghostdagDataStore model.GHOSTDAGDataStore,
+	blockHeaderStore model.BlockHeaderStore,
+	daaBlocksStore model.DAABlocksStore,
+	deploymentStateStore model.DeploymentStateStore,
+
+	deployments []externalapi.ConsensusDeployment,
+	threshold uint64,
+	window uint64,
+) model.DeploymentManager {
+
+	return &deploymentManager{
+		databaseContext: databaseContext,
+		genesisHash:     genesisHash,
+
+		ghostdagDataStore:    ghostdagDataStore,
+		blockHeaderStore:     blockHeaderStore,
+		daaBlocksStore:       daaBlocksStore,
+		deploymentStateStore: deploymentStateStore,
+
+		deployments: deployments,
+		threshold:   threshold,
+		window:      window,
+	}
+}
+
+// DeploymentStates returns the states of all the deployments in the given block,
+// in the order of the deployments in the network parameters
+func (dm *deploymentManager) DeploymentStates(stagingArea *model.StagingArea,
+	blockHash *externalapi.DomainHash) ([]externalapi.DeploymentState, error) {
+
+	if len(dm.deployments) == 0 || dm.window == 0 {
+		return dm.initialStates(), nil
+	}
+
+	// Walk down the selected chain until reaching a block with known states,
+	// then compute the states of the blocks above it one by one.
+	var uncomputedBlocks []*externalapi.DomainHash
+	var states []externalapi.DeploymentState
+	current := blockHash
+	for {
+		cachedStates, found, err := dm.cachedStates(stagingArea, current)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			states = cachedStates
+			break
+		}
+
+		selectedParent, err := dm.selectedParent(stagingArea, current)
+		if err != nil {
+			return nil, err
+		}
+		if selectedParent == nil {
+			// Any other block without a selected parent is a block with trusted data,
+			// whose states were received with it
+			if !current.Equal(dm.genesisHash) {
+				return nil, errors.Errorf("the deployment states of %s are unknown and its "+
+					"selected chain is not available to compute them", current)
+			}
+			states = dm.initialStates()
+			dm.stageStates(stagingArea, current, states)
+			break
+		}
+
+		uncomputedBlocks = append(uncomputedBlocks, current)
+		current = selectedParent
+	}
+
+	for i := len(uncomputedBlocks) - 1; i >= 0; i-- {
+		block := uncomputedBlocks[i]
+		daaScore, err := dm.daaScore(stagingArea, block)
+		if err != nil {
+			return nil, err
+		}
+
+		states, err = dm.nextStates(stagingArea, current, states, daaScore)
+		if err != nil {
+			return nil, err
+		}
+		dm.stageStates(stagingArea, block, states)
+		current = block
+	}
+
+	return states, nil
+}
+
+// HasDeploymentStates returns whether the states of all the deployments are stored for the given block
+func (dm *deploymentManager) HasDeploymentStates(stagingArea *model.StagingArea,
+	blockHash *externalapi.DomainHash) (bool, error) {
+
+	if len(dm.deployments) == 0 || dm.window == 0 {
+		return true, nil
+	}
+
+	_, found, err := dm.cachedStates(stagingArea, blockHash)
+	return found, err
+}
+
+// StageTrustedDeploymentStates stages the deployment states that were received along with a block
+// with trusted data, whose selected chain is not available to compute them from
+func (dm *deploymentManager) StageTrustedDeploymentStates(stagingArea *model.StagingArea,
+	blockHash *externalapi.DomainHash, states []externalapi.DeploymentState) error {
+
+	// The states of genesis are the initial states, and are computed like those of any other block
+	if blockHash.Equal(dm.genesisHash) || len(dm.deployments) == 0 || dm.window == 0 {
+		return nil
+	}
+
+	if len(states) != len(dm.deployments) {
+		return errors.Wrapf(ruleerrors.ErrInvalidTrustedDeploymentStates, "expected the states of "+
+			"%d deployments for block %s but got %d", len(dm.deployments), blockHash, len(states))
+	}
+	for i, state := range states {
+		if state > externalapi.DeploymentStateFailed {
+			return errors.Wrapf(ruleerrors.ErrInvalidTrustedDeploymentStates, "unknown state %d of "+
+				"deployment %s for block %s", state, dm.deployments[i].Name, blockHash)
+		}
+	}
+
+	dm.stageStates(stagingArea, blockHash, states)
+	return nil
+}
+
+// IsDeploymentActive returns whether the deployment with the given name is active in the given block
+func (dm *deploymentManager) IsDeploymentActive(stagingArea *model.StagingArea, blockHash *externalapi.DomainHash,
+	deploymentName string) (bool, error) {
+
+	for i, deployment := range dm.deployments {
+		if deployment.Name != deploymentName {
+			continue
+		}
+
+		states, err := dm.DeploymentStates(stagingArea, blockHash)
+		if err != nil {
+			return false, err
+		}
+		return states[i] == externalapi.DeploymentStateActive, nil
+	}
+
+	return false, errors.Errorf("unknown deployment %s", deploymentName)
+}
+
+// ActiveDeployments returns the deployments that are active in the given block
+func (dm *deploymentManager) ActiveDeployments(stagingArea *model.StagingArea,
+	blockHash *externalapi.DomainHash) ([]*externalapi.ConsensusDeployment, error) {
+
+	if len(dm.deployments) == 0 {
+		return nil, nil
+	}
+
+	states, err := dm.DeploymentStates(stagingArea, blockHash)
+	if err != nil {
+		return nil, err
+	}
+
+	var activeDeployments []*externalapi.ConsensusDeployment
+	for i, state := range states {
+		if state == externalapi.DeploymentStateActive {
+			activeDeployments = append(activeDeployments, &dm.deployments[i])
+		}
+	}
+	return activeDeployments, nil
+}
+
+// BlockVersion returns the version of a block whose states are the states of the given
+// block: the version signals for every started deployment, or is constants.BlockVersion
+// if there are none.
+func (dm *deploymentManager) BlockVersion(stagingArea *model.StagingArea, blockHash *externalapi.DomainHash) (uint16, error) {
+	if len(dm.deployments) == 0 {
+		return constants.BlockVersion, nil
+	}
+
+	states, err := dm.DeploymentStates(stagingArea, blockHash)
+	if err != nil {
+		return 0, err
+	}
+
+	signals := uint16(0)
+	for i, state := range states {
+		if state == externalapi.DeploymentStateStarted {
+			signals |= 1 << dm.deployments[i].BitNumber
+		}
+	}
+	if signals == 0 {
+		return constants.BlockVersion, nil
+	}
+	return constants.VersionBitsTopBits | signals, nil
+}
+
+// IsSignalingVersionAllowed returns whether a block with the given DAA score may have the given
+// signaling version: every deployment it signals for must be defined, and the window of the
+// block must be within the signaling period of that deployment.
+func (dm *deploymentManager) IsSignalingVersionAllowed(version uint16, daaScore uint64) bool {
+	if version&constants.VersionBitsTopMask != constants.VersionBitsTopBits {
+		return false
+	}
+	signals := version &^ constants.VersionBitsTopMask
+	if signals == 0 {
+		return false
+	}
+
+	// A deployment is started from the first window that starts at or after its start DAA
+	// score, until the first window that starts at or after its timeout DAA score
+	windowStartDAAScore := daaScore / dm.window * dm.window
+	for _, deployment := range dm.deployments {
+		bit := uint16(1) << deployment.BitNumber
+		if signals&bit == 0 {
+			continue
+		}
+		if windowStartDAAScore >= deployment.StartDAAScore && windowStartDAAScore < deployment.TimeoutDAAScore {
+			signals &^= bit
+		}
+	}
+	return signals == 0
+}
+
+// nextStates computes the states of a block with the given DAA score, given its
+// selected parent and the states of the selected parent.
+func (dm *deploymentManager) nextStates(stagingArea *model.StagingArea, selectedParent *externalapi.DomainHash,
+	selectedParentStates []externalapi.DeploymentState, daaScore uint64) ([]externalapi.DeploymentState, error) {
+
+	selectedParentDAAScore, err := dm.daaScore(stagingArea, selectedParent)
+	if err != nil {
+		return nil, err
+	}
+
+	selectedParentWindow := selectedParentDAAScore / dm.window
+	blockWindow := daaScore / dm.window
+	if blockWindow == selectedParentWindow {
+		return selectedParentStates, nil
+	}
+
+	var signalCounts []uint64
+	var chainBlockCount uint64
+	for _, state := range selectedParentStates {
+		if state == externalapi.DeploymentStateStarted {
+			signalCounts, chainBlockCount, err = dm.countSignals(stagingArea, selectedParent, selectedParentWindow)
+			if err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	states := make([]externalapi.DeploymentState, len(selectedParentStates))
+	copy(states, selectedParentStates)
+
+	// Windows that no chain block falls into have no signals
+	for window := selectedParentWindow + 1; window <= blockWindow; window++ {
+		windowStartDAAScore := window * dm.window
+		for i, deployment := range dm.deployments {
+			switch states[i] {
+			case externalapi.DeploymentStateDefined:
+				if windowStartDAAScore >= deployment.TimeoutDAAScore {
+					states[i] = externalapi.DeploymentStateFailed
+				} else if windowStartDAAScore >= deployment.StartDAAScore {
+					states[i] = externalapi.DeploymentStateStarted
+				}
+
+			case externalapi.DeploymentStateStarted:
+				if windowStartDAAScore >= deployment.TimeoutDAAScore {
+					states[i] = externalapi.DeploymentStateFailed
+				} else if window == selectedParentWindow+1 && dm.isThresholdReached(signalCounts[i], chainBlockCount) {
+					states[i] = externalapi.DeploymentStateLockedIn
+				}
+
+			case externalapi.DeploymentStateLockedIn:
+				states[i] = externalapi.DeploymentStateActive
+			}
+		}
+	}
+
+	return states, nil
+}
+
+// countSignals counts the signals for every deployment in the chain blocks of the given
+// window, starting from the given chain block which is the last one in the window.
+func (dm *deploymentManager) countSignals(stagingArea *model.StagingArea, lastChainBlock *externalapi.DomainHash,
+	window uint64) (signalCounts []uint64, chainBlockCount uint64, err error) {
+
+	signalCounts = make([]uint64, len(dm.deployments))
+	windowStartDAAScore := window * dm.window
+	current := lastChainBlock
+	for current != nil {
+		header, err := dm.blockHeaderStore.BlockHeader(dm.databaseContext, stagingArea, current)
+		if err != nil {
+			return nil, 0, err
+		}
+		if header.DAAScore() < windowStartDAAScore {
+			break
+		}
+
+		chainBlockCount++
+		version := header.Version()
+		if version&constants.VersionBitsTopMask == constants.VersionBitsTopBits {
+			for i, deployment := range dm.deployments {
+				if version&(1<<deployment.BitNumber) != 0 {
+					signalCounts[i]++
+				}
+			}
+		}
+
+		current, err = dm.selectedParent(stagingArea, current)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return signalCounts, chainBlockCount, nil
+}
+
+func (dm *deploymentManager) isThresholdReached(signalCount uint64, chainBlockCount uint64) bool {
+	return chainBlockCount > 0 && signalCount*dm.window >= dm.threshold*chainBlockCount
+}
+
+func (dm *deploymentManager) initialStates() []externalapi.DeploymentState {
+	states := make([]externalapi.DeploymentState, len(dm.deployments))
+	for i := range states {
+		states[i] = externalapi.DeploymentStateDefined
+	}
+	return states
+}
+
+func (dm *deploymentManager) cachedStates(stagingArea *model.StagingArea,
+	blockHash *externalapi.DomainHash) ([]externalapi.DeploymentState, bool, error) {
+
+	// The states of the virtual change with every virtual resolution, so they're never cached
+	if blockHash.Equal(model.VirtualBlockHash) {
+		return nil, false, nil
+	}
+
+	states, err := dm.deploymentStateStore.DeploymentStates(dm.databaseContext, stagingArea, blockHash)
+	if database.IsNotFoundError(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	// States that were cached before a deployment was added are recomputed
+	if len(states) != len(dm.deployments) {
+		return nil, false, nil
+	}
+	return states, true, nil
+}
+
+func (dm *deploymentManager) stageStates(stagingArea *model.StagingArea, blockHash *externalapi.DomainHash,
+	states []externalapi.DeploymentState) {
+
+	if blockHash.Equal(model.VirtualBlockHash) {
+		return
+	}
+	dm.deploymentStateStore.Stage(stagingArea, blockHash, states)
+}
+
+// selectedParent returns the selected parent of the given block, or nil if the
+// block is genesis or a block with trusted data
+func (dm *deploymentManager) selectedParent(stagingArea *model.StagingArea,
+	blockHash *externalapi.DomainHash) (*externalapi.DomainHash, error) {
+
+	ghostdagData, err := dm.ghostdagDataStore.Get(dm.databaseContext, stagingArea, blockHash, false)
+	if database.IsNotFoundError(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	selectedParent := ghostdagData.SelectedParent()
+	if selectedParent == nil || selectedParent.Equal(model.VirtualGenesisBlockHash) {
+		return nil, nil
+	}
+
+	hasHeader, err := dm.blockHeaderStore.HasBlockHeader(dm.databaseContext, stagingArea, selectedParent)
+	if err != nil {
+		return nil, err
+	}
+	if !hasHeader {
+		return nil, nil
+	}
+	return selectedParent, nil
+}
+
+func (dm *deploymentManager) daaScore(stagingArea *model.StagingArea, blockHash *externalapi.DomainHash) (uint64, error) {
+	if blockHash.Equal(model.VirtualBlockHash) {
+		return dm.daaBlocksStore.DAAScore(dm.databaseContext, stagingArea, blockHash)
+	}
+
+	header, err := dm.blockHeaderStore.BlockHeader(dm.databaseContext, stagingArea, blockHash)
+	if err != nil {
+		return 0, err
+	}
+	return header.DAAScore(), nil
+}
@@ -0,0 +1,393 @@
+package deploymentmanager_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/c4ei/c4exd/domain/consensus"
+	"github.com/c4ei/c4exd/domain/consensus/model"
+	"github.com/c4ei/c4exd/domain/consensus/model/externalapi"
+	"github.com/c4ei/c4exd/domain/consensus/model/testapi"
+	"github.com/c4ei/c4exd/domain/consensus/ruleerrors"
+	"github.com/c4ei/c4exd/domain/consensus/utils/constants"
+	"github.com/c4ei/c4exd/domain/dagconfig"
+	"github.com/pkg/errors"
+)
+
+func TestDeploymentStates(t *testing.T) {
+	consensusConfig := &consensus.Config{Params: dagconfig.SimnetParams}
+	consensusConfig.SkipProofOfWork = true
+	consensusConfig.MinerConfirmationWindow = 10
+	consensusConfig.RuleChangeActivationThreshold = 8
+	consensusConfig.Deployments = []externalapi.ConsensusDeployment{
+		{Name: "testdummy", BitNumber: 0, StartDAAScore: 20, TimeoutDAAScore: 1000},
+		{Name: "testexpired", BitNumber: 1, StartDAAScore: 20, TimeoutDAAScore: 30},
+	}
+
+	factory := consensus.NewFactory()
+	tc, teardown, err := factory.NewTestConsensus(consensusConfig, "TestDeploymentStates")
+	if err != nil {
+		t.Fatalf("Error setting up consensus: %+v", err)
+	}
+	defer teardown(false)
+
+	checkStates := func(stage string, expected ...externalapi.DeploymentState) {
+		states, err := tc.DeploymentManager().DeploymentStates(model.NewStagingArea(), model.VirtualBlockHash)
+		if err != nil {
+			t.Fatalf("%s: DeploymentStates: %+v", stage, err)
+		}
+		for i := range expected {
+			if states[i] != expected[i] {
+				t.Fatalf("%s: expected deployment %s to be %s but got %s",
+					stage, consensusConfig.Deployments[i].Name, expected[i], states[i])
+			}
+		}
+
+		virtualStates, err := tc.GetVirtualDeploymentStates()
+		if err != nil {
+			t.Fatalf("%s: GetVirtualDeploymentStates: %+v", stage, err)
+		}
+		for i := range expected {
+			if virtualStates[i] != expected[i] {
+				t.Fatalf("%s: expected virtual state of deployment %s to be %s but got %s",
+					stage, consensusConfig.Deployments[i].Name, expected[i], virtualStates[i])
+			}
+		}
+	}
+
+	checkStates("genesis", externalapi.DeploymentStateDefined, externalapi.DeploymentStateDefined)
+
+	// Blocks from the test block builder never signal
+	addNonSignalingBlocksUntil(t, tc, 20)
+	checkStates("start reached", externalapi.DeploymentStateStarted, externalapi.DeploymentStateStarted)
+
+	version, err := tc.DeploymentManager().BlockVersion(model.NewStagingArea(), model.VirtualBlockHash)
+	if err != nil {
+		t.Fatalf("BlockVersion: %+v", err)
+	}
+	expectedVersion := constants.VersionBitsTopBits | 1<<0 | 1<<1
+	if version != expectedVersion {
+		t.Fatalf("Expected block version %x but got %x", expectedVersion, version)
+	}
+
+	// No block signaled in the window, so testdummy stays started while testexpired times out
+	addNonSignalingBlocksUntil(t, tc, 30)
+	checkStates("timeout reached", externalapi.DeploymentStateStarted, externalapi.DeploymentStateFailed)
+
+	// Blocks from the block builder signal for all started deployments
+	addSignalingBlocksUntil(t, tc, 40)
+	checkStates("threshold reached", externalapi.DeploymentStateLockedIn, externalapi.DeploymentStateFailed)
+
+	addNonSignalingBlocksUntil(t, tc, 50)
+	checkStates("activation", externalapi.DeploymentStateActive, externalapi.DeploymentStateFailed)
+
+	isActive, err := tc.DeploymentManager().IsDeploymentActive(model.NewStagingArea(), model.VirtualBlockHash, "testdummy")
+	if err != nil {
+		t.Fatalf("IsDeploymentActive: %+v", err)
+	}
+	if !isActive {
+		t.Fatalf("Expected testdummy to be active")
+	}
+
+	version, err = tc.DeploymentManager().BlockVersion(model.NewStagingArea(), model.VirtualBlockHash)
+	if err != nil {
+		t.Fatalf("BlockVersion: %+v", err)
+	}
+	if version != constants.BlockVersion {
+		t.Fatalf("Expected block version %x once no deployment is started but got %x", constants.BlockVersion, version)
+	}
+}
+
+func TestDeploymentNotLockedInBelowThreshold(t *testing.T) {
+	consensusConfig := &consensus.Config{Params: dagconfig.SimnetParams}
+	consensusConfig.SkipProofOfWork = true
+	consensusConfig.MinerConfirmationWindow = 10
+	consensusConfig.RuleChangeActivationThreshold = 8
+	consensusConfig.Deployments = []externalapi.ConsensusDeployment{
+		{Name: "testdummy", BitNumber: 0, StartDAAScore: 20, TimeoutDAAScore: 1000},
+	}
+
+	factory := consensus.NewFactory()
+	tc, teardown, err := factory.NewTestConsensus(consensusConfig, "TestDeploymentNotLockedInBelowThreshold")
+	if err != nil {
+		t.Fatalf("Error setting up consensus: %+v", err)
+	}
+	defer teardown(false)
+
+	// Only the first half of the window signals
+	addNonSignalingBlocksUntil(t, tc, 20)
+	addSignalingBlocksUntil(t, tc, 25)
+	addNonSignalingBlocksUntil(t, tc, 40)
+
+	states, err := tc.DeploymentManager().DeploymentStates(model.NewStagingArea(), model.VirtualBlockHash)
+	if err != nil {
+		t.Fatalf("DeploymentStates: %+v", err)
+	}
+	if states[0] != externalapi.DeploymentStateStarted {
+		t.Fatalf("Expected testdummy to stay started but got %s", states[0])
+	}
+}
+
+func TestIsSignalingVersionAllowed(t *testing.T) {
+	consensusConfig := &consensus.Config{Params: dagconfig.SimnetParams}
+	consensusConfig.SkipProofOfWork = true
+	consensusConfig.MinerConfirmationWindow = 10
+	consensusConfig.RuleChangeActivationThreshold = 8
+	consensusConfig.Deployments = []externalapi.ConsensusDeployment{
+		{Name: "testdummy", BitNumber: 0, StartDAAScore: 20, TimeoutDAAScore: 45},
+	}
+
+	factory := consensus.NewFactory()
+	tc, teardown, err := factory.NewTestConsensus(consensusConfig, "TestIsSignalingVersionAllowed")
+	if err != nil {
+		t.Fatalf("Error setting up consensus: %+v", err)
+	}
+	defer teardown(false)
+
+	tests := []struct {
+		name     string
+		version  uint16
+		daaScore uint64
+		expected bool
+	}{
+		{name: "before the start", version: constants.VersionBitsTopBits | 1, daaScore: 19, expected: false},
+		{name: "first signaling window", version: constants.VersionBitsTopBits | 1, daaScore: 20, expected: true},
+		{name: "last signaling window", version: constants.VersionBitsTopBits | 1, daaScore: 49, expected: true},
+		{name: "after the timeout", version: constants.VersionBitsTopBits | 1, daaScore: 50, expected: false},
+		{name: "undefined bit", version: constants.VersionBitsTopBits | 1<<1, daaScore: 30, expected: false},
+		{name: "defined and undefined bits", version: constants.VersionBitsTopBits | 1 | 1<<1, daaScore: 30, expected: false},
+		{name: "no signals", version: constants.VersionBitsTopBits, daaScore: 30, expected: false},
+		{name: "wrong top bits", version: 0x4001, daaScore: 30, expected: false},
+	}
+	for _, test := range tests {
+		isAllowed := tc.DeploymentManager().IsSignalingVersionAllowed(test.version, test.daaScore)
+		if isAllowed != test.expected {
+			t.Errorf("%s: expected IsSignalingVersionAllowed(%x, %d) to be %t", test.name, test.version,
+				test.daaScore, test.expected)
+		}
+	}
+}
+
+func TestDeploymentStatesOfNodeSyncedFromTrustedData(t *testing.T) {
+	consensusConfig := &consensus.Config{Params: dagconfig.SimnetParams}
+	consensusConfig.SkipProofOfWork = true
+	consensusConfig.MinerConfirmationWindow = 10
+	consensusConfig.RuleChangeActivationThreshold = 8
+	consensusConfig.Deployments = []externalapi.ConsensusDeployment{
+		{Name: "testdummy", BitNumber: 0, StartDAAScore: 20, TimeoutDAAScore: 1000},
+	}
+	// This reduces the pruning depth to 12 blocks
+	consensusConfig.FinalityDuration = 5 * consensusConfig.TargetTimePerBlock
+	consensusConfig.K = 0
+	consensusConfig.PruningProofM = 1
+	// Blocks in the DAA window of the pruning point are kept, so a small window lets early blocks be pruned.
+	// The past median time window has to fit in the DAA window that is sent with the trusted data.
+	consensusConfig.DifficultyAdjustmentWindowSize = 10
+	consensusConfig.TimestampDeviationTolerance = 5
+
+	factory := consensus.NewFactory()
+	tcSyncer, teardownSyncer, err := factory.NewTestConsensus(consensusConfig, "TestDeploymentStatesOfNodeSyncedFromTrustedData")
+	if err != nil {
+		t.Fatalf("Error setting up tcSyncer: %+v", err)
+	}
+	defer teardownSyncer(false)
+
+	firstBlock, _, err := tcSyncer.AddBlock([]*externalapi.DomainHash{consensusConfig.GenesisHash}, nil, nil)
+	if err != nil {
+		t.Fatalf("AddBlock: %+v", err)
+	}
+	addNonSignalingBlocksUntil(t, tcSyncer, 20)
+	addSignalingBlocksUntil(t, tcSyncer, 30)
+	addNonSignalingBlocksUntil(t, tcSyncer, 100)
+
+	pruningPoint, err := tcSyncer.PruningPoint()
+	if err != nil {
+		t.Fatalf("PruningPoint: %+v", err)
+	}
+	pruningPointStates, err := tcSyncer.TrustedDeploymentStates(pruningPoint)
+	if err != nil {
+		t.Fatalf("TrustedDeploymentStates: %+v", err)
+	}
+	if pruningPointStates[0] != externalapi.DeploymentStateActive {
+		t.Fatalf("Expected testdummy to be active in the pruning point but got %s", pruningPointStates[0])
+	}
+
+	// The states of pruned blocks are deleted along with the rest of their data
+	hasStates, err := tcSyncer.DeploymentManager().HasDeploymentStates(model.NewStagingArea(), firstBlock)
+	if err != nil {
+		t.Fatalf("HasDeploymentStates: %+v", err)
+	}
+	if hasStates {
+		t.Fatalf("Expected the deployment states of a pruned block to be deleted")
+	}
+
+	stagingConfig := *consensusConfig
+	stagingConfig.SkipAddingGenesis = true
+	tcSyncee, teardownSyncee, err := factory.NewTestConsensus(&stagingConfig, "TestDeploymentStatesOfNodeSyncedFromTrustedDataSyncee")
+	if err != nil {
+		t.Fatalf("Error setting up tcSyncee: %+v", err)
+	}
+	defer teardownSyncee(false)
+
+	pruningPointProof, err := tcSyncer.BuildPruningPointProof()
+	if err != nil {
+		t.Fatalf("BuildPruningPointProof: %+v", err)
+	}
+	err = tcSyncee.ApplyPruningPointProof(pruningPointProof)
+	if err != nil {
+		t.Fatalf("ApplyPruningPointProof: %+v", err)
+	}
+	pruningPointHeaders, err := tcSyncer.PruningPointHeaders()
+	if err != nil {
+		t.Fatalf("PruningPointHeaders: %+v", err)
+	}
+	err = tcSyncee.ImportPruningPoints(pruningPointHeaders)
+	if err != nil {
+		t.Fatalf("ImportPruningPoints: %+v", err)
+	}
+
+	pruningPointAndItsAnticone, err := tcSyncer.PruningPointAndItsAnticone()
+	if err != nil {
+		t.Fatalf("PruningPointAndItsAnticone: %+v", err)
+	}
+	for i, blockHash := range pruningPointAndItsAnticone {
+		blockWithTrustedData := blockWithTrustedDataForTest(t, tcSyncer, blockHash)
+
+		// A block with trusted data must come with the states of all the deployments
+		if i == 0 {
+			deploymentStates := blockWithTrustedData.DeploymentStates
+			blockWithTrustedData.DeploymentStates = nil
+			err = tcSyncee.ValidateAndInsertBlockWithTrustedData(blockWithTrustedData, false)
+			if !errors.Is(err, ruleerrors.ErrInvalidTrustedDeploymentStates) {
+				t.Fatalf("Expected ErrInvalidTrustedDeploymentStates but got: %+v", err)
+			}
+			blockWithTrustedData.DeploymentStates = deploymentStates
+		}
+
+		err = tcSyncee.ValidateAndInsertBlockWithTrustedData(blockWithTrustedData, false)
+		if err != nil {
+			t.Fatalf("ValidateAndInsertBlockWithTrustedData: %+v", err)
+		}
+	}
+
+	syncerVirtualSelectedParent := virtualSelectedParent(t, tcSyncer)
+	missingHeaderHashes, _, err := tcSyncer.GetHashesBetween(pruningPoint, syncerVirtualSelectedParent, math.MaxUint64)
+	if err != nil {
+		t.Fatalf("GetHashesBetween: %+v", err)
+	}
+	for _, blockHash := range missingHeaderHashes {
+		blockInfo, err := tcSyncee.GetBlockInfo(blockHash)
+		if err != nil {
+			t.Fatalf("GetBlockInfo: %+v", err)
+		}
+		if blockInfo.Exists {
+			continue
+		}
+
+		header, err := tcSyncer.GetBlockHeader(blockHash)
+		if err != nil {
+			t.Fatalf("GetBlockHeader: %+v", err)
+		}
+		err = tcSyncee.ValidateAndInsertBlock(&externalapi.DomainBlock{Header: header}, false)
+		if err != nil {
+			t.Fatalf("ValidateAndInsertBlock: %+v", err)
+		}
+	}
+
+	synceeStates, err := tcSyncee.DeploymentManager().DeploymentStates(model.NewStagingArea(), syncerVirtualSelectedParent)
+	if err != nil {
+		t.Fatalf("DeploymentStates: %+v", err)
+	}
+	if synceeStates[0] != externalapi.DeploymentStateActive {
+		t.Fatalf("Expected testdummy to be active in the syncee but got %s", synceeStates[0])
+	}
+}
+
+func blockWithTrustedDataForTest(t *testing.T, tcSyncer testapi.TestConsensus,
+	blockHash *externalapi.DomainHash) *externalapi.BlockWithTrustedData {
+
+	block, _, err := tcSyncer.GetBlock(blockHash)
+	if err != nil {
+		t.Fatalf("GetBlock: %+v", err)
+	}
+	blockDAAWindowHashes, err := tcSyncer.BlockDAAWindowHashes(blockHash)
+	if err != nil {
+		t.Fatalf("BlockDAAWindowHashes: %+v", err)
+	}
+	ghostdagDataBlockHashes, err := tcSyncer.TrustedBlockAssociatedGHOSTDAGDataBlockHashes(blockHash)
+	if err != nil {
+		t.Fatalf("TrustedBlockAssociatedGHOSTDAGDataBlockHashes: %+v", err)
+	}
+	deploymentStates, err := tcSyncer.TrustedDeploymentStates(blockHash)
+	if err != nil {
+		t.Fatalf("TrustedDeploymentStates: %+v", err)
+	}
+
+	blockWithTrustedData := &externalapi.BlockWithTrustedData{
+		Block:            block,
+		DAAWindow:        make([]*externalapi.TrustedDataDataDAAHeader, 0, len(blockDAAWindowHashes)),
+		GHOSTDAGData:     make([]*externalapi.BlockGHOSTDAGDataHashPair, 0, len(ghostdagDataBlockHashes)),
+		DeploymentStates: deploymentStates,
+	}
+	for i, daaBlockHash := range blockDAAWindowHashes {
+		trustedDataDataDAAHeader, err := tcSyncer.TrustedDataDataDAAHeader(blockHash, daaBlockHash, uint64(i))
+		if err != nil {
+			t.Fatalf("TrustedDataDataDAAHeader: %+v", err)
+		}
+		blockWithTrustedData.DAAWindow = append(blockWithTrustedData.DAAWindow, trustedDataDataDAAHeader)
+	}
+	for _, ghostdagDataBlockHash := range ghostdagDataBlockHashes {
+		data, err := tcSyncer.TrustedGHOSTDAGData(ghostdagDataBlockHash)
+		if err != nil {
+			t.Fatalf("TrustedGHOSTDAGData: %+v", err)
+		}
+		blockWithTrustedData.GHOSTDAGData = append(blockWithTrustedData.GHOSTDAGData, &externalapi.BlockGHOSTDAGDataHashPair{
+			Hash:         ghostdagDataBlockHash,
+			GHOSTDAGData: data,
+		})
+	}
+	return blockWithTrustedData
+}
+
+func addNonSignalingBlocksUntil(t *testing.T, tc testapi.TestConsensus, daaScore uint64) {
+	for virtualDAAScore(t, tc) < daaScore {
+		_, _, err := tc.AddBlock([]*externalapi.DomainHash{virtualSelectedParent(t, tc)}, nil, nil)
+		if err != nil {
+			t.Fatalf("AddBlock: %+v", err)
+		}
+	}
+}
+
+func addSignalingBlocksUntil(t *testing.T, tc testapi.TestConsensus, daaScore uint64) {
+	for virtualDAAScore(t, tc) < daaScore {
+		block, err := tc.BuildBlock(&externalapi.DomainCoinbaseData{
+			ScriptPublicKey: &externalapi.ScriptPublicKey{Script: nil, Version: 0},
+		}, nil)
+		if err != nil {
+			t.Fatalf("BuildBlock: %+v", err)
+		}
+		if block.Header.Version()&constants.VersionBitsTopMask != constants.VersionBitsTopBits {
+			t.Fatalf("Expected block version %x to signal", block.Header.Version())
+		}
+		err = tc.ValidateAndInsertBlock(block, true)
+		if err != nil {
+			t.Fatalf("ValidateAndInsertBlock: %+v", err)
+		}
+	}
+}
+
+func virtualDAAScore(t *testing.T, tc testapi.TestConsensus) uint64 {
+	daaScore, err := tc.GetVirtualDAAScore()
+	if err != nil {
+		t.Fatalf("GetVirtualDAAScore: %+v", err)
+	}
+	return daaScore
+}
+
+func virtualSelectedParent(t *testing.T, tc testapi.TestConsensus) *externalapi.DomainHash {
+	virtualSelectedParent, err := tc.GetVirtualSelectedParent()
+	if err != nil {
+		t.Fatalf("GetVirtualSelectedParent: %+v", err)
+	}
+	return virtualSelectedParent
+}
@@ -224,3 +224,179 @@ func TestPruning(t *testing.T) {
 		}
 	})
 }
+
+func TestPruningRetention(t *testing.T) {
+	const retentionDepth = 200
+
+	consensusConfig := &consensus.Config{Params: dagconfig.SimnetParams}
+	consensusConfig.SkipProofOfWork = true
+	consensusConfig.DisableDifficultyAdjustment = true
+	consensusConfig.FinalityDuration = 7 * consensusConfig.TargetTimePerBlock
+	consensusConfig.MergeSetSizeLimit = 5
+	consensusConfig.DifficultyAdjustmentWindowSize = 10
+	consensusConfig.RetentionDepth = retentionDepth
+
+	factory := consensus.NewFactory()
+	tc, teardown, err := factory.NewTestConsensus(consensusConfig, "TestPruningRetention")
+	if err != nil {
+		t.Fatalf("Error setting up consensus: %+v", err)
+	}
+	defer teardown(false)
+
+	chain := []*externalapi.DomainHash{consensusConfig.GenesisHash}
+	for i := uint64(0); i < 2*consensusConfig.PruningDepth()+retentionDepth; i++ {
+		blockHash, _, err := tc.AddBlock([]*externalapi.DomainHash{chain[len(chain)-1]}, nil, nil)
+		if err != nil {
+			t.Fatalf("AddBlock: %+v", err)
+		}
+		chain = append(chain, blockHash)
+	}
+
+	pruningPoint, err := tc.PruningPoint()
+	if err != nil {
+		t.Fatalf("PruningPoint: %+v", err)
+	}
+	if pruningPoint.Equal(consensusConfig.GenesisHash) {
+		t.Fatalf("Expected the pruning point to move")
+	}
+	pruningPointHeader, err := tc.GetBlockHeader(pruningPoint)
+	if err != nil {
+		t.Fatalf("GetBlockHeader: %+v", err)
+	}
+	minDAAScore := pruningPointHeader.DAAScore() - retentionDepth
+
+	earliestAvailableBlock, err := tc.EarliestAvailableBlock()
+	if err != nil {
+		t.Fatalf("EarliestAvailableBlock: %+v", err)
+	}
+	earliestAvailableBlockHeader, err := tc.GetBlockHeader(earliestAvailableBlock)
+	if err != nil {
+		t.Fatalf("GetBlockHeader: %+v", err)
+	}
+	if earliestAvailableBlockHeader.DAAScore() != minDAAScore {
+		t.Fatalf("Expected the earliest available block to have DAA score %d but got %d",
+			minDAAScore, earliestAvailableBlockHeader.DAAScore())
+	}
+
+	stagingArea := model.NewStagingArea()
+	for _, blockHash := range chain {
+		header, err := tc.GetBlockHeader(blockHash)
+		if err != nil {
+			t.Fatalf("GetBlockHeader: %+v", err)
+		}
+		hasBlock, err := tc.BlockStore().HasBlock(tc.DatabaseContext(), stagingArea, blockHash)
+		if err != nil {
+			t.Fatalf("HasBlock: %+v", err)
+		}
+		expectsBlock := header.DAAScore() >= minDAAScore
+		if hasBlock != expectsBlock {
+			t.Fatalf("Block with DAA score %d: expected HasBlock to be %t but got %t",
+				header.DAAScore(), expectsBlock, hasBlock)
+		}
+		if !expectsBlock {
+			continue
+		}
+		_, err = tc.GetBlockAcceptanceData(blockHash)
+		if err != nil {
+			t.Fatalf("Block with DAA score %d: GetBlockAcceptanceData: %+v", header.DAAScore(), err)
+		}
+	}
+}
+
+func TestPruningRetentionOfSideBranches(t *testing.T) {
+	const retentionDepth = 200
+
+	consensusConfig := &consensus.Config{Params: dagconfig.SimnetParams}
+	consensusConfig.SkipProofOfWork = true
+	consensusConfig.DisableDifficultyAdjustment = true
+	consensusConfig.FinalityDuration = 7 * consensusConfig.TargetTimePerBlock
+	consensusConfig.MergeSetSizeLimit = 5
+	consensusConfig.DifficultyAdjustmentWindowSize = 10
+	consensusConfig.RetentionDepth = retentionDepth
+
+	factory := consensus.NewFactory()
+	tc, teardown, err := factory.NewTestConsensus(consensusConfig, "TestPruningRetentionOfSideBranches")
+	if err != nil {
+		t.Fatalf("Error setting up consensus: %+v", err)
+	}
+	defer teardown(false)
+
+	var blocks []*externalapi.DomainHash
+	addBlock := func(parents ...*externalapi.DomainHash) *externalapi.DomainHash {
+		blockHash, _, err := tc.AddBlock(parents, nil, nil)
+		if err != nil {
+			t.Fatalf("AddBlock: %+v", err)
+		}
+		blocks = append(blocks, blockHash)
+		return blockHash
+	}
+
+	// Every block of the main chain has a side block in its anticone, which is built on the
+	// block below its parent and is merged by the block above it, so the retention point has
+	// a block in its anticone whichever block it is. Early on, a side branch that is never
+	// merged is added, which is pruned as a tip once the pruning point moves past its fork point.
+	const sideBranchForkPoint = 10
+	const sideBranchLength = 6
+	chain := []*externalapi.DomainHash{consensusConfig.GenesisHash}
+	var sideBlock *externalapi.DomainHash
+	// The side blocks are blue, so each block of the main chain adds 2 to its blue score
+	for i := uint64(0); i < (consensusConfig.PruningDepth()+retentionDepth)/2+50; i++ {
+		if i == sideBranchForkPoint {
+			// It's forked deep enough to never have more blue work than the main chain
+			sideBranchTip := chain[len(chain)-sideBranchLength-2]
+			for j := 0; j < sideBranchLength; j++ {
+				sideBranchTip = addBlock(sideBranchTip)
+			}
+		}
+
+		parents := []*externalapi.DomainHash{chain[len(chain)-1]}
+		if sideBlock != nil {
+			parents = append(parents, sideBlock)
+		}
+		chain = append(chain, addBlock(parents...))
+		if len(chain) >= 3 {
+			sideBlock = addBlock(chain[len(chain)-3])
+		}
+	}
+
+	retentionPoint, err := tc.EarliestAvailableBlock()
+	if err != nil {
+		t.Fatalf("EarliestAvailableBlock: %+v", err)
+	}
+	pruningPoint, err := tc.PruningPoint()
+	if err != nil {
+		t.Fatalf("PruningPoint: %+v", err)
+	}
+	if retentionPoint.Equal(pruningPoint) || retentionPoint.Equal(consensusConfig.GenesisHash) {
+		t.Fatalf("Expected the retention point %s to be between the genesis and the pruning point %s",
+			retentionPoint, pruningPoint)
+	}
+
+	stagingArea := model.NewStagingArea()
+	deletedBlocksInRetentionPointAnticone := 0
+	for _, blockHash := range blocks {
+		isInRetentionWindow, err := tc.DAGTopologyManager().IsAncestorOf(stagingArea, retentionPoint, blockHash)
+		if err != nil {
+			t.Fatalf("IsAncestorOf: %+v", err)
+		}
+		hasBlock, err := tc.BlockStore().HasBlock(tc.DatabaseContext(), stagingArea, blockHash)
+		if err != nil {
+			t.Fatalf("HasBlock: %+v", err)
+		}
+		if hasBlock != isInRetentionWindow {
+			t.Fatalf("Block %s: expected HasBlock to be %t but got %t", blockHash, isInRetentionWindow, hasBlock)
+		}
+
+		isInRetentionPointPast, err := tc.DAGTopologyManager().IsAncestorOf(stagingArea, blockHash, retentionPoint)
+		if err != nil {
+			t.Fatalf("IsAncestorOf: %+v", err)
+		}
+		if !isInRetentionWindow && !isInRetentionPointPast {
+			deletedBlocksInRetentionPointAnticone++
+		}
+	}
+	if deletedBlocksInRetentionPointAnticone <= sideBranchLength {
+		t.Fatalf("Expected both the side branch and a merged block in the anticone of the retention "+
+			"point to be deleted, but only %d blocks in its anticone were", deletedBlocksInRetentionPointAnticone)
+	}
+}
@@ -37,8 +37,10 @@ type pruningManager struct {
 	utxoDiffStore                       model.UTXODiffStore
 	daaBlocksStore                      model.DAABlocksStore
 	reachabilityDataStore               model.ReachabilityDataStore
+	deploymentStateStore                model.DeploymentStateStore
 
 	isArchivalNode                  bool
+	retentionDepth                  uint64
 	genesisHash                     *externalapi.DomainHash
 	finalityInterval                uint64
 	pruningDepth                    uint64
@@ -72,8 +74,10 @@ func New(
 	daaBlocksStore model.DAABlocksStore,
 	reachabilityDataStore model.ReachabilityDataStore,
 	blocksWithTrustedDataDAAWindowStore model.BlocksWithTrustedDataDAAWindowStore,
+	deploymentStateStore model.DeploymentStateStore,
 
 	isArchivalNode bool,
+	retentionDepth uint64,
 	genesisHash *externalapi.DomainHash,
 	finalityInterval uint64,
 	pruningDepth uint64,
@@ -102,8 +106,10 @@ func New(
 		daaBlocksStore:                      daaBlocksStore,
 		reachabilityDataStore:               reachabilityDataStore,
 		blocksWithTrustedDataDAAWindowStore: blocksWithTrustedDataDAAWindowStore,
+		deploymentStateStore:                deploymentStateStore,
 
 		isArchivalNode:                  isArchivalNode,
+		retentionDepth:                  retentionDepth,
 		genesisHash:                     genesisHash,
 		pruningDepth:                    pruningDepth,
 		finalityInterval:                finalityInterval,
@@ -397,14 +403,309 @@ func (pm *pruningManager) deletePastBlocks(stagingArea *model.StagingArea, pruni
 	if err != nil {
 		return err
 	}
-	err = pm.deleteBlocksDownward(stagingArea, queue, blocksToKeep)
+	prunedBlocks, err := pm.deleteBlocksDownward(stagingArea, queue, blocksToKeep)
 	if err != nil {
 		return err
 	}
 
+	if pm.retentionDepth > 0 {
+		err = pm.deleteBlocksBelowRetentionPoint(stagingArea, pruningPoint, blocksToKeep, prunedBlocks)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// deleteBlocksBelowRetentionPoint deletes the data of pruned blocks that are outside
+// the future of the retention point, which is the selected chain block pm.retentionDepth
+// DAA score below the pruning point. These are the blocks in the past of the retention
+// point, and the blocks in its anticone, such as pruned tips and their past.
+//
+// Every call only visits the blocks that may have fallen out of the retention window
+// since the previous one: the past of the retention point down to the blocks whose data
+// had already been deleted, the blocks pruned by this call, and the future of the
+// previous retention point up to the future of the new one.
+func (pm *pruningManager) deleteBlocksBelowRetentionPoint(stagingArea *model.StagingArea,
+	pruningPoint *externalapi.DomainHash, blocksToKeep map[externalapi.DomainHash]struct{},
+	prunedBlocks []*externalapi.DomainHash) error {
+
+	onEnd := logger.LogAndMeasureExecutionTime(log, "pruningManager.deleteBlocksBelowRetentionPoint")
+	defer onEnd()
+
+	var previousRetentionPoint *externalapi.DomainHash
+	hasRetentionPoint, err := pm.pruningStore.HasRetentionPoint(pm.databaseContext, stagingArea)
+	if err != nil {
+		return err
+	}
+	if hasRetentionPoint {
+		previousRetentionPoint, err = pm.pruningStore.RetentionPoint(pm.databaseContext, stagingArea)
+		if err != nil {
+			return err
+		}
+	}
+
+	retentionPoint, err := pm.nextRetentionPoint(stagingArea, pruningPoint)
+	if err != nil {
+		return err
+	}
+	pm.pruningStore.StageRetentionPoint(stagingArea, retentionPoint)
+
+	// The past of the retention point is deleted first, since its traversal stops at blocks
+	// whose data had already been deleted
+	err = pm.deleteRetentionPointPast(stagingArea, retentionPoint, blocksToKeep)
+	if err != nil {
+		return err
+	}
+
+	for _, blockHash := range prunedBlocks {
+		err = pm.deleteBlockDataOutsideRetentionWindow(stagingArea, blockHash, retentionPoint, blocksToKeep)
+		if err != nil {
+			return err
+		}
+	}
+
+	if previousRetentionPoint == nil || previousRetentionPoint.Equal(retentionPoint) {
+		return nil
+	}
+	return pm.deleteBlocksBetweenRetentionPoints(stagingArea, previousRetentionPoint, retentionPoint, blocksToKeep)
+}
+
+// deleteRetentionPointPast deletes the data of the blocks in the past of the retention
+// point, down to the blocks whose data had already been deleted
+func (pm *pruningManager) deleteRetentionPointPast(stagingArea *model.StagingArea,
+	retentionPoint *externalapi.DomainHash, blocksToKeep map[externalapi.DomainHash]struct{}) error {
+
+	parents, err := pm.dagTopologyManager.Parents(stagingArea, retentionPoint)
+	if err != nil {
+		return err
+	}
+	if virtual.ContainsOnlyVirtualGenesis(parents) {
+		return nil
+	}
+
+	queue := pm.dagTraversalManager.NewDownHeap(stagingArea)
+	err = queue.PushSlice(parents)
+	if err != nil {
+		return err
+	}
+
+	visited := map[externalapi.DomainHash]struct{}{}
+	for queue.Len() > 0 {
+		current := queue.Pop()
+		if _, ok := visited[*current]; ok {
+			continue
+		}
+		visited[*current] = struct{}{}
+
+		if _, ok := blocksToKeep[*current]; !ok {
+			hasBlock, err := pm.blocksStore.HasBlock(pm.databaseContext, stagingArea, current)
+			if err != nil {
+				return err
+			}
+			if !hasBlock {
+				continue
+			}
+			pm.deleteBlockData(stagingArea, current)
+		}
+
+		parents, err := pm.dagTopologyManager.Parents(stagingArea, current)
+		if err != nil {
+			return err
+		}
+		if !virtual.ContainsOnlyVirtualGenesis(parents) {
+			err = queue.PushSlice(parents)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// deleteBlocksBetweenRetentionPoints deletes the data of the blocks in the future of
+// the previous retention point that are outside the future of the new one. Blocks that are
+// in the future of the new retention point stop the traversal.
+func (pm *pruningManager) deleteBlocksBetweenRetentionPoints(stagingArea *model.StagingArea,
+	previousRetentionPoint, retentionPoint *externalapi.DomainHash, blocksToKeep map[externalapi.DomainHash]struct{}) error {
+
+	queue := []*externalapi.DomainHash{previousRetentionPoint}
+	visited := map[externalapi.DomainHash]struct{}{*previousRetentionPoint: {}}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		isInRetentionWindow, err := pm.dagTopologyManager.IsAncestorOf(stagingArea, retentionPoint, current)
+		if err != nil {
+			return err
+		}
+		if isInRetentionWindow {
+			continue
+		}
+		err = pm.deleteBlockDataOutsideRetentionWindow(stagingArea, current, retentionPoint, blocksToKeep)
+		if err != nil {
+			return err
+		}
+
+		children, err := pm.dagTopologyManager.Children(stagingArea, current)
+		if err != nil {
+			return err
+		}
+		for _, child := range children {
+			if child.Equal(model.VirtualBlockHash) {
+				continue
+			}
+			if _, ok := visited[*child]; ok {
+				continue
+			}
+			visited[*child] = struct{}{}
+			queue = append(queue, child)
+		}
+	}
+
+	return nil
+}
+
+// deleteBlockDataOutsideRetentionWindow prunes the given block and deletes its data if it's
+// not in `blocksToKeep`, and it's neither the retention point nor in its future. Such a
+// block is either below the pruning point or in a side branch that can no longer be merged,
+// which is not always pruned by the time it leaves the window of the pruning point anticone.
+func (pm *pruningManager) deleteBlockDataOutsideRetentionWindow(stagingArea *model.StagingArea,
+	blockHash, retentionPoint *externalapi.DomainHash, blocksToKeep map[externalapi.DomainHash]struct{}) error {
+
+	if _, ok := blocksToKeep[*blockHash]; ok {
+		return nil
+	}
+
+	isInRetentionWindow, err := pm.dagTopologyManager.IsAncestorOf(stagingArea, retentionPoint, blockHash)
+	if err != nil {
+		return err
+	}
+	if isInRetentionWindow {
+		return nil
+	}
+
+	status, err := pm.blockStatusStore.Get(pm.databaseContext, stagingArea, blockHash)
+	if err != nil {
+		return err
+	}
+	if status != externalapi.StatusHeaderOnly {
+		pm.blockStatusStore.Stage(stagingArea, blockHash, externalapi.StatusHeaderOnly)
+	}
+	hasBlock, err := pm.blocksStore.HasBlock(pm.databaseContext, stagingArea, blockHash)
+	if err != nil {
+		return err
+	}
+	if hasBlock {
+		pm.deleteBlockData(stagingArea, blockHash)
+	}
+	return nil
+}
+
+// nextRetentionPoint returns the earliest block in the selected chain of the given
+// pruning point whose DAA score is still within pm.retentionDepth from it
+func (pm *pruningManager) nextRetentionPoint(stagingArea *model.StagingArea,
+	pruningPoint *externalapi.DomainHash) (*externalapi.DomainHash, error) {
+
+	pruningPointHeader, err := pm.blockHeaderStore.BlockHeader(pm.databaseContext, stagingArea, pruningPoint)
+	if err != nil {
+		return nil, err
+	}
+	if pruningPointHeader.DAAScore() <= pm.retentionDepth {
+		return pm.genesisHash, nil
+	}
+	minDAAScore := pruningPointHeader.DAAScore() - pm.retentionDepth
+
+	hasRetentionPoint, err := pm.pruningStore.HasRetentionPoint(pm.databaseContext, stagingArea)
+	if err != nil {
+		return nil, err
+	}
+
+	if hasRetentionPoint {
+		previousRetentionPoint, err := pm.pruningStore.RetentionPoint(pm.databaseContext, stagingArea)
+		if err != nil {
+			return nil, err
+		}
+		isInSelectedChainOfPruningPoint, err := pm.dagTopologyManager.IsInSelectedParentChainOf(stagingArea,
+			previousRetentionPoint, pruningPoint)
+		if err != nil {
+			return nil, err
+		}
+		if isInSelectedChainOfPruningPoint {
+			return pm.retentionPointAbove(stagingArea, previousRetentionPoint, pruningPoint, minDAAScore)
+		}
+	}
+
+	return pm.retentionPointBelow(stagingArea, pruningPoint, minDAAScore)
+}
+
+// retentionPointAbove walks up the selected chain from the previous retention point and
+// returns the last block whose DAA score is not above minDAAScore
+func (pm *pruningManager) retentionPointAbove(stagingArea *model.StagingArea,
+	previousRetentionPoint, pruningPoint *externalapi.DomainHash, minDAAScore uint64) (*externalapi.DomainHash, error) {
+
+	iterator, err := pm.dagTraversalManager.SelectedChildIterator(stagingArea, pruningPoint, previousRetentionPoint, true)
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Close()
+
+	retentionPoint := previousRetentionPoint
+	for ok := iterator.First(); ok; ok = iterator.Next() {
+		selectedChild, err := iterator.Get()
+		if err != nil {
+			return nil, err
+		}
+		selectedChildHeader, err := pm.blockHeaderStore.BlockHeader(pm.databaseContext, stagingArea, selectedChild)
+		if err != nil {
+			return nil, err
+		}
+		if selectedChildHeader.DAAScore() > minDAAScore {
+			break
+		}
+		retentionPoint = selectedChild
+	}
+
+	return retentionPoint, nil
+}
+
+// retentionPointBelow walks down the selected chain from the pruning point and returns
+// the first block whose DAA score is not above minDAAScore, or the lowest known block
+// if the selected chain ends before that
+func (pm *pruningManager) retentionPointBelow(stagingArea *model.StagingArea,
+	pruningPoint *externalapi.DomainHash, minDAAScore uint64) (*externalapi.DomainHash, error) {
+
+	current := pruningPoint
+	for {
+		currentHeader, err := pm.blockHeaderStore.BlockHeader(pm.databaseContext, stagingArea, current)
+		if err != nil {
+			return nil, err
+		}
+		if currentHeader.DAAScore() <= minDAAScore || current.Equal(pm.genesisHash) {
+			return current, nil
+		}
+
+		currentGHOSTDAGData, err := pm.ghostdagDataStore.Get(pm.databaseContext, stagingArea, current, false)
+		if err != nil {
+			return nil, err
+		}
+		selectedParent := currentGHOSTDAGData.SelectedParent()
+		if selectedParent.Equal(model.VirtualGenesisBlockHash) {
+			return current, nil
+		}
+		hasSelectedParentHeader, err := pm.blockHeaderStore.HasBlockHeader(pm.databaseContext, stagingArea, selectedParent)
+		if err != nil {
+			return nil, err
+		}
+		if !hasSelectedParentHeader {
+			return current, nil
+		}
+		current = selectedParent
+	}
+}
+
 func (pm *pruningManager) calculateBlocksToKeep(stagingArea *model.StagingArea,
 	pruningPoint *externalapi.DomainHash) (map[externalapi.DomainHash]struct{}, error) {
 
@@ -427,11 +728,13 @@ func (pm *pruningManager) calculateBlocksToKeep(stagingArea *model.StagingArea,
 	return blocksToKeep, nil
 }
 
+// deleteBlocksDownward prunes everything in the queue including its past, unless it's in
+// `blocksToKeep`, and returns the blocks that it pruned
 func (pm *pruningManager) deleteBlocksDownward(stagingArea *model.StagingArea,
-	queue model.BlockHeap, blocksToKeep map[externalapi.DomainHash]struct{}) error {
+	queue model.BlockHeap, blocksToKeep map[externalapi.DomainHash]struct{}) ([]*externalapi.DomainHash, error) {
 
+	var prunedBlocks []*externalapi.DomainHash
 	visited := map[externalapi.DomainHash]struct{}{}
-	// Prune everything in the queue including its past, unless it's in `blocksToKeep`
 	for queue.Len() > 0 {
 		current := queue.Pop()
 		if _, ok := visited[*current]; ok {
@@ -443,26 +746,29 @@ func (pm *pruningManager) deleteBlocksDownward(stagingArea *model.StagingArea,
 		if _, ok := blocksToKeep[*current]; !ok {
 			alreadyPruned, err := pm.deleteBlock(stagingArea, current)
 			if err != nil {
-				return err
+				return nil, err
 			}
 			shouldAddParents = !alreadyPruned
+			if !alreadyPruned {
+				prunedBlocks = append(prunedBlocks, current)
+			}
 		}
 
 		if shouldAddParents {
 			parents, err := pm.dagTopologyManager.Parents(stagingArea, current)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
 			if !virtual.ContainsOnlyVirtualGenesis(parents) {
 				err = queue.PushSlice(parents)
 				if err != nil {
-					return err
+					return nil, err
 				}
 			}
 		}
 	}
-	return nil
+	return prunedBlocks, nil
 }
 
 func (pm *pruningManager) pruneTips(stagingArea *model.StagingArea, pruningPoint *externalapi.DomainHash,
@@ -515,17 +821,48 @@ func (pm *pruningManager) deleteBlock(stagingArea *model.StagingArea, blockHash
 	}
 
 	pm.blockStatusStore.Stage(stagingArea, blockHash, externalapi.StatusHeaderOnly)
-	if pm.isArchivalNode {
+	if pm.isArchivalNode || pm.retentionDepth > 0 {
 		return false, nil
 	}
 
+	pm.deleteBlockData(stagingArea, blockHash)
+
+	return false, nil
+}
+
+func (pm *pruningManager) deleteBlockData(stagingArea *model.StagingArea, blockHash *externalapi.DomainHash) {
 	pm.multiSetStore.Delete(stagingArea, blockHash)
 	pm.acceptanceDataStore.Delete(stagingArea, blockHash)
 	pm.blocksStore.Delete(stagingArea, blockHash)
 	pm.utxoDiffStore.Delete(stagingArea, blockHash)
 	pm.daaBlocksStore.Delete(stagingArea, blockHash)
+	pm.deploymentStateStore.Delete(stagingArea, blockHash)
+}
 
-	return false, nil
+// EarliestAvailableBlock returns the earliest selected chain block whose block data is
+// still kept by this node
+func (pm *pruningManager) EarliestAvailableBlock(stagingArea *model.StagingArea) (*externalapi.DomainHash, error) {
+	if pm.isArchivalNode {
+		hasGenesis, err := pm.blocksStore.HasBlock(pm.databaseContext, stagingArea, pm.genesisHash)
+		if err != nil {
+			return nil, err
+		}
+		if hasGenesis {
+			return pm.genesisHash, nil
+		}
+	}
+
+	if pm.retentionDepth > 0 {
+		hasRetentionPoint, err := pm.pruningStore.HasRetentionPoint(pm.databaseContext, stagingArea)
+		if err != nil {
+			return nil, err
+		}
+		if hasRetentionPoint {
+			return pm.pruningStore.RetentionPoint(pm.databaseContext, stagingArea)
+		}
+	}
+
+	return pm.pruningStore.PruningPoint(pm.databaseContext, stagingArea)
 }
 
 func (pm *pruningManager) IsValidPruningPoint(stagingArea *model.StagingArea, blockHash *externalapi.DomainHash) (bool, error) {
@@ -46,6 +46,7 @@ type pruningProofManager struct {
 	k             externalapi.KType
 	pruningProofM uint64
 	maxBlockLevel int
+	checkpoints   []externalapi.Checkpoint
 
 	cachedPruningPoint *externalapi.DomainHash
 	cachedProof        *externalapi.PruningPointProof
@@ -75,6 +76,7 @@ func New(
 	k externalapi.KType,
 	pruningProofM uint64,
 	maxBlockLevel int,
+	checkpoints []externalapi.Checkpoint,
 ) model.PruningProofManager {
 
 	return &pruningProofManager{
@@ -99,6 +101,7 @@ func New(
 		k:             k,
 		pruningProofM: pruningProofM,
 		maxBlockLevel: maxBlockLevel,
+		checkpoints:   checkpoints,
 	}
 }
 
@@ -330,6 +333,12 @@ func (ppm *pruningProofManager) ValidatePruningPointProof(pruningPointProof *ext
 	pruningPointHeader := level0Headers[len(level0Headers)-1]
 	pruningPoint := consensushashing.HeaderHash(pruningPointHeader)
 	pruningPointBlockLevel := pruningPointHeader.BlockLevel(ppm.maxBlockLevel)
+
+	err := ppm.validateProofCheckpoints(pruningPointProof)
+	if err != nil {
+		return err
+	}
+
 	maxLevel := len(ppm.parentsManager.Parents(pruningPointHeader)) - 1
 	if maxLevel >= len(pruningPointProof.Headers) {
 		return errors.Wrapf(ruleerrors.ErrPruningProofEmpty, "proof has only %d levels while pruning point "+
@@ -569,6 +578,47 @@ func (ppm *pruningProofManager) ValidatePruningPointProof(pruningPointProof *ext
 		"shared blocks with the known DAGs, but doesn't have enough headers from levels higher than the existing block levels.")
 }
 
+// validateProofCheckpoints makes sure that the proof doesn't skip any of the
+// checkpoints it covers. A level-0 proof contains every block between its
+// root and the pruning point, so any checkpoint whose DAA score is within
+// that range must be included in it. Checkpoints above the pruning point are
+// enforced later by header validation, and checkpoints below the range of the
+// proof cannot be checked here.
+func (ppm *pruningProofManager) validateProofCheckpoints(pruningPointProof *externalapi.PruningPointProof) error {
+	if len(ppm.checkpoints) == 0 {
+		return nil
+	}
+
+	level0Headers := pruningPointProof.Headers[0]
+	pruningPointHeader := level0Headers[len(level0Headers)-1]
+	lowestDAAScore := pruningPointHeader.DAAScore()
+	for _, header := range level0Headers {
+		if header.DAAScore() < lowestDAAScore {
+			lowestDAAScore = header.DAAScore()
+		}
+	}
+
+	proofHashes := hashset.New()
+	for _, headers := range pruningPointProof.Headers {
+		for _, header := range headers {
+			proofHashes.Add(consensushashing.HeaderHash(header))
+		}
+	}
+
+	for _, checkpoint := range ppm.checkpoints {
+		if checkpoint.DAAScore > pruningPointHeader.DAAScore() || checkpoint.DAAScore <= lowestDAAScore {
+			continue
+		}
+
+		if !proofHashes.Contains(checkpoint.Hash) {
+			return errors.Wrapf(ruleerrors.ErrPruningProofMissingCheckpoint, "the proof covers DAA scores "+
+				"%d to %d but doesn't contain checkpoint %s", lowestDAAScore, pruningPointHeader.DAAScore(), checkpoint)
+		}
+	}
+
+	return nil
+}
+
 func (ppm *pruningProofManager) dagStores(maxLevel int) (model.BlockHeaderStore, []model.BlockRelationStore, []model.ReachabilityDataStore, []model.GHOSTDAGDataStore, error) {
 	blockRelationStores := make([]model.BlockRelationStore, maxLevel+1)
 	reachabilityDataStores := make([]model.ReachabilityDataStore, maxLevel+1)
@@ -95,7 +95,12 @@ func (v *transactionValidator) ValidateTransactionInContextAndPopulateFee(stagin
 		return err
 	}
 
-	err = v.validateTransactionScripts(tx)
+	scriptFlags, err := v.scriptFlags(stagingArea, povBlockHash)
+	if err != nil {
+		return err
+	}
+
+	err = v.validateTransactionScripts(tx, scriptFlags)
 	if err != nil {
 		return err
 	}
@@ -103,6 +108,22 @@ func (v *transactionValidator) ValidateTransactionInContextAndPopulateFee(stagin
 	return nil
 }
 
+// scriptFlags returns the script flags of all the deployments that are active in povBlockHash
+func (v *transactionValidator) scriptFlags(stagingArea *model.StagingArea,
+	povBlockHash *externalapi.DomainHash) (txscript.ScriptFlags, error) {
+
+	activeDeployments, err := v.deploymentManager.ActiveDeployments(stagingArea, povBlockHash)
+	if err != nil {
+		return txscript.ScriptNoFlags, err
+	}
+
+	flags := txscript.ScriptNoFlags
+	for _, deployment := range activeDeployments {
+		flags |= txscript.ScriptFlags(deployment.ScriptFlags)
+	}
+	return flags, nil
+}
+
 func (v *transactionValidator) checkTransactionCoinbaseMaturity(stagingArea *model.StagingArea,
 	povBlockHash *externalapi.DomainHash, tx *externalapi.DomainTransaction) error {
 
@@ -225,7 +246,7 @@ func (v *transactionValidator) checkTransactionSequenceLock(stagingArea *model.S
 	return nil
 }
 
-func (v *transactionValidator) validateTransactionScripts(tx *externalapi.DomainTransaction) error {
+func (v *transactionValidator) validateTransactionScripts(tx *externalapi.DomainTransaction, flags txscript.ScriptFlags) error {
 	var missingOutpoints []*externalapi.DomainOutpoint
 	sighashReusedValues := &consensushashing.SighashReusedValues{}
 
@@ -239,7 +260,7 @@ func (v *transactionValidator) validateTransactionScripts(tx *externalapi.Domain
 		}
 
 		scriptPubKey := utxoEntry.ScriptPublicKey()
-		vm, err := txscript.NewEngine(scriptPubKey, tx, i, txscript.ScriptNoFlags, v.sigCache, v.sigCacheECDSA, sighashReusedValues)
+		vm, err := txscript.NewEngine(scriptPubKey, tx, i, flags, v.sigCache, v.sigCacheECDSA, sighashReusedValues)
 		if err != nil {
 			return errors.Wrapf(ruleerrors.ErrScriptMalformed, "failed to parse input "+
 				"%d which references output %s - "+
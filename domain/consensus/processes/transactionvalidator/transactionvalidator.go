@@ -17,6 +17,7 @@ type transactionValidator struct {
 	pastMedianTimeManager                   model.PastMedianTimeManager
 	ghostdagDataStore                       model.GHOSTDAGDataStore
 	daaBlocksStore                          model.DAABlocksStore
+	deploymentManager                       model.DeploymentManager
 	enableNonNativeSubnetworks              bool
 	maxCoinbasePayloadLength                uint64
 	ghostdagK                               externalapi.KType
@@ -36,6 +37,7 @@ func New(blockCoinbaseMaturity uint64,
 	pastMedianTimeManager model.PastMedianTimeManager,
 	ghostdagDataStore model.GHOSTDAGDataStore,
 	daaBlocksStore model.DAABlocksStore,
+	deploymentManager model.DeploymentManager,
 	txMassCalculator *txmass.Calculator) model.TransactionValidator {
 
 	return &transactionValidator{
@@ -48,6 +50,7 @@ func New(blockCoinbaseMaturity uint64,
 		pastMedianTimeManager:                   pastMedianTimeManager,
 		ghostdagDataStore:                       ghostdagDataStore,
 		daaBlocksStore:                          daaBlocksStore,
+		deploymentManager:                       deploymentManager,
 		sigCache:                                txscript.NewSigCache(sigCacheSize),
 		sigCacheECDSA:                           txscript.NewSigCacheECDSA(sigCacheSize),
 		txMassCalculator:                        txMassCalculator,
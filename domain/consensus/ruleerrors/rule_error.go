@@ -130,6 +130,10 @@ var (
 	// for a coinbase transaction is too high.
 	ErrBadCoinbasePayloadLen = newRuleError("ErrBadCoinbasePayloadLen")
 
+	// ErrBadCoinbasePayouts indicates that the payouts in the coinbase payload
+	// don't split the rewards validly
+	ErrBadCoinbasePayouts = newRuleError("ErrBadCoinbasePayouts")
+
 	// ErrBadCoinbaseTransaction indicates that the block's coinbase transaction is not build as expected
 	ErrBadCoinbaseTransaction = newRuleError("ErrBadCoinbaseTransaction")
 
@@ -226,6 +230,14 @@ var (
 
 	ErrPruningPointSelectedChildDisqualifiedFromChain = newRuleError("ErrPruningPointSelectedChildDisqualifiedFromChain")
 
+	// ErrCheckpointViolation indicates that a block's selected chain passed the DAA score
+	// of a checkpoint without passing through the checkpoint itself.
+	ErrCheckpointViolation = newRuleError("ErrCheckpointViolation")
+
+	// ErrInvalidTrustedDeploymentStates indicates that a block with trusted data came with deployment
+	// states that don't match the deployments of the network.
+	ErrInvalidTrustedDeploymentStates = newRuleError("ErrInvalidTrustedDeploymentStates")
+
 	// ErrUnexpectedFinalityPoint indicates a block header pruning point does not align with
 	// the expected value.
 	ErrUnexpectedHeaderPruningPoint = newRuleError("ErrUnexpectedHeaderPruningPoint")
@@ -239,6 +251,7 @@ var (
 	ErrPruningProofMissingBlockAtDepthMFromNextLevel  = newRuleError("ErrPruningProofMissingBlockAtDepthMFromNextLevel")
 	ErrPruningProofMissesBlocksBelowPruningPoint      = newRuleError("ErrPruningProofMissesBlocksBelowPruningPoint")
 	ErrPruningProofEmpty                              = newRuleError("ErrPruningProofEmpty")
+	ErrPruningProofMissingCheckpoint                  = newRuleError("ErrPruningProofMissingCheckpoint")
 	ErrWrongCoinbaseSubsidy                           = newRuleError("ErrWrongCoinbaseSubsidy")
 	ErrWrongBlockVersion                              = newRuleError("ErrWrongBlockVersion")
 	ErrCoinbaseWithInputs                             = newRuleError("ErrCoinbaseWithInputs")
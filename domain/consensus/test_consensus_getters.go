@@ -135,6 +135,10 @@ func (tc *testConsensus) FinalityManager() model.FinalityManager {
 	return tc.finalityManager
 }
 
+func (tc *testConsensus) DeploymentManager() model.DeploymentManager {
+	return tc.deploymentManager
+}
+
 func (tc *testConsensus) FinalityStore() model.FinalityStore {
 	return tc.finalityStore
 }
@@ -6,6 +6,16 @@ const (
 	// BlockVersion represents the current block version
 	BlockVersion uint16 = 1
 
+	// VersionBitsTopBits are the top bits of a block version that signals for consensus
+	// deployments. The rest of the bits of such a version are the signals themselves.
+	VersionBitsTopBits uint16 = 0x2000
+
+	// VersionBitsTopMask is the mask of the top bits of a block version
+	VersionBitsTopMask uint16 = 0xe000
+
+	// VersionBitsNumBits is the number of bits available for deployment signals
+	VersionBitsNumBits = 13
+
 	// MaxTransactionVersion is the current latest supported transaction version.
 	MaxTransactionVersion uint16 = 0
 
@@ -60,6 +60,13 @@ func TestNewBlockHash(t *testing.T) {
 			"28f33681dcff1313674e07dacc2d74c3089f6d8cea7a4f8792a71fd870988ee5",
 			"2d53a43a42020a5091c125230bcd8a4cf0eeb188333e68325d4bce58a1c75ca3",
 		}},
+		{NewPersonalMessageSigningHashWriter(), []string{
+			"ec8236b2fbe6c10ef799dec760335f095a0fc3db17cd000a9b2b24a658bca3ef",
+			"1a6445dd7164216fcabdcbb21ab5292100d829f759152555569debac1e6eb1c4",
+			"c819b13068205aae7b8ad4df8493eeea857160240611a27f3b31d3ac50e91ddf",
+			"7a6554999f40fd4cb61fb90adb00f5fd4c1b036d4136c31be162ee87fcb38d4d",
+			"bace0eabdc02538dad7b39af66f2e157507bc5d702a4fbd35642ca24d9238d83",
+		}},
 	}
 
 	for _, testVector := range tests {
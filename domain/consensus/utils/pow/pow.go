@@ -62,6 +62,12 @@ func (state *State) CalculateProofOfWorkValue() *big.Int {
 	return toBig(heavyHash)
 }
 
+// PrePowHash returns the hash of the header with its timestamp and nonce zeroed, which
+// together with the timestamp and the nonce is all a miner needs to calculate the PoW
+func (state *State) PrePowHash() *externalapi.DomainHash {
+	return &state.prePowHash
+}
+
 // IncrementNonce the nonce in State by 1
 func (state *State) IncrementNonce() {
 	state.Nonce++
@@ -165,6 +165,40 @@ func (vm *Engine) DisasmPC() (string, error) {
 	return vm.disasm(scriptIdx, scriptOff), nil
 }
 
+// ScriptIndex returns the index of the script that will execute when Step() is
+// called next. Index 0 is the signature script, 1 is the public key script and
+// 2 is the redeem script of a pay-to-script-hash input.
+func (vm *Engine) ScriptIndex() int {
+	return vm.scriptIdx
+}
+
+// ScriptOffset returns the program counter within the current script, that is,
+// the index of the opcode that will execute when Step() is called next.
+func (vm *Engine) ScriptOffset() int {
+	return vm.scriptOff
+}
+
+// IsDone returns whether all the scripts have been executed, in which case
+// CheckErrorCondition reports the result of the execution.
+func (vm *Engine) IsDone() bool {
+	return vm.scriptIdx >= len(vm.scripts)
+}
+
+// IsBranchExecuting returns whether the opcode that will execute when Step()
+// is called next is in an actively executing conditional branch.
+func (vm *Engine) IsBranchExecuting() bool {
+	return vm.isBranchExecuting()
+}
+
+// GetCondStack returns the contents of the condition stack, where the last
+// item is the innermost conditional. Each item is one of OpCondFalse,
+// OpCondTrue or OpCondSkip.
+func (vm *Engine) GetCondStack() []int {
+	condStack := make([]int, len(vm.condStack))
+	copy(condStack, vm.condStack)
+	return condStack
+}
+
 // DisasmScript returns the disassembly string for the script at the requested
 // offset index. Index 0 is the signature script and 1 is the public key
 // script.
@@ -342,3 +342,104 @@ func TestDisasmScript(t *testing.T) {
 		}
 	}
 }
+
+// TestStepInspection steps through a script with nested conditionals and
+// ensures the program counter and the condition stack are exposed correctly.
+func TestStepInspection(t *testing.T) {
+	t.Parallel()
+
+	inputs := []*externalapi.DomainTransactionInput{{
+		PreviousOutpoint: externalapi.DomainOutpoint{
+			TransactionID: *externalapi.NewDomainTransactionIDFromByteArray(&[externalapi.DomainHashSize]byte{
+				0xc9, 0x97, 0xa5, 0xe5,
+				0x6e, 0x10, 0x41, 0x02,
+				0xfa, 0x20, 0x9c, 0x6a,
+				0x85, 0x2d, 0xd9, 0x06,
+				0x60, 0xa2, 0x0b, 0x2d,
+				0x9c, 0x35, 0x24, 0x23,
+				0xed, 0xce, 0x25, 0x85,
+				0x7f, 0xcd, 0x37, 0x04,
+			}),
+			Index: 0,
+		},
+		SignatureScript: mustParseShortForm("OP_1", 0),
+		Sequence:        4294967295,
+	}}
+	outputs := []*externalapi.DomainTransactionOutput{{
+		Value:           1000000000,
+		ScriptPublicKey: nil,
+	}}
+	tx := &externalapi.DomainTransaction{
+		Version: 1,
+		Inputs:  inputs,
+		Outputs: outputs,
+	}
+
+	scriptPubKey := &externalapi.ScriptPublicKey{
+		Script:  mustParseShortForm("OP_IF OP_0 OP_IF NOP OP_ENDIF OP_1 OP_ENDIF", 0),
+		Version: 0,
+	}
+	vm, err := NewEngine(scriptPubKey, tx, 0, 0, nil, nil, &consensushashing.SighashReusedValues{})
+	if err != nil {
+		t.Fatalf("failed to create script: %v", err)
+	}
+
+	// The expected state after every step
+	tests := []struct {
+		scriptIdx         int
+		scriptOff         int
+		condStack         []int
+		isBranchExecuting bool
+		stackDepth        int
+	}{
+		{1, 0, []int{}, true, 1},
+		{1, 1, []int{OpCondTrue}, true, 0},
+		{1, 2, []int{OpCondTrue}, true, 1},
+		{1, 3, []int{OpCondTrue, OpCondFalse}, false, 0},
+		{1, 4, []int{OpCondTrue, OpCondFalse}, false, 0},
+		{1, 5, []int{OpCondTrue}, true, 0},
+		{1, 6, []int{OpCondTrue}, true, 1},
+		{2, 0, []int{}, true, 1},
+	}
+
+	if vm.ScriptIndex() != 0 || vm.ScriptOffset() != 0 || vm.IsDone() {
+		t.Fatalf("unexpected initial program counter %d:%d", vm.ScriptIndex(), vm.ScriptOffset())
+	}
+
+	for i, test := range tests {
+		done, err := vm.Step()
+		if err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		if done != (i == len(tests)-1) {
+			t.Fatalf("step %d: unexpected done %t", i, done)
+		}
+		if vm.ScriptIndex() != test.scriptIdx || vm.ScriptOffset() != test.scriptOff {
+			t.Fatalf("step %d: expected program counter %d:%d but got %d:%d",
+				i, test.scriptIdx, test.scriptOff, vm.ScriptIndex(), vm.ScriptOffset())
+		}
+		condStack := vm.GetCondStack()
+		if len(condStack) != len(test.condStack) {
+			t.Fatalf("step %d: expected condition stack %v but got %v", i, test.condStack, condStack)
+		}
+		for j := range condStack {
+			if condStack[j] != test.condStack[j] {
+				t.Fatalf("step %d: expected condition stack %v but got %v", i, test.condStack, condStack)
+			}
+		}
+		if vm.IsBranchExecuting() != test.isBranchExecuting {
+			t.Fatalf("step %d: expected IsBranchExecuting %t", i, test.isBranchExecuting)
+		}
+		if len(vm.GetStack()) != test.stackDepth {
+			t.Fatalf("step %d: expected stack depth %d but got %d", i, test.stackDepth, len(vm.GetStack()))
+		}
+	}
+
+	if !vm.IsDone() {
+		t.Fatalf("expected the execution to be done")
+	}
+	err = vm.CheckErrorCondition(true)
+	if err != nil {
+		t.Fatalf("CheckErrorCondition: %v", err)
+	}
+}
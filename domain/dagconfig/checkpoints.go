@@ -0,0 +1,16 @@
+package dagconfig
+
+import "github.com/c4ei/c4exd/domain/consensus/model/externalapi"
+
+// The checkpoint lists of the default networks. Each checkpoint must be a
+// block in the selected chain of the network, and the lists are extended
+// as the networks mature. Operators may add further checkpoints with the
+// --addcheckpoint option.
+// 기본 네트워크의 체크포인트 목록입니다. 각 체크포인트는 네트워크의 선택된
+// 체인에 있는 블록이어야 하며, 네트워크가 성숙함에 따라 목록이 확장됩니다.
+var (
+	mainnetCheckpoints = []externalapi.Checkpoint{}
+	testnetCheckpoints = []externalapi.Checkpoint{}
+	simnetCheckpoints  = []externalapi.Checkpoint{}
+	devnetCheckpoints  = []externalapi.Checkpoint{}
+)
@@ -1,6 +1,7 @@
 package dagconfig
 
 import (
+	"math"
 	"time"
 
 	"github.com/c4ei/c4exd/domain/consensus/utils/constants"
@@ -139,4 +140,12 @@ const (
 	defaultDeflationaryPhaseDaaScore = 15778800 - 259200
 
 	defaultMergeDepth = 3600
+
+	// defaultPayoutSplitActivationBlueScore is the blue score from which coinbase payloads
+	// may split the rewards between several payees. It is a hard fork, so it stays inactive
+	// on a network until its activation is scheduled.
+	// defaultPayoutSplitActivationBlueScore는 코인베이스 페이로드가 보상을 여러 수취인에게
+	// 나눌 수 있게 되는 블루 점수입니다. 하드 포크이므로 활성화 일정이 정해질 때까지
+	// 네트워크에서 비활성 상태로 유지됩니다.
+	defaultPayoutSplitActivationBlueScore = math.MaxUint64
 )
@@ -147,6 +147,7 @@ type Params struct {
 	// 에.
 	RuleChangeActivationThreshold uint64
 	MinerConfirmationWindow       uint64
+	Deployments                   []externalapi.ConsensusDeployment
 
 	// Mempool parameters
 	RelayNonStdTxs bool
@@ -224,6 +225,12 @@ type Params struct {
 	// 디플레이션 단계로
 	DeflationaryPhaseDaaScore uint64
 
+	// PayoutSplitActivationBlueScore is the blue score from which coinbase payloads may split
+	// the rewards of a block between several payees
+	// PayoutSplitActivationBlueScore는 코인베이스 페이로드가 블록 보상을 여러 수취인에게
+	// 나눌 수 있게 되는 블루 점수입니다.
+	PayoutSplitActivationBlueScore uint64
+
 	DisallowDirectBlocksOnTopOfGenesis bool
 
 	// MaxBlockLevel is the maximum possible block level.
@@ -231,6 +238,12 @@ type Params struct {
 	MaxBlockLevel int
 
 	MergeDepth uint64
+
+	// Checkpoints is a list of known-good selected chain blocks. A chain that reaches
+	// the DAA score of a checkpoint without passing through it is rejected.
+	// Checkpoints는 알려진 정상 선택 체인 블록 목록입니다. 체크포인트를 거치지 않고
+	// 해당 DAA 점수에 도달하는 체인은 거부됩니다.
+	Checkpoints []externalapi.Checkpoint
 }
 
 // NormalizeRPCServerAddress returns addr with the current network default
@@ -331,12 +344,14 @@ var MainnetParams = Params{
 	CoinbasePayloadScriptPublicKeyMaxLength: defaultCoinbasePayloadScriptPublicKeyMaxLength,
 	PruningProofM:                           defaultPruningProofM,
 	DeflationaryPhaseDaaScore:               defaultDeflationaryPhaseDaaScore,
+	PayoutSplitActivationBlueScore:          defaultPayoutSplitActivationBlueScore,
 	DisallowDirectBlocksOnTopOfGenesis:      true,
 
 	// This is technically 255, but we clamped it at 256 - block level of mainnet genesis
 	// This means that any block that has a level lower or equal to genesis will be level 0.
 	MaxBlockLevel: 225,
 	MergeDepth:    defaultMergeDepth,
+	Checkpoints:   mainnetCheckpoints,
 }
 
 // TestnetParams defines the network parameters for the test C4ex network.
@@ -401,9 +416,11 @@ var TestnetParams = Params{
 	CoinbasePayloadScriptPublicKeyMaxLength: defaultCoinbasePayloadScriptPublicKeyMaxLength,
 	PruningProofM:                           defaultPruningProofM,
 	DeflationaryPhaseDaaScore:               defaultDeflationaryPhaseDaaScore,
+	PayoutSplitActivationBlueScore:          defaultPayoutSplitActivationBlueScore,
 
 	MaxBlockLevel: 250,
 	MergeDepth:    defaultMergeDepth,
+	Checkpoints:   testnetCheckpoints,
 }
 
 // SimnetParams defines the network parameters for the simulation test C4ex
@@ -474,9 +491,11 @@ var SimnetParams = Params{
 	CoinbasePayloadScriptPublicKeyMaxLength: defaultCoinbasePayloadScriptPublicKeyMaxLength,
 	PruningProofM:                           defaultPruningProofM,
 	DeflationaryPhaseDaaScore:               defaultDeflationaryPhaseDaaScore,
+	PayoutSplitActivationBlueScore:          defaultPayoutSplitActivationBlueScore,
 
 	MaxBlockLevel: 250,
 	MergeDepth:    defaultMergeDepth,
+	Checkpoints:   simnetCheckpoints,
 }
 
 // DevnetParams defines the network parameters for the development C4ex network.
@@ -536,9 +555,11 @@ var DevnetParams = Params{
 	CoinbasePayloadScriptPublicKeyMaxLength: defaultCoinbasePayloadScriptPublicKeyMaxLength,
 	PruningProofM:                           defaultPruningProofM,
 	DeflationaryPhaseDaaScore:               defaultDeflationaryPhaseDaaScore,
+	PayoutSplitActivationBlueScore:          defaultPayoutSplitActivationBlueScore,
 
 	MaxBlockLevel: 250,
 	MergeDepth:    defaultMergeDepth,
+	Checkpoints:   devnetCheckpoints,
 }
 
 // ErrDuplicateNet describes an error where the parameters for a C4ex
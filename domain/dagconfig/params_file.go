@@ -0,0 +1,444 @@
+package dagconfig
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/c4ei/c4exd/app/appmessage"
+	"github.com/c4ei/c4exd/domain/consensus/model/externalapi"
+	"github.com/c4ei/c4exd/domain/consensus/utils/blockheader"
+	"github.com/c4ei/c4exd/domain/consensus/utils/consensushashing"
+	"github.com/c4ei/c4exd/domain/consensus/utils/constants"
+	"github.com/c4ei/c4exd/domain/consensus/utils/merkle"
+	"github.com/c4ei/c4exd/domain/consensus/utils/pow"
+	"github.com/c4ei/c4exd/domain/consensus/utils/subnetworks"
+	"github.com/c4ei/c4exd/domain/consensus/utils/transactionhelper"
+	"github.com/c4ei/c4exd/util"
+	"github.com/c4ei/c4exd/util/difficulty"
+	"github.com/kaspanet/go-muhash"
+	"github.com/pkg/errors"
+)
+
+// ParamsFile is the definition of a custom network, as read by LoadParamsFile from a JSON
+// or a TOML file. Durations are given in milliseconds.
+type ParamsFile struct {
+	Name         string   `json:"name" toml:"name"`
+	Net          uint32   `json:"net" toml:"net"`
+	Prefix       string   `json:"prefix" toml:"prefix"`
+	PrivateKeyID byte     `json:"privateKeyId" toml:"privateKeyId"`
+	RPCPort      string   `json:"rpcPort" toml:"rpcPort"`
+	DefaultPort  string   `json:"defaultPort" toml:"defaultPort"`
+	DNSSeeds     []string `json:"dnsSeeds" toml:"dnsSeeds"`
+	GRPCSeeds    []string `json:"grpcSeeds" toml:"grpcSeeds"`
+
+	Genesis *GenesisFile `json:"genesis" toml:"genesis"`
+
+	K                                       externalapi.KType `json:"k" toml:"k"`
+	PowMax                                  string            `json:"powMax" toml:"powMax"`
+	BlockCoinbaseMaturity                   uint64            `json:"blockCoinbaseMaturity" toml:"blockCoinbaseMaturity"`
+	SubsidyGenesisReward                    uint64            `json:"subsidyGenesisReward" toml:"subsidyGenesisReward"`
+	PreDeflationaryPhaseBaseSubsidy         uint64            `json:"preDeflationaryPhaseBaseSubsidy" toml:"preDeflationaryPhaseBaseSubsidy"`
+	DeflationaryPhaseBaseSubsidy            uint64            `json:"deflationaryPhaseBaseSubsidy" toml:"deflationaryPhaseBaseSubsidy"`
+	DeflationaryPhaseDaaScore               uint64            `json:"deflationaryPhaseDaaScore" toml:"deflationaryPhaseDaaScore"`
+	PayoutSplitActivationBlueScore          uint64            `json:"payoutSplitActivationBlueScore" toml:"payoutSplitActivationBlueScore"`
+	TargetTimePerBlockInMilliSeconds        int64             `json:"targetTimePerBlockInMilliSeconds" toml:"targetTimePerBlockInMilliSeconds"`
+	FinalityDuration                        int64             `json:"finalityDuration" toml:"finalityDuration"`
+	TimestampDeviationTolerance             int               `json:"timestampDeviationTolerance" toml:"timestampDeviationTolerance"`
+	DifficultyAdjustmentWindowSize          int               `json:"difficultyAdjustmentWindowSize" toml:"difficultyAdjustmentWindowSize"`
+	DisableDifficultyAdjustment             bool              `json:"disableDifficultyAdjustment" toml:"disableDifficultyAdjustment"`
+	SkipProofOfWork                         bool              `json:"skipProofOfWork" toml:"skipProofOfWork"`
+	RelayNonStdTxs                          bool              `json:"relayNonStdTxs" toml:"relayNonStdTxs"`
+	AcceptUnroutable                        bool              `json:"acceptUnroutable" toml:"acceptUnroutable"`
+	EnableNonNativeSubnetworks              bool              `json:"enableNonNativeSubnetworks" toml:"enableNonNativeSubnetworks"`
+	MaxCoinbasePayloadLength                uint64            `json:"maxCoinbasePayloadLength" toml:"maxCoinbasePayloadLength"`
+	MaxBlockMass                            uint64            `json:"maxBlockMass" toml:"maxBlockMass"`
+	MaxBlockParents                         externalapi.KType `json:"maxBlockParents" toml:"maxBlockParents"`
+	MassPerTxByte                           uint64            `json:"massPerTxByte" toml:"massPerTxByte"`
+	MassPerScriptPubKeyByte                 uint64            `json:"massPerScriptPubKeyByte" toml:"massPerScriptPubKeyByte"`
+	MassPerSigOp                            uint64            `json:"massPerSigOp" toml:"massPerSigOp"`
+	MergeSetSizeLimit                       uint64            `json:"mergeSetSizeLimit" toml:"mergeSetSizeLimit"`
+	CoinbasePayloadScriptPublicKeyMaxLength uint8             `json:"coinbasePayloadScriptPublicKeyMaxLength" toml:"coinbasePayloadScriptPublicKeyMaxLength"`
+	PruningProofM                           uint64            `json:"pruningProofM" toml:"pruningProofM"`
+	DisallowDirectBlocksOnTopOfGenesis      bool              `json:"disallowDirectBlocksOnTopOfGenesis" toml:"disallowDirectBlocksOnTopOfGenesis"`
+	MaxBlockLevel                           int               `json:"maxBlockLevel" toml:"maxBlockLevel"`
+	MergeDepth                              uint64            `json:"mergeDepth" toml:"mergeDepth"`
+
+	RuleChangeActivationThreshold uint64           `json:"ruleChangeActivationThreshold" toml:"ruleChangeActivationThreshold"`
+	MinerConfirmationWindow       uint64           `json:"minerConfirmationWindow" toml:"minerConfirmationWindow"`
+	Deployments                   []DeploymentFile `json:"deployments" toml:"deployments"`
+
+	// Checkpoints are given in the <hash>:<daa score> form
+	Checkpoints []string `json:"checkpoints" toml:"checkpoints"`
+}
+
+// DeploymentFile is the definition of a consensus deployment of a custom network
+type DeploymentFile struct {
+	Name            string `json:"name" toml:"name"`
+	Bit             uint8  `json:"bit" toml:"bit"`
+	StartDAAScore   uint64 `json:"startDaaScore" toml:"startDaaScore"`
+	TimeoutDAAScore uint64 `json:"timeoutDaaScore" toml:"timeoutDaaScore"`
+}
+
+// GenesisFile is the definition of the genesis block of a custom network.
+// Hash is optional, and if it is set it must match the hash of the resulting block.
+type GenesisFile struct {
+	Version            uint16 `json:"version" toml:"version"`
+	TimeInMilliseconds int64  `json:"timeInMilliseconds" toml:"timeInMilliseconds"`
+	Bits               uint32 `json:"bits" toml:"bits"`
+	Nonce              uint64 `json:"nonce" toml:"nonce"`
+	DAAScore           uint64 `json:"daaScore" toml:"daaScore"`
+	CoinbaseExtraData  string `json:"coinbaseExtraData" toml:"coinbaseExtraData"`
+	Hash               string `json:"hash,omitempty" toml:"hash,omitempty"`
+}
+
+// NewParamsFile returns a ParamsFile filled with the default consensus values, to be
+// used as a base for fields that a custom network definition leaves out
+func NewParamsFile() *ParamsFile {
+	return &ParamsFile{
+		PowMax: new(big.Int).Sub(new(big.Int).Lsh(bigOne, 255), bigOne).Text(16),
+		Genesis: &GenesisFile{
+			Bits: 0x207fffff,
+		},
+		K:                                       defaultGHOSTDAGK,
+		BlockCoinbaseMaturity:                   100,
+		SubsidyGenesisReward:                    defaultSubsidyGenesisReward,
+		PreDeflationaryPhaseBaseSubsidy:         defaultPreDeflationaryPhaseBaseSubsidy,
+		DeflationaryPhaseBaseSubsidy:            defaultDeflationaryPhaseBaseSubsidy,
+		DeflationaryPhaseDaaScore:               defaultDeflationaryPhaseDaaScore,
+		PayoutSplitActivationBlueScore:          defaultPayoutSplitActivationBlueScore,
+		TargetTimePerBlockInMilliSeconds:        defaultTargetTimePerBlock.Milliseconds(),
+		FinalityDuration:                        defaultFinalityDuration.Milliseconds(),
+		TimestampDeviationTolerance:             defaultTimestampDeviationTolerance,
+		DifficultyAdjustmentWindowSize:          defaultDifficultyAdjustmentWindowSize,
+		MaxCoinbasePayloadLength:                defaultMaxCoinbasePayloadLength,
+		MaxBlockMass:                            defaultMaxBlockMass,
+		MaxBlockParents:                         defaultMaxBlockParents,
+		MassPerTxByte:                           defaultMassPerTxByte,
+		MassPerScriptPubKeyByte:                 defaultMassPerScriptPubKeyByte,
+		MassPerSigOp:                            defaultMassPerSigOp,
+		MergeSetSizeLimit:                       defaultMergeSetSizeLimit,
+		CoinbasePayloadScriptPublicKeyMaxLength: defaultCoinbasePayloadScriptPublicKeyMaxLength,
+		PruningProofM:                           defaultPruningProofM,
+		MaxBlockLevel:                           225,
+		MergeDepth:                              defaultMergeDepth,
+		RuleChangeActivationThreshold:           1512, // 75% of MinerConfirmationWindow
+		MinerConfirmationWindow:                 2016,
+	}
+}
+
+// IsTOMLParamsFile returns whether the network parameters file at the given path is
+// a TOML file, which is determined by its .toml extension. Any other file is JSON.
+func IsTOMLParamsFile(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".toml")
+}
+
+// ReadParamsFile reads a custom network definition from the given path, in the format
+// given by IsTOMLParamsFile. Fields missing from the file keep their values from NewParamsFile.
+func ReadParamsFile(path string) (*ParamsFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	paramsFile := NewParamsFile()
+	if IsTOMLParamsFile(path) {
+		err = decodeTOMLParamsFile(data, paramsFile)
+	} else {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(paramsFile)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing network parameters file %s", path)
+	}
+	return paramsFile, nil
+}
+
+// decodeTOMLParamsFile decodes a TOML network definition into paramsFile. As with
+// JSON, unknown keys are an error, so that a misspelled field isn't silently ignored.
+func decodeTOMLParamsFile(data []byte, paramsFile *ParamsFile) error {
+	metadata, err := toml.Decode(string(data), paramsFile)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if undecoded := metadata.Undecoded(); len(undecoded) > 0 {
+		return errors.Errorf("unknown field %q", undecoded[0].String())
+	}
+	return nil
+}
+
+// LoadParamsFile reads a custom network definition from the given path, converts
+// it to Params and validates them
+func LoadParamsFile(path string) (*Params, error) {
+	paramsFile, err := ReadParamsFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := paramsFile.ToParams()
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid network parameters in %s", path)
+	}
+	return params, nil
+}
+
+// ToParams converts the network definition to Params and validates them. The address
+// prefix of the network is registered only once the parameters are valid, so a definition
+// that fails validation doesn't leave its prefix behind.
+func (pf *ParamsFile) ToParams() (*Params, error) {
+	err := util.ValidateBech32Prefix(pf.Prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	powMax, ok := new(big.Int).SetString(pf.PowMax, 16)
+	if !ok {
+		return nil, errors.Errorf("couldn't convert powMax %s to big int", pf.PowMax)
+	}
+
+	genesisBlock, err := pf.GenesisBlock()
+	if err != nil {
+		return nil, err
+	}
+	genesisHash := consensushashing.BlockHash(genesisBlock)
+	if pf.Genesis.Hash != "" {
+		expectedGenesisHash, err := externalapi.NewDomainHashFromString(pf.Genesis.Hash)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid genesis hash %s", pf.Genesis.Hash)
+		}
+		if !expectedGenesisHash.Equal(genesisHash) {
+			return nil, errors.Errorf("genesis hash mismatch: the definition results in %s but %s was given",
+				genesisHash, expectedGenesisHash)
+		}
+	}
+
+	deployments := make([]externalapi.ConsensusDeployment, len(pf.Deployments))
+	for i, deployment := range pf.Deployments {
+		deployments[i] = externalapi.ConsensusDeployment{
+			Name:            deployment.Name,
+			BitNumber:       deployment.Bit,
+			StartDAAScore:   deployment.StartDAAScore,
+			TimeoutDAAScore: deployment.TimeoutDAAScore,
+		}
+	}
+
+	checkpoints := make([]externalapi.Checkpoint, len(pf.Checkpoints))
+	for i, checkpointString := range pf.Checkpoints {
+		checkpoints[i], err = externalapi.ParseCheckpoint(checkpointString)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	params := &Params{
+		K:                                       pf.K,
+		Name:                                    pf.Name,
+		Net:                                     appmessage.C4exNet(pf.Net),
+		RPCPort:                                 pf.RPCPort,
+		DefaultPort:                             pf.DefaultPort,
+		DNSSeeds:                                pf.DNSSeeds,
+		GRPCSeeds:                               pf.GRPCSeeds,
+		GenesisBlock:                            genesisBlock,
+		GenesisHash:                             genesisHash,
+		PowMax:                                  powMax,
+		BlockCoinbaseMaturity:                   pf.BlockCoinbaseMaturity,
+		SubsidyGenesisReward:                    pf.SubsidyGenesisReward,
+		PreDeflationaryPhaseBaseSubsidy:         pf.PreDeflationaryPhaseBaseSubsidy,
+		DeflationaryPhaseBaseSubsidy:            pf.DeflationaryPhaseBaseSubsidy,
+		TargetTimePerBlock:                      time.Duration(pf.TargetTimePerBlockInMilliSeconds) * time.Millisecond,
+		FinalityDuration:                        time.Duration(pf.FinalityDuration) * time.Millisecond,
+		TimestampDeviationTolerance:             pf.TimestampDeviationTolerance,
+		DifficultyAdjustmentWindowSize:          pf.DifficultyAdjustmentWindowSize,
+		RelayNonStdTxs:                          pf.RelayNonStdTxs,
+		AcceptUnroutable:                        pf.AcceptUnroutable,
+		PrivateKeyID:                            pf.PrivateKeyID,
+		EnableNonNativeSubnetworks:              pf.EnableNonNativeSubnetworks,
+		DisableDifficultyAdjustment:             pf.DisableDifficultyAdjustment,
+		SkipProofOfWork:                         pf.SkipProofOfWork,
+		MaxCoinbasePayloadLength:                pf.MaxCoinbasePayloadLength,
+		MaxBlockMass:                            pf.MaxBlockMass,
+		MaxBlockParents:                         pf.MaxBlockParents,
+		MassPerTxByte:                           pf.MassPerTxByte,
+		MassPerScriptPubKeyByte:                 pf.MassPerScriptPubKeyByte,
+		MassPerSigOp:                            pf.MassPerSigOp,
+		MergeSetSizeLimit:                       pf.MergeSetSizeLimit,
+		CoinbasePayloadScriptPublicKeyMaxLength: pf.CoinbasePayloadScriptPublicKeyMaxLength,
+		PruningProofM:                           pf.PruningProofM,
+		DeflationaryPhaseDaaScore:               pf.DeflationaryPhaseDaaScore,
+		PayoutSplitActivationBlueScore:          pf.PayoutSplitActivationBlueScore,
+		DisallowDirectBlocksOnTopOfGenesis:      pf.DisallowDirectBlocksOnTopOfGenesis,
+		MaxBlockLevel:                           pf.MaxBlockLevel,
+		MergeDepth:                              pf.MergeDepth,
+		RuleChangeActivationThreshold:           pf.RuleChangeActivationThreshold,
+		MinerConfirmationWindow:                 pf.MinerConfirmationWindow,
+		Deployments:                             deployments,
+		Checkpoints:                             checkpoints,
+	}
+	err = params.validateWithoutPrefix()
+	if err != nil {
+		return nil, err
+	}
+
+	params.Prefix, err = util.RegisterBech32Prefix(pf.Prefix)
+	if err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// GenesisBlock builds the genesis block described by the definition. Its coinbase
+// pays the genesis subsidy to an unspendable script, followed by the given extra data.
+func (pf *ParamsFile) GenesisBlock() (*externalapi.DomainBlock, error) {
+	gf := pf.Genesis
+	if gf == nil {
+		return nil, errors.New("missing genesis block definition")
+	}
+
+	extraData, err := hex.DecodeString(gf.CoinbaseExtraData)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid genesis coinbase extra data")
+	}
+
+	// Blue score || subsidy || script version || script length || OP-FALSE || extra data
+	payload := make([]byte, 8+8+2+1+1, 8+8+2+1+1+len(extraData))
+	binary.LittleEndian.PutUint64(payload[8:], pf.SubsidyGenesisReward)
+	payload[8+8+2] = 1
+	payload = append(payload, extraData...)
+
+	coinbaseTx := transactionhelper.NewSubnetworkTransaction(0, []*externalapi.DomainTransactionInput{},
+		[]*externalapi.DomainTransactionOutput{}, &subnetworks.SubnetworkIDCoinbase, 0, payload)
+	transactions := []*externalapi.DomainTransaction{coinbaseTx}
+
+	return &externalapi.DomainBlock{
+		Header: blockheader.NewImmutableBlockHeader(
+			gf.Version,
+			[]externalapi.BlockLevelParents{},
+			merkle.CalculateHashMerkleRoot(transactions),
+			&externalapi.DomainHash{},
+			externalapi.NewDomainHashFromByteArray(muhash.EmptyMuHashHash.AsArray()),
+			gf.TimeInMilliseconds,
+			gf.Bits,
+			gf.Nonce,
+			gf.DAAScore,
+			0,
+			big.NewInt(0),
+			&externalapi.DomainHash{},
+		),
+		Transactions: transactions,
+	}, nil
+}
+
+// Validate checks that the parameters describe a network that a node can run
+func (p *Params) Validate() error {
+	if p.Prefix == util.Bech32PrefixUnknown {
+		return errors.New("address prefix must be set")
+	}
+	return p.validateWithoutPrefix()
+}
+
+// validateWithoutPrefix checks everything Validate does but the address prefix,
+// which is only set once the rest of the parameters are known to be valid
+func (p *Params) validateWithoutPrefix() error {
+	if p.Name == "" {
+		return errors.New("network name must not be empty")
+	}
+	if p.Net == 0 {
+		return errors.New("network magic (net) must not be zero")
+	}
+	for _, port := range []string{p.RPCPort, p.DefaultPort} {
+		portNumber, err := strconv.ParseUint(port, 10, 16)
+		if err != nil || portNumber == 0 {
+			return errors.Errorf("invalid port %q", port)
+		}
+	}
+	if p.RPCPort == p.DefaultPort {
+		return errors.New("the RPC port and the P2P port must be different")
+	}
+	if p.K == 0 {
+		return errors.New("k must be positive")
+	}
+	if p.MaxBlockParents == 0 {
+		return errors.New("maxBlockParents must be positive")
+	}
+	if p.MergeSetSizeLimit == 0 {
+		return errors.New("mergeSetSizeLimit must be positive")
+	}
+	if p.TargetTimePerBlock <= 0 {
+		return errors.New("the target time per block must be positive")
+	}
+	if p.FinalityDuration < p.TargetTimePerBlock {
+		return errors.New("the finality duration must be at least the target time per block")
+	}
+	if p.DifficultyAdjustmentWindowSize <= 0 {
+		return errors.New("difficultyAdjustmentWindowSize must be positive")
+	}
+	if p.TimestampDeviationTolerance <= 0 {
+		return errors.New("timestampDeviationTolerance must be positive")
+	}
+	if p.MaxBlockMass == 0 {
+		return errors.New("maxBlockMass must be positive")
+	}
+	if p.MaxBlockLevel <= 0 {
+		return errors.New("maxBlockLevel must be positive")
+	}
+	if p.MergeDepth == 0 || p.MergeDepth > p.FinalityDepth() {
+		return errors.Errorf("mergeDepth must be positive and no larger than the finality depth (%d)",
+			p.FinalityDepth())
+	}
+	if p.PruningProofM == 0 {
+		return errors.New("pruningProofM must be positive")
+	}
+	if p.PowMax == nil || p.PowMax.Sign() <= 0 {
+		return errors.New("powMax must be positive")
+	}
+
+	genesisTarget := difficulty.CompactToBig(p.GenesisBlock.Header.Bits())
+	if genesisTarget.Sign() <= 0 || genesisTarget.Cmp(p.PowMax) > 0 {
+		return errors.Errorf("the genesis target (%s) must be positive and no larger than powMax (%s)",
+			genesisTarget.Text(16), p.PowMax.Text(16))
+	}
+	if !p.SkipProofOfWork && !pow.CheckProofOfWorkByBits(p.GenesisBlock.Header.ToMutable()) {
+		return errors.Errorf("the genesis block %s does not satisfy its proof of work target", p.GenesisHash)
+	}
+
+	return p.validateDeployments()
+}
+
+func (p *Params) validateDeployments() error {
+	if len(p.Deployments) == 0 {
+		return nil
+	}
+	if p.MinerConfirmationWindow == 0 {
+		return errors.New("minerConfirmationWindow must be positive when there are deployments")
+	}
+	if p.RuleChangeActivationThreshold == 0 || p.RuleChangeActivationThreshold > p.MinerConfirmationWindow {
+		return errors.New("ruleChangeActivationThreshold must be positive and no larger than minerConfirmationWindow")
+	}
+
+	usedBits := make(map[uint8]string)
+	for _, deployment := range p.Deployments {
+		if deployment.BitNumber >= constants.VersionBitsNumBits {
+			return errors.Errorf("the bit of deployment %s must be lower than %d",
+				deployment.Name, constants.VersionBitsNumBits)
+		}
+		if otherName, ok := usedBits[deployment.BitNumber]; ok {
+			return errors.Errorf("deployments %s and %s use the same bit %d",
+				otherName, deployment.Name, deployment.BitNumber)
+		}
+		usedBits[deployment.BitNumber] = deployment.Name
+		if deployment.StartDAAScore >= deployment.TimeoutDAAScore {
+			return errors.Errorf("the start DAA score of deployment %s must be lower than its timeout",
+				deployment.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package dagconfig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/c4ei/c4exd/domain/consensus/utils/consensushashing"
+	"github.com/c4ei/c4exd/util"
+)
+
+func writeParamsFile(t *testing.T, fileName string, content string) string {
+	path := filepath.Join(t.TempDir(), fileName)
+	err := os.WriteFile(path, []byte(content), 0600)
+	if err != nil {
+		t.Fatalf("WriteFile: %s", err)
+	}
+	return path
+}
+
+func TestLoadParamsFile(t *testing.T) {
+	path := writeParamsFile(t, "params.json", `{
+		"name": "c4ex-testparamsfile",
+		"net": 4660,
+		"prefix": "c4expf",
+		"rpcPort": "24510",
+		"defaultPort": "24511",
+		"dnsSeeds": ["seed.example.com"],
+		"genesis": {
+			"timeInMilliseconds": 1637609671037,
+			"bits": 545259519,
+			"coinbaseExtraData": "00ff"
+		},
+		"k": 10,
+		"targetTimePerBlockInMilliSeconds": 500,
+		"finalityDuration": 3600000,
+		"mergeDepth": 100,
+		"blockCoinbaseMaturity": 7,
+		"deflationaryPhaseDaaScore": 1000,
+		"skipProofOfWork": true
+	}`)
+
+	params, err := LoadParamsFile(path)
+	if err != nil {
+		t.Fatalf("LoadParamsFile: %+v", err)
+	}
+
+	if params.Name != "c4ex-testparamsfile" || params.Prefix.String() != "c4expf" {
+		t.Fatalf("unexpected name or prefix: %s, %s", params.Name, params.Prefix)
+	}
+	if params.K != 10 || params.TargetTimePerBlock != 500*time.Millisecond || params.FinalityDepth() != 7200 {
+		t.Fatalf("unexpected DAG parameters: k=%d, targetTimePerBlock=%s, finalityDepth=%d",
+			params.K, params.TargetTimePerBlock, params.FinalityDepth())
+	}
+	if params.BlockCoinbaseMaturity != 7 || params.DeflationaryPhaseDaaScore != 1000 {
+		t.Fatalf("unexpected coinbase parameters")
+	}
+	if params.MaxBlockMass != defaultMaxBlockMass {
+		t.Fatalf("expected omitted fields to get their default values")
+	}
+	if !params.GenesisHash.Equal(consensushashing.BlockHash(params.GenesisBlock)) {
+		t.Fatalf("genesis hash doesn't match the genesis block")
+	}
+	if params.GenesisBlock.Header.TimeInMilliseconds() != 1637609671037 {
+		t.Fatalf("unexpected genesis timestamp %d", params.GenesisBlock.Header.TimeInMilliseconds())
+	}
+}
+
+func TestLoadTOMLParamsFile(t *testing.T) {
+	path := writeParamsFile(t, "params.toml", `
+		name = "c4ex-testtomlparamsfile"
+		net = 4662
+		prefix = "c4expft"
+		rpcPort = "24530"
+		defaultPort = "24531"
+		dnsSeeds = ["seed.example.com"]
+		k = 10
+		targetTimePerBlockInMilliSeconds = 500
+		finalityDuration = 3600000
+		mergeDepth = 100
+		skipProofOfWork = true
+
+		[genesis]
+		timeInMilliseconds = 1637609671037
+		coinbaseExtraData = "00ff"
+
+		[[deployments]]
+		name = "test"
+		bit = 1
+		startDaaScore = 2016
+		timeoutDaaScore = 4032
+	`)
+
+	params, err := LoadParamsFile(path)
+	if err != nil {
+		t.Fatalf("LoadParamsFile: %+v", err)
+	}
+
+	if params.Name != "c4ex-testtomlparamsfile" || params.Prefix.String() != "c4expft" || params.Net != 4662 {
+		t.Fatalf("unexpected name, prefix or net: %s, %s, %d", params.Name, params.Prefix, params.Net)
+	}
+	if params.K != 10 || params.TargetTimePerBlock != 500*time.Millisecond || params.FinalityDepth() != 7200 {
+		t.Fatalf("unexpected DAG parameters: k=%d, targetTimePerBlock=%s, finalityDepth=%d",
+			params.K, params.TargetTimePerBlock, params.FinalityDepth())
+	}
+	if len(params.DNSSeeds) != 1 || params.DNSSeeds[0] != "seed.example.com" {
+		t.Fatalf("unexpected DNS seeds %v", params.DNSSeeds)
+	}
+	if len(params.Deployments) != 1 || params.Deployments[0].BitNumber != 1 ||
+		params.Deployments[0].StartDAAScore != 2016 || params.Deployments[0].TimeoutDAAScore != 4032 {
+		t.Fatalf("unexpected deployments %+v", params.Deployments)
+	}
+	if params.MaxBlockMass != defaultMaxBlockMass {
+		t.Fatalf("expected omitted fields to get their default values")
+	}
+	if params.GenesisBlock.Header.Bits() != NewParamsFile().Genesis.Bits {
+		t.Fatalf("expected the omitted genesis bits to get their default value, got %x",
+			params.GenesisBlock.Header.Bits())
+	}
+	if params.GenesisBlock.Header.TimeInMilliseconds() != 1637609671037 {
+		t.Fatalf("unexpected genesis timestamp %d", params.GenesisBlock.Header.TimeInMilliseconds())
+	}
+
+	_, err = ReadParamsFile(writeParamsFile(t, "params.toml", `
+		name = "c4ex-testtomlparamsfile"
+
+		[genesis]
+		notAField = 1
+	`))
+	if err == nil || !strings.Contains(err.Error(), "unknown field") {
+		t.Fatalf("expected an unknown field error, got: %v", err)
+	}
+
+	// Files that don't end with .toml are read as JSON
+	_, err = ReadParamsFile(writeParamsFile(t, "params.conf", `name = "c4ex-testtomlparamsfile"`))
+	if err == nil {
+		t.Fatalf("expected a TOML file without the .toml extension to fail to parse")
+	}
+}
+
+func TestLoadParamsFileErrors(t *testing.T) {
+	const validFields = `
+		"name": "c4ex-testparamsfileerrors",
+		"net": 4661,
+		"prefix": "c4expfe",
+		"skipProofOfWork": true,`
+
+	tests := []struct {
+		name          string
+		content       string
+		expectedError string
+	}{
+		{
+			name: "unknown field",
+			content: `{` + validFields + `
+				"rpcPort": "24520", "defaultPort": "24521", "genesis": {}, "notAField": 1}`,
+			expectedError: "unknown field",
+		},
+		{
+			name: "same ports",
+			content: `{` + validFields + `
+				"rpcPort": "24520", "defaultPort": "24520", "genesis": {"bits": 545259519}}`,
+			expectedError: "must be different",
+		},
+		{
+			name: "genesis hash mismatch",
+			content: `{` + validFields + `
+				"rpcPort": "24520", "defaultPort": "24521",
+				"genesis": {"bits": 545259519, "hash": "0000000000000000000000000000000000000000000000000000000000000000"}}`,
+			expectedError: "genesis hash mismatch",
+		},
+		{
+			name: "genesis target above powMax",
+			content: `{` + validFields + `
+				"rpcPort": "24520", "defaultPort": "24521", "powMax": "ff", "genesis": {"bits": 545259519}}`,
+			expectedError: "no larger than powMax",
+		},
+		{
+			name: "prefix with a separator",
+			content: `{"name": "c4ex-testparamsfileerrors", "net": 4661, "prefix": "c4ex:pfe", "skipProofOfWork": true,
+				"rpcPort": "24520", "defaultPort": "24521", "genesis": {"bits": 545259519}}`,
+			expectedError: "is not allowed",
+		},
+		{
+			name: "prefix with a space",
+			content: `{"name": "c4ex-testparamsfileerrors", "net": 4661, "prefix": "c4ex pfe", "skipProofOfWork": true,
+				"rpcPort": "24520", "defaultPort": "24521", "genesis": {"bits": 545259519}}`,
+			expectedError: "is not allowed",
+		},
+	}
+
+	for _, test := range tests {
+		_, err := LoadParamsFile(writeParamsFile(t, "params.json", test.content))
+		if err == nil {
+			t.Fatalf("%s: expected an error", test.name)
+		}
+		if !strings.Contains(err.Error(), test.expectedError) {
+			t.Fatalf("%s: expected error to contain %q but got: %s", test.name, test.expectedError, err)
+		}
+	}
+
+	// The prefix of a definition that failed validation is not registered
+	_, err := util.ParsePrefix("c4expfe")
+	if err == nil {
+		t.Fatalf("the prefix of an invalid network definition was registered")
+	}
+}
@@ -87,10 +87,16 @@ func syncConsensuses(syncer, syncee externalapi.Consensus) error {
 			return err
 		}
 
+		deploymentStates, err := syncer.TrustedDeploymentStates(blockHash)
+		if err != nil {
+			return err
+		}
+
 		blockWithTrustedData := &externalapi.BlockWithTrustedData{
-			Block:        block,
-			DAAWindow:    make([]*externalapi.TrustedDataDataDAAHeader, 0, len(blockDAAWindowHashes)),
-			GHOSTDAGData: make([]*externalapi.BlockGHOSTDAGDataHashPair, 0, len(ghostdagDataBlockHashes)),
+			Block:            block,
+			DAAWindow:        make([]*externalapi.TrustedDataDataDAAHeader, 0, len(blockDAAWindowHashes)),
+			GHOSTDAGData:     make([]*externalapi.BlockGHOSTDAGDataHashPair, 0, len(ghostdagDataBlockHashes)),
+			DeploymentStates: deploymentStates,
 		}
 
 		for i, daaBlockHash := range blockDAAWindowHashes {
@@ -38,17 +38,20 @@ type blockTemplateBuilder struct {
 	policy             policy
 
 	coinbasePayloadScriptPublicKeyMaxLength uint8
+	payoutSplitActivationBlueScore          uint64
 }
 
 // New creates a new blockTemplateBuilder
 func New(consensusReference consensusreference.ConsensusReference, mempool miningmanagerapi.Mempool,
-	blockMaxMass uint64, coinbasePayloadScriptPublicKeyMaxLength uint8) miningmanagerapi.BlockTemplateBuilder {
+	blockMaxMass uint64, coinbasePayloadScriptPublicKeyMaxLength uint8,
+	payoutSplitActivationBlueScore uint64) miningmanagerapi.BlockTemplateBuilder {
 	return &blockTemplateBuilder{
 		consensusReference: consensusReference,
 		mempool:            mempool,
 		policy:             policy{BlockMaxMass: blockMaxMass},
 
 		coinbasePayloadScriptPublicKeyMaxLength: coinbasePayloadScriptPublicKeyMaxLength,
+		payoutSplitActivationBlueScore:          payoutSplitActivationBlueScore,
 	}
 }
 
@@ -173,13 +176,20 @@ func (btb *blockTemplateBuilder) BuildBlockTemplate(
 	return blockTemplate, nil
 }
 
-// ModifyBlockTemplate modifies an existing block template to the requested coinbase data and updates the timestamp
+// ModifyBlockTemplate modifies an existing block template to the requested coinbase data and updates the timestamp.
+// Templates whose coinbase splits the rewards between payouts, before or after the modification, can't be modified
+// and have to be built anew
 func (btb *blockTemplateBuilder) ModifyBlockTemplate(newCoinbaseData *consensusexternalapi.DomainCoinbaseData,
 	blockTemplateToModify *consensusexternalapi.DomainBlockTemplate) (*consensusexternalapi.DomainBlockTemplate, error) {
 
+	if len(newCoinbaseData.Payouts) > 0 || len(blockTemplateToModify.CoinbaseData.Payouts) > 0 {
+		return nil, errors.New("block templates with coinbase payouts can't be modified")
+	}
+
 	// The first transaction is always the coinbase transaction
 	coinbaseTx := blockTemplateToModify.Block.Transactions[transactionhelper.CoinbaseTransactionIndex]
-	newPayload, err := coinbasemanager.ModifyCoinbasePayload(coinbaseTx.Payload, newCoinbaseData, btb.coinbasePayloadScriptPublicKeyMaxLength)
+	newPayload, err := coinbasemanager.ModifyCoinbasePayload(coinbaseTx.Payload, newCoinbaseData,
+		btb.coinbasePayloadScriptPublicKeyMaxLength, btb.payoutSplitActivationBlueScore)
 	if err != nil {
 		return nil, err
 	}
@@ -48,8 +48,10 @@ type miningManager struct {
 func (mm *miningManager) GetBlockTemplate(coinbaseData *externalapi.DomainCoinbaseData) (block *externalapi.DomainBlock, isNearlySynced bool, err error) {
 	mm.cacheLock.Lock()
 	immutableCachedTemplate := mm.getImmutableCachedTemplate()
-	// We first try and use a cached template
-	if immutableCachedTemplate != nil {
+	// We first try and use a cached template. Coinbases with payouts can't be modified in place,
+	// so templates with them are always built anew
+	if immutableCachedTemplate != nil && (immutableCachedTemplate.CoinbaseData.Equal(coinbaseData) ||
+		(len(immutableCachedTemplate.CoinbaseData.Payouts) == 0 && len(coinbaseData.Payouts) == 0)) {
 		mm.cacheLock.Unlock()
 		if immutableCachedTemplate.CoinbaseData.Equal(coinbaseData) {
 			return immutableCachedTemplate.Block, immutableCachedTemplate.IsNearlySynced, nil
@@ -3,6 +3,7 @@ module github.com/c4ei/c4exd
 go 1.18
 
 require (
+	github.com/BurntSushi/toml v1.3.2
 	github.com/btcsuite/btcutil v1.0.2
 	github.com/btcsuite/go-socks v0.0.0-20170105172521-4720035b7bfd
 	github.com/btcsuite/winsvc v1.0.0
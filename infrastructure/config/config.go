@@ -123,6 +123,9 @@ type Flags struct {
 	MaxUTXOCacheSize                uint64        `long:"maxutxocachesize" description:"Max size of loaded UTXO into ram from the disk in bytes"`
 	UTXOIndex                       bool          `long:"utxoindex" description:"Enable the UTXO index"`
 	IsArchivalNode                  bool          `long:"archival" description:"Run as an archival node: don't delete old block data when moving the pruning point (Warning: heavy disk usage)'"`
+	RetentionDAAScore               uint64        `long:"retention-daa-score" description:"Keep block bodies and acceptance data below the pruning point up to this DAA score distance from it"`
+	RetentionPruningPeriods         uint64        `long:"retention-pruning-periods" description:"Keep block bodies and acceptance data below the pruning point for this number of pruning periods (finality intervals)"`
+	AddCheckpoints                  []string      `long:"addcheckpoint" description:"Add a custom checkpoint -- Format: '<hash>:<daa score>'"`
 	AllowSubmitBlockWhenNotSynced   bool          `long:"allow-submit-block-when-not-synced" hidden:"true" description:"Allow the node to accept blocks from RPC while not synced (this flag is mainly used for testing)"`
 	EnableSanityCheckPruningUTXOSet bool          `long:"enable-sanity-check-pruning-utxo" hidden:"true" description:"When moving the pruning point - check that the utxo set matches the utxo commitment"`
 	ProtocolVersion                 uint32        `long:"protocol-version" description:"Use non default p2p protocol version"`
@@ -143,6 +146,15 @@ type Config struct {
 	SubnetworkID  *externalapi.DomainSubnetworkID // nil in full nodes
 }
 
+// RetentionDepth returns the DAA score distance below the pruning point up to which
+// block data should be kept, as set by either of the retention options.
+func (cfg *Config) RetentionDepth() uint64 {
+	if cfg.RetentionPruningPeriods > 0 {
+		return cfg.RetentionPruningPeriods * cfg.NetParams().FinalityDepth()
+	}
+	return cfg.RetentionDAAScore
+}
+
 // ServiceOptions defines the configuration options for the daemon as a service on
 // Windows.
 type ServiceOptions struct {
@@ -300,6 +312,27 @@ func LoadConfig() (*Config, error) {
 		return nil, err
 	}
 
+	// Add any checkpoints given by the operator on top of the ones of the active network.
+	// The network parameters are copied so that the defaults of the network are not modified.
+	if len(cfg.AddCheckpoints) > 0 {
+		activeNetParams := *cfg.ActiveNetParams
+		activeNetParams.Checkpoints = make([]externalapi.Checkpoint, len(cfg.ActiveNetParams.Checkpoints),
+			len(cfg.ActiveNetParams.Checkpoints)+len(cfg.AddCheckpoints))
+		copy(activeNetParams.Checkpoints, cfg.ActiveNetParams.Checkpoints)
+		for _, checkpointString := range cfg.AddCheckpoints {
+			checkpoint, err := externalapi.ParseCheckpoint(checkpointString)
+			if err != nil {
+				str := "%s: Error parsing checkpoint: %s"
+				err := errors.Errorf(str, funcName, err)
+				fmt.Fprintln(os.Stderr, err)
+				fmt.Fprintln(os.Stderr, usageMessage)
+				return nil, err
+			}
+			activeNetParams.Checkpoints = append(activeNetParams.Checkpoints, checkpoint)
+		}
+		cfg.ActiveNetParams = &activeNetParams
+	}
+
 	// Set the default policy for relaying non-standard transactions
 	// according to the default of the active network. The set
 	// configuration value takes precedence over the default value for the
@@ -320,6 +353,23 @@ func LoadConfig() (*Config, error) {
 	}
 	cfg.RelayNonStd = relayNonStd
 
+	if cfg.RetentionDAAScore > 0 && cfg.RetentionPruningPeriods > 0 {
+		str := "%s: retention-daa-score and retention-pruning-periods cannot be used " +
+			"together -- choose only one"
+		err := errors.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, err
+	}
+
+	if cfg.IsArchivalNode && (cfg.RetentionDAAScore > 0 || cfg.RetentionPruningPeriods > 0) {
+		str := "%s: the retention options cannot be used on an archival node"
+		err := errors.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, err
+	}
+
 	cfg.AppDir = cleanAndExpandPath(cfg.AppDir)
 	// Append the network type to the app directory so it is "namespaced"
 	// per network.
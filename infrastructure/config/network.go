@@ -21,6 +21,7 @@ type NetworkFlags struct {
 	Simnet                bool   `long:"simnet" description:"Use the simulation test network"`
 	Devnet                bool   `long:"devnet" description:"Use the development test network"`
 	OverrideDAGParamsFile string `long:"override-dag-params-file" description:"Overrides DAG params (allowed only on devnet)"`
+	NetParamsFile         string `long:"netparams" description:"Use a custom network defined by the given network parameters file (JSON, or TOML if its extension is .toml)"`
 
 	ActiveNetParams *dagconfig.Params
 }
@@ -50,6 +51,7 @@ type overrideDAGParamsConfig struct {
 	DisableDifficultyAdjustment             *bool              `json:"disableDifficultyAdjustment"`
 	SkipProofOfWork                         *bool              `json:"skipProofOfWork"`
 	HardForkOmitGenesisFromParentsDAAScore  *uint64            `json:"hardForkOmitGenesisFromParentsDaaScore"`
+	PayoutSplitActivationBlueScore          *uint64            `json:"payoutSplitActivationBlueScore"`
 }
 
 // ResolveNetwork parses the network command line argument and sets NetParams accordingly.
@@ -74,8 +76,20 @@ func (networkFlags *NetworkFlags) ResolveNetwork(parser *flags.Parser) error {
 		numNets++
 		networkFlags.ActiveNetParams = &dagconfig.DevnetParams
 	}
+	if networkFlags.NetParamsFile != "" {
+		numNets++
+		params, err := dagconfig.LoadParamsFile(networkFlags.NetParamsFile)
+		if err != nil {
+			return err
+		}
+		err = dagconfig.Register(params)
+		if err != nil {
+			return errors.Wrapf(err, "cannot register network %s", params.Name)
+		}
+		networkFlags.ActiveNetParams = params
+	}
 	if numNets > 1 {
-		message := "Multiple networks parameters (testnet, simnet, devnet, etc.) cannot be used" +
+		message := "Multiple networks parameters (testnet, simnet, devnet, netparams, etc.) cannot be used" +
 			"together. Please choose only one network"
 		err := errors.Errorf(message)
 		fmt.Fprintln(os.Stderr, err)
@@ -155,6 +169,10 @@ func (networkFlags *NetworkFlags) overrideDAGParams() error {
 		networkFlags.ActiveNetParams.CoinbasePayloadScriptPublicKeyMaxLength = *config.CoinbasePayloadScriptPublicKeyMaxLength
 	}
 
+	if config.PayoutSplitActivationBlueScore != nil {
+		networkFlags.ActiveNetParams.PayoutSplitActivationBlueScore = *config.PayoutSplitActivationBlueScore
+	}
+
 	if config.PowMax != nil {
 		powMax, ok := big.NewInt(0).SetString(*config.PowMax, 16)
 		if !ok {
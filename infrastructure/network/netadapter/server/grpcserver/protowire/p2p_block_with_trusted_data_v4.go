@@ -1,7 +1,10 @@
 package protowire
 
 import (
+	"math"
+
 	"github.com/c4ei/c4exd/app/appmessage"
+	"github.com/c4ei/c4exd/domain/consensus/model/externalapi"
 	"github.com/pkg/errors"
 )
 
@@ -15,18 +18,33 @@ func (x *C4exdMessage_BlockWithTrustedDataV4) toAppMessage() (appmessage.Message
 		return nil, err
 	}
 
+	deploymentStates := make([]externalapi.DeploymentState, len(x.BlockWithTrustedDataV4.DeploymentStates))
+	for i, state := range x.BlockWithTrustedDataV4.DeploymentStates {
+		if state > math.MaxUint8 {
+			return nil, errors.Errorf("deployment state %d is out of range", state)
+		}
+		deploymentStates[i] = externalapi.DeploymentState(state)
+	}
+
 	return &appmessage.MsgBlockWithTrustedDataV4{
 		Block:               msgBlock,
 		DAAWindowIndices:    x.BlockWithTrustedDataV4.DaaWindowIndices,
 		GHOSTDAGDataIndices: x.BlockWithTrustedDataV4.GhostdagDataIndices,
+		DeploymentStates:    deploymentStates,
 	}, nil
 }
 
 func (x *C4exdMessage_BlockWithTrustedDataV4) fromAppMessage(msgBlockWithTrustedData *appmessage.MsgBlockWithTrustedDataV4) error {
+	deploymentStates := make([]uint32, len(msgBlockWithTrustedData.DeploymentStates))
+	for i, state := range msgBlockWithTrustedData.DeploymentStates {
+		deploymentStates[i] = uint32(state)
+	}
+
 	x.BlockWithTrustedDataV4 = &BlockWithTrustedDataV4Message{
 		Block:               &BlockMessage{},
 		DaaWindowIndices:    msgBlockWithTrustedData.DAAWindowIndices,
 		GhostdagDataIndices: msgBlockWithTrustedData.GHOSTDAGDataIndices,
+		DeploymentStates:    deploymentStates,
 	}
 
 	err := x.BlockWithTrustedDataV4.Block.fromAppMessage(msgBlockWithTrustedData.Block)
@@ -1313,6 +1313,8 @@ type GetBlockTemplateRequestMessage struct {
 	// Which c4ex address should the coinbase block reward transaction pay into
 	PayAddress string `protobuf:"bytes,1,opt,name=payAddress,proto3" json:"payAddress,omitempty"`
 	ExtraData  string `protobuf:"bytes,2,opt,name=extraData,proto3" json:"extraData,omitempty"`
+	// Further payees the block rewards are split with. payAddress gets what they leave
+	Payouts []*RpcCoinbasePayout `protobuf:"bytes,3,rep,name=payouts,proto3" json:"payouts,omitempty"`
 }
 
 func (x *GetBlockTemplateRequestMessage) Reset() {
@@ -1361,6 +1363,70 @@ func (x *GetBlockTemplateRequestMessage) GetExtraData() string {
 	return ""
 }
 
+func (x *GetBlockTemplateRequestMessage) GetPayouts() []*RpcCoinbasePayout {
+	if x != nil {
+		return x.Payouts
+	}
+	return nil
+}
+
+// RpcCoinbasePayout is a payee of a share of the block rewards
+type RpcCoinbasePayout struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Address string `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	// The share of the rewards in basis points (1/10000)
+	Weight uint32 `protobuf:"varint,2,opt,name=weight,proto3" json:"weight,omitempty"`
+}
+
+func (x *RpcCoinbasePayout) Reset() {
+	*x = RpcCoinbasePayout{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_proto_msgTypes[19]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RpcCoinbasePayout) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RpcCoinbasePayout) ProtoMessage() {}
+
+func (x *RpcCoinbasePayout) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_proto_msgTypes[19]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RpcCoinbasePayout.ProtoReflect.Descriptor instead.
+func (*RpcCoinbasePayout) Descriptor() ([]byte, []int) {
+	return file_rpc_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *RpcCoinbasePayout) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *RpcCoinbasePayout) GetWeight() uint32 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
 type GetBlockTemplateResponseMessage struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -1378,7 +1444,7 @@ type GetBlockTemplateResponseMessage struct {
 func (x *GetBlockTemplateResponseMessage) Reset() {
 	*x = GetBlockTemplateResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[19]
+		mi := &file_rpc_proto_msgTypes[20]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1391,7 +1457,7 @@ func (x *GetBlockTemplateResponseMessage) String() string {
 func (*GetBlockTemplateResponseMessage) ProtoMessage() {}
 
 func (x *GetBlockTemplateResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[19]
+	mi := &file_rpc_proto_msgTypes[20]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1404,7 +1470,7 @@ func (x *GetBlockTemplateResponseMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetBlockTemplateResponseMessage.ProtoReflect.Descriptor instead.
 func (*GetBlockTemplateResponseMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{19}
+	return file_rpc_proto_rawDescGZIP(), []int{20}
 }
 
 func (x *GetBlockTemplateResponseMessage) GetBlock() *RpcBlock {
@@ -1440,7 +1506,7 @@ type NotifyBlockAddedRequestMessage struct {
 func (x *NotifyBlockAddedRequestMessage) Reset() {
 	*x = NotifyBlockAddedRequestMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[20]
+		mi := &file_rpc_proto_msgTypes[21]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1453,7 +1519,7 @@ func (x *NotifyBlockAddedRequestMessage) String() string {
 func (*NotifyBlockAddedRequestMessage) ProtoMessage() {}
 
 func (x *NotifyBlockAddedRequestMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[20]
+	mi := &file_rpc_proto_msgTypes[21]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1466,7 +1532,7 @@ func (x *NotifyBlockAddedRequestMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use NotifyBlockAddedRequestMessage.ProtoReflect.Descriptor instead.
 func (*NotifyBlockAddedRequestMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{20}
+	return file_rpc_proto_rawDescGZIP(), []int{21}
 }
 
 type NotifyBlockAddedResponseMessage struct {
@@ -1480,7 +1546,7 @@ type NotifyBlockAddedResponseMessage struct {
 func (x *NotifyBlockAddedResponseMessage) Reset() {
 	*x = NotifyBlockAddedResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[21]
+		mi := &file_rpc_proto_msgTypes[22]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1493,7 +1559,7 @@ func (x *NotifyBlockAddedResponseMessage) String() string {
 func (*NotifyBlockAddedResponseMessage) ProtoMessage() {}
 
 func (x *NotifyBlockAddedResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[21]
+	mi := &file_rpc_proto_msgTypes[22]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1506,7 +1572,7 @@ func (x *NotifyBlockAddedResponseMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use NotifyBlockAddedResponseMessage.ProtoReflect.Descriptor instead.
 func (*NotifyBlockAddedResponseMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{21}
+	return file_rpc_proto_rawDescGZIP(), []int{22}
 }
 
 func (x *NotifyBlockAddedResponseMessage) GetError() *RPCError {
@@ -1531,7 +1597,7 @@ type BlockAddedNotificationMessage struct {
 func (x *BlockAddedNotificationMessage) Reset() {
 	*x = BlockAddedNotificationMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[22]
+		mi := &file_rpc_proto_msgTypes[23]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1544,7 +1610,7 @@ func (x *BlockAddedNotificationMessage) String() string {
 func (*BlockAddedNotificationMessage) ProtoMessage() {}
 
 func (x *BlockAddedNotificationMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[22]
+	mi := &file_rpc_proto_msgTypes[23]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1557,7 +1623,7 @@ func (x *BlockAddedNotificationMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BlockAddedNotificationMessage.ProtoReflect.Descriptor instead.
 func (*BlockAddedNotificationMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{22}
+	return file_rpc_proto_rawDescGZIP(), []int{23}
 }
 
 func (x *BlockAddedNotificationMessage) GetBlock() *RpcBlock {
@@ -1578,7 +1644,7 @@ type GetPeerAddressesRequestMessage struct {
 func (x *GetPeerAddressesRequestMessage) Reset() {
 	*x = GetPeerAddressesRequestMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[23]
+		mi := &file_rpc_proto_msgTypes[24]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1591,7 +1657,7 @@ func (x *GetPeerAddressesRequestMessage) String() string {
 func (*GetPeerAddressesRequestMessage) ProtoMessage() {}
 
 func (x *GetPeerAddressesRequestMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[23]
+	mi := &file_rpc_proto_msgTypes[24]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1604,7 +1670,7 @@ func (x *GetPeerAddressesRequestMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetPeerAddressesRequestMessage.ProtoReflect.Descriptor instead.
 func (*GetPeerAddressesRequestMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{23}
+	return file_rpc_proto_rawDescGZIP(), []int{24}
 }
 
 type GetPeerAddressesResponseMessage struct {
@@ -1620,7 +1686,7 @@ type GetPeerAddressesResponseMessage struct {
 func (x *GetPeerAddressesResponseMessage) Reset() {
 	*x = GetPeerAddressesResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[24]
+		mi := &file_rpc_proto_msgTypes[25]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1633,7 +1699,7 @@ func (x *GetPeerAddressesResponseMessage) String() string {
 func (*GetPeerAddressesResponseMessage) ProtoMessage() {}
 
 func (x *GetPeerAddressesResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[24]
+	mi := &file_rpc_proto_msgTypes[25]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1646,7 +1712,7 @@ func (x *GetPeerAddressesResponseMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetPeerAddressesResponseMessage.ProtoReflect.Descriptor instead.
 func (*GetPeerAddressesResponseMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{24}
+	return file_rpc_proto_rawDescGZIP(), []int{25}
 }
 
 func (x *GetPeerAddressesResponseMessage) GetAddresses() []*GetPeerAddressesKnownAddressMessage {
@@ -1681,7 +1747,7 @@ type GetPeerAddressesKnownAddressMessage struct {
 func (x *GetPeerAddressesKnownAddressMessage) Reset() {
 	*x = GetPeerAddressesKnownAddressMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[25]
+		mi := &file_rpc_proto_msgTypes[26]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1694,7 +1760,7 @@ func (x *GetPeerAddressesKnownAddressMessage) String() string {
 func (*GetPeerAddressesKnownAddressMessage) ProtoMessage() {}
 
 func (x *GetPeerAddressesKnownAddressMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[25]
+	mi := &file_rpc_proto_msgTypes[26]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1707,7 +1773,7 @@ func (x *GetPeerAddressesKnownAddressMessage) ProtoReflect() protoreflect.Messag
 
 // Deprecated: Use GetPeerAddressesKnownAddressMessage.ProtoReflect.Descriptor instead.
 func (*GetPeerAddressesKnownAddressMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{25}
+	return file_rpc_proto_rawDescGZIP(), []int{26}
 }
 
 func (x *GetPeerAddressesKnownAddressMessage) GetAddr() string {
@@ -1728,7 +1794,7 @@ type GetSelectedTipHashRequestMessage struct {
 func (x *GetSelectedTipHashRequestMessage) Reset() {
 	*x = GetSelectedTipHashRequestMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[26]
+		mi := &file_rpc_proto_msgTypes[27]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1741,7 +1807,7 @@ func (x *GetSelectedTipHashRequestMessage) String() string {
 func (*GetSelectedTipHashRequestMessage) ProtoMessage() {}
 
 func (x *GetSelectedTipHashRequestMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[26]
+	mi := &file_rpc_proto_msgTypes[27]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1754,7 +1820,7 @@ func (x *GetSelectedTipHashRequestMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetSelectedTipHashRequestMessage.ProtoReflect.Descriptor instead.
 func (*GetSelectedTipHashRequestMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{26}
+	return file_rpc_proto_rawDescGZIP(), []int{27}
 }
 
 type GetSelectedTipHashResponseMessage struct {
@@ -1769,7 +1835,7 @@ type GetSelectedTipHashResponseMessage struct {
 func (x *GetSelectedTipHashResponseMessage) Reset() {
 	*x = GetSelectedTipHashResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[27]
+		mi := &file_rpc_proto_msgTypes[28]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1782,7 +1848,7 @@ func (x *GetSelectedTipHashResponseMessage) String() string {
 func (*GetSelectedTipHashResponseMessage) ProtoMessage() {}
 
 func (x *GetSelectedTipHashResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[27]
+	mi := &file_rpc_proto_msgTypes[28]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1795,7 +1861,7 @@ func (x *GetSelectedTipHashResponseMessage) ProtoReflect() protoreflect.Message
 
 // Deprecated: Use GetSelectedTipHashResponseMessage.ProtoReflect.Descriptor instead.
 func (*GetSelectedTipHashResponseMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{27}
+	return file_rpc_proto_rawDescGZIP(), []int{28}
 }
 
 func (x *GetSelectedTipHashResponseMessage) GetSelectedTipHash() string {
@@ -1828,7 +1894,7 @@ type GetMempoolEntryRequestMessage struct {
 func (x *GetMempoolEntryRequestMessage) Reset() {
 	*x = GetMempoolEntryRequestMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[28]
+		mi := &file_rpc_proto_msgTypes[29]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1841,7 +1907,7 @@ func (x *GetMempoolEntryRequestMessage) String() string {
 func (*GetMempoolEntryRequestMessage) ProtoMessage() {}
 
 func (x *GetMempoolEntryRequestMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[28]
+	mi := &file_rpc_proto_msgTypes[29]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1854,7 +1920,7 @@ func (x *GetMempoolEntryRequestMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetMempoolEntryRequestMessage.ProtoReflect.Descriptor instead.
 func (*GetMempoolEntryRequestMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{28}
+	return file_rpc_proto_rawDescGZIP(), []int{29}
 }
 
 func (x *GetMempoolEntryRequestMessage) GetTxId() string {
@@ -1890,7 +1956,7 @@ type GetMempoolEntryResponseMessage struct {
 func (x *GetMempoolEntryResponseMessage) Reset() {
 	*x = GetMempoolEntryResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[29]
+		mi := &file_rpc_proto_msgTypes[30]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1903,7 +1969,7 @@ func (x *GetMempoolEntryResponseMessage) String() string {
 func (*GetMempoolEntryResponseMessage) ProtoMessage() {}
 
 func (x *GetMempoolEntryResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[29]
+	mi := &file_rpc_proto_msgTypes[30]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1916,7 +1982,7 @@ func (x *GetMempoolEntryResponseMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetMempoolEntryResponseMessage.ProtoReflect.Descriptor instead.
 func (*GetMempoolEntryResponseMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{29}
+	return file_rpc_proto_rawDescGZIP(), []int{30}
 }
 
 func (x *GetMempoolEntryResponseMessage) GetEntry() *MempoolEntry {
@@ -1947,7 +2013,7 @@ type GetMempoolEntriesRequestMessage struct {
 func (x *GetMempoolEntriesRequestMessage) Reset() {
 	*x = GetMempoolEntriesRequestMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[30]
+		mi := &file_rpc_proto_msgTypes[31]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1960,7 +2026,7 @@ func (x *GetMempoolEntriesRequestMessage) String() string {
 func (*GetMempoolEntriesRequestMessage) ProtoMessage() {}
 
 func (x *GetMempoolEntriesRequestMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[30]
+	mi := &file_rpc_proto_msgTypes[31]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1973,7 +2039,7 @@ func (x *GetMempoolEntriesRequestMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetMempoolEntriesRequestMessage.ProtoReflect.Descriptor instead.
 func (*GetMempoolEntriesRequestMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{30}
+	return file_rpc_proto_rawDescGZIP(), []int{31}
 }
 
 func (x *GetMempoolEntriesRequestMessage) GetIncludeOrphanPool() bool {
@@ -2002,7 +2068,7 @@ type GetMempoolEntriesResponseMessage struct {
 func (x *GetMempoolEntriesResponseMessage) Reset() {
 	*x = GetMempoolEntriesResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[31]
+		mi := &file_rpc_proto_msgTypes[32]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2015,7 +2081,7 @@ func (x *GetMempoolEntriesResponseMessage) String() string {
 func (*GetMempoolEntriesResponseMessage) ProtoMessage() {}
 
 func (x *GetMempoolEntriesResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[31]
+	mi := &file_rpc_proto_msgTypes[32]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2028,7 +2094,7 @@ func (x *GetMempoolEntriesResponseMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetMempoolEntriesResponseMessage.ProtoReflect.Descriptor instead.
 func (*GetMempoolEntriesResponseMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{31}
+	return file_rpc_proto_rawDescGZIP(), []int{32}
 }
 
 func (x *GetMempoolEntriesResponseMessage) GetEntries() []*MempoolEntry {
@@ -2058,7 +2124,7 @@ type MempoolEntry struct {
 func (x *MempoolEntry) Reset() {
 	*x = MempoolEntry{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[32]
+		mi := &file_rpc_proto_msgTypes[33]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2071,7 +2137,7 @@ func (x *MempoolEntry) String() string {
 func (*MempoolEntry) ProtoMessage() {}
 
 func (x *MempoolEntry) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[32]
+	mi := &file_rpc_proto_msgTypes[33]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2084,7 +2150,7 @@ func (x *MempoolEntry) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MempoolEntry.ProtoReflect.Descriptor instead.
 func (*MempoolEntry) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{32}
+	return file_rpc_proto_rawDescGZIP(), []int{33}
 }
 
 func (x *MempoolEntry) GetFee() uint64 {
@@ -2119,7 +2185,7 @@ type GetConnectedPeerInfoRequestMessage struct {
 func (x *GetConnectedPeerInfoRequestMessage) Reset() {
 	*x = GetConnectedPeerInfoRequestMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[33]
+		mi := &file_rpc_proto_msgTypes[34]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2132,7 +2198,7 @@ func (x *GetConnectedPeerInfoRequestMessage) String() string {
 func (*GetConnectedPeerInfoRequestMessage) ProtoMessage() {}
 
 func (x *GetConnectedPeerInfoRequestMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[33]
+	mi := &file_rpc_proto_msgTypes[34]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2145,7 +2211,7 @@ func (x *GetConnectedPeerInfoRequestMessage) ProtoReflect() protoreflect.Message
 
 // Deprecated: Use GetConnectedPeerInfoRequestMessage.ProtoReflect.Descriptor instead.
 func (*GetConnectedPeerInfoRequestMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{33}
+	return file_rpc_proto_rawDescGZIP(), []int{34}
 }
 
 type GetConnectedPeerInfoResponseMessage struct {
@@ -2160,7 +2226,7 @@ type GetConnectedPeerInfoResponseMessage struct {
 func (x *GetConnectedPeerInfoResponseMessage) Reset() {
 	*x = GetConnectedPeerInfoResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[34]
+		mi := &file_rpc_proto_msgTypes[35]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2173,7 +2239,7 @@ func (x *GetConnectedPeerInfoResponseMessage) String() string {
 func (*GetConnectedPeerInfoResponseMessage) ProtoMessage() {}
 
 func (x *GetConnectedPeerInfoResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[34]
+	mi := &file_rpc_proto_msgTypes[35]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2186,7 +2252,7 @@ func (x *GetConnectedPeerInfoResponseMessage) ProtoReflect() protoreflect.Messag
 
 // Deprecated: Use GetConnectedPeerInfoResponseMessage.ProtoReflect.Descriptor instead.
 func (*GetConnectedPeerInfoResponseMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{34}
+	return file_rpc_proto_rawDescGZIP(), []int{35}
 }
 
 func (x *GetConnectedPeerInfoResponseMessage) GetInfos() []*GetConnectedPeerInfoMessage {
@@ -2227,7 +2293,7 @@ type GetConnectedPeerInfoMessage struct {
 func (x *GetConnectedPeerInfoMessage) Reset() {
 	*x = GetConnectedPeerInfoMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[35]
+		mi := &file_rpc_proto_msgTypes[36]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2240,7 +2306,7 @@ func (x *GetConnectedPeerInfoMessage) String() string {
 func (*GetConnectedPeerInfoMessage) ProtoMessage() {}
 
 func (x *GetConnectedPeerInfoMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[35]
+	mi := &file_rpc_proto_msgTypes[36]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2253,7 +2319,7 @@ func (x *GetConnectedPeerInfoMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetConnectedPeerInfoMessage.ProtoReflect.Descriptor instead.
 func (*GetConnectedPeerInfoMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{35}
+	return file_rpc_proto_rawDescGZIP(), []int{36}
 }
 
 func (x *GetConnectedPeerInfoMessage) GetId() string {
@@ -2334,7 +2400,7 @@ type AddPeerRequestMessage struct {
 func (x *AddPeerRequestMessage) Reset() {
 	*x = AddPeerRequestMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[36]
+		mi := &file_rpc_proto_msgTypes[37]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2347,7 +2413,7 @@ func (x *AddPeerRequestMessage) String() string {
 func (*AddPeerRequestMessage) ProtoMessage() {}
 
 func (x *AddPeerRequestMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[36]
+	mi := &file_rpc_proto_msgTypes[37]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2360,7 +2426,7 @@ func (x *AddPeerRequestMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AddPeerRequestMessage.ProtoReflect.Descriptor instead.
 func (*AddPeerRequestMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{36}
+	return file_rpc_proto_rawDescGZIP(), []int{37}
 }
 
 func (x *AddPeerRequestMessage) GetAddress() string {
@@ -2388,7 +2454,7 @@ type AddPeerResponseMessage struct {
 func (x *AddPeerResponseMessage) Reset() {
 	*x = AddPeerResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[37]
+		mi := &file_rpc_proto_msgTypes[38]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2401,7 +2467,7 @@ func (x *AddPeerResponseMessage) String() string {
 func (*AddPeerResponseMessage) ProtoMessage() {}
 
 func (x *AddPeerResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[37]
+	mi := &file_rpc_proto_msgTypes[38]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2414,7 +2480,7 @@ func (x *AddPeerResponseMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AddPeerResponseMessage.ProtoReflect.Descriptor instead.
 func (*AddPeerResponseMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{37}
+	return file_rpc_proto_rawDescGZIP(), []int{38}
 }
 
 func (x *AddPeerResponseMessage) GetError() *RPCError {
@@ -2437,7 +2503,7 @@ type SubmitTransactionRequestMessage struct {
 func (x *SubmitTransactionRequestMessage) Reset() {
 	*x = SubmitTransactionRequestMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[38]
+		mi := &file_rpc_proto_msgTypes[39]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2450,7 +2516,7 @@ func (x *SubmitTransactionRequestMessage) String() string {
 func (*SubmitTransactionRequestMessage) ProtoMessage() {}
 
 func (x *SubmitTransactionRequestMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[38]
+	mi := &file_rpc_proto_msgTypes[39]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2463,7 +2529,7 @@ func (x *SubmitTransactionRequestMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SubmitTransactionRequestMessage.ProtoReflect.Descriptor instead.
 func (*SubmitTransactionRequestMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{38}
+	return file_rpc_proto_rawDescGZIP(), []int{39}
 }
 
 func (x *SubmitTransactionRequestMessage) GetTransaction() *RpcTransaction {
@@ -2493,7 +2559,7 @@ type SubmitTransactionResponseMessage struct {
 func (x *SubmitTransactionResponseMessage) Reset() {
 	*x = SubmitTransactionResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[39]
+		mi := &file_rpc_proto_msgTypes[40]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2506,7 +2572,7 @@ func (x *SubmitTransactionResponseMessage) String() string {
 func (*SubmitTransactionResponseMessage) ProtoMessage() {}
 
 func (x *SubmitTransactionResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[39]
+	mi := &file_rpc_proto_msgTypes[40]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2519,7 +2585,7 @@ func (x *SubmitTransactionResponseMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SubmitTransactionResponseMessage.ProtoReflect.Descriptor instead.
 func (*SubmitTransactionResponseMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{39}
+	return file_rpc_proto_rawDescGZIP(), []int{40}
 }
 
 func (x *SubmitTransactionResponseMessage) GetTransactionId() string {
@@ -2550,7 +2616,7 @@ type NotifyVirtualSelectedParentChainChangedRequestMessage struct {
 func (x *NotifyVirtualSelectedParentChainChangedRequestMessage) Reset() {
 	*x = NotifyVirtualSelectedParentChainChangedRequestMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[40]
+		mi := &file_rpc_proto_msgTypes[41]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2563,7 +2629,7 @@ func (x *NotifyVirtualSelectedParentChainChangedRequestMessage) String() string
 func (*NotifyVirtualSelectedParentChainChangedRequestMessage) ProtoMessage() {}
 
 func (x *NotifyVirtualSelectedParentChainChangedRequestMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[40]
+	mi := &file_rpc_proto_msgTypes[41]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2576,7 +2642,7 @@ func (x *NotifyVirtualSelectedParentChainChangedRequestMessage) ProtoReflect() p
 
 // Deprecated: Use NotifyVirtualSelectedParentChainChangedRequestMessage.ProtoReflect.Descriptor instead.
 func (*NotifyVirtualSelectedParentChainChangedRequestMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{40}
+	return file_rpc_proto_rawDescGZIP(), []int{41}
 }
 
 func (x *NotifyVirtualSelectedParentChainChangedRequestMessage) GetIncludeAcceptedTransactionIds() bool {
@@ -2597,7 +2663,7 @@ type NotifyVirtualSelectedParentChainChangedResponseMessage struct {
 func (x *NotifyVirtualSelectedParentChainChangedResponseMessage) Reset() {
 	*x = NotifyVirtualSelectedParentChainChangedResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[41]
+		mi := &file_rpc_proto_msgTypes[42]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2610,7 +2676,7 @@ func (x *NotifyVirtualSelectedParentChainChangedResponseMessage) String() string
 func (*NotifyVirtualSelectedParentChainChangedResponseMessage) ProtoMessage() {}
 
 func (x *NotifyVirtualSelectedParentChainChangedResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[41]
+	mi := &file_rpc_proto_msgTypes[42]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2623,7 +2689,7 @@ func (x *NotifyVirtualSelectedParentChainChangedResponseMessage) ProtoReflect()
 
 // Deprecated: Use NotifyVirtualSelectedParentChainChangedResponseMessage.ProtoReflect.Descriptor instead.
 func (*NotifyVirtualSelectedParentChainChangedResponseMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{41}
+	return file_rpc_proto_rawDescGZIP(), []int{42}
 }
 
 func (x *NotifyVirtualSelectedParentChainChangedResponseMessage) GetError() *RPCError {
@@ -2653,7 +2719,7 @@ type VirtualSelectedParentChainChangedNotificationMessage struct {
 func (x *VirtualSelectedParentChainChangedNotificationMessage) Reset() {
 	*x = VirtualSelectedParentChainChangedNotificationMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[42]
+		mi := &file_rpc_proto_msgTypes[43]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2666,7 +2732,7 @@ func (x *VirtualSelectedParentChainChangedNotificationMessage) String() string {
 func (*VirtualSelectedParentChainChangedNotificationMessage) ProtoMessage() {}
 
 func (x *VirtualSelectedParentChainChangedNotificationMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[42]
+	mi := &file_rpc_proto_msgTypes[43]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2679,7 +2745,7 @@ func (x *VirtualSelectedParentChainChangedNotificationMessage) ProtoReflect() pr
 
 // Deprecated: Use VirtualSelectedParentChainChangedNotificationMessage.ProtoReflect.Descriptor instead.
 func (*VirtualSelectedParentChainChangedNotificationMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{42}
+	return file_rpc_proto_rawDescGZIP(), []int{43}
 }
 
 func (x *VirtualSelectedParentChainChangedNotificationMessage) GetRemovedChainBlockHashes() []string {
@@ -2718,7 +2784,7 @@ type GetBlockRequestMessage struct {
 func (x *GetBlockRequestMessage) Reset() {
 	*x = GetBlockRequestMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[43]
+		mi := &file_rpc_proto_msgTypes[44]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2731,7 +2797,7 @@ func (x *GetBlockRequestMessage) String() string {
 func (*GetBlockRequestMessage) ProtoMessage() {}
 
 func (x *GetBlockRequestMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[43]
+	mi := &file_rpc_proto_msgTypes[44]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2744,7 +2810,7 @@ func (x *GetBlockRequestMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetBlockRequestMessage.ProtoReflect.Descriptor instead.
 func (*GetBlockRequestMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{43}
+	return file_rpc_proto_rawDescGZIP(), []int{44}
 }
 
 func (x *GetBlockRequestMessage) GetHash() string {
@@ -2773,7 +2839,7 @@ type GetBlockResponseMessage struct {
 func (x *GetBlockResponseMessage) Reset() {
 	*x = GetBlockResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[44]
+		mi := &file_rpc_proto_msgTypes[45]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2786,7 +2852,7 @@ func (x *GetBlockResponseMessage) String() string {
 func (*GetBlockResponseMessage) ProtoMessage() {}
 
 func (x *GetBlockResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[44]
+	mi := &file_rpc_proto_msgTypes[45]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2799,7 +2865,7 @@ func (x *GetBlockResponseMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetBlockResponseMessage.ProtoReflect.Descriptor instead.
 func (*GetBlockResponseMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{44}
+	return file_rpc_proto_rawDescGZIP(), []int{45}
 }
 
 func (x *GetBlockResponseMessage) GetBlock() *RpcBlock {
@@ -2830,7 +2896,7 @@ type GetSubnetworkRequestMessage struct {
 func (x *GetSubnetworkRequestMessage) Reset() {
 	*x = GetSubnetworkRequestMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[45]
+		mi := &file_rpc_proto_msgTypes[46]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2843,7 +2909,7 @@ func (x *GetSubnetworkRequestMessage) String() string {
 func (*GetSubnetworkRequestMessage) ProtoMessage() {}
 
 func (x *GetSubnetworkRequestMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[45]
+	mi := &file_rpc_proto_msgTypes[46]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2856,7 +2922,7 @@ func (x *GetSubnetworkRequestMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetSubnetworkRequestMessage.ProtoReflect.Descriptor instead.
 func (*GetSubnetworkRequestMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{45}
+	return file_rpc_proto_rawDescGZIP(), []int{46}
 }
 
 func (x *GetSubnetworkRequestMessage) GetSubnetworkId() string {
@@ -2878,7 +2944,7 @@ type GetSubnetworkResponseMessage struct {
 func (x *GetSubnetworkResponseMessage) Reset() {
 	*x = GetSubnetworkResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[46]
+		mi := &file_rpc_proto_msgTypes[47]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2891,7 +2957,7 @@ func (x *GetSubnetworkResponseMessage) String() string {
 func (*GetSubnetworkResponseMessage) ProtoMessage() {}
 
 func (x *GetSubnetworkResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[46]
+	mi := &file_rpc_proto_msgTypes[47]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2904,7 +2970,7 @@ func (x *GetSubnetworkResponseMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetSubnetworkResponseMessage.ProtoReflect.Descriptor instead.
 func (*GetSubnetworkResponseMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{46}
+	return file_rpc_proto_rawDescGZIP(), []int{47}
 }
 
 func (x *GetSubnetworkResponseMessage) GetGasLimit() uint64 {
@@ -2935,7 +3001,7 @@ type GetVirtualSelectedParentChainFromBlockRequestMessage struct {
 func (x *GetVirtualSelectedParentChainFromBlockRequestMessage) Reset() {
 	*x = GetVirtualSelectedParentChainFromBlockRequestMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[47]
+		mi := &file_rpc_proto_msgTypes[48]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2948,7 +3014,7 @@ func (x *GetVirtualSelectedParentChainFromBlockRequestMessage) String() string {
 func (*GetVirtualSelectedParentChainFromBlockRequestMessage) ProtoMessage() {}
 
 func (x *GetVirtualSelectedParentChainFromBlockRequestMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[47]
+	mi := &file_rpc_proto_msgTypes[48]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2961,7 +3027,7 @@ func (x *GetVirtualSelectedParentChainFromBlockRequestMessage) ProtoReflect() pr
 
 // Deprecated: Use GetVirtualSelectedParentChainFromBlockRequestMessage.ProtoReflect.Descriptor instead.
 func (*GetVirtualSelectedParentChainFromBlockRequestMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{47}
+	return file_rpc_proto_rawDescGZIP(), []int{48}
 }
 
 func (x *GetVirtualSelectedParentChainFromBlockRequestMessage) GetStartHash() string {
@@ -2990,7 +3056,7 @@ type AcceptedTransactionIds struct {
 func (x *AcceptedTransactionIds) Reset() {
 	*x = AcceptedTransactionIds{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[48]
+		mi := &file_rpc_proto_msgTypes[49]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3003,7 +3069,7 @@ func (x *AcceptedTransactionIds) String() string {
 func (*AcceptedTransactionIds) ProtoMessage() {}
 
 func (x *AcceptedTransactionIds) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[48]
+	mi := &file_rpc_proto_msgTypes[49]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3016,7 +3082,7 @@ func (x *AcceptedTransactionIds) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AcceptedTransactionIds.ProtoReflect.Descriptor instead.
 func (*AcceptedTransactionIds) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{48}
+	return file_rpc_proto_rawDescGZIP(), []int{49}
 }
 
 func (x *AcceptedTransactionIds) GetAcceptingBlockHash() string {
@@ -3051,7 +3117,7 @@ type GetVirtualSelectedParentChainFromBlockResponseMessage struct {
 func (x *GetVirtualSelectedParentChainFromBlockResponseMessage) Reset() {
 	*x = GetVirtualSelectedParentChainFromBlockResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[49]
+		mi := &file_rpc_proto_msgTypes[50]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3064,7 +3130,7 @@ func (x *GetVirtualSelectedParentChainFromBlockResponseMessage) String() string
 func (*GetVirtualSelectedParentChainFromBlockResponseMessage) ProtoMessage() {}
 
 func (x *GetVirtualSelectedParentChainFromBlockResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[49]
+	mi := &file_rpc_proto_msgTypes[50]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3077,7 +3143,7 @@ func (x *GetVirtualSelectedParentChainFromBlockResponseMessage) ProtoReflect() p
 
 // Deprecated: Use GetVirtualSelectedParentChainFromBlockResponseMessage.ProtoReflect.Descriptor instead.
 func (*GetVirtualSelectedParentChainFromBlockResponseMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{49}
+	return file_rpc_proto_rawDescGZIP(), []int{50}
 }
 
 func (x *GetVirtualSelectedParentChainFromBlockResponseMessage) GetRemovedChainBlockHashes() []string {
@@ -3123,7 +3189,7 @@ type GetBlocksRequestMessage struct {
 func (x *GetBlocksRequestMessage) Reset() {
 	*x = GetBlocksRequestMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[50]
+		mi := &file_rpc_proto_msgTypes[51]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3136,7 +3202,7 @@ func (x *GetBlocksRequestMessage) String() string {
 func (*GetBlocksRequestMessage) ProtoMessage() {}
 
 func (x *GetBlocksRequestMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[50]
+	mi := &file_rpc_proto_msgTypes[51]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3149,7 +3215,7 @@ func (x *GetBlocksRequestMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetBlocksRequestMessage.ProtoReflect.Descriptor instead.
 func (*GetBlocksRequestMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{50}
+	return file_rpc_proto_rawDescGZIP(), []int{51}
 }
 
 func (x *GetBlocksRequestMessage) GetLowHash() string {
@@ -3186,7 +3252,7 @@ type GetBlocksResponseMessage struct {
 func (x *GetBlocksResponseMessage) Reset() {
 	*x = GetBlocksResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[51]
+		mi := &file_rpc_proto_msgTypes[52]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3199,7 +3265,7 @@ func (x *GetBlocksResponseMessage) String() string {
 func (*GetBlocksResponseMessage) ProtoMessage() {}
 
 func (x *GetBlocksResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[51]
+	mi := &file_rpc_proto_msgTypes[52]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3212,7 +3278,7 @@ func (x *GetBlocksResponseMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetBlocksResponseMessage.ProtoReflect.Descriptor instead.
 func (*GetBlocksResponseMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{51}
+	return file_rpc_proto_rawDescGZIP(), []int{52}
 }
 
 func (x *GetBlocksResponseMessage) GetBlockHashes() []string {
@@ -3247,7 +3313,7 @@ type GetBlockCountRequestMessage struct {
 func (x *GetBlockCountRequestMessage) Reset() {
 	*x = GetBlockCountRequestMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[52]
+		mi := &file_rpc_proto_msgTypes[53]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3260,7 +3326,7 @@ func (x *GetBlockCountRequestMessage) String() string {
 func (*GetBlockCountRequestMessage) ProtoMessage() {}
 
 func (x *GetBlockCountRequestMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[52]
+	mi := &file_rpc_proto_msgTypes[53]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3273,7 +3339,7 @@ func (x *GetBlockCountRequestMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetBlockCountRequestMessage.ProtoReflect.Descriptor instead.
 func (*GetBlockCountRequestMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{52}
+	return file_rpc_proto_rawDescGZIP(), []int{53}
 }
 
 type GetBlockCountResponseMessage struct {
@@ -3289,7 +3355,7 @@ type GetBlockCountResponseMessage struct {
 func (x *GetBlockCountResponseMessage) Reset() {
 	*x = GetBlockCountResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[53]
+		mi := &file_rpc_proto_msgTypes[54]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3302,7 +3368,7 @@ func (x *GetBlockCountResponseMessage) String() string {
 func (*GetBlockCountResponseMessage) ProtoMessage() {}
 
 func (x *GetBlockCountResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[53]
+	mi := &file_rpc_proto_msgTypes[54]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3315,7 +3381,7 @@ func (x *GetBlockCountResponseMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetBlockCountResponseMessage.ProtoReflect.Descriptor instead.
 func (*GetBlockCountResponseMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{53}
+	return file_rpc_proto_rawDescGZIP(), []int{54}
 }
 
 func (x *GetBlockCountResponseMessage) GetBlockCount() uint64 {
@@ -3350,7 +3416,7 @@ type GetBlockDagInfoRequestMessage struct {
 func (x *GetBlockDagInfoRequestMessage) Reset() {
 	*x = GetBlockDagInfoRequestMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[54]
+		mi := &file_rpc_proto_msgTypes[55]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3363,7 +3429,7 @@ func (x *GetBlockDagInfoRequestMessage) String() string {
 func (*GetBlockDagInfoRequestMessage) ProtoMessage() {}
 
 func (x *GetBlockDagInfoRequestMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[54]
+	mi := &file_rpc_proto_msgTypes[55]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3376,7 +3442,7 @@ func (x *GetBlockDagInfoRequestMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetBlockDagInfoRequestMessage.ProtoReflect.Descriptor instead.
 func (*GetBlockDagInfoRequestMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{54}
+	return file_rpc_proto_rawDescGZIP(), []int{55}
 }
 
 type GetBlockDagInfoResponseMessage struct {
@@ -3384,22 +3450,23 @@ type GetBlockDagInfoResponseMessage struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	NetworkName         string    `protobuf:"bytes,1,opt,name=networkName,proto3" json:"networkName,omitempty"`
-	BlockCount          uint64    `protobuf:"varint,2,opt,name=blockCount,proto3" json:"blockCount,omitempty"`
-	HeaderCount         uint64    `protobuf:"varint,3,opt,name=headerCount,proto3" json:"headerCount,omitempty"`
-	TipHashes           []string  `protobuf:"bytes,4,rep,name=tipHashes,proto3" json:"tipHashes,omitempty"`
-	Difficulty          float64   `protobuf:"fixed64,5,opt,name=difficulty,proto3" json:"difficulty,omitempty"`
-	PastMedianTime      int64     `protobuf:"varint,6,opt,name=pastMedianTime,proto3" json:"pastMedianTime,omitempty"`
-	VirtualParentHashes []string  `protobuf:"bytes,7,rep,name=virtualParentHashes,proto3" json:"virtualParentHashes,omitempty"`
-	PruningPointHash    string    `protobuf:"bytes,8,opt,name=pruningPointHash,proto3" json:"pruningPointHash,omitempty"`
-	VirtualDaaScore     uint64    `protobuf:"varint,9,opt,name=virtualDaaScore,proto3" json:"virtualDaaScore,omitempty"`
-	Error               *RPCError `protobuf:"bytes,1000,opt,name=error,proto3" json:"error,omitempty"`
+	NetworkName                string    `protobuf:"bytes,1,opt,name=networkName,proto3" json:"networkName,omitempty"`
+	BlockCount                 uint64    `protobuf:"varint,2,opt,name=blockCount,proto3" json:"blockCount,omitempty"`
+	HeaderCount                uint64    `protobuf:"varint,3,opt,name=headerCount,proto3" json:"headerCount,omitempty"`
+	TipHashes                  []string  `protobuf:"bytes,4,rep,name=tipHashes,proto3" json:"tipHashes,omitempty"`
+	Difficulty                 float64   `protobuf:"fixed64,5,opt,name=difficulty,proto3" json:"difficulty,omitempty"`
+	PastMedianTime             int64     `protobuf:"varint,6,opt,name=pastMedianTime,proto3" json:"pastMedianTime,omitempty"`
+	VirtualParentHashes        []string  `protobuf:"bytes,7,rep,name=virtualParentHashes,proto3" json:"virtualParentHashes,omitempty"`
+	PruningPointHash           string    `protobuf:"bytes,8,opt,name=pruningPointHash,proto3" json:"pruningPointHash,omitempty"`
+	VirtualDaaScore            uint64    `protobuf:"varint,9,opt,name=virtualDaaScore,proto3" json:"virtualDaaScore,omitempty"`
+	EarliestAvailableBlockHash string    `protobuf:"bytes,10,opt,name=earliestAvailableBlockHash,proto3" json:"earliestAvailableBlockHash,omitempty"`
+	Error                      *RPCError `protobuf:"bytes,1000,opt,name=error,proto3" json:"error,omitempty"`
 }
 
 func (x *GetBlockDagInfoResponseMessage) Reset() {
 	*x = GetBlockDagInfoResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[55]
+		mi := &file_rpc_proto_msgTypes[56]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3412,7 +3479,7 @@ func (x *GetBlockDagInfoResponseMessage) String() string {
 func (*GetBlockDagInfoResponseMessage) ProtoMessage() {}
 
 func (x *GetBlockDagInfoResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[55]
+	mi := &file_rpc_proto_msgTypes[56]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3425,7 +3492,7 @@ func (x *GetBlockDagInfoResponseMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetBlockDagInfoResponseMessage.ProtoReflect.Descriptor instead.
 func (*GetBlockDagInfoResponseMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{55}
+	return file_rpc_proto_rawDescGZIP(), []int{56}
 }
 
 func (x *GetBlockDagInfoResponseMessage) GetNetworkName() string {
@@ -3491,6 +3558,13 @@ func (x *GetBlockDagInfoResponseMessage) GetVirtualDaaScore() uint64 {
 	return 0
 }
 
+func (x *GetBlockDagInfoResponseMessage) GetEarliestAvailableBlockHash() string {
+	if x != nil {
+		return x.EarliestAvailableBlockHash
+	}
+	return ""
+}
+
 func (x *GetBlockDagInfoResponseMessage) GetError() *RPCError {
 	if x != nil {
 		return x.Error
@@ -3509,7 +3583,7 @@ type ResolveFinalityConflictRequestMessage struct {
 func (x *ResolveFinalityConflictRequestMessage) Reset() {
 	*x = ResolveFinalityConflictRequestMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[56]
+		mi := &file_rpc_proto_msgTypes[57]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3522,7 +3596,7 @@ func (x *ResolveFinalityConflictRequestMessage) String() string {
 func (*ResolveFinalityConflictRequestMessage) ProtoMessage() {}
 
 func (x *ResolveFinalityConflictRequestMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[56]
+	mi := &file_rpc_proto_msgTypes[57]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3535,7 +3609,7 @@ func (x *ResolveFinalityConflictRequestMessage) ProtoReflect() protoreflect.Mess
 
 // Deprecated: Use ResolveFinalityConflictRequestMessage.ProtoReflect.Descriptor instead.
 func (*ResolveFinalityConflictRequestMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{56}
+	return file_rpc_proto_rawDescGZIP(), []int{57}
 }
 
 func (x *ResolveFinalityConflictRequestMessage) GetFinalityBlockHash() string {
@@ -3556,7 +3630,7 @@ type ResolveFinalityConflictResponseMessage struct {
 func (x *ResolveFinalityConflictResponseMessage) Reset() {
 	*x = ResolveFinalityConflictResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[57]
+		mi := &file_rpc_proto_msgTypes[58]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3569,7 +3643,7 @@ func (x *ResolveFinalityConflictResponseMessage) String() string {
 func (*ResolveFinalityConflictResponseMessage) ProtoMessage() {}
 
 func (x *ResolveFinalityConflictResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[57]
+	mi := &file_rpc_proto_msgTypes[58]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3582,7 +3656,7 @@ func (x *ResolveFinalityConflictResponseMessage) ProtoReflect() protoreflect.Mes
 
 // Deprecated: Use ResolveFinalityConflictResponseMessage.ProtoReflect.Descriptor instead.
 func (*ResolveFinalityConflictResponseMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{57}
+	return file_rpc_proto_rawDescGZIP(), []int{58}
 }
 
 func (x *ResolveFinalityConflictResponseMessage) GetError() *RPCError {
@@ -3601,7 +3675,7 @@ type NotifyFinalityConflictsRequestMessage struct {
 func (x *NotifyFinalityConflictsRequestMessage) Reset() {
 	*x = NotifyFinalityConflictsRequestMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[58]
+		mi := &file_rpc_proto_msgTypes[59]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3614,7 +3688,7 @@ func (x *NotifyFinalityConflictsRequestMessage) String() string {
 func (*NotifyFinalityConflictsRequestMessage) ProtoMessage() {}
 
 func (x *NotifyFinalityConflictsRequestMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[58]
+	mi := &file_rpc_proto_msgTypes[59]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3627,7 +3701,7 @@ func (x *NotifyFinalityConflictsRequestMessage) ProtoReflect() protoreflect.Mess
 
 // Deprecated: Use NotifyFinalityConflictsRequestMessage.ProtoReflect.Descriptor instead.
 func (*NotifyFinalityConflictsRequestMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{58}
+	return file_rpc_proto_rawDescGZIP(), []int{59}
 }
 
 type NotifyFinalityConflictsResponseMessage struct {
@@ -3641,7 +3715,7 @@ type NotifyFinalityConflictsResponseMessage struct {
 func (x *NotifyFinalityConflictsResponseMessage) Reset() {
 	*x = NotifyFinalityConflictsResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[59]
+		mi := &file_rpc_proto_msgTypes[60]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3654,7 +3728,7 @@ func (x *NotifyFinalityConflictsResponseMessage) String() string {
 func (*NotifyFinalityConflictsResponseMessage) ProtoMessage() {}
 
 func (x *NotifyFinalityConflictsResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[59]
+	mi := &file_rpc_proto_msgTypes[60]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3667,7 +3741,7 @@ func (x *NotifyFinalityConflictsResponseMessage) ProtoReflect() protoreflect.Mes
 
 // Deprecated: Use NotifyFinalityConflictsResponseMessage.ProtoReflect.Descriptor instead.
 func (*NotifyFinalityConflictsResponseMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{59}
+	return file_rpc_proto_rawDescGZIP(), []int{60}
 }
 
 func (x *NotifyFinalityConflictsResponseMessage) GetError() *RPCError {
@@ -3688,7 +3762,7 @@ type FinalityConflictNotificationMessage struct {
 func (x *FinalityConflictNotificationMessage) Reset() {
 	*x = FinalityConflictNotificationMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[60]
+		mi := &file_rpc_proto_msgTypes[61]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3701,7 +3775,7 @@ func (x *FinalityConflictNotificationMessage) String() string {
 func (*FinalityConflictNotificationMessage) ProtoMessage() {}
 
 func (x *FinalityConflictNotificationMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[60]
+	mi := &file_rpc_proto_msgTypes[61]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3714,7 +3788,7 @@ func (x *FinalityConflictNotificationMessage) ProtoReflect() protoreflect.Messag
 
 // Deprecated: Use FinalityConflictNotificationMessage.ProtoReflect.Descriptor instead.
 func (*FinalityConflictNotificationMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{60}
+	return file_rpc_proto_rawDescGZIP(), []int{61}
 }
 
 func (x *FinalityConflictNotificationMessage) GetViolatingBlockHash() string {
@@ -3735,7 +3809,7 @@ type FinalityConflictResolvedNotificationMessage struct {
 func (x *FinalityConflictResolvedNotificationMessage) Reset() {
 	*x = FinalityConflictResolvedNotificationMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[61]
+		mi := &file_rpc_proto_msgTypes[62]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3748,7 +3822,7 @@ func (x *FinalityConflictResolvedNotificationMessage) String() string {
 func (*FinalityConflictResolvedNotificationMessage) ProtoMessage() {}
 
 func (x *FinalityConflictResolvedNotificationMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[61]
+	mi := &file_rpc_proto_msgTypes[62]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3761,7 +3835,7 @@ func (x *FinalityConflictResolvedNotificationMessage) ProtoReflect() protoreflec
 
 // Deprecated: Use FinalityConflictResolvedNotificationMessage.ProtoReflect.Descriptor instead.
 func (*FinalityConflictResolvedNotificationMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{61}
+	return file_rpc_proto_rawDescGZIP(), []int{62}
 }
 
 func (x *FinalityConflictResolvedNotificationMessage) GetFinalityBlockHash() string {
@@ -3781,7 +3855,7 @@ type ShutDownRequestMessage struct {
 func (x *ShutDownRequestMessage) Reset() {
 	*x = ShutDownRequestMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[62]
+		mi := &file_rpc_proto_msgTypes[63]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3794,7 +3868,7 @@ func (x *ShutDownRequestMessage) String() string {
 func (*ShutDownRequestMessage) ProtoMessage() {}
 
 func (x *ShutDownRequestMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[62]
+	mi := &file_rpc_proto_msgTypes[63]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3807,7 +3881,7 @@ func (x *ShutDownRequestMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ShutDownRequestMessage.ProtoReflect.Descriptor instead.
 func (*ShutDownRequestMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{62}
+	return file_rpc_proto_rawDescGZIP(), []int{63}
 }
 
 type ShutDownResponseMessage struct {
@@ -3821,7 +3895,7 @@ type ShutDownResponseMessage struct {
 func (x *ShutDownResponseMessage) Reset() {
 	*x = ShutDownResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[63]
+		mi := &file_rpc_proto_msgTypes[64]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3834,7 +3908,7 @@ func (x *ShutDownResponseMessage) String() string {
 func (*ShutDownResponseMessage) ProtoMessage() {}
 
 func (x *ShutDownResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[63]
+	mi := &file_rpc_proto_msgTypes[64]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3847,7 +3921,7 @@ func (x *ShutDownResponseMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ShutDownResponseMessage.ProtoReflect.Descriptor instead.
 func (*ShutDownResponseMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{63}
+	return file_rpc_proto_rawDescGZIP(), []int{64}
 }
 
 func (x *ShutDownResponseMessage) GetError() *RPCError {
@@ -3872,7 +3946,7 @@ type GetHeadersRequestMessage struct {
 func (x *GetHeadersRequestMessage) Reset() {
 	*x = GetHeadersRequestMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[64]
+		mi := &file_rpc_proto_msgTypes[65]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3885,7 +3959,7 @@ func (x *GetHeadersRequestMessage) String() string {
 func (*GetHeadersRequestMessage) ProtoMessage() {}
 
 func (x *GetHeadersRequestMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[64]
+	mi := &file_rpc_proto_msgTypes[65]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3898,7 +3972,7 @@ func (x *GetHeadersRequestMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetHeadersRequestMessage.ProtoReflect.Descriptor instead.
 func (*GetHeadersRequestMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{64}
+	return file_rpc_proto_rawDescGZIP(), []int{65}
 }
 
 func (x *GetHeadersRequestMessage) GetStartHash() string {
@@ -3934,7 +4008,7 @@ type GetHeadersResponseMessage struct {
 func (x *GetHeadersResponseMessage) Reset() {
 	*x = GetHeadersResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[65]
+		mi := &file_rpc_proto_msgTypes[66]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3947,7 +4021,7 @@ func (x *GetHeadersResponseMessage) String() string {
 func (*GetHeadersResponseMessage) ProtoMessage() {}
 
 func (x *GetHeadersResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[65]
+	mi := &file_rpc_proto_msgTypes[66]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3960,7 +4034,7 @@ func (x *GetHeadersResponseMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetHeadersResponseMessage.ProtoReflect.Descriptor instead.
 func (*GetHeadersResponseMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{65}
+	return file_rpc_proto_rawDescGZIP(), []int{66}
 }
 
 func (x *GetHeadersResponseMessage) GetHeaders() []string {
@@ -3994,7 +4068,7 @@ type NotifyUtxosChangedRequestMessage struct {
 func (x *NotifyUtxosChangedRequestMessage) Reset() {
 	*x = NotifyUtxosChangedRequestMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[66]
+		mi := &file_rpc_proto_msgTypes[67]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -4007,7 +4081,7 @@ func (x *NotifyUtxosChangedRequestMessage) String() string {
 func (*NotifyUtxosChangedRequestMessage) ProtoMessage() {}
 
 func (x *NotifyUtxosChangedRequestMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[66]
+	mi := &file_rpc_proto_msgTypes[67]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4020,7 +4094,7 @@ func (x *NotifyUtxosChangedRequestMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use NotifyUtxosChangedRequestMessage.ProtoReflect.Descriptor instead.
 func (*NotifyUtxosChangedRequestMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{66}
+	return file_rpc_proto_rawDescGZIP(), []int{67}
 }
 
 func (x *NotifyUtxosChangedRequestMessage) GetAddresses() []string {
@@ -4041,7 +4115,7 @@ type NotifyUtxosChangedResponseMessage struct {
 func (x *NotifyUtxosChangedResponseMessage) Reset() {
 	*x = NotifyUtxosChangedResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[67]
+		mi := &file_rpc_proto_msgTypes[68]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -4054,7 +4128,7 @@ func (x *NotifyUtxosChangedResponseMessage) String() string {
 func (*NotifyUtxosChangedResponseMessage) ProtoMessage() {}
 
 func (x *NotifyUtxosChangedResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[67]
+	mi := &file_rpc_proto_msgTypes[68]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4067,7 +4141,7 @@ func (x *NotifyUtxosChangedResponseMessage) ProtoReflect() protoreflect.Message
 
 // Deprecated: Use NotifyUtxosChangedResponseMessage.ProtoReflect.Descriptor instead.
 func (*NotifyUtxosChangedResponseMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{67}
+	return file_rpc_proto_rawDescGZIP(), []int{68}
 }
 
 func (x *NotifyUtxosChangedResponseMessage) GetError() *RPCError {
@@ -4092,7 +4166,7 @@ type UtxosChangedNotificationMessage struct {
 func (x *UtxosChangedNotificationMessage) Reset() {
 	*x = UtxosChangedNotificationMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[68]
+		mi := &file_rpc_proto_msgTypes[69]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -4105,7 +4179,7 @@ func (x *UtxosChangedNotificationMessage) String() string {
 func (*UtxosChangedNotificationMessage) ProtoMessage() {}
 
 func (x *UtxosChangedNotificationMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[68]
+	mi := &file_rpc_proto_msgTypes[69]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4118,7 +4192,7 @@ func (x *UtxosChangedNotificationMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UtxosChangedNotificationMessage.ProtoReflect.Descriptor instead.
 func (*UtxosChangedNotificationMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{68}
+	return file_rpc_proto_rawDescGZIP(), []int{69}
 }
 
 func (x *UtxosChangedNotificationMessage) GetAdded() []*UtxosByAddressesEntry {
@@ -4148,7 +4222,7 @@ type UtxosByAddressesEntry struct {
 func (x *UtxosByAddressesEntry) Reset() {
 	*x = UtxosByAddressesEntry{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[69]
+		mi := &file_rpc_proto_msgTypes[70]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -4161,7 +4235,7 @@ func (x *UtxosByAddressesEntry) String() string {
 func (*UtxosByAddressesEntry) ProtoMessage() {}
 
 func (x *UtxosByAddressesEntry) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[69]
+	mi := &file_rpc_proto_msgTypes[70]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4174,7 +4248,7 @@ func (x *UtxosByAddressesEntry) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UtxosByAddressesEntry.ProtoReflect.Descriptor instead.
 func (*UtxosByAddressesEntry) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{69}
+	return file_rpc_proto_rawDescGZIP(), []int{70}
 }
 
 func (x *UtxosByAddressesEntry) GetAddress() string {
@@ -4215,7 +4289,7 @@ type StopNotifyingUtxosChangedRequestMessage struct {
 func (x *StopNotifyingUtxosChangedRequestMessage) Reset() {
 	*x = StopNotifyingUtxosChangedRequestMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[70]
+		mi := &file_rpc_proto_msgTypes[71]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -4228,7 +4302,7 @@ func (x *StopNotifyingUtxosChangedRequestMessage) String() string {
 func (*StopNotifyingUtxosChangedRequestMessage) ProtoMessage() {}
 
 func (x *StopNotifyingUtxosChangedRequestMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[70]
+	mi := &file_rpc_proto_msgTypes[71]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4241,7 +4315,7 @@ func (x *StopNotifyingUtxosChangedRequestMessage) ProtoReflect() protoreflect.Me
 
 // Deprecated: Use StopNotifyingUtxosChangedRequestMessage.ProtoReflect.Descriptor instead.
 func (*StopNotifyingUtxosChangedRequestMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{70}
+	return file_rpc_proto_rawDescGZIP(), []int{71}
 }
 
 func (x *StopNotifyingUtxosChangedRequestMessage) GetAddresses() []string {
@@ -4262,7 +4336,7 @@ type StopNotifyingUtxosChangedResponseMessage struct {
 func (x *StopNotifyingUtxosChangedResponseMessage) Reset() {
 	*x = StopNotifyingUtxosChangedResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[71]
+		mi := &file_rpc_proto_msgTypes[72]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -4275,7 +4349,7 @@ func (x *StopNotifyingUtxosChangedResponseMessage) String() string {
 func (*StopNotifyingUtxosChangedResponseMessage) ProtoMessage() {}
 
 func (x *StopNotifyingUtxosChangedResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[71]
+	mi := &file_rpc_proto_msgTypes[72]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4288,7 +4362,7 @@ func (x *StopNotifyingUtxosChangedResponseMessage) ProtoReflect() protoreflect.M
 
 // Deprecated: Use StopNotifyingUtxosChangedResponseMessage.ProtoReflect.Descriptor instead.
 func (*StopNotifyingUtxosChangedResponseMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{71}
+	return file_rpc_proto_rawDescGZIP(), []int{72}
 }
 
 func (x *StopNotifyingUtxosChangedResponseMessage) GetError() *RPCError {
@@ -4312,7 +4386,7 @@ type GetUtxosByAddressesRequestMessage struct {
 func (x *GetUtxosByAddressesRequestMessage) Reset() {
 	*x = GetUtxosByAddressesRequestMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[72]
+		mi := &file_rpc_proto_msgTypes[73]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -4325,7 +4399,7 @@ func (x *GetUtxosByAddressesRequestMessage) String() string {
 func (*GetUtxosByAddressesRequestMessage) ProtoMessage() {}
 
 func (x *GetUtxosByAddressesRequestMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[72]
+	mi := &file_rpc_proto_msgTypes[73]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4338,7 +4412,7 @@ func (x *GetUtxosByAddressesRequestMessage) ProtoReflect() protoreflect.Message
 
 // Deprecated: Use GetUtxosByAddressesRequestMessage.ProtoReflect.Descriptor instead.
 func (*GetUtxosByAddressesRequestMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{72}
+	return file_rpc_proto_rawDescGZIP(), []int{73}
 }
 
 func (x *GetUtxosByAddressesRequestMessage) GetAddresses() []string {
@@ -4360,7 +4434,7 @@ type GetUtxosByAddressesResponseMessage struct {
 func (x *GetUtxosByAddressesResponseMessage) Reset() {
 	*x = GetUtxosByAddressesResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[73]
+		mi := &file_rpc_proto_msgTypes[74]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -4373,7 +4447,7 @@ func (x *GetUtxosByAddressesResponseMessage) String() string {
 func (*GetUtxosByAddressesResponseMessage) ProtoMessage() {}
 
 func (x *GetUtxosByAddressesResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[73]
+	mi := &file_rpc_proto_msgTypes[74]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4386,7 +4460,7 @@ func (x *GetUtxosByAddressesResponseMessage) ProtoReflect() protoreflect.Message
 
 // Deprecated: Use GetUtxosByAddressesResponseMessage.ProtoReflect.Descriptor instead.
 func (*GetUtxosByAddressesResponseMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{73}
+	return file_rpc_proto_rawDescGZIP(), []int{74}
 }
 
 func (x *GetUtxosByAddressesResponseMessage) GetEntries() []*UtxosByAddressesEntry {
@@ -4417,7 +4491,7 @@ type GetBalanceByAddressRequestMessage struct {
 func (x *GetBalanceByAddressRequestMessage) Reset() {
 	*x = GetBalanceByAddressRequestMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[74]
+		mi := &file_rpc_proto_msgTypes[75]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -4430,7 +4504,7 @@ func (x *GetBalanceByAddressRequestMessage) String() string {
 func (*GetBalanceByAddressRequestMessage) ProtoMessage() {}
 
 func (x *GetBalanceByAddressRequestMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[74]
+	mi := &file_rpc_proto_msgTypes[75]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4443,7 +4517,7 @@ func (x *GetBalanceByAddressRequestMessage) ProtoReflect() protoreflect.Message
 
 // Deprecated: Use GetBalanceByAddressRequestMessage.ProtoReflect.Descriptor instead.
 func (*GetBalanceByAddressRequestMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{74}
+	return file_rpc_proto_rawDescGZIP(), []int{75}
 }
 
 func (x *GetBalanceByAddressRequestMessage) GetAddress() string {
@@ -4465,7 +4539,7 @@ type GetBalanceByAddressResponseMessage struct {
 func (x *GetBalanceByAddressResponseMessage) Reset() {
 	*x = GetBalanceByAddressResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[75]
+		mi := &file_rpc_proto_msgTypes[76]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -4478,7 +4552,7 @@ func (x *GetBalanceByAddressResponseMessage) String() string {
 func (*GetBalanceByAddressResponseMessage) ProtoMessage() {}
 
 func (x *GetBalanceByAddressResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[75]
+	mi := &file_rpc_proto_msgTypes[76]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4491,7 +4565,7 @@ func (x *GetBalanceByAddressResponseMessage) ProtoReflect() protoreflect.Message
 
 // Deprecated: Use GetBalanceByAddressResponseMessage.ProtoReflect.Descriptor instead.
 func (*GetBalanceByAddressResponseMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{75}
+	return file_rpc_proto_rawDescGZIP(), []int{76}
 }
 
 func (x *GetBalanceByAddressResponseMessage) GetBalance() uint64 {
@@ -4519,7 +4593,7 @@ type GetBalancesByAddressesRequestMessage struct {
 func (x *GetBalancesByAddressesRequestMessage) Reset() {
 	*x = GetBalancesByAddressesRequestMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[76]
+		mi := &file_rpc_proto_msgTypes[77]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -4532,7 +4606,7 @@ func (x *GetBalancesByAddressesRequestMessage) String() string {
 func (*GetBalancesByAddressesRequestMessage) ProtoMessage() {}
 
 func (x *GetBalancesByAddressesRequestMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[76]
+	mi := &file_rpc_proto_msgTypes[77]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4545,7 +4619,7 @@ func (x *GetBalancesByAddressesRequestMessage) ProtoReflect() protoreflect.Messa
 
 // Deprecated: Use GetBalancesByAddressesRequestMessage.ProtoReflect.Descriptor instead.
 func (*GetBalancesByAddressesRequestMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{76}
+	return file_rpc_proto_rawDescGZIP(), []int{77}
 }
 
 func (x *GetBalancesByAddressesRequestMessage) GetAddresses() []string {
@@ -4568,7 +4642,7 @@ type BalancesByAddressEntry struct {
 func (x *BalancesByAddressEntry) Reset() {
 	*x = BalancesByAddressEntry{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[77]
+		mi := &file_rpc_proto_msgTypes[78]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -4581,7 +4655,7 @@ func (x *BalancesByAddressEntry) String() string {
 func (*BalancesByAddressEntry) ProtoMessage() {}
 
 func (x *BalancesByAddressEntry) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[77]
+	mi := &file_rpc_proto_msgTypes[78]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4594,7 +4668,7 @@ func (x *BalancesByAddressEntry) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BalancesByAddressEntry.ProtoReflect.Descriptor instead.
 func (*BalancesByAddressEntry) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{77}
+	return file_rpc_proto_rawDescGZIP(), []int{78}
 }
 
 func (x *BalancesByAddressEntry) GetAddress() string {
@@ -4630,7 +4704,7 @@ type GetBalancesByAddressesResponseMessage struct {
 func (x *GetBalancesByAddressesResponseMessage) Reset() {
 	*x = GetBalancesByAddressesResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[78]
+		mi := &file_rpc_proto_msgTypes[79]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -4643,7 +4717,7 @@ func (x *GetBalancesByAddressesResponseMessage) String() string {
 func (*GetBalancesByAddressesResponseMessage) ProtoMessage() {}
 
 func (x *GetBalancesByAddressesResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[78]
+	mi := &file_rpc_proto_msgTypes[79]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4656,7 +4730,7 @@ func (x *GetBalancesByAddressesResponseMessage) ProtoReflect() protoreflect.Mess
 
 // Deprecated: Use GetBalancesByAddressesResponseMessage.ProtoReflect.Descriptor instead.
 func (*GetBalancesByAddressesResponseMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{78}
+	return file_rpc_proto_rawDescGZIP(), []int{79}
 }
 
 func (x *GetBalancesByAddressesResponseMessage) GetEntries() []*BalancesByAddressEntry {
@@ -4684,7 +4758,7 @@ type GetVirtualSelectedParentBlueScoreRequestMessage struct {
 func (x *GetVirtualSelectedParentBlueScoreRequestMessage) Reset() {
 	*x = GetVirtualSelectedParentBlueScoreRequestMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[79]
+		mi := &file_rpc_proto_msgTypes[80]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -4697,7 +4771,7 @@ func (x *GetVirtualSelectedParentBlueScoreRequestMessage) String() string {
 func (*GetVirtualSelectedParentBlueScoreRequestMessage) ProtoMessage() {}
 
 func (x *GetVirtualSelectedParentBlueScoreRequestMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[79]
+	mi := &file_rpc_proto_msgTypes[80]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4710,7 +4784,7 @@ func (x *GetVirtualSelectedParentBlueScoreRequestMessage) ProtoReflect() protore
 
 // Deprecated: Use GetVirtualSelectedParentBlueScoreRequestMessage.ProtoReflect.Descriptor instead.
 func (*GetVirtualSelectedParentBlueScoreRequestMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{79}
+	return file_rpc_proto_rawDescGZIP(), []int{80}
 }
 
 type GetVirtualSelectedParentBlueScoreResponseMessage struct {
@@ -4725,7 +4799,7 @@ type GetVirtualSelectedParentBlueScoreResponseMessage struct {
 func (x *GetVirtualSelectedParentBlueScoreResponseMessage) Reset() {
 	*x = GetVirtualSelectedParentBlueScoreResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[80]
+		mi := &file_rpc_proto_msgTypes[81]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -4738,7 +4812,7 @@ func (x *GetVirtualSelectedParentBlueScoreResponseMessage) String() string {
 func (*GetVirtualSelectedParentBlueScoreResponseMessage) ProtoMessage() {}
 
 func (x *GetVirtualSelectedParentBlueScoreResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[80]
+	mi := &file_rpc_proto_msgTypes[81]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4751,7 +4825,7 @@ func (x *GetVirtualSelectedParentBlueScoreResponseMessage) ProtoReflect() protor
 
 // Deprecated: Use GetVirtualSelectedParentBlueScoreResponseMessage.ProtoReflect.Descriptor instead.
 func (*GetVirtualSelectedParentBlueScoreResponseMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{80}
+	return file_rpc_proto_rawDescGZIP(), []int{81}
 }
 
 func (x *GetVirtualSelectedParentBlueScoreResponseMessage) GetBlueScore() uint64 {
@@ -4781,7 +4855,7 @@ type NotifyVirtualSelectedParentBlueScoreChangedRequestMessage struct {
 func (x *NotifyVirtualSelectedParentBlueScoreChangedRequestMessage) Reset() {
 	*x = NotifyVirtualSelectedParentBlueScoreChangedRequestMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[81]
+		mi := &file_rpc_proto_msgTypes[82]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -4794,7 +4868,7 @@ func (x *NotifyVirtualSelectedParentBlueScoreChangedRequestMessage) String() str
 func (*NotifyVirtualSelectedParentBlueScoreChangedRequestMessage) ProtoMessage() {}
 
 func (x *NotifyVirtualSelectedParentBlueScoreChangedRequestMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[81]
+	mi := &file_rpc_proto_msgTypes[82]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4807,7 +4881,7 @@ func (x *NotifyVirtualSelectedParentBlueScoreChangedRequestMessage) ProtoReflect
 
 // Deprecated: Use NotifyVirtualSelectedParentBlueScoreChangedRequestMessage.ProtoReflect.Descriptor instead.
 func (*NotifyVirtualSelectedParentBlueScoreChangedRequestMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{81}
+	return file_rpc_proto_rawDescGZIP(), []int{82}
 }
 
 type NotifyVirtualSelectedParentBlueScoreChangedResponseMessage struct {
@@ -4821,7 +4895,7 @@ type NotifyVirtualSelectedParentBlueScoreChangedResponseMessage struct {
 func (x *NotifyVirtualSelectedParentBlueScoreChangedResponseMessage) Reset() {
 	*x = NotifyVirtualSelectedParentBlueScoreChangedResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[82]
+		mi := &file_rpc_proto_msgTypes[83]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -4834,7 +4908,7 @@ func (x *NotifyVirtualSelectedParentBlueScoreChangedResponseMessage) String() st
 func (*NotifyVirtualSelectedParentBlueScoreChangedResponseMessage) ProtoMessage() {}
 
 func (x *NotifyVirtualSelectedParentBlueScoreChangedResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[82]
+	mi := &file_rpc_proto_msgTypes[83]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4847,7 +4921,7 @@ func (x *NotifyVirtualSelectedParentBlueScoreChangedResponseMessage) ProtoReflec
 
 // Deprecated: Use NotifyVirtualSelectedParentBlueScoreChangedResponseMessage.ProtoReflect.Descriptor instead.
 func (*NotifyVirtualSelectedParentBlueScoreChangedResponseMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{82}
+	return file_rpc_proto_rawDescGZIP(), []int{83}
 }
 
 func (x *NotifyVirtualSelectedParentBlueScoreChangedResponseMessage) GetError() *RPCError {
@@ -4872,7 +4946,7 @@ type VirtualSelectedParentBlueScoreChangedNotificationMessage struct {
 func (x *VirtualSelectedParentBlueScoreChangedNotificationMessage) Reset() {
 	*x = VirtualSelectedParentBlueScoreChangedNotificationMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[83]
+		mi := &file_rpc_proto_msgTypes[84]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -4885,7 +4959,7 @@ func (x *VirtualSelectedParentBlueScoreChangedNotificationMessage) String() stri
 func (*VirtualSelectedParentBlueScoreChangedNotificationMessage) ProtoMessage() {}
 
 func (x *VirtualSelectedParentBlueScoreChangedNotificationMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[83]
+	mi := &file_rpc_proto_msgTypes[84]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4898,7 +4972,7 @@ func (x *VirtualSelectedParentBlueScoreChangedNotificationMessage) ProtoReflect(
 
 // Deprecated: Use VirtualSelectedParentBlueScoreChangedNotificationMessage.ProtoReflect.Descriptor instead.
 func (*VirtualSelectedParentBlueScoreChangedNotificationMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{83}
+	return file_rpc_proto_rawDescGZIP(), []int{84}
 }
 
 func (x *VirtualSelectedParentBlueScoreChangedNotificationMessage) GetVirtualSelectedParentBlueScore() uint64 {
@@ -4921,7 +4995,7 @@ type NotifyVirtualDaaScoreChangedRequestMessage struct {
 func (x *NotifyVirtualDaaScoreChangedRequestMessage) Reset() {
 	*x = NotifyVirtualDaaScoreChangedRequestMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[84]
+		mi := &file_rpc_proto_msgTypes[85]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -4934,7 +5008,7 @@ func (x *NotifyVirtualDaaScoreChangedRequestMessage) String() string {
 func (*NotifyVirtualDaaScoreChangedRequestMessage) ProtoMessage() {}
 
 func (x *NotifyVirtualDaaScoreChangedRequestMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[84]
+	mi := &file_rpc_proto_msgTypes[85]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4947,7 +5021,7 @@ func (x *NotifyVirtualDaaScoreChangedRequestMessage) ProtoReflect() protoreflect
 
 // Deprecated: Use NotifyVirtualDaaScoreChangedRequestMessage.ProtoReflect.Descriptor instead.
 func (*NotifyVirtualDaaScoreChangedRequestMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{84}
+	return file_rpc_proto_rawDescGZIP(), []int{85}
 }
 
 type NotifyVirtualDaaScoreChangedResponseMessage struct {
@@ -4961,7 +5035,7 @@ type NotifyVirtualDaaScoreChangedResponseMessage struct {
 func (x *NotifyVirtualDaaScoreChangedResponseMessage) Reset() {
 	*x = NotifyVirtualDaaScoreChangedResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[85]
+		mi := &file_rpc_proto_msgTypes[86]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -4974,7 +5048,7 @@ func (x *NotifyVirtualDaaScoreChangedResponseMessage) String() string {
 func (*NotifyVirtualDaaScoreChangedResponseMessage) ProtoMessage() {}
 
 func (x *NotifyVirtualDaaScoreChangedResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[85]
+	mi := &file_rpc_proto_msgTypes[86]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4987,7 +5061,7 @@ func (x *NotifyVirtualDaaScoreChangedResponseMessage) ProtoReflect() protoreflec
 
 // Deprecated: Use NotifyVirtualDaaScoreChangedResponseMessage.ProtoReflect.Descriptor instead.
 func (*NotifyVirtualDaaScoreChangedResponseMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{85}
+	return file_rpc_proto_rawDescGZIP(), []int{86}
 }
 
 func (x *NotifyVirtualDaaScoreChangedResponseMessage) GetError() *RPCError {
@@ -5012,7 +5086,7 @@ type VirtualDaaScoreChangedNotificationMessage struct {
 func (x *VirtualDaaScoreChangedNotificationMessage) Reset() {
 	*x = VirtualDaaScoreChangedNotificationMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[86]
+		mi := &file_rpc_proto_msgTypes[87]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -5025,7 +5099,7 @@ func (x *VirtualDaaScoreChangedNotificationMessage) String() string {
 func (*VirtualDaaScoreChangedNotificationMessage) ProtoMessage() {}
 
 func (x *VirtualDaaScoreChangedNotificationMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[86]
+	mi := &file_rpc_proto_msgTypes[87]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5038,7 +5112,7 @@ func (x *VirtualDaaScoreChangedNotificationMessage) ProtoReflect() protoreflect.
 
 // Deprecated: Use VirtualDaaScoreChangedNotificationMessage.ProtoReflect.Descriptor instead.
 func (*VirtualDaaScoreChangedNotificationMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{86}
+	return file_rpc_proto_rawDescGZIP(), []int{87}
 }
 
 func (x *VirtualDaaScoreChangedNotificationMessage) GetVirtualDaaScore() uint64 {
@@ -5063,7 +5137,7 @@ type NotifyPruningPointUTXOSetOverrideRequestMessage struct {
 func (x *NotifyPruningPointUTXOSetOverrideRequestMessage) Reset() {
 	*x = NotifyPruningPointUTXOSetOverrideRequestMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[87]
+		mi := &file_rpc_proto_msgTypes[88]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -5076,7 +5150,7 @@ func (x *NotifyPruningPointUTXOSetOverrideRequestMessage) String() string {
 func (*NotifyPruningPointUTXOSetOverrideRequestMessage) ProtoMessage() {}
 
 func (x *NotifyPruningPointUTXOSetOverrideRequestMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[87]
+	mi := &file_rpc_proto_msgTypes[88]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5089,7 +5163,7 @@ func (x *NotifyPruningPointUTXOSetOverrideRequestMessage) ProtoReflect() protore
 
 // Deprecated: Use NotifyPruningPointUTXOSetOverrideRequestMessage.ProtoReflect.Descriptor instead.
 func (*NotifyPruningPointUTXOSetOverrideRequestMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{87}
+	return file_rpc_proto_rawDescGZIP(), []int{88}
 }
 
 type NotifyPruningPointUTXOSetOverrideResponseMessage struct {
@@ -5103,7 +5177,7 @@ type NotifyPruningPointUTXOSetOverrideResponseMessage struct {
 func (x *NotifyPruningPointUTXOSetOverrideResponseMessage) Reset() {
 	*x = NotifyPruningPointUTXOSetOverrideResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[88]
+		mi := &file_rpc_proto_msgTypes[89]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -5116,7 +5190,7 @@ func (x *NotifyPruningPointUTXOSetOverrideResponseMessage) String() string {
 func (*NotifyPruningPointUTXOSetOverrideResponseMessage) ProtoMessage() {}
 
 func (x *NotifyPruningPointUTXOSetOverrideResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[88]
+	mi := &file_rpc_proto_msgTypes[89]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5129,7 +5203,7 @@ func (x *NotifyPruningPointUTXOSetOverrideResponseMessage) ProtoReflect() protor
 
 // Deprecated: Use NotifyPruningPointUTXOSetOverrideResponseMessage.ProtoReflect.Descriptor instead.
 func (*NotifyPruningPointUTXOSetOverrideResponseMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{88}
+	return file_rpc_proto_rawDescGZIP(), []int{89}
 }
 
 func (x *NotifyPruningPointUTXOSetOverrideResponseMessage) GetError() *RPCError {
@@ -5152,7 +5226,7 @@ type PruningPointUTXOSetOverrideNotificationMessage struct {
 func (x *PruningPointUTXOSetOverrideNotificationMessage) Reset() {
 	*x = PruningPointUTXOSetOverrideNotificationMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[89]
+		mi := &file_rpc_proto_msgTypes[90]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -5165,7 +5239,7 @@ func (x *PruningPointUTXOSetOverrideNotificationMessage) String() string {
 func (*PruningPointUTXOSetOverrideNotificationMessage) ProtoMessage() {}
 
 func (x *PruningPointUTXOSetOverrideNotificationMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[89]
+	mi := &file_rpc_proto_msgTypes[90]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5178,7 +5252,7 @@ func (x *PruningPointUTXOSetOverrideNotificationMessage) ProtoReflect() protoref
 
 // Deprecated: Use PruningPointUTXOSetOverrideNotificationMessage.ProtoReflect.Descriptor instead.
 func (*PruningPointUTXOSetOverrideNotificationMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{89}
+	return file_rpc_proto_rawDescGZIP(), []int{90}
 }
 
 // StopNotifyingPruningPointUTXOSetOverrideRequestMessage unregisters this connection for
@@ -5196,7 +5270,7 @@ type StopNotifyingPruningPointUTXOSetOverrideRequestMessage struct {
 func (x *StopNotifyingPruningPointUTXOSetOverrideRequestMessage) Reset() {
 	*x = StopNotifyingPruningPointUTXOSetOverrideRequestMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[90]
+		mi := &file_rpc_proto_msgTypes[91]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -5209,7 +5283,7 @@ func (x *StopNotifyingPruningPointUTXOSetOverrideRequestMessage) String() string
 func (*StopNotifyingPruningPointUTXOSetOverrideRequestMessage) ProtoMessage() {}
 
 func (x *StopNotifyingPruningPointUTXOSetOverrideRequestMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[90]
+	mi := &file_rpc_proto_msgTypes[91]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5222,7 +5296,7 @@ func (x *StopNotifyingPruningPointUTXOSetOverrideRequestMessage) ProtoReflect()
 
 // Deprecated: Use StopNotifyingPruningPointUTXOSetOverrideRequestMessage.ProtoReflect.Descriptor instead.
 func (*StopNotifyingPruningPointUTXOSetOverrideRequestMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{90}
+	return file_rpc_proto_rawDescGZIP(), []int{91}
 }
 
 type StopNotifyingPruningPointUTXOSetOverrideResponseMessage struct {
@@ -5236,7 +5310,7 @@ type StopNotifyingPruningPointUTXOSetOverrideResponseMessage struct {
 func (x *StopNotifyingPruningPointUTXOSetOverrideResponseMessage) Reset() {
 	*x = StopNotifyingPruningPointUTXOSetOverrideResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[91]
+		mi := &file_rpc_proto_msgTypes[92]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -5249,7 +5323,7 @@ func (x *StopNotifyingPruningPointUTXOSetOverrideResponseMessage) String() strin
 func (*StopNotifyingPruningPointUTXOSetOverrideResponseMessage) ProtoMessage() {}
 
 func (x *StopNotifyingPruningPointUTXOSetOverrideResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[91]
+	mi := &file_rpc_proto_msgTypes[92]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5262,7 +5336,7 @@ func (x *StopNotifyingPruningPointUTXOSetOverrideResponseMessage) ProtoReflect()
 
 // Deprecated: Use StopNotifyingPruningPointUTXOSetOverrideResponseMessage.ProtoReflect.Descriptor instead.
 func (*StopNotifyingPruningPointUTXOSetOverrideResponseMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{91}
+	return file_rpc_proto_rawDescGZIP(), []int{92}
 }
 
 func (x *StopNotifyingPruningPointUTXOSetOverrideResponseMessage) GetError() *RPCError {
@@ -5284,7 +5358,7 @@ type BanRequestMessage struct {
 func (x *BanRequestMessage) Reset() {
 	*x = BanRequestMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[92]
+		mi := &file_rpc_proto_msgTypes[93]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -5297,7 +5371,7 @@ func (x *BanRequestMessage) String() string {
 func (*BanRequestMessage) ProtoMessage() {}
 
 func (x *BanRequestMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[92]
+	mi := &file_rpc_proto_msgTypes[93]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5310,7 +5384,7 @@ func (x *BanRequestMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BanRequestMessage.ProtoReflect.Descriptor instead.
 func (*BanRequestMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{92}
+	return file_rpc_proto_rawDescGZIP(), []int{93}
 }
 
 func (x *BanRequestMessage) GetIp() string {
@@ -5331,7 +5405,7 @@ type BanResponseMessage struct {
 func (x *BanResponseMessage) Reset() {
 	*x = BanResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[93]
+		mi := &file_rpc_proto_msgTypes[94]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -5344,7 +5418,7 @@ func (x *BanResponseMessage) String() string {
 func (*BanResponseMessage) ProtoMessage() {}
 
 func (x *BanResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[93]
+	mi := &file_rpc_proto_msgTypes[94]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5357,7 +5431,7 @@ func (x *BanResponseMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BanResponseMessage.ProtoReflect.Descriptor instead.
 func (*BanResponseMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{93}
+	return file_rpc_proto_rawDescGZIP(), []int{94}
 }
 
 func (x *BanResponseMessage) GetError() *RPCError {
@@ -5379,7 +5453,7 @@ type UnbanRequestMessage struct {
 func (x *UnbanRequestMessage) Reset() {
 	*x = UnbanRequestMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[94]
+		mi := &file_rpc_proto_msgTypes[95]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -5392,7 +5466,7 @@ func (x *UnbanRequestMessage) String() string {
 func (*UnbanRequestMessage) ProtoMessage() {}
 
 func (x *UnbanRequestMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[94]
+	mi := &file_rpc_proto_msgTypes[95]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5405,7 +5479,7 @@ func (x *UnbanRequestMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UnbanRequestMessage.ProtoReflect.Descriptor instead.
 func (*UnbanRequestMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{94}
+	return file_rpc_proto_rawDescGZIP(), []int{95}
 }
 
 func (x *UnbanRequestMessage) GetIp() string {
@@ -5426,7 +5500,7 @@ type UnbanResponseMessage struct {
 func (x *UnbanResponseMessage) Reset() {
 	*x = UnbanResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[95]
+		mi := &file_rpc_proto_msgTypes[96]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -5439,7 +5513,7 @@ func (x *UnbanResponseMessage) String() string {
 func (*UnbanResponseMessage) ProtoMessage() {}
 
 func (x *UnbanResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[95]
+	mi := &file_rpc_proto_msgTypes[96]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5452,7 +5526,7 @@ func (x *UnbanResponseMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UnbanResponseMessage.ProtoReflect.Descriptor instead.
 func (*UnbanResponseMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{95}
+	return file_rpc_proto_rawDescGZIP(), []int{96}
 }
 
 func (x *UnbanResponseMessage) GetError() *RPCError {
@@ -5472,7 +5546,7 @@ type GetInfoRequestMessage struct {
 func (x *GetInfoRequestMessage) Reset() {
 	*x = GetInfoRequestMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[96]
+		mi := &file_rpc_proto_msgTypes[97]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -5485,7 +5559,7 @@ func (x *GetInfoRequestMessage) String() string {
 func (*GetInfoRequestMessage) ProtoMessage() {}
 
 func (x *GetInfoRequestMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[96]
+	mi := &file_rpc_proto_msgTypes[97]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5498,7 +5572,7 @@ func (x *GetInfoRequestMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetInfoRequestMessage.ProtoReflect.Descriptor instead.
 func (*GetInfoRequestMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{96}
+	return file_rpc_proto_rawDescGZIP(), []int{97}
 }
 
 type GetInfoResponseMessage struct {
@@ -5517,7 +5591,7 @@ type GetInfoResponseMessage struct {
 func (x *GetInfoResponseMessage) Reset() {
 	*x = GetInfoResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[97]
+		mi := &file_rpc_proto_msgTypes[98]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -5530,7 +5604,7 @@ func (x *GetInfoResponseMessage) String() string {
 func (*GetInfoResponseMessage) ProtoMessage() {}
 
 func (x *GetInfoResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[97]
+	mi := &file_rpc_proto_msgTypes[98]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5543,7 +5617,7 @@ func (x *GetInfoResponseMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetInfoResponseMessage.ProtoReflect.Descriptor instead.
 func (*GetInfoResponseMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{97}
+	return file_rpc_proto_rawDescGZIP(), []int{98}
 }
 
 func (x *GetInfoResponseMessage) GetP2PId() string {
@@ -5600,7 +5674,7 @@ type EstimateNetworkHashesPerSecondRequestMessage struct {
 func (x *EstimateNetworkHashesPerSecondRequestMessage) Reset() {
 	*x = EstimateNetworkHashesPerSecondRequestMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[98]
+		mi := &file_rpc_proto_msgTypes[99]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -5613,7 +5687,7 @@ func (x *EstimateNetworkHashesPerSecondRequestMessage) String() string {
 func (*EstimateNetworkHashesPerSecondRequestMessage) ProtoMessage() {}
 
 func (x *EstimateNetworkHashesPerSecondRequestMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[98]
+	mi := &file_rpc_proto_msgTypes[99]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5626,7 +5700,7 @@ func (x *EstimateNetworkHashesPerSecondRequestMessage) ProtoReflect() protorefle
 
 // Deprecated: Use EstimateNetworkHashesPerSecondRequestMessage.ProtoReflect.Descriptor instead.
 func (*EstimateNetworkHashesPerSecondRequestMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{98}
+	return file_rpc_proto_rawDescGZIP(), []int{99}
 }
 
 func (x *EstimateNetworkHashesPerSecondRequestMessage) GetWindowSize() uint32 {
@@ -5655,7 +5729,7 @@ type EstimateNetworkHashesPerSecondResponseMessage struct {
 func (x *EstimateNetworkHashesPerSecondResponseMessage) Reset() {
 	*x = EstimateNetworkHashesPerSecondResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[99]
+		mi := &file_rpc_proto_msgTypes[100]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -5668,7 +5742,7 @@ func (x *EstimateNetworkHashesPerSecondResponseMessage) String() string {
 func (*EstimateNetworkHashesPerSecondResponseMessage) ProtoMessage() {}
 
 func (x *EstimateNetworkHashesPerSecondResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[99]
+	mi := &file_rpc_proto_msgTypes[100]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5681,7 +5755,7 @@ func (x *EstimateNetworkHashesPerSecondResponseMessage) ProtoReflect() protorefl
 
 // Deprecated: Use EstimateNetworkHashesPerSecondResponseMessage.ProtoReflect.Descriptor instead.
 func (*EstimateNetworkHashesPerSecondResponseMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{99}
+	return file_rpc_proto_rawDescGZIP(), []int{100}
 }
 
 func (x *EstimateNetworkHashesPerSecondResponseMessage) GetNetworkHashesPerSecond() uint64 {
@@ -5711,7 +5785,7 @@ type NotifyNewBlockTemplateRequestMessage struct {
 func (x *NotifyNewBlockTemplateRequestMessage) Reset() {
 	*x = NotifyNewBlockTemplateRequestMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[100]
+		mi := &file_rpc_proto_msgTypes[101]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -5724,7 +5798,7 @@ func (x *NotifyNewBlockTemplateRequestMessage) String() string {
 func (*NotifyNewBlockTemplateRequestMessage) ProtoMessage() {}
 
 func (x *NotifyNewBlockTemplateRequestMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[100]
+	mi := &file_rpc_proto_msgTypes[101]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5737,7 +5811,7 @@ func (x *NotifyNewBlockTemplateRequestMessage) ProtoReflect() protoreflect.Messa
 
 // Deprecated: Use NotifyNewBlockTemplateRequestMessage.ProtoReflect.Descriptor instead.
 func (*NotifyNewBlockTemplateRequestMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{100}
+	return file_rpc_proto_rawDescGZIP(), []int{101}
 }
 
 type NotifyNewBlockTemplateResponseMessage struct {
@@ -5751,7 +5825,7 @@ type NotifyNewBlockTemplateResponseMessage struct {
 func (x *NotifyNewBlockTemplateResponseMessage) Reset() {
 	*x = NotifyNewBlockTemplateResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[101]
+		mi := &file_rpc_proto_msgTypes[102]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -5764,7 +5838,7 @@ func (x *NotifyNewBlockTemplateResponseMessage) String() string {
 func (*NotifyNewBlockTemplateResponseMessage) ProtoMessage() {}
 
 func (x *NotifyNewBlockTemplateResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[101]
+	mi := &file_rpc_proto_msgTypes[102]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5777,7 +5851,7 @@ func (x *NotifyNewBlockTemplateResponseMessage) ProtoReflect() protoreflect.Mess
 
 // Deprecated: Use NotifyNewBlockTemplateResponseMessage.ProtoReflect.Descriptor instead.
 func (*NotifyNewBlockTemplateResponseMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{101}
+	return file_rpc_proto_rawDescGZIP(), []int{102}
 }
 
 func (x *NotifyNewBlockTemplateResponseMessage) GetError() *RPCError {
@@ -5800,7 +5874,7 @@ type NewBlockTemplateNotificationMessage struct {
 func (x *NewBlockTemplateNotificationMessage) Reset() {
 	*x = NewBlockTemplateNotificationMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[102]
+		mi := &file_rpc_proto_msgTypes[103]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -5813,7 +5887,7 @@ func (x *NewBlockTemplateNotificationMessage) String() string {
 func (*NewBlockTemplateNotificationMessage) ProtoMessage() {}
 
 func (x *NewBlockTemplateNotificationMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[102]
+	mi := &file_rpc_proto_msgTypes[103]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5826,7 +5900,7 @@ func (x *NewBlockTemplateNotificationMessage) ProtoReflect() protoreflect.Messag
 
 // Deprecated: Use NewBlockTemplateNotificationMessage.ProtoReflect.Descriptor instead.
 func (*NewBlockTemplateNotificationMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{102}
+	return file_rpc_proto_rawDescGZIP(), []int{103}
 }
 
 type MempoolEntryByAddress struct {
@@ -5842,7 +5916,7 @@ type MempoolEntryByAddress struct {
 func (x *MempoolEntryByAddress) Reset() {
 	*x = MempoolEntryByAddress{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[103]
+		mi := &file_rpc_proto_msgTypes[104]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -5855,7 +5929,7 @@ func (x *MempoolEntryByAddress) String() string {
 func (*MempoolEntryByAddress) ProtoMessage() {}
 
 func (x *MempoolEntryByAddress) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[103]
+	mi := &file_rpc_proto_msgTypes[104]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5868,7 +5942,7 @@ func (x *MempoolEntryByAddress) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MempoolEntryByAddress.ProtoReflect.Descriptor instead.
 func (*MempoolEntryByAddress) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{103}
+	return file_rpc_proto_rawDescGZIP(), []int{104}
 }
 
 func (x *MempoolEntryByAddress) GetAddress() string {
@@ -5905,7 +5979,7 @@ type GetMempoolEntriesByAddressesRequestMessage struct {
 func (x *GetMempoolEntriesByAddressesRequestMessage) Reset() {
 	*x = GetMempoolEntriesByAddressesRequestMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[104]
+		mi := &file_rpc_proto_msgTypes[105]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -5918,7 +5992,7 @@ func (x *GetMempoolEntriesByAddressesRequestMessage) String() string {
 func (*GetMempoolEntriesByAddressesRequestMessage) ProtoMessage() {}
 
 func (x *GetMempoolEntriesByAddressesRequestMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[104]
+	mi := &file_rpc_proto_msgTypes[105]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5931,7 +6005,7 @@ func (x *GetMempoolEntriesByAddressesRequestMessage) ProtoReflect() protoreflect
 
 // Deprecated: Use GetMempoolEntriesByAddressesRequestMessage.ProtoReflect.Descriptor instead.
 func (*GetMempoolEntriesByAddressesRequestMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{104}
+	return file_rpc_proto_rawDescGZIP(), []int{105}
 }
 
 func (x *GetMempoolEntriesByAddressesRequestMessage) GetAddresses() []string {
@@ -5967,7 +6041,7 @@ type GetMempoolEntriesByAddressesResponseMessage struct {
 func (x *GetMempoolEntriesByAddressesResponseMessage) Reset() {
 	*x = GetMempoolEntriesByAddressesResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[105]
+		mi := &file_rpc_proto_msgTypes[106]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -5980,7 +6054,7 @@ func (x *GetMempoolEntriesByAddressesResponseMessage) String() string {
 func (*GetMempoolEntriesByAddressesResponseMessage) ProtoMessage() {}
 
 func (x *GetMempoolEntriesByAddressesResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[105]
+	mi := &file_rpc_proto_msgTypes[106]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5993,7 +6067,7 @@ func (x *GetMempoolEntriesByAddressesResponseMessage) ProtoReflect() protoreflec
 
 // Deprecated: Use GetMempoolEntriesByAddressesResponseMessage.ProtoReflect.Descriptor instead.
 func (*GetMempoolEntriesByAddressesResponseMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{105}
+	return file_rpc_proto_rawDescGZIP(), []int{106}
 }
 
 func (x *GetMempoolEntriesByAddressesResponseMessage) GetEntries() []*MempoolEntryByAddress {
@@ -6019,7 +6093,7 @@ type GetCoinSupplyRequestMessage struct {
 func (x *GetCoinSupplyRequestMessage) Reset() {
 	*x = GetCoinSupplyRequestMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[106]
+		mi := &file_rpc_proto_msgTypes[107]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -6032,7 +6106,7 @@ func (x *GetCoinSupplyRequestMessage) String() string {
 func (*GetCoinSupplyRequestMessage) ProtoMessage() {}
 
 func (x *GetCoinSupplyRequestMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[106]
+	mi := &file_rpc_proto_msgTypes[107]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6045,7 +6119,7 @@ func (x *GetCoinSupplyRequestMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetCoinSupplyRequestMessage.ProtoReflect.Descriptor instead.
 func (*GetCoinSupplyRequestMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{106}
+	return file_rpc_proto_rawDescGZIP(), []int{107}
 }
 
 type GetCoinSupplyResponseMessage struct {
@@ -6061,7 +6135,7 @@ type GetCoinSupplyResponseMessage struct {
 func (x *GetCoinSupplyResponseMessage) Reset() {
 	*x = GetCoinSupplyResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_proto_msgTypes[107]
+		mi := &file_rpc_proto_msgTypes[108]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -6074,7 +6148,7 @@ func (x *GetCoinSupplyResponseMessage) String() string {
 func (*GetCoinSupplyResponseMessage) ProtoMessage() {}
 
 func (x *GetCoinSupplyResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_proto_msgTypes[107]
+	mi := &file_rpc_proto_msgTypes[108]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6087,7 +6161,7 @@ func (x *GetCoinSupplyResponseMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetCoinSupplyResponseMessage.ProtoReflect.Descriptor instead.
 func (*GetCoinSupplyResponseMessage) Descriptor() ([]byte, []int) {
-	return file_rpc_proto_rawDescGZIP(), []int{107}
+	return file_rpc_proto_rawDescGZIP(), []int{108}
 }
 
 func (x *GetCoinSupplyResponseMessage) GetMaxSompi() uint64 {
@@ -6111,6 +6185,468 @@ func (x *GetCoinSupplyResponseMessage) GetError() *RPCError {
 	return nil
 }
 
+// GetCheckpointsRequestMessage requests the consensus checkpoints of the node:
+// the known-good blocks that every accepted chain must pass through.
+type GetCheckpointsRequestMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *GetCheckpointsRequestMessage) Reset() {
+	*x = GetCheckpointsRequestMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_proto_msgTypes[109]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetCheckpointsRequestMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCheckpointsRequestMessage) ProtoMessage() {}
+
+func (x *GetCheckpointsRequestMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_proto_msgTypes[109]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCheckpointsRequestMessage.ProtoReflect.Descriptor instead.
+func (*GetCheckpointsRequestMessage) Descriptor() ([]byte, []int) {
+	return file_rpc_proto_rawDescGZIP(), []int{109}
+}
+
+type RpcCheckpoint struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Hash     string `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	DaaScore uint64 `protobuf:"varint,2,opt,name=daaScore,proto3" json:"daaScore,omitempty"`
+}
+
+func (x *RpcCheckpoint) Reset() {
+	*x = RpcCheckpoint{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_proto_msgTypes[110]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RpcCheckpoint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RpcCheckpoint) ProtoMessage() {}
+
+func (x *RpcCheckpoint) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_proto_msgTypes[110]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RpcCheckpoint.ProtoReflect.Descriptor instead.
+func (*RpcCheckpoint) Descriptor() ([]byte, []int) {
+	return file_rpc_proto_rawDescGZIP(), []int{110}
+}
+
+func (x *RpcCheckpoint) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *RpcCheckpoint) GetDaaScore() uint64 {
+	if x != nil {
+		return x.DaaScore
+	}
+	return 0
+}
+
+type GetCheckpointsResponseMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Checkpoints []*RpcCheckpoint `protobuf:"bytes,1,rep,name=checkpoints,proto3" json:"checkpoints,omitempty"`
+	Error       *RPCError        `protobuf:"bytes,1000,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (x *GetCheckpointsResponseMessage) Reset() {
+	*x = GetCheckpointsResponseMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_proto_msgTypes[111]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetCheckpointsResponseMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCheckpointsResponseMessage) ProtoMessage() {}
+
+func (x *GetCheckpointsResponseMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_proto_msgTypes[111]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCheckpointsResponseMessage.ProtoReflect.Descriptor instead.
+func (*GetCheckpointsResponseMessage) Descriptor() ([]byte, []int) {
+	return file_rpc_proto_rawDescGZIP(), []int{111}
+}
+
+func (x *GetCheckpointsResponseMessage) GetCheckpoints() []*RpcCheckpoint {
+	if x != nil {
+		return x.Checkpoints
+	}
+	return nil
+}
+
+func (x *GetCheckpointsResponseMessage) GetError() *RPCError {
+	if x != nil {
+		return x.Error
+	}
+	return nil
+}
+
+// GetDeploymentInfoRequestMessage requests the consensus rule change deployments
+// of the network and their states in the virtual block.
+type GetDeploymentInfoRequestMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *GetDeploymentInfoRequestMessage) Reset() {
+	*x = GetDeploymentInfoRequestMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_proto_msgTypes[112]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetDeploymentInfoRequestMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDeploymentInfoRequestMessage) ProtoMessage() {}
+
+func (x *GetDeploymentInfoRequestMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_proto_msgTypes[112]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDeploymentInfoRequestMessage.ProtoReflect.Descriptor instead.
+func (*GetDeploymentInfoRequestMessage) Descriptor() ([]byte, []int) {
+	return file_rpc_proto_rawDescGZIP(), []int{112}
+}
+
+type RpcDeployment struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name            string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	BitNumber       uint32 `protobuf:"varint,2,opt,name=bitNumber,proto3" json:"bitNumber,omitempty"`
+	StartDaaScore   uint64 `protobuf:"varint,3,opt,name=startDaaScore,proto3" json:"startDaaScore,omitempty"`
+	TimeoutDaaScore uint64 `protobuf:"varint,4,opt,name=timeoutDaaScore,proto3" json:"timeoutDaaScore,omitempty"`
+	// One of defined, started, locked-in, active or failed
+	State string `protobuf:"bytes,5,opt,name=state,proto3" json:"state,omitempty"`
+}
+
+func (x *RpcDeployment) Reset() {
+	*x = RpcDeployment{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_proto_msgTypes[113]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RpcDeployment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RpcDeployment) ProtoMessage() {}
+
+func (x *RpcDeployment) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_proto_msgTypes[113]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RpcDeployment.ProtoReflect.Descriptor instead.
+func (*RpcDeployment) Descriptor() ([]byte, []int) {
+	return file_rpc_proto_rawDescGZIP(), []int{113}
+}
+
+func (x *RpcDeployment) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *RpcDeployment) GetBitNumber() uint32 {
+	if x != nil {
+		return x.BitNumber
+	}
+	return 0
+}
+
+func (x *RpcDeployment) GetStartDaaScore() uint64 {
+	if x != nil {
+		return x.StartDaaScore
+	}
+	return 0
+}
+
+func (x *RpcDeployment) GetTimeoutDaaScore() uint64 {
+	if x != nil {
+		return x.TimeoutDaaScore
+	}
+	return 0
+}
+
+func (x *RpcDeployment) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+type GetDeploymentInfoResponseMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Deployments                   []*RpcDeployment `protobuf:"bytes,1,rep,name=deployments,proto3" json:"deployments,omitempty"`
+	MinerConfirmationWindow       uint64           `protobuf:"varint,2,opt,name=minerConfirmationWindow,proto3" json:"minerConfirmationWindow,omitempty"`
+	RuleChangeActivationThreshold uint64           `protobuf:"varint,3,opt,name=ruleChangeActivationThreshold,proto3" json:"ruleChangeActivationThreshold,omitempty"`
+	Error                         *RPCError        `protobuf:"bytes,1000,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (x *GetDeploymentInfoResponseMessage) Reset() {
+	*x = GetDeploymentInfoResponseMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_proto_msgTypes[114]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetDeploymentInfoResponseMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDeploymentInfoResponseMessage) ProtoMessage() {}
+
+func (x *GetDeploymentInfoResponseMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_proto_msgTypes[114]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDeploymentInfoResponseMessage.ProtoReflect.Descriptor instead.
+func (*GetDeploymentInfoResponseMessage) Descriptor() ([]byte, []int) {
+	return file_rpc_proto_rawDescGZIP(), []int{114}
+}
+
+func (x *GetDeploymentInfoResponseMessage) GetDeployments() []*RpcDeployment {
+	if x != nil {
+		return x.Deployments
+	}
+	return nil
+}
+
+func (x *GetDeploymentInfoResponseMessage) GetMinerConfirmationWindow() uint64 {
+	if x != nil {
+		return x.MinerConfirmationWindow
+	}
+	return 0
+}
+
+func (x *GetDeploymentInfoResponseMessage) GetRuleChangeActivationThreshold() uint64 {
+	if x != nil {
+		return x.RuleChangeActivationThreshold
+	}
+	return 0
+}
+
+func (x *GetDeploymentInfoResponseMessage) GetError() *RPCError {
+	if x != nil {
+		return x.Error
+	}
+	return nil
+}
+
+// VerifyMessageRequestMessage requests to verify that a signature, such as one
+// created by c4exwallet sign-message, is a signature of a message by an address.
+// Only pay-to-pubkey addresses, of either Schnorr or ECDSA keys, can sign messages.
+type VerifyMessageRequestMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Address   string `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Message   string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Signature string `protobuf:"bytes,3,opt,name=signature,proto3" json:"signature,omitempty"` // Encoded in hex
+}
+
+func (x *VerifyMessageRequestMessage) Reset() {
+	*x = VerifyMessageRequestMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_proto_msgTypes[115]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *VerifyMessageRequestMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyMessageRequestMessage) ProtoMessage() {}
+
+func (x *VerifyMessageRequestMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_proto_msgTypes[115]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyMessageRequestMessage.ProtoReflect.Descriptor instead.
+func (*VerifyMessageRequestMessage) Descriptor() ([]byte, []int) {
+	return file_rpc_proto_rawDescGZIP(), []int{115}
+}
+
+func (x *VerifyMessageRequestMessage) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *VerifyMessageRequestMessage) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *VerifyMessageRequestMessage) GetSignature() string {
+	if x != nil {
+		return x.Signature
+	}
+	return ""
+}
+
+type VerifyMessageResponseMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	IsValid bool      `protobuf:"varint,1,opt,name=isValid,proto3" json:"isValid,omitempty"`
+	Error   *RPCError `protobuf:"bytes,1000,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (x *VerifyMessageResponseMessage) Reset() {
+	*x = VerifyMessageResponseMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_proto_msgTypes[116]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *VerifyMessageResponseMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyMessageResponseMessage) ProtoMessage() {}
+
+func (x *VerifyMessageResponseMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_proto_msgTypes[116]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyMessageResponseMessage.ProtoReflect.Descriptor instead.
+func (*VerifyMessageResponseMessage) Descriptor() ([]byte, []int) {
+	return file_rpc_proto_rawDescGZIP(), []int{116}
+}
+
+func (x *VerifyMessageResponseMessage) GetIsValid() bool {
+	if x != nil {
+		return x.IsValid
+	}
+	return false
+}
+
+func (x *VerifyMessageResponseMessage) GetError() *RPCError {
+	if x != nil {
+		return x.Error
+	}
+	return nil
+}
+
 var File_rpc_proto protoreflect.FileDescriptor
 
 var file_rpc_proto_rawDesc = []byte{
@@ -6310,13 +6846,21 @@ var file_rpc_proto_rawDesc = []byte{
 	0x3a, 0x0a, 0x0c, 0x52, 0x65, 0x6a, 0x65, 0x63, 0x74, 0x52, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x12,
 	0x08, 0x0a, 0x04, 0x4e, 0x4f, 0x4e, 0x45, 0x10, 0x00, 0x12, 0x11, 0x0a, 0x0d, 0x42, 0x4c, 0x4f,
 	0x43, 0x4b, 0x5f, 0x49, 0x4e, 0x56, 0x41, 0x4c, 0x49, 0x44, 0x10, 0x01, 0x12, 0x0d, 0x0a, 0x09,
-	0x49, 0x53, 0x5f, 0x49, 0x4e, 0x5f, 0x49, 0x42, 0x44, 0x10, 0x02, 0x22, 0x5e, 0x0a, 0x1e, 0x47,
-	0x65, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x54, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x1e, 0x0a,
-	0x0a, 0x70, 0x61, 0x79, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x0a, 0x70, 0x61, 0x79, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x1c, 0x0a,
-	0x09, 0x65, 0x78, 0x74, 0x72, 0x61, 0x44, 0x61, 0x74, 0x61, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x09, 0x65, 0x78, 0x74, 0x72, 0x61, 0x44, 0x61, 0x74, 0x61, 0x22, 0x94, 0x01, 0x0a, 0x1f,
+	0x49, 0x53, 0x5f, 0x49, 0x4e, 0x5f, 0x49, 0x42, 0x44, 0x10, 0x02, 0x22, 0x96, 0x01, 0x0a, 0x1e,
+	0x47, 0x65, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x54, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x1e,
+	0x0a, 0x0a, 0x70, 0x61, 0x79, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0a, 0x70, 0x61, 0x79, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x1c,
+	0x0a, 0x09, 0x65, 0x78, 0x74, 0x72, 0x61, 0x44, 0x61, 0x74, 0x61, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x09, 0x65, 0x78, 0x74, 0x72, 0x61, 0x44, 0x61, 0x74, 0x61, 0x12, 0x36, 0x0a, 0x07,
+	0x70, 0x61, 0x79, 0x6f, 0x75, 0x74, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1c, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x52, 0x70, 0x63, 0x43, 0x6f, 0x69,
+	0x6e, 0x62, 0x61, 0x73, 0x65, 0x50, 0x61, 0x79, 0x6f, 0x75, 0x74, 0x52, 0x07, 0x70, 0x61, 0x79,
+	0x6f, 0x75, 0x74, 0x73, 0x22, 0x45, 0x0a, 0x11, 0x52, 0x70, 0x63, 0x43, 0x6f, 0x69, 0x6e, 0x62,
+	0x61, 0x73, 0x65, 0x50, 0x61, 0x79, 0x6f, 0x75, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x64, 0x64,
+	0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72,
+	0x65, 0x73, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x77, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x06, 0x77, 0x65, 0x69, 0x67, 0x68, 0x74, 0x22, 0x94, 0x01, 0x0a, 0x1f,
 	0x47, 0x65, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x54, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65,
 	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12,
 	0x29, 0x0a, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13,
@@ -6592,7 +7136,7 @@ var file_rpc_proto_rawDesc = []byte{
 	0x50, 0x43, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x1f,
 	0x0a, 0x1d, 0x47, 0x65, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x44, 0x61, 0x67, 0x49, 0x6e, 0x66,
 	0x6f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22,
-	0x9e, 0x03, 0x0a, 0x1e, 0x47, 0x65, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x44, 0x61, 0x67, 0x49,
+	0xde, 0x03, 0x0a, 0x1e, 0x47, 0x65, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x44, 0x61, 0x67, 0x49,
 	0x6e, 0x66, 0x6f, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61,
 	0x67, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x4e, 0x61, 0x6d,
 	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b,
@@ -6615,7 +7159,11 @@ var file_rpc_proto_rawDesc = []byte{
 	0x67, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x48, 0x61, 0x73, 0x68, 0x12, 0x28, 0x0a, 0x0f, 0x76, 0x69,
 	0x72, 0x74, 0x75, 0x61, 0x6c, 0x44, 0x61, 0x61, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x18, 0x09, 0x20,
 	0x01, 0x28, 0x04, 0x52, 0x0f, 0x76, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x44, 0x61, 0x61, 0x53,
-	0x63, 0x6f, 0x72, 0x65, 0x12, 0x2a, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0xe8, 0x07,
+	0x63, 0x6f, 0x72, 0x65, 0x12, 0x3e, 0x0a, 0x1a, 0x65, 0x61, 0x72, 0x6c, 0x69, 0x65, 0x73, 0x74,
+	0x41, 0x76, 0x61, 0x69, 0x6c, 0x61, 0x62, 0x6c, 0x65, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61,
+	0x73, 0x68, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x1a, 0x65, 0x61, 0x72, 0x6c, 0x69, 0x65,
+	0x73, 0x74, 0x41, 0x76, 0x61, 0x69, 0x6c, 0x61, 0x62, 0x6c, 0x65, 0x42, 0x6c, 0x6f, 0x63, 0x6b,
+	0x48, 0x61, 0x73, 0x68, 0x12, 0x2a, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0xe8, 0x07,
 	0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x77, 0x69, 0x72, 0x65,
 	0x2e, 0x52, 0x50, 0x43, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72,
 	0x22, 0x55, 0x0a, 0x25, 0x52, 0x65, 0x73, 0x6f, 0x6c, 0x76, 0x65, 0x46, 0x69, 0x6e, 0x61, 0x6c,
@@ -6914,10 +7462,67 @@ var file_rpc_proto_rawDesc = []byte{
 	0x63, 0x75, 0x6c, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x53, 0x6f, 0x6d, 0x70, 0x69, 0x12, 0x2a, 0x0a,
 	0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0xe8, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e,
 	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x52, 0x50, 0x43, 0x45, 0x72, 0x72,
-	0x6f, 0x72, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x42, 0x26, 0x5a, 0x24, 0x67, 0x69, 0x74,
-	0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6b, 0x61, 0x73, 0x70, 0x61, 0x6e, 0x65, 0x74,
-	0x2f, 0x6b, 0x61, 0x73, 0x70, 0x61, 0x64, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x77, 0x69, 0x72,
-	0x65, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x6f, 0x72, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x1e, 0x0a, 0x1c, 0x47, 0x65, 0x74,
+	0x43, 0x68, 0x65, 0x63, 0x6b, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x3f, 0x0a, 0x0d, 0x52, 0x70, 0x63,
+	0x43, 0x68, 0x65, 0x63, 0x6b, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x61,
+	0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x68, 0x61, 0x73, 0x68, 0x12, 0x1a,
+	0x0a, 0x08, 0x64, 0x61, 0x61, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x08, 0x64, 0x61, 0x61, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x22, 0x87, 0x01, 0x0a, 0x1d, 0x47,
+	0x65, 0x74, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x3a, 0x0a, 0x0b,
+	0x63, 0x68, 0x65, 0x63, 0x6b, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x18, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x52, 0x70,
+	0x63, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x52, 0x0b, 0x63, 0x68, 0x65,
+	0x63, 0x6b, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x12, 0x2a, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f,
+	0x72, 0x18, 0xe8, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x77, 0x69, 0x72, 0x65, 0x2e, 0x52, 0x50, 0x43, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x52, 0x05, 0x65,
+	0x72, 0x72, 0x6f, 0x72, 0x22, 0x21, 0x0a, 0x1f, 0x47, 0x65, 0x74, 0x44, 0x65, 0x70, 0x6c, 0x6f,
+	0x79, 0x6d, 0x65, 0x6e, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0xa7, 0x01, 0x0a, 0x0d, 0x52, 0x70, 0x63, 0x44,
+	0x65, 0x70, 0x6c, 0x6f, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1c, 0x0a,
+	0x09, 0x62, 0x69, 0x74, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x09, 0x62, 0x69, 0x74, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x24, 0x0a, 0x0d, 0x73,
+	0x74, 0x61, 0x72, 0x74, 0x44, 0x61, 0x61, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x0d, 0x73, 0x74, 0x61, 0x72, 0x74, 0x44, 0x61, 0x61, 0x53, 0x63, 0x6f, 0x72,
+	0x65, 0x12, 0x28, 0x0a, 0x0f, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x44, 0x61, 0x61, 0x53,
+	0x63, 0x6f, 0x72, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0f, 0x74, 0x69, 0x6d, 0x65,
+	0x6f, 0x75, 0x74, 0x44, 0x61, 0x61, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x73,
+	0x74, 0x61, 0x74, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x73, 0x74, 0x61, 0x74,
+	0x65, 0x22, 0x8a, 0x02, 0x0a, 0x20, 0x47, 0x65, 0x74, 0x44, 0x65, 0x70, 0x6c, 0x6f, 0x79, 0x6d,
+	0x65, 0x6e, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x4d,
+	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x3a, 0x0a, 0x0b, 0x64, 0x65, 0x70, 0x6c, 0x6f, 0x79,
+	0x6d, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x52, 0x70, 0x63, 0x44, 0x65, 0x70, 0x6c, 0x6f,
+	0x79, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x0b, 0x64, 0x65, 0x70, 0x6c, 0x6f, 0x79, 0x6d, 0x65, 0x6e,
+	0x74, 0x73, 0x12, 0x38, 0x0a, 0x17, 0x6d, 0x69, 0x6e, 0x65, 0x72, 0x43, 0x6f, 0x6e, 0x66, 0x69,
+	0x72, 0x6d, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x57, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x17, 0x6d, 0x69, 0x6e, 0x65, 0x72, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x72,
+	0x6d, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x57, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x12, 0x44, 0x0a, 0x1d,
+	0x72, 0x75, 0x6c, 0x65, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x41, 0x63, 0x74, 0x69, 0x76, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x54, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x1d, 0x72, 0x75, 0x6c, 0x65, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x41,
+	0x63, 0x74, 0x69, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x54, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f,
+	0x6c, 0x64, 0x12, 0x2a, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0xe8, 0x07, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x13, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x52,
+	0x50, 0x43, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x6f,
+	0x0a, 0x1b, 0x56, 0x65, 0x72, 0x69, 0x66, 0x79, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x18, 0x0a,
+	0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
+	0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61,
+	0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x22,
+	0x64, 0x0a, 0x1c, 0x56, 0x65, 0x72, 0x69, 0x66, 0x79, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12,
+	0x18, 0x0a, 0x07, 0x69, 0x73, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x07, 0x69, 0x73, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x12, 0x2a, 0x0a, 0x05, 0x65, 0x72, 0x72,
+	0x6f, 0x72, 0x18, 0xe8, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x52, 0x50, 0x43, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x52, 0x05,
+	0x65, 0x72, 0x72, 0x6f, 0x72, 0x42, 0x21, 0x5a, 0x1f, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e,
+	0x63, 0x6f, 0x6d, 0x2f, 0x63, 0x34, 0x65, 0x69, 0x2f, 0x63, 0x34, 0x65, 0x78, 0x64, 0x2f, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x77, 0x69, 0x72, 0x65, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -6933,7 +7538,7 @@ func file_rpc_proto_rawDescGZIP() []byte {
 }
 
 var file_rpc_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_rpc_proto_msgTypes = make([]protoimpl.MessageInfo, 108)
+var file_rpc_proto_msgTypes = make([]protoimpl.MessageInfo, 117)
 var file_rpc_proto_goTypes = []interface{}{
 	(SubmitBlockResponseMessage_RejectReason)(0), // 0: protowire.SubmitBlockResponseMessage.RejectReason
 	(*RPCError)(nil),                                                   // 1: protowire.RPCError
@@ -6955,95 +7560,104 @@ var file_rpc_proto_goTypes = []interface{}{
 	(*SubmitBlockRequestMessage)(nil),                                  // 17: protowire.SubmitBlockRequestMessage
 	(*SubmitBlockResponseMessage)(nil),                                 // 18: protowire.SubmitBlockResponseMessage
 	(*GetBlockTemplateRequestMessage)(nil),                             // 19: protowire.GetBlockTemplateRequestMessage
-	(*GetBlockTemplateResponseMessage)(nil),                            // 20: protowire.GetBlockTemplateResponseMessage
-	(*NotifyBlockAddedRequestMessage)(nil),                             // 21: protowire.NotifyBlockAddedRequestMessage
-	(*NotifyBlockAddedResponseMessage)(nil),                            // 22: protowire.NotifyBlockAddedResponseMessage
-	(*BlockAddedNotificationMessage)(nil),                              // 23: protowire.BlockAddedNotificationMessage
-	(*GetPeerAddressesRequestMessage)(nil),                             // 24: protowire.GetPeerAddressesRequestMessage
-	(*GetPeerAddressesResponseMessage)(nil),                            // 25: protowire.GetPeerAddressesResponseMessage
-	(*GetPeerAddressesKnownAddressMessage)(nil),                        // 26: protowire.GetPeerAddressesKnownAddressMessage
-	(*GetSelectedTipHashRequestMessage)(nil),                           // 27: protowire.GetSelectedTipHashRequestMessage
-	(*GetSelectedTipHashResponseMessage)(nil),                          // 28: protowire.GetSelectedTipHashResponseMessage
-	(*GetMempoolEntryRequestMessage)(nil),                              // 29: protowire.GetMempoolEntryRequestMessage
-	(*GetMempoolEntryResponseMessage)(nil),                             // 30: protowire.GetMempoolEntryResponseMessage
-	(*GetMempoolEntriesRequestMessage)(nil),                            // 31: protowire.GetMempoolEntriesRequestMessage
-	(*GetMempoolEntriesResponseMessage)(nil),                           // 32: protowire.GetMempoolEntriesResponseMessage
-	(*MempoolEntry)(nil),                                               // 33: protowire.MempoolEntry
-	(*GetConnectedPeerInfoRequestMessage)(nil),                         // 34: protowire.GetConnectedPeerInfoRequestMessage
-	(*GetConnectedPeerInfoResponseMessage)(nil),                        // 35: protowire.GetConnectedPeerInfoResponseMessage
-	(*GetConnectedPeerInfoMessage)(nil),                                // 36: protowire.GetConnectedPeerInfoMessage
-	(*AddPeerRequestMessage)(nil),                                      // 37: protowire.AddPeerRequestMessage
-	(*AddPeerResponseMessage)(nil),                                     // 38: protowire.AddPeerResponseMessage
-	(*SubmitTransactionRequestMessage)(nil),                            // 39: protowire.SubmitTransactionRequestMessage
-	(*SubmitTransactionResponseMessage)(nil),                           // 40: protowire.SubmitTransactionResponseMessage
-	(*NotifyVirtualSelectedParentChainChangedRequestMessage)(nil),      // 41: protowire.NotifyVirtualSelectedParentChainChangedRequestMessage
-	(*NotifyVirtualSelectedParentChainChangedResponseMessage)(nil),     // 42: protowire.NotifyVirtualSelectedParentChainChangedResponseMessage
-	(*VirtualSelectedParentChainChangedNotificationMessage)(nil),       // 43: protowire.VirtualSelectedParentChainChangedNotificationMessage
-	(*GetBlockRequestMessage)(nil),                                     // 44: protowire.GetBlockRequestMessage
-	(*GetBlockResponseMessage)(nil),                                    // 45: protowire.GetBlockResponseMessage
-	(*GetSubnetworkRequestMessage)(nil),                                // 46: protowire.GetSubnetworkRequestMessage
-	(*GetSubnetworkResponseMessage)(nil),                               // 47: protowire.GetSubnetworkResponseMessage
-	(*GetVirtualSelectedParentChainFromBlockRequestMessage)(nil),       // 48: protowire.GetVirtualSelectedParentChainFromBlockRequestMessage
-	(*AcceptedTransactionIds)(nil),                                     // 49: protowire.AcceptedTransactionIds
-	(*GetVirtualSelectedParentChainFromBlockResponseMessage)(nil),      // 50: protowire.GetVirtualSelectedParentChainFromBlockResponseMessage
-	(*GetBlocksRequestMessage)(nil),                                    // 51: protowire.GetBlocksRequestMessage
-	(*GetBlocksResponseMessage)(nil),                                   // 52: protowire.GetBlocksResponseMessage
-	(*GetBlockCountRequestMessage)(nil),                                // 53: protowire.GetBlockCountRequestMessage
-	(*GetBlockCountResponseMessage)(nil),                               // 54: protowire.GetBlockCountResponseMessage
-	(*GetBlockDagInfoRequestMessage)(nil),                              // 55: protowire.GetBlockDagInfoRequestMessage
-	(*GetBlockDagInfoResponseMessage)(nil),                             // 56: protowire.GetBlockDagInfoResponseMessage
-	(*ResolveFinalityConflictRequestMessage)(nil),                      // 57: protowire.ResolveFinalityConflictRequestMessage
-	(*ResolveFinalityConflictResponseMessage)(nil),                     // 58: protowire.ResolveFinalityConflictResponseMessage
-	(*NotifyFinalityConflictsRequestMessage)(nil),                      // 59: protowire.NotifyFinalityConflictsRequestMessage
-	(*NotifyFinalityConflictsResponseMessage)(nil),                     // 60: protowire.NotifyFinalityConflictsResponseMessage
-	(*FinalityConflictNotificationMessage)(nil),                        // 61: protowire.FinalityConflictNotificationMessage
-	(*FinalityConflictResolvedNotificationMessage)(nil),                // 62: protowire.FinalityConflictResolvedNotificationMessage
-	(*ShutDownRequestMessage)(nil),                                     // 63: protowire.ShutDownRequestMessage
-	(*ShutDownResponseMessage)(nil),                                    // 64: protowire.ShutDownResponseMessage
-	(*GetHeadersRequestMessage)(nil),                                   // 65: protowire.GetHeadersRequestMessage
-	(*GetHeadersResponseMessage)(nil),                                  // 66: protowire.GetHeadersResponseMessage
-	(*NotifyUtxosChangedRequestMessage)(nil),                           // 67: protowire.NotifyUtxosChangedRequestMessage
-	(*NotifyUtxosChangedResponseMessage)(nil),                          // 68: protowire.NotifyUtxosChangedResponseMessage
-	(*UtxosChangedNotificationMessage)(nil),                            // 69: protowire.UtxosChangedNotificationMessage
-	(*UtxosByAddressesEntry)(nil),                                      // 70: protowire.UtxosByAddressesEntry
-	(*StopNotifyingUtxosChangedRequestMessage)(nil),                    // 71: protowire.StopNotifyingUtxosChangedRequestMessage
-	(*StopNotifyingUtxosChangedResponseMessage)(nil),                   // 72: protowire.StopNotifyingUtxosChangedResponseMessage
-	(*GetUtxosByAddressesRequestMessage)(nil),                          // 73: protowire.GetUtxosByAddressesRequestMessage
-	(*GetUtxosByAddressesResponseMessage)(nil),                         // 74: protowire.GetUtxosByAddressesResponseMessage
-	(*GetBalanceByAddressRequestMessage)(nil),                          // 75: protowire.GetBalanceByAddressRequestMessage
-	(*GetBalanceByAddressResponseMessage)(nil),                         // 76: protowire.GetBalanceByAddressResponseMessage
-	(*GetBalancesByAddressesRequestMessage)(nil),                       // 77: protowire.GetBalancesByAddressesRequestMessage
-	(*BalancesByAddressEntry)(nil),                                     // 78: protowire.BalancesByAddressEntry
-	(*GetBalancesByAddressesResponseMessage)(nil),                      // 79: protowire.GetBalancesByAddressesResponseMessage
-	(*GetVirtualSelectedParentBlueScoreRequestMessage)(nil),            // 80: protowire.GetVirtualSelectedParentBlueScoreRequestMessage
-	(*GetVirtualSelectedParentBlueScoreResponseMessage)(nil),           // 81: protowire.GetVirtualSelectedParentBlueScoreResponseMessage
-	(*NotifyVirtualSelectedParentBlueScoreChangedRequestMessage)(nil),  // 82: protowire.NotifyVirtualSelectedParentBlueScoreChangedRequestMessage
-	(*NotifyVirtualSelectedParentBlueScoreChangedResponseMessage)(nil), // 83: protowire.NotifyVirtualSelectedParentBlueScoreChangedResponseMessage
-	(*VirtualSelectedParentBlueScoreChangedNotificationMessage)(nil),   // 84: protowire.VirtualSelectedParentBlueScoreChangedNotificationMessage
-	(*NotifyVirtualDaaScoreChangedRequestMessage)(nil),                 // 85: protowire.NotifyVirtualDaaScoreChangedRequestMessage
-	(*NotifyVirtualDaaScoreChangedResponseMessage)(nil),                // 86: protowire.NotifyVirtualDaaScoreChangedResponseMessage
-	(*VirtualDaaScoreChangedNotificationMessage)(nil),                  // 87: protowire.VirtualDaaScoreChangedNotificationMessage
-	(*NotifyPruningPointUTXOSetOverrideRequestMessage)(nil),            // 88: protowire.NotifyPruningPointUTXOSetOverrideRequestMessage
-	(*NotifyPruningPointUTXOSetOverrideResponseMessage)(nil),           // 89: protowire.NotifyPruningPointUTXOSetOverrideResponseMessage
-	(*PruningPointUTXOSetOverrideNotificationMessage)(nil),             // 90: protowire.PruningPointUTXOSetOverrideNotificationMessage
-	(*StopNotifyingPruningPointUTXOSetOverrideRequestMessage)(nil),     // 91: protowire.StopNotifyingPruningPointUTXOSetOverrideRequestMessage
-	(*StopNotifyingPruningPointUTXOSetOverrideResponseMessage)(nil),    // 92: protowire.StopNotifyingPruningPointUTXOSetOverrideResponseMessage
-	(*BanRequestMessage)(nil),                                          // 93: protowire.BanRequestMessage
-	(*BanResponseMessage)(nil),                                         // 94: protowire.BanResponseMessage
-	(*UnbanRequestMessage)(nil),                                        // 95: protowire.UnbanRequestMessage
-	(*UnbanResponseMessage)(nil),                                       // 96: protowire.UnbanResponseMessage
-	(*GetInfoRequestMessage)(nil),                                      // 97: protowire.GetInfoRequestMessage
-	(*GetInfoResponseMessage)(nil),                                     // 98: protowire.GetInfoResponseMessage
-	(*EstimateNetworkHashesPerSecondRequestMessage)(nil),               // 99: protowire.EstimateNetworkHashesPerSecondRequestMessage
-	(*EstimateNetworkHashesPerSecondResponseMessage)(nil),              // 100: protowire.EstimateNetworkHashesPerSecondResponseMessage
-	(*NotifyNewBlockTemplateRequestMessage)(nil),                       // 101: protowire.NotifyNewBlockTemplateRequestMessage
-	(*NotifyNewBlockTemplateResponseMessage)(nil),                      // 102: protowire.NotifyNewBlockTemplateResponseMessage
-	(*NewBlockTemplateNotificationMessage)(nil),                        // 103: protowire.NewBlockTemplateNotificationMessage
-	(*MempoolEntryByAddress)(nil),                                      // 104: protowire.MempoolEntryByAddress
-	(*GetMempoolEntriesByAddressesRequestMessage)(nil),                 // 105: protowire.GetMempoolEntriesByAddressesRequestMessage
-	(*GetMempoolEntriesByAddressesResponseMessage)(nil),                // 106: protowire.GetMempoolEntriesByAddressesResponseMessage
-	(*GetCoinSupplyRequestMessage)(nil),                                // 107: protowire.GetCoinSupplyRequestMessage
-	(*GetCoinSupplyResponseMessage)(nil),                               // 108: protowire.GetCoinSupplyResponseMessage
+	(*RpcCoinbasePayout)(nil),                                          // 20: protowire.RpcCoinbasePayout
+	(*GetBlockTemplateResponseMessage)(nil),                            // 21: protowire.GetBlockTemplateResponseMessage
+	(*NotifyBlockAddedRequestMessage)(nil),                             // 22: protowire.NotifyBlockAddedRequestMessage
+	(*NotifyBlockAddedResponseMessage)(nil),                            // 23: protowire.NotifyBlockAddedResponseMessage
+	(*BlockAddedNotificationMessage)(nil),                              // 24: protowire.BlockAddedNotificationMessage
+	(*GetPeerAddressesRequestMessage)(nil),                             // 25: protowire.GetPeerAddressesRequestMessage
+	(*GetPeerAddressesResponseMessage)(nil),                            // 26: protowire.GetPeerAddressesResponseMessage
+	(*GetPeerAddressesKnownAddressMessage)(nil),                        // 27: protowire.GetPeerAddressesKnownAddressMessage
+	(*GetSelectedTipHashRequestMessage)(nil),                           // 28: protowire.GetSelectedTipHashRequestMessage
+	(*GetSelectedTipHashResponseMessage)(nil),                          // 29: protowire.GetSelectedTipHashResponseMessage
+	(*GetMempoolEntryRequestMessage)(nil),                              // 30: protowire.GetMempoolEntryRequestMessage
+	(*GetMempoolEntryResponseMessage)(nil),                             // 31: protowire.GetMempoolEntryResponseMessage
+	(*GetMempoolEntriesRequestMessage)(nil),                            // 32: protowire.GetMempoolEntriesRequestMessage
+	(*GetMempoolEntriesResponseMessage)(nil),                           // 33: protowire.GetMempoolEntriesResponseMessage
+	(*MempoolEntry)(nil),                                               // 34: protowire.MempoolEntry
+	(*GetConnectedPeerInfoRequestMessage)(nil),                         // 35: protowire.GetConnectedPeerInfoRequestMessage
+	(*GetConnectedPeerInfoResponseMessage)(nil),                        // 36: protowire.GetConnectedPeerInfoResponseMessage
+	(*GetConnectedPeerInfoMessage)(nil),                                // 37: protowire.GetConnectedPeerInfoMessage
+	(*AddPeerRequestMessage)(nil),                                      // 38: protowire.AddPeerRequestMessage
+	(*AddPeerResponseMessage)(nil),                                     // 39: protowire.AddPeerResponseMessage
+	(*SubmitTransactionRequestMessage)(nil),                            // 40: protowire.SubmitTransactionRequestMessage
+	(*SubmitTransactionResponseMessage)(nil),                           // 41: protowire.SubmitTransactionResponseMessage
+	(*NotifyVirtualSelectedParentChainChangedRequestMessage)(nil),      // 42: protowire.NotifyVirtualSelectedParentChainChangedRequestMessage
+	(*NotifyVirtualSelectedParentChainChangedResponseMessage)(nil),     // 43: protowire.NotifyVirtualSelectedParentChainChangedResponseMessage
+	(*VirtualSelectedParentChainChangedNotificationMessage)(nil),       // 44: protowire.VirtualSelectedParentChainChangedNotificationMessage
+	(*GetBlockRequestMessage)(nil),                                     // 45: protowire.GetBlockRequestMessage
+	(*GetBlockResponseMessage)(nil),                                    // 46: protowire.GetBlockResponseMessage
+	(*GetSubnetworkRequestMessage)(nil),                                // 47: protowire.GetSubnetworkRequestMessage
+	(*GetSubnetworkResponseMessage)(nil),                               // 48: protowire.GetSubnetworkResponseMessage
+	(*GetVirtualSelectedParentChainFromBlockRequestMessage)(nil),       // 49: protowire.GetVirtualSelectedParentChainFromBlockRequestMessage
+	(*AcceptedTransactionIds)(nil),                                     // 50: protowire.AcceptedTransactionIds
+	(*GetVirtualSelectedParentChainFromBlockResponseMessage)(nil),      // 51: protowire.GetVirtualSelectedParentChainFromBlockResponseMessage
+	(*GetBlocksRequestMessage)(nil),                                    // 52: protowire.GetBlocksRequestMessage
+	(*GetBlocksResponseMessage)(nil),                                   // 53: protowire.GetBlocksResponseMessage
+	(*GetBlockCountRequestMessage)(nil),                                // 54: protowire.GetBlockCountRequestMessage
+	(*GetBlockCountResponseMessage)(nil),                               // 55: protowire.GetBlockCountResponseMessage
+	(*GetBlockDagInfoRequestMessage)(nil),                              // 56: protowire.GetBlockDagInfoRequestMessage
+	(*GetBlockDagInfoResponseMessage)(nil),                             // 57: protowire.GetBlockDagInfoResponseMessage
+	(*ResolveFinalityConflictRequestMessage)(nil),                      // 58: protowire.ResolveFinalityConflictRequestMessage
+	(*ResolveFinalityConflictResponseMessage)(nil),                     // 59: protowire.ResolveFinalityConflictResponseMessage
+	(*NotifyFinalityConflictsRequestMessage)(nil),                      // 60: protowire.NotifyFinalityConflictsRequestMessage
+	(*NotifyFinalityConflictsResponseMessage)(nil),                     // 61: protowire.NotifyFinalityConflictsResponseMessage
+	(*FinalityConflictNotificationMessage)(nil),                        // 62: protowire.FinalityConflictNotificationMessage
+	(*FinalityConflictResolvedNotificationMessage)(nil),                // 63: protowire.FinalityConflictResolvedNotificationMessage
+	(*ShutDownRequestMessage)(nil),                                     // 64: protowire.ShutDownRequestMessage
+	(*ShutDownResponseMessage)(nil),                                    // 65: protowire.ShutDownResponseMessage
+	(*GetHeadersRequestMessage)(nil),                                   // 66: protowire.GetHeadersRequestMessage
+	(*GetHeadersResponseMessage)(nil),                                  // 67: protowire.GetHeadersResponseMessage
+	(*NotifyUtxosChangedRequestMessage)(nil),                           // 68: protowire.NotifyUtxosChangedRequestMessage
+	(*NotifyUtxosChangedResponseMessage)(nil),                          // 69: protowire.NotifyUtxosChangedResponseMessage
+	(*UtxosChangedNotificationMessage)(nil),                            // 70: protowire.UtxosChangedNotificationMessage
+	(*UtxosByAddressesEntry)(nil),                                      // 71: protowire.UtxosByAddressesEntry
+	(*StopNotifyingUtxosChangedRequestMessage)(nil),                    // 72: protowire.StopNotifyingUtxosChangedRequestMessage
+	(*StopNotifyingUtxosChangedResponseMessage)(nil),                   // 73: protowire.StopNotifyingUtxosChangedResponseMessage
+	(*GetUtxosByAddressesRequestMessage)(nil),                          // 74: protowire.GetUtxosByAddressesRequestMessage
+	(*GetUtxosByAddressesResponseMessage)(nil),                         // 75: protowire.GetUtxosByAddressesResponseMessage
+	(*GetBalanceByAddressRequestMessage)(nil),                          // 76: protowire.GetBalanceByAddressRequestMessage
+	(*GetBalanceByAddressResponseMessage)(nil),                         // 77: protowire.GetBalanceByAddressResponseMessage
+	(*GetBalancesByAddressesRequestMessage)(nil),                       // 78: protowire.GetBalancesByAddressesRequestMessage
+	(*BalancesByAddressEntry)(nil),                                     // 79: protowire.BalancesByAddressEntry
+	(*GetBalancesByAddressesResponseMessage)(nil),                      // 80: protowire.GetBalancesByAddressesResponseMessage
+	(*GetVirtualSelectedParentBlueScoreRequestMessage)(nil),            // 81: protowire.GetVirtualSelectedParentBlueScoreRequestMessage
+	(*GetVirtualSelectedParentBlueScoreResponseMessage)(nil),           // 82: protowire.GetVirtualSelectedParentBlueScoreResponseMessage
+	(*NotifyVirtualSelectedParentBlueScoreChangedRequestMessage)(nil),  // 83: protowire.NotifyVirtualSelectedParentBlueScoreChangedRequestMessage
+	(*NotifyVirtualSelectedParentBlueScoreChangedResponseMessage)(nil), // 84: protowire.NotifyVirtualSelectedParentBlueScoreChangedResponseMessage
+	(*VirtualSelectedParentBlueScoreChangedNotificationMessage)(nil),   // 85: protowire.VirtualSelectedParentBlueScoreChangedNotificationMessage
+	(*NotifyVirtualDaaScoreChangedRequestMessage)(nil),                 // 86: protowire.NotifyVirtualDaaScoreChangedRequestMessage
+	(*NotifyVirtualDaaScoreChangedResponseMessage)(nil),                // 87: protowire.NotifyVirtualDaaScoreChangedResponseMessage
+	(*VirtualDaaScoreChangedNotificationMessage)(nil),                  // 88: protowire.VirtualDaaScoreChangedNotificationMessage
+	(*NotifyPruningPointUTXOSetOverrideRequestMessage)(nil),            // 89: protowire.NotifyPruningPointUTXOSetOverrideRequestMessage
+	(*NotifyPruningPointUTXOSetOverrideResponseMessage)(nil),           // 90: protowire.NotifyPruningPointUTXOSetOverrideResponseMessage
+	(*PruningPointUTXOSetOverrideNotificationMessage)(nil),             // 91: protowire.PruningPointUTXOSetOverrideNotificationMessage
+	(*StopNotifyingPruningPointUTXOSetOverrideRequestMessage)(nil),     // 92: protowire.StopNotifyingPruningPointUTXOSetOverrideRequestMessage
+	(*StopNotifyingPruningPointUTXOSetOverrideResponseMessage)(nil),    // 93: protowire.StopNotifyingPruningPointUTXOSetOverrideResponseMessage
+	(*BanRequestMessage)(nil),                                          // 94: protowire.BanRequestMessage
+	(*BanResponseMessage)(nil),                                         // 95: protowire.BanResponseMessage
+	(*UnbanRequestMessage)(nil),                                        // 96: protowire.UnbanRequestMessage
+	(*UnbanResponseMessage)(nil),                                       // 97: protowire.UnbanResponseMessage
+	(*GetInfoRequestMessage)(nil),                                      // 98: protowire.GetInfoRequestMessage
+	(*GetInfoResponseMessage)(nil),                                     // 99: protowire.GetInfoResponseMessage
+	(*EstimateNetworkHashesPerSecondRequestMessage)(nil),               // 100: protowire.EstimateNetworkHashesPerSecondRequestMessage
+	(*EstimateNetworkHashesPerSecondResponseMessage)(nil),              // 101: protowire.EstimateNetworkHashesPerSecondResponseMessage
+	(*NotifyNewBlockTemplateRequestMessage)(nil),                       // 102: protowire.NotifyNewBlockTemplateRequestMessage
+	(*NotifyNewBlockTemplateResponseMessage)(nil),                      // 103: protowire.NotifyNewBlockTemplateResponseMessage
+	(*NewBlockTemplateNotificationMessage)(nil),                        // 104: protowire.NewBlockTemplateNotificationMessage
+	(*MempoolEntryByAddress)(nil),                                      // 105: protowire.MempoolEntryByAddress
+	(*GetMempoolEntriesByAddressesRequestMessage)(nil),                 // 106: protowire.GetMempoolEntriesByAddressesRequestMessage
+	(*GetMempoolEntriesByAddressesResponseMessage)(nil),                // 107: protowire.GetMempoolEntriesByAddressesResponseMessage
+	(*GetCoinSupplyRequestMessage)(nil),                                // 108: protowire.GetCoinSupplyRequestMessage
+	(*GetCoinSupplyResponseMessage)(nil),                               // 109: protowire.GetCoinSupplyResponseMessage
+	(*GetCheckpointsRequestMessage)(nil),                               // 110: protowire.GetCheckpointsRequestMessage
+	(*RpcCheckpoint)(nil),                                              // 111: protowire.RpcCheckpoint
+	(*GetCheckpointsResponseMessage)(nil),                              // 112: protowire.GetCheckpointsResponseMessage
+	(*GetDeploymentInfoRequestMessage)(nil),                            // 113: protowire.GetDeploymentInfoRequestMessage
+	(*RpcDeployment)(nil),                                              // 114: protowire.RpcDeployment
+	(*GetDeploymentInfoResponseMessage)(nil),                           // 115: protowire.GetDeploymentInfoResponseMessage
+	(*VerifyMessageRequestMessage)(nil),                                // 116: protowire.VerifyMessageRequestMessage
+	(*VerifyMessageResponseMessage)(nil),                               // 117: protowire.VerifyMessageResponseMessage
 }
 var file_rpc_proto_depIdxs = []int32{
 	3,   // 0: protowire.RpcBlock.header:type_name -> protowire.RpcBlockHeader
@@ -7062,71 +7676,77 @@ var file_rpc_proto_depIdxs = []int32{
 	2,   // 13: protowire.SubmitBlockRequestMessage.block:type_name -> protowire.RpcBlock
 	0,   // 14: protowire.SubmitBlockResponseMessage.rejectReason:type_name -> protowire.SubmitBlockResponseMessage.RejectReason
 	1,   // 15: protowire.SubmitBlockResponseMessage.error:type_name -> protowire.RPCError
-	2,   // 16: protowire.GetBlockTemplateResponseMessage.block:type_name -> protowire.RpcBlock
-	1,   // 17: protowire.GetBlockTemplateResponseMessage.error:type_name -> protowire.RPCError
-	1,   // 18: protowire.NotifyBlockAddedResponseMessage.error:type_name -> protowire.RPCError
-	2,   // 19: protowire.BlockAddedNotificationMessage.block:type_name -> protowire.RpcBlock
-	26,  // 20: protowire.GetPeerAddressesResponseMessage.addresses:type_name -> protowire.GetPeerAddressesKnownAddressMessage
-	26,  // 21: protowire.GetPeerAddressesResponseMessage.bannedAddresses:type_name -> protowire.GetPeerAddressesKnownAddressMessage
-	1,   // 22: protowire.GetPeerAddressesResponseMessage.error:type_name -> protowire.RPCError
-	1,   // 23: protowire.GetSelectedTipHashResponseMessage.error:type_name -> protowire.RPCError
-	33,  // 24: protowire.GetMempoolEntryResponseMessage.entry:type_name -> protowire.MempoolEntry
-	1,   // 25: protowire.GetMempoolEntryResponseMessage.error:type_name -> protowire.RPCError
-	33,  // 26: protowire.GetMempoolEntriesResponseMessage.entries:type_name -> protowire.MempoolEntry
-	1,   // 27: protowire.GetMempoolEntriesResponseMessage.error:type_name -> protowire.RPCError
-	6,   // 28: protowire.MempoolEntry.transaction:type_name -> protowire.RpcTransaction
-	36,  // 29: protowire.GetConnectedPeerInfoResponseMessage.infos:type_name -> protowire.GetConnectedPeerInfoMessage
-	1,   // 30: protowire.GetConnectedPeerInfoResponseMessage.error:type_name -> protowire.RPCError
-	1,   // 31: protowire.AddPeerResponseMessage.error:type_name -> protowire.RPCError
-	6,   // 32: protowire.SubmitTransactionRequestMessage.transaction:type_name -> protowire.RpcTransaction
-	1,   // 33: protowire.SubmitTransactionResponseMessage.error:type_name -> protowire.RPCError
-	1,   // 34: protowire.NotifyVirtualSelectedParentChainChangedResponseMessage.error:type_name -> protowire.RPCError
-	49,  // 35: protowire.VirtualSelectedParentChainChangedNotificationMessage.acceptedTransactionIds:type_name -> protowire.AcceptedTransactionIds
-	2,   // 36: protowire.GetBlockResponseMessage.block:type_name -> protowire.RpcBlock
-	1,   // 37: protowire.GetBlockResponseMessage.error:type_name -> protowire.RPCError
-	1,   // 38: protowire.GetSubnetworkResponseMessage.error:type_name -> protowire.RPCError
-	49,  // 39: protowire.GetVirtualSelectedParentChainFromBlockResponseMessage.acceptedTransactionIds:type_name -> protowire.AcceptedTransactionIds
-	1,   // 40: protowire.GetVirtualSelectedParentChainFromBlockResponseMessage.error:type_name -> protowire.RPCError
-	2,   // 41: protowire.GetBlocksResponseMessage.blocks:type_name -> protowire.RpcBlock
-	1,   // 42: protowire.GetBlocksResponseMessage.error:type_name -> protowire.RPCError
-	1,   // 43: protowire.GetBlockCountResponseMessage.error:type_name -> protowire.RPCError
-	1,   // 44: protowire.GetBlockDagInfoResponseMessage.error:type_name -> protowire.RPCError
-	1,   // 45: protowire.ResolveFinalityConflictResponseMessage.error:type_name -> protowire.RPCError
-	1,   // 46: protowire.NotifyFinalityConflictsResponseMessage.error:type_name -> protowire.RPCError
-	1,   // 47: protowire.ShutDownResponseMessage.error:type_name -> protowire.RPCError
-	1,   // 48: protowire.GetHeadersResponseMessage.error:type_name -> protowire.RPCError
-	1,   // 49: protowire.NotifyUtxosChangedResponseMessage.error:type_name -> protowire.RPCError
-	70,  // 50: protowire.UtxosChangedNotificationMessage.added:type_name -> protowire.UtxosByAddressesEntry
-	70,  // 51: protowire.UtxosChangedNotificationMessage.removed:type_name -> protowire.UtxosByAddressesEntry
-	10,  // 52: protowire.UtxosByAddressesEntry.outpoint:type_name -> protowire.RpcOutpoint
-	11,  // 53: protowire.UtxosByAddressesEntry.utxoEntry:type_name -> protowire.RpcUtxoEntry
-	1,   // 54: protowire.StopNotifyingUtxosChangedResponseMessage.error:type_name -> protowire.RPCError
-	70,  // 55: protowire.GetUtxosByAddressesResponseMessage.entries:type_name -> protowire.UtxosByAddressesEntry
-	1,   // 56: protowire.GetUtxosByAddressesResponseMessage.error:type_name -> protowire.RPCError
-	1,   // 57: protowire.GetBalanceByAddressResponseMessage.error:type_name -> protowire.RPCError
-	1,   // 58: protowire.BalancesByAddressEntry.error:type_name -> protowire.RPCError
-	78,  // 59: protowire.GetBalancesByAddressesResponseMessage.entries:type_name -> protowire.BalancesByAddressEntry
-	1,   // 60: protowire.GetBalancesByAddressesResponseMessage.error:type_name -> protowire.RPCError
-	1,   // 61: protowire.GetVirtualSelectedParentBlueScoreResponseMessage.error:type_name -> protowire.RPCError
-	1,   // 62: protowire.NotifyVirtualSelectedParentBlueScoreChangedResponseMessage.error:type_name -> protowire.RPCError
-	1,   // 63: protowire.NotifyVirtualDaaScoreChangedResponseMessage.error:type_name -> protowire.RPCError
-	1,   // 64: protowire.NotifyPruningPointUTXOSetOverrideResponseMessage.error:type_name -> protowire.RPCError
-	1,   // 65: protowire.StopNotifyingPruningPointUTXOSetOverrideResponseMessage.error:type_name -> protowire.RPCError
-	1,   // 66: protowire.BanResponseMessage.error:type_name -> protowire.RPCError
-	1,   // 67: protowire.UnbanResponseMessage.error:type_name -> protowire.RPCError
-	1,   // 68: protowire.GetInfoResponseMessage.error:type_name -> protowire.RPCError
-	1,   // 69: protowire.EstimateNetworkHashesPerSecondResponseMessage.error:type_name -> protowire.RPCError
-	1,   // 70: protowire.NotifyNewBlockTemplateResponseMessage.error:type_name -> protowire.RPCError
-	33,  // 71: protowire.MempoolEntryByAddress.sending:type_name -> protowire.MempoolEntry
-	33,  // 72: protowire.MempoolEntryByAddress.receiving:type_name -> protowire.MempoolEntry
-	104, // 73: protowire.GetMempoolEntriesByAddressesResponseMessage.entries:type_name -> protowire.MempoolEntryByAddress
-	1,   // 74: protowire.GetMempoolEntriesByAddressesResponseMessage.error:type_name -> protowire.RPCError
-	1,   // 75: protowire.GetCoinSupplyResponseMessage.error:type_name -> protowire.RPCError
-	76,  // [76:76] is the sub-list for method output_type
-	76,  // [76:76] is the sub-list for method input_type
-	76,  // [76:76] is the sub-list for extension type_name
-	76,  // [76:76] is the sub-list for extension extendee
-	0,   // [0:76] is the sub-list for field type_name
+	20,  // 16: protowire.GetBlockTemplateRequestMessage.payouts:type_name -> protowire.RpcCoinbasePayout
+	2,   // 17: protowire.GetBlockTemplateResponseMessage.block:type_name -> protowire.RpcBlock
+	1,   // 18: protowire.GetBlockTemplateResponseMessage.error:type_name -> protowire.RPCError
+	1,   // 19: protowire.NotifyBlockAddedResponseMessage.error:type_name -> protowire.RPCError
+	2,   // 20: protowire.BlockAddedNotificationMessage.block:type_name -> protowire.RpcBlock
+	27,  // 21: protowire.GetPeerAddressesResponseMessage.addresses:type_name -> protowire.GetPeerAddressesKnownAddressMessage
+	27,  // 22: protowire.GetPeerAddressesResponseMessage.bannedAddresses:type_name -> protowire.GetPeerAddressesKnownAddressMessage
+	1,   // 23: protowire.GetPeerAddressesResponseMessage.error:type_name -> protowire.RPCError
+	1,   // 24: protowire.GetSelectedTipHashResponseMessage.error:type_name -> protowire.RPCError
+	34,  // 25: protowire.GetMempoolEntryResponseMessage.entry:type_name -> protowire.MempoolEntry
+	1,   // 26: protowire.GetMempoolEntryResponseMessage.error:type_name -> protowire.RPCError
+	34,  // 27: protowire.GetMempoolEntriesResponseMessage.entries:type_name -> protowire.MempoolEntry
+	1,   // 28: protowire.GetMempoolEntriesResponseMessage.error:type_name -> protowire.RPCError
+	6,   // 29: protowire.MempoolEntry.transaction:type_name -> protowire.RpcTransaction
+	37,  // 30: protowire.GetConnectedPeerInfoResponseMessage.infos:type_name -> protowire.GetConnectedPeerInfoMessage
+	1,   // 31: protowire.GetConnectedPeerInfoResponseMessage.error:type_name -> protowire.RPCError
+	1,   // 32: protowire.AddPeerResponseMessage.error:type_name -> protowire.RPCError
+	6,   // 33: protowire.SubmitTransactionRequestMessage.transaction:type_name -> protowire.RpcTransaction
+	1,   // 34: protowire.SubmitTransactionResponseMessage.error:type_name -> protowire.RPCError
+	1,   // 35: protowire.NotifyVirtualSelectedParentChainChangedResponseMessage.error:type_name -> protowire.RPCError
+	50,  // 36: protowire.VirtualSelectedParentChainChangedNotificationMessage.acceptedTransactionIds:type_name -> protowire.AcceptedTransactionIds
+	2,   // 37: protowire.GetBlockResponseMessage.block:type_name -> protowire.RpcBlock
+	1,   // 38: protowire.GetBlockResponseMessage.error:type_name -> protowire.RPCError
+	1,   // 39: protowire.GetSubnetworkResponseMessage.error:type_name -> protowire.RPCError
+	50,  // 40: protowire.GetVirtualSelectedParentChainFromBlockResponseMessage.acceptedTransactionIds:type_name -> protowire.AcceptedTransactionIds
+	1,   // 41: protowire.GetVirtualSelectedParentChainFromBlockResponseMessage.error:type_name -> protowire.RPCError
+	2,   // 42: protowire.GetBlocksResponseMessage.blocks:type_name -> protowire.RpcBlock
+	1,   // 43: protowire.GetBlocksResponseMessage.error:type_name -> protowire.RPCError
+	1,   // 44: protowire.GetBlockCountResponseMessage.error:type_name -> protowire.RPCError
+	1,   // 45: protowire.GetBlockDagInfoResponseMessage.error:type_name -> protowire.RPCError
+	1,   // 46: protowire.ResolveFinalityConflictResponseMessage.error:type_name -> protowire.RPCError
+	1,   // 47: protowire.NotifyFinalityConflictsResponseMessage.error:type_name -> protowire.RPCError
+	1,   // 48: protowire.ShutDownResponseMessage.error:type_name -> protowire.RPCError
+	1,   // 49: protowire.GetHeadersResponseMessage.error:type_name -> protowire.RPCError
+	1,   // 50: protowire.NotifyUtxosChangedResponseMessage.error:type_name -> protowire.RPCError
+	71,  // 51: protowire.UtxosChangedNotificationMessage.added:type_name -> protowire.UtxosByAddressesEntry
+	71,  // 52: protowire.UtxosChangedNotificationMessage.removed:type_name -> protowire.UtxosByAddressesEntry
+	10,  // 53: protowire.UtxosByAddressesEntry.outpoint:type_name -> protowire.RpcOutpoint
+	11,  // 54: protowire.UtxosByAddressesEntry.utxoEntry:type_name -> protowire.RpcUtxoEntry
+	1,   // 55: protowire.StopNotifyingUtxosChangedResponseMessage.error:type_name -> protowire.RPCError
+	71,  // 56: protowire.GetUtxosByAddressesResponseMessage.entries:type_name -> protowire.UtxosByAddressesEntry
+	1,   // 57: protowire.GetUtxosByAddressesResponseMessage.error:type_name -> protowire.RPCError
+	1,   // 58: protowire.GetBalanceByAddressResponseMessage.error:type_name -> protowire.RPCError
+	1,   // 59: protowire.BalancesByAddressEntry.error:type_name -> protowire.RPCError
+	79,  // 60: protowire.GetBalancesByAddressesResponseMessage.entries:type_name -> protowire.BalancesByAddressEntry
+	1,   // 61: protowire.GetBalancesByAddressesResponseMessage.error:type_name -> protowire.RPCError
+	1,   // 62: protowire.GetVirtualSelectedParentBlueScoreResponseMessage.error:type_name -> protowire.RPCError
+	1,   // 63: protowire.NotifyVirtualSelectedParentBlueScoreChangedResponseMessage.error:type_name -> protowire.RPCError
+	1,   // 64: protowire.NotifyVirtualDaaScoreChangedResponseMessage.error:type_name -> protowire.RPCError
+	1,   // 65: protowire.NotifyPruningPointUTXOSetOverrideResponseMessage.error:type_name -> protowire.RPCError
+	1,   // 66: protowire.StopNotifyingPruningPointUTXOSetOverrideResponseMessage.error:type_name -> protowire.RPCError
+	1,   // 67: protowire.BanResponseMessage.error:type_name -> protowire.RPCError
+	1,   // 68: protowire.UnbanResponseMessage.error:type_name -> protowire.RPCError
+	1,   // 69: protowire.GetInfoResponseMessage.error:type_name -> protowire.RPCError
+	1,   // 70: protowire.EstimateNetworkHashesPerSecondResponseMessage.error:type_name -> protowire.RPCError
+	1,   // 71: protowire.NotifyNewBlockTemplateResponseMessage.error:type_name -> protowire.RPCError
+	34,  // 72: protowire.MempoolEntryByAddress.sending:type_name -> protowire.MempoolEntry
+	34,  // 73: protowire.MempoolEntryByAddress.receiving:type_name -> protowire.MempoolEntry
+	105, // 74: protowire.GetMempoolEntriesByAddressesResponseMessage.entries:type_name -> protowire.MempoolEntryByAddress
+	1,   // 75: protowire.GetMempoolEntriesByAddressesResponseMessage.error:type_name -> protowire.RPCError
+	1,   // 76: protowire.GetCoinSupplyResponseMessage.error:type_name -> protowire.RPCError
+	111, // 77: protowire.GetCheckpointsResponseMessage.checkpoints:type_name -> protowire.RpcCheckpoint
+	1,   // 78: protowire.GetCheckpointsResponseMessage.error:type_name -> protowire.RPCError
+	114, // 79: protowire.GetDeploymentInfoResponseMessage.deployments:type_name -> protowire.RpcDeployment
+	1,   // 80: protowire.GetDeploymentInfoResponseMessage.error:type_name -> protowire.RPCError
+	1,   // 81: protowire.VerifyMessageResponseMessage.error:type_name -> protowire.RPCError
+	82,  // [82:82] is the sub-list for method output_type
+	82,  // [82:82] is the sub-list for method input_type
+	82,  // [82:82] is the sub-list for extension type_name
+	82,  // [82:82] is the sub-list for extension extendee
+	0,   // [0:82] is the sub-list for field type_name
 }
 
 func init() { file_rpc_proto_init() }
@@ -7364,6 +7984,18 @@ func file_rpc_proto_init() {
 			}
 		}
 		file_rpc_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RpcCoinbasePayout); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rpc_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetBlockTemplateResponseMessage); i {
 			case 0:
 				return &v.state
@@ -7375,7 +8007,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*NotifyBlockAddedRequestMessage); i {
 			case 0:
 				return &v.state
@@ -7387,7 +8019,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*NotifyBlockAddedResponseMessage); i {
 			case 0:
 				return &v.state
@@ -7399,7 +8031,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*BlockAddedNotificationMessage); i {
 			case 0:
 				return &v.state
@@ -7411,7 +8043,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[24].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetPeerAddressesRequestMessage); i {
 			case 0:
 				return &v.state
@@ -7423,7 +8055,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[24].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[25].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetPeerAddressesResponseMessage); i {
 			case 0:
 				return &v.state
@@ -7435,7 +8067,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[25].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[26].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetPeerAddressesKnownAddressMessage); i {
 			case 0:
 				return &v.state
@@ -7447,7 +8079,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[26].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[27].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetSelectedTipHashRequestMessage); i {
 			case 0:
 				return &v.state
@@ -7459,7 +8091,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[27].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[28].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetSelectedTipHashResponseMessage); i {
 			case 0:
 				return &v.state
@@ -7471,7 +8103,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[28].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[29].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetMempoolEntryRequestMessage); i {
 			case 0:
 				return &v.state
@@ -7483,7 +8115,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[29].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[30].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetMempoolEntryResponseMessage); i {
 			case 0:
 				return &v.state
@@ -7495,7 +8127,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[30].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[31].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetMempoolEntriesRequestMessage); i {
 			case 0:
 				return &v.state
@@ -7507,7 +8139,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[31].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[32].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetMempoolEntriesResponseMessage); i {
 			case 0:
 				return &v.state
@@ -7519,7 +8151,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[32].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[33].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*MempoolEntry); i {
 			case 0:
 				return &v.state
@@ -7531,7 +8163,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[33].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[34].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetConnectedPeerInfoRequestMessage); i {
 			case 0:
 				return &v.state
@@ -7543,7 +8175,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[34].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[35].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetConnectedPeerInfoResponseMessage); i {
 			case 0:
 				return &v.state
@@ -7555,7 +8187,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[35].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[36].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetConnectedPeerInfoMessage); i {
 			case 0:
 				return &v.state
@@ -7567,7 +8199,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[36].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[37].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*AddPeerRequestMessage); i {
 			case 0:
 				return &v.state
@@ -7579,7 +8211,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[37].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[38].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*AddPeerResponseMessage); i {
 			case 0:
 				return &v.state
@@ -7591,7 +8223,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[38].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[39].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*SubmitTransactionRequestMessage); i {
 			case 0:
 				return &v.state
@@ -7603,7 +8235,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[39].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[40].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*SubmitTransactionResponseMessage); i {
 			case 0:
 				return &v.state
@@ -7615,7 +8247,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[40].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[41].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*NotifyVirtualSelectedParentChainChangedRequestMessage); i {
 			case 0:
 				return &v.state
@@ -7627,7 +8259,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[41].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[42].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*NotifyVirtualSelectedParentChainChangedResponseMessage); i {
 			case 0:
 				return &v.state
@@ -7639,7 +8271,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[42].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[43].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*VirtualSelectedParentChainChangedNotificationMessage); i {
 			case 0:
 				return &v.state
@@ -7651,7 +8283,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[43].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[44].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetBlockRequestMessage); i {
 			case 0:
 				return &v.state
@@ -7663,7 +8295,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[44].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[45].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetBlockResponseMessage); i {
 			case 0:
 				return &v.state
@@ -7675,7 +8307,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[45].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[46].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetSubnetworkRequestMessage); i {
 			case 0:
 				return &v.state
@@ -7687,7 +8319,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[46].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[47].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetSubnetworkResponseMessage); i {
 			case 0:
 				return &v.state
@@ -7699,7 +8331,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[47].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[48].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetVirtualSelectedParentChainFromBlockRequestMessage); i {
 			case 0:
 				return &v.state
@@ -7711,7 +8343,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[48].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[49].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*AcceptedTransactionIds); i {
 			case 0:
 				return &v.state
@@ -7723,7 +8355,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[49].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[50].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetVirtualSelectedParentChainFromBlockResponseMessage); i {
 			case 0:
 				return &v.state
@@ -7735,7 +8367,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[50].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[51].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetBlocksRequestMessage); i {
 			case 0:
 				return &v.state
@@ -7747,7 +8379,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[51].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[52].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetBlocksResponseMessage); i {
 			case 0:
 				return &v.state
@@ -7759,7 +8391,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[52].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[53].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetBlockCountRequestMessage); i {
 			case 0:
 				return &v.state
@@ -7771,7 +8403,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[53].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[54].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetBlockCountResponseMessage); i {
 			case 0:
 				return &v.state
@@ -7783,7 +8415,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[54].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[55].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetBlockDagInfoRequestMessage); i {
 			case 0:
 				return &v.state
@@ -7795,7 +8427,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[55].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[56].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetBlockDagInfoResponseMessage); i {
 			case 0:
 				return &v.state
@@ -7807,7 +8439,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[56].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[57].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*ResolveFinalityConflictRequestMessage); i {
 			case 0:
 				return &v.state
@@ -7819,7 +8451,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[57].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[58].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*ResolveFinalityConflictResponseMessage); i {
 			case 0:
 				return &v.state
@@ -7831,7 +8463,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[58].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[59].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*NotifyFinalityConflictsRequestMessage); i {
 			case 0:
 				return &v.state
@@ -7843,7 +8475,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[59].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[60].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*NotifyFinalityConflictsResponseMessage); i {
 			case 0:
 				return &v.state
@@ -7855,7 +8487,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[60].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[61].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*FinalityConflictNotificationMessage); i {
 			case 0:
 				return &v.state
@@ -7867,7 +8499,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[61].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[62].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*FinalityConflictResolvedNotificationMessage); i {
 			case 0:
 				return &v.state
@@ -7879,7 +8511,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[62].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[63].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*ShutDownRequestMessage); i {
 			case 0:
 				return &v.state
@@ -7891,7 +8523,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[63].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[64].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*ShutDownResponseMessage); i {
 			case 0:
 				return &v.state
@@ -7903,7 +8535,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[64].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[65].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetHeadersRequestMessage); i {
 			case 0:
 				return &v.state
@@ -7915,7 +8547,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[65].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[66].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetHeadersResponseMessage); i {
 			case 0:
 				return &v.state
@@ -7927,7 +8559,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[66].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[67].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*NotifyUtxosChangedRequestMessage); i {
 			case 0:
 				return &v.state
@@ -7939,7 +8571,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[67].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[68].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*NotifyUtxosChangedResponseMessage); i {
 			case 0:
 				return &v.state
@@ -7951,7 +8583,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[68].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[69].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*UtxosChangedNotificationMessage); i {
 			case 0:
 				return &v.state
@@ -7963,7 +8595,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[69].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[70].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*UtxosByAddressesEntry); i {
 			case 0:
 				return &v.state
@@ -7975,7 +8607,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[70].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[71].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*StopNotifyingUtxosChangedRequestMessage); i {
 			case 0:
 				return &v.state
@@ -7987,7 +8619,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[71].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[72].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*StopNotifyingUtxosChangedResponseMessage); i {
 			case 0:
 				return &v.state
@@ -7999,7 +8631,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[72].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[73].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetUtxosByAddressesRequestMessage); i {
 			case 0:
 				return &v.state
@@ -8011,7 +8643,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[73].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[74].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetUtxosByAddressesResponseMessage); i {
 			case 0:
 				return &v.state
@@ -8023,7 +8655,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[74].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[75].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetBalanceByAddressRequestMessage); i {
 			case 0:
 				return &v.state
@@ -8035,7 +8667,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[75].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[76].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetBalanceByAddressResponseMessage); i {
 			case 0:
 				return &v.state
@@ -8047,7 +8679,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[76].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[77].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetBalancesByAddressesRequestMessage); i {
 			case 0:
 				return &v.state
@@ -8059,7 +8691,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[77].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[78].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*BalancesByAddressEntry); i {
 			case 0:
 				return &v.state
@@ -8071,7 +8703,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[78].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[79].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetBalancesByAddressesResponseMessage); i {
 			case 0:
 				return &v.state
@@ -8083,7 +8715,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[79].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[80].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetVirtualSelectedParentBlueScoreRequestMessage); i {
 			case 0:
 				return &v.state
@@ -8095,7 +8727,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[80].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[81].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetVirtualSelectedParentBlueScoreResponseMessage); i {
 			case 0:
 				return &v.state
@@ -8107,7 +8739,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[81].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[82].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*NotifyVirtualSelectedParentBlueScoreChangedRequestMessage); i {
 			case 0:
 				return &v.state
@@ -8119,7 +8751,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[82].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[83].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*NotifyVirtualSelectedParentBlueScoreChangedResponseMessage); i {
 			case 0:
 				return &v.state
@@ -8131,7 +8763,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[83].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[84].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*VirtualSelectedParentBlueScoreChangedNotificationMessage); i {
 			case 0:
 				return &v.state
@@ -8143,7 +8775,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[84].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[85].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*NotifyVirtualDaaScoreChangedRequestMessage); i {
 			case 0:
 				return &v.state
@@ -8155,7 +8787,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[85].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[86].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*NotifyVirtualDaaScoreChangedResponseMessage); i {
 			case 0:
 				return &v.state
@@ -8167,7 +8799,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[86].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[87].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*VirtualDaaScoreChangedNotificationMessage); i {
 			case 0:
 				return &v.state
@@ -8179,7 +8811,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[87].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[88].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*NotifyPruningPointUTXOSetOverrideRequestMessage); i {
 			case 0:
 				return &v.state
@@ -8191,7 +8823,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[88].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[89].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*NotifyPruningPointUTXOSetOverrideResponseMessage); i {
 			case 0:
 				return &v.state
@@ -8203,7 +8835,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[89].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[90].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*PruningPointUTXOSetOverrideNotificationMessage); i {
 			case 0:
 				return &v.state
@@ -8215,7 +8847,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[90].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[91].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*StopNotifyingPruningPointUTXOSetOverrideRequestMessage); i {
 			case 0:
 				return &v.state
@@ -8227,7 +8859,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[91].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[92].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*StopNotifyingPruningPointUTXOSetOverrideResponseMessage); i {
 			case 0:
 				return &v.state
@@ -8239,7 +8871,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[92].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[93].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*BanRequestMessage); i {
 			case 0:
 				return &v.state
@@ -8251,7 +8883,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[93].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[94].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*BanResponseMessage); i {
 			case 0:
 				return &v.state
@@ -8263,7 +8895,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[94].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[95].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*UnbanRequestMessage); i {
 			case 0:
 				return &v.state
@@ -8275,7 +8907,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[95].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[96].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*UnbanResponseMessage); i {
 			case 0:
 				return &v.state
@@ -8287,7 +8919,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[96].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[97].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetInfoRequestMessage); i {
 			case 0:
 				return &v.state
@@ -8299,7 +8931,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[97].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[98].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetInfoResponseMessage); i {
 			case 0:
 				return &v.state
@@ -8311,7 +8943,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[98].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[99].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*EstimateNetworkHashesPerSecondRequestMessage); i {
 			case 0:
 				return &v.state
@@ -8323,7 +8955,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[99].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[100].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*EstimateNetworkHashesPerSecondResponseMessage); i {
 			case 0:
 				return &v.state
@@ -8335,7 +8967,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[100].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[101].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*NotifyNewBlockTemplateRequestMessage); i {
 			case 0:
 				return &v.state
@@ -8347,7 +8979,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[101].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[102].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*NotifyNewBlockTemplateResponseMessage); i {
 			case 0:
 				return &v.state
@@ -8359,7 +8991,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[102].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[103].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*NewBlockTemplateNotificationMessage); i {
 			case 0:
 				return &v.state
@@ -8371,7 +9003,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[103].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[104].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*MempoolEntryByAddress); i {
 			case 0:
 				return &v.state
@@ -8383,7 +9015,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[104].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[105].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetMempoolEntriesByAddressesRequestMessage); i {
 			case 0:
 				return &v.state
@@ -8395,7 +9027,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[105].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[106].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetMempoolEntriesByAddressesResponseMessage); i {
 			case 0:
 				return &v.state
@@ -8407,7 +9039,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[106].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[107].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetCoinSupplyRequestMessage); i {
 			case 0:
 				return &v.state
@@ -8419,7 +9051,7 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
-		file_rpc_proto_msgTypes[107].Exporter = func(v interface{}, i int) interface{} {
+		file_rpc_proto_msgTypes[108].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetCoinSupplyResponseMessage); i {
 			case 0:
 				return &v.state
@@ -8431,6 +9063,102 @@ func file_rpc_proto_init() {
 				return nil
 			}
 		}
+		file_rpc_proto_msgTypes[109].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetCheckpointsRequestMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rpc_proto_msgTypes[110].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RpcCheckpoint); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rpc_proto_msgTypes[111].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetCheckpointsResponseMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rpc_proto_msgTypes[112].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetDeploymentInfoRequestMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rpc_proto_msgTypes[113].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RpcDeployment); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rpc_proto_msgTypes[114].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetDeploymentInfoResponseMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rpc_proto_msgTypes[115].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*VerifyMessageRequestMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rpc_proto_msgTypes[116].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*VerifyMessageResponseMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -8438,7 +9166,7 @@ func file_rpc_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_rpc_proto_rawDesc,
 			NumEnums:      1,
-			NumMessages:   108,
+			NumMessages:   117,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
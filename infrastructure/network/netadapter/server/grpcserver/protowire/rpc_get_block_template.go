@@ -13,9 +13,17 @@ func (x *C4exdMessage_GetBlockTemplateRequest) toAppMessage() (appmessage.Messag
 }
 
 func (x *C4exdMessage_GetBlockTemplateRequest) fromAppMessage(message *appmessage.GetBlockTemplateRequestMessage) error {
+	payouts := make([]*RpcCoinbasePayout, len(message.Payouts))
+	for i, payout := range message.Payouts {
+		payouts[i] = &RpcCoinbasePayout{
+			Address: payout.Address,
+			Weight:  payout.Weight,
+		}
+	}
 	x.GetBlockTemplateRequest = &GetBlockTemplateRequestMessage{
 		PayAddress: message.PayAddress,
 		ExtraData:  message.ExtraData,
+		Payouts:    payouts,
 	}
 	return nil
 }
@@ -24,9 +32,20 @@ func (x *GetBlockTemplateRequestMessage) toAppMessage() (appmessage.Message, err
 	if x == nil {
 		return nil, errors.Wrapf(errorNil, "GetBlockTemplateRequestMessage is nil")
 	}
+	var payouts []*appmessage.RPCCoinbasePayout
+	for _, payout := range x.Payouts {
+		if payout == nil {
+			return nil, errors.Wrapf(errorNil, "RpcCoinbasePayout is nil")
+		}
+		payouts = append(payouts, &appmessage.RPCCoinbasePayout{
+			Address: payout.Address,
+			Weight:  payout.Weight,
+		})
+	}
 	return &appmessage.GetBlockTemplateRequestMessage{
 		PayAddress: x.PayAddress,
 		ExtraData:  x.ExtraData,
+		Payouts:    payouts,
 	}, nil
 }
 
@@ -0,0 +1,67 @@
+package protowire
+
+import (
+	"github.com/c4ei/c4exd/app/appmessage"
+	"github.com/pkg/errors"
+)
+
+func (x *C4exdMessage_GetCheckpointsRequest) toAppMessage() (appmessage.Message, error) {
+	return &appmessage.GetCheckpointsRequestMessage{}, nil
+}
+
+func (x *C4exdMessage_GetCheckpointsRequest) fromAppMessage(_ *appmessage.GetCheckpointsRequestMessage) error {
+	x.GetCheckpointsRequest = &GetCheckpointsRequestMessage{}
+	return nil
+}
+
+func (x *C4exdMessage_GetCheckpointsResponse) toAppMessage() (appmessage.Message, error) {
+	if x == nil {
+		return nil, errors.Wrapf(errorNil, "C4exdMessage_GetCheckpointsResponse is nil")
+	}
+	return x.GetCheckpointsResponse.toAppMessage()
+}
+
+func (x *C4exdMessage_GetCheckpointsResponse) fromAppMessage(message *appmessage.GetCheckpointsResponseMessage) error {
+	var err *RPCError
+	if message.Error != nil {
+		err = &RPCError{Message: message.Error.Message}
+	}
+	checkpoints := make([]*RpcCheckpoint, len(message.Checkpoints))
+	for i, checkpoint := range message.Checkpoints {
+		checkpoints[i] = &RpcCheckpoint{
+			Hash:     checkpoint.Hash,
+			DaaScore: checkpoint.DAAScore,
+		}
+	}
+	x.GetCheckpointsResponse = &GetCheckpointsResponseMessage{
+		Checkpoints: checkpoints,
+
+		Error: err,
+	}
+	return nil
+}
+
+func (x *GetCheckpointsResponseMessage) toAppMessage() (appmessage.Message, error) {
+	if x == nil {
+		return nil, errors.Wrapf(errorNil, "GetCheckpointsResponseMessage is nil")
+	}
+	rpcErr, err := x.Error.toAppMessage()
+	// Error is an optional field
+	if err != nil && !errors.Is(err, errorNil) {
+		return nil, err
+	}
+
+	checkpoints := make([]*appmessage.RPCCheckpoint, len(x.Checkpoints))
+	for i, checkpoint := range x.Checkpoints {
+		checkpoints[i] = &appmessage.RPCCheckpoint{
+			Hash:     checkpoint.Hash,
+			DAAScore: checkpoint.DaaScore,
+		}
+	}
+
+	return &appmessage.GetCheckpointsResponseMessage{
+		Checkpoints: checkpoints,
+
+		Error: rpcErr,
+	}, nil
+}
@@ -0,0 +1,77 @@
+package protowire
+
+import (
+	"github.com/c4ei/c4exd/app/appmessage"
+	"github.com/pkg/errors"
+)
+
+func (x *C4exdMessage_GetDeploymentInfoRequest) toAppMessage() (appmessage.Message, error) {
+	return &appmessage.GetDeploymentInfoRequestMessage{}, nil
+}
+
+func (x *C4exdMessage_GetDeploymentInfoRequest) fromAppMessage(_ *appmessage.GetDeploymentInfoRequestMessage) error {
+	x.GetDeploymentInfoRequest = &GetDeploymentInfoRequestMessage{}
+	return nil
+}
+
+func (x *C4exdMessage_GetDeploymentInfoResponse) toAppMessage() (appmessage.Message, error) {
+	if x == nil {
+		return nil, errors.Wrapf(errorNil, "C4exdMessage_GetDeploymentInfoResponse is nil")
+	}
+	return x.GetDeploymentInfoResponse.toAppMessage()
+}
+
+func (x *C4exdMessage_GetDeploymentInfoResponse) fromAppMessage(message *appmessage.GetDeploymentInfoResponseMessage) error {
+	var err *RPCError
+	if message.Error != nil {
+		err = &RPCError{Message: message.Error.Message}
+	}
+	deployments := make([]*RpcDeployment, len(message.Deployments))
+	for i, deployment := range message.Deployments {
+		deployments[i] = &RpcDeployment{
+			Name:            deployment.Name,
+			BitNumber:       deployment.BitNumber,
+			StartDaaScore:   deployment.StartDAAScore,
+			TimeoutDaaScore: deployment.TimeoutDAAScore,
+			State:           deployment.State,
+		}
+	}
+	x.GetDeploymentInfoResponse = &GetDeploymentInfoResponseMessage{
+		Deployments:                   deployments,
+		MinerConfirmationWindow:       message.MinerConfirmationWindow,
+		RuleChangeActivationThreshold: message.RuleChangeActivationThreshold,
+
+		Error: err,
+	}
+	return nil
+}
+
+func (x *GetDeploymentInfoResponseMessage) toAppMessage() (appmessage.Message, error) {
+	if x == nil {
+		return nil, errors.Wrapf(errorNil, "GetDeploymentInfoResponseMessage is nil")
+	}
+	rpcErr, err := x.Error.toAppMessage()
+	// Error is an optional field
+	if err != nil && !errors.Is(err, errorNil) {
+		return nil, err
+	}
+
+	deployments := make([]*appmessage.RPCDeployment, len(x.Deployments))
+	for i, deployment := range x.Deployments {
+		deployments[i] = &appmessage.RPCDeployment{
+			Name:            deployment.Name,
+			BitNumber:       deployment.BitNumber,
+			StartDAAScore:   deployment.StartDaaScore,
+			TimeoutDAAScore: deployment.TimeoutDaaScore,
+			State:           deployment.State,
+		}
+	}
+
+	return &appmessage.GetDeploymentInfoResponseMessage{
+		Deployments:                   deployments,
+		MinerConfirmationWindow:       x.MinerConfirmationWindow,
+		RuleChangeActivationThreshold: x.RuleChangeActivationThreshold,
+
+		Error: rpcErr,
+	}, nil
+}
@@ -0,0 +1,68 @@
+package protowire
+
+import (
+	"github.com/c4ei/c4exd/app/appmessage"
+	"github.com/pkg/errors"
+)
+
+func (x *C4exdMessage_VerifyMessageRequest) toAppMessage() (appmessage.Message, error) {
+	if x == nil {
+		return nil, errors.Wrapf(errorNil, "C4exdMessage_VerifyMessageRequest is nil")
+	}
+	return x.VerifyMessageRequest.toAppMessage()
+}
+
+func (x *C4exdMessage_VerifyMessageRequest) fromAppMessage(message *appmessage.VerifyMessageRequestMessage) error {
+	x.VerifyMessageRequest = &VerifyMessageRequestMessage{
+		Address:   message.Address,
+		Message:   message.Message,
+		Signature: message.Signature,
+	}
+	return nil
+}
+
+func (x *VerifyMessageRequestMessage) toAppMessage() (appmessage.Message, error) {
+	if x == nil {
+		return nil, errors.Wrapf(errorNil, "VerifyMessageRequestMessage is nil")
+	}
+	return &appmessage.VerifyMessageRequestMessage{
+		Address:   x.Address,
+		Message:   x.Message,
+		Signature: x.Signature,
+	}, nil
+}
+
+func (x *C4exdMessage_VerifyMessageResponse) toAppMessage() (appmessage.Message, error) {
+	if x == nil {
+		return nil, errors.Wrapf(errorNil, "C4exdMessage_VerifyMessageResponse is nil")
+	}
+	return x.VerifyMessageResponse.toAppMessage()
+}
+
+func (x *C4exdMessage_VerifyMessageResponse) fromAppMessage(message *appmessage.VerifyMessageResponseMessage) error {
+	var err *RPCError
+	if message.Error != nil {
+		err = &RPCError{Message: message.Error.Message}
+	}
+	x.VerifyMessageResponse = &VerifyMessageResponseMessage{
+		IsValid: message.IsValid,
+		Error:   err,
+	}
+	return nil
+}
+
+func (x *VerifyMessageResponseMessage) toAppMessage() (appmessage.Message, error) {
+	if x == nil {
+		return nil, errors.Wrapf(errorNil, "VerifyMessageResponseMessage is nil")
+	}
+	rpcErr, err := x.Error.toAppMessage()
+	// Error is an optional field
+	if err != nil && !errors.Is(err, errorNil) {
+		return nil, err
+	}
+
+	return &appmessage.VerifyMessageResponseMessage{
+		IsValid: x.IsValid,
+		Error:   rpcErr,
+	}, nil
+}
@@ -968,6 +968,48 @@ func toRPCPayload(message appmessage.Message) (isC4exdMessage_Payload, error) {
 			return nil, err
 		}
 		return payload, nil
+	case *appmessage.GetCheckpointsRequestMessage:
+		payload := new(C4exdMessage_GetCheckpointsRequest)
+		err := payload.fromAppMessage(message)
+		if err != nil {
+			return nil, err
+		}
+		return payload, nil
+	case *appmessage.GetCheckpointsResponseMessage:
+		payload := new(C4exdMessage_GetCheckpointsResponse)
+		err := payload.fromAppMessage(message)
+		if err != nil {
+			return nil, err
+		}
+		return payload, nil
+	case *appmessage.GetDeploymentInfoRequestMessage:
+		payload := new(C4exdMessage_GetDeploymentInfoRequest)
+		err := payload.fromAppMessage(message)
+		if err != nil {
+			return nil, err
+		}
+		return payload, nil
+	case *appmessage.GetDeploymentInfoResponseMessage:
+		payload := new(C4exdMessage_GetDeploymentInfoResponse)
+		err := payload.fromAppMessage(message)
+		if err != nil {
+			return nil, err
+		}
+		return payload, nil
+	case *appmessage.VerifyMessageRequestMessage:
+		payload := new(C4exdMessage_VerifyMessageRequest)
+		err := payload.fromAppMessage(message)
+		if err != nil {
+			return nil, err
+		}
+		return payload, nil
+	case *appmessage.VerifyMessageResponseMessage:
+		payload := new(C4exdMessage_VerifyMessageResponse)
+		err := payload.fromAppMessage(message)
+		if err != nil {
+			return nil, err
+		}
+		return payload, nil
 	default:
 		return nil, nil
 	}
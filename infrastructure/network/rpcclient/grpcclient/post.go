@@ -62,3 +62,24 @@ func (c *GRPCClient) Post(request *protowire.C4exdMessage) (*protowire.C4exdMess
 	}
 	return response, nil
 }
+
+// Send sends the given message to the RPC server without waiting
+// for a response. Callers that use it are expected to read every
+// message that arrives back, responses and notifications alike,
+// using Receive
+func (c *GRPCClient) Send(message *protowire.C4exdMessage) error {
+	err := c.stream.Send(message)
+	if err != nil {
+		return errors.Wrapf(err, "error sending the message to the RPC server")
+	}
+	return nil
+}
+
+// Receive waits for the next message from the RPC server and returns it
+func (c *GRPCClient) Receive() (*protowire.C4exdMessage, error) {
+	message, err := c.stream.Recv()
+	if err != nil {
+		return nil, errors.Wrapf(err, "error receiving a message from the RPC server")
+	}
+	return message, nil
+}
@@ -4,7 +4,15 @@ import "github.com/c4ei/c4exd/app/appmessage"
 
 // GetBlockTemplate sends an RPC request respective to the function's name and returns the RPC server's response
 func (c *RPCClient) GetBlockTemplate(miningAddress, extraData string) (*appmessage.GetBlockTemplateResponseMessage, error) {
-	err := c.rpcRouter.outgoingRoute().Enqueue(appmessage.NewGetBlockTemplateRequestMessage(miningAddress, extraData))
+	return c.GetBlockTemplateWithPayouts(miningAddress, extraData, nil)
+}
+
+// GetBlockTemplateWithPayouts sends a GetBlockTemplate RPC request whose coinbase splits the block rewards
+// between miningAddress and the given payouts, and returns the RPC server's response
+func (c *RPCClient) GetBlockTemplateWithPayouts(miningAddress, extraData string,
+	payouts []*appmessage.RPCCoinbasePayout) (*appmessage.GetBlockTemplateResponseMessage, error) {
+
+	err := c.rpcRouter.outgoingRoute().Enqueue(appmessage.NewGetBlockTemplateRequestMessage(miningAddress, extraData, payouts))
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,20 @@
+package rpcclient
+
+import "github.com/c4ei/c4exd/app/appmessage"
+
+// GetCheckpoints sends an RPC request respective to the function's name and returns the RPC server's response
+func (c *RPCClient) GetCheckpoints() (*appmessage.GetCheckpointsResponseMessage, error) {
+	err := c.rpcRouter.outgoingRoute().Enqueue(appmessage.NewGetCheckpointsRequestMessage())
+	if err != nil {
+		return nil, err
+	}
+	response, err := c.route(appmessage.CmdGetCheckpointsResponseMessage).DequeueWithTimeout(c.timeout)
+	if err != nil {
+		return nil, err
+	}
+	getCheckpointsResponse := response.(*appmessage.GetCheckpointsResponseMessage)
+	if getCheckpointsResponse.Error != nil {
+		return nil, c.convertRPCError(getCheckpointsResponse.Error)
+	}
+	return getCheckpointsResponse, nil
+}
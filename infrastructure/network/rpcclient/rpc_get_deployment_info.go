@@ -0,0 +1,20 @@
+package rpcclient
+
+import "github.com/c4ei/c4exd/app/appmessage"
+
+// GetDeploymentInfo sends an RPC request respective to the function's name and returns the RPC server's response
+func (c *RPCClient) GetDeploymentInfo() (*appmessage.GetDeploymentInfoResponseMessage, error) {
+	err := c.rpcRouter.outgoingRoute().Enqueue(appmessage.NewGetDeploymentInfoRequestMessage())
+	if err != nil {
+		return nil, err
+	}
+	response, err := c.route(appmessage.CmdGetDeploymentInfoResponseMessage).DequeueWithTimeout(c.timeout)
+	if err != nil {
+		return nil, err
+	}
+	getDeploymentInfoResponse := response.(*appmessage.GetDeploymentInfoResponseMessage)
+	if getDeploymentInfoResponse.Error != nil {
+		return nil, c.convertRPCError(getDeploymentInfoResponse.Error)
+	}
+	return getDeploymentInfoResponse, nil
+}
@@ -26,7 +26,8 @@ type RPCClient struct {
 	isReconnecting       uint32
 	lastDisconnectedTime time.Time
 
-	timeout time.Duration
+	timeout              time.Duration
+	onReconnectedHandler func()
 }
 
 // NewRPCClient сreates a new RPC client with a default call timeout value
@@ -118,6 +119,9 @@ func (c *RPCClient) Reconnect() error {
 		if time.Since(c.lastDisconnectedTime) > retryDelay {
 			err := c.connect()
 			if err == nil {
+				if c.onReconnectedHandler != nil {
+					c.onReconnectedHandler()
+				}
 				return nil
 			}
 			log.Warnf("Could not automatically reconnect to %s: %s", c.rpcAddress, err)
@@ -150,6 +154,13 @@ func (c *RPCClient) handleClientError(err error) {
 	c.handleClientDisconnected()
 }
 
+// SetOnReconnectedHandler sets a handler that is called every time the client
+// reconnects. Notification registrations don't survive reconnections, so this
+// is where they should be renewed.
+func (c *RPCClient) SetOnReconnectedHandler(onReconnectedHandler func()) {
+	c.onReconnectedHandler = onReconnectedHandler
+}
+
 // SetTimeout sets the timeout by which to wait for RPC responses
 func (c *RPCClient) SetTimeout(timeout time.Duration) {
 	c.timeout = timeout
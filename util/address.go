@@ -5,6 +5,8 @@
 package util
 
 import (
+	"strings"
+
 	"github.com/pkg/errors"
 	"golang.org/x/crypto/blake2b"
 
@@ -60,6 +62,38 @@ var stringsToBech32Prefixes = map[string]Bech32Prefix{
 	"c4exsim":  Bech32PrefixC4exSim,
 }
 
+// RegisterBech32Prefix registers a Bech32 address prefix for a custom network
+// and returns its constant. Registering an already known prefix returns the
+// existing constant.
+func RegisterBech32Prefix(prefixString string) (Bech32Prefix, error) {
+	if prefix, ok := stringsToBech32Prefixes[prefixString]; ok {
+		return prefix, nil
+	}
+	err := ValidateBech32Prefix(prefixString)
+	if err != nil {
+		return Bech32PrefixUnknown, err
+	}
+
+	prefix := Bech32Prefix(len(stringsToBech32Prefixes) + 1)
+	stringsToBech32Prefixes[prefixString] = prefix
+	return prefix, nil
+}
+
+// ValidateBech32Prefix checks that the given string can be the prefix of Bech32
+// addresses: it must be non-empty and lowercase, and consist of printable ASCII
+// characters other than the ':' that separates it from the rest of the address.
+func ValidateBech32Prefix(prefixString string) error {
+	if prefixString == "" || strings.ToLower(prefixString) != prefixString {
+		return errors.Errorf("invalid prefix %q: must be non-empty and lowercase", prefixString)
+	}
+	for i := 0; i < len(prefixString); i++ {
+		if prefixString[i] < 33 || prefixString[i] > 126 || prefixString[i] == ':' {
+			return errors.Errorf("invalid prefix %q: character %q is not allowed", prefixString, prefixString[i])
+		}
+	}
+	return nil
+}
+
 // ParsePrefix attempts to parse a Bech32 address prefix.
 func ParsePrefix(prefixString string) (Bech32Prefix, error) {
 	prefix, ok := stringsToBech32Prefixes[prefixString]
@@ -7,6 +7,7 @@ package util
 import (
 	"math"
 	"strconv"
+	"strings"
 
 	"github.com/c4ei/c4exd/domain/consensus/utils/constants"
 	"github.com/pkg/errors"
@@ -116,6 +117,55 @@ func (a Amount) String() string {
 	return a.Format(AmountC4X)
 }
 
+// C4XString formats a monetary amount counted in c4ex base units as an
+// amount of C4X, without a unit and with only the decimal places it needs
+// (e.g. 1.5). Unlike Format, the conversion is exact.
+func (a Amount) C4XString() string {
+	whole := uint64(a) / constants.SompiPerC4ex
+	fraction := uint64(a) % constants.SompiPerC4ex
+	if fraction == 0 {
+		return strconv.FormatUint(whole, 10)
+	}
+	fractionString := strings.TrimRight(strconv.FormatUint(fraction+constants.SompiPerC4ex, 10)[1:], "0")
+	return strconv.FormatUint(whole, 10) + "." + fractionString
+}
+
+// ParseC4X parses an amount of C4X with up to 8 decimal places, such as
+// 1234.12345678, into an Amount. Unlike NewAmount, the conversion is exact.
+// Signs, exponents and amounts that overflow an Amount are rejected.
+func ParseC4X(amount string) (Amount, error) {
+	wholeString, fractionString, hasFraction := strings.Cut(amount, ".")
+	if wholeString == "" || (hasFraction && fractionString == "") || len(fractionString) > 8 {
+		return 0, errors.Errorf("invalid amount %s: expected an amount of C4X with up to 8 decimal places", amount)
+	}
+	for _, digits := range []string{wholeString, fractionString} {
+		for _, char := range digits {
+			if char < '0' || char > '9' {
+				return 0, errors.Errorf("invalid amount %s: expected an amount of C4X with up to 8 decimal places",
+					amount)
+			}
+		}
+	}
+
+	whole, err := strconv.ParseUint(wholeString, 10, 64)
+	if err != nil || whole > math.MaxUint64/constants.SompiPerC4ex {
+		return 0, errors.Errorf("amount %s is too large", amount)
+	}
+	var fraction uint64
+	if hasFraction {
+		fraction, err = strconv.ParseUint(fractionString+strings.Repeat("0", 8-len(fractionString)), 10, 64)
+		if err != nil {
+			return 0, errors.WithStack(err)
+		}
+	}
+
+	sompi := whole*constants.SompiPerC4ex + fraction
+	if sompi < fraction {
+		return 0, errors.Errorf("amount %s is too large", amount)
+	}
+	return Amount(sompi), nil
+}
+
 // MulF64 multiplies an Amount by a floating point value. While this is not
 // an operation that must typically be done by a full node or wallet, it is
 // useful for services that build on top of c4ex (for example, calculating
@@ -255,3 +255,68 @@ func TestAmountMulF64(t *testing.T) {
 		}
 	}
 }
+
+func TestParseC4X(t *testing.T) {
+	tests := []struct {
+		amount   string
+		expected Amount
+		valid    bool
+	}{
+		{amount: "0", expected: 0, valid: true},
+		{amount: "1", expected: 1e8, valid: true},
+		{amount: "1.5", expected: 150_000_000, valid: true},
+		{amount: "0.29", expected: 29_000_000, valid: true},
+		{amount: "0.00000001", expected: 1, valid: true},
+		{amount: "184467440737.09551615", expected: math.MaxUint64, valid: true},
+		{amount: "184467440737.09551616"},
+		{amount: "1.123456789"},
+		{amount: ".5"},
+		{amount: "5."},
+		{amount: "-1"},
+		{amount: "1e3"},
+		{amount: " 1"},
+		{amount: ""},
+	}
+
+	for _, test := range tests {
+		amount, err := ParseC4X(test.amount)
+		if !test.valid {
+			if err == nil {
+				t.Errorf("ParseC4X(%q): expected an error, got %d", test.amount, amount)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseC4X(%q): %s", test.amount, err)
+			continue
+		}
+		if amount != test.expected {
+			t.Errorf("ParseC4X(%q): expected %d, got %d", test.amount, test.expected, amount)
+		}
+	}
+}
+
+func TestAmountC4XString(t *testing.T) {
+	tests := []struct {
+		amount   Amount
+		expected string
+	}{
+		{amount: 0, expected: "0"},
+		{amount: 1e8, expected: "1"},
+		{amount: 150_000_000, expected: "1.5"},
+		{amount: 29_000_000, expected: "0.29"},
+		{amount: 1, expected: "0.00000001"},
+		{amount: math.MaxUint64, expected: "184467440737.09551615"},
+	}
+
+	for _, test := range tests {
+		c4xString := test.amount.C4XString()
+		if c4xString != test.expected {
+			t.Errorf("C4XString of %d: expected %s, got %s", uint64(test.amount), test.expected, c4xString)
+		}
+		parsed, err := ParseC4X(c4xString)
+		if err != nil || parsed != test.amount {
+			t.Errorf("ParseC4X(%s): expected %d, got %d (%v)", c4xString, uint64(test.amount), parsed, err)
+		}
+	}
+}
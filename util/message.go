@@ -0,0 +1,46 @@
+package util
+
+import (
+	"github.com/c4ei/c4exd/domain/consensus/model/externalapi"
+	"github.com/c4ei/c4exd/domain/consensus/utils/hashes"
+	"github.com/kaspanet/go-secp256k1"
+	"github.com/pkg/errors"
+)
+
+// MessageHash returns the hash that is signed when signing a message. It is domain separated from the
+// hashes that are signed in transactions, so that a signed message can never be used to spend funds
+func MessageHash(message string) *externalapi.DomainHash {
+	hashWriter := hashes.NewPersonalMessageSigningHashWriter()
+	hashWriter.InfallibleWrite([]byte(message))
+	return hashWriter.Finalize()
+}
+
+// VerifyMessage returns whether the signature is a signature of the message by the key of the address.
+// Only pay-to-pubkey addresses, of either Schnorr or ECDSA keys, may sign messages
+func VerifyMessage(address Address, message string, signature []byte) (bool, error) {
+	secpHash := secp256k1.Hash(*MessageHash(message).ByteArray())
+	switch address.(type) {
+	case *AddressPublicKey:
+		publicKey, err := secp256k1.DeserializeSchnorrPubKey(address.ScriptAddress())
+		if err != nil {
+			return false, errors.Wrap(err, "Failed to deserialize public key")
+		}
+		schnorrSignature, err := secp256k1.DeserializeSchnorrSignatureFromSlice(signature)
+		if err != nil {
+			return false, errors.Wrap(err, "Failed to deserialize signature")
+		}
+		return publicKey.SchnorrVerify(&secpHash, schnorrSignature), nil
+	case *AddressPublicKeyECDSA:
+		publicKey, err := secp256k1.DeserializeECDSAPubKey(address.ScriptAddress())
+		if err != nil {
+			return false, errors.Wrap(err, "Failed to deserialize public key")
+		}
+		ecdsaSignature, err := secp256k1.DeserializeECDSASignatureFromSlice(signature)
+		if err != nil {
+			return false, errors.Wrap(err, "Failed to deserialize signature")
+		}
+		return publicKey.ECDSAVerify(&secpHash, ecdsaSignature), nil
+	default:
+		return false, errors.Errorf("address %s can't sign messages: only pay-to-pubkey addresses can", address)
+	}
+}
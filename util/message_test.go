@@ -0,0 +1,84 @@
+package util_test
+
+import (
+	"testing"
+
+	"github.com/c4ei/c4exd/util"
+	"github.com/kaspanet/go-secp256k1"
+)
+
+func TestVerifyMessage(t *testing.T) {
+	const message = "I control this address"
+	messageHash := secp256k1.Hash(*util.MessageHash(message).ByteArray())
+
+	schnorrKeyPair, err := secp256k1.GenerateSchnorrKeyPair()
+	if err != nil {
+		t.Fatalf("GenerateSchnorrKeyPair: %s", err)
+	}
+	schnorrPublicKey, err := schnorrKeyPair.SchnorrPublicKey()
+	if err != nil {
+		t.Fatalf("SchnorrPublicKey: %s", err)
+	}
+	serializedSchnorrPublicKey, err := schnorrPublicKey.Serialize()
+	if err != nil {
+		t.Fatalf("Serialize: %s", err)
+	}
+	schnorrAddress, err := util.NewAddressPublicKey(serializedSchnorrPublicKey[:], util.Bech32PrefixC4exSim)
+	if err != nil {
+		t.Fatalf("NewAddressPublicKey: %s", err)
+	}
+	schnorrSignature, err := schnorrKeyPair.SchnorrSign(&messageHash)
+	if err != nil {
+		t.Fatalf("SchnorrSign: %s", err)
+	}
+
+	ecdsaPrivateKey, err := secp256k1.GenerateECDSAPrivateKey()
+	if err != nil {
+		t.Fatalf("GenerateECDSAPrivateKey: %s", err)
+	}
+	ecdsaPublicKey, err := ecdsaPrivateKey.ECDSAPublicKey()
+	if err != nil {
+		t.Fatalf("ECDSAPublicKey: %s", err)
+	}
+	serializedECDSAPublicKey, err := ecdsaPublicKey.Serialize()
+	if err != nil {
+		t.Fatalf("Serialize: %s", err)
+	}
+	ecdsaAddress, err := util.NewAddressPublicKeyECDSA(serializedECDSAPublicKey[:], util.Bech32PrefixC4exSim)
+	if err != nil {
+		t.Fatalf("NewAddressPublicKeyECDSA: %s", err)
+	}
+	ecdsaSignature, err := ecdsaPrivateKey.ECDSASign(&messageHash)
+	if err != nil {
+		t.Fatalf("ECDSASign: %s", err)
+	}
+
+	serializedSchnorrSignature := schnorrSignature.Serialize()
+	serializedECDSASignature := ecdsaSignature.Serialize()
+	tests := []struct {
+		name      string
+		address   util.Address
+		message   string
+		signature []byte
+		expected  bool
+	}{
+		{name: "schnorr", address: schnorrAddress, message: message, signature: serializedSchnorrSignature[:], expected: true},
+		{name: "ecdsa", address: ecdsaAddress, message: message, signature: serializedECDSASignature[:], expected: true},
+		{name: "other message", address: schnorrAddress, message: message + ".", signature: serializedSchnorrSignature[:]},
+		{name: "other key type", address: ecdsaAddress, message: message, signature: serializedSchnorrSignature[:]},
+	}
+	for _, test := range tests {
+		isValid, err := util.VerifyMessage(test.address, test.message, test.signature)
+		if err != nil {
+			t.Fatalf("%s: VerifyMessage: %s", test.name, err)
+		}
+		if isValid != test.expected {
+			t.Fatalf("%s: expected the signature validity to be %t", test.name, test.expected)
+		}
+	}
+
+	_, err = util.VerifyMessage(schnorrAddress, message, serializedSchnorrSignature[1:])
+	if err == nil {
+		t.Fatalf("Expected an error for a malformed signature")
+	}
+}
@@ -0,0 +1,125 @@
+package util
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/c4ei/c4exd/domain/consensus/utils/constants"
+	"github.com/pkg/errors"
+)
+
+// Payment URI parameters
+const (
+	paymentURIAmountParameter  = "amount"
+	paymentURILabelParameter   = "label"
+	paymentURIMessageParameter = "message"
+	paymentURIExpiryParameter  = "expiry"
+
+	// paymentURIRequiredParameterPrefix prefixes the parameters that a payer must understand in order
+	// to pay. Parameters without it that the payer doesn't understand are ignored
+	paymentURIRequiredParameterPrefix = "req-"
+)
+
+// PaymentURI is a request for a payment to an address. It's encoded as the address followed
+// by its optional parameters, such as:
+//
+//	c4ex:qr...?amount=1.5&label=Shop&message=Order%2042&expiry=1700000000
+//
+// The scheme of the URI is the prefix of the address, so a URI can only be paid on the network
+// of the address. The amount is in C4X, and the expiry is a UNIX timestamp in seconds.
+type PaymentURI struct {
+	Address Address
+	// Amount is zero if the payer chooses the amount
+	Amount  Amount
+	Label   string
+	Message string
+	// Expiry is zero if the request never expires
+	Expiry time.Time
+}
+
+// String returns the encoding of the payment URI
+func (p *PaymentURI) String() string {
+	var parameters []string
+	if p.Amount != 0 {
+		parameters = append(parameters, paymentURIAmountParameter+"="+p.Amount.C4XString())
+	}
+	if p.Label != "" {
+		parameters = append(parameters, paymentURILabelParameter+"="+escapePaymentURIValue(p.Label))
+	}
+	if p.Message != "" {
+		parameters = append(parameters, paymentURIMessageParameter+"="+escapePaymentURIValue(p.Message))
+	}
+	if !p.Expiry.IsZero() {
+		parameters = append(parameters, paymentURIExpiryParameter+"="+strconv.FormatInt(p.Expiry.Unix(), 10))
+	}
+
+	if len(parameters) == 0 {
+		return p.Address.String()
+	}
+	return p.Address.String() + "?" + strings.Join(parameters, "&")
+}
+
+// IsExpired returns whether the payment request expired at the given time
+func (p *PaymentURI) IsExpired(now time.Time) bool {
+	return !p.Expiry.IsZero() && !now.Before(p.Expiry)
+}
+
+// DecodePaymentURI decodes a payment URI, whose address must be of the expected prefix
+func DecodePaymentURI(uri string, expectedPrefix Bech32Prefix) (*PaymentURI, error) {
+	addressString, query, _ := strings.Cut(uri, "?")
+	address, err := DecodeAddress(addressString, expectedPrefix)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid address in payment URI")
+	}
+
+	paymentURI := &PaymentURI{Address: address}
+	if query == "" {
+		return paymentURI, nil
+	}
+	parameters, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid parameters in payment URI")
+	}
+	for name, values := range parameters {
+		if len(values) > 1 {
+			return nil, errors.Errorf("the parameter %s appears more than once in the payment URI", name)
+		}
+		value := values[0]
+
+		switch name {
+		case paymentURIAmountParameter:
+			paymentURI.Amount, err = ParseC4X(value)
+			if err != nil {
+				return nil, errors.Wrap(err, "invalid payment URI")
+			}
+			if paymentURI.Amount == 0 || uint64(paymentURI.Amount) > constants.MaxSompi {
+				return nil, errors.Errorf("invalid amount %s in payment URI: expected a positive amount of "+
+					"at most %d C4X", value, constants.MaxSompi/constants.SompiPerC4ex)
+			}
+		case paymentURILabelParameter:
+			paymentURI.Label = value
+		case paymentURIMessageParameter:
+			paymentURI.Message = value
+		case paymentURIExpiryParameter:
+			expiry, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || expiry <= 0 {
+				return nil, errors.Errorf("invalid expiry %s in payment URI: expected a UNIX timestamp in seconds", value)
+			}
+			paymentURI.Expiry = time.Unix(expiry, 0)
+		default:
+			if strings.HasPrefix(name, paymentURIRequiredParameterPrefix) {
+				return nil, errors.Errorf("the payment URI requires the unsupported parameter %s", name)
+			}
+		}
+	}
+
+	return paymentURI, nil
+}
+
+// escapePaymentURIValue escapes a parameter value with %20 for spaces rather than +, since
+// not all URI parsers decode + to a space
+func escapePaymentURIValue(value string) string {
+	return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+}
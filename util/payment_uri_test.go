@@ -0,0 +1,102 @@
+package util_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/c4ei/c4exd/util"
+)
+
+func TestPaymentURI(t *testing.T) {
+	const addressString = "c4exsim:qqer478r2lg4ukhfgqrw0rzemh2ttraq4993pj4k74w4hn7kpucuu6lvqah48"
+	address, err := util.DecodeAddress(addressString, util.Bech32PrefixC4exSim)
+	if err != nil {
+		t.Fatalf("DecodeAddress: %s", err)
+	}
+
+	tests := []struct {
+		paymentURI  *util.PaymentURI
+		expectedURI string
+	}{
+		{
+			paymentURI:  &util.PaymentURI{Address: address},
+			expectedURI: addressString,
+		},
+		{
+			paymentURI:  &util.PaymentURI{Address: address, Amount: 150_000_000},
+			expectedURI: addressString + "?amount=1.5",
+		},
+		{
+			paymentURI: &util.PaymentURI{
+				Address: address,
+				Amount:  1,
+				Label:   "Coffee & Co",
+				Message: "Order #42+1",
+				Expiry:  time.Unix(1700000000, 0),
+			},
+			expectedURI: addressString + "?amount=0.00000001&label=Coffee%20%26%20Co&message=Order%20%2342%2B1&expiry=1700000000",
+		},
+	}
+	for _, test := range tests {
+		uri := test.paymentURI.String()
+		if uri != test.expectedURI {
+			t.Errorf("Expected URI %s, got %s", test.expectedURI, uri)
+			continue
+		}
+
+		decoded, err := util.DecodePaymentURI(uri, util.Bech32PrefixC4exSim)
+		if err != nil {
+			t.Errorf("DecodePaymentURI(%s): %s", uri, err)
+			continue
+		}
+		if decoded.Address.String() != test.paymentURI.Address.String() || decoded.Amount != test.paymentURI.Amount ||
+			decoded.Label != test.paymentURI.Label || decoded.Message != test.paymentURI.Message ||
+			!decoded.Expiry.Equal(test.paymentURI.Expiry) {
+			t.Errorf("Decoding %s: expected %+v, got %+v", uri, test.paymentURI, decoded)
+		}
+	}
+
+	// Unknown parameters are ignored unless they're required
+	decoded, err := util.DecodePaymentURI(addressString+"?amount=2&foo=bar&label=a+b", util.Bech32PrefixC4exSim)
+	if err != nil {
+		t.Fatalf("DecodePaymentURI: %s", err)
+	}
+	if decoded.Amount != 200_000_000 || decoded.Label != "a b" {
+		t.Fatalf("Unexpected decoded payment URI %+v", decoded)
+	}
+
+	invalidURIs := []string{
+		addressString + "?req-foo=bar",
+		addressString + "?amount=1&amount=2",
+		addressString + "?amount=0",
+		addressString + "?amount=1.123456789",
+		addressString + "?amount=-1",
+		addressString + "?amount=1e3",
+		addressString + "?amount=.5",
+		addressString + "?amount=5.",
+		addressString + "?amount=29000000001",
+		addressString + "?expiry=soon",
+		"c4ex:qqer478r2lg4ukhfgqrw0rzemh2ttraq4993pj4k74w4hn7kpucuu6lvqah48?amount=1",
+		addressString + "?amount=1%",
+	}
+	for _, uri := range invalidURIs {
+		_, err := util.DecodePaymentURI(uri, util.Bech32PrefixC4exSim)
+		if err == nil {
+			t.Errorf("Expected an error for %s", uri)
+		}
+	}
+}
+
+func TestPaymentURIIsExpired(t *testing.T) {
+	expiry := time.Unix(1700000000, 0)
+	paymentURI := &util.PaymentURI{Expiry: expiry}
+	if paymentURI.IsExpired(expiry.Add(-time.Second)) {
+		t.Fatalf("Expected the payment URI not to be expired before its expiry")
+	}
+	if !paymentURI.IsExpired(expiry) {
+		t.Fatalf("Expected the payment URI to be expired at its expiry")
+	}
+	if (&util.PaymentURI{}).IsExpired(expiry) {
+		t.Fatalf("Expected a payment URI without an expiry never to expire")
+	}
+}